@@ -1,32 +1,213 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"microservices-travel-backend/internal/booking-service/adapters/clients"
+	"microservices-travel-backend/internal/booking-service/adapters/documents"
 	"microservices-travel-backend/internal/booking-service/adapters/handlers"
+	"microservices-travel-backend/internal/booking-service/adapters/messaging"
 	"microservices-travel-backend/internal/booking-service/adapters/repositories"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
 	"microservices-travel-backend/internal/booking-service/infrastructure"
 	"microservices-travel-backend/internal/booking-service/services"
+	"microservices-travel-backend/pkg/exchangerates"
+	"microservices-travel-backend/pkg/middlewares"
+	"microservices-travel-backend/pkg/scheduler"
+	"microservices-travel-backend/pkg/storage"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	config.LoadConfig("dev")
-	repo := repositories.NewDynamoDBRepository()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := middleware.CheckJWTSecret(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	repo, err := newBookingRepository(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create repository: %v", err)
+	}
+
+	rates := exchangerates.NewStaticExchangeRates()
 
-	service := services.BookingService(repo)
+	promotionService := services.NewPromotionService(repo, rates)
+
+	service := services.NewBookingService(repo, promotionService)
+
+	tripService := services.NewTripService(repo, repo, repo, rates)
+
+	flights := clients.NewFlightClient(cfg.Services.FlightURL)
+	hotels := clients.NewHotelClient(cfg.Services.HotelURL)
+	payments := clients.NewPaymentClient(cfg.Services.PaymentURL)
+
+	packageService := services.NewSagaOrchestrator(repo, repo, flights, hotels, payments,
+		clients.NewLoyaltyClient(cfg.Services.UserURL),
+		rates, promotionService)
+
+	// Pick up package bookings a previous run left half done, on one replica at a time so that no
+	// step is taken twice.
+	leaderLock, err := newLeaderLock(repo)
+	if err != nil {
+		log.Fatalf("Failed to create scheduler lock: %v", err)
+	}
+	recovery := scheduler.New("booking-service-recovery", leaderLock, cfg.Saga.RecoveryInterval)
+	recovery.Add(scheduler.Job{Name: "resume-package-bookings", Schedule: scheduler.Every(cfg.Saga.RecoveryInterval),
+		Run: func(time.Time) error { return packageService.ResumeSagas() }})
+	go recovery.Run()
+
+	modificationService := services.NewModificationService(repo, repo, repo, flights, hotels, payments, rates,
+		services.ModificationPolicy{
+			HotelChangeFee: models.Money{Amount: cfg.Modifications.HotelChangeFee, Currency: cfg.Modifications.HotelChangeFeeCurrency},
+			QuoteTTL:       cfg.Modifications.QuoteTTL,
+		})
+
+	// Release expired quotes and finish modifications a previous run left half done.
+	go modificationService.SweepPeriodically(cfg.Modifications.SweepInterval)
+
+	legalEntities, err := config.LoadLegalEntities(cfg.Invoicing.LegalEntitiesFile)
+	if err != nil {
+		log.Fatalf("Failed to load legal entities: %v", err)
+	}
+	documentStorage, err := storage.NewS3Client()
+	if err != nil {
+		log.Fatalf("Failed to create document storage: %v", err)
+	}
+	invoiceService := services.NewInvoiceService(repo, repo, repo, repo, rates,
+		documents.NewPDFInvoiceRenderer(), documentStorage, legalEntities)
+
+	bus, err := newMessageBus(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to message bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Publish the events recorded with every change of a booking, email travellers about them and
+	// credit the invoices of cancelled bookings.
+	notifier := services.NewBookingNotifier(clients.NewNotificationClient(cfg.Services.UserURL))
+	relayBus := messaging.NewFanoutBus(bus, notifier, services.NewInvoiceCanceller(invoiceService))
+	go services.NewOutboxRelay(repo, relayBus, cfg.Outbox.BatchSize).RelayPeriodically(cfg.Outbox.RelayInterval)
 
 	bookingHandler := handlers.NewBookingHandler(service)
 
+	tripHandler := handlers.NewTripHandler(tripService)
+
+	packageHandler := handlers.NewPackageHandler(packageService)
+
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	modificationHandler := handlers.NewModificationHandler(modificationService)
+
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+
+	idempotencyStore, err := newIdempotencyStore(repo)
+	if err != nil {
+		log.Fatalf("Failed to create idempotency store: %v", err)
+	}
+
 	router := mux.NewRouter()
 
+	// Retried POST, PUT, PATCH and DELETE requests with the same Idempotency-Key get the first response.
+	router.Use(middleware.IdempotencyMiddleware(idempotencyStore, cfg.Idempotency.KeyTTL))
+
 	bookingHandler.RegisterRoutes(router)
 
-	port := ":6000"
-	log.Printf("Starting booking service port %s...", port)
-	err := http.ListenAndServe(port, router)
+	tripHandler.RegisterRoutes(router)
+
+	packageHandler.RegisterRoutes(router)
+
+	promotionHandler.RegisterRoutes(router)
+
+	modificationHandler.RegisterRoutes(router)
+
+	invoiceHandler.RegisterRoutes(router)
+
+	port := fmt.Sprintf(":%d", cfg.Service.Port)
+	log.Printf("Starting booking service port %s with %s storage...", port, cfg.Storage.Driver)
+	err = http.ListenAndServe(port, router)
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// bookingRepository is implemented by both storage backends.
+type bookingRepository interface {
+	ports.BookingDB
+	ports.TripDB
+	ports.SagaDB
+	ports.OutboxDB
+	ports.PromotionDB
+	ports.ModificationDB
+	ports.InvoiceDB
+}
+
+// newBookingRepository connects to the storage backend selected by BOOKING_STORAGE.
+func newBookingRepository(cfg *config.Config) (bookingRepository, error) {
+	if cfg.Storage.Driver == config.StorageDynamoDB {
+		repo, err := repositories.NewDynamoDBRepository(repositories.DynamoDBOptions{
+			Table:              cfg.Storage.DynamoDB.Table,
+			TripsTable:         cfg.Storage.DynamoDB.TripsTable,
+			SagasTable:         cfg.Storage.DynamoDB.SagasTable,
+			OutboxTable:        cfg.Storage.DynamoDB.OutboxTable,
+			HistoryTable:       cfg.Storage.DynamoDB.HistoryTable,
+			PromotionsTable:    cfg.Storage.DynamoDB.PromotionsTable,
+			RedemptionsTable:   cfg.Storage.DynamoDB.RedemptionsTable,
+			ModificationsTable: cfg.Storage.DynamoDB.ModificationsTable,
+			InvoicesTable:      cfg.Storage.DynamoDB.InvoicesTable,
+			IdempotencyTable:   cfg.Storage.DynamoDB.IdempotencyTable,
+			LocksTable:         cfg.Storage.DynamoDB.LocksTable,
+			Region:             cfg.AWS.Region,
+			Endpoint:           cfg.Storage.DynamoDB.Endpoint,
+			AccessKeyID:        cfg.AWS.AccessKeyID,
+			SecretAccessKey:    cfg.AWS.SecretAccessKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// DynamoDB Local starts empty; real tables are provisioned with the deployment.
+		if cfg.Storage.DynamoDB.Endpoint != "" {
+			if err := repo.CreateTables(); err != nil {
+				return nil, err
+			}
+		}
+		return repo, nil
+	}
+	return repositories.NewPostgresBookingRepository(cfg.PostgresDSN())
+}
+
+// newIdempotencyStore keeps Idempotency-Key records next to the bookings.
+func newIdempotencyStore(repo bookingRepository) (middleware.IdempotencyStore, error) {
+	switch repo := repo.(type) {
+	case *repositories.DynamoDBBookingRepository:
+		return repo.IdempotencyStore(), nil
+	case *repositories.PostgresBookingRepository:
+		return middleware.NewGormIdempotencyStore(repo.DB, "booking-service")
+	}
+	return nil, fmt.Errorf("no idempotency store for %T", repo)
+}
+
+// newLeaderLock keeps the lease of the replica running the recovery jobs next to the bookings.
+func newLeaderLock(repo bookingRepository) (scheduler.LeaderLock, error) {
+	switch repo := repo.(type) {
+	case *repositories.DynamoDBBookingRepository:
+		return repo.LeaderLock(), nil
+	case *repositories.PostgresBookingRepository:
+		return scheduler.NewGormLeaderLock(repo.DB)
+	}
+	return nil, fmt.Errorf("no leader lock for %T", repo)
+}
+
+// newMessageBus connects to the message bus selected by MESSAGE_BUS.
+func newMessageBus(cfg *config.Config) (ports.MessageBus, error) {
+	if cfg.MessageBus.Driver == config.MessageBusNATS {
+		return messaging.NewNATSBus(cfg.MessageBus.NATSURL, cfg.MessageBus.SubjectPrefix)
+	}
+	return messaging.NewMemoryBus(), nil
+}
@@ -2,31 +2,97 @@ package main
 
 import (
 	"log"
+	"microservices-travel-backend/internal/flight-booking/adapters/clients"
+	"microservices-travel-backend/internal/flight-booking/adapters/flight_provider"
+	"microservices-travel-backend/internal/flight-booking/adapters/flight_status"
 	"microservices-travel-backend/internal/flight-booking/adapters/handlers"
+	"microservices-travel-backend/internal/flight-booking/adapters/notifications"
 	"microservices-travel-backend/internal/flight-booking/adapters/repositories"
+	"microservices-travel-backend/internal/flight-booking/adapters/seat_inventory"
+	"microservices-travel-backend/internal/flight-booking/domain/mapper"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
 	"microservices-travel-backend/internal/flight-booking/services"
+	"microservices-travel-backend/pkg/destinations"
+	"microservices-travel-backend/pkg/exchangerates"
+	"microservices-travel-backend/pkg/middlewares"
+	"microservices-travel-backend/pkg/storage"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	if err := middleware.CheckJWTSecret(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	repo, err := repositories.NewPostgresRepository()
 
 	if err != nil {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
-	service := services.NewFlightService(repo)
+	gdsProvider := flight_provider.NewMockGDSAdapter("mock-gds-key", "MockGDS")
+
+	providers := []ports.FlightProvider{gdsProvider}
+
+	flightMapper := mapper.NewFlightMapper()
+
+	destinationCatalog, err := destinations.NewCatalog()
+	if err != nil {
+		log.Fatalf("Failed to load destination catalog: %v", err)
+	}
+
+	seatInventory := seat_inventory.NewPostgresSeatInventory(repo.DB)
+
+	documentStorage, err := storage.NewS3Client()
+	if err != nil {
+		log.Fatalf("Failed to create document storage: %v", err)
+	}
+
+	notifier := notifications.NewLogNotifier()
+
+	// Flown segments earn points in the user service when it is configured.
+	var loyalty ports.LoyaltyProgram
+	if userServiceURL := os.Getenv("USER_SERVICE_URL"); userServiceURL != "" {
+		loyalty = clients.NewLoyaltyClient(userServiceURL)
+	}
+
+	// Tickets are only issued for bookings the payment service reports as paid.
+	paymentServiceURL := os.Getenv("PAYMENT_SERVICE_URL")
+	if paymentServiceURL == "" {
+		paymentServiceURL = "http://localhost:6200"
+	}
+
+	service := services.NewFlightService(repo, repo, providers, flightMapper, seatInventory, repo, repo, documentStorage, repo, notifier, loyalty,
+		exchangerates.NewStaticExchangeRates(), clients.NewPaymentClient(paymentServiceURL))
+
+	// Airline status updates arrive on POST /flights/status-events, or from a feed file when one is configured.
+	if feedPath := os.Getenv("FLIGHT_STATUS_FEED_FILE"); feedPath != "" {
+		statusFeed := flight_status.NewFileStatusFeed(feedPath, service, 30*time.Second)
+		go statusFeed.Start(make(chan struct{}))
+	}
 
 	flightHandler := handlers.NewFlightHandler(service)
 
+	idempotencyStore, err := middleware.NewGormIdempotencyStore(repo.DB, "flight-booking-service")
+	if err != nil {
+		log.Fatalf("Failed to create idempotency store: %v", err)
+	}
+
 	router := mux.NewRouter()
 
+	// Retried booking requests with the same Idempotency-Key get the first response instead of booking twice.
+	router.Use(middleware.IdempotencyMiddleware(idempotencyStore, middleware.IdempotencyWindowFromEnv()))
+
 	flightHandler.RegisterRoutes(router)
 
+	destinations.NewHandler(destinationCatalog).RegisterRoutes(router)
+
 	port := ":6100"
-	log.Printf("Starting hotel-booking service on port %s...", port)
+	log.Printf("Starting flight booking service on port %s...", port)
 	err = http.ListenAndServe(port, router)
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
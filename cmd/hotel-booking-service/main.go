@@ -2,19 +2,28 @@ package main
 
 import (
 	"log"
+	"microservices-travel-backend/internal/hotel-booking/adapters/clients"
 	"microservices-travel-backend/internal/hotel-booking/adapters/handlers"
 	"microservices-travel-backend/internal/hotel-booking/adapters/hotel_provider"
 	"microservices-travel-backend/internal/hotel-booking/adapters/repositories"
 	"microservices-travel-backend/internal/hotel-booking/domain/mapper"
 	"microservices-travel-backend/internal/hotel-booking/domain/ports"
 	"microservices-travel-backend/internal/hotel-booking/services"
+	"microservices-travel-backend/pkg/destinations"
+	"microservices-travel-backend/pkg/middlewares"
+	"microservices-travel-backend/pkg/scheduler"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	if err := middleware.CheckJWTSecret(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	repo, err := repositories.NewPostgresRepository()
 
 	if err != nil {
@@ -27,14 +36,41 @@ func main() {
 
 	hotelMapper := mapper.NewHotelMapper()
 
+	destinationCatalog, err := destinations.NewCatalog()
+	if err != nil {
+		log.Fatalf("Failed to load destination catalog: %v", err)
+	}
+
 	service := services.NewHotelService(repo, providers, hotelMapper)
 
 	hotelHandler := handlers.NewHotelHandler(service)
+	bookingHandler := handlers.NewBookingHandler(services.NewBookingService(repo))
+
+	// Move bookings on with time and remind guests of their stays, on one replica at a time.
+	leaderLock, err := scheduler.NewGormLeaderLock(repo.DB)
+	if err != nil {
+		log.Fatalf("Failed to create scheduler lock: %v", err)
+	}
+	policy := services.DefaultLifecyclePolicy()
+	policy.PaymentWindow = durationFromEnv("BOOKING_PAYMENT_WINDOW", policy.PaymentWindow)
+	policy.ReviewDelay = durationFromEnv("BOOKING_REVIEW_DELAY", policy.ReviewDelay)
+	policy.ReminderLead = durationFromEnv("TRIP_REMINDER_LEAD", policy.ReminderLead)
+	lifecycle := services.NewBookingLifecycle(repo, clients.NewNotificationClient(os.Getenv("USER_SERVICE_URL")), policy)
+	lifecycleSchedule := cronFromEnv("BOOKING_LIFECYCLE_SCHEDULE", "*/5 * * * *")
+	jobs := scheduler.New("hotel-booking-lifecycle", leaderLock, 30*time.Second)
+	jobs.Add(scheduler.Job{Name: "expire-unpaid-bookings", Schedule: lifecycleSchedule, Run: lifecycle.ExpireUnpaid})
+	jobs.Add(scheduler.Job{Name: "mark-no-shows", Schedule: lifecycleSchedule, Run: lifecycle.MarkNoShows})
+	jobs.Add(scheduler.Job{Name: "request-reviews", Schedule: lifecycleSchedule, Run: lifecycle.RequestReviews})
+	jobs.Add(scheduler.Job{Name: "send-trip-reminders", Schedule: cronFromEnv("TRIP_REMINDER_SCHEDULE", "0 8 * * *"), Run: lifecycle.SendReminders})
+	go jobs.Run()
 
 	router := mux.NewRouter()
 
+	bookingHandler.RegisterRoutes(router)
 	hotelHandler.RegisterRoutes(router)
 
+	destinations.NewHandler(destinationCatalog).RegisterRoutes(router)
+
 	port := ":5100"
 	baseURL := os.Getenv("HOTEL_API_BASE_URL")
 	log.Printf("Starting Hotel Booking Service on port %s with base URL: %s", port, baseURL)
@@ -44,3 +80,35 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// durationFromEnv reads a duration such as "24h" from the environment, falling back to fallback
+// when it is unset or invalid.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid %s %q, using %s\n", name, value, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// cronFromEnv reads a cron expression from the environment, falling back to fallback when it is
+// unset or invalid.
+func cronFromEnv(name string, fallback string) scheduler.Schedule {
+	if value := os.Getenv(name); value != "" {
+		schedule, err := scheduler.ParseCron(value)
+		if err == nil {
+			return schedule
+		}
+		log.Printf("Invalid %s: %v, using %q\n", name, err, fallback)
+	}
+	schedule, err := scheduler.ParseCron(fallback)
+	if err != nil {
+		log.Fatalf("Invalid default schedule of %s: %v", name, err)
+	}
+	return schedule
+}
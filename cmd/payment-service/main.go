@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/payment-service/adapters/clients"
+	"microservices-travel-backend/internal/payment-service/adapters/gateways"
+	"microservices-travel-backend/internal/payment-service/adapters/handlers"
+	"microservices-travel-backend/internal/payment-service/adapters/repositories"
+	"microservices-travel-backend/internal/payment-service/infrastructure"
+	"microservices-travel-backend/internal/payment-service/services"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := middleware.CheckJWTSecret(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	repo, err := repositories.NewPostgresPaymentRepository(cfg.PostgresDSN())
+	if err != nil {
+		log.Fatalf("Failed to create repository: %v", err)
+	}
+
+	gateway := gateways.NewMockGateway(gateways.MockGatewayOptions{
+		WebhookURL:       cfg.Service.PublicURL + "/webhooks/gateway",
+		WebhookSecret:    cfg.Gateway.WebhookSecret,
+		ChallengeBaseURL: cfg.Service.PublicURL,
+		Latency:          cfg.Gateway.Mock.Latency,
+		SettleDelay:      cfg.Gateway.Mock.SettleDelay,
+	})
+
+	service := services.NewPaymentService(repo, repo, gateway, clients.NewBookingClient(cfg.Services.BookingURL), cfg.Refunds.ApprovalThreshold)
+
+	// Refunds whose webhook got lost are settled with what the gateway tells about them.
+	go service.SyncRefundsPeriodically(cfg.Refunds.SyncInterval)
+
+	paymentHandler := handlers.NewPaymentHandler(service)
+
+	refundHandler := handlers.NewRefundHandler(service)
+
+	mockGatewayHandler := handlers.NewMockGatewayHandler(gateway)
+
+	idempotencyStore, err := middleware.NewGormIdempotencyStore(repo.DB, "payment-service")
+	if err != nil {
+		log.Fatalf("Failed to create idempotency store: %v", err)
+	}
+
+	router := mux.NewRouter()
+
+	// Retried POST requests with the same Idempotency-Key get the first response instead of paying twice.
+	router.Use(middleware.IdempotencyMiddleware(idempotencyStore, cfg.Idempotency.KeyTTL))
+
+	paymentHandler.RegisterRoutes(router)
+
+	refundHandler.RegisterRoutes(router)
+
+	mockGatewayHandler.RegisterRoutes(router)
+
+	port := fmt.Sprintf(":%d", cfg.Service.Port)
+	log.Printf("Starting payment service on port %s with the %s gateway...", port, cfg.Gateway.Driver)
+	if err := http.ListenAndServe(port, router); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
@@ -3,14 +3,24 @@ package main
 import (
 	"log"
 	"microservices-travel-backend/internal/user-service/adapters/handlers"
+	"microservices-travel-backend/internal/user-service/adapters/notifiers"
 	"microservices-travel-backend/internal/user-service/adapters/repositories"
+	"microservices-travel-backend/internal/user-service/adapters/templates"
 	"microservices-travel-backend/internal/user-service/services"
+	"microservices-travel-backend/pkg/exchangerates"
+	"microservices-travel-backend/pkg/middlewares"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	if err := middleware.CheckJWTSecret(); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
 	userRepo, err := repositories.NewPostgreSQLUserRepository()
 
@@ -18,11 +28,53 @@ func main() {
 		log.Fatalf("Failed to create repository: %v", err)
 	}
 
-	userService := service.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService)
+	idempotencyStore, err := middleware.NewGormIdempotencyStore(userRepo.DB(), "user-service")
+	if err != nil {
+		log.Fatalf("Failed to create idempotency store: %v", err)
+	}
+
+	emailTemplates, err := templates.NewEmailTemplates()
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+	notifier, err := notifiers.NewSMTPNotifier(notifiers.SMTPOptions{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     intFromEnv("SMTP_PORT", 1025),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("NOTIFICATION_FROM"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create notifier: %v", err)
+	}
+	notificationRepo, err := repositories.NewPostgreSQLNotificationRepository(userRepo.DB())
+	if err != nil {
+		log.Fatalf("Failed to create notification repository: %v", err)
+	}
+	notificationPolicy := services.DefaultNotificationPolicy()
+	notificationPolicy.MaxAttempts = intFromEnv("NOTIFICATION_MAX_ATTEMPTS", notificationPolicy.MaxAttempts)
+	notificationPolicy.RetryBackoff = durationFromEnv("NOTIFICATION_RETRY_BACKOFF", notificationPolicy.RetryBackoff)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, notifier, emailTemplates, notificationPolicy)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	go notificationService.DeliverPeriodically(durationFromEnv("NOTIFICATION_DELIVERY_INTERVAL", 10*time.Second))
+
+	userService := services.NewUserService(userRepo, notificationService)
+	userHandler := handlers.NewUserHandler(userService, middleware.IdempotencyMiddleware(idempotencyStore, middleware.IdempotencyWindowFromEnv()))
+
+	loyaltyRepo, err := repositories.NewPostgreSQLLoyaltyRepository(userRepo.DB())
+	if err != nil {
+		log.Fatalf("Failed to create loyalty repository: %v", err)
+	}
+	policy := services.DefaultLoyaltyPolicy()
+	policy.Validity = durationFromEnv("LOYALTY_POINTS_VALIDITY", policy.Validity)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo, exchangerates.NewStaticExchangeRates(), policy)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+	go loyaltyService.ExpirePointsPeriodically(durationFromEnv("LOYALTY_EXPIRY_INTERVAL", time.Hour))
 
 	router := mux.NewRouter()
 	userHandler.RegisterRoutes(router)
+	loyaltyHandler.RegisterRoutes(router)
+	notificationHandler.RegisterRoutes(router)
 
 	port := ":7100"
 	log.Printf("Starting user service on port %s...", port)
@@ -31,3 +83,33 @@ func main() {
 	}
 
 }
+
+// durationFromEnv reads a duration such as "12960h" from the environment, falling back to
+// fallback when it is unset or invalid.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid %s %q, using %s\n", name, value, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// intFromEnv reads a positive number from the environment, falling back to fallback when it is
+// unset or invalid.
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Printf("Invalid %s %q, using %d\n", name, value, fallback)
+		return fallback
+	}
+	return number
+}
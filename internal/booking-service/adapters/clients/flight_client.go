@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"net/http"
+	"net/url"
+)
+
+// FlightClient books, changes and cancels itineraries through the flight booking service.
+type FlightClient struct {
+	serviceClient
+}
+
+func NewFlightClient(baseURL string) *FlightClient {
+	return &FlightClient{serviceClient: newServiceClient(baseURL)}
+}
+
+type itineraryBookingRequest struct {
+	ItineraryID string `json:"itinerary_id"`
+	UserID      string `json:"user_id"`
+	Passengers  struct {
+		Adults   int `json:"adults"`
+		Children int `json:"children"`
+		Infants  int `json:"infants"`
+	} `json:"passengers"`
+}
+
+type flightBooking struct {
+	ID         string  `json:"id"`
+	TotalPrice float64 `json:"total_price"`
+	Currency   string  `json:"currency"`
+	Itinerary  struct {
+		Legs []struct {
+			Destination string `json:"destination"`
+		} `json:"legs"`
+	} `json:"itinerary"`
+}
+
+func (c *FlightClient) ReserveFlight(key string, userID string, request models.FlightReservationRequest) (*models.Reservation, error) {
+	body := itineraryBookingRequest{ItineraryID: request.ItineraryID, UserID: userID}
+	body.Passengers.Adults = request.Adults
+	body.Passengers.Children = request.Children
+	body.Passengers.Infants = request.Infants
+
+	var booking flightBooking
+	if err := c.do(http.MethodPost, "/flights/bookings", key, body, &booking); err != nil {
+		return nil, err
+	}
+	reservation := &models.Reservation{ID: booking.ID, Price: models.Money{Amount: booking.TotalPrice, Currency: booking.Currency}}
+	if legs := booking.Itinerary.Legs; len(legs) > 0 {
+		// The first leg goes to the destination; a return leg comes back.
+		reservation.Destination = legs[0].Destination
+	}
+	return reservation, nil
+}
+
+// ReleaseFlight cancels a flight booking; one that no longer exists counts as released.
+func (c *FlightClient) ReleaseFlight(key string, flightBookingID string) error {
+	err := c.do(http.MethodDelete, "/flights/bookings/"+url.PathEscape(flightBookingID), key, nil, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+type itineraryChangeRequest struct {
+	ItineraryID string `json:"itinerary_id"`
+}
+
+type feeQuote struct {
+	Fee            float64 `json:"fee"`
+	FareDifference float64 `json:"fare_difference"`
+	AmountDue      float64 `json:"amount_due"`
+	Refund         float64 `json:"refund"`
+	Currency       string  `json:"currency"`
+}
+
+// QuoteFlightChange prices moving a flight booking onto another itinerary. The flight service
+// reports the fare difference and the refund apart; both end up in the price difference, and
+// whatever else is due is a change fee.
+func (c *FlightClient) QuoteFlightChange(flightBookingID string, itineraryID string) (*models.ChangeCharges, error) {
+	path := "/flights/bookings/" + url.PathEscape(flightBookingID) + "/itinerary?itinerary_id=" + url.QueryEscape(itineraryID)
+	var quote feeQuote
+	if err := c.do(http.MethodGet, path, "", nil, &quote); err != nil {
+		return nil, err
+	}
+	return &models.ChangeCharges{
+		PriceDifference: quote.FareDifference - quote.Refund,
+		ChangeFees:      quote.AmountDue - quote.FareDifference,
+		Currency:        quote.Currency,
+	}, nil
+}
+
+func (c *FlightClient) ChangeFlight(key string, flightBookingID string, itineraryID string) (*models.Money, error) {
+	var change struct {
+		Booking flightBooking `json:"booking"`
+	}
+	path := "/flights/bookings/" + url.PathEscape(flightBookingID) + "/itinerary"
+	if err := c.do(http.MethodPut, path, key, itineraryChangeRequest{ItineraryID: itineraryID}, &change); err != nil {
+		return nil, err
+	}
+	return &models.Money{Amount: change.Booking.TotalPrice, Currency: change.Booking.Currency}, nil
+}
@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HotelClient reserves, confirms and cancels rooms through the booking API of the hotel booking service.
+type HotelClient struct {
+	serviceClient
+}
+
+func NewHotelClient(baseURL string) *HotelClient {
+	return &HotelClient{serviceClient: newServiceClient(baseURL)}
+}
+
+type hotelBookingRequest struct {
+	UserID          string    `json:"user_id"`
+	HotelID         string    `json:"hotel_id"`
+	RoomID          *string   `json:"room_id,omitempty"`
+	StartDate       time.Time `json:"start_date"`
+	EndDate         time.Time `json:"end_date"`
+	GuestCount      int       `json:"guest_count"`
+	SpecialRequests string    `json:"special_requests,omitempty"`
+}
+
+type hotelBooking struct {
+	ID         string  `json:"id"`
+	TotalPrice float64 `json:"total_price"`
+	Currency   string  `json:"currency"`
+}
+
+func (c *HotelClient) ReserveHotel(key string, userID string, request models.HotelReservationRequest) (*models.Reservation, error) {
+	body := hotelBookingRequest{
+		UserID:          userID,
+		HotelID:         request.HotelID,
+		StartDate:       request.CheckIn,
+		EndDate:         request.CheckOut,
+		GuestCount:      request.Guests,
+		SpecialRequests: request.SpecialRequests,
+	}
+	if request.RoomID != "" {
+		body.RoomID = &request.RoomID
+	}
+
+	var booking hotelBooking
+	if err := c.do(http.MethodPost, "/hotels/bookings", key, body, &booking); err != nil {
+		return nil, err
+	}
+	return &models.Reservation{ID: booking.ID, Price: models.Money{Amount: booking.TotalPrice, Currency: booking.Currency}}, nil
+}
+
+func (c *HotelClient) ConfirmHotel(key string, hotelBookingID string) error {
+	body := map[string]string{"status": "confirmed"}
+	return c.do(http.MethodPatch, "/hotels/bookings/"+url.PathEscape(hotelBookingID), key, body, nil)
+}
+
+// CancelHotel cancels a hotel booking; one that no longer exists counts as cancelled.
+func (c *HotelClient) CancelHotel(key string, hotelBookingID string) error {
+	body := map[string]string{"cancellation_reason": "package booking rolled back"}
+	err := c.do(http.MethodPost, "/hotels/bookings/"+url.PathEscape(hotelBookingID)+"/cancel", key, body, nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
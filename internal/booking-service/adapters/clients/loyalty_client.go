@@ -0,0 +1,62 @@
+package clients
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"net/http"
+)
+
+// LoyaltyClient pays for bookings with loyalty points through the user service.
+type LoyaltyClient struct {
+	serviceClient
+}
+
+func NewLoyaltyClient(baseURL string) *LoyaltyClient {
+	return &LoyaltyClient{serviceClient: newServiceClient(baseURL)}
+}
+
+type redemptionRequest struct {
+	EventID   string  `json:"event_id"`
+	Source    string  `json:"source"`
+	UserID    string  `json:"user_id"`
+	BookingID string  `json:"booking_id"`
+	Points    int     `json:"points"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+type ledgerEntry struct {
+	Points   int     `json:"points"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type reversalRequest struct {
+	EventID   string `json:"event_id"`
+	Source    string `json:"source"`
+	BookingID string `json:"booking_id"`
+}
+
+// RedeemPoints uses the key as the event of the redemption, so repeating it redeems the points once.
+// A traveller without enough points gets a 409, which rejects the step.
+func (c *LoyaltyClient) RedeemPoints(key string, userID string, bookingID string, points int, price models.Money) (*models.Money, error) {
+	body := redemptionRequest{
+		EventID:   key,
+		Source:    serviceName,
+		UserID:    userID,
+		BookingID: bookingID,
+		Points:    points,
+		Amount:    price.Amount,
+		Currency:  price.Currency,
+	}
+
+	var redeemed ledgerEntry
+	if err := c.do(http.MethodPost, "/loyalty/redemptions", key, body, &redeemed); err != nil {
+		return nil, err
+	}
+	return &models.Money{Amount: redeemed.Amount, Currency: redeemed.Currency}, nil
+}
+
+func (c *LoyaltyClient) ReverseRedemptions(key string, bookingID string) error {
+	body := reversalRequest{EventID: key, Source: serviceName, BookingID: bookingID}
+	return c.do(http.MethodPost, "/loyalty/redemptions/reverse", key, body, nil)
+}
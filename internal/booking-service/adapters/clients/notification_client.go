@@ -0,0 +1,34 @@
+package clients
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"net/http"
+)
+
+// NotificationClient emails travellers through the notification endpoint of the user service.
+type NotificationClient struct {
+	serviceClient
+}
+
+func NewNotificationClient(baseURL string) *NotificationClient {
+	return &NotificationClient{serviceClient: newServiceClient(baseURL)}
+}
+
+type notificationRequest struct {
+	ID       string            `json:"id"`
+	Source   string            `json:"source"`
+	UserID   string            `json:"user_id"`
+	Template string            `json:"template"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+func (c *NotificationClient) Notify(notification models.Notification) error {
+	body := notificationRequest{
+		ID:       notification.ID,
+		Source:   serviceName,
+		UserID:   notification.UserID,
+		Template: notification.Template,
+		Data:     notification.Data,
+	}
+	return c.do(http.MethodPost, "/notifications", notification.ID, body, nil)
+}
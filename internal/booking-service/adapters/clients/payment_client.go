@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"net/http"
+	"net/url"
+)
+
+// paymentCaptured is the status of a payment whose money was taken.
+const paymentCaptured = "captured"
+
+// PaymentClient charges and refunds travellers through the payment service.
+type PaymentClient struct {
+	serviceClient
+}
+
+func NewPaymentClient(baseURL string) *PaymentClient {
+	return &PaymentClient{serviceClient: newServiceClient(baseURL)}
+}
+
+type paymentRequest struct {
+	UserID        string  `json:"user_id"`
+	Reference     string  `json:"reference"`
+	PaymentMethod string  `json:"payment_method"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+type payment struct {
+	ID       string  `json:"id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Status   string  `json:"status"`
+}
+
+type refundRequest struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// TakePayment charges a traveller. Payments that need the traveller to act, such as a 3-D Secure
+// challenge, cannot complete within a package booking, so they are voided and the step rejected.
+func (c *PaymentClient) TakePayment(key string, userID string, reference string, method string, amount models.Money) (*models.Reservation, error) {
+	body := paymentRequest{
+		UserID:        userID,
+		Reference:     reference,
+		PaymentMethod: method,
+		Amount:        amount.Amount,
+		Currency:      amount.Currency,
+	}
+
+	var charged payment
+	if err := c.do(http.MethodPost, "/payments", key, body, &charged); err != nil {
+		return nil, err
+	}
+	if charged.Status != paymentCaptured {
+		if err := c.do(http.MethodPost, "/payments/"+url.PathEscape(charged.ID)+"/void", key+"-void", nil, nil); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: payment %s is %s", models.ErrStepRejected, charged.ID, charged.Status)
+	}
+	return &models.Reservation{ID: charged.ID, Price: models.Money{Amount: charged.Amount, Currency: charged.Currency}}, nil
+}
+
+func (c *PaymentClient) RefundPayment(key string, paymentID string, amount models.Money) error {
+	body := refundRequest{Amount: amount.Amount, Currency: amount.Currency}
+	return c.do(http.MethodPost, "/payments/"+url.PathEscape(paymentID)+"/refunds", key, body, nil)
+}
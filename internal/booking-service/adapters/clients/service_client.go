@@ -0,0 +1,97 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serviceName identifies the booking service in the tokens it sends to other services.
+const serviceName = "booking-service"
+
+// serviceClient calls the JSON API of another service of the platform.
+type serviceClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func newServiceClient(baseURL string) serviceClient {
+	return serviceClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// do sends body as JSON and decodes the response into out when it is not nil. Client errors other
+// than timeouts and rate limiting wrap models.ErrStepRejected since repeating the call will not help.
+func (c serviceClient) do(method string, path string, idempotencyKey string, body interface{}, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %v", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	token, err := middleware.GenerateJWT(serviceName)
+	if err != nil {
+		return fmt.Errorf("error generating service token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("%s %s responded %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return &statusError{status: resp.StatusCode, err: err}
+		}
+		return err
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("error decoding response of %s %s: %v", method, path, err)
+		}
+	}
+	return nil
+}
+
+// statusError is a client error response; it counts as a rejected step.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Is(target error) bool {
+	return target == models.ErrStepRejected
+}
+
+// isNotFound reports whether err is a 404 response, which compensations treat as already undone.
+func isNotFound(err error) bool {
+	statusErr, ok := err.(*statusError)
+	return ok && statusErr.status == http.StatusNotFound
+}
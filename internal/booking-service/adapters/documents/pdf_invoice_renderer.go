@@ -0,0 +1,227 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"strings"
+)
+
+// Page layout in PDF points, on A4.
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 50
+	lineHeight   = 14
+	courierWidth = 0.6 // Width of every Courier glyph, in em.
+)
+
+// Fonts every PDF reader has, so none are embedded.
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+	fontAmounts = "F3" // Courier, whose fixed width lines up the amounts
+)
+
+// PDFInvoiceRenderer renders invoices and credit notes as plain one-column PDF documents.
+type PDFInvoiceRenderer struct{}
+
+func NewPDFInvoiceRenderer() *PDFInvoiceRenderer {
+	return &PDFInvoiceRenderer{}
+}
+
+func (r *PDFInvoiceRenderer) Render(invoice *models.Invoice) ([]byte, error) {
+	page := &pdfPages{}
+	page.newPage()
+
+	title := "INVOICE"
+	if invoice.Kind == models.KindCreditNote {
+		title = "CREDIT NOTE"
+	}
+	page.text(margin, fontBold, 16, invoice.Issuer.Name)
+	page.textRight(pageWidth-margin, fontBold, 16, title)
+	page.skip(1.5)
+	for _, line := range addressLines(invoice.Issuer.Address) {
+		page.line(fontRegular, 9, line)
+	}
+	if invoice.Issuer.VATID != "" {
+		page.line(fontRegular, 9, "VAT ID: "+invoice.Issuer.VATID)
+	}
+	page.skip(1.5)
+
+	page.line(fontBold, 10, "Bill to")
+	page.line(fontRegular, 10, invoice.Billing.Name)
+	for _, line := range addressLines(invoice.Billing.Address) {
+		page.line(fontRegular, 10, line)
+	}
+	if invoice.Billing.VATID != "" {
+		page.line(fontRegular, 10, "VAT ID: "+invoice.Billing.VATID)
+	}
+	page.skip(1)
+
+	details := [][2]string{
+		{"Number", invoice.Number},
+		{"Date", invoice.IssuedAt.Format("2006-01-02")},
+		{"Booking", invoice.BookingID},
+	}
+	if invoice.Kind == models.KindCreditNote {
+		details = append(details, [2]string{"Cancels invoice", invoice.CreditedNumber})
+		if invoice.Reason != "" {
+			details = append(details, [2]string{"Reason", invoice.Reason})
+		}
+	}
+	for _, detail := range details {
+		page.text(margin, fontBold, 10, detail[0])
+		page.text(margin+110, fontRegular, 10, detail[1])
+		page.skip(1)
+	}
+	page.skip(1.5)
+
+	// Columns: description, net, VAT rate, VAT, gross; amounts are right-aligned at these edges.
+	columns := []float64{330, 390, 460, pageWidth - margin}
+	page.text(margin, fontBold, 10, "Description")
+	for i, header := range []string{"Net", "VAT %", "VAT", "Gross"} {
+		page.textRight(columns[i], fontBold, 10, header)
+	}
+	page.skip(1)
+	for _, line := range invoice.Lines {
+		page.text(margin, fontRegular, 10, line.Description)
+		for i, value := range []string{amount(line.NetAmount), rate(line.TaxRate), amount(line.TaxAmount), amount(line.GrossAmount)} {
+			page.textRight(columns[i], fontAmounts, 10, value)
+		}
+		page.skip(1)
+	}
+	page.skip(1)
+
+	for _, tax := range invoice.Taxes {
+		page.text(margin, fontRegular, 10, fmt.Sprintf("VAT %s%% on %s", rate(tax.Rate), amount(tax.NetAmount)))
+		page.textRight(columns[3], fontAmounts, 10, amount(tax.TaxAmount))
+		page.skip(1)
+	}
+	totals := [][2]string{
+		{"Net total", amount(invoice.NetTotal)},
+		{"VAT total", amount(invoice.TaxTotal)},
+		{"Total " + invoice.Currency, amount(invoice.GrossTotal)},
+	}
+	for i, total := range totals {
+		font := fontRegular
+		if i == len(totals)-1 {
+			font = fontBold
+		}
+		page.text(columns[1], font, 10, total[0])
+		page.textRight(columns[3], fontAmounts, 10, total[1])
+		page.skip(1)
+	}
+	return page.document(), nil
+}
+
+func addressLines(address models.Address) []string {
+	var lines []string
+	for _, line := range []string{address.Line1, address.Line2, strings.TrimSpace(address.PostalCode + " " + address.City), address.Country} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func amount(value float64) string {
+	return fmt.Sprintf("%.2f", value)
+}
+
+func rate(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value*100), "0"), ".")
+}
+
+// pdfPages writes text top to bottom, starting a new page when one is full.
+type pdfPages struct {
+	pages []*bytes.Buffer // Content streams.
+	y     float64         // Baseline of the next line.
+}
+
+func (p *pdfPages) newPage() {
+	p.pages = append(p.pages, &bytes.Buffer{})
+	p.y = pageHeight - margin
+}
+
+// text writes s with its left edge at x on the current line.
+func (p *pdfPages) text(x float64, font string, size float64, s string) {
+	if p.y < margin {
+		p.newPage()
+	}
+	fmt.Fprintf(p.pages[len(p.pages)-1], "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.y, escape(s))
+}
+
+// textRight writes s with its right edge at x. Only Courier has a known width, so other fonts are
+// measured as if they were Courier, which is wide enough for headers.
+func (p *pdfPages) textRight(x float64, font string, size float64, s string) {
+	width := float64(len([]rune(s))) * size * courierWidth
+	p.text(x-width, font, size, s)
+}
+
+// line writes s at the left margin and moves to the next line.
+func (p *pdfPages) line(font string, size float64, s string) {
+	p.text(margin, font, size, s)
+	p.skip(1)
+}
+
+func (p *pdfPages) skip(lines float64) {
+	p.y -= lines * lineHeight
+}
+
+// document assembles the pages into a PDF file.
+func (p *pdfPages) document() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 to 5 are the catalog, the page tree and the fonts; each page and its content follow.
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	for _, name := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	for i, content := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R /%s 5 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, fontAmounts, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape encodes s in WinAnsiEncoding as the body of a PDF string; characters it lacks become "?".
+func escape(s string) string {
+	var out strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+		case r == '€':
+			out.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7f:
+			out.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&out, "\\%03o", r)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	return out.String()
+}
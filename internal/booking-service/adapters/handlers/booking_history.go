@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// GetBookingHistory lists every change of a booking, oldest first, with who made it, why and the
+// fields it changed. End users only see the history of their own bookings.
+func (h *BookingHandler) GetBookingHistory(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetBookingHistory(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// changeOf tells who makes a write from the claims of its JWT and why from the X-Change-Reason
+// header, unless the request body gave a reason.
+func changeOf(r *http.Request, reason string) (models.Change, bool) {
+	caller, ok := middleware.CallerFromContext(r.Context())
+	if !ok {
+		return models.Change{}, false
+	}
+	if reason == "" {
+		reason = strings.TrimSpace(r.Header.Get("X-Change-Reason"))
+	}
+	if caller.IsService() {
+		return models.Change{Actor: models.Actor{Type: models.ActorService, ID: caller.Service}, Reason: reason}, true
+	}
+	return models.Change{Actor: models.Actor{Type: models.ActorUser, ID: caller.UserID}, Reason: reason}, true
+}
+
+// requireChange reads the change a write makes, answering 401 when its token names nobody.
+func requireChange(w http.ResponseWriter, r *http.Request, reason string) (models.Change, bool) {
+	change, ok := changeOf(r, reason)
+	if !ok {
+		http.Error(w, "Token does not name a user or service", http.StatusUnauthorized)
+	}
+	return change, ok
+}
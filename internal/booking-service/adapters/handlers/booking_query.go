@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requesterOf tells who made a request from the claims of its JWT: other services, agents and
+// admins are privileged; end users are their subject.
+func requesterOf(r *http.Request) (models.Requester, bool) {
+	caller, ok := middleware.CallerFromContext(r.Context())
+	return requesterFor(caller), ok
+}
+
+// requesterFor is the requester the booking services see for a caller.
+func requesterFor(caller middleware.Caller) models.Requester {
+	return models.Requester{
+		UserID:     caller.UserID,
+		Privileged: caller.IsService() || caller.IsStaff(),
+		Service:    caller.IsService(),
+	}
+}
+
+// bookingQuery reads a booking search from the query string:
+//
+//	status, productType, channel   comma-separated or repeated; any of the values matches
+//	createdFrom, createdTo         RFC 3339 times or dates; a date as upper bound includes the whole day
+//	travelFrom, travelTo           likewise, for the travel date
+//	sort                           createdAt, updatedAt or travelDate; prefixed with - to sort descending
+//	limit, cursor                  page size and the nextCursor of the previous page
+func bookingQuery(r *http.Request) (models.BookingQuery, error) {
+	values := r.URL.Query()
+	query := models.BookingQuery{
+		Statuses:     listParam(values["status"]),
+		ProductTypes: listParam(values["productType"]),
+		Channels:     listParam(values["channel"]),
+		Cursor:       values.Get("cursor"),
+	}
+
+	sort := values.Get("sort")
+	query.Descending = strings.HasPrefix(sort, "-")
+	query.SortBy = strings.TrimPrefix(sort, "-")
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return query, fmt.Errorf("%w: limit must be a positive number", models.ErrInvalidQuery)
+		}
+		query.Limit = n
+	}
+
+	var err error
+	ranges := []struct {
+		param     string
+		target    **time.Time
+		endOfDays bool
+	}{
+		{"createdFrom", &query.CreatedFrom, false},
+		{"createdTo", &query.CreatedTo, true},
+		{"travelFrom", &query.TravelFrom, false},
+		{"travelTo", &query.TravelTo, true},
+	}
+	for _, bound := range ranges {
+		if *bound.target, err = timeParam(values.Get(bound.param), bound.endOfDays); err != nil {
+			return query, fmt.Errorf("%w: %s: %v", models.ErrInvalidQuery, bound.param, err)
+		}
+	}
+	return query, nil
+}
+
+// listParam splits comma-separated values, dropping empty ones.
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// timeParam reads an RFC 3339 time or a date. A date is the start of the day, or its last moment
+// when endOfDay is set, so that a range ending on a date includes that day.
+func timeParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither an RFC 3339 time nor a date", value)
+	}
+	if endOfDay {
+		day = day.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &day, nil
+}
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/gorilla/mux"
 	"microservices-travel-backend/internal/booking-service/domain/models"
 	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/httputil"
+	"microservices-travel-backend/pkg/middlewares"
 	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 type BookingHandler struct {
@@ -20,39 +25,47 @@ func NewBookingHandler(service ports.BookingService) *BookingHandler {
 func (h *BookingHandler) RegisterRoutes(router *mux.Router) {
 	// Hotel routes
 	bookingRouter := router.PathPrefix("/bookings").Subrouter()
-	bookingRouter.HandleFunc("/", h.CreateBooking).Methods(http.MethodPost)
-	bookingRouter.HandleFunc("/", h.GetBookings).Methods(http.MethodGet)
-	bookingRouter.HandleFunc("/{id}", h.GetBookingByID).Methods(http.MethodGet)
-	bookingRouter.HandleFunc("/{id}", h.UpdateBooking).Methods(http.MethodPatch)
-	bookingRouter.HandleFunc("/status/{id}", h.UpdateBookingStatus).Methods(http.MethodPatch)
-	bookingRouter.HandleFunc("/{id}", h.DeleteBooking).Methods(http.MethodDelete)
+	// Requests are made on behalf of the user or service their JWT names, which the booking history
+	// records. End users only read and change their own bookings.
+	bookingRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.CreateBooking))).Methods(http.MethodPost)
+	bookingRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.SearchBookings))).Methods(http.MethodGet)
+	bookingRouter.Handle("/user/{userID}", middleware.JWTMiddleware(http.HandlerFunc(h.GetBookingsByUserID))).Methods(http.MethodGet)
+	bookingRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.GetBookingByID))).Methods(http.MethodGet)
+	bookingRouter.Handle("/{id}/history", middleware.JWTMiddleware(http.HandlerFunc(h.GetBookingHistory))).Methods(http.MethodGet)
+	bookingRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.UpdateBooking))).Methods(http.MethodPatch)
+	bookingRouter.Handle("/status/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.UpdateBookingStatus))).Methods(http.MethodPatch)
+	bookingRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.DeleteBooking))).Methods(http.MethodDelete)
 }
 
-func (h *BookingHandler) GetBookings(w http.ResponseWriter, r *http.Request) {
-	bookings, err := h.service.GetAllBookings()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(bookings)
+// SearchBookings returns a page of the bookings matching the filters in the query string, see
+// bookingQuery. End users only ever see their own bookings.
+func (h *BookingHandler) SearchBookings(w http.ResponseWriter, r *http.Request) {
+	h.searchBookings(w, r, r.URL.Query().Get("userID"))
 }
 
 func (h *BookingHandler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
 
-	booking, err := h.service.GetBookingByID(id)
+	booking, err := h.service.GetBookingByID(requester, id)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
+	httputil.SetETag(w, booking.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(booking)
 }
 
 func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
 	var booking models.Booking
 
 	// Parse JSON body
@@ -62,20 +75,34 @@ func (h *BookingHandler) CreateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	change, ok := requireChange(w, r, "")
+	if !ok {
+		return
+	}
+
 	// Call service to create booking
-	err = h.service.CreateBooking(&booking)
+	err = h.service.CreateBooking(requester, &booking, change)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
 	// Respond with the created booking
+	httputil.SetETag(w, booking.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(booking)
 }
 
 func (h *BookingHandler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	version, ok := httputil.RequireVersion(w, r)
+	if !ok {
+		return
+	}
 
 	var booking models.Booking
 	err := json.NewDecoder(r.Body).Decode(&booking)
@@ -84,21 +111,40 @@ func (h *BookingHandler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedBooking, err := h.service.UpdateBooking(id, &booking)
+	change, ok := requireChange(w, r, "")
+	if !ok {
+		return
+	}
+
+	updatedBooking, err := h.service.UpdateBooking(requester, id, &booking, version, change)
+	if errors.Is(err, models.ErrVersionConflict) {
+		h.writeConflict(w, requester, id)
+		return
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
+	httputil.SetETag(w, updatedBooking.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedBooking)
 }
 
 func (h *BookingHandler) UpdateBookingStatus(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	version, ok := httputil.RequireVersion(w, r)
+	if !ok {
+		return
+	}
 
 	var statusRequest struct {
 		Status string `json:"bookingStatus"`
+		Reason string `json:"reason"`
 	}
 	err := json.NewDecoder(r.Body).Decode(&statusRequest)
 	if err != nil {
@@ -106,12 +152,22 @@ func (h *BookingHandler) UpdateBookingStatus(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = h.service.UpdateBookingStatus(id, statusRequest.Status)
+	change, ok := requireChange(w, r, strings.TrimSpace(statusRequest.Reason))
+	if !ok {
+		return
+	}
+
+	updatedBooking, err := h.service.UpdateBookingStatus(requester, id, statusRequest.Status, version, change)
+	if errors.Is(err, models.ErrVersionConflict) {
+		h.writeConflict(w, requester, id)
+		return
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
+	httputil.SetETag(w, updatedBooking.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(struct {
 		Message string `json:"message"`
@@ -120,25 +176,86 @@ func (h *BookingHandler) UpdateBookingStatus(w http.ResponseWriter, r *http.Requ
 
 func (h *BookingHandler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	version, ok := httputil.RequireVersion(w, r)
+	if !ok {
+		return
+	}
+
+	change, ok := requireChange(w, r, "")
+	if !ok {
+		return
+	}
 
-	err := h.service.DeleteBooking(id)
+	err := h.service.DeleteBooking(requester, id, version, change)
+	if errors.Is(err, models.ErrVersionConflict) {
+		h.writeConflict(w, requester, id)
+		return
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetBookingsByUserID searches the bookings of one user, with the same filters as SearchBookings.
 func (h *BookingHandler) GetBookingsByUserID(w http.ResponseWriter, r *http.Request) {
-	userID := mux.Vars(r)["userID"]
+	h.searchBookings(w, r, mux.Vars(r)["userID"])
+}
 
-	bookings, err := h.service.GetBookingsByUserID(userID)
+func (h *BookingHandler) searchBookings(w http.ResponseWriter, r *http.Request, userID string) {
+	requester, ok := requesterOf(r)
+	if !ok {
+		http.Error(w, "Token does not name a user or service", http.StatusUnauthorized)
+		return
+	}
+	query, err := bookingQuery(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+	query.UserID = userID
+
+	page, err := h.service.QueryBookings(requester, query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(bookings)
+	json.NewEncoder(w).Encode(page)
+}
+
+// errorStatus maps the errors of the booking service to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBookingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicateBooking):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidBooking), errors.Is(err, models.ErrInvalidQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeConflict answers a write made against an outdated version with 412 and the booking as it
+// is now.
+func (h *BookingHandler) writeConflict(w http.ResponseWriter, requester models.Requester, id string) {
+	current, err := h.service.GetBookingByID(requester, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+	httputil.WriteConflict(w, current.Version, current)
 }
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type InvoiceHandler struct {
+	service ports.InvoiceService
+}
+
+func NewInvoiceHandler(service ports.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{service: service}
+}
+
+// RegisterRoutes registers the invoice endpoints of bookings. Travellers are invoiced for their own
+// bookings; agents, admins and services read and issue the invoices of any.
+func (h *InvoiceHandler) RegisterRoutes(router *mux.Router) {
+	invoiceRouter := router.PathPrefix("/bookings/{id}").Subrouter()
+	invoiceRouter.Use(middleware.JWTMiddleware)
+	invoiceRouter.HandleFunc("/invoice", h.IssueInvoice).Methods(http.MethodPost)
+	invoiceRouter.HandleFunc("/invoice", h.GetInvoice).Methods(http.MethodGet)
+	invoiceRouter.HandleFunc("/invoices", h.GetInvoices).Methods(http.MethodGet)
+}
+
+// IssueInvoice invoices a confirmed package booking to the billing details in the body. Issuing
+// again with other details cancels the invoice with a credit note and issues a corrected one.
+func (h *InvoiceHandler) IssueInvoice(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var billing models.BillingDetails
+	if err := json.NewDecoder(r.Body).Decode(&billing); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	invoice, err := h.service.IssueInvoice(requester, mux.Vars(r)["id"], billing)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), invoiceErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoice returns the document last issued for a booking, as a PDF when the request accepts
+// application/pdf and as JSON otherwise.
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	invoice, err := h.service.GetInvoice(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), invoiceErrorStatus(err))
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/pdf") {
+		document, err := h.service.RenderInvoice(invoice)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error: %v", err), invoiceErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoice.Number+".pdf"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(document)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoices returns every invoice and credit note of a booking in the order they were issued.
+func (h *InvoiceHandler) GetInvoices(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	invoices, err := h.service.GetInvoices(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), invoiceErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invoices)
+}
+
+// invoiceErrorStatus maps the errors of the invoice service to HTTP status codes.
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBookingNotFound), errors.Is(err, models.ErrSagaNotFound),
+		errors.Is(err, models.ErrInvoiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidInvoice):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
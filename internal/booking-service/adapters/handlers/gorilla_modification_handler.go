@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type ModificationHandler struct {
+	service ports.ModificationService
+}
+
+func NewModificationHandler(service ports.ModificationService) *ModificationHandler {
+	return &ModificationHandler{service: service}
+}
+
+// RegisterRoutes registers the modification endpoints of bookings. Travellers change their own
+// bookings; agents, admins and services any.
+func (h *ModificationHandler) RegisterRoutes(router *mux.Router) {
+	modificationRouter := router.PathPrefix("/bookings/{id}/modifications").Subrouter()
+	modificationRouter.Use(middleware.JWTMiddleware)
+	modificationRouter.HandleFunc("", h.RequestModification).Methods(http.MethodPost)
+	modificationRouter.HandleFunc("", h.GetModifications).Methods(http.MethodGet)
+	modificationRouter.HandleFunc("/{modificationID}", h.GetModification).Methods(http.MethodGet)
+	modificationRouter.HandleFunc("/{modificationID}/confirm", h.ConfirmModification).Methods(http.MethodPost)
+	modificationRouter.HandleFunc("/{modificationID}/decline", h.DeclineModification).Methods(http.MethodPost)
+}
+
+// RequestModification quotes a change of the hotel stay, the flights or both of a package booking
+// and holds the new stay until the quote expires. Nothing changes until the quote is confirmed.
+func (h *ModificationHandler) RequestModification(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request models.ModificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	change, ok := requireChange(w, r, "")
+	if !ok {
+		return
+	}
+
+	modification, err := h.service.RequestModification(requester, mux.Vars(r)["id"], request, change)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), modificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(modification)
+}
+
+func (h *ModificationHandler) GetModifications(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	modifications, err := h.service.GetModifications(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), modificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(modifications)
+}
+
+func (h *ModificationHandler) GetModification(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+
+	modification, err := h.service.GetModification(requester, vars["id"], vars["modificationID"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), modificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(modification)
+}
+
+// ConfirmModification applies a quoted change and collects or refunds the difference. It responds
+// 200 once the change is made, 202 while it waits for a service that could not be reached, and 422
+// when a service refused it and it was undone.
+func (h *ModificationHandler) ConfirmModification(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+
+	modification, err := h.service.ConfirmModification(requester, vars["id"], vars["modificationID"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), modificationErrorStatus(err))
+		return
+	}
+
+	switch modification.Status {
+	case models.ModificationCompleted:
+		w.WriteHeader(http.StatusOK)
+	case models.ModificationFailed:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(modification)
+}
+
+// DeclineModification gives up a quoted change and releases the stay held for it.
+func (h *ModificationHandler) DeclineModification(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+
+	modification, err := h.service.DeclineModification(requester, vars["id"], vars["modificationID"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), modificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(modification)
+}
+
+// requireRequester reads who is asking, answering 401 when the token names nobody.
+func requireRequester(w http.ResponseWriter, r *http.Request) (models.Requester, bool) {
+	caller, ok := middleware.RequireCaller(w, r)
+	return requesterFor(caller), ok
+}
+
+// modificationErrorStatus maps the errors of the modification service to HTTP status codes.
+func modificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBookingNotFound), errors.Is(err, models.ErrSagaNotFound),
+		errors.Is(err, models.ErrModificationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidModification):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrModificationConflict), errors.Is(err, models.ErrModificationUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrModificationExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type PackageHandler struct {
+	service ports.PackageBookingService
+}
+
+func NewPackageHandler(service ports.PackageBookingService) *PackageHandler {
+	return &PackageHandler{service: service}
+}
+
+func (h *PackageHandler) RegisterRoutes(router *mux.Router) {
+	packageRouter := router.PathPrefix("/packages").Subrouter()
+	// Travellers book and follow their own packages; other services may book for any user.
+	packageRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.BookPackage))).Methods(http.MethodPost)
+	packageRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.GetPackageBooking))).Methods(http.MethodGet)
+}
+
+// BookPackage books a flight and a hotel stay together. It responds 201 once both are confirmed and
+// paid, 202 while the booking waits for a service that could not be reached, and 422 when it was
+// rolled back; GET /packages/{id} shows how far it got.
+func (h *PackageHandler) BookPackage(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request models.PackageBookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	saga, err := h.service.BookPackage(requester, request)
+	if err != nil {
+		if saga != nil && errors.Is(err, models.ErrPackageBookingFailed) {
+			http.Error(w, fmt.Sprintf("Error: package booking %s: %v", saga.SagaID, err), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), packageErrorStatus(err))
+		return
+	}
+
+	if saga.Status == models.SagaCompleted {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(saga)
+}
+
+func (h *PackageHandler) GetPackageBooking(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	saga, err := h.service.GetPackageBooking(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), packageErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(saga)
+}
+
+// packageErrorStatus maps the errors of the package booking service to HTTP status codes.
+func packageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrSagaNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidBooking), errors.Is(err, models.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type PromotionHandler struct {
+	service ports.PromotionService
+}
+
+func NewPromotionHandler(service ports.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+// RegisterRoutes serves the promotions to agents, admins and other services, and price quotes to
+// everyone with a token.
+func (h *PromotionHandler) RegisterRoutes(router *mux.Router) {
+	promotionRouter := router.PathPrefix("/promotions").Subrouter()
+	promotionRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.CreatePromotion))).Methods(http.MethodPost)
+	promotionRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.GetPromotions))).Methods(http.MethodGet)
+	promotionRouter.Handle("/quote", middleware.JWTMiddleware(http.HandlerFunc(h.QuotePrice))).Methods(http.MethodPost)
+	promotionRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.GetPromotion))).Methods(http.MethodGet)
+	promotionRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.UpdatePromotion))).Methods(http.MethodPut)
+}
+
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreatePromotion(&promotion); err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), promotionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promotion)
+}
+
+func (h *PromotionHandler) GetPromotions(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+	promotions, err := h.service.GetPromotions()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), promotionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(promotions)
+}
+
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+	promotion, err := h.service.GetPromotion(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), promotionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// UpdatePromotion replaces the rules of a promotion; its usage count is kept.
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+	var promotion models.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.service.UpdatePromotion(mux.Vars(r)["id"], &promotion)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), promotionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// QuotePrice shows what the promotions take off a price before booking. End users are always
+// quoted for themselves, so that their per-user caps apply.
+func (h *PromotionHandler) QuotePrice(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requesterOf(r)
+	if !ok {
+		http.Error(w, "Token does not name a user or service", http.StatusUnauthorized)
+		return
+	}
+	var request models.PricingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if !requester.Privileged {
+		request.UserID = requester.UserID
+	}
+
+	quote, err := h.service.QuotePrice(request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), promotionErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
+
+// requirePrivileged responds 401 or 403 unless the request comes from an agent, an admin or
+// another service.
+func requirePrivileged(w http.ResponseWriter, r *http.Request) bool {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return false
+	}
+	if !requester.Privileged {
+		http.Error(w, "Only agents and admins can manage promotions", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// promotionErrorStatus maps the errors of the promotion service to HTTP status codes.
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrDuplicatePromotion), errors.Is(err, models.ErrPromotionUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type TripHandler struct {
+	service ports.TripService
+}
+
+func NewTripHandler(service ports.TripService) *TripHandler {
+	return &TripHandler{service: service}
+}
+
+func (h *TripHandler) RegisterRoutes(router *mux.Router) {
+	tripRouter := router.PathPrefix("/trips").Subrouter()
+	// Share links are read without a token; everything else is done on behalf of the user or
+	// service the JWT names, and end users only see and change their own trips.
+	tripRouter.HandleFunc("/shared/{token}", h.GetSharedTrip).Methods(http.MethodGet)
+	tripRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.CreateTrip))).Methods(http.MethodPost)
+	tripRouter.Handle("/", middleware.JWTMiddleware(http.HandlerFunc(h.GetTripsByUserID))).Methods(http.MethodGet)
+	tripRouter.Handle("/{id}", middleware.JWTMiddleware(http.HandlerFunc(h.GetTrip))).Methods(http.MethodGet)
+	tripRouter.Handle("/{id}/items", middleware.JWTMiddleware(http.HandlerFunc(h.AddTripItem))).Methods(http.MethodPost)
+	tripRouter.Handle("/{id}/items/{itemId}", middleware.JWTMiddleware(http.HandlerFunc(h.RemoveTripItem))).Methods(http.MethodDelete)
+	tripRouter.Handle("/{id}/share", middleware.JWTMiddleware(http.HandlerFunc(h.ShareTrip))).Methods(http.MethodPost)
+	tripRouter.Handle("/{id}/share", middleware.JWTMiddleware(http.HandlerFunc(h.StopSharingTrip))).Methods(http.MethodDelete)
+}
+
+func (h *TripHandler) CreateTrip(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request models.CreateTripRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	trip, err := h.service.CreateTrip(requester, request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(trip)
+}
+
+// GetTripsByUserID lists the trips of the requester, or of another user for privileged requesters,
+// e.g. GET /trips/?userID=42
+func (h *TripHandler) GetTripsByUserID(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	trips, err := h.service.GetTripsByUserID(requester, r.URL.Query().Get("userID"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trips)
+}
+
+// GetTrip returns a trip with its chronological timeline and total cost.
+func (h *TripHandler) GetTrip(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	trip, err := h.service.GetTrip(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trip)
+}
+
+func (h *TripHandler) AddTripItem(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var item models.TripItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	trip, err := h.service.AddTripItem(requester, mux.Vars(r)["id"], item)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trip)
+}
+
+func (h *TripHandler) RemoveTripItem(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	trip, err := h.service.RemoveTripItem(requester, vars["id"], vars["itemId"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trip)
+}
+
+// ShareTrip returns the token of a read-only link to the trip, served at GET /trips/shared/{token}.
+func (h *TripHandler) ShareTrip(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	share, err := h.service.ShareTrip(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(share)
+}
+
+func (h *TripHandler) StopSharingTrip(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.StopSharingTrip(requester, mux.Vars(r)["id"]); err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TripHandler) GetSharedTrip(w http.ResponseWriter, r *http.Request) {
+	trip, err := h.service.GetSharedTrip(mux.Vars(r)["token"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), tripErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trip)
+}
+
+// tripErrorStatus maps the errors of the trip service to HTTP status codes.
+func tripErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrTripNotFound), errors.Is(err, models.ErrTripItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidTrip):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,35 @@
+package messaging
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+)
+
+// FanoutBus publishes every event to several buses in turn. When one fails the event counts as
+// not published and is relayed again, also to the buses that already took it; consumers tell the
+// repeats apart by the event ID.
+type FanoutBus struct {
+	buses []ports.MessageBus
+}
+
+func NewFanoutBus(buses ...ports.MessageBus) *FanoutBus {
+	return &FanoutBus{buses: buses}
+}
+
+func (b *FanoutBus) Publish(event models.OutboxEvent) error {
+	for _, bus := range b.buses {
+		if err := bus.Publish(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *FanoutBus) Close() error {
+	var errs []error
+	for _, bus := range b.buses {
+		errs = append(errs, bus.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,36 @@
+package messaging
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sync"
+)
+
+// MemoryBus hands events to subscribers in the same process, for development and tests.
+type MemoryBus struct {
+	mu          sync.RWMutex
+	subscribers []func(event models.OutboxEvent)
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+// Subscribe calls handler with every event published from now on, in the publishing goroutine.
+func (b *MemoryBus) Subscribe(handler func(event models.OutboxEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handler)
+}
+
+func (b *MemoryBus) Publish(event models.OutboxEvent) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.subscribers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	return nil
+}
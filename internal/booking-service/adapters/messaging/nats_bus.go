@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// publishTimeout bounds how long publishing waits for JetStream to acknowledge an event.
+const publishTimeout = 5 * time.Second
+
+// NATSBus publishes events to NATS JetStream under <prefix>.<event type>, e.g.
+// bookings.BookingCreated. The event ID is sent as Nats-Msg-Id so JetStream drops events the
+// relay publishes twice within the stream's duplicate window.
+type NATSBus struct {
+	conn          *nats.Conn
+	stream        nats.JetStreamContext
+	subjectPrefix string
+}
+
+// NewNATSBus connects to NATS and makes sure a stream captures the events under subjectPrefix.
+func NewNATSBus(url string, subjectPrefix string) (*NATSBus, error) {
+	conn, err := nats.Connect(url, nats.Name("booking-service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %v", err)
+	}
+	stream, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %v", err)
+	}
+
+	streamName := "BOOKING_EVENTS"
+	if _, err := stream.StreamInfo(streamName); err != nil {
+		_, err = stream.AddStream(&nats.StreamConfig{
+			Name:       streamName,
+			Subjects:   []string{subjectPrefix + ".>"},
+			Storage:    nats.FileStorage,
+			Duplicates: 10 * time.Minute,
+		})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create stream %s: %v", streamName, err)
+		}
+	}
+	return &NATSBus{conn: conn, stream: stream, subjectPrefix: subjectPrefix}, nil
+}
+
+func (b *NATSBus) Publish(event models.OutboxEvent) error {
+	message := nats.NewMsg(b.subjectPrefix + "." + string(event.Type))
+	message.Header.Set(nats.MsgIdHdr, event.EventID)
+	message.Header.Set("Aggregate-Id", event.AggregateID)
+	message.Header.Set("Content-Type", "application/json")
+	message.Data = event.Payload
+
+	if _, err := b.stream.PublishMsg(message, nats.AckWait(publishTimeout)); err != nil {
+		return fmt.Errorf("error publishing %s event: %v", event.Type, err)
+	}
+	return nil
+}
+
+func (b *NATSBus) Close() error {
+	return b.conn.Drain()
+}
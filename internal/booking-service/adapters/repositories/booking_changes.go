@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+)
+
+// bookingChanges lists the columns an update sets, keyed by the column and attribute names both
+// repositories store bookings under. Empty fields are left unchanged; the ID and creation time never change.
+func bookingChanges(booking *models.Booking) map[string]interface{} {
+	changes := map[string]interface{}{
+		"updated_at": time.Now().UTC(),
+	}
+	if booking.UserID != "" {
+		changes["user_id"] = booking.UserID
+	}
+	if booking.FlightID != "" {
+		changes["flight_id"] = booking.FlightID
+	}
+	if booking.BookingStatus != "" {
+		changes["booking_status"] = booking.BookingStatus
+	}
+	if booking.TravelDate != nil {
+		changes["travel_date"] = *booking.TravelDate
+	}
+	if booking.Discounts != nil {
+		changes["discounts"] = booking.Discounts
+	}
+	return changes
+}
+
+// applyBookingChanges sets the changes listed by bookingChanges on a booking.
+func applyBookingChanges(booking *models.Booking, changes map[string]interface{}) {
+	for column, value := range changes {
+		switch column {
+		case "user_id":
+			booking.UserID = value.(string)
+		case "flight_id":
+			booking.FlightID = value.(string)
+		case "booking_status":
+			booking.BookingStatus = value.(string)
+		case "travel_date":
+			travelDate := value.(time.Time)
+			booking.TravelDate = &travelDate
+		case "discounts":
+			booking.Discounts = value.(models.AppliedDiscounts)
+		case "updated_at":
+			booking.UpdatedAt = value.(time.Time)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+)
+
+// bookingCursor is the position after the last booking of a page: its sort value and ID. It
+// remembers the sort it was made for, so it cannot be used to continue a different one.
+type bookingCursor struct {
+	SortBy     string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Value      time.Time `json:"v"`
+	BookingID  string    `json:"id"`
+}
+
+func encodeCursor(query models.BookingQuery, last *models.Booking) string {
+	data, _ := json.Marshal(bookingCursor{
+		SortBy:     query.SortBy,
+		Descending: query.Descending,
+		Value:      sortValue(last, query.SortBy),
+		BookingID:  last.BookingID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads the cursor of a query, returning nil for the first page.
+func decodeCursor(query models.BookingQuery) (*bookingCursor, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", models.ErrInvalidQuery)
+	}
+	var cursor bookingCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.BookingID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", models.ErrInvalidQuery)
+	}
+	if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+		return nil, fmt.Errorf("%w: cursor belongs to a search with another sort order", models.ErrInvalidQuery)
+	}
+	return &cursor, nil
+}
+
+// sortValue is what a booking is sorted by; bookings without a travel date sort as the zero time.
+func sortValue(booking *models.Booking, sortBy string) time.Time {
+	switch sortBy {
+	case models.SortByUpdatedAt:
+		return booking.UpdatedAt
+	case models.SortByTravelDate:
+		if booking.TravelDate == nil {
+			return time.Time{}
+		}
+		return *booking.TravelDate
+	default:
+		return booking.CreatedAt
+	}
+}
@@ -0,0 +1,1297 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"microservices-travel-backend/pkg/scheduler"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testChange is the change the conformance suites make bookings with.
+var testChange = models.Change{Actor: models.Actor{Type: models.ActorUser, ID: "conformance-test"}}
+
+// testBookingDB runs the behaviour every ports.BookingDB implementation must share against db.
+// Bookings are created under a user of their own so the suite can run against a shared database.
+func testBookingDB(t *testing.T, db ports.BookingDB) {
+	userID := "user-" + uuid.NewString()
+	newBooking := func(t *testing.T) *models.Booking {
+		t.Helper()
+		booking := &models.Booking{
+			BookingID:     uuid.NewString(),
+			UserID:        userID,
+			FlightID:      "LH1000-20250601",
+			BookingStatus: "pending",
+		}
+		if err := db.CreateBooking(booking, testChange); err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+		t.Cleanup(func() { db.DeleteBooking(booking.BookingID, models.AnyVersion, testChange) })
+		return booking
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		created := newBooking(t)
+		if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+			t.Fatalf("CreateBooking did not set timestamps: %+v", created)
+		}
+
+		stored, err := db.GetBookingByID(created.BookingID)
+		if err != nil {
+			t.Fatalf("GetBookingByID: %v", err)
+		}
+		if stored.BookingID != created.BookingID || stored.UserID != created.UserID ||
+			stored.FlightID != created.FlightID || stored.BookingStatus != created.BookingStatus {
+			t.Errorf("stored booking %+v does not match created %+v", stored, created)
+		}
+		if !sameTime(stored.CreatedAt, created.CreatedAt) {
+			t.Errorf("created at %v, want %v", stored.CreatedAt, created.CreatedAt)
+		}
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		created := newBooking(t)
+		duplicate := *created
+		if err := db.CreateBooking(&duplicate, testChange); !errors.Is(err, models.ErrDuplicateBooking) {
+			t.Errorf("CreateBooking with a used ID: got %v, want %v", err, models.ErrDuplicateBooking)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		if _, err := db.GetBookingByID(uuid.NewString()); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("GetBookingByID: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		created := newBooking(t)
+		time.Sleep(10 * time.Millisecond)
+		updated, err := db.UpdateBookingStatus(created.BookingID, "confirmed", created.Version, testChange)
+		if err != nil {
+			t.Fatalf("UpdateBookingStatus: %v", err)
+		}
+		if updated.BookingStatus != "confirmed" || updated.Version != created.Version+1 {
+			t.Errorf("UpdateBookingStatus returned %+v, want it confirmed at version %d", updated, created.Version+1)
+		}
+
+		stored, err := db.GetBookingByID(created.BookingID)
+		if err != nil {
+			t.Fatalf("GetBookingByID: %v", err)
+		}
+		if stored.BookingStatus != "confirmed" {
+			t.Errorf("status %q, want confirmed", stored.BookingStatus)
+		}
+		if !stored.UpdatedAt.After(created.UpdatedAt) {
+			t.Errorf("updated at %v was not moved past %v", stored.UpdatedAt, created.UpdatedAt)
+		}
+
+		if _, err := db.UpdateBookingStatus(uuid.NewString(), "confirmed", models.AnyVersion, testChange); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("UpdateBookingStatus of a missing booking: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		created := newBooking(t)
+		updated, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, created.Version, testChange)
+		if err != nil {
+			t.Fatalf("UpdateBooking: %v", err)
+		}
+		if updated.BookingID != created.BookingID || updated.FlightID != "BA2000-20250602" ||
+			updated.UserID != created.UserID || updated.BookingStatus != created.BookingStatus {
+			t.Errorf("UpdateBooking returned %+v, want only the flight of %+v changed", updated, created)
+		}
+		if !sameTime(updated.CreatedAt, created.CreatedAt) {
+			t.Errorf("UpdateBooking changed created at to %v", updated.CreatedAt)
+		}
+
+		travelDate := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+		moved, err := db.UpdateBooking(created.BookingID, &models.Booking{TravelDate: &travelDate}, updated.Version, testChange)
+		if err != nil {
+			t.Fatalf("UpdateBooking: %v", err)
+		}
+		if moved.TravelDate == nil || !sameTime(*moved.TravelDate, travelDate) || moved.FlightID != "BA2000-20250602" {
+			t.Errorf("UpdateBooking returned %+v, want it moved to %v", moved, travelDate)
+		}
+
+		if _, err := db.UpdateBooking(uuid.NewString(), &models.Booking{FlightID: "BA2000-20250602"}, models.AnyVersion, testChange); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("UpdateBooking of a missing booking: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		created := newBooking(t)
+		if created.Version != 1 {
+			t.Fatalf("CreateBooking set version %d, want 1", created.Version)
+		}
+		updated, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, 1, testChange)
+		if err != nil {
+			t.Fatalf("UpdateBooking: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Errorf("UpdateBooking left version %d, want 2", updated.Version)
+		}
+
+		if _, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "AF3000-20250603"}, 1, testChange); !errors.Is(err, models.ErrVersionConflict) {
+			t.Errorf("UpdateBooking of an outdated version: got %v, want %v", err, models.ErrVersionConflict)
+		}
+		if _, err := db.UpdateBookingStatus(created.BookingID, "confirmed", 1, testChange); !errors.Is(err, models.ErrVersionConflict) {
+			t.Errorf("UpdateBookingStatus of an outdated version: got %v, want %v", err, models.ErrVersionConflict)
+		}
+		if err := db.DeleteBooking(created.BookingID, 1, testChange); !errors.Is(err, models.ErrVersionConflict) {
+			t.Errorf("DeleteBooking of an outdated version: got %v, want %v", err, models.ErrVersionConflict)
+		}
+		stored, err := db.GetBookingByID(created.BookingID)
+		if err != nil {
+			t.Fatalf("GetBookingByID: %v", err)
+		}
+		if stored.FlightID != "BA2000-20250602" || stored.BookingStatus != "pending" || stored.Version != 2 {
+			t.Errorf("a conflicting write changed the booking to %+v", stored)
+		}
+
+		confirmed, err := db.UpdateBookingStatus(created.BookingID, "confirmed", models.AnyVersion, testChange)
+		if err != nil {
+			t.Fatalf("UpdateBookingStatus of any version: %v", err)
+		}
+		if confirmed.Version != 3 {
+			t.Errorf("UpdateBookingStatus of any version left version %d, want 3", confirmed.Version)
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		queryUser := "user-" + uuid.NewString()
+		day := func(d int) *time.Time {
+			t := time.Date(2025, 7, d, 0, 0, 0, 0, time.UTC)
+			return &t
+		}
+		var created []*models.Booking
+		for i, spec := range []struct {
+			status, product, channel string
+			travel                   *time.Time
+		}{
+			{"pending", models.ProductFlight, models.ChannelWeb, day(10)},
+			{"confirmed", models.ProductPackage, models.ChannelMobile, day(5)},
+			{"confirmed", models.ProductFlight, models.ChannelWeb, nil},
+		} {
+			if i > 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			booking := &models.Booking{
+				BookingID:     uuid.NewString(),
+				UserID:        queryUser,
+				FlightID:      "LH1000-20250601",
+				BookingStatus: spec.status,
+				ProductType:   spec.product,
+				Channel:       spec.channel,
+				TravelDate:    spec.travel,
+			}
+			if err := db.CreateBooking(booking, testChange); err != nil {
+				t.Fatalf("CreateBooking: %v", err)
+			}
+			t.Cleanup(func() { db.DeleteBooking(booking.BookingID, models.AnyVersion, testChange) })
+			created = append(created, booking)
+		}
+		ids := func(page *models.BookingPage) []string {
+			var ids []string
+			for _, booking := range page.Bookings {
+				ids = append(ids, booking.BookingID)
+			}
+			return ids
+		}
+		search := func(t *testing.T, query models.BookingQuery) *models.BookingPage {
+			t.Helper()
+			query.UserID = queryUser
+			if query.SortBy == "" {
+				query.SortBy = models.SortByCreatedAt
+			}
+			if query.Limit == 0 {
+				query.Limit = 10
+			}
+			page, err := db.QueryBookings(query)
+			if err != nil {
+				t.Fatalf("QueryBookings(%+v): %v", query, err)
+			}
+			return page
+		}
+		want := func(t *testing.T, page *models.BookingPage, bookings ...*models.Booking) {
+			t.Helper()
+			got := ids(page)
+			if len(got) != len(bookings) {
+				t.Fatalf("QueryBookings returned %v, want %d bookings", got, len(bookings))
+			}
+			for i, booking := range bookings {
+				if got[i] != booking.BookingID {
+					t.Fatalf("QueryBookings returned %v, want booking %d to be %s", got, i, booking.BookingID)
+				}
+			}
+		}
+
+		want(t, search(t, models.BookingQuery{}), created[0], created[1], created[2])
+		want(t, search(t, models.BookingQuery{Descending: true}), created[2], created[1], created[0])
+		want(t, search(t, models.BookingQuery{Statuses: []string{"confirmed"}}), created[1], created[2])
+		want(t, search(t, models.BookingQuery{ProductTypes: []string{models.ProductFlight}, Channels: []string{models.ChannelWeb}}), created[0], created[2])
+		want(t, search(t, models.BookingQuery{TravelFrom: day(1), TravelTo: day(6)}), created[1])
+		want(t, search(t, models.BookingQuery{CreatedFrom: &created[1].CreatedAt}), created[1], created[2])
+		want(t, search(t, models.BookingQuery{SortBy: models.SortByTravelDate}), created[2], created[1], created[0])
+
+		first := search(t, models.BookingQuery{Limit: 2})
+		want(t, first, created[0], created[1])
+		if first.NextCursor == "" {
+			t.Fatalf("QueryBookings returned no cursor for a partial page")
+		}
+		second := search(t, models.BookingQuery{Limit: 2, Cursor: first.NextCursor})
+		want(t, second, created[2])
+		if second.NextCursor != "" {
+			t.Errorf("QueryBookings returned a cursor on the last page")
+		}
+
+		for _, query := range []models.BookingQuery{
+			{UserID: queryUser, SortBy: models.SortByCreatedAt, Limit: 2, Cursor: "not a cursor"},
+			{UserID: queryUser, SortBy: models.SortByUpdatedAt, Limit: 2, Cursor: first.NextCursor},
+		} {
+			if _, err := db.QueryBookings(query); !errors.Is(err, models.ErrInvalidQuery) {
+				t.Errorf("QueryBookings with cursor %q for %s: got %v, want %v", query.Cursor, query.SortBy, err, models.ErrInvalidQuery)
+			}
+		}
+	})
+
+	t.Run("History", func(t *testing.T) {
+		created := newBooking(t)
+		agent := models.Change{Actor: models.Actor{Type: models.ActorUser, ID: "agent-1"}, Reason: "customer called"}
+		if _, err := db.UpdateBookingStatus(created.BookingID, "confirmed", 1, agent); err != nil {
+			t.Fatalf("UpdateBookingStatus: %v", err)
+		}
+		if _, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, 1, agent); !errors.Is(err, models.ErrVersionConflict) {
+			t.Fatalf("UpdateBooking of an outdated version: got %v, want %v", err, models.ErrVersionConflict)
+		}
+		rebooking := models.Change{Actor: models.Actor{Type: models.ActorService, ID: "flight-booking"}, Reason: "schedule change"}
+		if _, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, 2, rebooking); err != nil {
+			t.Fatalf("UpdateBooking: %v", err)
+		}
+		if _, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, 3, rebooking); err != nil {
+			t.Fatalf("UpdateBooking without changes: %v", err)
+		}
+		if err := db.DeleteBooking(created.BookingID, models.AnyVersion, models.SystemChange("test", "cleanup")); err != nil {
+			t.Fatalf("DeleteBooking: %v", err)
+		}
+
+		history, err := db.GetBookingHistory(created.BookingID)
+		if err != nil {
+			t.Fatalf("GetBookingHistory of a deleted booking: %v", err)
+		}
+		want := []struct {
+			action  models.HistoryAction
+			actor   models.Actor
+			reason  string
+			version int
+			change  models.FieldChange
+		}{
+			{models.HistoryCreated, testChange.Actor, "", 1, models.FieldChange{Field: "flightID", After: "LH1000-20250601"}},
+			{models.HistoryStatusChanged, agent.Actor, "customer called", 2, models.FieldChange{Field: "bookingStatus", Before: "pending", After: "confirmed"}},
+			{models.HistoryUpdated, rebooking.Actor, "schedule change", 3, models.FieldChange{Field: "flightID", Before: "LH1000-20250601", After: "BA2000-20250602"}},
+			{models.HistoryDeleted, models.Actor{Type: models.ActorSystem, ID: "test"}, "cleanup", 4, models.FieldChange{Field: "bookingStatus", Before: "confirmed"}},
+		}
+		if len(history) != len(want) {
+			t.Fatalf("GetBookingHistory returned %d entries, want %d: %+v", len(history), len(want), history)
+		}
+		for i, entry := range history {
+			w := want[i]
+			if entry.BookingID != created.BookingID || entry.UserID != created.UserID || entry.Action != w.action ||
+				entry.ActorType != w.actor.Type || entry.ActorID != w.actor.ID || entry.Reason != w.reason || entry.Version != w.version {
+				t.Errorf("entry %d is %+v, want %s by %+v for %q at version %d", i, entry, w.action, w.actor, w.reason, w.version)
+			}
+			if !containsChange(entry.Changes, w.change) {
+				t.Errorf("entry %d changes %+v, want them to include %+v", i, entry.Changes, w.change)
+			}
+		}
+
+		if _, err := db.GetBookingHistory(uuid.NewString()); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("GetBookingHistory of a missing booking: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		created := newBooking(t)
+		if err := db.DeleteBooking(created.BookingID, created.Version, testChange); err != nil {
+			t.Fatalf("DeleteBooking: %v", err)
+		}
+		if _, err := db.GetBookingByID(created.BookingID); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("GetBookingByID after delete: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+		if err := db.DeleteBooking(created.BookingID, models.AnyVersion, testChange); !errors.Is(err, models.ErrBookingNotFound) {
+			t.Errorf("DeleteBooking twice: got %v, want %v", err, models.ErrBookingNotFound)
+		}
+	})
+}
+
+// sameTime compares timestamps within the microsecond rounding Postgres applies.
+func sameTime(a, b time.Time) bool {
+	return a.Sub(b).Abs() <= time.Microsecond
+}
+
+func containsChange(changes models.FieldChanges, change models.FieldChange) bool {
+	for _, c := range changes {
+		if c == change {
+			return true
+		}
+	}
+	return false
+}
+
+func position(bookings []models.Booking, id string) int {
+	for i, booking := range bookings {
+		if booking.BookingID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// testTripDB runs the behaviour every ports.TripDB implementation must share against db.
+func testTripDB(t *testing.T, db ports.TripDB) {
+	userID := "user-" + uuid.NewString()
+	newTrip := func(t *testing.T) *models.Trip {
+		t.Helper()
+		departure := time.Date(2025, 6, 1, 7, 30, 0, 0, time.UTC)
+		arrival := departure.Add(2 * time.Hour)
+		trip := &models.Trip{
+			TripID:   uuid.NewString(),
+			UserID:   userID,
+			Name:     "Summer in Lisbon",
+			Currency: "EUR",
+			Items: models.TripItems{{
+				ItemID:      uuid.NewString(),
+				Type:        models.TripItemFlight,
+				Title:       "TP 573",
+				Origin:      "FRA",
+				Destination: "LIS",
+				StartTime:   departure,
+				EndTime:     &arrival,
+				Cost:        &models.Money{Amount: 189.90, Currency: "EUR"},
+			}},
+		}
+		if err := db.CreateTrip(trip); err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+		return trip
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		created := newTrip(t)
+		if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+			t.Fatalf("CreateTrip did not set timestamps: %+v", created)
+		}
+
+		stored, err := db.GetTripByID(created.TripID)
+		if err != nil {
+			t.Fatalf("GetTripByID: %v", err)
+		}
+		if stored.UserID != userID || stored.Name != created.Name || stored.Currency != created.Currency {
+			t.Errorf("stored trip %+v does not match created %+v", stored, created)
+		}
+		if len(stored.Items) != 1 {
+			t.Fatalf("stored %d items, want 1", len(stored.Items))
+		}
+		item := stored.Items[0]
+		if item.ItemID != created.Items[0].ItemID || item.Destination != "LIS" ||
+			!item.StartTime.Equal(created.Items[0].StartTime) || item.EndTime == nil ||
+			!item.EndTime.Equal(*created.Items[0].EndTime) || item.Cost == nil || *item.Cost != *created.Items[0].Cost {
+			t.Errorf("stored item %+v does not match created %+v", item, created.Items[0])
+		}
+		if stored.ShareToken != nil {
+			t.Errorf("new trip is shared with token %q", *stored.ShareToken)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		if _, err := db.GetTripByID(uuid.NewString()); !errors.Is(err, models.ErrTripNotFound) {
+			t.Errorf("GetTripByID of a missing trip: got %v, want %v", err, models.ErrTripNotFound)
+		}
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		first := newTrip(t)
+		second := newTrip(t)
+
+		trips, err := db.GetTripsByUserID(userID)
+		if err != nil {
+			t.Fatalf("GetTripsByUserID: %v", err)
+		}
+		firstAt, secondAt := tripPosition(trips, first.TripID), tripPosition(trips, second.TripID)
+		if firstAt < 0 || secondAt < 0 || firstAt > secondAt {
+			t.Errorf("trips %v and %v not listed oldest first: positions %d and %d", first.TripID, second.TripID, firstAt, secondAt)
+		}
+		for _, trip := range trips {
+			if trip.UserID != userID {
+				t.Errorf("trip %s of user %s listed for %s", trip.TripID, trip.UserID, userID)
+			}
+		}
+
+		none, err := db.GetTripsByUserID("user-" + uuid.NewString())
+		if err != nil {
+			t.Fatalf("GetTripsByUserID: %v", err)
+		}
+		if none == nil || len(none) != 0 {
+			t.Errorf("GetTripsByUserID of a user without trips: got %v, want an empty list", none)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		trip := newTrip(t)
+		checkIn := time.Date(2025, 6, 1, 15, 0, 0, 0, time.UTC)
+		checkOut := checkIn.Add(72 * time.Hour)
+		trip.Name = "Lisbon and Porto"
+		trip.Items = append(trip.Items, models.TripItem{
+			ItemID:    uuid.NewString(),
+			Type:      models.TripItemHotel,
+			Title:     "Hotel Avenida",
+			Location:  "LIS",
+			StartTime: checkIn,
+			EndTime:   &checkOut,
+		})
+		if err := db.UpdateTrip(trip); err != nil {
+			t.Fatalf("UpdateTrip: %v", err)
+		}
+
+		stored, err := db.GetTripByID(trip.TripID)
+		if err != nil {
+			t.Fatalf("GetTripByID: %v", err)
+		}
+		if stored.Name != trip.Name || len(stored.Items) != 2 || stored.Items[1].Title != "Hotel Avenida" {
+			t.Errorf("stored trip %+v does not reflect the update", stored)
+		}
+		if stored.UserID != userID {
+			t.Errorf("update changed user to %q", stored.UserID)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		missing := &models.Trip{TripID: uuid.NewString(), UserID: userID, Name: "Nowhere", Currency: "EUR"}
+		if err := db.UpdateTrip(missing); !errors.Is(err, models.ErrTripNotFound) {
+			t.Errorf("UpdateTrip of a missing trip: got %v, want %v", err, models.ErrTripNotFound)
+		}
+	})
+
+	t.Run("Share", func(t *testing.T) {
+		trip := newTrip(t)
+		token := "share-" + uuid.NewString()
+		trip.ShareToken = &token
+		if err := db.UpdateTrip(trip); err != nil {
+			t.Fatalf("UpdateTrip: %v", err)
+		}
+
+		shared, err := db.GetTripByShareToken(token)
+		if err != nil {
+			t.Fatalf("GetTripByShareToken: %v", err)
+		}
+		if shared.TripID != trip.TripID {
+			t.Errorf("share token found trip %s, want %s", shared.TripID, trip.TripID)
+		}
+
+		trip.ShareToken = nil
+		if err := db.UpdateTrip(trip); err != nil {
+			t.Fatalf("UpdateTrip: %v", err)
+		}
+		if _, err := db.GetTripByShareToken(token); !errors.Is(err, models.ErrTripNotFound) {
+			t.Errorf("GetTripByShareToken after sharing stopped: got %v, want %v", err, models.ErrTripNotFound)
+		}
+	})
+}
+
+func tripPosition(trips []models.Trip, id string) int {
+	for i, trip := range trips {
+		if trip.TripID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// testSagaDB runs the behaviour every ports.SagaDB implementation must share against db.
+func testSagaDB(t *testing.T, db ports.SagaDB) {
+	newSaga := func(t *testing.T) *models.Saga {
+		t.Helper()
+		checkIn := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+		saga := &models.Saga{
+			SagaID:    uuid.NewString(),
+			BookingID: uuid.NewString(),
+			UserID:    "user-" + uuid.NewString(),
+			Status:    models.SagaRunning,
+			Request: models.PackageBookingRequest{
+				Flight:  models.FlightReservationRequest{ItineraryID: "itinerary-1", Adults: 2},
+				Hotel:   models.HotelReservationRequest{HotelID: "hotel-1", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 2},
+				Payment: models.PaymentRequest{Method: "tok_visa", Currency: "EUR"},
+			},
+		}
+		for _, step := range models.PackageBookingSteps {
+			saga.Steps = append(saga.Steps, models.SagaStepState{Step: step, Status: models.StepPending})
+		}
+		if err := db.CreateSaga(saga); err != nil {
+			t.Fatalf("CreateSaga: %v", err)
+		}
+		return saga
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		created := newSaga(t)
+		stored, err := db.GetSagaByID(created.SagaID)
+		if err != nil {
+			t.Fatalf("GetSagaByID: %v", err)
+		}
+		if stored.BookingID != created.BookingID || stored.Status != models.SagaRunning ||
+			stored.Request.Hotel.HotelID != "hotel-1" || !stored.Request.Hotel.CheckOut.Equal(created.Request.Hotel.CheckOut) ||
+			len(stored.Steps) != len(models.PackageBookingSteps) || stored.Steps[0].Step != models.StepReserveFlight {
+			t.Errorf("stored saga %+v does not match created %+v", stored, created)
+		}
+		if stored.FlightPrice != nil || stored.Charged != nil {
+			t.Errorf("new saga has prices: %+v", stored)
+		}
+	})
+
+	t.Run("GetByBookingID", func(t *testing.T) {
+		created := newSaga(t)
+		stored, err := db.GetSagaByBookingID(created.BookingID)
+		if err != nil {
+			t.Fatalf("GetSagaByBookingID: %v", err)
+		}
+		if stored.SagaID != created.SagaID {
+			t.Errorf("GetSagaByBookingID returned saga %s, want %s", stored.SagaID, created.SagaID)
+		}
+		if _, err := db.GetSagaByBookingID(uuid.NewString()); !errors.Is(err, models.ErrSagaNotFound) {
+			t.Errorf("GetSagaByBookingID of a booking without saga: got %v, want %v", err, models.ErrSagaNotFound)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		if _, err := db.GetSagaByID(uuid.NewString()); !errors.Is(err, models.ErrSagaNotFound) {
+			t.Errorf("GetSagaByID of a missing saga: got %v, want %v", err, models.ErrSagaNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		saga := newSaga(t)
+		saga.Status = models.SagaCompensating
+		saga.Steps[0].Status = models.StepCompleted
+		saga.Steps[1].Status = models.StepFailed
+		saga.Steps[1].Error = "room sold out"
+		saga.FlightBookingID = "flight-booking-1"
+		saga.FlightPrice = &models.Money{Amount: 320.5, Currency: "EUR"}
+		saga.Error = "reserve_hotel: room sold out"
+		if err := db.UpdateSaga(saga); err != nil {
+			t.Fatalf("UpdateSaga: %v", err)
+		}
+
+		stored, err := db.GetSagaByID(saga.SagaID)
+		if err != nil {
+			t.Fatalf("GetSagaByID: %v", err)
+		}
+		if stored.Status != models.SagaCompensating || stored.Steps[1].Status != models.StepFailed ||
+			stored.Steps[1].Error != "room sold out" || stored.FlightBookingID != "flight-booking-1" ||
+			stored.FlightPrice == nil || *stored.FlightPrice != *saga.FlightPrice || stored.Error != saga.Error {
+			t.Errorf("stored saga %+v does not reflect the update", stored)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		missing := &models.Saga{SagaID: uuid.NewString(), Status: models.SagaRunning}
+		if err := db.UpdateSaga(missing); !errors.Is(err, models.ErrSagaNotFound) {
+			t.Errorf("UpdateSaga of a missing saga: got %v, want %v", err, models.ErrSagaNotFound)
+		}
+	})
+
+	t.Run("GetUnfinished", func(t *testing.T) {
+		running := newSaga(t)
+		compensating := newSaga(t)
+		compensating.Status = models.SagaCompensating
+		completed := newSaga(t)
+		completed.Status = models.SagaCompleted
+		for _, saga := range []*models.Saga{compensating, completed} {
+			if err := db.UpdateSaga(saga); err != nil {
+				t.Fatalf("UpdateSaga: %v", err)
+			}
+		}
+
+		sagas, err := db.GetUnfinishedSagas()
+		if err != nil {
+			t.Fatalf("GetUnfinishedSagas: %v", err)
+		}
+		found := make(map[string]bool)
+		for _, saga := range sagas {
+			if saga.Status.Finished() {
+				t.Errorf("saga %s is %s but listed as unfinished", saga.SagaID, saga.Status)
+			}
+			found[saga.SagaID] = true
+		}
+		if !found[running.SagaID] || !found[compensating.SagaID] {
+			t.Errorf("unfinished sagas %v miss %s or %s", sagas, running.SagaID, compensating.SagaID)
+		}
+	})
+}
+
+// testOutboxDB checks that changes of bookings record their events and that the outbox tracks
+// which ones are published.
+func testOutboxDB(t *testing.T, db interface {
+	ports.BookingDB
+	ports.OutboxDB
+}) {
+	// pending returns the unpublished events of a booking, oldest first.
+	pending := func(t *testing.T, bookingID string) []models.OutboxEvent {
+		t.Helper()
+		events, err := db.GetPendingEvents(1000)
+		if err != nil {
+			t.Fatalf("GetPendingEvents: %v", err)
+		}
+		var ofBooking []models.OutboxEvent
+		for _, event := range events {
+			if event.AggregateID == bookingID {
+				ofBooking = append(ofBooking, event)
+			}
+		}
+		return ofBooking
+	}
+	types := func(events []models.OutboxEvent) []models.EventType {
+		var types []models.EventType
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+	publishAll := func(t *testing.T, events []models.OutboxEvent) {
+		t.Helper()
+		for _, event := range events {
+			if err := db.MarkEventPublished(event.EventID); err != nil {
+				t.Fatalf("MarkEventPublished: %v", err)
+			}
+		}
+	}
+	newBooking := func(t *testing.T) *models.Booking {
+		t.Helper()
+		booking := &models.Booking{
+			BookingID:     uuid.NewString(),
+			UserID:        "user-" + uuid.NewString(),
+			FlightID:      "LH1000-20250601",
+			BookingStatus: "pending",
+		}
+		if err := db.CreateBooking(booking, testChange); err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+		t.Cleanup(func() {
+			db.DeleteBooking(booking.BookingID, models.AnyVersion, testChange)
+			publishAll(t, pending(t, booking.BookingID))
+		})
+		return booking
+	}
+
+	t.Run("Created", func(t *testing.T) {
+		created := newBooking(t)
+		events := pending(t, created.BookingID)
+		if len(events) != 1 || events[0].Type != models.EventBookingCreated {
+			t.Fatalf("CreateBooking recorded %v, want [%s]", types(events), models.EventBookingCreated)
+		}
+		var payload models.BookingEvent
+		if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+			t.Fatalf("decoding payload: %v", err)
+		}
+		if payload.BookingID != created.BookingID || payload.BookingStatus != "pending" || payload.Version != 1 {
+			t.Errorf("BookingCreated payload %+v does not describe %+v", payload, created)
+		}
+	})
+
+	t.Run("StatusChangedAndCancelled", func(t *testing.T) {
+		created := newBooking(t)
+		publishAll(t, pending(t, created.BookingID))
+
+		if _, err := db.UpdateBooking(created.BookingID, &models.Booking{FlightID: "BA2000-20250602"}, models.AnyVersion, testChange); err != nil {
+			t.Fatalf("UpdateBooking: %v", err)
+		}
+		if events := pending(t, created.BookingID); len(events) != 0 {
+			t.Errorf("changing the flight recorded %v, want no events", types(events))
+		}
+		if _, err := db.UpdateBookingStatus(created.BookingID, "confirmed", 1, testChange); !errors.Is(err, models.ErrVersionConflict) {
+			t.Fatalf("UpdateBookingStatus of an outdated version: got %v, want %v", err, models.ErrVersionConflict)
+		}
+		if events := pending(t, created.BookingID); len(events) != 0 {
+			t.Errorf("a conflicting update recorded %v, want no events", types(events))
+		}
+
+		if _, err := db.UpdateBookingStatus(created.BookingID, models.BookingCancelledStatus, 2, testChange); err != nil {
+			t.Fatalf("UpdateBookingStatus: %v", err)
+		}
+		events := pending(t, created.BookingID)
+		if len(events) != 2 || events[0].Type != models.EventBookingStatusChanged || events[1].Type != models.EventBookingCancelled {
+			t.Fatalf("cancelling recorded %v, want [%s %s]", types(events), models.EventBookingStatusChanged, models.EventBookingCancelled)
+		}
+		var payload models.BookingEvent
+		if err := json.Unmarshal(events[0].Payload, &payload); err != nil {
+			t.Fatalf("decoding payload: %v", err)
+		}
+		if payload.PreviousStatus != "pending" || payload.BookingStatus != models.BookingCancelledStatus || payload.Version != 3 {
+			t.Errorf("BookingStatusChanged payload %+v, want pending to cancelled at version 3", payload)
+		}
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		created := newBooking(t)
+		publishAll(t, pending(t, created.BookingID))
+		if err := db.DeleteBooking(created.BookingID, created.Version, testChange); err != nil {
+			t.Fatalf("DeleteBooking: %v", err)
+		}
+		events := pending(t, created.BookingID)
+		if len(events) != 1 || events[0].Type != models.EventBookingCancelled {
+			t.Errorf("DeleteBooking recorded %v, want [%s]", types(events), models.EventBookingCancelled)
+		}
+	})
+
+	t.Run("PublishAndFail", func(t *testing.T) {
+		created := newBooking(t)
+		event := pending(t, created.BookingID)[0]
+
+		if err := db.RecordEventFailure(event.EventID, "bus unavailable"); err != nil {
+			t.Fatalf("RecordEventFailure: %v", err)
+		}
+		failed := pending(t, created.BookingID)
+		if len(failed) != 1 || failed[0].Attempts != 1 || failed[0].LastError != "bus unavailable" {
+			t.Errorf("after a failed publish the outbox holds %+v, want one event with one attempt", failed)
+		}
+
+		if err := db.MarkEventPublished(event.EventID); err != nil {
+			t.Fatalf("MarkEventPublished: %v", err)
+		}
+		if events := pending(t, created.BookingID); len(events) != 0 {
+			t.Errorf("published events are still pending: %v", types(events))
+		}
+
+		if err := db.MarkEventPublished(uuid.NewString()); !errors.Is(err, models.ErrEventNotFound) {
+			t.Errorf("MarkEventPublished of a missing event: got %v, want %v", err, models.ErrEventNotFound)
+		}
+		if err := db.RecordEventFailure(uuid.NewString(), "bus unavailable"); !errors.Is(err, models.ErrEventNotFound) {
+			t.Errorf("RecordEventFailure of a missing event: got %v, want %v", err, models.ErrEventNotFound)
+		}
+	})
+}
+
+// testPromotionDB checks that promotion codes are unique and that redemptions keep to the usage
+// caps of their promotions and give their uses back when reversed.
+func testPromotionDB(t *testing.T, db ports.PromotionDB) {
+	newPromotion := func(t *testing.T, maxUses int, maxUsesPerUser int) *models.Promotion {
+		t.Helper()
+		code := "TEST" + uuid.NewString()[:8]
+		promotion := &models.Promotion{
+			PromotionID:    uuid.NewString(),
+			Code:           &code,
+			Name:           "Conformance test",
+			DiscountType:   models.DiscountPercentage,
+			Value:          10,
+			ProductTypes:   models.StringList{models.ProductHotel},
+			MaxUses:        maxUses,
+			MaxUsesPerUser: maxUsesPerUser,
+			Stackable:      true,
+			Active:         true,
+		}
+		if err := db.CreatePromotion(promotion); err != nil {
+			t.Fatalf("CreatePromotion: %v", err)
+		}
+		return promotion
+	}
+	redemption := func(promotion *models.Promotion, bookingID string, userID string) models.PromotionRedemption {
+		return models.PromotionRedemption{
+			RedemptionID: models.RedemptionID(promotion.PromotionID, bookingID),
+			PromotionID:  promotion.PromotionID,
+			BookingID:    bookingID,
+			UserID:       userID,
+			Code:         *promotion.Code,
+			Discount:     models.Money{Amount: 12.5, Currency: "EUR"},
+			Status:       models.RedemptionActive,
+			CreatedAt:    time.Now().UTC(),
+		}
+	}
+	uses := func(t *testing.T, promotion *models.Promotion) int {
+		t.Helper()
+		stored, err := db.GetPromotionByID(promotion.PromotionID)
+		if err != nil {
+			t.Fatalf("GetPromotionByID: %v", err)
+		}
+		return stored.Uses
+	}
+
+	t.Run("CreateGetAndUpdate", func(t *testing.T) {
+		created := newPromotion(t, 0, 0)
+		stored, err := db.GetPromotionByCode(*created.Code)
+		if err != nil {
+			t.Fatalf("GetPromotionByCode: %v", err)
+		}
+		if stored.PromotionID != created.PromotionID || stored.Value != 10 || len(stored.ProductTypes) != 1 || !stored.Stackable {
+			t.Errorf("stored promotion %+v does not match created %+v", stored, created)
+		}
+
+		duplicate := *created
+		duplicate.PromotionID = uuid.NewString()
+		if err := db.CreatePromotion(&duplicate); !errors.Is(err, models.ErrDuplicatePromotion) {
+			t.Errorf("CreatePromotion with a taken code: got %v, want %v", err, models.ErrDuplicatePromotion)
+		}
+
+		code := *created.Code + "X"
+		updated := *stored
+		updated.Code = &code
+		updated.Value = 15
+		updated.Uses = 99
+		if err := db.UpdatePromotion(&updated); err != nil {
+			t.Fatalf("UpdatePromotion: %v", err)
+		}
+		stored, err = db.GetPromotionByCode(code)
+		if err != nil {
+			t.Fatalf("GetPromotionByCode of the new code: %v", err)
+		}
+		if stored.Value != 15 || stored.Uses != 0 {
+			t.Errorf("updated promotion %+v, want value 15 and its uses left alone", stored)
+		}
+		if _, err := db.GetPromotionByCode(*created.Code); !errors.Is(err, models.ErrPromotionNotFound) {
+			t.Errorf("GetPromotionByCode of the old code: got %v, want %v", err, models.ErrPromotionNotFound)
+		}
+
+		promotions, err := db.GetPromotions()
+		if err != nil {
+			t.Fatalf("GetPromotions: %v", err)
+		}
+		found := false
+		for _, promotion := range promotions {
+			found = found || promotion.PromotionID == created.PromotionID
+		}
+		if !found {
+			t.Errorf("GetPromotions does not list promotion %s", created.PromotionID)
+		}
+		if _, err := db.GetPromotionByID(uuid.NewString()); !errors.Is(err, models.ErrPromotionNotFound) {
+			t.Errorf("GetPromotionByID of a missing promotion: got %v, want %v", err, models.ErrPromotionNotFound)
+		}
+	})
+
+	t.Run("Caps", func(t *testing.T) {
+		promotion := newPromotion(t, 2, 1)
+		user := "user-" + uuid.NewString()
+		first := redemption(promotion, uuid.NewString(), user)
+		if err := db.RedeemPromotions([]models.PromotionRedemption{first}); err != nil {
+			t.Fatalf("RedeemPromotions: %v", err)
+		}
+		if err := db.RedeemPromotions([]models.PromotionRedemption{first}); err != nil {
+			t.Errorf("RedeemPromotions of a recorded redemption: %v", err)
+		}
+		if n := uses(t, promotion); n != 1 {
+			t.Errorf("promotion has %d uses, want 1", n)
+		}
+		if count, err := db.CountRedemptions(promotion.PromotionID, user); err != nil || count != 1 {
+			t.Errorf("CountRedemptions returned %d, %v, want 1", count, err)
+		}
+
+		again := redemption(promotion, uuid.NewString(), user)
+		if err := db.RedeemPromotions([]models.PromotionRedemption{again}); !errors.Is(err, models.ErrPromotionUnavailable) {
+			t.Errorf("RedeemPromotions over the per-user cap: got %v, want %v", err, models.ErrPromotionUnavailable)
+		}
+		if err := db.RedeemPromotions([]models.PromotionRedemption{redemption(promotion, uuid.NewString(), "user-"+uuid.NewString())}); err != nil {
+			t.Fatalf("RedeemPromotions by another user: %v", err)
+		}
+		if err := db.RedeemPromotions([]models.PromotionRedemption{redemption(promotion, uuid.NewString(), "user-"+uuid.NewString())}); !errors.Is(err, models.ErrPromotionUnavailable) {
+			t.Errorf("RedeemPromotions over the global cap: got %v, want %v", err, models.ErrPromotionUnavailable)
+		}
+		if n := uses(t, promotion); n != 2 {
+			t.Errorf("promotion has %d uses, want 2", n)
+		}
+	})
+
+	t.Run("AllOrNothingAndReverse", func(t *testing.T) {
+		open := newPromotion(t, 0, 0)
+		used := newPromotion(t, 1, 0)
+		if err := db.RedeemPromotions([]models.PromotionRedemption{redemption(used, uuid.NewString(), "user-"+uuid.NewString())}); err != nil {
+			t.Fatalf("RedeemPromotions: %v", err)
+		}
+
+		bookingID := uuid.NewString()
+		user := "user-" + uuid.NewString()
+		both := []models.PromotionRedemption{redemption(open, bookingID, user), redemption(used, bookingID, user)}
+		if err := db.RedeemPromotions(both); !errors.Is(err, models.ErrPromotionUnavailable) {
+			t.Fatalf("RedeemPromotions with a used up promotion: got %v, want %v", err, models.ErrPromotionUnavailable)
+		}
+		if n := uses(t, open); n != 0 {
+			t.Errorf("failed RedeemPromotions left %d uses, want 0", n)
+		}
+
+		if err := db.RedeemPromotions(both[:1]); err != nil {
+			t.Fatalf("RedeemPromotions: %v", err)
+		}
+		reversed, err := db.ReverseRedemptions(bookingID)
+		if err != nil {
+			t.Fatalf("ReverseRedemptions: %v", err)
+		}
+		if len(reversed) != 1 || reversed[0].PromotionID != open.PromotionID {
+			t.Errorf("ReverseRedemptions returned %+v, want the redemption of %s", reversed, open.PromotionID)
+		}
+		if reversed, err := db.ReverseRedemptions(bookingID); err != nil || len(reversed) != 0 {
+			t.Errorf("ReverseRedemptions again returned %+v, %v, want nothing", reversed, err)
+		}
+		if n := uses(t, open); n != 0 {
+			t.Errorf("reversed promotion has %d uses, want 0", n)
+		}
+		if count, err := db.CountRedemptions(open.PromotionID, user); err != nil || count != 0 {
+			t.Errorf("CountRedemptions after reversing returned %d, %v, want 0", count, err)
+		}
+
+		redemptions, err := db.GetRedemptionsByBooking(bookingID)
+		if err != nil {
+			t.Fatalf("GetRedemptionsByBooking: %v", err)
+		}
+		if len(redemptions) != 1 || redemptions[0].Status != models.RedemptionReversed || redemptions[0].ReversedAt == nil ||
+			redemptions[0].Discount.Amount != 12.5 {
+			t.Errorf("GetRedemptionsByBooking returned %+v, want one reversed redemption of 12.50", redemptions)
+		}
+	})
+}
+
+// testModificationDB checks that modifications are listed per booking and status and that an
+// update only applies while the modification is still in the status it was read in.
+func testModificationDB(t *testing.T, db ports.ModificationDB) {
+	newModification := func(t *testing.T, bookingID string) *models.Modification {
+		t.Helper()
+		checkIn := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+		modification := &models.Modification{
+			ModificationID: uuid.NewString(),
+			BookingID:      bookingID,
+			SagaID:         uuid.NewString(),
+			UserID:         "user-" + uuid.NewString(),
+			Status:         models.ModificationQuoted,
+			Request:        models.ModificationRequest{Hotel: &models.HotelChange{CheckIn: &checkIn, Guests: 3}},
+			Hotel:          &models.HotelReservationRequest{HotelID: "hotel-1", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 3},
+			HotelHoldID:    "hold-" + uuid.NewString(),
+			HotelPrice:     &models.Money{Amount: 480, Currency: "EUR"},
+			Quote: models.ModificationQuote{
+				Hotel:           &models.ChangeCharges{PriceDifference: 60, ChangeFees: 25, Currency: "EUR"},
+				PriceDifference: 60,
+				ChangeFees:      25,
+				AmountDue:       85,
+				Currency:        "EUR",
+			},
+			RequestedBy: models.Actor{Type: models.ActorUser, ID: "user-1"},
+			ExpiresAt:   time.Now().UTC().Add(15 * time.Minute),
+		}
+		if err := db.CreateModification(modification); err != nil {
+			t.Fatalf("CreateModification: %v", err)
+		}
+		return modification
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		created := newModification(t, uuid.NewString())
+		stored, err := db.GetModificationByID(created.ModificationID)
+		if err != nil {
+			t.Fatalf("GetModificationByID: %v", err)
+		}
+		if stored.BookingID != created.BookingID || stored.Status != models.ModificationQuoted ||
+			stored.Request.Hotel == nil || stored.Request.Hotel.Guests != 3 || stored.Request.Flight != nil ||
+			stored.Hotel == nil || !stored.Hotel.CheckOut.Equal(created.Hotel.CheckOut) ||
+			stored.Quote.Hotel == nil || stored.Quote.AmountDue != 85 || stored.HotelPrice.Amount != 480 {
+			t.Errorf("stored modification %+v does not match created %+v", stored, created)
+		}
+		if _, err := db.GetModificationByID(uuid.NewString()); !errors.Is(err, models.ErrModificationNotFound) {
+			t.Errorf("GetModificationByID of a missing modification: got %v, want %v", err, models.ErrModificationNotFound)
+		}
+	})
+
+	t.Run("ListByBookingAndStatus", func(t *testing.T) {
+		bookingID := uuid.NewString()
+		first := newModification(t, bookingID)
+		second := newModification(t, bookingID)
+		newModification(t, uuid.NewString())
+
+		modifications, err := db.GetModificationsByBooking(bookingID)
+		if err != nil {
+			t.Fatalf("GetModificationsByBooking: %v", err)
+		}
+		if len(modifications) != 2 {
+			t.Fatalf("GetModificationsByBooking returned %d modifications, want 2", len(modifications))
+		}
+		ids := map[string]bool{modifications[0].ModificationID: true, modifications[1].ModificationID: true}
+		if !ids[first.ModificationID] || !ids[second.ModificationID] {
+			t.Errorf("GetModificationsByBooking returned %+v, want %s and %s", modifications, first.ModificationID, second.ModificationID)
+		}
+
+		quoted, err := db.GetModificationsByStatus(models.ModificationQuoted)
+		if err != nil {
+			t.Fatalf("GetModificationsByStatus: %v", err)
+		}
+		found := 0
+		for _, modification := range quoted {
+			if modification.Status != models.ModificationQuoted {
+				t.Errorf("GetModificationsByStatus(quoted) returned a %s modification", modification.Status)
+			}
+			if modification.BookingID == bookingID {
+				found++
+			}
+		}
+		if found != 2 {
+			t.Errorf("GetModificationsByStatus(quoted) returned %d modifications of the booking, want 2", found)
+		}
+	})
+
+	t.Run("UpdateFromStatus", func(t *testing.T) {
+		created := newModification(t, uuid.NewString())
+		claimed := *created
+		claimed.Status = models.ModificationConfirming
+		claimed.PaymentID = "payment-1"
+		if err := db.UpdateModification(&claimed, models.ModificationQuoted); err != nil {
+			t.Fatalf("UpdateModification: %v", err)
+		}
+
+		// A second confirmation read the modification before it was claimed.
+		late := *created
+		late.Status = models.ModificationConfirming
+		if err := db.UpdateModification(&late, models.ModificationQuoted); !errors.Is(err, models.ErrModificationConflict) {
+			t.Errorf("UpdateModification from a stale status: got %v, want %v", err, models.ErrModificationConflict)
+		}
+
+		stored, err := db.GetModificationByID(created.ModificationID)
+		if err != nil {
+			t.Fatalf("GetModificationByID: %v", err)
+		}
+		if stored.Status != models.ModificationConfirming || stored.PaymentID != "payment-1" ||
+			!stored.CreatedAt.Truncate(time.Second).Equal(created.CreatedAt.Truncate(time.Second)) {
+			t.Errorf("updated modification %+v, want confirming with payment-1", stored)
+		}
+
+		missing := *created
+		missing.ModificationID = uuid.NewString()
+		if err := db.UpdateModification(&missing, models.ModificationQuoted); !errors.Is(err, models.ErrModificationNotFound) {
+			t.Errorf("UpdateModification of a missing modification: got %v, want %v", err, models.ErrModificationNotFound)
+		}
+	})
+}
+
+// testInvoiceDB checks that the invoices of a series are numbered without gaps, also when stored
+// concurrently or when one of them cannot be stored, and that they are listed per booking.
+func testInvoiceDB(t *testing.T, db ports.InvoiceDB) {
+	newInvoice := func(issuer string, bookingID string, kind models.InvoiceKind) *models.Invoice {
+		return &models.Invoice{
+			InvoiceID: uuid.NewString(),
+			Kind:      kind,
+			Issuer:    models.LegalEntity{Code: issuer, Name: "Travel GmbH", VATID: "DE123456789"},
+			BookingID: bookingID,
+			UserID:    "user-1",
+			Billing:   models.BillingDetails{Name: "ACME AG", VATID: "DE987654321", Address: models.Address{City: "Berlin", Country: "DE"}},
+			Lines: []models.InvoiceLine{
+				{Description: "Hotel stay", ProductType: models.ProductHotel, NetAmount: 100, TaxRate: 0.07, TaxAmount: 7, GrossAmount: 107},
+			},
+			Taxes:      []models.TaxSummary{{Rate: 0.07, NetAmount: 100, TaxAmount: 7}},
+			NetTotal:   100,
+			TaxTotal:   7,
+			GrossTotal: 107,
+			Currency:   "EUR",
+			IssuedAt:   time.Now().UTC(),
+		}
+	}
+
+	t.Run("NumbersWithoutGaps", func(t *testing.T) {
+		issuer := "T" + uuid.NewString()[:8]
+		first := newInvoice(issuer, uuid.NewString(), models.KindInvoice)
+		if err := db.CreateInvoice(first); err != nil {
+			t.Fatalf("CreateInvoice: %v", err)
+		}
+		if first.Sequence != 1 || first.Number != models.InvoiceNumber(issuer+"-INV", 1) {
+			t.Errorf("first invoice numbered %d %s, want 1", first.Sequence, first.Number)
+		}
+
+		duplicate := newInvoice(issuer, uuid.NewString(), models.KindInvoice)
+		duplicate.InvoiceID = first.InvoiceID
+		if err := db.CreateInvoice(duplicate); !errors.Is(err, models.ErrDuplicateInvoice) {
+			t.Errorf("CreateInvoice with a taken ID: got %v, want %v", err, models.ErrDuplicateInvoice)
+		}
+
+		// Credit notes have a series of their own.
+		credit := newInvoice(issuer, first.BookingID, models.KindCreditNote)
+		if err := db.CreateInvoice(credit); err != nil {
+			t.Fatalf("CreateInvoice of a credit note: %v", err)
+		}
+		if credit.Sequence != 1 {
+			t.Errorf("first credit note numbered %d, want 1", credit.Sequence)
+		}
+
+		var wg sync.WaitGroup
+		created := make([]*models.Invoice, 5)
+		errs := make([]error, 5)
+		for i := range created {
+			created[i] = newInvoice(issuer, uuid.NewString(), models.KindInvoice)
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = db.CreateInvoice(created[i])
+			}(i)
+		}
+		wg.Wait()
+		numbers := map[int]bool{}
+		for i, invoice := range created {
+			if errs[i] != nil {
+				t.Fatalf("concurrent CreateInvoice: %v", errs[i])
+			}
+			numbers[invoice.Sequence] = true
+		}
+		// The taken ID did not use up number 2.
+		for sequence := 2; sequence <= 6; sequence++ {
+			if !numbers[sequence] {
+				t.Errorf("concurrent invoices were numbered %v, want 2 to 6", numbers)
+				break
+			}
+		}
+	})
+
+	t.Run("GetAndList", func(t *testing.T) {
+		issuer := "T" + uuid.NewString()[:8]
+		bookingID := uuid.NewString()
+		invoice := newInvoice(issuer, bookingID, models.KindInvoice)
+		if err := db.CreateInvoice(invoice); err != nil {
+			t.Fatalf("CreateInvoice: %v", err)
+		}
+		credit := newInvoice(issuer, bookingID, models.KindCreditNote)
+		credit.CreditedID = invoice.InvoiceID
+		credit.CreditedNumber = invoice.Number
+		credit.IssuedAt = invoice.IssuedAt.Add(time.Second)
+		if err := db.CreateInvoice(credit); err != nil {
+			t.Fatalf("CreateInvoice: %v", err)
+		}
+		if err := db.CreateInvoice(newInvoice(issuer, uuid.NewString(), models.KindInvoice)); err != nil {
+			t.Fatalf("CreateInvoice: %v", err)
+		}
+
+		if err := db.SetInvoiceDocument(invoice.InvoiceID, "https://bucket/invoice.pdf"); err != nil {
+			t.Fatalf("SetInvoiceDocument: %v", err)
+		}
+		stored, err := db.GetInvoiceByID(invoice.InvoiceID)
+		if err != nil {
+			t.Fatalf("GetInvoiceByID: %v", err)
+		}
+		if stored.Number != invoice.Number || stored.DocumentURL != "https://bucket/invoice.pdf" ||
+			stored.Billing.VATID != "DE987654321" || len(stored.Lines) != 1 || stored.Lines[0].TaxAmount != 7 ||
+			stored.Issuer.Code != issuer || stored.GrossTotal != 107 {
+			t.Errorf("stored invoice %+v does not match created %+v", stored, invoice)
+		}
+
+		invoices, err := db.GetInvoicesByBooking(bookingID)
+		if err != nil {
+			t.Fatalf("GetInvoicesByBooking: %v", err)
+		}
+		if len(invoices) != 2 || invoices[0].InvoiceID != invoice.InvoiceID || invoices[1].CreditedID != invoice.InvoiceID {
+			t.Errorf("GetInvoicesByBooking returned %+v, want the invoice and its credit note", invoices)
+		}
+
+		if _, err := db.GetInvoiceByID(uuid.NewString()); !errors.Is(err, models.ErrInvoiceNotFound) {
+			t.Errorf("GetInvoiceByID of a missing invoice: got %v, want %v", err, models.ErrInvoiceNotFound)
+		}
+		if err := db.SetInvoiceDocument(uuid.NewString(), "https://bucket/missing.pdf"); !errors.Is(err, models.ErrInvoiceNotFound) {
+			t.Errorf("SetInvoiceDocument of a missing invoice: got %v, want %v", err, models.ErrInvoiceNotFound)
+		}
+	})
+}
+
+// testIdempotencyStore runs the behaviour every middleware.IdempotencyStore the booking service
+// stores keys in must share against store.
+func testIdempotencyStore(t *testing.T, store middleware.IdempotencyStore) {
+	newRecord := func(expiresIn time.Duration) *middleware.IdempotencyRecord {
+		now := time.Now().UTC()
+		return &middleware.IdempotencyRecord{
+			Key:         uuid.NewString(),
+			Fingerprint: "fingerprint-" + uuid.NewString(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(expiresIn),
+		}
+	}
+
+	t.Run("ReserveAndComplete", func(t *testing.T) {
+		record := newRecord(time.Hour)
+		if existing, err := store.Reserve(record); err != nil || existing != nil {
+			t.Fatalf("Reserve of a new key: got %v, %v", existing, err)
+		}
+
+		again := *record
+		existing, err := store.Reserve(&again)
+		if err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if existing == nil || existing.Completed || existing.Fingerprint != record.Fingerprint {
+			t.Fatalf("Reserve of a key in progress returned %+v", existing)
+		}
+
+		record.Completed = true
+		record.StatusCode = http.StatusCreated
+		record.Header = http.Header{"Content-Type": {"application/json"}}
+		record.Body = []byte(`{"bookingID":"1"}`)
+		if err := store.Complete(record); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		existing, err = store.Reserve(&again)
+		if err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if existing == nil || !existing.Completed || existing.StatusCode != http.StatusCreated ||
+			string(existing.Body) != string(record.Body) || existing.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Reserve of a completed key returned %+v", existing)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		record := newRecord(time.Hour)
+		if _, err := store.Reserve(record); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		if err := store.Release(record.Key); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if existing, err := store.Reserve(record); err != nil || existing != nil {
+			t.Errorf("Reserve of a released key: got %v, %v", existing, err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		record := newRecord(-time.Minute)
+		if _, err := store.Reserve(record); err != nil {
+			t.Fatalf("Reserve: %v", err)
+		}
+		fresh := *record
+		fresh.Fingerprint = "another request"
+		fresh.ExpiresAt = time.Now().UTC().Add(time.Hour)
+		if existing, err := store.Reserve(&fresh); err != nil || existing != nil {
+			t.Errorf("Reserve of an expired key: got %v, %v", existing, err)
+		}
+	})
+}
+
+// testLeaderLock checks that one holder at a time gets the lease, which others take over once it
+// expired.
+func testLeaderLock(t *testing.T, lock scheduler.LeaderLock) {
+	name := "lock-" + uuid.NewString()
+	now := time.Now().UTC()
+	acquire := func(holder string, at time.Time) bool {
+		t.Helper()
+		held, err := lock.Acquire(name, holder, at, at.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		return held
+	}
+
+	if !acquire("replica-1", now) {
+		t.Fatal("replica-1 did not get a free lock")
+	}
+	if !acquire("replica-1", now.Add(30*time.Second)) {
+		t.Error("replica-1 could not renew its lease")
+	}
+	if acquire("replica-2", now.Add(time.Minute)) {
+		t.Error("replica-2 took over a lease that has not expired")
+	}
+	if !acquire("replica-2", now.Add(2*time.Minute)) {
+		t.Error("replica-2 did not take over an expired lease")
+	}
+	if acquire("replica-1", now.Add(2*time.Minute)) {
+		t.Error("replica-1 took the lock back from replica-2")
+	}
+}
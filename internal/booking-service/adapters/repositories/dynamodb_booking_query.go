@@ -0,0 +1,250 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Bookings carry their sort values a second time as fixed-width strings, which unlike the
+// timestamps themselves sort in time order. A missing travel date sorts as the zero time.
+const (
+	sortCreatedAt  = "sort_created_at"
+	sortUpdatedAt  = "sort_updated_at"
+	sortTravelDate = "sort_travel_date"
+	sortKeyLayout  = "2006-01-02T15:04:05.000000000Z"
+)
+
+// bookingSortKeys are the attributes bookings are sorted by in the indexes, per sort of a query.
+var bookingSortKeys = map[string]string{
+	models.SortByCreatedAt:  sortCreatedAt,
+	models.SortByUpdatedAt:  sortUpdatedAt,
+	models.SortByTravelDate: sortTravelDate,
+}
+
+// bookingIndexes are the indexes of the bookings table: the bookings of a user in every sort
+// order, and the bookings in a status across users, newest or oldest first.
+var bookingIndexes = []string{
+	userIndexBy(sortCreatedAt), userIndexBy(sortUpdatedAt), userIndexBy(sortTravelDate),
+	statusIndexBy(sortCreatedAt),
+}
+
+func userIndexBy(sortKey string) string   { return "user_id-" + sortKey + "-index" }
+func statusIndexBy(sortKey string) string { return "booking_status-" + sortKey + "-index" }
+
+// dynamoCursor is the key of the last booking of a page, where DynamoDB continues the query. Like
+// bookingCursor it remembers the sort it was made for.
+type dynamoCursor struct {
+	SortBy     string            `json:"s"`
+	Descending bool              `json:"d,omitempty"`
+	Key        map[string]string `json:"k"`
+}
+
+// encodeBooking encodes a booking for the bookings table, together with its sort keys.
+func encodeBooking(booking *models.Booking) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(booking)
+	if err != nil {
+		return nil, err
+	}
+	for sortBy, attribute := range bookingSortKeys {
+		item[attribute] = &types.AttributeValueMemberS{Value: sortKey(sortValue(booking, sortBy))}
+	}
+	return item, nil
+}
+
+func sortKey(t time.Time) string {
+	return t.UTC().Format(sortKeyLayout)
+}
+
+// QueryBookings reads one page of bookings through an index, newest or oldest first as the index
+// orders them, and lets DynamoDB apply the other filters. Queries by user may use any sort; queries
+// across users go through the status index, so they need a single status and sort by creation.
+// The cursor is the key DynamoDB stopped at.
+func (r *DynamoDBBookingRepository) QueryBookings(query models.BookingQuery) (*models.BookingPage, error) {
+	sortAttribute := bookingSortKeys[query.SortBy]
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(r.Table),
+		ScanIndexForward:          aws.Bool(!query.Descending),
+		ExpressionAttributeNames:  map[string]string{"#partition": "user_id"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{},
+	}
+	var partition string
+	switch {
+	case query.UserID != "":
+		input.IndexName = aws.String(userIndexBy(sortAttribute))
+		partition = query.UserID
+	case len(query.Statuses) == 1 && query.SortBy == models.SortByCreatedAt:
+		input.IndexName = aws.String(statusIndexBy(sortAttribute))
+		input.ExpressionAttributeNames["#partition"] = "booking_status"
+		partition = query.Statuses[0]
+	default:
+		return nil, fmt.Errorf("%w: searches across users need a single status and the %s sort", models.ErrInvalidQuery, models.SortByCreatedAt)
+	}
+	input.ExpressionAttributeValues[":partition"] = &types.AttributeValueMemberS{Value: partition}
+
+	keyCondition := "#partition = :partition"
+	var from, to *time.Time
+	if query.SortBy == models.SortByCreatedAt {
+		from, to = query.CreatedFrom, query.CreatedTo
+	}
+	if query.SortBy == models.SortByTravelDate {
+		from, to = query.TravelFrom, query.TravelTo
+		if from == nil && to != nil {
+			// Bookings without a travel date only match an open range.
+			after := time.Time{}.Add(time.Nanosecond)
+			from = &after
+		}
+	}
+	if from != nil || to != nil {
+		input.ExpressionAttributeNames["#sort"] = sortAttribute
+	}
+	switch {
+	case from != nil && to != nil:
+		keyCondition += " AND #sort BETWEEN :from AND :to"
+	case from != nil:
+		keyCondition += " AND #sort >= :from"
+	case to != nil:
+		keyCondition += " AND #sort <= :to"
+	}
+	if from != nil {
+		input.ExpressionAttributeValues[":from"] = &types.AttributeValueMemberS{Value: sortKey(*from)}
+	}
+	if to != nil {
+		input.ExpressionAttributeValues[":to"] = &types.AttributeValueMemberS{Value: sortKey(*to)}
+	}
+	input.KeyConditionExpression = aws.String(keyCondition)
+	if filter := bookingFilter(query, input.ExpressionAttributeNames, input.ExpressionAttributeValues); filter != "" {
+		input.FilterExpression = aws.String(filter)
+	}
+
+	startKey, err := decodeDynamoCursor(query, input.ExpressionAttributeNames["#partition"], partition)
+	if err != nil {
+		return nil, err
+	}
+	input.ExclusiveStartKey = startKey
+
+	// Limit counts the items read before filtering, so a page may take several reads. Each asks for
+	// no more than the page still lacks: the read that fills the page ends on its last booking.
+	page := &models.BookingPage{Bookings: []models.Booking{}}
+	for {
+		input.Limit = aws.Int32(int32(query.Limit - len(page.Bookings)))
+		output, err := r.Client.Query(context.TODO(), input)
+		if err != nil {
+			return nil, fmt.Errorf("error querying bookings: %v", err)
+		}
+		for _, item := range output.Items {
+			booking, err := decodeBooking(item)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding bookings: %v", err)
+			}
+			page.Bookings = append(page.Bookings, *booking)
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return page, nil
+		}
+		if len(page.Bookings) == query.Limit {
+			page.NextCursor = encodeDynamoCursor(query, output.LastEvaluatedKey)
+			return page, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// bookingFilter is the filter expression for the parts of a query the index key does not cover;
+// DynamoDB does not filter on the attributes of the key.
+func bookingFilter(query models.BookingQuery, names map[string]string, values map[string]types.AttributeValue) string {
+	var conditions []string
+	in := func(attribute string, options []string) {
+		if len(options) == 0 {
+			return
+		}
+		name := "#" + attribute
+		names[name] = attribute
+		var placeholders []string
+		for i, option := range options {
+			placeholder := ":" + attribute + strconv.Itoa(i)
+			values[placeholder] = &types.AttributeValueMemberS{Value: option}
+			placeholders = append(placeholders, placeholder)
+		}
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", name, strings.Join(placeholders, ", ")))
+	}
+	between := func(attribute string, from, to *time.Time) {
+		name := "#" + attribute
+		if from != nil {
+			names[name] = attribute
+			values[":"+attribute+"_from"] = &types.AttributeValueMemberS{Value: sortKey(*from)}
+			conditions = append(conditions, fmt.Sprintf("%s >= :%s_from", name, attribute))
+		}
+		if to != nil {
+			names[name] = attribute
+			values[":"+attribute+"_to"] = &types.AttributeValueMemberS{Value: sortKey(*to)}
+			conditions = append(conditions, fmt.Sprintf("%s <= :%s_to", name, attribute))
+		}
+	}
+
+	if query.UserID != "" {
+		in("booking_status", query.Statuses)
+	}
+	in("product_type", query.ProductTypes)
+	in("channel", query.Channels)
+	if query.SortBy != models.SortByCreatedAt {
+		between(sortCreatedAt, query.CreatedFrom, query.CreatedTo)
+	}
+	if query.SortBy != models.SortByTravelDate && (query.TravelFrom != nil || query.TravelTo != nil) {
+		// Bookings without a travel date only match an open range.
+		names["#"+sortTravelDate] = sortTravelDate
+		values[":no_travel_date"] = &types.AttributeValueMemberS{Value: sortKey(time.Time{})}
+		conditions = append(conditions, fmt.Sprintf("#%s <> :no_travel_date", sortTravelDate))
+		between(sortTravelDate, query.TravelFrom, query.TravelTo)
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func encodeDynamoCursor(query models.BookingQuery, key map[string]types.AttributeValue) string {
+	cursor := dynamoCursor{SortBy: query.SortBy, Descending: query.Descending, Key: map[string]string{}}
+	for attribute, value := range key {
+		if s, ok := value.(*types.AttributeValueMemberS); ok {
+			cursor.Key[attribute] = s.Value
+		}
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeDynamoCursor reads the cursor of a query into the key to continue after, returning nil for
+// the first page. The cursor must come from a query on the same partition of the same index.
+func decodeDynamoCursor(query models.BookingQuery, partitionAttribute string, partition string) (map[string]types.AttributeValue, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", models.ErrInvalidQuery)
+	}
+	var cursor dynamoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Key["booking_id"] == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", models.ErrInvalidQuery)
+	}
+	if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+		return nil, fmt.Errorf("%w: cursor belongs to a search with another sort order", models.ErrInvalidQuery)
+	}
+	if cursor.Key[partitionAttribute] != partition || cursor.Key[bookingSortKeys[query.SortBy]] == "" {
+		return nil, fmt.Errorf("%w: cursor belongs to another search", models.ErrInvalidQuery)
+	}
+
+	key := map[string]types.AttributeValue{}
+	for attribute, value := range cursor.Key {
+		key[attribute] = &types.AttributeValueMemberS{Value: value}
+	}
+	return key, nil
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// GetBookingHistory lists the changes of a booking, oldest first, including those of a deleted booking.
+func (r *DynamoDBBookingRepository) GetBookingHistory(id string) ([]models.BookingHistoryEntry, error) {
+	history := []models.BookingHistoryEntry{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.HistoryTable),
+		IndexName:                 aws.String(bookingIndex),
+		KeyConditionExpression:    aws.String("booking_id = :booking_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":booking_id": &types.AttributeValueMemberS{Value: id}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching booking history: %v", err)
+		}
+		var entries []models.BookingHistoryEntry
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &entries); err != nil {
+			return nil, fmt.Errorf("error decoding booking history: %v", err)
+		}
+		history = append(history, entries...)
+	}
+	if len(history) == 0 {
+		// Bookings made before changes were recorded have an empty history.
+		if _, err := r.GetBookingByID(id); err != nil {
+			return nil, err
+		}
+	}
+	// The index is not ordered; order the entries the way the Postgres repository returns them.
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].CreatedAt.Equal(history[j].CreatedAt) {
+			return history[i].CreatedAt.Before(history[j].CreatedAt)
+		}
+		return history[i].Version < history[j].Version
+	})
+	return history, nil
+}
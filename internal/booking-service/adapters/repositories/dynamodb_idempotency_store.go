@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/pkg/middlewares"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBIdempotencyStore keeps Idempotency-Key records in a DynamoDB table keyed by
+// idempotency_key. Expired records are overwritten when their key is reused; enabling TTL on the
+// ttl attribute lets DynamoDB delete them.
+type DynamoDBIdempotencyStore struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+func (r *DynamoDBBookingRepository) IdempotencyStore() *DynamoDBIdempotencyStore {
+	return &DynamoDBIdempotencyStore{Client: r.Client, Table: r.IdempotencyTable}
+}
+
+func (s *DynamoDBIdempotencyStore) Reserve(record *middleware.IdempotencyRecord) (*middleware.IdempotencyRecord, error) {
+	item, err := idempotencyItem(record)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:                           aws.String(s.Table),
+		Item:                                item,
+		ConditionExpression:                 aws.String("attribute_not_exists(idempotency_key) OR #ttl < :now"),
+		ExpressionAttributeNames:            map[string]string{"#ttl": "ttl"},
+		ExpressionAttributeValues:           map[string]types.AttributeValue{":now": epoch(time.Now())},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return nil, nil
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionFailed) {
+		return nil, fmt.Errorf("error reserving idempotency key: %v", err)
+	}
+	var existing middleware.IdempotencyRecord
+	if err := attributevalue.UnmarshalMap(conditionFailed.Item, &existing); err != nil {
+		return nil, fmt.Errorf("error decoding idempotency record: %v", err)
+	}
+	return &existing, nil
+}
+
+func (s *DynamoDBIdempotencyStore) Complete(record *middleware.IdempotencyRecord) error {
+	item, err := idempotencyItem(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{TableName: aws.String(s.Table), Item: item})
+	if err != nil {
+		return fmt.Errorf("error storing idempotent response: %v", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBIdempotencyStore) Release(key string) error {
+	_, err := s.Client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.Table),
+		Key:       map[string]types.AttributeValue{"idempotency_key": &types.AttributeValueMemberS{Value: key}},
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing idempotency key: %v", err)
+	}
+	return nil
+}
+
+// idempotencyItem encodes a record with its expiry as epoch seconds in ttl, the format DynamoDB TTL expects.
+func idempotencyItem(record *middleware.IdempotencyRecord) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding idempotency record: %v", err)
+	}
+	item["ttl"] = epoch(record.ExpiresAt)
+	return item, nil
+}
+
+func epoch(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The last number given out in a series of invoices is kept by an item of the invoices table keyed
+// seriesPrefix + series. An invoice is stored in the same transaction that moves the number on, on
+// the condition that no other invoice moved it first.
+const (
+	seriesPrefix = "series#"
+	// seriesAttempts is how often numbering an invoice is tried when other invoices of the series
+	// are being stored at the same time.
+	seriesAttempts = 5
+)
+
+// CreateInvoice numbers and stores a new invoice, setting its timestamps the way GORM does for the
+// Postgres repository.
+func (r *DynamoDBBookingRepository) CreateInvoice(invoice *models.Invoice) error {
+	now := time.Now().UTC()
+	if invoice.CreatedAt.IsZero() {
+		invoice.CreatedAt = now
+	}
+	if invoice.UpdatedAt.IsZero() {
+		invoice.UpdatedAt = now
+	}
+	series := invoice.Series()
+
+	for attempt := 0; attempt < seriesAttempts; attempt++ {
+		last, err := r.lastInvoiceNumber(series)
+		if err != nil {
+			return err
+		}
+		invoice.Sequence = last + 1
+		invoice.Number = models.InvoiceNumber(series, invoice.Sequence)
+		item, err := attributevalue.MarshalMap(invoice)
+		if err != nil {
+			return fmt.Errorf("error encoding invoice: %v", err)
+		}
+
+		values := map[string]types.AttributeValue{":next": &types.AttributeValueMemberN{Value: strconv.Itoa(invoice.Sequence)}}
+		condition := "attribute_not_exists(invoice_id)"
+		if last > 0 {
+			values[":last"] = &types.AttributeValueMemberN{Value: strconv.Itoa(last)}
+			condition = "last_number = :last"
+		}
+		_, err = r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{
+				TableName:                 aws.String(r.InvoicesTable),
+				Key:                       invoiceKey(seriesPrefix + series),
+				UpdateExpression:          aws.String("SET last_number = :next"),
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			}},
+			{Put: &types.Put{
+				TableName:           aws.String(r.InvoicesTable),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(invoice_id)"),
+			}},
+		}})
+		switch {
+		case err == nil:
+			return nil
+		case conditionFailed(err) == 0, transactionConflict(err):
+			continue
+		case conditionFailed(err) == 1:
+			invoice.Sequence, invoice.Number = 0, ""
+			return models.ErrDuplicateInvoice
+		default:
+			invoice.Sequence, invoice.Number = 0, ""
+			return fmt.Errorf("error creating invoice: %v", err)
+		}
+	}
+	invoice.Sequence, invoice.Number = 0, ""
+	return fmt.Errorf("error creating invoice: series %s kept changing", series)
+}
+
+// lastInvoiceNumber returns the last number given out in a series, 0 for a new series.
+func (r *DynamoDBBookingRepository) lastInvoiceNumber(series string) (int, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.InvoicesTable),
+		Key:            invoiceKey(seriesPrefix + series),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading invoice series: %v", err)
+	}
+	var sequence struct {
+		LastNumber int `dynamodbav:"last_number"`
+	}
+	if output.Item != nil {
+		if err := attributevalue.UnmarshalMap(output.Item, &sequence); err != nil {
+			return 0, fmt.Errorf("error decoding invoice series: %v", err)
+		}
+	}
+	return sequence.LastNumber, nil
+}
+
+func (r *DynamoDBBookingRepository) GetInvoiceByID(id string) (*models.Invoice, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.InvoicesTable),
+		Key:            invoiceKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching invoice: %v", err)
+	}
+	if output.Item == nil || strings.HasPrefix(id, seriesPrefix) {
+		return nil, models.ErrInvoiceNotFound
+	}
+
+	var invoice models.Invoice
+	if err := attributevalue.UnmarshalMap(output.Item, &invoice); err != nil {
+		return nil, fmt.Errorf("error decoding invoice: %v", err)
+	}
+	return &invoice, nil
+}
+
+func (r *DynamoDBBookingRepository) GetInvoicesByBooking(bookingID string) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.InvoicesTable),
+		IndexName:                 aws.String(bookingIndex),
+		KeyConditionExpression:    aws.String("booking_id = :booking"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":booking": &types.AttributeValueMemberS{Value: bookingID}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching invoices: %v", err)
+		}
+		var items []models.Invoice
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("error decoding invoices: %v", err)
+		}
+		invoices = append(invoices, items...)
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		if !invoices[i].IssuedAt.Equal(invoices[j].IssuedAt) {
+			return invoices[i].IssuedAt.Before(invoices[j].IssuedAt)
+		}
+		return invoices[i].InvoiceID < invoices[j].InvoiceID
+	})
+	return invoices, nil
+}
+
+func (r *DynamoDBBookingRepository) SetInvoiceDocument(id string, documentURL string) error {
+	_, err := r.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.InvoicesTable),
+		Key:                 invoiceKey(id),
+		UpdateExpression:    aws.String("SET document_url = :url, updated_at = :now"),
+		ConditionExpression: aws.String("attribute_exists(booking_id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":url": &types.AttributeValueMemberS{Value: documentURL},
+			":now": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return models.ErrInvoiceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error updating invoice: %v", err)
+	}
+	return nil
+}
+
+// transactionConflict reports whether a transaction was cancelled because another one was writing
+// the same items.
+func transactionConflict(err error) bool {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return false
+	}
+	for _, reason := range cancelled.CancellationReasons {
+		if aws.ToString(reason.Code) == "TransactionConflict" {
+			return true
+		}
+	}
+	return false
+}
+
+func invoiceKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"invoice_id": &types.AttributeValueMemberS{Value: id}}
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBLeaderLock keeps the leases of scheduler leader locks in a DynamoDB table keyed by name,
+// shared by the replicas of the service.
+type DynamoDBLeaderLock struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+func (r *DynamoDBBookingRepository) LeaderLock() *DynamoDBLeaderLock {
+	return &DynamoDBLeaderLock{Client: r.Client, Table: r.LocksTable}
+}
+
+func (l *DynamoDBLeaderLock) Acquire(name string, holder string, now time.Time, until time.Time) (bool, error) {
+	// The condition and the write are one request, so two replicas cannot both take over.
+	_, err := l.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(l.Table),
+		Item: map[string]types.AttributeValue{
+			"name":       &types.AttributeValueMemberS{Value: name},
+			"holder":     &types.AttributeValueMemberS{Value: holder},
+			"expires_at": epoch(until),
+		},
+		ConditionExpression:      aws.String("attribute_not_exists(#name) OR holder = :holder OR expires_at < :now"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":holder": &types.AttributeValueMemberS{Value: holder},
+			":now":    epoch(now),
+		},
+	})
+	if err == nil {
+		return true, nil
+	}
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return false, nil
+	}
+	return false, fmt.Errorf("error acquiring lock %s: %v", name, err)
+}
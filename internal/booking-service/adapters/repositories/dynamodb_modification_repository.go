@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CreateModification stores a new modification, setting its timestamps the way GORM does for the
+// Postgres repository.
+func (r *DynamoDBBookingRepository) CreateModification(modification *models.Modification) error {
+	now := time.Now().UTC()
+	if modification.CreatedAt.IsZero() {
+		modification.CreatedAt = now
+	}
+	if modification.UpdatedAt.IsZero() {
+		modification.UpdatedAt = now
+	}
+	item, err := attributevalue.MarshalMap(modification)
+	if err != nil {
+		return fmt.Errorf("error encoding modification: %v", err)
+	}
+	_, err = r.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.ModificationsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(modification_id)"),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating modification: %v", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) GetModificationByID(id string) (*models.Modification, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.ModificationsTable),
+		Key:            map[string]types.AttributeValue{"modification_id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching modification: %v", err)
+	}
+	if output.Item == nil {
+		return nil, models.ErrModificationNotFound
+	}
+
+	var modification models.Modification
+	if err := attributevalue.UnmarshalMap(output.Item, &modification); err != nil {
+		return nil, fmt.Errorf("error decoding modification: %v", err)
+	}
+	return &modification, nil
+}
+
+func (r *DynamoDBBookingRepository) GetModificationsByBooking(bookingID string) ([]models.Modification, error) {
+	return r.queryModifications(bookingIndex, "booking_id", bookingID)
+}
+
+func (r *DynamoDBBookingRepository) GetModificationsByStatus(status models.ModificationStatus) ([]models.Modification, error) {
+	return r.queryModifications(statusIndex, "status", string(status))
+}
+
+func (r *DynamoDBBookingRepository) queryModifications(index string, attribute string, value string) ([]models.Modification, error) {
+	modifications := []models.Modification{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.ModificationsTable),
+		IndexName:                 aws.String(index),
+		KeyConditionExpression:    aws.String("#attribute = :value"),
+		ExpressionAttributeNames:  map[string]string{"#attribute": attribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":value": &types.AttributeValueMemberS{Value: value}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching modifications: %v", err)
+		}
+		var items []models.Modification
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("error decoding modifications: %v", err)
+		}
+		modifications = append(modifications, items...)
+	}
+	sort.Slice(modifications, func(i, j int) bool {
+		if !modifications[i].CreatedAt.Equal(modifications[j].CreatedAt) {
+			return modifications[i].CreatedAt.Before(modifications[j].CreatedAt)
+		}
+		return modifications[i].ModificationID < modifications[j].ModificationID
+	})
+	return modifications, nil
+}
+
+func (r *DynamoDBBookingRepository) UpdateModification(modification *models.Modification, from models.ModificationStatus) error {
+	modification.UpdatedAt = time.Now().UTC()
+	item, err := attributevalue.MarshalMap(modification)
+	if err != nil {
+		return fmt.Errorf("error encoding modification: %v", err)
+	}
+	_, err = r.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:                 aws.String(r.ModificationsTable),
+		Item:                      item,
+		ConditionExpression:       aws.String("#status = :from"),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":from": &types.AttributeValueMemberS{Value: string(from)}},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		if _, err := r.GetModificationByID(modification.ModificationID); err != nil {
+			return err
+		}
+		return models.ErrModificationConflict
+	}
+	if err != nil {
+		return fmt.Errorf("error updating modification: %v", err)
+	}
+	return nil
+}
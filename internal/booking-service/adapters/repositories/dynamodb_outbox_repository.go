@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pendingMarker is the value of the pending attribute that puts an unpublished event in pendingIndex.
+const pendingMarker = "pending"
+
+// encodeOutboxEvent stores an unpublished event so that it appears in pendingIndex.
+func encodeOutboxEvent(event *models.OutboxEvent) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(event)
+	if err != nil {
+		return nil, err
+	}
+	item["pending"] = &types.AttributeValueMemberS{Value: pendingMarker}
+	return item, nil
+}
+
+func (r *DynamoDBBookingRepository) GetPendingEvents(limit int) ([]models.OutboxEvent, error) {
+	events := []models.OutboxEvent{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.OutboxTable),
+		IndexName:                 aws.String(pendingIndex),
+		KeyConditionExpression:    aws.String("pending = :pending"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pending": &types.AttributeValueMemberS{Value: pendingMarker}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching pending events: %v", err)
+		}
+		var items []models.OutboxEvent
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("error decoding events: %v", err)
+		}
+		events = append(events, items...)
+	}
+	// The index is not ordered; order the events the way the Postgres repository returns them.
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].EventID < events[j].EventID
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// MarkEventPublished records when an event was published and takes it out of pendingIndex.
+func (r *DynamoDBBookingRepository) MarkEventPublished(id string) error {
+	publishedAt, err := attributevalue.Marshal(time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error encoding event: %v", err)
+	}
+	err = r.updateEvent(id, "SET published_at = :published_at REMOVE pending, last_error",
+		map[string]types.AttributeValue{":published_at": publishedAt})
+	if err != nil && !errors.Is(err, models.ErrEventNotFound) {
+		return fmt.Errorf("error marking event published: %v", err)
+	}
+	return err
+}
+
+func (r *DynamoDBBookingRepository) RecordEventFailure(id string, reason string) error {
+	err := r.updateEvent(id, "SET attempts = attempts + :one, last_error = :reason",
+		map[string]types.AttributeValue{
+			":one":    &types.AttributeValueMemberN{Value: "1"},
+			":reason": &types.AttributeValueMemberS{Value: reason},
+		})
+	if err != nil && !errors.Is(err, models.ErrEventNotFound) {
+		return fmt.Errorf("error recording event failure: %v", err)
+	}
+	return err
+}
+
+func (r *DynamoDBBookingRepository) updateEvent(id string, update string, values map[string]types.AttributeValue) error {
+	_, err := r.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.OutboxTable),
+		Key:                       map[string]types.AttributeValue{"event_id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(event_id)"),
+		ExpressionAttributeValues: values,
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return models.ErrEventNotFound
+	}
+	return err
+}
@@ -0,0 +1,455 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB has no unique indexes: the code of a promotion is claimed by an item of the promotions
+// table keyed codeClaimPrefix + code, which also finds the promotion by its code. The redemptions
+// of each user are counted by an item of the redemptions table keyed userUsesPrefix +
+// promotion ID + "/" + user ID, so that caps are checked within the redeeming transaction.
+const (
+	codeClaimPrefix = "code#"
+	userUsesPrefix  = "uses#"
+)
+
+// codeClaim is the item claiming the code of a promotion.
+type codeClaim struct {
+	Key         string `dynamodbav:"promotion_id"`
+	PromotionID string `dynamodbav:"claimed_by"`
+}
+
+// CreatePromotion stores a new promotion, claiming its code, and sets its timestamps the way GORM
+// does for the Postgres repository.
+func (r *DynamoDBBookingRepository) CreatePromotion(promotion *models.Promotion) error {
+	now := time.Now().UTC()
+	if promotion.CreatedAt.IsZero() {
+		promotion.CreatedAt = now
+	}
+	if promotion.UpdatedAt.IsZero() {
+		promotion.UpdatedAt = now
+	}
+
+	item, err := attributevalue.MarshalMap(promotion)
+	if err != nil {
+		return fmt.Errorf("error encoding promotion: %v", err)
+	}
+	writes := []types.TransactWriteItem{{Put: &types.Put{
+		TableName:           aws.String(r.PromotionsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(promotion_id)"),
+	}}}
+	if promotion.Code != nil {
+		claim, err := r.claimCode(*promotion.Code, promotion.PromotionID)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, claim)
+	}
+	if _, err := r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: writes}); err != nil {
+		if conditionFailed(err) >= 0 {
+			return models.ErrDuplicatePromotion
+		}
+		return fmt.Errorf("error creating promotion: %v", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) GetPromotionByID(id string) (*models.Promotion, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.PromotionsTable),
+		Key:            promotionKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching promotion: %v", err)
+	}
+	if output.Item == nil || strings.HasPrefix(id, codeClaimPrefix) {
+		return nil, models.ErrPromotionNotFound
+	}
+
+	var promotion models.Promotion
+	if err := attributevalue.UnmarshalMap(output.Item, &promotion); err != nil {
+		return nil, fmt.Errorf("error decoding promotion: %v", err)
+	}
+	return &promotion, nil
+}
+
+func (r *DynamoDBBookingRepository) GetPromotionByCode(code string) (*models.Promotion, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.PromotionsTable),
+		Key:            promotionKey(codeClaimPrefix + code),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching promotion: %v", err)
+	}
+	if output.Item == nil {
+		return nil, models.ErrPromotionNotFound
+	}
+	var claim codeClaim
+	if err := attributevalue.UnmarshalMap(output.Item, &claim); err != nil {
+		return nil, fmt.Errorf("error decoding promotion code: %v", err)
+	}
+	return r.GetPromotionByID(claim.PromotionID)
+}
+
+func (r *DynamoDBBookingRepository) GetPromotions() ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	paginator := dynamodb.NewScanPaginator(r.Client, &dynamodb.ScanInput{
+		TableName:                 aws.String(r.PromotionsTable),
+		FilterExpression:          aws.String("NOT begins_with(promotion_id, :claim)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":claim": &types.AttributeValueMemberS{Value: codeClaimPrefix}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching promotions: %v", err)
+		}
+		var items []models.Promotion
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("error decoding promotions: %v", err)
+		}
+		promotions = append(promotions, items...)
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		if !promotions[i].CreatedAt.Equal(promotions[j].CreatedAt) {
+			return promotions[i].CreatedAt.Before(promotions[j].CreatedAt)
+		}
+		return promotions[i].PromotionID < promotions[j].PromotionID
+	})
+	return promotions, nil
+}
+
+// UpdatePromotion sets every rule of a promotion but its uses, which redemptions change
+// concurrently, and moves the claim of its code when the code changed.
+func (r *DynamoDBBookingRepository) UpdatePromotion(promotion *models.Promotion) error {
+	stored, err := r.GetPromotionByID(promotion.PromotionID)
+	if err != nil {
+		return err
+	}
+	promotion.UpdatedAt = time.Now().UTC()
+	item, err := attributevalue.MarshalMap(promotion)
+	if err != nil {
+		return fmt.Errorf("error encoding promotion: %v", err)
+	}
+
+	var sets, removes []string
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	for _, attribute := range []string{"code", "name", "discount_type", "value", "currency", "min_spend", "product_types",
+		"destinations", "travel_from", "travel_until", "starts_at", "ends_at", "max_uses", "max_uses_per_user",
+		"stackable", "active", "updated_at"} {
+		name := "#" + attribute
+		names[name] = attribute
+		if value, ok := item[attribute]; ok {
+			values[":"+attribute] = value
+			sets = append(sets, name+" = :"+attribute)
+		} else {
+			removes = append(removes, name)
+		}
+	}
+	expression := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		expression += " REMOVE " + strings.Join(removes, ", ")
+	}
+	writes := []types.TransactWriteItem{{Update: &types.Update{
+		TableName:                 aws.String(r.PromotionsTable),
+		Key:                       promotionKey(promotion.PromotionID),
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       aws.String("attribute_exists(promotion_id)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}}}
+
+	oldCode, newCode := aws.ToString(stored.Code), aws.ToString(promotion.Code)
+	if newCode != oldCode {
+		if newCode != "" {
+			claim, err := r.claimCode(newCode, promotion.PromotionID)
+			if err != nil {
+				return err
+			}
+			writes = append(writes, claim)
+		}
+		if oldCode != "" {
+			writes = append(writes, types.TransactWriteItem{Delete: &types.Delete{
+				TableName: aws.String(r.PromotionsTable),
+				Key:       promotionKey(codeClaimPrefix + oldCode),
+			}})
+		}
+	}
+	if _, err := r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: writes}); err != nil {
+		switch conditionFailed(err) {
+		case 0:
+			return models.ErrPromotionNotFound
+		case 1:
+			return models.ErrDuplicatePromotion
+		}
+		return fmt.Errorf("error updating promotion: %v", err)
+	}
+	promotion.Uses = stored.Uses
+	promotion.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) CountRedemptions(promotionID string, userID string) (int, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.RedemptionsTable),
+		Key:            redemptionKey(userUsesKey(promotionID, userID)),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting redemptions: %v", err)
+	}
+	var counter struct {
+		Uses int `dynamodbav:"uses"`
+	}
+	if err := attributevalue.UnmarshalMap(output.Item, &counter); err != nil {
+		return 0, fmt.Errorf("error decoding redemption count: %v", err)
+	}
+	return counter.Uses, nil
+}
+
+// RedeemPromotions counts every redemption against its promotion and the user's uses of it in one
+// transaction, conditional on the caps, so that concurrent redemptions cannot exceed them.
+func (r *DynamoDBBookingRepository) RedeemPromotions(redemptions []models.PromotionRedemption) error {
+	var writes []types.TransactWriteItem
+	var promotions []*models.Promotion
+	for _, redemption := range redemptions {
+		existing, err := r.getRedemption(redemption.RedemptionID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Status == models.RedemptionActive {
+			continue
+		}
+		promotion, err := r.GetPromotionByID(redemption.PromotionID)
+		if err != nil {
+			return err
+		}
+
+		redemption.Status = models.RedemptionActive
+		redemption.ReversedAt = nil
+		item, err := attributevalue.MarshalMap(redemption)
+		if err != nil {
+			return fmt.Errorf("error encoding redemption: %v", err)
+		}
+		userUses := &types.Update{
+			TableName:        aws.String(r.RedemptionsTable),
+			Key:              redemptionKey(userUsesKey(promotion.PromotionID, redemption.UserID)),
+			UpdateExpression: aws.String("SET uses = if_not_exists(uses, :zero) + :one"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":zero": &types.AttributeValueMemberN{Value: "0"},
+				":one":  &types.AttributeValueMemberN{Value: "1"},
+			},
+		}
+		if promotion.MaxUsesPerUser > 0 {
+			userUses.ConditionExpression = aws.String("attribute_not_exists(uses) OR uses < :cap")
+			userUses.ExpressionAttributeValues[":cap"] = &types.AttributeValueMemberN{Value: strconv.Itoa(promotion.MaxUsesPerUser)}
+		}
+		writes = append(writes,
+			types.TransactWriteItem{Update: &types.Update{
+				TableName:           aws.String(r.PromotionsTable),
+				Key:                 promotionKey(promotion.PromotionID),
+				UpdateExpression:    aws.String("SET uses = if_not_exists(uses, :zero) + :one"),
+				ConditionExpression: aws.String("attribute_exists(promotion_id) AND (max_uses = :zero OR attribute_not_exists(max_uses) OR uses < max_uses)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":zero": &types.AttributeValueMemberN{Value: "0"},
+					":one":  &types.AttributeValueMemberN{Value: "1"},
+				},
+			}},
+			types.TransactWriteItem{Update: userUses},
+			types.TransactWriteItem{Put: &types.Put{
+				TableName:                 aws.String(r.RedemptionsTable),
+				Item:                      item,
+				ConditionExpression:       aws.String("attribute_not_exists(redemption_id) OR #status = :reversed"),
+				ExpressionAttributeNames:  map[string]string{"#status": "status"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":reversed": &types.AttributeValueMemberS{Value: string(models.RedemptionReversed)}},
+			}},
+		)
+		promotions = append(promotions, promotion)
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	_, err := r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+	if err != nil {
+		failed := conditionFailed(err)
+		if failed < 0 {
+			return fmt.Errorf("error redeeming promotions: %v", err)
+		}
+		promotion := promotions[failed/3]
+		switch failed % 3 {
+		case 0:
+			return fmt.Errorf("%w: %s reached its limit of %d uses", models.ErrPromotionUnavailable, promotion.Name, promotion.MaxUses)
+		case 1:
+			return fmt.Errorf("%w: %s can be used %d times per traveller", models.ErrPromotionUnavailable, promotion.Name, promotion.MaxUsesPerUser)
+		default:
+			return fmt.Errorf("error redeeming promotions: %s was redeemed concurrently", promotion.Name)
+		}
+	}
+	return nil
+}
+
+// ReverseRedemptions reverses each active redemption of a booking in a transaction of its own,
+// conditional on it still being active, so that a redemption is never given back twice.
+func (r *DynamoDBBookingRepository) ReverseRedemptions(bookingID string) ([]models.PromotionRedemption, error) {
+	redemptions, err := r.GetRedemptionsByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	reversed := []models.PromotionRedemption{}
+	now := time.Now().UTC()
+	for _, redemption := range redemptions {
+		if redemption.Status != models.RedemptionActive {
+			continue
+		}
+		reversedAt, err := attributevalue.Marshal(now)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding redemption: %v", err)
+		}
+		decrement := map[string]types.AttributeValue{
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+		}
+		writes := []types.TransactWriteItem{
+			{Update: &types.Update{
+				TableName:                aws.String(r.RedemptionsTable),
+				Key:                      redemptionKey(redemption.RedemptionID),
+				UpdateExpression:         aws.String("SET #status = :reversed, reversed_at = :reversed_at"),
+				ConditionExpression:      aws.String("#status = :active"),
+				ExpressionAttributeNames: map[string]string{"#status": "status"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":reversed":    &types.AttributeValueMemberS{Value: string(models.RedemptionReversed)},
+					":active":      &types.AttributeValueMemberS{Value: string(models.RedemptionActive)},
+					":reversed_at": reversedAt,
+				},
+			}},
+			{Update: &types.Update{
+				TableName:                 aws.String(r.PromotionsTable),
+				Key:                       promotionKey(redemption.PromotionID),
+				UpdateExpression:          aws.String("SET uses = uses - :one"),
+				ConditionExpression:       aws.String("uses > :zero"),
+				ExpressionAttributeValues: decrement,
+			}},
+			{Update: &types.Update{
+				TableName:                 aws.String(r.RedemptionsTable),
+				Key:                       redemptionKey(userUsesKey(redemption.PromotionID, redemption.UserID)),
+				UpdateExpression:          aws.String("SET uses = uses - :one"),
+				ConditionExpression:       aws.String("uses > :zero"),
+				ExpressionAttributeValues: decrement,
+			}},
+		}
+		_, err = r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+		if conditionFailed(err) == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reversing redemptions: %v", err)
+		}
+		redemption.Status = models.RedemptionReversed
+		redemption.ReversedAt = &now
+		reversed = append(reversed, redemption)
+	}
+	return reversed, nil
+}
+
+func (r *DynamoDBBookingRepository) GetRedemptionsByBooking(bookingID string) ([]models.PromotionRedemption, error) {
+	redemptions := []models.PromotionRedemption{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.RedemptionsTable),
+		IndexName:                 aws.String(bookingIndex),
+		KeyConditionExpression:    aws.String("booking_id = :booking_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":booking_id": &types.AttributeValueMemberS{Value: bookingID}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("error fetching redemptions: %v", err)
+		}
+		var items []models.PromotionRedemption
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("error decoding redemptions: %v", err)
+		}
+		redemptions = append(redemptions, items...)
+	}
+	sort.Slice(redemptions, func(i, j int) bool {
+		if !redemptions[i].CreatedAt.Equal(redemptions[j].CreatedAt) {
+			return redemptions[i].CreatedAt.Before(redemptions[j].CreatedAt)
+		}
+		return redemptions[i].RedemptionID < redemptions[j].RedemptionID
+	})
+	return redemptions, nil
+}
+
+func (r *DynamoDBBookingRepository) getRedemption(id string) (*models.PromotionRedemption, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.RedemptionsTable),
+		Key:            redemptionKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching redemption: %v", err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+	var redemption models.PromotionRedemption
+	if err := attributevalue.UnmarshalMap(output.Item, &redemption); err != nil {
+		return nil, fmt.Errorf("error decoding redemption: %v", err)
+	}
+	return &redemption, nil
+}
+
+// claimCode is the write claiming a code for a promotion, failing when another one has it.
+func (r *DynamoDBBookingRepository) claimCode(code string, promotionID string) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(codeClaim{Key: codeClaimPrefix + code, PromotionID: promotionID})
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("error encoding promotion code: %v", err)
+	}
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(r.PromotionsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(promotion_id)"),
+	}}, nil
+}
+
+// conditionFailed returns the position of the write whose condition cancelled a transaction, or
+// -1 when err is not such a cancellation.
+func conditionFailed(err error) int {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return -1
+	}
+	for i, reason := range cancelled.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return i
+		}
+	}
+	return -1
+}
+
+func promotionKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"promotion_id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func redemptionKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"redemption_id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func userUsesKey(promotionID string, userID string) string {
+	return userUsesPrefix + promotionID + "/" + userID
+}
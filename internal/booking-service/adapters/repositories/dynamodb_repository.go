@@ -1,30 +1,419 @@
 package repositories
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// userIndex is the global secondary index trips are looked up by user through.
+	userIndex = "user_id-index"
+	// shareTokenIndex is the sparse index of the trips that are currently shared.
+	shareTokenIndex = "share_token-index"
+	// statusIndex is the index unfinished sagas are found through after a restart.
+	statusIndex = "status-index"
+	// pendingIndex is the sparse index of the outbox events that are not published yet.
+	pendingIndex = "pending-index"
+	// bookingIndex is the index the records kept about a booking are read through.
+	bookingIndex = "booking_id-index"
 )
 
+// DynamoDBOptions name the tables of the repository and tell how to reach them. Each table is keyed
+// by a single string attribute.
+type DynamoDBOptions struct {
+	Table              string // Bookings, keyed by booking_id.
+	TripsTable         string // Trips, keyed by trip_id.
+	SagasTable         string // Package booking sagas, keyed by saga_id.
+	OutboxTable        string // Booking events awaiting publication, keyed by event_id.
+	HistoryTable       string // Booking history entries, keyed by entry_id.
+	PromotionsTable    string // Promotions, keyed by promotion_id.
+	RedemptionsTable   string // Promotion redemptions, keyed by redemption_id.
+	ModificationsTable string // Booking modifications, keyed by modification_id.
+	InvoicesTable      string // Invoices and credit notes, keyed by invoice_id.
+	IdempotencyTable   string // Idempotency-Key records, keyed by idempotency_key.
+	LocksTable         string // Leases of scheduler leader locks, keyed by name.
+	Region             string
+	// Endpoint overrides the AWS endpoint, e.g. http://localhost:8000 for DynamoDB Local.
+	Endpoint string
+	// AccessKeyID and SecretAccessKey are optional; the default AWS credential chain is used without them.
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// DynamoDBBookingRepository stores bookings and the records kept about them in the DynamoDB
+// tables named by DynamoDBOptions.
 type DynamoDBBookingRepository struct {
+	Client             *dynamodb.Client
+	Table              string
+	TripsTable         string
+	SagasTable         string
+	OutboxTable        string
+	HistoryTable       string
+	PromotionsTable    string
+	RedemptionsTable   string
+	ModificationsTable string
+	InvoicesTable      string
+	IdempotencyTable   string
+	LocksTable         string
 }
 
-func NewDynamoDBRepository() *DynamoDBBookingRepository {
-	return &DynamoDBBookingRepository{}
+func NewDynamoDBRepository(options DynamoDBOptions) (*DynamoDBBookingRepository, error) {
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(options.Region)}
+	if options.AccessKeyID != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(options.AccessKeyID, options.SecretAccessKey, "")))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS config: %v", err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if options.Endpoint != "" {
+			o.BaseEndpoint = aws.String(options.Endpoint)
+		}
+	})
+	return &DynamoDBBookingRepository{
+		Client:             client,
+		Table:              options.Table,
+		TripsTable:         options.TripsTable,
+		SagasTable:         options.SagasTable,
+		OutboxTable:        options.OutboxTable,
+		HistoryTable:       options.HistoryTable,
+		PromotionsTable:    options.PromotionsTable,
+		RedemptionsTable:   options.RedemptionsTable,
+		ModificationsTable: options.ModificationsTable,
+		InvoicesTable:      options.InvoicesTable,
+		IdempotencyTable:   options.IdempotencyTable,
+		LocksTable:         options.LocksTable,
+	}, nil
 }
 
-func (r *DynamoDBBookingRepository) CreateHotel(hotel *models.Hotel) (*models.Hotel, error) {
-	fmt.Println("Hotel created in DynamoDB")
-	return hotel, nil
+// CreateTables creates the tables of the repository with their indexes unless they already exist,
+// and waits until they are active. Deployments normally provision the tables; this serves DynamoDB
+// Local.
+func (r *DynamoDBBookingRepository) CreateTables() error {
+	if err := r.createTable(r.Table, "booking_id", bookingIndexes...); err != nil {
+		return err
+	}
+	if err := r.createTable(r.TripsTable, "trip_id", userIndex, shareTokenIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.SagasTable, "saga_id", statusIndex, bookingIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.OutboxTable, "event_id", pendingIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.HistoryTable, "entry_id", bookingIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.PromotionsTable, "promotion_id"); err != nil {
+		return err
+	}
+	if err := r.createTable(r.RedemptionsTable, "redemption_id", bookingIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.ModificationsTable, "modification_id", bookingIndex, statusIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.InvoicesTable, "invoice_id", bookingIndex); err != nil {
+		return err
+	}
+	if err := r.createTable(r.IdempotencyTable, "idempotency_key"); err != nil {
+		return err
+	}
+	return r.createTable(r.LocksTable, "name")
 }
 
-func (r *DynamoDBBookingRepository) GetHotelByID(id string) (*models.Hotel, error) {
-	return &models.Hotel{}, nil
+// createTable creates a table keyed by a string attribute, with an index named after the string
+// attributes it is keyed by: <attribute>-index, or <partition attribute>-<sort attribute>-index.
+func (r *DynamoDBBookingRepository) createTable(table string, key string, indexes ...string) error {
+	ctx := context.TODO()
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(key), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(key), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	}
+	defined := map[string]bool{key: true}
+	for _, index := range indexes {
+		var keySchema []types.KeySchemaElement
+		for i, attribute := range strings.Split(strings.TrimSuffix(index, "-index"), "-") {
+			keyType := types.KeyTypeHash
+			if i > 0 {
+				keyType = types.KeyTypeRange
+			}
+			keySchema = append(keySchema, types.KeySchemaElement{AttributeName: aws.String(attribute), KeyType: keyType})
+			if !defined[attribute] {
+				defined[attribute] = true
+				input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+					AttributeName: aws.String(attribute), AttributeType: types.ScalarAttributeTypeS,
+				})
+			}
+		}
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:  aws.String(index),
+			KeySchema:  keySchema,
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		})
+	}
+
+	_, err := r.Client.CreateTable(ctx, input)
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return fmt.Errorf("error creating table %s: %v", table, err)
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(r.Client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)}, time.Minute); err != nil {
+		return fmt.Errorf("error waiting for table %s: %v", table, err)
+	}
+	return nil
 }
 
-func (r *DynamoDBBookingRepository) UpdateHotel(id string, hotel *models.Hotel) (*models.Hotel, error) {
-	return hotel, nil
+func (r *DynamoDBBookingRepository) GetBookingByID(id string) (*models.Booking, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.Table),
+		Key:            bookingKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching booking: %v", err)
+	}
+	if output.Item == nil {
+		return nil, models.ErrBookingNotFound
+	}
+
+	booking, err := decodeBooking(output.Item)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding booking: %v", err)
+	}
+	return booking, nil
+}
+
+// CreateBooking stores a new booking together with its BookingCreated event and first history
+// entry, setting its timestamps the way GORM does for the Postgres repository.
+func (r *DynamoDBBookingRepository) CreateBooking(booking *models.Booking, change models.Change) error {
+	now := time.Now().UTC()
+	if booking.CreatedAt.IsZero() {
+		booking.CreatedAt = now
+	}
+	if booking.UpdatedAt.IsZero() {
+		booking.UpdatedAt = now
+	}
+	booking.Version = 1
+
+	item, err := encodeBooking(booking)
+	if err != nil {
+		return fmt.Errorf("error encoding booking: %v", err)
+	}
+	write := types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(r.Table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(booking_id)"),
+	}}
+	if err := r.writeChange(write, nil, booking, change); err != nil {
+		if bookingConditionFailed(err) != nil {
+			return models.ErrDuplicateBooking
+		}
+		return fmt.Errorf("error creating booking: %v", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) UpdateBookingStatus(id string, status string, version int, change models.Change) (*models.Booking, error) {
+	updated, err := r.updateBooking(id, map[string]interface{}{
+		"booking_status": status,
+		"updated_at":     time.Now().UTC(),
+	}, version, change)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating booking status: %v", err)
+	}
+	return updated, nil
 }
 
-func (r *DynamoDBBookingRepository) DeleteHotel(id string) error {
+// DeleteBooking removes a version of a booking, recording that it was cancelled and deleted.
+func (r *DynamoDBBookingRepository) DeleteBooking(id string, version int, change models.Change) error {
+	err := r.changeBooking(id, version, change, func(current *models.Booking) (*models.Booking, types.TransactWriteItem, error) {
+		condition, values := versionCondition(current.Version)
+		return nil, types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                           aws.String(r.Table),
+			Key:                                 bookingKey(id),
+			ConditionExpression:                 aws.String(condition),
+			ExpressionAttributeNames:            map[string]string{"#version": "version"},
+			ExpressionAttributeValues:           values,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		}}, nil
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return err
+		}
+		return fmt.Errorf("error deleting booking: %v", err)
+	}
 	return nil
 }
+
+// UpdateBooking changes the user, flight, status, travel date and discounts of a booking where they are set and returns the stored booking.
+func (r *DynamoDBBookingRepository) UpdateBooking(id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error) {
+	updated, err := r.updateBooking(id, bookingChanges(booking), version, change)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating booking: %v", err)
+	}
+	return updated, nil
+}
+
+// updateBooking sets the given attributes on a version of a booking, increments its version and
+// returns the stored booking.
+func (r *DynamoDBBookingRepository) updateBooking(id string, changes map[string]interface{}, version int, change models.Change) (*models.Booking, error) {
+	var updated *models.Booking
+	err := r.changeBooking(id, version, change, func(current *models.Booking) (*models.Booking, types.TransactWriteItem, error) {
+		after := *current
+		applyBookingChanges(&after, changes)
+		after.Version = current.Version + 1
+		item, err := encodeBooking(&after)
+		if err != nil {
+			return nil, types.TransactWriteItem{}, err
+		}
+		condition, values := versionCondition(current.Version)
+		updated = &after
+		return &after, types.TransactWriteItem{Put: &types.Put{
+			TableName:                           aws.String(r.Table),
+			Item:                                item,
+			ConditionExpression:                 aws.String(condition),
+			ExpressionAttributeNames:            map[string]string{"#version": "version"},
+			ExpressionAttributeValues:           values,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// maxChangeAttempts bounds how often a change of any version is retried when the booking keeps
+// changing between reading and writing it.
+const maxChangeAttempts = 3
+
+// changeBooking reads a booking, lets apply turn it into the booking after the change (nil once
+// deleted) and the write making it, and writes it together with the history entry and events of
+// the change. The write is conditional on the version read, so a booking changed in between is a
+// conflict; when any version may be changed, the change is retried on the newer booking instead.
+func (r *DynamoDBBookingRepository) changeBooking(id string, version int, change models.Change, apply func(current *models.Booking) (*models.Booking, types.TransactWriteItem, error)) error {
+	for attempt := 1; ; attempt++ {
+		current, err := r.GetBookingByID(id)
+		if err != nil {
+			return err
+		}
+		if version != models.AnyVersion && current.Version != version {
+			return models.ErrVersionConflict
+		}
+		after, write, err := apply(current)
+		if err != nil {
+			return err
+		}
+		err = r.writeChange(write, current, after, change)
+		missed := bookingConditionFailed(err)
+		if missed == nil {
+			return err
+		}
+		if errors.Is(missed, models.ErrBookingNotFound) || version != models.AnyVersion || attempt == maxChangeAttempts {
+			return missed
+		}
+	}
+}
+
+// writeChange makes a write of a booking and stores the history entry and events of the change in
+// one transaction.
+func (r *DynamoDBBookingRepository) writeChange(write types.TransactWriteItem, before, after *models.Booking, change models.Change) error {
+	events, err := models.BookingEvents(before, after)
+	if err != nil {
+		return err
+	}
+	writes := []types.TransactWriteItem{write}
+	if entry := models.NewHistoryEntry(before, after, change); entry != nil {
+		item, err := attributevalue.MarshalMap(entry)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, types.TransactWriteItem{Put: &types.Put{TableName: aws.String(r.HistoryTable), Item: item}})
+	}
+	for _, event := range events {
+		item, err := encodeOutboxEvent(&event)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, types.TransactWriteItem{Put: &types.Put{TableName: aws.String(r.OutboxTable), Item: item}})
+	}
+	_, err = r.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+	return err
+}
+
+// bookingConditionFailed explains a transaction cancelled because the condition on the booking,
+// its first write, failed: the booking is missing or was changed. It returns nil for other errors.
+func bookingConditionFailed(err error) error {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) || len(cancelled.CancellationReasons) == 0 {
+		return nil
+	}
+	reason := cancelled.CancellationReasons[0]
+	if aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+		return nil
+	}
+	if len(reason.Item) == 0 {
+		return models.ErrBookingNotFound
+	}
+	return models.ErrVersionConflict
+}
+
+// versionCondition only lets a write through to an existing booking at the given version.
+// Bookings stored before they had versions count as version 1.
+func versionCondition(version int) (string, map[string]types.AttributeValue) {
+	condition := "attribute_exists(booking_id) AND #version = :expected"
+	if version == 1 {
+		condition = "attribute_exists(booking_id) AND (#version = :expected OR attribute_not_exists(#version))"
+	}
+	return condition, map[string]types.AttributeValue{
+		":expected": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+	}
+}
+
+// decodeBooking reads a stored booking, giving bookings stored before they had versions version 1.
+func decodeBooking(item map[string]types.AttributeValue) (*models.Booking, error) {
+	var booking models.Booking
+	if err := attributevalue.UnmarshalMap(item, &booking); err != nil {
+		return nil, err
+	}
+	if booking.Version == 0 {
+		booking.Version = 1
+	}
+	return &booking, nil
+}
+
+func bookingKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"booking_id": &types.AttributeValueMemberS{Value: id}}
+}
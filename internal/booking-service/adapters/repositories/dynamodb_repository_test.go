@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// Run against DynamoDB Local, e.g.
+// docker run -p 8000:8000 amazon/dynamodb-local
+// BOOKING_TEST_DYNAMODB_ENDPOINT=http://localhost:8000 go test ./internal/booking-service/...
+func TestDynamoDBBookingRepository(t *testing.T) {
+	endpoint := os.Getenv("BOOKING_TEST_DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BOOKING_TEST_DYNAMODB_ENDPOINT is not set")
+	}
+
+	repo, err := NewDynamoDBRepository(DynamoDBOptions{
+		Table:              "bookings-test-" + uuid.NewString(),
+		TripsTable:         "trips-test-" + uuid.NewString(),
+		SagasTable:         "sagas-test-" + uuid.NewString(),
+		OutboxTable:        "outbox-test-" + uuid.NewString(),
+		HistoryTable:       "history-test-" + uuid.NewString(),
+		PromotionsTable:    "promotions-test-" + uuid.NewString(),
+		RedemptionsTable:   "redemptions-test-" + uuid.NewString(),
+		ModificationsTable: "modifications-test-" + uuid.NewString(),
+		InvoicesTable:      "invoices-test-" + uuid.NewString(),
+		IdempotencyTable:   "idempotency-test-" + uuid.NewString(),
+		LocksTable:         "locks-test-" + uuid.NewString(),
+		Region:             "us-east-1",
+		Endpoint:           endpoint,
+		AccessKeyID:        "local",
+		SecretAccessKey:    "local",
+	})
+	if err != nil {
+		t.Fatalf("NewDynamoDBRepository: %v", err)
+	}
+	if err := repo.CreateTables(); err != nil {
+		t.Fatalf("CreateTables: %v", err)
+	}
+	t.Cleanup(func() {
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.Table)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.TripsTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.SagasTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.OutboxTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.HistoryTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.PromotionsTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.RedemptionsTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.ModificationsTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.InvoicesTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.IdempotencyTable)})
+		repo.Client.DeleteTable(context.TODO(), &dynamodb.DeleteTableInput{TableName: aws.String(repo.LocksTable)})
+	})
+	t.Run("Bookings", func(t *testing.T) { testBookingDB(t, repo) })
+	t.Run("Trips", func(t *testing.T) { testTripDB(t, repo) })
+	t.Run("Sagas", func(t *testing.T) { testSagaDB(t, repo) })
+	t.Run("Outbox", func(t *testing.T) { testOutboxDB(t, repo) })
+	t.Run("Promotions", func(t *testing.T) { testPromotionDB(t, repo) })
+	t.Run("Modifications", func(t *testing.T) { testModificationDB(t, repo) })
+	t.Run("Invoices", func(t *testing.T) { testInvoiceDB(t, repo) })
+	t.Run("IdempotencyKeys", func(t *testing.T) { testIdempotencyStore(t, repo.IdempotencyStore()) })
+	t.Run("LeaderLock", func(t *testing.T) { testLeaderLock(t, repo.LeaderLock()) })
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CreateSaga stores a new saga, setting its timestamps the way GORM does for the Postgres repository.
+func (r *DynamoDBBookingRepository) CreateSaga(saga *models.Saga) error {
+	now := time.Now().UTC()
+	if saga.CreatedAt.IsZero() {
+		saga.CreatedAt = now
+	}
+	if saga.UpdatedAt.IsZero() {
+		saga.UpdatedAt = now
+	}
+	if err := r.putSaga(saga, "attribute_not_exists(saga_id)"); err != nil {
+		return fmt.Errorf("error creating saga: %v", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) GetSagaByID(id string) (*models.Saga, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.SagasTable),
+		Key:            map[string]types.AttributeValue{"saga_id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saga: %v", err)
+	}
+	if output.Item == nil {
+		return nil, models.ErrSagaNotFound
+	}
+
+	var saga models.Saga
+	if err := attributevalue.UnmarshalMap(output.Item, &saga); err != nil {
+		return nil, fmt.Errorf("error decoding saga: %v", err)
+	}
+	return &saga, nil
+}
+
+func (r *DynamoDBBookingRepository) GetSagaByBookingID(bookingID string) (*models.Saga, error) {
+	output, err := r.Client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:                 aws.String(r.SagasTable),
+		IndexName:                 aws.String(bookingIndex),
+		KeyConditionExpression:    aws.String("booking_id = :booking_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":booking_id": &types.AttributeValueMemberS{Value: bookingID}},
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saga: %v", err)
+	}
+	if len(output.Items) == 0 {
+		return nil, models.ErrSagaNotFound
+	}
+
+	var saga models.Saga
+	if err := attributevalue.UnmarshalMap(output.Items[0], &saga); err != nil {
+		return nil, fmt.Errorf("error decoding saga: %v", err)
+	}
+	return &saga, nil
+}
+
+func (r *DynamoDBBookingRepository) GetUnfinishedSagas() ([]models.Saga, error) {
+	sagas := []models.Saga{}
+	for _, status := range unfinishedSagaStatuses {
+		paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+			TableName:                 aws.String(r.SagasTable),
+			IndexName:                 aws.String(statusIndex),
+			KeyConditionExpression:    aws.String("#status = :status"),
+			ExpressionAttributeNames:  map[string]string{"#status": "status"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":status": &types.AttributeValueMemberS{Value: string(status)}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return nil, fmt.Errorf("error fetching unfinished sagas: %v", err)
+			}
+			var items []models.Saga
+			if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+				return nil, fmt.Errorf("error decoding sagas: %v", err)
+			}
+			sagas = append(sagas, items...)
+		}
+	}
+	sort.Slice(sagas, func(i, j int) bool {
+		if !sagas[i].CreatedAt.Equal(sagas[j].CreatedAt) {
+			return sagas[i].CreatedAt.Before(sagas[j].CreatedAt)
+		}
+		return sagas[i].SagaID < sagas[j].SagaID
+	})
+	return sagas, nil
+}
+
+func (r *DynamoDBBookingRepository) UpdateSaga(saga *models.Saga) error {
+	saga.UpdatedAt = time.Now().UTC()
+	if err := r.putSaga(saga, "attribute_exists(saga_id)"); err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return models.ErrSagaNotFound
+		}
+		return fmt.Errorf("error updating saga: %v", err)
+	}
+	return nil
+}
+
+// putSaga writes the whole saga if the condition holds.
+func (r *DynamoDBBookingRepository) putSaga(saga *models.Saga, condition string) error {
+	item, err := attributevalue.MarshalMap(saga)
+	if err != nil {
+		return err
+	}
+	_, err = r.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.SagasTable),
+		Item:                item,
+		ConditionExpression: aws.String(condition),
+	})
+	return err
+}
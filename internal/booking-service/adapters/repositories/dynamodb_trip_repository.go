@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CreateTrip stores a new trip, setting its timestamps the way GORM does for the Postgres repository.
+func (r *DynamoDBBookingRepository) CreateTrip(trip *models.Trip) error {
+	now := time.Now().UTC()
+	if trip.CreatedAt.IsZero() {
+		trip.CreatedAt = now
+	}
+	if trip.UpdatedAt.IsZero() {
+		trip.UpdatedAt = now
+	}
+
+	item, err := attributevalue.MarshalMap(trip)
+	if err != nil {
+		return fmt.Errorf("error encoding trip: %v", err)
+	}
+	_, err = r.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TripsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(trip_id)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return fmt.Errorf("%w: trip %s already exists", models.ErrInvalidTrip, trip.TripID)
+		}
+		return fmt.Errorf("error creating trip: %v", err)
+	}
+	return nil
+}
+
+func (r *DynamoDBBookingRepository) GetTripByID(id string) (*models.Trip, error) {
+	output, err := r.Client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(r.TripsTable),
+		Key:            tripKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching trip: %v", err)
+	}
+	if output.Item == nil {
+		return nil, models.ErrTripNotFound
+	}
+
+	var trip models.Trip
+	if err := attributevalue.UnmarshalMap(output.Item, &trip); err != nil {
+		return nil, fmt.Errorf("error decoding trip: %v", err)
+	}
+	return &trip, nil
+}
+
+func (r *DynamoDBBookingRepository) GetTripsByUserID(userID string) ([]models.Trip, error) {
+	trips, err := r.queryTrips(userIndex, "user_id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching trips for user: %v", err)
+	}
+	sort.Slice(trips, func(i, j int) bool {
+		if !trips[i].CreatedAt.Equal(trips[j].CreatedAt) {
+			return trips[i].CreatedAt.Before(trips[j].CreatedAt)
+		}
+		return trips[i].TripID < trips[j].TripID
+	})
+	return trips, nil
+}
+
+func (r *DynamoDBBookingRepository) GetTripByShareToken(token string) (*models.Trip, error) {
+	trips, err := r.queryTrips(shareTokenIndex, "share_token", token)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching shared trip: %v", err)
+	}
+	if len(trips) == 0 {
+		return nil, models.ErrTripNotFound
+	}
+	return &trips[0], nil
+}
+
+func (r *DynamoDBBookingRepository) UpdateTrip(trip *models.Trip) error {
+	stored, err := r.GetTripByID(trip.TripID)
+	if err != nil {
+		return err
+	}
+	updated := *trip
+	updated.UserID = stored.UserID
+	updated.CreatedAt = stored.CreatedAt
+	updated.UpdatedAt = time.Now().UTC()
+
+	item, err := attributevalue.MarshalMap(updated)
+	if err != nil {
+		return fmt.Errorf("error encoding trip: %v", err)
+	}
+	_, err = r.Client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TripsTable),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(trip_id)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return models.ErrTripNotFound
+		}
+		return fmt.Errorf("error updating trip: %v", err)
+	}
+	trip.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// queryTrips returns the trips whose indexed attribute has the given value.
+func (r *DynamoDBBookingRepository) queryTrips(index string, attribute string, value string) ([]models.Trip, error) {
+	trips := []models.Trip{}
+	paginator := dynamodb.NewQueryPaginator(r.Client, &dynamodb.QueryInput{
+		TableName:                 aws.String(r.TripsTable),
+		IndexName:                 aws.String(index),
+		KeyConditionExpression:    aws.String("#attribute = :value"),
+		ExpressionAttributeNames:  map[string]string{"#attribute": attribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":value": &types.AttributeValueMemberS{Value: value}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		var items []models.Trip
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		trips = append(trips, items...)
+	}
+	return trips, nil
+}
+
+func tripKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"trip_id": &types.AttributeValueMemberS{Value: id}}
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+)
+
+// GetBookingHistory lists the changes of a booking, oldest first, including those of a deleted booking.
+func (r *PostgresBookingRepository) GetBookingHistory(id string) ([]models.BookingHistoryEntry, error) {
+	history := []models.BookingHistoryEntry{}
+	if err := r.DB.Where("booking_id = ?", id).Order("created_at, version").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("error fetching booking history: %v", err)
+	}
+	if len(history) == 0 {
+		// Bookings made before changes were recorded have an empty history.
+		if _, err := r.GetBookingByID(id); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
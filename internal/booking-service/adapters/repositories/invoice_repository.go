@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invoiceSequence holds the last number given out in a series of invoices.
+type invoiceSequence struct {
+	Series     string `gorm:"column:series;primaryKey"`
+	LastNumber int    `gorm:"column:last_number;not null;default:0"`
+}
+
+func (invoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
+
+func (r *PostgresBookingRepository) CreateInvoice(invoice *models.Invoice) error {
+	series := invoice.Series()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&invoiceSequence{Series: series}).Error; err != nil {
+			return fmt.Errorf("error creating invoice series: %v", err)
+		}
+		// The series stays locked until the transaction ends, so its invoices are numbered one at a
+		// time and a failed insert gives its number back.
+		var sequence invoiceSequence
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sequence, "series = ?", series).Error; err != nil {
+			return fmt.Errorf("error reading invoice series: %v", err)
+		}
+		invoice.Sequence = sequence.LastNumber + 1
+		invoice.Number = models.InvoiceNumber(series, invoice.Sequence)
+		if err := tx.Create(invoice).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return models.ErrDuplicateInvoice
+			}
+			return fmt.Errorf("error creating invoice: %v", err)
+		}
+		err := tx.Model(&invoiceSequence{}).Where("series = ?", series).Update("last_number", invoice.Sequence).Error
+		if err != nil {
+			return fmt.Errorf("error updating invoice series: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		invoice.Sequence, invoice.Number = 0, ""
+	}
+	return err
+}
+
+func (r *PostgresBookingRepository) GetInvoiceByID(id string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.DB.First(&invoice, "invoice_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("error fetching invoice: %v", err)
+	}
+	return &invoice, nil
+}
+
+func (r *PostgresBookingRepository) GetInvoicesByBooking(bookingID string) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	if err := r.DB.Where("booking_id = ?", bookingID).Order("issued_at, invoice_id").Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("error fetching invoices: %v", err)
+	}
+	return invoices, nil
+}
+
+func (r *PostgresBookingRepository) SetInvoiceDocument(id string, documentURL string) error {
+	result := r.DB.Model(&models.Invoice{}).Where("invoice_id = ?", id).
+		Updates(map[string]interface{}{"document_url": documentURL, "updated_at": time.Now().UTC()})
+	if result.Error != nil {
+		return fmt.Errorf("error updating invoice: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrInvoiceNotFound
+	}
+	return nil
+}
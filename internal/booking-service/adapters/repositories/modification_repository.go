@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *PostgresBookingRepository) CreateModification(modification *models.Modification) error {
+	if err := r.DB.Create(modification).Error; err != nil {
+		return fmt.Errorf("error creating modification: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetModificationByID(id string) (*models.Modification, error) {
+	var modification models.Modification
+	if err := r.DB.First(&modification, "modification_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrModificationNotFound
+		}
+		return nil, fmt.Errorf("error fetching modification: %v", err)
+	}
+	return &modification, nil
+}
+
+func (r *PostgresBookingRepository) GetModificationsByBooking(bookingID string) ([]models.Modification, error) {
+	return r.findModifications("booking_id = ?", bookingID)
+}
+
+func (r *PostgresBookingRepository) GetModificationsByStatus(status models.ModificationStatus) ([]models.Modification, error) {
+	return r.findModifications("status = ?", status)
+}
+
+func (r *PostgresBookingRepository) findModifications(condition string, value interface{}) ([]models.Modification, error) {
+	modifications := []models.Modification{}
+	if err := r.DB.Where(condition, value).Order("created_at, modification_id").Find(&modifications).Error; err != nil {
+		return nil, fmt.Errorf("error fetching modifications: %v", err)
+	}
+	return modifications, nil
+}
+
+func (r *PostgresBookingRepository) UpdateModification(modification *models.Modification, from models.ModificationStatus) error {
+	modification.UpdatedAt = time.Now().UTC()
+	result := r.DB.Model(&models.Modification{}).
+		Where("modification_id = ? AND status = ?", modification.ModificationID, from).
+		Select("*").Omit("modification_id", "created_at").Updates(modification)
+	if result.Error != nil {
+		return fmt.Errorf("error updating modification: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetModificationByID(modification.ModificationID); err != nil {
+			return err
+		}
+		return models.ErrModificationConflict
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordChange stores the history entry and the events of a change of a booking in the
+// transaction making the change.
+func recordChange(tx *gorm.DB, before, after *models.Booking, change models.Change) error {
+	if entry := models.NewHistoryEntry(before, after, change); entry != nil {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+	}
+	events, err := models.BookingEvents(before, after)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+	return tx.Create(&events).Error
+}
+
+func (r *PostgresBookingRepository) GetPendingEvents(limit int) ([]models.OutboxEvent, error) {
+	events := []models.OutboxEvent{}
+	err := r.DB.Where("published_at IS NULL").Order("created_at, event_id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pending events: %v", err)
+	}
+	return events, nil
+}
+
+func (r *PostgresBookingRepository) MarkEventPublished(id string) error {
+	result := r.DB.Model(&models.OutboxEvent{}).Where("event_id = ?", id).
+		Updates(map[string]interface{}{"published_at": time.Now().UTC(), "last_error": ""})
+	if result.Error != nil {
+		return fmt.Errorf("error marking event published: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrEventNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) RecordEventFailure(id string, reason string) error {
+	result := r.DB.Model(&models.OutboxEvent{}).Where("event_id = ?", id).
+		Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "last_error": reason})
+	if result.Error != nil {
+		return fmt.Errorf("error recording event failure: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrEventNotFound
+	}
+	return nil
+}
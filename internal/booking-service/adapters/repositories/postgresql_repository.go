@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgresBookingRepository struct {
@@ -13,63 +16,204 @@ type PostgresBookingRepository struct {
 }
 
 func NewPostgresBookingRepository(dsn string) (*PostgresBookingRepository, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
-	db.AutoMigrate(&models.Booking{})
-	return &PostgresBookingRepository{DB: db}, nil
-}
-
-func (r *PostgresBookingRepository) GetAllBookings() ([]models.Booking, error) {
-	var bookings []models.Booking
-	if err := r.DB.Find(&bookings).Error; err != nil {
-		return nil, fmt.Errorf("error fetching bookings: %v", err)
+	if err := db.AutoMigrate(&models.Booking{}, &models.Trip{}, &models.Saga{}, &models.OutboxEvent{}, &models.BookingHistoryEntry{},
+		&models.Promotion{}, &models.PromotionRedemption{}, &models.Modification{}, &models.Invoice{}, &invoiceSequence{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate booking tables: %v", err)
 	}
-	return bookings, nil
+	return &PostgresBookingRepository{DB: db}, nil
 }
 
 func (r *PostgresBookingRepository) GetBookingByID(id string) (*models.Booking, error) {
 	var booking models.Booking
 	if err := r.DB.First(&booking, "booking_id = ?", id).Error; err != nil {
-		return nil, fmt.Errorf("booking not found: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrBookingNotFound
+		}
+		return nil, fmt.Errorf("error fetching booking: %v", err)
 	}
 	return &booking, nil
 }
 
-func (r *PostgresBookingRepository) CreateBooking(booking *models.Booking) error {
-	if err := r.DB.Create(booking).Error; err != nil {
+// CreateBooking stores a new booking together with its BookingCreated event and first history entry.
+func (r *PostgresBookingRepository) CreateBooking(booking *models.Booking, change models.Change) error {
+	booking.Version = 1
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+		return recordChange(tx, nil, booking, change)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.ErrDuplicateBooking
+		}
 		return fmt.Errorf("error creating booking: %v", err)
 	}
 	return nil
 }
 
-func (r *PostgresBookingRepository) UpdateBookingStatus(id string, status string) error {
-	if err := r.DB.Model(&models.Booking{}).Where("booking_id = ?", id).Update("status", status).Error; err != nil {
-		return fmt.Errorf("error updating booking status: %v", err)
+func (r *PostgresBookingRepository) UpdateBookingStatus(id string, status string, version int, change models.Change) (*models.Booking, error) {
+	changes := map[string]interface{}{"booking_status": status, "updated_at": time.Now().UTC()}
+	updated, err := r.updateBooking(id, changes, version, change)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating booking status: %v", err)
+	}
+	return updated, nil
+}
+
+// DeleteBooking removes a version of a booking, recording that it was cancelled and deleted.
+func (r *PostgresBookingRepository) DeleteBooking(id string, version int, change models.Change) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := lockBooking(tx, id, version)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Booking{}, "booking_id = ?", id).Error; err != nil {
+			return err
+		}
+		return recordChange(tx, current, nil, change)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return err
+		}
+		return fmt.Errorf("error deleting booking: %v", err)
 	}
 	return nil
 }
 
-func (r *PostgresBookingRepository) GetBookingsByUserID(userID string) ([]models.Booking, error) {
-	var bookings []models.Booking
-	if err := r.DB.Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
-		return nil, fmt.Errorf("error fetching bookings for user: %v", err)
+// UpdateBooking changes the user, flight, status and discounts of a booking where they are set and returns the stored booking.
+func (r *PostgresBookingRepository) UpdateBooking(id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error) {
+	updated, err := r.updateBooking(id, bookingChanges(booking), version, change)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating booking: %v", err)
 	}
-	return bookings, nil
+	return updated, nil
 }
 
-func (r *PostgresBookingRepository) DeleteBooking(id string) error {
-	if err := r.DB.Delete(&models.Booking{}, "booking_id = ?", id).Error; err != nil {
-		return fmt.Errorf("error deleting booking: %v", err)
+// updateBooking sets the given columns on a version of a booking, increments its version and
+// records the change in the history and outbox, all in one transaction.
+func (r *PostgresBookingRepository) updateBooking(id string, changes map[string]interface{}, version int, change models.Change) (*models.Booking, error) {
+	var updated models.Booking
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		current, err := lockBooking(tx, id, version)
+		if err != nil {
+			return err
+		}
+		changes["version"] = gorm.Expr("version + 1")
+		if err := tx.Model(&models.Booking{}).Where("booking_id = ?", id).Updates(changes).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&updated, "booking_id = ?", id).Error; err != nil {
+			return err
+		}
+		return recordChange(tx, current, &updated, change)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &updated, nil
 }
 
-func (r *PostgresBookingRepository) UpdateBooking(id string, booking *models.Booking) (*models.Booking, error) {
-	if err := r.DB.Model(&models.Booking{}).Where("booking_id = ?", id).Updates(booking).Error; err != nil {
-		return nil, fmt.Errorf("error updating booking: %v", err)
+// lockBooking reads a booking for a change within tx, at the given version unless it is
+// models.AnyVersion, and keeps others from changing it until tx ends.
+func lockBooking(tx *gorm.DB, id string, version int) (*models.Booking, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("booking_id = ?", id)
+	if version != models.AnyVersion {
+		query = query.Where("version = ?", version)
+	}
+	var booking models.Booking
+	err := query.First(&booking).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Tell a missing booking from one that has moved on to another version.
+		if err := tx.Select("booking_id").First(&models.Booking{}, "booking_id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, models.ErrBookingNotFound
+			}
+			return nil, err
+		}
+		return nil, models.ErrVersionConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// sortColumns are the SQL expressions bookings are sorted by, matching sortValue.
+var sortColumns = map[string]string{
+	models.SortByCreatedAt:  "created_at",
+	models.SortByUpdatedAt:  "updated_at",
+	models.SortByTravelDate: "COALESCE(travel_date, '0001-01-01 00:00:00+00')",
+}
+
+// QueryBookings filters, sorts and pages bookings in SQL, seeking past the cursor so that later
+// pages cost no more than the first.
+func (r *PostgresBookingRepository) QueryBookings(query models.BookingQuery) (*models.BookingPage, error) {
+	cursor, err := decodeCursor(query)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.DB.Model(&models.Booking{})
+	if query.UserID != "" {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("booking_status IN ?", query.Statuses)
+	}
+	if len(query.ProductTypes) > 0 {
+		db = db.Where("product_type IN ?", query.ProductTypes)
+	}
+	if len(query.Channels) > 0 {
+		db = db.Where("channel IN ?", query.Channels)
+	}
+	if query.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		db = db.Where("created_at <= ?", *query.CreatedTo)
 	}
-	return booking, nil
-}
\ No newline at end of file
+	if query.TravelFrom != nil {
+		db = db.Where("travel_date >= ?", *query.TravelFrom)
+	}
+	if query.TravelTo != nil {
+		db = db.Where("travel_date <= ?", *query.TravelTo)
+	}
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		column = sortColumns[models.SortByCreatedAt]
+	}
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if cursor != nil {
+		db = db.Where(fmt.Sprintf("(%s, booking_id) %s (?, ?)", column, comparison), cursor.Value, cursor.BookingID)
+	}
+
+	// One booking more than the page tells whether there is a next page.
+	bookings := []models.Booking{}
+	err = db.Order(fmt.Sprintf("%s %s, booking_id %s", column, direction, direction)).Limit(query.Limit + 1).Find(&bookings).Error
+	if err != nil {
+		return nil, fmt.Errorf("error searching bookings: %v", err)
+	}
+	page := &models.BookingPage{Bookings: bookings}
+	if len(bookings) > query.Limit {
+		page.Bookings = bookings[:query.Limit]
+		page.NextCursor = encodeCursor(query, &page.Bookings[query.Limit-1])
+	}
+	return page, nil
+}
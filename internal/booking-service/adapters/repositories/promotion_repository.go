@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *PostgresBookingRepository) CreatePromotion(promotion *models.Promotion) error {
+	if err := r.DB.Create(promotion).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.ErrDuplicatePromotion
+		}
+		return fmt.Errorf("error creating promotion: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetPromotionByID(id string) (*models.Promotion, error) {
+	return r.getPromotion("promotion_id = ?", id)
+}
+
+func (r *PostgresBookingRepository) GetPromotionByCode(code string) (*models.Promotion, error) {
+	return r.getPromotion("code = ?", code)
+}
+
+func (r *PostgresBookingRepository) getPromotion(condition string, value string) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.DB.First(&promotion, condition, value).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPromotionNotFound
+		}
+		return nil, fmt.Errorf("error fetching promotion: %v", err)
+	}
+	return &promotion, nil
+}
+
+func (r *PostgresBookingRepository) GetPromotions() ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+	if err := r.DB.Order("created_at, promotion_id").Find(&promotions).Error; err != nil {
+		return nil, fmt.Errorf("error fetching promotions: %v", err)
+	}
+	return promotions, nil
+}
+
+func (r *PostgresBookingRepository) UpdatePromotion(promotion *models.Promotion) error {
+	result := r.DB.Model(&models.Promotion{}).Where("promotion_id = ?", promotion.PromotionID).
+		Select("*").Omit("promotion_id", "uses", "created_at").Updates(promotion)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return models.ErrDuplicatePromotion
+		}
+		return fmt.Errorf("error updating promotion: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrPromotionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) CountRedemptions(promotionID string, userID string) (int, error) {
+	var count int64
+	err := r.DB.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ? AND status = ?", promotionID, userID, models.RedemptionActive).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("error counting redemptions: %v", err)
+	}
+	return int(count), nil
+}
+
+// RedeemPromotions locks the promotions being redeemed, so that concurrent redemptions are counted
+// one after the other against their caps.
+func (r *PostgresBookingRepository) RedeemPromotions(redemptions []models.PromotionRedemption) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		for _, redemption := range redemptions {
+			var existing models.PromotionRedemption
+			err := tx.First(&existing, "redemption_id = ?", redemption.RedemptionID).Error
+			if err == nil && existing.Status == models.RedemptionActive {
+				continue
+			}
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			var promotion models.Promotion
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, "promotion_id = ?", redemption.PromotionID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrPromotionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if promotion.MaxUses > 0 && promotion.Uses >= promotion.MaxUses {
+				return fmt.Errorf("%w: %s reached its limit of %d uses", models.ErrPromotionUnavailable, promotion.Name, promotion.MaxUses)
+			}
+			if promotion.MaxUsesPerUser > 0 {
+				var count int64
+				err := tx.Model(&models.PromotionRedemption{}).
+					Where("promotion_id = ? AND user_id = ? AND status = ?", promotion.PromotionID, redemption.UserID, models.RedemptionActive).
+					Count(&count).Error
+				if err != nil {
+					return err
+				}
+				if int(count) >= promotion.MaxUsesPerUser {
+					return fmt.Errorf("%w: %s can be used %d times per traveller", models.ErrPromotionUnavailable, promotion.Name, promotion.MaxUsesPerUser)
+				}
+			}
+
+			redemption.Status = models.RedemptionActive
+			redemption.ReversedAt = nil
+			if err := tx.Save(&redemption).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&promotion).Update("uses", gorm.Expr("uses + 1")).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrPromotionUnavailable) || errors.Is(err, models.ErrPromotionNotFound) {
+			return err
+		}
+		return fmt.Errorf("error redeeming promotions: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) ReverseRedemptions(bookingID string) ([]models.PromotionRedemption, error) {
+	reversed := []models.PromotionRedemption{}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var redemptions []models.PromotionRedemption
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ? AND status = ?", bookingID, models.RedemptionActive).Find(&redemptions).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for _, redemption := range redemptions {
+			redemption.Status = models.RedemptionReversed
+			redemption.ReversedAt = &now
+			if err := tx.Save(&redemption).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&models.Promotion{}).Where("promotion_id = ? AND uses > 0", redemption.PromotionID).
+				Update("uses", gorm.Expr("uses - 1")).Error
+			if err != nil {
+				return err
+			}
+			reversed = append(reversed, redemption)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reversing redemptions: %v", err)
+	}
+	return reversed, nil
+}
+
+func (r *PostgresBookingRepository) GetRedemptionsByBooking(bookingID string) ([]models.PromotionRedemption, error) {
+	redemptions := []models.PromotionRedemption{}
+	if err := r.DB.Where("booking_id = ?", bookingID).Order("created_at, redemption_id").Find(&redemptions).Error; err != nil {
+		return nil, fmt.Errorf("error fetching redemptions: %v", err)
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+// unfinishedSagaStatuses are the statuses of the sagas the orchestrator still has to move on.
+var unfinishedSagaStatuses = []models.SagaStatus{models.SagaRunning, models.SagaCompensating}
+
+func (r *PostgresBookingRepository) CreateSaga(saga *models.Saga) error {
+	if err := r.DB.Create(saga).Error; err != nil {
+		return fmt.Errorf("error creating saga: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetSagaByID(id string) (*models.Saga, error) {
+	var saga models.Saga
+	if err := r.DB.First(&saga, "saga_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSagaNotFound
+		}
+		return nil, fmt.Errorf("error fetching saga: %v", err)
+	}
+	return &saga, nil
+}
+
+func (r *PostgresBookingRepository) GetSagaByBookingID(bookingID string) (*models.Saga, error) {
+	var saga models.Saga
+	if err := r.DB.First(&saga, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSagaNotFound
+		}
+		return nil, fmt.Errorf("error fetching saga: %v", err)
+	}
+	return &saga, nil
+}
+
+func (r *PostgresBookingRepository) GetUnfinishedSagas() ([]models.Saga, error) {
+	var sagas []models.Saga
+	if err := r.DB.Where("status IN ?", unfinishedSagaStatuses).Order("created_at, saga_id").Find(&sagas).Error; err != nil {
+		return nil, fmt.Errorf("error fetching unfinished sagas: %v", err)
+	}
+	return sagas, nil
+}
+
+func (r *PostgresBookingRepository) UpdateSaga(saga *models.Saga) error {
+	result := r.DB.Model(&models.Saga{}).Where("saga_id = ?", saga.SagaID).
+		Select("status", "steps", "flight_booking_id", "flight_price", "flight_destination", "hotel_booking_id",
+			"hotel_price", "payment_id", "discounts", "points_paid", "charged", "error", "updated_at").Updates(saga)
+	if result.Error != nil {
+		return fmt.Errorf("error updating saga: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrSagaNotFound
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+func (r *PostgresBookingRepository) CreateTrip(trip *models.Trip) error {
+	if err := r.DB.Create(trip).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: trip %s already exists", models.ErrInvalidTrip, trip.TripID)
+		}
+		return fmt.Errorf("error creating trip: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetTripByID(id string) (*models.Trip, error) {
+	var trip models.Trip
+	if err := r.DB.First(&trip, "trip_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTripNotFound
+		}
+		return nil, fmt.Errorf("error fetching trip: %v", err)
+	}
+	return &trip, nil
+}
+
+func (r *PostgresBookingRepository) GetTripsByUserID(userID string) ([]models.Trip, error) {
+	var trips []models.Trip
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at, trip_id").Find(&trips).Error; err != nil {
+		return nil, fmt.Errorf("error fetching trips for user: %v", err)
+	}
+	return trips, nil
+}
+
+func (r *PostgresBookingRepository) GetTripByShareToken(token string) (*models.Trip, error) {
+	var trip models.Trip
+	if err := r.DB.First(&trip, "share_token = ?", token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTripNotFound
+		}
+		return nil, fmt.Errorf("error fetching shared trip: %v", err)
+	}
+	return &trip, nil
+}
+
+func (r *PostgresBookingRepository) UpdateTrip(trip *models.Trip) error {
+	result := r.DB.Model(&models.Trip{}).Where("trip_id = ?", trip.TripID).
+		Select("name", "currency", "items", "share_token", "updated_at").Updates(trip)
+	if result.Error != nil {
+		return fmt.Errorf("error updating trip: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrTripNotFound
+	}
+	return nil
+}
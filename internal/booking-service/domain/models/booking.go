@@ -2,11 +2,34 @@ package models
 
 import "time"
 
+// Product types of bookings.
+const (
+	ProductFlight  = "flight"
+	ProductHotel   = "hotel"
+	ProductPackage = "package"
+)
+
+// Channels bookings are made through.
+const (
+	ChannelWeb    = "web"
+	ChannelMobile = "mobile"
+	ChannelAgent  = "agent"
+	ChannelAPI    = "api"
+)
+
+// AnyVersion skips the version check of an update, for transitions the service makes on its own.
+const AnyVersion = 0
+
 type Booking struct {
-	BookingID     string    `json:"bookingID"`
-	UserID        string    `json:"userID"`
-	FlightID      string    `json:"flightID"`
-	BookingStatus string    `json:"bookingStatus"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	BookingID     string           `json:"bookingID" gorm:"column:booking_id;primaryKey" dynamodbav:"booking_id"`
+	UserID        string           `json:"userID" gorm:"column:user_id;index" dynamodbav:"user_id"`
+	FlightID      string           `json:"flightID" gorm:"column:flight_id" dynamodbav:"flight_id"`
+	BookingStatus string           `json:"bookingStatus" gorm:"column:booking_status" dynamodbav:"booking_status"`
+	ProductType   string           `json:"productType" gorm:"column:product_type;index;default:flight" dynamodbav:"product_type"`
+	Channel       string           `json:"channel,omitempty" gorm:"column:channel" dynamodbav:"channel,omitempty"`                  // Where the booking was made, see the Channel constants.
+	TravelDate    *time.Time       `json:"travelDate,omitempty" gorm:"column:travel_date;index" dynamodbav:"travel_date,omitempty"` // When the trip starts, if known.
+	Discounts     AppliedDiscounts `json:"discounts,omitempty" gorm:"column:discounts;type:jsonb" dynamodbav:"discounts,omitempty"` // Promotions taken off the price of the booking.
+	Version       int              `json:"version" gorm:"column:version;not null;default:1" dynamodbav:"version"`                   // Incremented by every change; updates must name the version they change.
+	CreatedAt     time.Time        `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt     time.Time        `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Fields bookings can be sorted by in a search.
+const (
+	SortByCreatedAt  = "createdAt"
+	SortByUpdatedAt  = "updatedAt"
+	SortByTravelDate = "travelDate" // Bookings without a travel date come first.
+)
+
+const (
+	DefaultBookingPageSize = 20
+	MaxBookingPageSize     = 100
+)
+
+// BookingQuery selects a page of bookings. Empty filters match every booking; the sets match
+// bookings with any of their values and the ranges include their bounds.
+type BookingQuery struct {
+	UserID       string
+	Statuses     []string
+	ProductTypes []string
+	Channels     []string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	TravelFrom   *time.Time
+	TravelTo     *time.Time
+	SortBy       string
+	Descending   bool
+	Limit        int
+	// Cursor continues the search after the page it was returned with.
+	Cursor string
+}
+
+// BookingPage is one page of the bookings a query matched.
+type BookingPage struct {
+	Bookings   []Booking `json:"bookings"`
+	NextCursor string    `json:"nextCursor,omitempty"` // Empty on the last page.
+}
+
+// Requester is who a request was made by, as told by its JWT.
+type Requester struct {
+	UserID string // Subject of an end-user token.
+	// Privileged requesters, such as other services, agents and admins, may see every user's bookings.
+	Privileged bool
+	// Service requesters are other services; only they book on behalf of any user.
+	Service bool
+}
@@ -0,0 +1,62 @@
+package models
+
+import "errors"
+
+var (
+	// ErrBookingNotFound is returned when a booking does not exist.
+	ErrBookingNotFound = errors.New("booking not found")
+	// ErrVersionConflict is returned when a booking was changed since the version an update was based on.
+	ErrVersionConflict = errors.New("booking was changed by someone else")
+	// ErrDuplicateBooking is returned when a booking is created with an ID that is already in use.
+	ErrDuplicateBooking = errors.New("booking already exists")
+	// ErrTripNotFound is returned when a trip or a shared trip link does not exist.
+	ErrTripNotFound = errors.New("trip not found")
+	// ErrTripItemNotFound is returned when a trip has no item with the given ID.
+	ErrTripItemNotFound = errors.New("trip item not found")
+	// ErrInvalidTrip is returned when a trip or one of its items is missing required details.
+	ErrInvalidTrip = errors.New("invalid trip")
+	// ErrInvalidQuery is returned when a booking search has a malformed filter, sort or cursor.
+	ErrInvalidQuery = errors.New("invalid booking query")
+	// ErrForbidden is returned when a requester asks for bookings of another user.
+	ErrForbidden = errors.New("not allowed to see these bookings")
+	// ErrInvalidBooking is returned when a booking is missing required details.
+	ErrInvalidBooking = errors.New("invalid booking")
+	// ErrSagaNotFound is returned when a package booking saga does not exist.
+	ErrSagaNotFound = errors.New("package booking not found")
+	// ErrPackageBookingFailed is returned when a package booking was rolled back or could not be undone.
+	ErrPackageBookingFailed = errors.New("package booking failed")
+	// ErrStepRejected is returned when another service refuses a saga step, e.g. because a room is
+	// sold out or a card is declined; retrying the step will not help.
+	ErrStepRejected = errors.New("step rejected")
+	// ErrPromotionNotFound is returned when a promotion does not exist.
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrDuplicatePromotion is returned when a promotion is given a code another one already has.
+	ErrDuplicatePromotion = errors.New("promotion code already exists")
+	// ErrInvalidPromotion is returned when a promotion is malformed, or a code entered is unknown,
+	// expired or does not apply to what is being priced.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// ErrPromotionUnavailable is returned when a promotion reached its global or per-user usage cap.
+	ErrPromotionUnavailable = errors.New("promotion is used up")
+	// ErrEventNotFound is returned when the outbox has no event with the given ID.
+	ErrEventNotFound = errors.New("event not found")
+	// ErrModificationNotFound is returned when a booking has no modification with the given ID.
+	ErrModificationNotFound = errors.New("modification not found")
+	// ErrInvalidModification is returned when a modification changes nothing, is missing details or
+	// is asked for a booking that cannot be modified.
+	ErrInvalidModification = errors.New("invalid modification")
+	// ErrModificationConflict is returned when another modification of the booking is open, or a
+	// modification is no longer in the state an action needs.
+	ErrModificationConflict = errors.New("modification conflicts with the state of the booking")
+	// ErrModificationExpired is returned when a quote is confirmed after it expired.
+	ErrModificationExpired = errors.New("modification quote expired")
+	// ErrModificationUnavailable is returned when the new stay or flights cannot be had, e.g. because
+	// the room is sold out or the fare does not allow changes.
+	ErrModificationUnavailable = errors.New("modification not available")
+	// ErrInvoiceNotFound is returned when a booking has no invoice.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrDuplicateInvoice is returned when an invoice is stored with the ID of another one.
+	ErrDuplicateInvoice = errors.New("invoice already exists")
+	// ErrInvalidInvoice is returned when billing details are incomplete, or an invoice is asked for a
+	// booking that cannot be invoiced, e.g. because it is not confirmed.
+	ErrInvalidInvoice = errors.New("invalid invoice")
+)
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingCancelledStatus is the status a booking is cancelled with.
+const BookingCancelledStatus = "cancelled"
+
+type EventType string
+
+// Domain events recorded in the outbox whenever a booking changes.
+const (
+	EventBookingCreated       EventType = "BookingCreated"
+	EventBookingStatusChanged EventType = "BookingStatusChanged"
+	EventBookingCancelled     EventType = "BookingCancelled" // Also recorded when a booking is deleted.
+)
+
+// BookingEvent is the payload of the booking events, describing the booking after the change.
+type BookingEvent struct {
+	BookingID      string    `json:"bookingID"`
+	UserID         string    `json:"userID"`
+	FlightID       string    `json:"flightID"`
+	BookingStatus  string    `json:"bookingStatus"`
+	PreviousStatus string    `json:"previousStatus,omitempty"`
+	Version        int       `json:"version"`
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// OutboxEvent is a domain event stored in the same transaction as the change it describes, until
+// the outbox relay has published it to the message bus.
+type OutboxEvent struct {
+	EventID     string     `json:"eventID" gorm:"column:event_id;primaryKey" dynamodbav:"event_id"`
+	AggregateID string     `json:"aggregateID" gorm:"column:aggregate_id;index" dynamodbav:"aggregate_id"` // Booking the event is about.
+	Type        EventType  `json:"type" gorm:"column:type" dynamodbav:"type"`
+	Payload     []byte     `json:"payload" gorm:"column:payload;type:jsonb" dynamodbav:"payload"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"column:created_at;index" dynamodbav:"created_at"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty" gorm:"column:published_at;index" dynamodbav:"published_at,omitempty"`
+	Attempts    int        `json:"attempts" gorm:"column:attempts" dynamodbav:"attempts"`
+	LastError   string     `json:"lastError,omitempty" gorm:"column:last_error" dynamodbav:"last_error,omitempty"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// BookingEvents lists the events a change of a booking raises: before is nil for a new booking
+// and after is nil for a deleted one.
+func BookingEvents(before, after *Booking) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+	now := time.Now().UTC()
+	add := func(eventType EventType, booking *Booking, previousStatus string) error {
+		event, err := newBookingEvent(eventType, booking, previousStatus, now)
+		if err != nil {
+			return err
+		}
+		// Events are relayed in the order they were created; keep those of one change apart.
+		event.CreatedAt = event.CreatedAt.Add(time.Duration(len(events)) * time.Microsecond)
+		events = append(events, *event)
+		return nil
+	}
+
+	switch {
+	case before == nil && after != nil:
+		if err := add(EventBookingCreated, after, ""); err != nil {
+			return nil, err
+		}
+	case before != nil && after == nil:
+		if before.BookingStatus != BookingCancelledStatus {
+			cancelled := *before
+			cancelled.BookingStatus = BookingCancelledStatus
+			if err := add(EventBookingCancelled, &cancelled, before.BookingStatus); err != nil {
+				return nil, err
+			}
+		}
+	case before != nil && after != nil && before.BookingStatus != after.BookingStatus:
+		if err := add(EventBookingStatusChanged, after, before.BookingStatus); err != nil {
+			return nil, err
+		}
+		if after.BookingStatus == BookingCancelledStatus {
+			if err := add(EventBookingCancelled, after, before.BookingStatus); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return events, nil
+}
+
+func newBookingEvent(eventType EventType, booking *Booking, previousStatus string, now time.Time) (*OutboxEvent, error) {
+	payload, err := json.Marshal(BookingEvent{
+		BookingID:      booking.BookingID,
+		UserID:         booking.UserID,
+		FlightID:       booking.FlightID,
+		BookingStatus:  booking.BookingStatus,
+		PreviousStatus: previousStatus,
+		Version:        booking.Version,
+		OccurredAt:     now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		EventID:     uuid.NewString(),
+		AggregateID: booking.BookingID,
+		Type:        eventType,
+		Payload:     payload,
+		CreatedAt:   now,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ActorType string
+
+const (
+	ActorUser    ActorType = "user"
+	ActorService ActorType = "service"
+	ActorSystem  ActorType = "system" // This service acting on its own, e.g. a package booking saga.
+)
+
+// Actor is who changed a booking.
+type Actor struct {
+	Type ActorType `json:"type"`
+	ID   string    `json:"id,omitempty"` // User ID or service name.
+}
+
+// Change describes who makes a change of a booking and why, for its history.
+type Change struct {
+	Actor  Actor
+	Reason string
+}
+
+// SystemChange is a change the booking service makes on its own.
+func SystemChange(process string, reason string) Change {
+	return Change{Actor: Actor{Type: ActorSystem, ID: process}, Reason: reason}
+}
+
+type HistoryAction string
+
+const (
+	HistoryCreated       HistoryAction = "created"
+	HistoryStatusChanged HistoryAction = "status_changed" // The status changed, possibly along with other fields.
+	HistoryUpdated       HistoryAction = "updated"
+	HistoryDeleted       HistoryAction = "deleted"
+)
+
+// FieldChange is the value of a booking field before and after a change, empty when unset.
+type FieldChange struct {
+	Field  string `json:"field" dynamodbav:"field"`
+	Before string `json:"before" dynamodbav:"before"`
+	After  string `json:"after" dynamodbav:"after"`
+}
+
+type FieldChanges []FieldChange
+
+// Value stores the field changes of a history entry as JSON.
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]FieldChange{})
+	}
+	return json.Marshal(c)
+}
+
+// Scan reads field changes stored as JSON.
+func (c *FieldChanges) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+// BookingHistoryEntry records one change of a booking. Entries are only ever added, and outlive
+// the booking when it is deleted.
+type BookingHistoryEntry struct {
+	EntryID   string        `json:"entryID" gorm:"column:entry_id;primaryKey" dynamodbav:"entry_id"`
+	BookingID string        `json:"bookingID" gorm:"column:booking_id;index" dynamodbav:"booking_id"`
+	UserID    string        `json:"userID" gorm:"column:user_id" dynamodbav:"user_id"` // Owner of the booking at the time.
+	Action    HistoryAction `json:"action" gorm:"column:action" dynamodbav:"action"`
+	ActorType ActorType     `json:"actorType" gorm:"column:actor_type" dynamodbav:"actor_type"`
+	ActorID   string        `json:"actorID,omitempty" gorm:"column:actor_id" dynamodbav:"actor_id,omitempty"`
+	Reason    string        `json:"reason,omitempty" gorm:"column:reason" dynamodbav:"reason,omitempty"`
+	Changes   FieldChanges  `json:"changes" gorm:"column:changes;type:jsonb" dynamodbav:"changes"`
+	Version   int           `json:"version" gorm:"column:version" dynamodbav:"version"` // Version of the booking after the change; the deleted version for deletions.
+	CreatedAt time.Time     `json:"createdAt" gorm:"column:created_at;index" dynamodbav:"created_at"`
+}
+
+func (BookingHistoryEntry) TableName() string {
+	return "booking_history"
+}
+
+// NewHistoryEntry records a change of a booking: before is nil for a new booking and after is nil
+// for a deleted one. It returns nil when no field changed.
+func NewHistoryEntry(before, after *Booking, change Change) *BookingHistoryEntry {
+	var empty Booking
+	action := HistoryUpdated
+	from, to := before, after
+	switch {
+	case before == nil:
+		action, from = HistoryCreated, &empty
+	case after == nil:
+		action, to = HistoryDeleted, &empty
+	case before.BookingStatus != after.BookingStatus:
+		action = HistoryStatusChanged
+	}
+
+	changes := diffBookings(from, to)
+	if action == HistoryUpdated && len(changes) == 0 {
+		return nil
+	}
+	current := after
+	if current == nil {
+		current = before
+	}
+	return &BookingHistoryEntry{
+		EntryID:   uuid.NewString(),
+		BookingID: current.BookingID,
+		UserID:    current.UserID,
+		Action:    action,
+		ActorType: change.Actor.Type,
+		ActorID:   change.Actor.ID,
+		Reason:    change.Reason,
+		Changes:   changes,
+		Version:   current.Version,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// diffBookings lists the fields that differ between two bookings, leaving out the bookkeeping
+// ones: ID, version and timestamps.
+func diffBookings(before, after *Booking) FieldChanges {
+	changes := FieldChanges{}
+	compare := func(field string, a, b string) {
+		if a != b {
+			changes = append(changes, FieldChange{Field: field, Before: a, After: b})
+		}
+	}
+	compare("userID", before.UserID, after.UserID)
+	compare("flightID", before.FlightID, after.FlightID)
+	compare("bookingStatus", before.BookingStatus, after.BookingStatus)
+	compare("productType", before.ProductType, after.ProductType)
+	compare("channel", before.Channel, after.Channel)
+	compare("travelDate", formatTime(before.TravelDate), formatTime(after.TravelDate))
+	compare("discounts", formatDiscounts(before.Discounts), formatDiscounts(after.Discounts))
+	return changes
+}
+
+// formatDiscounts lists discounts as e.g. "SUMMER10 -25.00 EUR, Early bird -10.00 EUR".
+func formatDiscounts(discounts AppliedDiscounts) string {
+	formatted := make([]string, 0, len(discounts))
+	for _, discount := range discounts {
+		name := discount.Code
+		if name == "" {
+			name = discount.Name
+		}
+		formatted = append(formatted, fmt.Sprintf("%s -%.2f %s", name, discount.Amount.Amount, discount.Amount.Currency))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type InvoiceKind string
+
+const (
+	KindInvoice    InvoiceKind = "invoice"
+	KindCreditNote InvoiceKind = "credit_note" // Cancels an invoice, in full.
+)
+
+// Address is a postal address on an invoice.
+type Address struct {
+	Line1      string `json:"line1" dynamodbav:"line1"`
+	Line2      string `json:"line2,omitempty" dynamodbav:"line2,omitempty"`
+	PostalCode string `json:"postalCode" dynamodbav:"postal_code"`
+	City       string `json:"city" dynamodbav:"city"`
+	Country    string `json:"country" dynamodbav:"country"` // ISO 3166-1 alpha-2 code, e.g. "DE".
+}
+
+// LegalEntity is a company of the group that issues invoices. Each numbers its invoices and its
+// credit notes in series of their own, without gaps.
+type LegalEntity struct {
+	Code    string  `json:"code" dynamodbav:"code"` // Prefix of its invoice numbers, e.g. "TRV-DE".
+	Name    string  `json:"name" dynamodbav:"name"`
+	Address Address `json:"address" dynamodbav:"address"`
+	VATID   string  `json:"vatID" dynamodbav:"vat_id"`
+	// Countries are the billing countries the entity invoices; the entity without countries
+	// invoices the others.
+	Countries []string `json:"countries,omitempty" dynamodbav:"countries,omitempty"`
+	// TaxRates are the VAT rates charged per product type, e.g. {"hotel": 0.07}; products missing
+	// from it are not taxed.
+	TaxRates map[string]float64 `json:"taxRates,omitempty" dynamodbav:"tax_rates,omitempty"`
+}
+
+// BillingDetails name who an invoice is addressed to.
+type BillingDetails struct {
+	Name    string  `json:"name" dynamodbav:"name"` // Person or company.
+	VATID   string  `json:"vatID,omitempty" dynamodbav:"vat_id,omitempty"`
+	Address Address `json:"address" dynamodbav:"address"`
+}
+
+// InvoiceLine is one position of an invoice. Prices of bookings include VAT, so the net amount and
+// the tax are worked out from the gross amount.
+type InvoiceLine struct {
+	Description string  `json:"description" dynamodbav:"description"`
+	ProductType string  `json:"productType" dynamodbav:"product_type"`
+	NetAmount   float64 `json:"netAmount" dynamodbav:"net_amount"`
+	TaxRate     float64 `json:"taxRate" dynamodbav:"tax_rate"`
+	TaxAmount   float64 `json:"taxAmount" dynamodbav:"tax_amount"`
+	GrossAmount float64 `json:"grossAmount" dynamodbav:"gross_amount"`
+}
+
+// TaxSummary adds up the lines of an invoice taxed at one rate.
+type TaxSummary struct {
+	Rate      float64 `json:"rate" dynamodbav:"rate"`
+	NetAmount float64 `json:"netAmount" dynamodbav:"net_amount"`
+	TaxAmount float64 `json:"taxAmount" dynamodbav:"tax_amount"`
+}
+
+// Invoice is an invoice or credit note of a booking. Once issued it never changes but for the
+// location of its document; a mistake is corrected with a credit note and a new invoice.
+type Invoice struct {
+	InvoiceID string      `json:"invoiceID" gorm:"column:invoice_id;primaryKey" dynamodbav:"invoice_id"`
+	Kind      InvoiceKind `json:"kind" gorm:"column:kind" dynamodbav:"kind"`
+	// Number is given when the invoice is stored: the series of its issuer and kind, followed by
+	// its place in the series.
+	Number     string      `json:"number" gorm:"column:number;uniqueIndex" dynamodbav:"number"`
+	Sequence   int         `json:"sequence" gorm:"column:sequence" dynamodbav:"sequence"`
+	Issuer     LegalEntity `json:"issuer" gorm:"column:issuer;serializer:json" dynamodbav:"issuer"`
+	BookingID  string      `json:"bookingID" gorm:"column:booking_id;index" dynamodbav:"booking_id"`
+	UserID     string      `json:"userID" gorm:"column:user_id" dynamodbav:"user_id"`
+	CreditedID string      `json:"creditedID,omitempty" gorm:"column:credited_id" dynamodbav:"credited_id,omitempty"` // Invoice a credit note cancels.
+	// CreditedNumber is the number of the invoice a credit note cancels, printed on the credit note.
+	CreditedNumber string         `json:"creditedNumber,omitempty" gorm:"column:credited_number" dynamodbav:"credited_number,omitempty"`
+	Reason         string         `json:"reason,omitempty" gorm:"column:reason" dynamodbav:"reason,omitempty"` // Why a credit note was issued.
+	Billing        BillingDetails `json:"billing" gorm:"column:billing;serializer:json" dynamodbav:"billing"`
+	Lines          []InvoiceLine  `json:"lines" gorm:"column:lines;serializer:json" dynamodbav:"lines"`
+	Taxes          []TaxSummary   `json:"taxes" gorm:"column:taxes;serializer:json" dynamodbav:"taxes"`
+	NetTotal       float64        `json:"netTotal" gorm:"column:net_total" dynamodbav:"net_total"`
+	TaxTotal       float64        `json:"taxTotal" gorm:"column:tax_total" dynamodbav:"tax_total"`
+	GrossTotal     float64        `json:"grossTotal" gorm:"column:gross_total" dynamodbav:"gross_total"`
+	Currency       string         `json:"currency" gorm:"column:currency" dynamodbav:"currency"`
+	DocumentURL    string         `json:"documentURL,omitempty" gorm:"column:document_url" dynamodbav:"document_url,omitempty"` // Stored PDF.
+	IssuedAt       time.Time      `json:"issuedAt" gorm:"column:issued_at" dynamodbav:"issued_at"`
+	CreatedAt      time.Time      `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt      time.Time      `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
+}
+
+// Series names the series an invoice is numbered in, e.g. "TRV-DE-INV".
+func (i *Invoice) Series() string {
+	if i.Kind == KindCreditNote {
+		return i.Issuer.Code + "-CN"
+	}
+	return i.Issuer.Code + "-INV"
+}
+
+// InvoiceNumber formats the number of the invoice at position sequence of a series.
+func InvoiceNumber(series string, sequence int) string {
+	return fmt.Sprintf("%s-%06d", strings.ToUpper(series), sequence)
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+type ModificationStatus string
+
+const (
+	ModificationQuoted     ModificationStatus = "quoted"     // Priced and waiting for the traveller to confirm; the new stay is held.
+	ModificationConfirming ModificationStatus = "confirming" // Being applied; resumed when a service could not be reached.
+	ModificationCompleted  ModificationStatus = "completed"
+	ModificationFailed     ModificationStatus = "failed"   // A service refused the change, which was undone.
+	ModificationDeclined   ModificationStatus = "declined" // The traveller did not want the change.
+	ModificationExpired    ModificationStatus = "expired"  // Not confirmed in time.
+)
+
+// Open reports whether a modification still holds the booking, so that no other one can start.
+func (s ModificationStatus) Open() bool {
+	return s == ModificationQuoted || s == ModificationConfirming
+}
+
+// ModificationRequest changes the hotel stay, the flights or both of a package booking. Fields of
+// the hotel change that are left out keep their booked value.
+type ModificationRequest struct {
+	Hotel  *HotelChange  `json:"hotel,omitempty" dynamodbav:"hotel,omitempty"`
+	Flight *FlightChange `json:"flight,omitempty" dynamodbav:"flight,omitempty"`
+}
+
+type HotelChange struct {
+	CheckIn  *time.Time `json:"checkIn,omitempty" dynamodbav:"check_in,omitempty"`
+	CheckOut *time.Time `json:"checkOut,omitempty" dynamodbav:"check_out,omitempty"`
+	RoomID   string     `json:"roomID,omitempty" dynamodbav:"room_id,omitempty"`
+	Guests   int        `json:"guests,omitempty" dynamodbav:"guests,omitempty"`
+}
+
+// FlightChange moves the flights onto an itinerary returned by a flight search, for another date or
+// route.
+type FlightChange struct {
+	ItineraryID string `json:"itineraryID" dynamodbav:"itinerary_id"`
+}
+
+// ChangeCharges is what changing one part of a booking costs.
+type ChangeCharges struct {
+	PriceDifference float64 `json:"priceDifference" dynamodbav:"price_difference"` // New price minus old price; negative when cheaper.
+	ChangeFees      float64 `json:"changeFees" dynamodbav:"change_fees"`
+	Currency        string  `json:"currency" dynamodbav:"currency"`
+}
+
+// ModificationQuote adds up the charges of a modification in the currency the booking was paid in.
+type ModificationQuote struct {
+	Hotel           *ChangeCharges `json:"hotel,omitempty" dynamodbav:"hotel,omitempty"`
+	Flight          *ChangeCharges `json:"flight,omitempty" dynamodbav:"flight,omitempty"`
+	PriceDifference float64        `json:"priceDifference" dynamodbav:"price_difference"`
+	ChangeFees      float64        `json:"changeFees" dynamodbav:"change_fees"`
+	AmountDue       float64        `json:"amountDue" dynamodbav:"amount_due"` // Collected when positive, refunded when negative.
+	Currency        string         `json:"currency" dynamodbav:"currency"`
+}
+
+// Modification is a priced change of a package booking. Once confirmed, the difference is collected
+// or refunded together with the change: the payment is taken and the new stay confirmed before the
+// flights change, and both are undone if the flights cannot.
+type Modification struct {
+	ModificationID string                   `json:"modificationID" gorm:"column:modification_id;primaryKey" dynamodbav:"modification_id"`
+	BookingID      string                   `json:"bookingID" gorm:"column:booking_id;index" dynamodbav:"booking_id"`
+	SagaID         string                   `json:"sagaID" gorm:"column:saga_id" dynamodbav:"saga_id"` // Package booking the change applies to.
+	UserID         string                   `json:"userID" gorm:"column:user_id" dynamodbav:"user_id"`
+	Status         ModificationStatus       `json:"status" gorm:"column:status;index" dynamodbav:"status"`
+	Request        ModificationRequest      `json:"request" gorm:"column:request;serializer:json" dynamodbav:"request"`
+	Hotel          *HotelReservationRequest `json:"hotel,omitempty" gorm:"column:hotel;serializer:json" dynamodbav:"hotel,omitempty"` // The stay after the change.
+	HotelHoldID    string                   `json:"hotelHoldID,omitempty" gorm:"column:hotel_hold_id" dynamodbav:"hotel_hold_id,omitempty"`
+	HotelPrice     *Money                   `json:"hotelPrice,omitempty" gorm:"column:hotel_price;serializer:json" dynamodbav:"hotel_price,omitempty"`
+	Quote          ModificationQuote        `json:"quote" gorm:"column:quote;serializer:json" dynamodbav:"quote"`
+	RequestedBy    Actor                    `json:"requestedBy" gorm:"column:requested_by;serializer:json" dynamodbav:"requested_by"`
+	Reason         string                   `json:"reason,omitempty" gorm:"column:reason" dynamodbav:"reason,omitempty"` // Why the traveller changes the booking, for its history.
+	// Progress of applying the change, so that it is resumed where it stopped.
+	PaymentID        string     `json:"paymentID,omitempty" gorm:"column:payment_id" dynamodbav:"payment_id,omitempty"` // Payment collecting the difference.
+	HotelConfirmed   bool       `json:"hotelConfirmed,omitempty" gorm:"column:hotel_confirmed" dynamodbav:"hotel_confirmed,omitempty"`
+	FlightPrice      *Money     `json:"flightPrice,omitempty" gorm:"column:flight_price;serializer:json" dynamodbav:"flight_price,omitempty"` // Set once the flights changed.
+	OldHotelReleased bool       `json:"oldHotelReleased,omitempty" gorm:"column:old_hotel_released" dynamodbav:"old_hotel_released,omitempty"`
+	Refunded         bool       `json:"refunded,omitempty" gorm:"column:refunded" dynamodbav:"refunded,omitempty"`
+	SagaUpdated      bool       `json:"sagaUpdated,omitempty" gorm:"column:saga_updated" dynamodbav:"saga_updated,omitempty"` // The package records the new stay, flights and charge.
+	Error            string     `json:"error,omitempty" gorm:"column:error" dynamodbav:"error,omitempty"`                     // Why the change failed; set while it is being undone.
+	ExpiresAt        time.Time  `json:"expiresAt" gorm:"column:expires_at" dynamodbav:"expires_at"`                           // Until when the quote can be confirmed.
+	CompletedAt      *time.Time `json:"completedAt,omitempty" gorm:"column:completed_at" dynamodbav:"completed_at,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Emails the user service sends to travellers about their bookings.
+const (
+	TemplateBookingConfirmed = "booking_confirmed"
+	TemplateBookingCancelled = "booking_cancelled"
+	TemplateTripReminder     = "trip_reminder"
+)
+
+// Notification asks the user service to email a traveller. Notifications are recognised by their
+// ID, so one sent again after a timeout or a replayed event reaches the traveller once.
+type Notification struct {
+	ID       string            // E.g. the ID of the event the email is about.
+	UserID   string            // Recipient.
+	Template string            // One of the Template constants.
+	Data     map[string]string // Values the template fills in.
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+// Promotion is a discount rule of a marketing campaign. Promotions with a code apply when the
+// traveller enters the code; those without apply on their own to every price they match.
+type Promotion struct {
+	PromotionID  string       `json:"promotionID" gorm:"column:promotion_id;primaryKey" dynamodbav:"promotion_id"`
+	Code         *string      `json:"code,omitempty" gorm:"column:code;uniqueIndex" dynamodbav:"code,omitempty"` // Upper case; nil for automatic promotions.
+	Name         string       `json:"name" gorm:"column:name" dynamodbav:"name"`
+	DiscountType DiscountType `json:"discountType" gorm:"column:discount_type" dynamodbav:"discount_type"`
+	Value        float64      `json:"value" gorm:"column:value" dynamodbav:"value"`                                // Percent off, or the amount off in Currency.
+	Currency     string       `json:"currency,omitempty" gorm:"column:currency" dynamodbav:"currency,omitempty"`   // Currency of fixed discounts and the minimum spend.
+	MinSpend     float64      `json:"minSpend,omitempty" gorm:"column:min_spend" dynamodbav:"min_spend,omitempty"` // Least the matching products must cost.
+	// ProductTypes are the products the discount is taken off, e.g. only the hotel of a package;
+	// every product when empty.
+	ProductTypes StringList `json:"productTypes,omitempty" gorm:"column:product_types;type:jsonb" dynamodbav:"product_types,omitempty"`
+	// Destinations are the arrival airports trips must go to; any when empty.
+	Destinations StringList `json:"destinations,omitempty" gorm:"column:destinations;type:jsonb" dynamodbav:"destinations,omitempty"`
+	TravelFrom   *time.Time `json:"travelFrom,omitempty" gorm:"column:travel_from" dynamodbav:"travel_from,omitempty"`    // Earliest start of the trip.
+	TravelUntil  *time.Time `json:"travelUntil,omitempty" gorm:"column:travel_until" dynamodbav:"travel_until,omitempty"` // Latest start of the trip.
+	StartsAt     *time.Time `json:"startsAt,omitempty" gorm:"column:starts_at" dynamodbav:"starts_at,omitempty"`          // When the campaign starts.
+	EndsAt       *time.Time `json:"endsAt,omitempty" gorm:"column:ends_at" dynamodbav:"ends_at,omitempty"`                // When the campaign ends.
+	// MaxUses caps the redemptions across all users and MaxUsesPerUser those of each user; zero
+	// means unlimited. Uses counts the redemptions that were not reversed.
+	MaxUses        int  `json:"maxUses,omitempty" gorm:"column:max_uses" dynamodbav:"max_uses"`
+	MaxUsesPerUser int  `json:"maxUsesPerUser,omitempty" gorm:"column:max_uses_per_user" dynamodbav:"max_uses_per_user"`
+	Uses           int  `json:"uses" gorm:"column:uses" dynamodbav:"uses"`
+	Stackable      bool `json:"stackable" gorm:"column:stackable" dynamodbav:"stackable"` // May be combined with other stackable promotions.
+	Active         bool `json:"active" gorm:"column:active" dynamodbav:"active"`
+
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
+}
+
+// Running reports whether the campaign of the promotion is on at the given time.
+func (p *Promotion) Running(at time.Time) bool {
+	return p.Active && (p.StartsAt == nil || !at.Before(*p.StartsAt)) && (p.EndsAt == nil || at.Before(*p.EndsAt))
+}
+
+type StringList []string
+
+// Value stores a list of strings as JSON.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(l))
+}
+
+// Scan reads a list of strings stored as JSON.
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+type RedemptionStatus string
+
+const (
+	RedemptionActive   RedemptionStatus = "active"
+	RedemptionReversed RedemptionStatus = "reversed" // The booking was cancelled; the use counts no more.
+)
+
+// PromotionRedemption is the use of a promotion by a booking; a booking redeems a promotion once.
+type PromotionRedemption struct {
+	RedemptionID string           `json:"redemptionID" gorm:"column:redemption_id;primaryKey" dynamodbav:"redemption_id"` // See RedemptionID.
+	PromotionID  string           `json:"promotionID" gorm:"column:promotion_id;index:idx_redemption_user" dynamodbav:"promotion_id"`
+	BookingID    string           `json:"bookingID" gorm:"column:booking_id;index" dynamodbav:"booking_id"`
+	UserID       string           `json:"userID" gorm:"column:user_id;index:idx_redemption_user" dynamodbav:"user_id"`
+	Code         string           `json:"code,omitempty" gorm:"column:code" dynamodbav:"code,omitempty"`
+	Discount     Money            `json:"discount" gorm:"column:discount;serializer:json" dynamodbav:"discount"`
+	Status       RedemptionStatus `json:"status" gorm:"column:status" dynamodbav:"status"`
+	CreatedAt    time.Time        `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	ReversedAt   *time.Time       `json:"reversedAt,omitempty" gorm:"column:reversed_at" dynamodbav:"reversed_at,omitempty"`
+}
+
+// RedemptionID identifies the redemption of a promotion by a booking.
+func RedemptionID(promotionID string, bookingID string) string {
+	return promotionID + "/" + bookingID
+}
+
+// AppliedDiscount is what a promotion took off the price of a booking.
+type AppliedDiscount struct {
+	PromotionID string `json:"promotionID" dynamodbav:"promotion_id"`
+	Code        string `json:"code,omitempty" dynamodbav:"code,omitempty"`
+	Name        string `json:"name" dynamodbav:"name"`
+	Amount      Money  `json:"amount" dynamodbav:"amount"`
+}
+
+type AppliedDiscounts []AppliedDiscount
+
+// Value stores the discounts of a booking as JSON.
+func (d AppliedDiscounts) Value() (driver.Value, error) {
+	if d == nil {
+		return json.Marshal([]AppliedDiscount{})
+	}
+	return json.Marshal([]AppliedDiscount(d))
+}
+
+// Scan reads discounts stored as JSON.
+func (d *AppliedDiscounts) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
+
+// PriceComponent is the price of one of the products being priced, e.g. the hotel of a package.
+type PriceComponent struct {
+	ProductType string `json:"productType"`
+	Price       Money  `json:"price"`
+}
+
+// PricingRequest asks what the running promotions, and those of the codes entered, take off a price.
+type PricingRequest struct {
+	UserID      string           `json:"userID"`
+	BookingID   string           `json:"bookingID,omitempty"` // Booking being priced again, whose redemptions do not count against the caps.
+	Components  []PriceComponent `json:"components"`
+	Currency    string           `json:"currency"` // Currency of the quote; that of the first component when empty.
+	Destination string           `json:"destination,omitempty"`
+	TravelDate  *time.Time       `json:"travelDate,omitempty"`
+	Codes       []string         `json:"codes,omitempty"`
+}
+
+// PriceQuote is a price with the discounts taken off it.
+type PriceQuote struct {
+	Subtotal  Money            `json:"subtotal"`
+	Discounts AppliedDiscounts `json:"discounts"`
+	Total     Money            `json:"total"`
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompensating SagaStatus = "compensating"
+	SagaCompleted    SagaStatus = "completed"
+	SagaRolledBack   SagaStatus = "rolled_back" // A step failed and every earlier step was undone.
+	SagaFailed       SagaStatus = "failed"      // A step could not be undone and needs manual attention.
+)
+
+// Finished reports whether the orchestrator is done with a saga.
+func (s SagaStatus) Finished() bool {
+	return s == SagaCompleted || s == SagaRolledBack || s == SagaFailed
+}
+
+type SagaStep string
+
+// Steps of a package booking, in the order they run.
+const (
+	StepReserveFlight SagaStep = "reserve_flight"
+	StepReserveHotel  SagaStep = "reserve_hotel"
+	StepTakePayment   SagaStep = "take_payment"
+	StepConfirm       SagaStep = "confirm"
+)
+
+var PackageBookingSteps = []SagaStep{StepReserveFlight, StepReserveHotel, StepTakePayment, StepConfirm}
+
+type StepStatus string
+
+const (
+	StepPending            StepStatus = "pending"
+	StepStarted            StepStatus = "started" // Sent to the other service; the outcome is not known yet.
+	StepCompleted          StepStatus = "completed"
+	StepFailed             StepStatus = "failed"
+	StepCompensated        StepStatus = "compensated"
+	StepCompensationFailed StepStatus = "compensation_failed"
+)
+
+type SagaStepState struct {
+	Step      SagaStep   `json:"step" dynamodbav:"step"`
+	Status    StepStatus `json:"status" dynamodbav:"status"`
+	Attempts  int        `json:"attempts" dynamodbav:"attempts"`
+	Error     string     `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt" dynamodbav:"updated_at"`
+}
+
+type SagaSteps []SagaStepState
+
+// Value stores the steps of a saga as JSON.
+func (s SagaSteps) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal([]SagaStepState{})
+	}
+	return json.Marshal(s)
+}
+
+// Scan reads saga steps stored as JSON.
+func (s *SagaSteps) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+// PackageBookingRequest books a flight and a hotel stay together and pays for both at once.
+type PackageBookingRequest struct {
+	UserID  string                   `json:"userID" dynamodbav:"user_id"`
+	Flight  FlightReservationRequest `json:"flight" dynamodbav:"flight"`
+	Hotel   HotelReservationRequest  `json:"hotel" dynamodbav:"hotel"`
+	Payment PaymentRequest           `json:"payment" dynamodbav:"payment"`
+	// PromoCodes are the promotion codes the traveller entered; running automatic promotions
+	// apply without one.
+	PromoCodes []string `json:"promoCodes,omitempty" dynamodbav:"promo_codes,omitempty"`
+}
+
+// Value stores the request a saga was started with as JSON.
+func (r PackageBookingRequest) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan reads a package booking request stored as JSON.
+func (r *PackageBookingRequest) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
+
+// FlightReservationRequest books an itinerary returned by a flight search of the flight booking service.
+type FlightReservationRequest struct {
+	ItineraryID string `json:"itineraryID" dynamodbav:"itinerary_id"`
+	Adults      int    `json:"adults" dynamodbav:"adults"`
+	Children    int    `json:"children" dynamodbav:"children"`
+	Infants     int    `json:"infants" dynamodbav:"infants"`
+}
+
+type HotelReservationRequest struct {
+	HotelID         string    `json:"hotelID" dynamodbav:"hotel_id"`
+	RoomID          string    `json:"roomID,omitempty" dynamodbav:"room_id,omitempty"`
+	CheckIn         time.Time `json:"checkIn" dynamodbav:"check_in"`
+	CheckOut        time.Time `json:"checkOut" dynamodbav:"check_out"`
+	Guests          int       `json:"guests" dynamodbav:"guests"`
+	SpecialRequests string    `json:"specialRequests,omitempty" dynamodbav:"special_requests,omitempty"`
+}
+
+type PaymentRequest struct {
+	Method   string `json:"method" dynamodbav:"method"`                         // Payment method token, e.g. a tokenized card.
+	Currency string `json:"currency,omitempty" dynamodbav:"currency,omitempty"` // Defaults to the currency of the flight.
+	// Points are the most loyalty points to pay with; the payment method is charged the rest.
+	Points int `json:"points,omitempty" dynamodbav:"points,omitempty"`
+}
+
+// Reservation is what another service returns for a reservation or payment it made.
+type Reservation struct {
+	ID          string `json:"id"`
+	Price       Money  `json:"price"`
+	Destination string `json:"destination,omitempty"` // Arrival airport of a flight, which promotions may be limited to.
+}
+
+// Saga tracks a package booking across the flight, hotel and payment services so it can be
+// resumed after a crash and undone when a step fails.
+type Saga struct {
+	SagaID            string                `json:"sagaID" gorm:"column:saga_id;primaryKey" dynamodbav:"saga_id"`
+	BookingID         string                `json:"bookingID" gorm:"column:booking_id;index" dynamodbav:"booking_id"` // Booking of this service the package is recorded as.
+	UserID            string                `json:"userID" gorm:"column:user_id;index" dynamodbav:"user_id"`
+	Status            SagaStatus            `json:"status" gorm:"column:status;index" dynamodbav:"status"`
+	Request           PackageBookingRequest `json:"request" gorm:"column:request;type:jsonb" dynamodbav:"request"`
+	Steps             SagaSteps             `json:"steps" gorm:"column:steps;type:jsonb" dynamodbav:"steps"`
+	FlightBookingID   string                `json:"flightBookingID,omitempty" gorm:"column:flight_booking_id" dynamodbav:"flight_booking_id,omitempty"`
+	FlightPrice       *Money                `json:"flightPrice,omitempty" gorm:"column:flight_price;serializer:json" dynamodbav:"flight_price,omitempty"`
+	FlightDestination string                `json:"flightDestination,omitempty" gorm:"column:flight_destination" dynamodbav:"flight_destination,omitempty"`
+	HotelBookingID    string                `json:"hotelBookingID,omitempty" gorm:"column:hotel_booking_id" dynamodbav:"hotel_booking_id,omitempty"`
+	HotelPrice        *Money                `json:"hotelPrice,omitempty" gorm:"column:hotel_price;serializer:json" dynamodbav:"hotel_price,omitempty"`
+	PaymentID         string                `json:"paymentID,omitempty" gorm:"column:payment_id" dynamodbav:"payment_id,omitempty"`
+	Discounts         AppliedDiscounts      `json:"discounts,omitempty" gorm:"column:discounts;type:jsonb" dynamodbav:"discounts,omitempty"` // Promotions taken off the charge.
+	PointsPaid        *Money                `json:"pointsPaid,omitempty" gorm:"column:points_paid;serializer:json" dynamodbav:"points_paid,omitempty"`
+	Charged           *Money                `json:"charged,omitempty" gorm:"column:charged;serializer:json" dynamodbav:"charged,omitempty"`
+	Error             string                `json:"error,omitempty" gorm:"column:error" dynamodbav:"error,omitempty"` // Why the saga is being or was rolled back.
+	CreatedAt         time.Time             `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt         time.Time             `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
+}
+
+// Step returns the state of one step of the saga.
+func (s *Saga) Step(step SagaStep) *SagaStepState {
+	for i := range s.Steps {
+		if s.Steps[i].Step == step {
+			return &s.Steps[i]
+		}
+	}
+	return nil
+}
+
+func scanJSON(value interface{}, target interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported JSON column type")
+	}
+	return json.Unmarshal(data, target)
+}
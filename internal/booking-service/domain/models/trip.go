@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+type TripItemType string
+
+const (
+	TripItemFlight   TripItemType = "flight"
+	TripItemHotel    TripItemType = "hotel"
+	TripItemActivity TripItemType = "activity"
+	TripItemTransfer TripItemType = "transfer"
+	TripItemOther    TripItemType = "other"
+)
+
+type Money struct {
+	Amount   float64 `json:"amount" dynamodbav:"amount"`
+	Currency string  `json:"currency" dynamodbav:"currency"`
+}
+
+// Trip groups everything a user booked for one journey: flight segments, hotel stays and other items.
+type Trip struct {
+	TripID     string    `json:"tripID" gorm:"column:trip_id;primaryKey" dynamodbav:"trip_id"`
+	UserID     string    `json:"userID,omitempty" gorm:"column:user_id;index" dynamodbav:"user_id"`
+	Name       string    `json:"name" gorm:"column:name" dynamodbav:"name"`
+	Currency   string    `json:"currency" gorm:"column:currency" dynamodbav:"currency"` // Currency the trip total is reported in.
+	Items      TripItems `json:"items" gorm:"column:items;type:jsonb" dynamodbav:"items"`
+	ShareToken *string   `json:"shareToken,omitempty" gorm:"column:share_token;uniqueIndex" dynamodbav:"share_token,omitempty"` // Set while the trip is shared.
+	CreatedAt  time.Time `json:"createdAt" gorm:"column:created_at" dynamodbav:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" gorm:"column:updated_at" dynamodbav:"updated_at"`
+
+	// Derived from the items when a trip is read; never stored.
+	Timeline []TimelineEntry `json:"timeline" gorm:"-" dynamodbav:"-"`
+	Costs    []Money         `json:"costs,omitempty" gorm:"-" dynamodbav:"-"` // Item costs summed per currency.
+	Total    *Money          `json:"total,omitempty" gorm:"-" dynamodbav:"-"` // All costs converted into the trip currency.
+}
+
+// TripItem is one part of a trip. Flights and transfers go from an origin to a destination; hotel
+// stays and activities take place at a location.
+type TripItem struct {
+	ItemID      string       `json:"itemID" dynamodbav:"item_id"`
+	Type        TripItemType `json:"type" dynamodbav:"type"`
+	BookingID   string       `json:"bookingID,omitempty" dynamodbav:"booking_id,omitempty"` // Booking of this service the item stands for.
+	Reference   string       `json:"reference,omitempty" dynamodbav:"reference,omitempty"`  // Confirmation number from the airline, hotel or supplier.
+	Title       string       `json:"title" dynamodbav:"title"`
+	Origin      string       `json:"origin,omitempty" dynamodbav:"origin,omitempty"`
+	Destination string       `json:"destination,omitempty" dynamodbav:"destination,omitempty"`
+	Location    string       `json:"location,omitempty" dynamodbav:"location,omitempty"`
+	StartTime   time.Time    `json:"startTime" dynamodbav:"start_time"`                 // Departure, check-in or start.
+	EndTime     *time.Time   `json:"endTime,omitempty" dynamodbav:"end_time,omitempty"` // Arrival, check-out or end.
+	Cost        *Money       `json:"cost,omitempty" dynamodbav:"cost,omitempty"`
+	Notes       string       `json:"notes,omitempty" dynamodbav:"notes,omitempty"`
+}
+
+type TripItems []TripItem
+
+// Value stores the items of a trip as JSON.
+func (i TripItems) Value() (driver.Value, error) {
+	if i == nil {
+		return json.Marshal([]TripItem{})
+	}
+	return json.Marshal(i)
+}
+
+// Scan reads trip items stored as JSON.
+func (i *TripItems) Scan(value interface{}) error {
+	return scanJSON(value, i)
+}
+
+type TimelineEvent string
+
+const (
+	TimelineDeparture TimelineEvent = "departure"
+	TimelineArrival   TimelineEvent = "arrival"
+	TimelineCheckIn   TimelineEvent = "check_in"
+	TimelineCheckOut  TimelineEvent = "check_out"
+	TimelineStart     TimelineEvent = "start"
+	TimelineEnd       TimelineEvent = "end"
+)
+
+// TimelineEntry is one moment of a trip, e.g. a departure or a hotel check-in.
+type TimelineEntry struct {
+	Time     time.Time     `json:"time"`
+	Event    TimelineEvent `json:"event"`
+	ItemID   string        `json:"itemID"`
+	Type     TripItemType  `json:"type"`
+	Title    string        `json:"title"`
+	Location string        `json:"location,omitempty"`
+}
+
+type CreateTripRequest struct {
+	UserID   string     `json:"userID"`
+	Name     string     `json:"name"`
+	Currency string     `json:"currency"`
+	Items    []TripItem `json:"items"`
+}
+
+type TripShare struct {
+	TripID     string `json:"tripID"`
+	ShareToken string `json:"shareToken"`
+}
@@ -2,12 +2,20 @@ package ports
 
 import "microservices-travel-backend/internal/booking-service/domain/models"
 
+// BookingDB stores bookings. Updates and deletes only apply to the given version of a booking,
+// failing with models.ErrVersionConflict when it has moved on, unless models.AnyVersion is given;
+// every change increments the version. Every change is recorded in the booking's history, on
+// behalf of the actor of the given models.Change.
 type BookingDB interface {
-	GetAllBookings() ([]models.Booking, error)
 	GetBookingByID(id string) (*models.Booking, error)
-	CreateBooking(booking *models.Booking) error
-	UpdateBookingStatus(id string, status string) error
-	GetBookingsByUserID(userID string) ([]models.Booking, error)
-	DeleteBooking(id string) error
-	UpdateBooking(id string, booking *models.Booking) (*models.Booking, error)
+	CreateBooking(booking *models.Booking, change models.Change) error
+	UpdateBookingStatus(id string, status string, version int, change models.Change) (*models.Booking, error)
+	// QueryBookings returns a page of the bookings matching a query whose sort and limit are set.
+	// A malformed cursor fails with models.ErrInvalidQuery.
+	QueryBookings(query models.BookingQuery) (*models.BookingPage, error)
+	DeleteBooking(id string, version int, change models.Change) error
+	UpdateBooking(id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error)
+	// GetBookingHistory returns the changes of a booking, oldest first, also once it is deleted.
+	// It fails with models.ErrBookingNotFound when the booking never existed.
+	GetBookingHistory(id string) ([]models.BookingHistoryEntry, error)
 }
@@ -2,12 +2,17 @@ package ports
 
 import "microservices-travel-backend/internal/booking-service/domain/models"
 
+// BookingService reads and changes bookings on behalf of a requester. End users only ever see and
+// change their own bookings; the bookings of others are reported as not found.
 type BookingService interface {
-	GetAllBookings() ([]models.Booking, error)
-	GetBookingByID(id string) (*models.Booking, error)
-	CreateBooking(booking *models.Booking) error
-	UpdateBookingStatus(id string, status string) error
-	GetBookingsByUserID(userID string) ([]models.Booking, error)
-	DeleteBooking(id string) error
-	UpdateBooking(id string, booking *models.Booking) (*models.Booking, error)
+	GetBookingByID(requester models.Requester, id string) (*models.Booking, error)
+	// CreateBooking books for the requester; only services book on behalf of another user.
+	CreateBooking(requester models.Requester, booking *models.Booking, change models.Change) error
+	UpdateBookingStatus(requester models.Requester, id string, status string, version int, change models.Change) (*models.Booking, error)
+	// QueryBookings searches the bookings the requester may see: end users only see their own.
+	QueryBookings(requester models.Requester, query models.BookingQuery) (*models.BookingPage, error)
+	DeleteBooking(requester models.Requester, id string, version int, change models.Change) error
+	UpdateBooking(requester models.Requester, id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error)
+	// GetBookingHistory returns the changes of a booking to privileged requesters and its owner.
+	GetBookingHistory(requester models.Requester, id string) ([]models.BookingHistoryEntry, error)
 }
@@ -0,0 +1,8 @@
+package ports
+
+import "io"
+
+// DocumentStorage stores generated documents and returns the URL they can be downloaded from.
+type DocumentStorage interface {
+	UploadDocument(body io.Reader, fileName string, contentType string) (string, error)
+}
@@ -0,0 +1,7 @@
+package ports
+
+// ExchangeRates converts amounts between currencies.
+type ExchangeRates interface {
+	Convert(amount float64, from string, to string) (float64, error)
+	Supports(currency string) bool
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// InvoiceDB stores invoices and credit notes.
+type InvoiceDB interface {
+	// CreateInvoice gives the invoice the next number of its series and stores it in one
+	// transaction, so that the numbers of a series have no gaps. It fails with
+	// models.ErrDuplicateInvoice, without using up a number, when the ID is taken.
+	CreateInvoice(invoice *models.Invoice) error
+	GetInvoiceByID(id string) (*models.Invoice, error)
+	// GetInvoicesByBooking lists the invoices and credit notes of a booking in the order they were issued.
+	GetInvoicesByBooking(bookingID string) ([]models.Invoice, error)
+	// SetInvoiceDocument records where the PDF of an invoice is stored.
+	SetInvoiceDocument(id string, documentURL string) error
+}
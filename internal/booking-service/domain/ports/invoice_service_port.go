@@ -0,0 +1,19 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// InvoiceService issues the invoices and credit notes of bookings. Every method taking a booking
+// checks that the requester owns it or is privileged.
+type InvoiceService interface {
+	IssueInvoice(requester models.Requester, bookingID string, billing models.BillingDetails) (*models.Invoice, error)
+	// GetInvoice returns the document last issued for a booking: its invoice, or the credit note
+	// that cancelled it.
+	GetInvoice(requester models.Requester, bookingID string) (*models.Invoice, error)
+	GetInvoices(requester models.Requester, bookingID string) ([]models.Invoice, error)
+	RenderInvoice(invoice *models.Invoice) ([]byte, error)
+}
+
+// InvoiceRenderer renders invoices to PDF.
+type InvoiceRenderer interface {
+	Render(invoice *models.Invoice) ([]byte, error)
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// MessageBus delivers domain events to other services. Delivery is at least once: consumers
+// recognise repeated events by their ID.
+type MessageBus interface {
+	Publish(event models.OutboxEvent) error
+	Close() error
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// ModificationDB stores the modifications of package bookings.
+type ModificationDB interface {
+	CreateModification(modification *models.Modification) error
+	GetModificationByID(id string) (*models.Modification, error)
+	// GetModificationsByBooking lists the modifications of a booking, oldest first.
+	GetModificationsByBooking(bookingID string) ([]models.Modification, error)
+	// GetModificationsByStatus lists the modifications in a status, oldest first.
+	GetModificationsByStatus(status models.ModificationStatus) ([]models.Modification, error)
+	// UpdateModification replaces a stored modification if it is still in the given status, failing
+	// with models.ErrModificationConflict otherwise.
+	UpdateModification(modification *models.Modification, from models.ModificationStatus) error
+}
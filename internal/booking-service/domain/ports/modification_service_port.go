@@ -0,0 +1,13 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// ModificationService quotes and applies changes of package bookings. Every method checks that the
+// requester owns the booking or is privileged.
+type ModificationService interface {
+	RequestModification(requester models.Requester, bookingID string, request models.ModificationRequest, change models.Change) (*models.Modification, error)
+	GetModification(requester models.Requester, bookingID string, id string) (*models.Modification, error)
+	GetModifications(requester models.Requester, bookingID string) ([]models.Modification, error)
+	ConfirmModification(requester models.Requester, bookingID string, id string) (*models.Modification, error)
+	DeclineModification(requester models.Requester, bookingID string, id string) (*models.Modification, error)
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// Notifications emails travellers through the user service, which knows their addresses and
+// languages. Errors wrapping models.ErrStepRejected mean the notification will never be taken,
+// e.g. because the user does not exist; any other error may be retried.
+type Notifications interface {
+	Notify(notification models.Notification) error
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// OutboxDB holds the domain events the BookingDB records with every change of a booking until
+// they are published.
+type OutboxDB interface {
+	// GetPendingEvents returns up to limit unpublished events, oldest first.
+	GetPendingEvents(limit int) ([]models.OutboxEvent, error)
+	MarkEventPublished(id string) error
+	// RecordEventFailure counts a failed attempt to publish an event.
+	RecordEventFailure(id string, reason string) error
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+type PackageBookingService interface {
+	BookPackage(requester models.Requester, request models.PackageBookingRequest) (*models.Saga, error)
+	GetPackageBooking(requester models.Requester, id string) (*models.Saga, error)
+	ResumeSagas() error
+}
@@ -0,0 +1,26 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// PromotionDB stores promotions and their redemptions. The usage caps of promotions are enforced
+// when redemptions are recorded, so that concurrent bookings cannot exceed them.
+type PromotionDB interface {
+	// CreatePromotion fails with models.ErrDuplicatePromotion when the code is taken.
+	CreatePromotion(promotion *models.Promotion) error
+	GetPromotionByID(id string) (*models.Promotion, error)
+	GetPromotionByCode(code string) (*models.Promotion, error)
+	// GetPromotions lists every promotion, oldest first.
+	GetPromotions() ([]models.Promotion, error)
+	// UpdatePromotion replaces the rules of a stored promotion; its uses are left alone.
+	UpdatePromotion(promotion *models.Promotion) error
+	// CountRedemptions counts the redemptions of a promotion by a user that were not reversed.
+	CountRedemptions(promotionID string, userID string) (int, error)
+	// RedeemPromotions records the redemptions of a booking, all or none, failing with
+	// models.ErrPromotionUnavailable when one would exceed a cap of its promotion. Redemptions the
+	// booking already made are left as they are.
+	RedeemPromotions(redemptions []models.PromotionRedemption) error
+	// ReverseRedemptions reverses the redemptions of a booking that are still active, giving their
+	// uses back, and returns them.
+	ReverseRedemptions(bookingID string) ([]models.PromotionRedemption, error)
+	GetRedemptionsByBooking(bookingID string) ([]models.PromotionRedemption, error)
+}
@@ -0,0 +1,22 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+type PromotionService interface {
+	CreatePromotion(promotion *models.Promotion) error
+	GetPromotion(id string) (*models.Promotion, error)
+	GetPromotions() ([]models.Promotion, error)
+	UpdatePromotion(id string, promotion *models.Promotion) (*models.Promotion, error)
+	QuotePrice(request models.PricingRequest) (*models.PriceQuote, error)
+}
+
+// Discounts applies promotions to the prices of bookings and takes them back when bookings are
+// cancelled.
+type Discounts interface {
+	QuotePrice(request models.PricingRequest) (*models.PriceQuote, error)
+	// CheckCodes fails with models.ErrInvalidPromotion unless every code names a running promotion.
+	CheckCodes(codes []string) error
+	RedeemDiscounts(bookingID string, userID string, discounts models.AppliedDiscounts) error
+	// ReverseDiscounts gives back the uses of the promotions a booking redeemed.
+	ReverseDiscounts(bookingID string) error
+}
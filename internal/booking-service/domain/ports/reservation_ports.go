@@ -0,0 +1,39 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+// The saga orchestrator reaches the other services through these ports. Every call carries an
+// idempotency key, so repeating a call after a crash or timeout does not reserve or charge twice.
+// Errors wrapping models.ErrStepRejected are final; any other error may be retried.
+
+type FlightReservations interface {
+	ReserveFlight(key string, userID string, request models.FlightReservationRequest) (*models.Reservation, error)
+	ReleaseFlight(key string, flightBookingID string) error
+}
+
+// FlightChanges moves flight bookings onto other itineraries. Quoting checks that the change is
+// possible without making it.
+type FlightChanges interface {
+	QuoteFlightChange(flightBookingID string, itineraryID string) (*models.ChangeCharges, error)
+	// ChangeFlight makes the change and returns the new total price of the flight booking.
+	ChangeFlight(key string, flightBookingID string, itineraryID string) (*models.Money, error)
+}
+
+type HotelReservations interface {
+	ReserveHotel(key string, userID string, request models.HotelReservationRequest) (*models.Reservation, error)
+	ConfirmHotel(key string, hotelBookingID string) error
+	CancelHotel(key string, hotelBookingID string) error
+}
+
+type Payments interface {
+	TakePayment(key string, userID string, reference string, method string, amount models.Money) (*models.Reservation, error)
+	RefundPayment(key string, paymentID string, amount models.Money) error
+}
+
+// LoyaltyPoints pays for bookings with the points of the traveller.
+type LoyaltyPoints interface {
+	// RedeemPoints uses up to points to pay for price and returns what they paid.
+	RedeemPoints(key string, userID string, bookingID string, points int, price models.Money) (*models.Money, error)
+	// ReverseRedemptions gives back the points redeemed for a booking.
+	ReverseRedemptions(key string, bookingID string) error
+}
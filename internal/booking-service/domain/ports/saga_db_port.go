@@ -0,0 +1,14 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+type SagaDB interface {
+	CreateSaga(saga *models.Saga) error
+	GetSagaByID(id string) (*models.Saga, error)
+	// GetSagaByBookingID returns the saga a package booking was made through.
+	GetSagaByBookingID(bookingID string) (*models.Saga, error)
+	// GetUnfinishedSagas returns the sagas that are still running or compensating.
+	GetUnfinishedSagas() ([]models.Saga, error)
+	// UpdateSaga replaces the state of a stored saga.
+	UpdateSaga(saga *models.Saga) error
+}
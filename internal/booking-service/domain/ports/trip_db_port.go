@@ -0,0 +1,12 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+type TripDB interface {
+	CreateTrip(trip *models.Trip) error
+	GetTripByID(id string) (*models.Trip, error)
+	GetTripsByUserID(userID string) ([]models.Trip, error)
+	GetTripByShareToken(token string) (*models.Trip, error)
+	// UpdateTrip replaces the name, currency, items and share token of a stored trip.
+	UpdateTrip(trip *models.Trip) error
+}
@@ -0,0 +1,14 @@
+package ports
+
+import "microservices-travel-backend/internal/booking-service/domain/models"
+
+type TripService interface {
+	CreateTrip(requester models.Requester, request models.CreateTripRequest) (*models.Trip, error)
+	GetTrip(requester models.Requester, id string) (*models.Trip, error)
+	GetTripsByUserID(requester models.Requester, userID string) ([]models.Trip, error)
+	AddTripItem(requester models.Requester, tripID string, item models.TripItem) (*models.Trip, error)
+	RemoveTripItem(requester models.Requester, tripID string, itemID string) (*models.Trip, error)
+	ShareTrip(requester models.Requester, tripID string) (*models.TripShare, error)
+	StopSharingTrip(requester models.Requester, tripID string) error
+	GetSharedTrip(token string) (*models.Trip, error)
+}
@@ -1,11 +1,25 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"microservices-travel-backend/pkg/middlewares"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+// Storage backends the booking service can persist bookings in.
+const (
+	StoragePostgres = "postgres"
+	StorageDynamoDB = "dynamodb"
+)
+
+// Message buses the events of bookings can be published to.
+const (
+	MessageBusMemory = "memory"
+	MessageBusNATS   = "nats"
+)
+
 type Config struct {
 	Database struct {
 		Host     string `mapstructure:"host"`
@@ -13,7 +27,7 @@ type Config struct {
 		User     string `mapstructure:"user"`
 		Password string `mapstructure:"password"`
 		DBName   string `mapstructure:"db_name"`
-		SSL      bool   `mapstructure:"ssl"`
+		SSLMode  string `mapstructure:"ssl_mode"`
 	} `mapstructure:"database"`
 
 	AWS struct {
@@ -22,31 +36,201 @@ type Config struct {
 		Region          string `mapstructure:"region"`
 	} `mapstructure:"aws"`
 
+	Storage struct {
+		Driver   string `mapstructure:"driver"`
+		DynamoDB struct {
+			Table      string `mapstructure:"table"`
+			TripsTable string `mapstructure:"trips_table"`
+			SagasTable string `mapstructure:"sagas_table"`
+			// OutboxTable holds the events of bookings until they are published.
+			OutboxTable string `mapstructure:"outbox_table"`
+			// HistoryTable holds the history of every change of a booking.
+			HistoryTable string `mapstructure:"history_table"`
+			// PromotionsTable and RedemptionsTable hold the promotions and which bookings used them.
+			PromotionsTable  string `mapstructure:"promotions_table"`
+			RedemptionsTable string `mapstructure:"redemptions_table"`
+			// ModificationsTable holds the priced changes of package bookings.
+			ModificationsTable string `mapstructure:"modifications_table"`
+			// InvoicesTable holds the invoices and credit notes, and the last number of every series.
+			InvoicesTable string `mapstructure:"invoices_table"`
+			// IdempotencyTable keeps Idempotency-Key records when bookings are stored in DynamoDB.
+			IdempotencyTable string `mapstructure:"idempotency_table"`
+			// LocksTable holds the lease of the replica that resumes unfinished package bookings.
+			LocksTable string `mapstructure:"locks_table"`
+			// Endpoint overrides the AWS endpoint, e.g. http://localhost:8000 for DynamoDB Local.
+			Endpoint string `mapstructure:"endpoint"`
+		} `mapstructure:"dynamodb"`
+	} `mapstructure:"storage"`
+
 	Service struct {
 		Host string `mapstructure:"host"`
 		Port int    `mapstructure:"port"`
 	} `mapstructure:"service"`
 
+	// Services are the base URLs of the services package bookings are made through.
+	Services struct {
+		FlightURL  string `mapstructure:"flight_url"`
+		HotelURL   string `mapstructure:"hotel_url"`
+		PaymentURL string `mapstructure:"payment_url"`
+		UserURL    string `mapstructure:"user_url"` // Loyalty points are redeemed and travellers emailed there.
+	} `mapstructure:"services"`
+
+	Idempotency struct {
+		// KeyTTL is how long Idempotency-Key responses are kept for replay.
+		KeyTTL time.Duration `mapstructure:"key_ttl"`
+	} `mapstructure:"idempotency"`
+
+	// MessageBus is where the events of bookings are published; the memory bus only reaches
+	// subscribers within this process.
+	MessageBus struct {
+		Driver        string `mapstructure:"driver"`
+		NATSURL       string `mapstructure:"nats_url"`
+		SubjectPrefix string `mapstructure:"subject_prefix"`
+	} `mapstructure:"message_bus"`
+
+	Outbox struct {
+		// RelayInterval is how often events waiting in the outbox are published.
+		RelayInterval time.Duration `mapstructure:"relay_interval"`
+		// BatchSize is how many events are published per relay run at most.
+		BatchSize int `mapstructure:"batch_size"`
+	} `mapstructure:"outbox"`
+
+	Saga struct {
+		// RecoveryInterval is how often unfinished package bookings are looked for and resumed.
+		RecoveryInterval time.Duration `mapstructure:"recovery_interval"`
+	} `mapstructure:"saga"`
+
+	Modifications struct {
+		// HotelChangeFee is charged, in HotelChangeFeeCurrency, for every change of a hotel stay.
+		HotelChangeFee         float64 `mapstructure:"hotel_change_fee"`
+		HotelChangeFeeCurrency string  `mapstructure:"hotel_change_fee_currency"`
+		// QuoteTTL is how long a quoted change can be confirmed; the new stay is held until then.
+		QuoteTTL time.Duration `mapstructure:"quote_ttl"`
+		// SweepInterval is how often expired quotes are released and interrupted changes resumed.
+		SweepInterval time.Duration `mapstructure:"sweep_interval"`
+	} `mapstructure:"modifications"`
+
+	Invoicing struct {
+		// LegalEntitiesFile lists the legal entities invoices are issued by, with the billing
+		// countries each serves and their tax rates.
+		LegalEntitiesFile string `mapstructure:"legal_entities_file"`
+	} `mapstructure:"invoicing"`
+
 	Logging struct {
 		Level  string `mapstructure:"level"`
 		Format string `mapstructure:"format"`
 	} `mapstructure:"logging"`
 }
 
-var AppConfig Config
-
-func LoadConfig(env string) {
-	viper.AddConfigPath("./config/env")
-	viper.SetConfigType("yaml")
+// environment maps each setting to the variable it is read from; the database and AWS ones are
+// shared with the other services through config/shared.
+var environment = map[string]string{
+	"database.host":                           "DATABASE_URL",
+	"database.port":                           "DATABASE_PORT",
+	"database.user":                           "DATABASE_USERNAME",
+	"database.password":                       "DATABASE_PASSWORD",
+	"database.db_name":                        "DATABASE_NAME",
+	"database.ssl_mode":                       "DATABASE_SSLMODE",
+	"aws.access_key_id":                       "AWS_ACCESS_KEY",
+	"aws.secret_access_key":                   "AWS_SECRET_KEY",
+	"aws.region":                              "AWS_REGION",
+	"storage.driver":                          "BOOKING_STORAGE",
+	"storage.dynamodb.table":                  "DYNAMODB_BOOKINGS_TABLE",
+	"storage.dynamodb.trips_table":            "DYNAMODB_TRIPS_TABLE",
+	"storage.dynamodb.sagas_table":            "DYNAMODB_SAGAS_TABLE",
+	"storage.dynamodb.outbox_table":           "DYNAMODB_OUTBOX_TABLE",
+	"storage.dynamodb.history_table":          "DYNAMODB_HISTORY_TABLE",
+	"storage.dynamodb.promotions_table":       "DYNAMODB_PROMOTIONS_TABLE",
+	"storage.dynamodb.redemptions_table":      "DYNAMODB_REDEMPTIONS_TABLE",
+	"storage.dynamodb.modifications_table":    "DYNAMODB_MODIFICATIONS_TABLE",
+	"storage.dynamodb.invoices_table":         "DYNAMODB_INVOICES_TABLE",
+	"storage.dynamodb.idempotency_table":      "DYNAMODB_IDEMPOTENCY_TABLE",
+	"storage.dynamodb.locks_table":            "DYNAMODB_LOCKS_TABLE",
+	"storage.dynamodb.endpoint":               "DYNAMODB_ENDPOINT",
+	"service.host":                            "BOOKING_SERVICE_HOST",
+	"service.port":                            "BOOKING_SERVICE_PORT",
+	"services.flight_url":                     "FLIGHT_SERVICE_URL",
+	"services.hotel_url":                      "HOTEL_SERVICE_URL",
+	"services.payment_url":                    "PAYMENT_SERVICE_URL",
+	"services.user_url":                       "USER_SERVICE_URL",
+	"saga.recovery_interval":                  "SAGA_RECOVERY_INTERVAL",
+	"modifications.hotel_change_fee":          "MODIFICATION_HOTEL_CHANGE_FEE",
+	"modifications.hotel_change_fee_currency": "MODIFICATION_HOTEL_CHANGE_FEE_CURRENCY",
+	"modifications.quote_ttl":                 "MODIFICATION_QUOTE_TTL",
+	"modifications.sweep_interval":            "MODIFICATION_SWEEP_INTERVAL",
+	"message_bus.driver":                      "MESSAGE_BUS",
+	"message_bus.nats_url":                    "NATS_URL",
+	"message_bus.subject_prefix":              "MESSAGE_BUS_SUBJECT_PREFIX",
+	"invoicing.legal_entities_file":           "INVOICE_LEGAL_ENTITIES_FILE",
+	"outbox.relay_interval":                   "OUTBOX_RELAY_INTERVAL",
+	"outbox.batch_size":                       "OUTBOX_BATCH_SIZE",
+	"idempotency.key_ttl":                     "IDEMPOTENCY_KEY_TTL",
+	"logging.level":                           "LOG_LEVEL",
+	"logging.format":                          "LOG_FORMAT",
+}
 
-	configFile := "./config/env/" + env + "/hotel-booking.yaml"
-	viper.SetConfigFile(configFile)
+// LoadConfig reads the booking service configuration from the environment.
+func LoadConfig() (*Config, error) {
+	v := viper.New()
+	for key, variable := range environment {
+		if err := v.BindEnv(key, variable); err != nil {
+			return nil, fmt.Errorf("error binding %s: %v", variable, err)
+		}
+	}
+	v.SetDefault("database.port", 5432)
+	v.SetDefault("database.ssl_mode", "disable")
+	v.SetDefault("storage.driver", StoragePostgres)
+	v.SetDefault("storage.dynamodb.table", "bookings")
+	v.SetDefault("storage.dynamodb.trips_table", "trips")
+	v.SetDefault("storage.dynamodb.sagas_table", "sagas")
+	v.SetDefault("storage.dynamodb.outbox_table", "booking_outbox")
+	v.SetDefault("storage.dynamodb.history_table", "booking_history")
+	v.SetDefault("storage.dynamodb.promotions_table", "promotions")
+	v.SetDefault("storage.dynamodb.redemptions_table", "promotion_redemptions")
+	v.SetDefault("storage.dynamodb.modifications_table", "booking_modifications")
+	v.SetDefault("storage.dynamodb.invoices_table", "invoices")
+	v.SetDefault("storage.dynamodb.idempotency_table", "idempotency_keys")
+	v.SetDefault("storage.dynamodb.locks_table", "scheduler_locks")
+	v.SetDefault("service.port", 6000)
+	v.SetDefault("services.flight_url", "http://localhost:6100")
+	v.SetDefault("services.hotel_url", "http://localhost:5100")
+	v.SetDefault("services.payment_url", "http://localhost:6200")
+	v.SetDefault("services.user_url", "http://localhost:7100")
+	v.SetDefault("saga.recovery_interval", time.Minute)
+	v.SetDefault("modifications.hotel_change_fee", 25)
+	v.SetDefault("modifications.hotel_change_fee_currency", "EUR")
+	v.SetDefault("modifications.quote_ttl", 15*time.Minute)
+	v.SetDefault("modifications.sweep_interval", time.Minute)
+	v.SetDefault("invoicing.legal_entities_file", "config/booking-service/legal_entities.json")
+	v.SetDefault("message_bus.driver", MessageBusMemory)
+	v.SetDefault("message_bus.nats_url", "nats://localhost:4222")
+	v.SetDefault("message_bus.subject_prefix", "bookings")
+	v.SetDefault("outbox.relay_interval", time.Second)
+	v.SetDefault("outbox.batch_size", 100)
+	v.SetDefault("idempotency.key_ttl", middleware.DefaultIdempotencyWindow)
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file, %s", err)
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("unable to decode configuration: %v", err)
 	}
 
-	if err := viper.Unmarshal(&AppConfig); err != nil {
-		log.Fatalf("Unable to decode into struct, %v", err)
+	switch config.Storage.Driver {
+	case StoragePostgres, StorageDynamoDB:
+	default:
+		return nil, fmt.Errorf("unsupported booking storage %q, expected %s or %s", config.Storage.Driver, StoragePostgres, StorageDynamoDB)
+	}
+	switch config.MessageBus.Driver {
+	case MessageBusMemory, MessageBusNATS:
+	default:
+		return nil, fmt.Errorf("unsupported message bus %q, expected %s or %s", config.MessageBus.Driver, MessageBusMemory, MessageBusNATS)
 	}
+	return &config, nil
+}
+
+// PostgresDSN builds the connection string of the bookings database, leaving out the database
+// name when none is configured so the server's default database is used.
+func (c *Config) PostgresDSN() string {
+	db := c.Database
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		db.User, db.Password, db.Host, db.Port, db.DBName, db.SSLMode)
 }
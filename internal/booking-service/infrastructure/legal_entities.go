@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"os"
+)
+
+// LoadLegalEntities reads the legal entities invoices are issued by. Each entity invoices the
+// billing countries it lists; at most one lists none and invoices every other country.
+func LoadLegalEntities(path string) ([]models.LegalEntity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading legal entities: %v", err)
+	}
+	var entities []models.LegalEntity
+	if err := json.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("error decoding legal entities in %s: %v", path, err)
+	}
+
+	codes := map[string]bool{}
+	fallback := ""
+	for _, entity := range entities {
+		if entity.Code == "" || entity.Name == "" {
+			return nil, fmt.Errorf("legal entities in %s need a code and a name", path)
+		}
+		if codes[entity.Code] {
+			return nil, fmt.Errorf("legal entity %s is listed twice in %s", entity.Code, path)
+		}
+		codes[entity.Code] = true
+		if len(entity.Countries) == 0 {
+			if fallback != "" {
+				return nil, fmt.Errorf("legal entities %s and %s in %s both list no countries", fallback, entity.Code, path)
+			}
+			fallback = entity.Code
+		}
+	}
+	return entities, nil
+}
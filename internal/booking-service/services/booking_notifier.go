@@ -0,0 +1,59 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+)
+
+// bookingConfirmedStatus is the status of a booking the traveller is told is confirmed.
+const bookingConfirmedStatus = "confirmed"
+
+// BookingNotifier emails travellers when their bookings are confirmed or cancelled. It takes the
+// booking events from the outbox relay like a message bus, so an email the user service could not
+// take is tried again with the event; the event ID keeps it from being sent twice.
+type BookingNotifier struct {
+	notifications ports.Notifications
+}
+
+func NewBookingNotifier(notifications ports.Notifications) *BookingNotifier {
+	return &BookingNotifier{notifications: notifications}
+}
+
+func (n *BookingNotifier) Publish(event models.OutboxEvent) error {
+	var booking models.BookingEvent
+	if err := json.Unmarshal(event.Payload, &booking); err != nil {
+		return fmt.Errorf("error decoding %s event %s: %v", event.Type, event.EventID, err)
+	}
+
+	template := ""
+	switch {
+	case event.Type == models.EventBookingCancelled:
+		template = models.TemplateBookingCancelled
+	case event.Type == models.EventBookingStatusChanged && booking.BookingStatus == bookingConfirmedStatus:
+		template = models.TemplateBookingConfirmed
+	}
+	if template == "" || booking.UserID == "" {
+		return nil
+	}
+
+	err := n.notifications.Notify(models.Notification{
+		ID:       event.EventID,
+		UserID:   booking.UserID,
+		Template: template,
+		Data:     map[string]string{"bookingID": booking.BookingID},
+	})
+	if errors.Is(err, models.ErrStepRejected) {
+		// Retrying will not help and would hold back the events after this one.
+		log.Printf("Notification of %s event %s was refused: %v\n", event.Type, event.EventID, err)
+		return nil
+	}
+	return err
+}
+
+func (n *BookingNotifier) Close() error {
+	return nil
+}
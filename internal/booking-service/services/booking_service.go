@@ -1,71 +1,211 @@
 package services
 
 import (
+	"fmt"
+	"log"
 	"microservices-travel-backend/internal/booking-service/domain/models"
 	"microservices-travel-backend/internal/booking-service/domain/ports"
+
+	"github.com/google/uuid"
 )
 
 type BookingService struct {
-	db ports.BookingDB
+	db         ports.BookingDB
+	promotions ports.Discounts
 }
 
 // NewBookingService initializes a new BookingService
-func NewBookingService(db ports.BookingDB) *BookingService {
-	return &BookingService{db: db}
-}
-
-// GetAllBookings retrieves all bookings from the repository
-func (b *BookingService) GetAllBookings() ([]models.Booking, error) {
-	return b.db.GetAllBookings()
+func NewBookingService(db ports.BookingDB, promotions ports.Discounts) *BookingService {
+	return &BookingService{db: db, promotions: promotions}
 }
 
-// GetBookingByID retrieves a booking by its ID
-func (b *BookingService) GetBookingByID(id string) (*models.Booking, error) {
+// GetBookingByID retrieves a booking by its ID for privileged requesters and its owner
+func (b *BookingService) GetBookingByID(requester models.Requester, id string) (*models.Booking, error) {
 	booking, err := b.db.GetBookingByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if !requester.Privileged && (requester.UserID == "" || booking.UserID != requester.UserID) {
+		// Do not tell others which bookings exist.
+		return nil, models.ErrBookingNotFound
+	}
 	return booking, nil
 }
 
-// CreateBooking creates a new booking
-func (b *BookingService) CreateBooking(booking *models.Booking) error {
-	if err := b.db.CreateBooking(booking); err != nil {
-		return err
+// CreateBooking creates a new booking, generating its ID unless the caller chose one. Bookings made
+// by anyone but another service belong to the requester.
+func (b *BookingService) CreateBooking(requester models.Requester, booking *models.Booking, change models.Change) error {
+	if !requester.Service {
+		booking.UserID = requester.UserID
 	}
-	return nil
-}
+	if booking.UserID == "" {
+		return fmt.Errorf("%w: user ID is required", models.ErrInvalidBooking)
+	}
+	if booking.FlightID == "" {
+		return fmt.Errorf("%w: flight ID is required", models.ErrInvalidBooking)
+	}
+	if booking.ProductType == "" {
+		booking.ProductType = models.ProductFlight
+	}
+	if !oneOf(booking.ProductType, productTypes) {
+		return fmt.Errorf("%w: unknown product type %q", models.ErrInvalidBooking, booking.ProductType)
+	}
+	if booking.Channel != "" && !oneOf(booking.Channel, channels) {
+		return fmt.Errorf("%w: unknown channel %q", models.ErrInvalidBooking, booking.Channel)
+	}
+	if booking.BookingID == "" {
+		booking.BookingID = uuid.NewString()
+	}
+	// Discounts are only recorded when promotions are redeemed during pricing.
+	booking.Discounts = nil
 
-// UpdateBookingStatus updates the status of a booking
-func (b *BookingService) UpdateBookingStatus(id string, status string) error {
-	if err := b.db.UpdateBookingStatus(id, status); err != nil {
+	if err := b.db.CreateBooking(booking, change); err != nil {
 		return err
 	}
 	return nil
 }
 
-// GetBookingsByUserID retrieves bookings for a specific user
-func (b *BookingService) GetBookingsByUserID(userID string) ([]models.Booking, error) {
-	bookings, err := b.db.GetBookingsByUserID(userID)
+// UpdateBookingStatus updates the status of a version of a booking
+func (b *BookingService) UpdateBookingStatus(requester models.Requester, id string, status string, version int, change models.Change) (*models.Booking, error) {
+	if status == "" {
+		return nil, fmt.Errorf("%w: booking status is required", models.ErrInvalidBooking)
+	}
+	if _, err := b.GetBookingByID(requester, id); err != nil {
+		return nil, err
+	}
+	booking, err := b.db.UpdateBookingStatus(id, status, version, change)
 	if err != nil {
 		return nil, err
 	}
-	return bookings, nil
+	if status == models.BookingCancelledStatus {
+		b.reverseDiscounts(id)
+	}
+	return booking, nil
 }
 
-// DeleteBooking deletes a booking by its ID
-func (b *BookingService) DeleteBooking(id string) error {
-	if err := b.db.DeleteBooking(id); err != nil {
+// DeleteBooking deletes a version of a booking by its ID
+func (b *BookingService) DeleteBooking(requester models.Requester, id string, version int, change models.Change) error {
+	if _, err := b.GetBookingByID(requester, id); err != nil {
 		return err
 	}
+	if err := b.db.DeleteBooking(id, version, change); err != nil {
+		return err
+	}
+	b.reverseDiscounts(id)
 	return nil
 }
 
-// UpdateBooking updates an existing booking
-func (b *BookingService) UpdateBooking(id string, booking *models.Booking) (*models.Booking, error) {
-	updatedBooking, err := b.db.UpdateBooking(id, booking)
+// reverseDiscounts gives back the promotions a cancelled or deleted booking used. The booking
+// change already happened, so a failure is only logged.
+func (b *BookingService) reverseDiscounts(id string) {
+	if err := b.promotions.ReverseDiscounts(id); err != nil {
+		log.Printf("Failed to reverse the discounts of booking %s: %v\n", id, err)
+	}
+}
+
+// UpdateBooking updates a version of an existing booking. The dates and flights of package bookings
+// are changed through modifications, which reprice them.
+func (b *BookingService) UpdateBooking(requester models.Requester, id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error) {
+	existing, err := b.GetBookingByID(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Service && booking.UserID != "" && booking.UserID != existing.UserID {
+		return nil, fmt.Errorf("%w: only services can move a booking to another user", models.ErrForbidden)
+	}
+	if (booking.TravelDate != nil || booking.FlightID != "") && existing.ProductType == models.ProductPackage {
+		return nil, fmt.Errorf("%w: change the dates or flights of a package through POST /bookings/%s/modifications", models.ErrInvalidBooking, id)
+	}
+	booking.Discounts = nil
+	updatedBooking, err := b.db.UpdateBooking(id, booking, version, change)
 	if err != nil {
 		return nil, err
 	}
 	return updatedBooking, nil
 }
+
+// GetBookingHistory retrieves the changes of a booking for privileged requesters and its owner
+func (b *BookingService) GetBookingHistory(requester models.Requester, id string) ([]models.BookingHistoryEntry, error) {
+	history, err := b.db.GetBookingHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && !ownedBy(history, requester.UserID) {
+		// Do not tell others which bookings exist.
+		return nil, models.ErrBookingNotFound
+	}
+	return history, nil
+}
+
+// ownedBy reports whether a user owned the booking at any point of its history.
+func ownedBy(history []models.BookingHistoryEntry, userID string) bool {
+	for _, entry := range history {
+		if userID != "" && entry.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryBookings searches bookings, limiting end users to their own
+func (b *BookingService) QueryBookings(requester models.Requester, query models.BookingQuery) (*models.BookingPage, error) {
+	if !requester.Privileged {
+		if requester.UserID == "" || (query.UserID != "" && query.UserID != requester.UserID) {
+			return nil, models.ErrForbidden
+		}
+		query.UserID = requester.UserID
+	}
+	if err := normalizeQuery(&query); err != nil {
+		return nil, err
+	}
+	return b.db.QueryBookings(query)
+}
+
+var (
+	productTypes = []string{models.ProductFlight, models.ProductHotel, models.ProductPackage}
+	channels     = []string{models.ChannelWeb, models.ChannelMobile, models.ChannelAgent, models.ChannelAPI}
+	sortFields   = []string{models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByTravelDate}
+)
+
+// normalizeQuery checks a booking query and fills in the default sort and page size.
+func normalizeQuery(query *models.BookingQuery) error {
+	if query.SortBy == "" {
+		query.SortBy = models.SortByCreatedAt
+	}
+	if query.Limit == 0 {
+		query.Limit = models.DefaultBookingPageSize
+	}
+	switch {
+	case !oneOf(query.SortBy, sortFields):
+		return fmt.Errorf("%w: bookings cannot be sorted by %q", models.ErrInvalidQuery, query.SortBy)
+	case query.Limit < 0 || query.Limit > models.MaxBookingPageSize:
+		return fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidQuery, models.MaxBookingPageSize)
+	case !allOneOf(query.ProductTypes, productTypes):
+		return fmt.Errorf("%w: unknown product type in %v", models.ErrInvalidQuery, query.ProductTypes)
+	case !allOneOf(query.Channels, channels):
+		return fmt.Errorf("%w: unknown channel in %v", models.ErrInvalidQuery, query.Channels)
+	case query.CreatedFrom != nil && query.CreatedTo != nil && query.CreatedTo.Before(*query.CreatedFrom):
+		return fmt.Errorf("%w: created range ends before it starts", models.ErrInvalidQuery)
+	case query.TravelFrom != nil && query.TravelTo != nil && query.TravelTo.Before(*query.TravelFrom):
+		return fmt.Errorf("%w: travel range ends before it starts", models.ErrInvalidQuery)
+	}
+	return nil
+}
+
+func oneOf(value string, values []string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func allOneOf(values []string, allowed []string) bool {
+	for _, value := range values {
+		if !oneOf(value, allowed) {
+			return false
+		}
+	}
+	return true
+}
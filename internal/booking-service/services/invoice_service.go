@@ -0,0 +1,445 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InvoiceService invoices confirmed package bookings. The lines are worked out from the prices the
+// package was booked and changed at, less its discounts, and the invoice is issued by the legal
+// entity serving the billing country. An invoice is never changed: when the billing details or the
+// price of a booking change, or the booking is cancelled, it is cancelled by a credit note and, for
+// a change, issued again.
+type InvoiceService struct {
+	invoices      ports.InvoiceDB
+	bookings      ports.BookingDB
+	sagas         ports.SagaDB
+	modifications ports.ModificationDB
+	rates         ports.ExchangeRates
+	renderer      ports.InvoiceRenderer
+	documents     ports.DocumentStorage
+	entities      []models.LegalEntity
+}
+
+func NewInvoiceService(invoices ports.InvoiceDB, bookings ports.BookingDB, sagas ports.SagaDB,
+	modifications ports.ModificationDB, rates ports.ExchangeRates, renderer ports.InvoiceRenderer,
+	documents ports.DocumentStorage, entities []models.LegalEntity) *InvoiceService {
+	return &InvoiceService{
+		invoices:      invoices,
+		bookings:      bookings,
+		sagas:         sagas,
+		modifications: modifications,
+		rates:         rates,
+		renderer:      renderer,
+		documents:     documents,
+		entities:      entities,
+	}
+}
+
+// IssueInvoice invoices a booking to the given billing details. Asking again for the same details
+// and price returns the invoice already issued.
+func (s *InvoiceService) IssueInvoice(requester models.Requester, bookingID string, billing models.BillingDetails) (*models.Invoice, error) {
+	if billing.Name == "" || billing.Address.Line1 == "" || billing.Address.City == "" || billing.Address.Country == "" {
+		return nil, fmt.Errorf("%w: name, address line, city and country are required", models.ErrInvalidInvoice)
+	}
+	billing.Address.Country = strings.ToUpper(billing.Address.Country)
+	booking, err := s.bookingOf(requester, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.ProductType != models.ProductPackage || booking.BookingStatus != packageConfirmed {
+		return nil, fmt.Errorf("%w: only confirmed package bookings can be invoiced", models.ErrInvalidInvoice)
+	}
+	saga, err := s.sagas.GetSagaByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status != models.SagaCompleted {
+		return nil, fmt.Errorf("%w: the package booking is not completed", models.ErrInvalidInvoice)
+	}
+
+	draft, err := s.draft(saga, billing)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := s.invoices.GetInvoicesByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if current := outstanding(issued); current != nil {
+		if sameInvoice(current, draft) {
+			return current, nil
+		}
+		if _, err := s.credit(current, "Replaced by a corrected invoice"); err != nil {
+			return nil, err
+		}
+	}
+
+	count := 0
+	for _, invoice := range issued {
+		if invoice.Kind == models.KindInvoice {
+			count++
+		}
+	}
+	// The ID counts the invoices of the booking, so of two requests issuing the same one only the
+	// first gets a number.
+	draft.InvoiceID = fmt.Sprintf("%s-invoice-%d", bookingID, count+1)
+	draft.IssuedAt = time.Now().UTC()
+	if err := s.invoices.CreateInvoice(draft); err != nil {
+		if errors.Is(err, models.ErrDuplicateInvoice) {
+			return s.invoices.GetInvoiceByID(draft.InvoiceID)
+		}
+		return nil, err
+	}
+	s.storeDocument(draft)
+	return draft, nil
+}
+
+// GetInvoice returns the document last issued for a booking, storing its PDF if that failed before.
+func (s *InvoiceService) GetInvoice(requester models.Requester, bookingID string) (*models.Invoice, error) {
+	invoices, err := s.GetInvoices(requester, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return nil, models.ErrInvoiceNotFound
+	}
+	last := &invoices[len(invoices)-1]
+	if last.DocumentURL == "" {
+		s.storeDocument(last)
+	}
+	return last, nil
+}
+
+func (s *InvoiceService) GetInvoices(requester models.Requester, bookingID string) ([]models.Invoice, error) {
+	if _, err := s.bookingOf(requester, bookingID); err != nil {
+		return nil, err
+	}
+	return s.invoices.GetInvoicesByBooking(bookingID)
+}
+
+func (s *InvoiceService) RenderInvoice(invoice *models.Invoice) ([]byte, error) {
+	return s.renderer.Render(invoice)
+}
+
+// CreditBooking cancels the outstanding invoice of a booking with a credit note. It fails with
+// models.ErrInvoiceNotFound when the booking has none.
+func (s *InvoiceService) CreditBooking(bookingID string, reason string) (*models.Invoice, error) {
+	issued, err := s.invoices.GetInvoicesByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	current := outstanding(issued)
+	if current == nil {
+		return nil, models.ErrInvoiceNotFound
+	}
+	return s.credit(current, reason)
+}
+
+// credit issues the credit note cancelling an invoice. Its ID is derived from the invoice, so an
+// invoice is credited once however often this runs.
+func (s *InvoiceService) credit(invoice *models.Invoice, reason string) (*models.Invoice, error) {
+	note := *invoice
+	note.InvoiceID = invoice.InvoiceID + "-credit"
+	note.Kind = models.KindCreditNote
+	note.CreditedID = invoice.InvoiceID
+	note.CreditedNumber = invoice.Number
+	note.Reason = reason
+	note.DocumentURL = ""
+	note.Lines = make([]models.InvoiceLine, len(invoice.Lines))
+	for i, line := range invoice.Lines {
+		line.NetAmount, line.TaxAmount, line.GrossAmount = -line.NetAmount, -line.TaxAmount, -line.GrossAmount
+		note.Lines[i] = line
+	}
+	note.Taxes = make([]models.TaxSummary, len(invoice.Taxes))
+	for i, tax := range invoice.Taxes {
+		tax.NetAmount, tax.TaxAmount = -tax.NetAmount, -tax.TaxAmount
+		note.Taxes[i] = tax
+	}
+	note.NetTotal, note.TaxTotal, note.GrossTotal = -invoice.NetTotal, -invoice.TaxTotal, -invoice.GrossTotal
+	note.IssuedAt = time.Now().UTC()
+	note.CreatedAt, note.UpdatedAt = time.Time{}, time.Time{}
+
+	if err := s.invoices.CreateInvoice(&note); err != nil {
+		if errors.Is(err, models.ErrDuplicateInvoice) {
+			return s.invoices.GetInvoiceByID(note.InvoiceID)
+		}
+		return nil, err
+	}
+	s.storeDocument(&note)
+	return &note, nil
+}
+
+// draft works out the invoice of a package booking as it stands, without ID and number.
+func (s *InvoiceService) draft(saga *models.Saga, billing models.BillingDetails) (*models.Invoice, error) {
+	issuer, err := s.issuerFor(billing.Address.Country)
+	if err != nil {
+		return nil, err
+	}
+	currency := paymentCurrency(saga)
+	if saga.Charged != nil {
+		currency = saga.Charged.Currency
+	}
+
+	type component struct {
+		productType, description string
+		gross                    float64
+	}
+	var components []component
+	if saga.FlightPrice != nil {
+		description := "Flight"
+		if saga.FlightDestination != "" {
+			description = "Flight to " + saga.FlightDestination
+		}
+		gross, err := s.convert(*saga.FlightPrice, currency)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component{models.ProductFlight, description, gross})
+	}
+	if saga.HotelPrice != nil {
+		stay := saga.Request.Hotel
+		description := fmt.Sprintf("Hotel stay %s to %s", stay.CheckIn.Format("2006-01-02"), stay.CheckOut.Format("2006-01-02"))
+		gross, err := s.convert(*saga.HotelPrice, currency)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component{models.ProductHotel, description, gross})
+	}
+	subtotal := 0.0
+	for _, c := range components {
+		subtotal += c.gross
+	}
+
+	invoice := &models.Invoice{
+		Kind:      models.KindInvoice,
+		Issuer:    *issuer,
+		BookingID: saga.BookingID,
+		UserID:    saga.UserID,
+		Billing:   billing,
+		Currency:  currency,
+	}
+	addLine := func(productType string, description string, gross float64) {
+		gross = roundAmount(gross)
+		if gross == 0 {
+			return
+		}
+		taxRate := issuer.TaxRates[productType]
+		net := roundAmount(gross / (1 + taxRate))
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Description: description,
+			ProductType: productType,
+			NetAmount:   net,
+			TaxRate:     taxRate,
+			TaxAmount:   roundAmount(gross - net),
+			GrossAmount: gross,
+		})
+	}
+	for _, c := range components {
+		addLine(c.productType, c.description, c.gross)
+	}
+
+	// A discount lowers the taxable amount of every product it was taken off, in proportion to
+	// their prices; the last one takes the rounding difference.
+	for _, discount := range saga.Discounts {
+		amount, err := s.convert(discount.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		name := "Discount " + discount.Name
+		if discount.Code != "" {
+			name += " (" + discount.Code + ")"
+		}
+		remaining := roundAmount(amount)
+		for i, c := range components {
+			share := remaining
+			if i < len(components)-1 && subtotal > 0 {
+				share = roundAmount(amount * c.gross / subtotal)
+			}
+			remaining = roundAmount(remaining - share)
+			addLine(c.productType, name+", "+c.productType, -share)
+		}
+	}
+
+	modifications, err := s.modifications.GetModificationsByBooking(saga.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, modification := range modifications {
+		if modification.Status != models.ModificationCompleted {
+			continue
+		}
+		for productType, charges := range map[string]*models.ChangeCharges{
+			models.ProductHotel: modification.Quote.Hotel, models.ProductFlight: modification.Quote.Flight} {
+			if charges == nil || charges.ChangeFees == 0 {
+				continue
+			}
+			currencyOfFees := charges.Currency
+			if currencyOfFees == "" {
+				currencyOfFees = modification.Quote.Currency
+			}
+			fees, err := s.convert(models.Money{Amount: charges.ChangeFees, Currency: currencyOfFees}, currency)
+			if err != nil {
+				return nil, err
+			}
+			addLine(productType, fmt.Sprintf("Change fee, %s, %s", productType, modification.CreatedAt.Format("2006-01-02")), fees)
+		}
+	}
+	sort.SliceStable(invoice.Lines, func(i, j int) bool {
+		return invoice.Lines[i].GrossAmount > 0 && invoice.Lines[j].GrossAmount < 0
+	})
+
+	taxes := map[float64]*models.TaxSummary{}
+	for _, line := range invoice.Lines {
+		summary, ok := taxes[line.TaxRate]
+		if !ok {
+			summary = &models.TaxSummary{Rate: line.TaxRate}
+			taxes[line.TaxRate] = summary
+		}
+		summary.NetAmount = roundAmount(summary.NetAmount + line.NetAmount)
+		summary.TaxAmount = roundAmount(summary.TaxAmount + line.TaxAmount)
+		invoice.NetTotal = roundAmount(invoice.NetTotal + line.NetAmount)
+		invoice.TaxTotal = roundAmount(invoice.TaxTotal + line.TaxAmount)
+		invoice.GrossTotal = roundAmount(invoice.GrossTotal + line.GrossAmount)
+	}
+	for _, summary := range taxes {
+		invoice.Taxes = append(invoice.Taxes, *summary)
+	}
+	sort.Slice(invoice.Taxes, func(i, j int) bool { return invoice.Taxes[i].Rate < invoice.Taxes[j].Rate })
+	return invoice, nil
+}
+
+// issuerFor returns the legal entity invoicing a billing country.
+func (s *InvoiceService) issuerFor(country string) (*models.LegalEntity, error) {
+	var fallback *models.LegalEntity
+	for i := range s.entities {
+		entity := &s.entities[i]
+		if len(entity.Countries) == 0 && fallback == nil {
+			fallback = entity
+		}
+		for _, served := range entity.Countries {
+			if strings.EqualFold(served, country) {
+				return entity, nil
+			}
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("%w: no legal entity invoices %s", models.ErrInvalidInvoice, country)
+	}
+	return fallback, nil
+}
+
+// storeDocument renders an invoice and stores the PDF. Failures are logged; the document is stored
+// again when the invoice is next read.
+func (s *InvoiceService) storeDocument(invoice *models.Invoice) {
+	document, err := s.renderer.Render(invoice)
+	if err != nil {
+		log.Printf("Failed to render invoice %s: %v\n", invoice.Number, err)
+		return
+	}
+	fileName := fmt.Sprintf("invoices/%s/%s.pdf", invoice.Issuer.Code, invoice.Number)
+	url, err := s.documents.UploadDocument(bytes.NewReader(document), fileName, "application/pdf")
+	if err != nil {
+		log.Printf("Failed to store invoice %s: %v\n", invoice.Number, err)
+		return
+	}
+	if err := s.invoices.SetInvoiceDocument(invoice.InvoiceID, url); err != nil {
+		log.Printf("Failed to record document of invoice %s: %v\n", invoice.Number, err)
+		return
+	}
+	invoice.DocumentURL = url
+}
+
+func (s *InvoiceService) bookingOf(requester models.Requester, bookingID string) (*models.Booking, error) {
+	booking, err := s.bookings.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && (requester.UserID == "" || booking.UserID != requester.UserID) {
+		return nil, models.ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+func (s *InvoiceService) convert(amount models.Money, currency string) (float64, error) {
+	if strings.EqualFold(amount.Currency, currency) {
+		return amount.Amount, nil
+	}
+	converted, err := s.rates.Convert(amount.Amount, amount.Currency, currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", models.ErrInvalidInvoice, err)
+	}
+	return converted, nil
+}
+
+// outstanding returns the last invoice of those issued for a booking unless a credit note
+// cancelled it.
+func outstanding(issued []models.Invoice) *models.Invoice {
+	credited := map[string]bool{}
+	for _, invoice := range issued {
+		if invoice.Kind == models.KindCreditNote {
+			credited[invoice.CreditedID] = true
+		}
+	}
+	for i := len(issued) - 1; i >= 0; i-- {
+		if issued[i].Kind == models.KindInvoice {
+			if credited[issued[i].InvoiceID] {
+				return nil
+			}
+			return &issued[i]
+		}
+	}
+	return nil
+}
+
+// sameInvoice reports whether an issued invoice already says what a draft does.
+func sameInvoice(issued *models.Invoice, draft *models.Invoice) bool {
+	if issued.Issuer.Code != draft.Issuer.Code || issued.Billing != draft.Billing || issued.Currency != draft.Currency ||
+		len(issued.Lines) != len(draft.Lines) {
+		return false
+	}
+	for i := range issued.Lines {
+		if issued.Lines[i] != draft.Lines[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// InvoiceCanceller credits the invoices of cancelled bookings. It takes the booking events from the
+// outbox relay like a message bus, so a credit note that could not be stored is tried again with
+// the event.
+type InvoiceCanceller struct {
+	invoices *InvoiceService
+}
+
+func NewInvoiceCanceller(invoices *InvoiceService) *InvoiceCanceller {
+	return &InvoiceCanceller{invoices: invoices}
+}
+
+func (c *InvoiceCanceller) Publish(event models.OutboxEvent) error {
+	if event.Type != models.EventBookingCancelled {
+		return nil
+	}
+	var booking models.BookingEvent
+	if err := json.Unmarshal(event.Payload, &booking); err != nil {
+		return fmt.Errorf("error decoding %s event %s: %v", event.Type, event.EventID, err)
+	}
+	_, err := c.invoices.CreditBooking(booking.BookingID, "Booking cancelled")
+	if errors.Is(err, models.ErrInvoiceNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (c *InvoiceCanceller) Close() error {
+	return nil
+}
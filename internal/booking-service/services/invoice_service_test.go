@@ -0,0 +1,108 @@
+package services
+
+import (
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/pkg/exchangerates"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInvoiceTotals(t *testing.T) {
+	entities := []models.LegalEntity{
+		{Code: "TRV-DE", Countries: []string{"DE"}, TaxRates: map[string]float64{models.ProductHotel: 0.07}},
+		{Code: "TRV-INT"},
+	}
+	checkIn := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	newSaga := func() *models.Saga {
+		return &models.Saga{
+			BookingID:   "booking-1",
+			UserID:      "user-1",
+			Request:     models.PackageBookingRequest{Hotel: models.HotelReservationRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3)}},
+			FlightPrice: &models.Money{Amount: 300, Currency: "EUR"},
+			HotelPrice:  &models.Money{Amount: 214, Currency: "EUR"},
+			Charged:     &models.Money{Amount: 514, Currency: "EUR"},
+		}
+	}
+	germany := models.BillingDetails{Name: "Ana Garcia", Address: models.Address{Line1: "Hauptstr. 1", City: "Berlin", Country: "DE"}}
+
+	tests := []struct {
+		name          string
+		billing       models.BillingDetails
+		discounts     models.AppliedDiscounts
+		modifications []models.Modification
+		wantIssuer    string
+		wantNet       float64
+		wantTax       float64
+		wantGross     float64
+		wantTaxes     []models.TaxSummary
+	}{
+		{
+			name:       "hotels are taxed at the rate of the issuer",
+			billing:    germany,
+			wantIssuer: "TRV-DE",
+			wantNet:    500, wantTax: 14, wantGross: 514,
+			wantTaxes: []models.TaxSummary{{Rate: 0, NetAmount: 300}, {Rate: 0.07, NetAmount: 200, TaxAmount: 14}},
+		},
+		{
+			name:       "other countries are invoiced by the entity without countries",
+			billing:    models.BillingDetails{Name: "Ana Garcia", Address: models.Address{Line1: "Main St 1", City: "Boston", Country: "US"}},
+			wantIssuer: "TRV-INT",
+			wantNet:    514, wantTax: 0, wantGross: 514,
+			wantTaxes: []models.TaxSummary{{Rate: 0, NetAmount: 514}},
+		},
+		{
+			name:       "a discount lowers each product in proportion to its price",
+			billing:    germany,
+			discounts:  models.AppliedDiscounts{{PromotionID: "summer", Name: "Summer", Amount: models.Money{Amount: 51.40, Currency: "EUR"}}},
+			wantIssuer: "TRV-DE",
+			wantNet:    450, wantTax: 12.60, wantGross: 462.60,
+			wantTaxes: []models.TaxSummary{{Rate: 0, NetAmount: 270}, {Rate: 0.07, NetAmount: 180, TaxAmount: 12.60}},
+		},
+		{
+			name:    "completed changes add their fees",
+			billing: germany,
+			modifications: []models.Modification{
+				{BookingID: "booking-1", Status: models.ModificationCompleted,
+					Quote: models.ModificationQuote{Hotel: &models.ChangeCharges{ChangeFees: 25}, Currency: "EUR"}},
+				{BookingID: "booking-1", Status: models.ModificationQuoted,
+					Quote: models.ModificationQuote{Flight: &models.ChangeCharges{ChangeFees: 40}, Currency: "EUR"}},
+			},
+			wantIssuer: "TRV-DE",
+			wantNet:    523.36, wantTax: 15.64, wantGross: 539,
+			wantTaxes: []models.TaxSummary{{Rate: 0, NetAmount: 300}, {Rate: 0.07, NetAmount: 223.36, TaxAmount: 15.64}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saga := newSaga()
+			saga.Discounts = tt.discounts
+			s := NewInvoiceService(nil, nil, nil, &storedModifications{modifications: tt.modifications},
+				exchangerates.NewStaticExchangeRates(), nil, nil, entities)
+
+			invoice, err := s.draft(saga, tt.billing)
+			if err != nil {
+				t.Fatalf("draft: %v", err)
+			}
+			if invoice.Issuer.Code != tt.wantIssuer {
+				t.Errorf("issued by %s, want %s", invoice.Issuer.Code, tt.wantIssuer)
+			}
+			if invoice.NetTotal != tt.wantNet || invoice.TaxTotal != tt.wantTax || invoice.GrossTotal != tt.wantGross {
+				t.Errorf("net %.2f, tax %.2f, gross %.2f, want %.2f, %.2f and %.2f", invoice.NetTotal, invoice.TaxTotal,
+					invoice.GrossTotal, tt.wantNet, tt.wantTax, tt.wantGross)
+			}
+			if !reflect.DeepEqual(invoice.Taxes, tt.wantTaxes) {
+				t.Errorf("taxes = %+v, want %+v", invoice.Taxes, tt.wantTaxes)
+			}
+			for i, line := range invoice.Lines {
+				if roundAmount(line.NetAmount+line.TaxAmount) != line.GrossAmount {
+					t.Errorf("line %d: net %.2f and tax %.2f do not add up to %.2f", i, line.NetAmount, line.TaxAmount, line.GrossAmount)
+				}
+				if i > 0 && line.GrossAmount > 0 && invoice.Lines[i-1].GrossAmount < 0 {
+					t.Errorf("line %d is charged after a discount", i)
+				}
+			}
+		})
+	}
+}
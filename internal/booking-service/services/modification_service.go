@@ -0,0 +1,586 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModificationPolicy sets what changing a package booking costs and how long a quote holds.
+type ModificationPolicy struct {
+	HotelChangeFee models.Money  // Charged for every change of the hotel stay.
+	QuoteTTL       time.Duration // How long a quote can be confirmed; the new stay is held until then.
+}
+
+// ModificationService changes the hotel stay and flights of package bookings. A change is quoted
+// first: the new stay is held, which checks that it is available, and the flight service prices
+// the new itinerary. Once confirmed, the difference is collected, the new stay confirmed and the
+// flights changed; a refusal up to there undoes what was done. Only then is the old stay released
+// and an amount owed to the traveller refunded, so the booking never ends up half changed.
+type ModificationService struct {
+	modifications ports.ModificationDB
+	sagas         ports.SagaDB
+	bookings      ports.BookingDB
+	flights       ports.FlightChanges
+	hotels        ports.HotelReservations
+	payments      ports.Payments
+	rates         ports.ExchangeRates
+	policy        ModificationPolicy
+
+	mu     sync.Mutex
+	active map[string]bool // Bookings this process is modifying, so a second request or the sweep waits.
+}
+
+func NewModificationService(modifications ports.ModificationDB, sagas ports.SagaDB, bookings ports.BookingDB,
+	flights ports.FlightChanges, hotels ports.HotelReservations, payments ports.Payments, rates ports.ExchangeRates,
+	policy ModificationPolicy) *ModificationService {
+	return &ModificationService{
+		modifications: modifications,
+		sagas:         sagas,
+		bookings:      bookings,
+		flights:       flights,
+		hotels:        hotels,
+		payments:      payments,
+		rates:         rates,
+		policy:        policy,
+		active:        make(map[string]bool),
+	}
+}
+
+// RequestModification quotes a change of a confirmed package booking and holds the new stay until
+// the quote expires. A booking has at most one quoted or confirming modification at a time.
+func (s *ModificationService) RequestModification(requester models.Requester, bookingID string,
+	request models.ModificationRequest, change models.Change) (*models.Modification, error) {
+	if request.Hotel == nil && request.Flight == nil {
+		return nil, fmt.Errorf("%w: a hotel or flight change is required", models.ErrInvalidModification)
+	}
+	if request.Flight != nil && request.Flight.ItineraryID == "" {
+		return nil, fmt.Errorf("%w: the flight change needs an itinerary ID", models.ErrInvalidModification)
+	}
+	booking, err := s.bookingOf(requester, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.ProductType != models.ProductPackage || booking.BookingStatus != packageConfirmed {
+		return nil, fmt.Errorf("%w: only confirmed package bookings can be modified", models.ErrInvalidModification)
+	}
+	saga, err := s.sagas.GetSagaByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status != models.SagaCompleted {
+		return nil, fmt.Errorf("%w: the package booking is not completed", models.ErrInvalidModification)
+	}
+
+	if !s.claim(bookingID) {
+		return nil, fmt.Errorf("%w: the booking is being modified", models.ErrModificationConflict)
+	}
+	defer s.release(bookingID)
+	existing, err := s.modifications.GetModificationsByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		other := &existing[i]
+		if other.Status == models.ModificationQuoted && time.Now().After(other.ExpiresAt) {
+			// The sweep has not got to it yet.
+			if err := s.close(other, models.ModificationExpired); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if other.Status.Open() {
+			return nil, fmt.Errorf("%w: modification %s is %s", models.ErrModificationConflict, other.ModificationID, other.Status)
+		}
+	}
+
+	currency := paymentCurrency(saga)
+	if saga.Charged != nil {
+		currency = saga.Charged.Currency
+	}
+	modification := &models.Modification{
+		ModificationID: uuid.NewString(),
+		BookingID:      bookingID,
+		SagaID:         saga.SagaID,
+		UserID:         saga.UserID,
+		Status:         models.ModificationQuoted,
+		Request:        request,
+		Quote:          models.ModificationQuote{Currency: currency},
+		RequestedBy:    change.Actor,
+		Reason:         change.Reason,
+	}
+	if request.Hotel != nil {
+		if err := s.quoteHotel(saga, modification); err != nil {
+			return nil, err
+		}
+	}
+	if request.Flight != nil {
+		if err := s.quoteFlight(saga, modification); err != nil {
+			s.releaseHold(modification)
+			return nil, err
+		}
+	}
+	quote := &modification.Quote
+	for _, charges := range []*models.ChangeCharges{quote.Hotel, quote.Flight} {
+		if charges != nil {
+			quote.PriceDifference += charges.PriceDifference
+			quote.ChangeFees += charges.ChangeFees
+		}
+	}
+	quote.PriceDifference = roundAmount(quote.PriceDifference)
+	quote.ChangeFees = roundAmount(quote.ChangeFees)
+	quote.AmountDue = roundAmount(quote.PriceDifference + quote.ChangeFees)
+	modification.ExpiresAt = time.Now().UTC().Add(s.policy.QuoteTTL)
+
+	if err := s.modifications.CreateModification(modification); err != nil {
+		s.releaseHold(modification)
+		return nil, err
+	}
+	return modification, nil
+}
+
+// quoteHotel holds the stay the booking changes to and prices it against the one booked.
+func (s *ModificationService) quoteHotel(saga *models.Saga, modification *models.Modification) error {
+	change := modification.Request.Hotel
+	stay := saga.Request.Hotel
+	if change.CheckIn != nil {
+		stay.CheckIn = *change.CheckIn
+	}
+	if change.CheckOut != nil {
+		stay.CheckOut = *change.CheckOut
+	}
+	if change.RoomID != "" {
+		stay.RoomID = change.RoomID
+	}
+	if change.Guests != 0 {
+		stay.Guests = change.Guests
+	}
+	booked := saga.Request.Hotel
+	if stay.CheckIn.Equal(booked.CheckIn) && stay.CheckOut.Equal(booked.CheckOut) && stay.RoomID == booked.RoomID && stay.Guests == booked.Guests {
+		return fmt.Errorf("%w: the hotel change does not change the stay", models.ErrInvalidModification)
+	}
+	if !stay.CheckIn.Before(stay.CheckOut) || stay.Guests <= 0 {
+		return fmt.Errorf("%w: check-in must be before check-out and there must be at least one guest", models.ErrInvalidModification)
+	}
+	if stay.CheckIn.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		return fmt.Errorf("%w: check-in is in the past", models.ErrInvalidModification)
+	}
+
+	reservation, err := s.hotels.ReserveHotel(modification.ModificationID+"/hold", saga.UserID, stay)
+	if err != nil {
+		if errors.Is(err, models.ErrStepRejected) {
+			return fmt.Errorf("%w: %v", models.ErrModificationUnavailable, err)
+		}
+		return err
+	}
+	if reservation.Price.Currency == "" {
+		reservation.Price.Currency = modification.Quote.Currency
+	}
+	modification.Hotel = &stay
+	modification.HotelHoldID = reservation.ID
+	modification.HotelPrice = &reservation.Price
+
+	newPrice, err := s.convert(reservation.Price, modification.Quote.Currency)
+	if err != nil {
+		s.releaseHold(modification)
+		return err
+	}
+	oldPrice, err := s.convert(*saga.HotelPrice, modification.Quote.Currency)
+	if err != nil {
+		s.releaseHold(modification)
+		return err
+	}
+	fee, err := s.convert(s.policy.HotelChangeFee, modification.Quote.Currency)
+	if err != nil {
+		s.releaseHold(modification)
+		return err
+	}
+	modification.Quote.Hotel = &models.ChangeCharges{
+		PriceDifference: roundAmount(newPrice - oldPrice),
+		ChangeFees:      roundAmount(fee),
+		Currency:        modification.Quote.Currency,
+	}
+	return nil
+}
+
+// quoteFlight asks the flight service what moving onto the new itinerary costs under the fare rules.
+func (s *ModificationService) quoteFlight(saga *models.Saga, modification *models.Modification) error {
+	charges, err := s.flights.QuoteFlightChange(saga.FlightBookingID, modification.Request.Flight.ItineraryID)
+	if err != nil {
+		if errors.Is(err, models.ErrStepRejected) {
+			return fmt.Errorf("%w: %v", models.ErrModificationUnavailable, err)
+		}
+		return err
+	}
+	difference, err := s.convert(models.Money{Amount: charges.PriceDifference, Currency: charges.Currency}, modification.Quote.Currency)
+	if err != nil {
+		return err
+	}
+	fees, err := s.convert(models.Money{Amount: charges.ChangeFees, Currency: charges.Currency}, modification.Quote.Currency)
+	if err != nil {
+		return err
+	}
+	modification.Quote.Flight = &models.ChangeCharges{
+		PriceDifference: roundAmount(difference),
+		ChangeFees:      roundAmount(fees),
+		Currency:        modification.Quote.Currency,
+	}
+	return nil
+}
+
+func (s *ModificationService) GetModification(requester models.Requester, bookingID string, id string) (*models.Modification, error) {
+	if _, err := s.bookingOf(requester, bookingID); err != nil {
+		return nil, err
+	}
+	modification, err := s.modifications.GetModificationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if modification.BookingID != bookingID {
+		return nil, models.ErrModificationNotFound
+	}
+	return modification, nil
+}
+
+func (s *ModificationService) GetModifications(requester models.Requester, bookingID string) ([]models.Modification, error) {
+	if _, err := s.bookingOf(requester, bookingID); err != nil {
+		return nil, err
+	}
+	return s.modifications.GetModificationsByBooking(bookingID)
+}
+
+// ConfirmModification applies a quoted change. The returned modification is completed, failed when
+// another service refused part of it (which was then undone), or still confirming when a service
+// could not be reached; the sweep then finishes it. Confirming a completed modification again
+// returns it unchanged.
+func (s *ModificationService) ConfirmModification(requester models.Requester, bookingID string, id string) (*models.Modification, error) {
+	modification, err := s.GetModification(requester, bookingID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch modification.Status {
+	case models.ModificationCompleted, models.ModificationConfirming:
+		return modification, nil
+	case models.ModificationQuoted:
+	default:
+		return nil, fmt.Errorf("%w: modification is %s", models.ErrModificationConflict, modification.Status)
+	}
+	if time.Now().After(modification.ExpiresAt) {
+		if err := s.close(modification, models.ModificationExpired); err != nil {
+			return nil, err
+		}
+		return nil, models.ErrModificationExpired
+	}
+
+	if !s.claim(bookingID) {
+		return nil, fmt.Errorf("%w: the booking is being modified", models.ErrModificationConflict)
+	}
+	defer s.release(bookingID)
+	modification.Status = models.ModificationConfirming
+	if err := s.modifications.UpdateModification(modification, models.ModificationQuoted); err != nil {
+		return nil, err
+	}
+	if err := s.apply(modification); err != nil {
+		log.Printf("Modification %s of booking %s paused: %v\n", modification.ModificationID, bookingID, err)
+	}
+	return modification, nil
+}
+
+// DeclineModification gives up a quoted change and releases the stay held for it.
+func (s *ModificationService) DeclineModification(requester models.Requester, bookingID string, id string) (*models.Modification, error) {
+	modification, err := s.GetModification(requester, bookingID, id)
+	if err != nil {
+		return nil, err
+	}
+	if modification.Status != models.ModificationQuoted {
+		return nil, fmt.Errorf("%w: modification is %s", models.ErrModificationConflict, modification.Status)
+	}
+	if err := s.close(modification, models.ModificationDeclined); err != nil {
+		return nil, err
+	}
+	return modification, nil
+}
+
+// Sweep expires the quotes nobody confirmed in time and resumes the modifications a crash or an
+// unreachable service left confirming.
+func (s *ModificationService) Sweep() error {
+	quoted, err := s.modifications.GetModificationsByStatus(models.ModificationQuoted)
+	if err != nil {
+		return err
+	}
+	for i := range quoted {
+		modification := &quoted[i]
+		if time.Now().Before(modification.ExpiresAt) {
+			continue
+		}
+		if err := s.close(modification, models.ModificationExpired); err != nil && !errors.Is(err, models.ErrModificationConflict) {
+			log.Printf("Failed to expire modification %s: %v\n", modification.ModificationID, err)
+		}
+	}
+
+	confirming, err := s.modifications.GetModificationsByStatus(models.ModificationConfirming)
+	if err != nil {
+		return err
+	}
+	for i := range confirming {
+		modification := &confirming[i]
+		if !s.claim(modification.BookingID) {
+			continue
+		}
+		log.Printf("Resuming modification %s of booking %s\n", modification.ModificationID, modification.BookingID)
+		err := s.apply(modification)
+		s.release(modification.BookingID)
+		if err != nil {
+			log.Printf("Failed to resume modification %s: %v\n", modification.ModificationID, err)
+		}
+	}
+	return nil
+}
+
+// SweepPeriodically runs Sweep right away and then at every interval. It is meant to run in its
+// own goroutine on a single replica.
+func (s *ModificationService) SweepPeriodically(interval time.Duration) {
+	for {
+		if err := s.Sweep(); err != nil {
+			log.Printf("Failed to load open modifications: %v\n", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// apply carries out a confirming modification from where it stopped. Every step is stored before
+// the next one starts and is keyed by the modification, so repeating it after a crash does not
+// charge or book twice. An error means a service could not be reached and the modification stays
+// confirming.
+func (s *ModificationService) apply(modification *models.Modification) error {
+	if modification.Error != "" {
+		return s.undo(modification)
+	}
+	saga, err := s.sagas.GetSagaByID(modification.SagaID)
+	if err != nil {
+		return err
+	}
+	key := modification.ModificationID
+
+	// Until the flights changed, a refusal is undone and the booking stays as it was.
+	if modification.Quote.AmountDue > 0 && modification.PaymentID == "" {
+		amount := models.Money{Amount: modification.Quote.AmountDue, Currency: modification.Quote.Currency}
+		payment, err := s.payments.TakePayment(key+"/collect", saga.UserID, saga.BookingID, saga.Request.Payment.Method, amount)
+		if err != nil {
+			return s.fail(modification, "collecting the difference", err)
+		}
+		modification.PaymentID = payment.ID
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+	if modification.HotelHoldID != "" && !modification.HotelConfirmed {
+		if err := s.hotels.ConfirmHotel(key+"/hold/confirm", modification.HotelHoldID); err != nil {
+			return s.fail(modification, "confirming the new stay", err)
+		}
+		modification.HotelConfirmed = true
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+	if modification.Request.Flight != nil && modification.FlightPrice == nil {
+		price, err := s.flights.ChangeFlight(key+"/flight", saga.FlightBookingID, modification.Request.Flight.ItineraryID)
+		if err != nil {
+			return s.fail(modification, "changing the flights", err)
+		}
+		modification.FlightPrice = price
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+
+	// The change is made; what is left cannot be refused in a way that undoing would fix.
+	if modification.HotelHoldID != "" && !modification.OldHotelReleased {
+		err := s.hotels.CancelHotel(key+"/release", saga.HotelBookingID)
+		if err != nil && !errors.Is(err, models.ErrStepRejected) {
+			return err
+		}
+		if err != nil {
+			log.Printf("Modification %s could not release hotel booking %s: %v\n", modification.ModificationID, saga.HotelBookingID, err)
+		}
+		modification.OldHotelReleased = true
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+	if modification.Quote.AmountDue < 0 && !modification.Refunded {
+		if err := s.refund(modification, saga); err != nil {
+			return err
+		}
+		modification.Refunded = true
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+	if !modification.SagaUpdated {
+		s.applyToPackage(modification, saga)
+		if err := s.sagas.UpdateSaga(saga); err != nil {
+			return fmt.Errorf("error saving package booking %s: %v", saga.SagaID, err)
+		}
+		modification.SagaUpdated = true
+		if err := s.save(modification); err != nil {
+			return err
+		}
+	}
+	if modification.Hotel != nil {
+		checkIn := modification.Hotel.CheckIn
+		_, err := s.bookings.UpdateBooking(modification.BookingID, &models.Booking{TravelDate: &checkIn}, models.AnyVersion,
+			models.Change{Actor: modification.RequestedBy, Reason: modification.Reason})
+		if err != nil {
+			return err
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	modification.CompletedAt = &completedAt
+	modification.Status = models.ModificationCompleted
+	return s.modifications.UpdateModification(modification, models.ModificationConfirming)
+}
+
+// refund pays back what the traveller is owed on the payment of the package, at most what it
+// charged. A refusal is logged for manual follow-up since the change itself is already made.
+func (s *ModificationService) refund(modification *models.Modification, saga *models.Saga) error {
+	if saga.PaymentID == "" || saga.Charged == nil {
+		return nil // Paid with points only.
+	}
+	amount := math.Min(-modification.Quote.AmountDue, saga.Charged.Amount)
+	if amount <= 0 {
+		return nil
+	}
+	err := s.payments.RefundPayment(modification.ModificationID+"/refund", saga.PaymentID,
+		models.Money{Amount: roundAmount(amount), Currency: modification.Quote.Currency})
+	if errors.Is(err, models.ErrStepRejected) {
+		log.Printf("Modification %s could not refund %.2f %s: %v\n", modification.ModificationID, amount, modification.Quote.Currency, err)
+		return nil
+	}
+	return err
+}
+
+// applyToPackage records the new stay, flights and charge on the package booking.
+func (s *ModificationService) applyToPackage(modification *models.Modification, saga *models.Saga) {
+	if modification.Hotel != nil {
+		saga.Request.Hotel = *modification.Hotel
+		saga.HotelBookingID = modification.HotelHoldID
+		saga.HotelPrice = modification.HotelPrice
+	}
+	if modification.Request.Flight != nil {
+		saga.Request.Flight.ItineraryID = modification.Request.Flight.ItineraryID
+		saga.FlightPrice = modification.FlightPrice
+	}
+	if saga.Charged != nil {
+		saga.Charged.Amount = math.Max(roundAmount(saga.Charged.Amount+modification.Quote.AmountDue), 0)
+	}
+	saga.UpdatedAt = time.Now().UTC()
+}
+
+// fail undoes a modification a service refused. Other errors leave it confirming to be resumed.
+func (s *ModificationService) fail(modification *models.Modification, step string, err error) error {
+	if !errors.Is(err, models.ErrStepRejected) {
+		return err
+	}
+	modification.Error = fmt.Sprintf("%s: %v", step, err)
+	if err := s.save(modification); err != nil {
+		return err
+	}
+	return s.undo(modification)
+}
+
+// undo refunds what was collected and cancels the new stay, then marks the modification failed.
+func (s *ModificationService) undo(modification *models.Modification) error {
+	key := modification.ModificationID
+	if modification.PaymentID != "" {
+		amount := models.Money{Amount: modification.Quote.AmountDue, Currency: modification.Quote.Currency}
+		if err := s.payments.RefundPayment(key+"/collect/undo", modification.PaymentID, amount); err != nil && !errors.Is(err, models.ErrStepRejected) {
+			return err
+		}
+	}
+	if modification.HotelHoldID != "" {
+		if err := s.hotels.CancelHotel(key+"/hold/undo", modification.HotelHoldID); err != nil && !errors.Is(err, models.ErrStepRejected) {
+			return err
+		}
+	}
+	modification.Status = models.ModificationFailed
+	return s.modifications.UpdateModification(modification, models.ModificationConfirming)
+}
+
+// close ends a quoted modification without applying it and releases the stay held for it.
+func (s *ModificationService) close(modification *models.Modification, status models.ModificationStatus) error {
+	modification.Status = status
+	if err := s.modifications.UpdateModification(modification, models.ModificationQuoted); err != nil {
+		return err
+	}
+	s.releaseHold(modification)
+	return nil
+}
+
+// releaseHold cancels the stay held for a quote. The hotel service lets unconfirmed holds lapse, so
+// a failure is only logged.
+func (s *ModificationService) releaseHold(modification *models.Modification) {
+	if modification.HotelHoldID == "" {
+		return
+	}
+	if err := s.hotels.CancelHotel(modification.ModificationID+"/hold/undo", modification.HotelHoldID); err != nil {
+		log.Printf("Failed to release hotel hold %s of modification %s: %v\n", modification.HotelHoldID, modification.ModificationID, err)
+	}
+}
+
+// bookingOf returns a booking its owner or a privileged requester asks for. Others are told it
+// does not exist.
+func (s *ModificationService) bookingOf(requester models.Requester, bookingID string) (*models.Booking, error) {
+	booking, err := s.bookings.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && (requester.UserID == "" || booking.UserID != requester.UserID) {
+		return nil, models.ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+func (s *ModificationService) convert(amount models.Money, currency string) (float64, error) {
+	if strings.EqualFold(amount.Currency, currency) {
+		return amount.Amount, nil
+	}
+	converted, err := s.rates.Convert(amount.Amount, amount.Currency, currency)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", models.ErrInvalidModification, err)
+	}
+	return converted, nil
+}
+
+func (s *ModificationService) save(modification *models.Modification) error {
+	if err := s.modifications.UpdateModification(modification, models.ModificationConfirming); err != nil {
+		return fmt.Errorf("error saving modification %s: %v", modification.ModificationID, err)
+	}
+	return nil
+}
+
+// claim marks a booking as being modified by this process; it returns false if it already is.
+func (s *ModificationService) claim(bookingID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[bookingID] {
+		return false
+	}
+	s.active[bookingID] = true
+	return true
+}
+
+func (s *ModificationService) release(bookingID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, bookingID)
+}
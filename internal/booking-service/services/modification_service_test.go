@@ -0,0 +1,263 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/exchangerates"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// storedModifications keeps modifications in memory and only updates one still in the status the
+// caller expects.
+type storedModifications struct {
+	ports.ModificationDB
+	modifications []models.Modification
+}
+
+func (s *storedModifications) CreateModification(modification *models.Modification) error {
+	s.modifications = append(s.modifications, *modification)
+	return nil
+}
+
+func (s *storedModifications) GetModificationByID(id string) (*models.Modification, error) {
+	for _, modification := range s.modifications {
+		if modification.ModificationID == id {
+			return &modification, nil
+		}
+	}
+	return nil, models.ErrModificationNotFound
+}
+
+func (s *storedModifications) GetModificationsByBooking(bookingID string) ([]models.Modification, error) {
+	var modifications []models.Modification
+	for _, modification := range s.modifications {
+		if modification.BookingID == bookingID {
+			modifications = append(modifications, modification)
+		}
+	}
+	return modifications, nil
+}
+
+func (s *storedModifications) UpdateModification(modification *models.Modification, from models.ModificationStatus) error {
+	for i := range s.modifications {
+		if s.modifications[i].ModificationID != modification.ModificationID {
+			continue
+		}
+		if s.modifications[i].Status != from {
+			return models.ErrModificationConflict
+		}
+		s.modifications[i] = *modification
+		return nil
+	}
+	return models.ErrModificationNotFound
+}
+
+// bookedPackage stores a confirmed package of a 300 EUR flight and a 200 EUR stay, paid in full.
+func bookedPackage(t *testing.T, bookings *storedBookings, sagas *storedSagas) *models.Saga {
+	t.Helper()
+	checkIn := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 2, 0)
+	booking := &models.Booking{BookingID: "booking-1", UserID: "user-1", BookingStatus: packageConfirmed,
+		ProductType: models.ProductPackage, TravelDate: &checkIn}
+	if err := bookings.CreateBooking(booking, sagaChange("package booking started")); err != nil {
+		t.Fatalf("CreateBooking: %v", err)
+	}
+	saga := &models.Saga{
+		SagaID:    "saga-1",
+		BookingID: booking.BookingID,
+		UserID:    booking.UserID,
+		Status:    models.SagaCompleted,
+		Request: models.PackageBookingRequest{
+			UserID:  booking.UserID,
+			Flight:  models.FlightReservationRequest{ItineraryID: "itinerary-1", Adults: 1},
+			Hotel:   models.HotelReservationRequest{HotelID: "hotel", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 1},
+			Payment: models.PaymentRequest{Method: "card-token"},
+		},
+		FlightBookingID: "flight-1",
+		FlightPrice:     &models.Money{Amount: 300, Currency: "EUR"},
+		HotelBookingID:  "hotel-1",
+		HotelPrice:      &models.Money{Amount: 200, Currency: "EUR"},
+		PaymentID:       "payment-1",
+		Charged:         &models.Money{Amount: 500, Currency: "EUR"},
+	}
+	if err := sagas.CreateSaga(saga); err != nil {
+		t.Fatalf("CreateSaga: %v", err)
+	}
+	return saga
+}
+
+func TestModificationRepricing(t *testing.T) {
+	rejected := fmt.Errorf("%w: fare does not allow changes", models.ErrStepRejected)
+	longerStay := func(saga *models.Saga) *models.HotelChange {
+		checkOut := saga.Request.Hotel.CheckOut.AddDate(0, 0, 1)
+		return &models.HotelChange{CheckOut: &checkOut}
+	}
+	laterStay := func(saga *models.Saga) *models.HotelChange {
+		checkIn, checkOut := saga.Request.Hotel.CheckIn.AddDate(0, 0, 7), saga.Request.Hotel.CheckOut.AddDate(0, 0, 7)
+		return &models.HotelChange{CheckIn: &checkIn, CheckOut: &checkOut}
+	}
+
+	tests := []struct {
+		name         string
+		hotel        func(saga *models.Saga) *models.HotelChange
+		flight       bool
+		hotelPrice   float64
+		flightChange models.ChangeCharges
+		flightPrice  float64
+		fail         map[string]error
+		wantQuote    models.ModificationQuote // Only the totals are compared.
+		wantStatus   models.ModificationStatus
+		wantCalls    []string
+		wantCharged  float64 // Charged for the package afterwards.
+		wantMoved    bool    // The travel date of the booking follows the new check-in.
+	}{
+		{
+			name:        "a dearer stay collects the difference and the fee",
+			hotel:       longerStay,
+			hotelPrice:  260,
+			wantQuote:   models.ModificationQuote{PriceDifference: 60, ChangeFees: 25, AmountDue: 85},
+			wantStatus:  models.ModificationCompleted,
+			wantCalls:   []string{"ReserveHotel", "TakePayment", "ConfirmHotel", "CancelHotel"},
+			wantCharged: 585,
+		},
+		{
+			name:        "a cheaper stay refunds the difference less the fee",
+			hotel:       laterStay,
+			hotelPrice:  120,
+			wantQuote:   models.ModificationQuote{PriceDifference: -80, ChangeFees: 25, AmountDue: -55},
+			wantStatus:  models.ModificationCompleted,
+			wantCalls:   []string{"ReserveHotel", "ConfirmHotel", "CancelHotel", "RefundPayment"},
+			wantCharged: 445,
+			wantMoved:   true,
+		},
+		{
+			name:         "a flight change is priced by the flight service",
+			flight:       true,
+			flightChange: models.ChangeCharges{PriceDifference: 40, ChangeFees: 50, Currency: "EUR"},
+			flightPrice:  340,
+			wantQuote:    models.ModificationQuote{PriceDifference: 40, ChangeFees: 50, AmountDue: 90},
+			wantStatus:   models.ModificationCompleted,
+			wantCalls:    []string{"TakePayment", "ChangeFlight"},
+			wantCharged:  590,
+		},
+		{
+			name:         "hotel and flight changes add up",
+			hotel:        longerStay,
+			hotelPrice:   260,
+			flight:       true,
+			flightChange: models.ChangeCharges{PriceDifference: -100, ChangeFees: 30, Currency: "EUR"},
+			flightPrice:  200,
+			wantQuote:    models.ModificationQuote{PriceDifference: -40, ChangeFees: 55, AmountDue: 15},
+			wantStatus:   models.ModificationCompleted,
+			wantCalls:    []string{"ReserveHotel", "TakePayment", "ConfirmHotel", "ChangeFlight", "CancelHotel"},
+			wantCharged:  515,
+		},
+		{
+			name:         "the refund is capped at what was charged",
+			hotel:        laterStay,
+			hotelPrice:   20,
+			flight:       true,
+			flightChange: models.ChangeCharges{PriceDifference: -400, Currency: "EUR"},
+			wantQuote:    models.ModificationQuote{PriceDifference: -580, ChangeFees: 25, AmountDue: -555},
+			wantStatus:   models.ModificationCompleted,
+			wantCalls:    []string{"ReserveHotel", "ConfirmHotel", "ChangeFlight", "CancelHotel", "RefundPayment"},
+			wantCharged:  0,
+			wantMoved:    true,
+		},
+		{
+			name:         "a refused flight change refunds the difference and cancels the new stay",
+			hotel:        laterStay,
+			hotelPrice:   260,
+			flight:       true,
+			flightChange: models.ChangeCharges{PriceDifference: 40, Currency: "EUR"},
+			fail:         map[string]error{"ChangeFlight": rejected},
+			wantQuote:    models.ModificationQuote{PriceDifference: 100, ChangeFees: 25, AmountDue: 125},
+			wantStatus:   models.ModificationFailed,
+			wantCalls:    []string{"ReserveHotel", "TakePayment", "ConfirmHotel", "ChangeFlight", "RefundPayment", "CancelHotel"},
+			wantCharged:  500,
+		},
+		{
+			name:         "an unreachable flight service leaves the change confirming",
+			flight:       true,
+			flightChange: models.ChangeCharges{PriceDifference: 40, Currency: "EUR"},
+			fail:         map[string]error{"ChangeFlight": errors.New("connection refused")},
+			wantQuote:    models.ModificationQuote{PriceDifference: 40, AmountDue: 40},
+			wantStatus:   models.ModificationConfirming,
+			wantCalls:    []string{"TakePayment", "ChangeFlight"},
+			wantCharged:  500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings, sagas := &storedBookings{bookings: map[string]models.Booking{}}, &storedSagas{sagas: map[string]models.Saga{}}
+			saga := bookedPackage(t, bookings, sagas)
+			partners := &fakePartners{hotelPrice: tt.hotelPrice, flightChange: tt.flightChange,
+				flightPrice: models.Money{Amount: tt.flightPrice, Currency: "EUR"}}
+			s := NewModificationService(&storedModifications{}, sagas, bookings, partners, partners, partners, exchangerates.NewStaticExchangeRates(),
+				ModificationPolicy{HotelChangeFee: models.Money{Amount: 25, Currency: "EUR"}, QuoteTTL: time.Hour})
+
+			var request models.ModificationRequest
+			if tt.hotel != nil {
+				request.Hotel = tt.hotel(saga)
+			}
+			if tt.flight {
+				request.Flight = &models.FlightChange{ItineraryID: "itinerary-2"}
+			}
+			requester := models.Requester{UserID: "user-1"}
+			quoted, err := s.RequestModification(requester, saga.BookingID, request,
+				models.Change{Actor: models.Actor{Type: models.ActorUser, ID: "user-1"}})
+			if err != nil {
+				t.Fatalf("RequestModification: %v", err)
+			}
+			quote := quoted.Quote
+			if quote.PriceDifference != tt.wantQuote.PriceDifference || quote.ChangeFees != tt.wantQuote.ChangeFees ||
+				quote.AmountDue != tt.wantQuote.AmountDue || quote.Currency != "EUR" {
+				t.Errorf("quote = %+v, want %+v in EUR", quote, tt.wantQuote)
+			}
+
+			partners.fail = tt.fail
+			confirmed, err := s.ConfirmModification(requester, saga.BookingID, quoted.ModificationID)
+			if err != nil {
+				t.Fatalf("ConfirmModification: %v", err)
+			}
+			if confirmed.Status != tt.wantStatus {
+				t.Errorf("status = %s (%s), want %s", confirmed.Status, confirmed.Error, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(partners.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", partners.calls, tt.wantCalls)
+			}
+
+			stored, err := sagas.GetSagaByID(saga.SagaID)
+			if err != nil {
+				t.Fatalf("GetSagaByID: %v", err)
+			}
+			if stored.Charged.Amount != tt.wantCharged {
+				t.Errorf("charged %.2f for the package, want %.2f", stored.Charged.Amount, tt.wantCharged)
+			}
+			if tt.wantStatus == models.ModificationCompleted && request.Hotel != nil &&
+				(stored.HotelPrice.Amount != tt.hotelPrice || stored.HotelBookingID != quoted.HotelHoldID) {
+				t.Errorf("package has stay %s at %.2f, want %s at %.2f", stored.HotelBookingID, stored.HotelPrice.Amount,
+					quoted.HotelHoldID, tt.hotelPrice)
+			}
+			if tt.wantStatus == models.ModificationCompleted && tt.flight && stored.FlightPrice.Amount != tt.flightPrice {
+				t.Errorf("package has flights at %.2f, want %.2f", stored.FlightPrice.Amount, tt.flightPrice)
+			}
+
+			booking, err := bookings.GetBookingByID(saga.BookingID)
+			if err != nil {
+				t.Fatalf("GetBookingByID: %v", err)
+			}
+			wantTravelDate := saga.Request.Hotel.CheckIn
+			if tt.wantMoved {
+				wantTravelDate = *request.Hotel.CheckIn
+			}
+			if !booking.TravelDate.Equal(wantTravelDate) {
+				t.Errorf("travel date = %v, want %v", booking.TravelDate, wantTravelDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"log"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"time"
+)
+
+// OutboxRelay publishes the events waiting in the outbox to the message bus, oldest first. An
+// event that cannot be published holds back the ones after it, so consumers see the changes of a
+// booking in order. An event can be published twice when marking it published fails; consumers
+// tell repeats apart by the event ID.
+type OutboxRelay struct {
+	outbox    ports.OutboxDB
+	bus       ports.MessageBus
+	batchSize int
+}
+
+func NewOutboxRelay(outbox ports.OutboxDB, bus ports.MessageBus, batchSize int) *OutboxRelay {
+	return &OutboxRelay{outbox: outbox, bus: bus, batchSize: batchSize}
+}
+
+// RelayPending publishes pending events until none are left or one fails, and returns how many
+// were published.
+func (r *OutboxRelay) RelayPending() (int, error) {
+	published := 0
+	for {
+		events, err := r.outbox.GetPendingEvents(r.batchSize)
+		if err != nil {
+			return published, err
+		}
+		for _, event := range events {
+			if err := r.bus.Publish(event); err != nil {
+				if recordErr := r.outbox.RecordEventFailure(event.EventID, err.Error()); recordErr != nil {
+					log.Printf("Failed to record failed publish of event %s: %v\n", event.EventID, recordErr)
+				}
+				return published, err
+			}
+			if err := r.outbox.MarkEventPublished(event.EventID); err != nil {
+				return published, err
+			}
+			published++
+		}
+		if len(events) < r.batchSize {
+			return published, nil
+		}
+	}
+}
+
+// RelayPeriodically runs RelayPending at every interval. It is meant to run in its own goroutine
+// on a single replica.
+func (r *OutboxRelay) RelayPeriodically(interval time.Duration) {
+	for {
+		if _, err := r.RelayPending(); err != nil {
+			log.Printf("Failed to relay booking events: %v\n", err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"reflect"
+	"testing"
+)
+
+// pendingEvents is an outbox of events in the order they were recorded. Marking the event in
+// failMark published fails once.
+type pendingEvents struct {
+	events   []models.OutboxEvent
+	failMark string
+}
+
+func (p *pendingEvents) GetPendingEvents(limit int) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	for _, event := range p.events {
+		if event.PublishedAt == nil && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (p *pendingEvents) MarkEventPublished(id string) error {
+	if id == p.failMark {
+		p.failMark = ""
+		return errors.New("connection reset")
+	}
+	for i := range p.events {
+		if p.events[i].EventID == id {
+			published := p.events[i].CreatedAt
+			p.events[i].PublishedAt = &published
+		}
+	}
+	return nil
+}
+
+func (p *pendingEvents) RecordEventFailure(id string, reason string) error {
+	for i := range p.events {
+		if p.events[i].EventID == id {
+			p.events[i].Attempts++
+			p.events[i].LastError = reason
+		}
+	}
+	return nil
+}
+
+// recordingBus keeps the IDs of the events it publishes and refuses the ones in down.
+type recordingBus struct {
+	ports.MessageBus
+	down      map[string]bool
+	published []string
+}
+
+func (b *recordingBus) Publish(event models.OutboxEvent) error {
+	if b.down[event.EventID] {
+		return errors.New("broker unavailable")
+	}
+	b.published = append(b.published, event.EventID)
+	return nil
+}
+
+func TestRelayPending(t *testing.T) {
+	newOutbox := func() *pendingEvents {
+		outbox := &pendingEvents{}
+		for i := 1; i <= 5; i++ {
+			outbox.events = append(outbox.events, models.OutboxEvent{EventID: fmt.Sprintf("event-%d", i), AggregateID: "booking-1",
+				Type: models.EventBookingStatusChanged})
+		}
+		return outbox
+	}
+
+	t.Run("publishes every batch in order", func(t *testing.T) {
+		outbox, bus := newOutbox(), &recordingBus{}
+		published, err := NewOutboxRelay(outbox, bus, 2).RelayPending()
+		if err != nil {
+			t.Fatalf("RelayPending: %v", err)
+		}
+		want := []string{"event-1", "event-2", "event-3", "event-4", "event-5"}
+		if published != 5 || !reflect.DeepEqual(bus.published, want) {
+			t.Errorf("published %d events %v, want %v", published, bus.published, want)
+		}
+		if pending, _ := outbox.GetPendingEvents(10); len(pending) != 0 {
+			t.Errorf("%d events still pending", len(pending))
+		}
+	})
+
+	t.Run("a failed event holds back the ones after it", func(t *testing.T) {
+		outbox, bus := newOutbox(), &recordingBus{down: map[string]bool{"event-3": true}}
+		relay := NewOutboxRelay(outbox, bus, 2)
+		if published, err := relay.RelayPending(); err == nil || published != 2 {
+			t.Fatalf("RelayPending published %d events with error %v, want 2 and the failure", published, err)
+		}
+		if failed := outbox.events[2]; failed.Attempts != 1 || failed.LastError != "broker unavailable" {
+			t.Errorf("failed event recorded %d attempts with %q", failed.Attempts, failed.LastError)
+		}
+
+		bus.down = nil
+		if published, err := relay.RelayPending(); err != nil || published != 3 {
+			t.Errorf("RelayPending after the broker recovered published %d events with error %v, want 3", published, err)
+		}
+		want := []string{"event-1", "event-2", "event-3", "event-4", "event-5"}
+		if !reflect.DeepEqual(bus.published, want) {
+			t.Errorf("published %v, want %v", bus.published, want)
+		}
+	})
+
+	t.Run("an event not marked published is published again", func(t *testing.T) {
+		outbox, bus := newOutbox(), &recordingBus{}
+		outbox.failMark = "event-2"
+		relay := NewOutboxRelay(outbox, bus, 10)
+		if _, err := relay.RelayPending(); err == nil {
+			t.Fatal("RelayPending succeeded although an event could not be marked published")
+		}
+		if _, err := relay.RelayPending(); err != nil {
+			t.Fatalf("RelayPending: %v", err)
+		}
+		want := []string{"event-1", "event-2", "event-2", "event-3", "event-4", "event-5"}
+		if !reflect.DeepEqual(bus.published, want) {
+			t.Errorf("published %v, want %v", bus.published, want)
+		}
+	})
+}
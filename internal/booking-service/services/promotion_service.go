@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionService manages promotions and takes them off prices. Promotions that are not stackable
+// apply alone; stackable ones apply together. A price gets whichever of the two discounts more.
+type PromotionService struct {
+	promotions ports.PromotionDB
+	rates      ports.ExchangeRates
+}
+
+func NewPromotionService(promotions ports.PromotionDB, rates ports.ExchangeRates) *PromotionService {
+	return &PromotionService{promotions: promotions, rates: rates}
+}
+
+func (s *PromotionService) CreatePromotion(promotion *models.Promotion) error {
+	if err := s.normalizePromotion(promotion); err != nil {
+		return err
+	}
+	promotion.PromotionID = uuid.NewString()
+	promotion.Uses = 0
+	return s.promotions.CreatePromotion(promotion)
+}
+
+func (s *PromotionService) GetPromotion(id string) (*models.Promotion, error) {
+	return s.promotions.GetPromotionByID(id)
+}
+
+func (s *PromotionService) GetPromotions() ([]models.Promotion, error) {
+	return s.promotions.GetPromotions()
+}
+
+// UpdatePromotion replaces the rules of a promotion, keeping its ID and how often it was used.
+func (s *PromotionService) UpdatePromotion(id string, promotion *models.Promotion) (*models.Promotion, error) {
+	stored, err := s.promotions.GetPromotionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.normalizePromotion(promotion); err != nil {
+		return nil, err
+	}
+	promotion.PromotionID = stored.PromotionID
+	promotion.Uses = stored.Uses
+	promotion.CreatedAt = stored.CreatedAt
+	if err := s.promotions.UpdatePromotion(promotion); err != nil {
+		return nil, err
+	}
+	return s.promotions.GetPromotionByID(id)
+}
+
+// normalizePromotion checks the rules of a promotion and brings its code, currency, product types
+// and destinations to upper or lower case the way they are matched.
+func (s *PromotionService) normalizePromotion(promotion *models.Promotion) error {
+	if promotion.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*promotion.Code))
+		promotion.Code = &code
+		if code == "" {
+			promotion.Code = nil
+		}
+	}
+	promotion.Name = strings.TrimSpace(promotion.Name)
+	promotion.Currency = strings.ToUpper(promotion.Currency)
+	for i, productType := range promotion.ProductTypes {
+		promotion.ProductTypes[i] = strings.ToLower(productType)
+	}
+	for i, destination := range promotion.Destinations {
+		promotion.Destinations[i] = strings.ToUpper(destination)
+	}
+
+	switch {
+	case promotion.Name == "":
+		return fmt.Errorf("%w: name is required", models.ErrInvalidPromotion)
+	case promotion.DiscountType != models.DiscountPercentage && promotion.DiscountType != models.DiscountFixed:
+		return fmt.Errorf("%w: unknown discount type %q", models.ErrInvalidPromotion, promotion.DiscountType)
+	case promotion.Value <= 0:
+		return fmt.Errorf("%w: discount value must be positive", models.ErrInvalidPromotion)
+	case promotion.DiscountType == models.DiscountPercentage && promotion.Value > 100:
+		return fmt.Errorf("%w: a percentage discount cannot exceed 100", models.ErrInvalidPromotion)
+	case (promotion.DiscountType == models.DiscountFixed || promotion.MinSpend > 0) && promotion.Currency == "":
+		return fmt.Errorf("%w: currency is required for fixed discounts and minimum spends", models.ErrInvalidPromotion)
+	case promotion.Currency != "" && !s.rates.Supports(promotion.Currency):
+		return fmt.Errorf("%w: unsupported currency %s", models.ErrInvalidPromotion, promotion.Currency)
+	case promotion.MinSpend < 0 || promotion.MaxUses < 0 || promotion.MaxUsesPerUser < 0:
+		return fmt.Errorf("%w: minimum spend and usage caps cannot be negative", models.ErrInvalidPromotion)
+	case !allOneOf(promotion.ProductTypes, []string{models.ProductFlight, models.ProductHotel}):
+		return fmt.Errorf("%w: unknown product type in %v", models.ErrInvalidPromotion, promotion.ProductTypes)
+	case promotion.TravelFrom != nil && promotion.TravelUntil != nil && promotion.TravelUntil.Before(*promotion.TravelFrom):
+		return fmt.Errorf("%w: travel window ends before it starts", models.ErrInvalidPromotion)
+	case promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt):
+		return fmt.Errorf("%w: campaign ends before it starts", models.ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// QuotePrice adds up the components of a price in the currency of the request and takes off the
+// running automatic promotions and those of the codes entered. A code that does not apply fails
+// the quote, so the traveller learns why; automatic promotions that do not apply are left out.
+func (s *PromotionService) QuotePrice(request models.PricingRequest) (*models.PriceQuote, error) {
+	if len(request.Components) == 0 {
+		return nil, fmt.Errorf("%w: nothing to price", models.ErrInvalidPromotion)
+	}
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = strings.ToUpper(request.Components[0].Price.Currency)
+	}
+	components := make([]models.PriceComponent, len(request.Components))
+	subtotal := 0.0
+	for i, component := range request.Components {
+		amount, err := s.rates.Convert(component.Price.Amount, strings.ToUpper(component.Price.Currency), currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidPromotion, err)
+		}
+		components[i] = models.PriceComponent{
+			ProductType: strings.ToLower(component.ProductType),
+			Price:       models.Money{Amount: amount, Currency: currency},
+		}
+		subtotal += amount
+	}
+
+	candidates, err := s.candidates(request.Codes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	var best *models.AppliedDiscount
+	stacked := models.AppliedDiscounts{}
+	stackedAmount := 0.0
+	for _, candidate := range candidates {
+		discount, err := s.discount(candidate.promotion, request, components, currency, now)
+		if err != nil {
+			if candidate.entered {
+				return nil, err
+			}
+			continue
+		}
+		if discount == nil {
+			continue
+		}
+		if candidate.promotion.Stackable {
+			stacked = append(stacked, *discount)
+			stackedAmount += discount.Amount.Amount
+		} else if best == nil || discount.Amount.Amount > best.Amount.Amount {
+			best = discount
+		}
+	}
+
+	discounts := stacked
+	if best != nil && best.Amount.Amount > stackedAmount {
+		discounts = models.AppliedDiscounts{*best}
+	}
+	// Never take off more than the price, trimming the smallest discounts first.
+	sort.SliceStable(discounts, func(i, j int) bool { return discounts[i].Amount.Amount > discounts[j].Amount.Amount })
+	remaining := subtotal
+	applied := models.AppliedDiscounts{}
+	for _, discount := range discounts {
+		if remaining <= 0 {
+			break
+		}
+		if discount.Amount.Amount > remaining {
+			discount.Amount.Amount = remaining
+		}
+		discount.Amount.Amount = roundAmount(discount.Amount.Amount)
+		remaining -= discount.Amount.Amount
+		applied = append(applied, discount)
+	}
+
+	return &models.PriceQuote{
+		Subtotal:  models.Money{Amount: roundAmount(subtotal), Currency: currency},
+		Discounts: applied,
+		Total:     models.Money{Amount: roundAmount(remaining), Currency: currency},
+	}, nil
+}
+
+// candidate is a promotion considered for a price; entered tells whether its code was entered.
+type candidate struct {
+	promotion *models.Promotion
+	entered   bool
+}
+
+// candidates returns the promotions of the codes entered followed by the automatic promotions.
+func (s *PromotionService) candidates(codes []string) ([]candidate, error) {
+	candidates := []candidate{}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		promotion, err := s.promotions.GetPromotionByCode(code)
+		if err != nil {
+			if errors.Is(err, models.ErrPromotionNotFound) {
+				return nil, fmt.Errorf("%w: unknown promotion code %s", models.ErrInvalidPromotion, code)
+			}
+			return nil, err
+		}
+		candidates = append(candidates, candidate{promotion: promotion, entered: true})
+	}
+
+	promotions, err := s.promotions.GetPromotions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range promotions {
+		if promotions[i].Code == nil {
+			candidates = append(candidates, candidate{promotion: &promotions[i]})
+		}
+	}
+	return candidates, nil
+}
+
+// discount works out what a promotion takes off the components of a price. It fails with
+// models.ErrInvalidPromotion when the promotion does not apply and models.ErrPromotionUnavailable
+// when its caps are reached, and returns nil when it would take nothing off.
+func (s *PromotionService) discount(promotion *models.Promotion, request models.PricingRequest,
+	components []models.PriceComponent, currency string, now time.Time) (*models.AppliedDiscount, error) {
+	label := promotion.Name
+	if promotion.Code != nil {
+		label = *promotion.Code
+	}
+	if !promotion.Running(now) {
+		return nil, fmt.Errorf("%w: %s is not running", models.ErrInvalidPromotion, label)
+	}
+	if len(promotion.Destinations) > 0 && !oneOf(strings.ToUpper(request.Destination), promotion.Destinations) {
+		return nil, fmt.Errorf("%w: %s does not apply to trips to %s", models.ErrInvalidPromotion, label, request.Destination)
+	}
+	if promotion.TravelFrom != nil || promotion.TravelUntil != nil {
+		if request.TravelDate == nil ||
+			(promotion.TravelFrom != nil && request.TravelDate.Before(*promotion.TravelFrom)) ||
+			(promotion.TravelUntil != nil && request.TravelDate.After(*promotion.TravelUntil)) {
+			return nil, fmt.Errorf("%w: %s does not apply to these travel dates", models.ErrInvalidPromotion, label)
+		}
+	}
+
+	eligible := 0.0
+	for _, component := range components {
+		if len(promotion.ProductTypes) == 0 || oneOf(component.ProductType, promotion.ProductTypes) {
+			eligible += component.Price.Amount
+		}
+	}
+	if eligible <= 0 {
+		return nil, fmt.Errorf("%w: %s does not apply to these products", models.ErrInvalidPromotion, label)
+	}
+	if promotion.MinSpend > 0 {
+		spent, err := s.rates.Convert(eligible, currency, promotion.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if spent < promotion.MinSpend {
+			return nil, fmt.Errorf("%w: %s needs a spend of at least %.2f %s", models.ErrInvalidPromotion,
+				label, promotion.MinSpend, promotion.Currency)
+		}
+	}
+	if err := s.checkCaps(promotion, label, request); err != nil {
+		return nil, err
+	}
+
+	amount := eligible * promotion.Value / 100
+	if promotion.DiscountType == models.DiscountFixed {
+		converted, err := s.rates.Convert(promotion.Value, promotion.Currency, currency)
+		if err != nil {
+			return nil, err
+		}
+		amount = converted
+	}
+	if amount > eligible {
+		amount = eligible
+	}
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	discount := &models.AppliedDiscount{
+		PromotionID: promotion.PromotionID,
+		Name:        promotion.Name,
+		Amount:      models.Money{Amount: amount, Currency: currency},
+	}
+	if promotion.Code != nil {
+		discount.Code = *promotion.Code
+	}
+	return discount, nil
+}
+
+// checkCaps tells early whether a promotion is used up; the caps are enforced again when the
+// discount is redeemed. Promotions the booking already redeemed do not count against them.
+func (s *PromotionService) checkCaps(promotion *models.Promotion, label string, request models.PricingRequest) error {
+	if promotion.MaxUses == 0 && (promotion.MaxUsesPerUser == 0 || request.UserID == "") {
+		return nil
+	}
+	if request.BookingID != "" {
+		redemptions, err := s.promotions.GetRedemptionsByBooking(request.BookingID)
+		if err != nil {
+			return err
+		}
+		for _, redemption := range redemptions {
+			if redemption.PromotionID == promotion.PromotionID && redemption.Status == models.RedemptionActive {
+				return nil
+			}
+		}
+	}
+	if promotion.MaxUses > 0 && promotion.Uses >= promotion.MaxUses {
+		return fmt.Errorf("%w: %s reached its limit of %d uses", models.ErrPromotionUnavailable, label, promotion.MaxUses)
+	}
+	if promotion.MaxUsesPerUser > 0 && request.UserID != "" {
+		count, err := s.promotions.CountRedemptions(promotion.PromotionID, request.UserID)
+		if err != nil {
+			return err
+		}
+		if count >= promotion.MaxUsesPerUser {
+			return fmt.Errorf("%w: %s can be used %d times per traveller", models.ErrPromotionUnavailable, label, promotion.MaxUsesPerUser)
+		}
+	}
+	return nil
+}
+
+// CheckCodes fails unless every code names a promotion that is running, so that a package booking
+// with a mistyped code is refused before anything is reserved.
+func (s *PromotionService) CheckCodes(codes []string) error {
+	now := time.Now().UTC()
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		promotion, err := s.promotions.GetPromotionByCode(code)
+		if errors.Is(err, models.ErrPromotionNotFound) {
+			return fmt.Errorf("%w: unknown promotion code %s", models.ErrInvalidPromotion, code)
+		}
+		if err != nil {
+			return err
+		}
+		if !promotion.Running(now) {
+			return fmt.Errorf("%w: %s is not running", models.ErrInvalidPromotion, code)
+		}
+	}
+	return nil
+}
+
+// RedeemDiscounts records that a booking used the promotions of its discounts.
+func (s *PromotionService) RedeemDiscounts(bookingID string, userID string, discounts models.AppliedDiscounts) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	redemptions := make([]models.PromotionRedemption, 0, len(discounts))
+	for _, discount := range discounts {
+		redemptions = append(redemptions, models.PromotionRedemption{
+			RedemptionID: models.RedemptionID(discount.PromotionID, bookingID),
+			PromotionID:  discount.PromotionID,
+			BookingID:    bookingID,
+			UserID:       userID,
+			Code:         discount.Code,
+			Discount:     discount.Amount,
+			Status:       models.RedemptionActive,
+			CreatedAt:    now,
+		})
+	}
+	return s.promotions.RedeemPromotions(redemptions)
+}
+
+// ReverseDiscounts gives back the uses of the promotions a cancelled booking redeemed.
+func (s *PromotionService) ReverseDiscounts(bookingID string) error {
+	reversed, err := s.promotions.ReverseRedemptions(bookingID)
+	if err != nil {
+		return err
+	}
+	for _, redemption := range reversed {
+		log.Printf("Reversed promotion %s of booking %s\n", redemption.PromotionID, bookingID)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/exchangerates"
+	"reflect"
+	"testing"
+)
+
+// promotionsOnFile answers from a fixed list of promotions and redemptions. It records the
+// redemptions it is asked to store, or refuses them with redeemErr, and the bookings it reverses.
+type promotionsOnFile struct {
+	ports.PromotionDB
+	promotions  []models.Promotion
+	redemptions []models.PromotionRedemption
+	redeemErr   error
+
+	redeemed []models.PromotionRedemption
+	reversed []string
+}
+
+func (p *promotionsOnFile) GetPromotionByCode(code string) (*models.Promotion, error) {
+	for _, promotion := range p.promotions {
+		if promotion.Code != nil && *promotion.Code == code {
+			return &promotion, nil
+		}
+	}
+	return nil, models.ErrPromotionNotFound
+}
+
+func (p *promotionsOnFile) GetPromotions() ([]models.Promotion, error) {
+	return p.promotions, nil
+}
+
+func (p *promotionsOnFile) GetRedemptionsByBooking(bookingID string) ([]models.PromotionRedemption, error) {
+	var redemptions []models.PromotionRedemption
+	for _, redemption := range p.redemptions {
+		if redemption.BookingID == bookingID {
+			redemptions = append(redemptions, redemption)
+		}
+	}
+	return redemptions, nil
+}
+
+func (p *promotionsOnFile) CountRedemptions(promotionID string, userID string) (int, error) {
+	count := 0
+	for _, redemption := range p.redemptions {
+		if redemption.PromotionID == promotionID && redemption.UserID == userID && redemption.Status == models.RedemptionActive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (p *promotionsOnFile) RedeemPromotions(redemptions []models.PromotionRedemption) error {
+	if p.redeemErr != nil {
+		return p.redeemErr
+	}
+	p.redeemed = append(p.redeemed, redemptions...)
+	return nil
+}
+
+func (p *promotionsOnFile) ReverseRedemptions(bookingID string) ([]models.PromotionRedemption, error) {
+	p.reversed = append(p.reversed, bookingID)
+	return nil, nil
+}
+
+func promoCode(c string) *string { return &c }
+
+func TestQuotePriceStackingAndCaps(t *testing.T) {
+	percent := func(id string, value float64, stackable bool) models.Promotion {
+		return models.Promotion{PromotionID: id, Name: id, DiscountType: models.DiscountPercentage, Value: value,
+			Stackable: stackable, Active: true}
+	}
+	fixed := func(id string, value float64, stackable bool) models.Promotion {
+		return models.Promotion{PromotionID: id, Name: id, DiscountType: models.DiscountFixed, Value: value,
+			Currency: "EUR", Stackable: stackable, Active: true}
+	}
+	withCode := func(promotion models.Promotion, c string) models.Promotion {
+		promotion.Code = promoCode(c)
+		return promotion
+	}
+	hotelOnly := percent("hotel-half", 50, false)
+	hotelOnly.ProductTypes = models.StringList{models.ProductHotel}
+	bigSpend := withCode(percent("big-spend", 10, false), "BIG")
+	bigSpend.MinSpend, bigSpend.Currency = 1000, "EUR"
+	autoBigSpend := percent("auto-big-spend", 30, false)
+	autoBigSpend.MinSpend, autoBigSpend.Currency = 1000, "EUR"
+	usedUp := withCode(percent("used-up", 10, false), "ONCE")
+	usedUp.MaxUses, usedUp.Uses = 1, 1
+	perUser := withCode(percent("per-user", 10, false), "WELCOME")
+	perUser.MaxUsesPerUser = 1
+	paused := withCode(percent("paused", 10, false), "PAUSED")
+	paused.Active = false
+
+	tests := []struct {
+		name          string
+		promotions    []models.Promotion
+		redemptions   []models.PromotionRedemption
+		bookingID     string
+		codes         []string
+		wantDiscounts map[string]float64
+		wantTotal     float64
+		wantErr       error
+	}{
+		{
+			name:          "no promotions",
+			wantDiscounts: map[string]float64{},
+			wantTotal:     500,
+		},
+		{
+			name:          "stackable promotions apply together",
+			promotions:    []models.Promotion{percent("tenth", 10, true), fixed("twenty-off", 20, true)},
+			wantDiscounts: map[string]float64{"tenth": 50, "twenty-off": 20},
+			wantTotal:     430,
+		},
+		{
+			name:          "a larger single promotion beats the stack",
+			promotions:    []models.Promotion{percent("tenth", 10, true), fixed("twenty-off", 20, true), percent("fifteen", 15, false)},
+			wantDiscounts: map[string]float64{"fifteen": 75},
+			wantTotal:     425,
+		},
+		{
+			name:          "a larger stack beats the single promotion",
+			promotions:    []models.Promotion{percent("tenth", 10, true), fixed("forty-off", 40, true), percent("fifteen", 15, false)},
+			wantDiscounts: map[string]float64{"tenth": 50, "forty-off": 40},
+			wantTotal:     410,
+		},
+		{
+			name:          "only the best of the single promotions applies",
+			promotions:    []models.Promotion{percent("fifteen", 15, false), fixed("hundred-off", 100, false)},
+			wantDiscounts: map[string]float64{"hundred-off": 100},
+			wantTotal:     400,
+		},
+		{
+			name:          "product types limit what is discounted",
+			promotions:    []models.Promotion{hotelOnly},
+			wantDiscounts: map[string]float64{"hotel-half": 100},
+			wantTotal:     400,
+		},
+		{
+			name:          "discounts never exceed the price",
+			promotions:    []models.Promotion{fixed("four-hundred-off", 400, true), fixed("three-hundred-off", 300, true)},
+			wantDiscounts: map[string]float64{"four-hundred-off": 400, "three-hundred-off": 100},
+			wantTotal:     0,
+		},
+		{
+			name:          "automatic promotions that do not apply are left out",
+			promotions:    []models.Promotion{autoBigSpend, percent("tenth", 10, true)},
+			wantDiscounts: map[string]float64{"tenth": 50},
+			wantTotal:     450,
+		},
+		{
+			name:       "an entered code below its minimum spend fails",
+			promotions: []models.Promotion{bigSpend},
+			codes:      []string{"big"},
+			wantErr:    models.ErrInvalidPromotion,
+		},
+		{
+			name:       "an entered code that is not running fails",
+			promotions: []models.Promotion{paused},
+			codes:      []string{"PAUSED"},
+			wantErr:    models.ErrInvalidPromotion,
+		},
+		{
+			name:    "an unknown code fails",
+			codes:   []string{"NOPE"},
+			wantErr: models.ErrInvalidPromotion,
+		},
+		{
+			name:       "a used up code fails",
+			promotions: []models.Promotion{usedUp},
+			codes:      []string{"ONCE"},
+			wantErr:    models.ErrPromotionUnavailable,
+		},
+		{
+			name:       "the booking that used up a code may still use it",
+			promotions: []models.Promotion{usedUp},
+			redemptions: []models.PromotionRedemption{{RedemptionID: models.RedemptionID("used-up", "booking-1"),
+				PromotionID: "used-up", BookingID: "booking-1", UserID: "user-1", Status: models.RedemptionActive}},
+			bookingID:     "booking-1",
+			codes:         []string{"ONCE"},
+			wantDiscounts: map[string]float64{"used-up": 50},
+			wantTotal:     450,
+		},
+		{
+			name:       "a code the traveller used up fails",
+			promotions: []models.Promotion{perUser},
+			redemptions: []models.PromotionRedemption{{RedemptionID: models.RedemptionID("per-user", "booking-0"),
+				PromotionID: "per-user", BookingID: "booking-0", UserID: "user-1", Status: models.RedemptionActive}},
+			codes:   []string{"WELCOME"},
+			wantErr: models.ErrPromotionUnavailable,
+		},
+		{
+			name:       "a reversed use does not count against the traveller",
+			promotions: []models.Promotion{perUser},
+			redemptions: []models.PromotionRedemption{{RedemptionID: models.RedemptionID("per-user", "booking-0"),
+				PromotionID: "per-user", BookingID: "booking-0", UserID: "user-1", Status: models.RedemptionReversed}},
+			codes:         []string{"WELCOME"},
+			wantDiscounts: map[string]float64{"per-user": 50},
+			wantTotal:     450,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPromotionService(&promotionsOnFile{promotions: tt.promotions, redemptions: tt.redemptions}, exchangerates.NewStaticExchangeRates())
+			quote, err := s.QuotePrice(models.PricingRequest{
+				UserID:    "user-1",
+				BookingID: tt.bookingID,
+				Components: []models.PriceComponent{
+					{ProductType: models.ProductFlight, Price: models.Money{Amount: 300, Currency: "EUR"}},
+					{ProductType: models.ProductHotel, Price: models.Money{Amount: 200, Currency: "EUR"}},
+				},
+				Codes: tt.codes,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("QuotePrice error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QuotePrice: %v", err)
+			}
+
+			got := map[string]float64{}
+			for _, discount := range quote.Discounts {
+				got[discount.PromotionID] = discount.Amount.Amount
+			}
+			if len(got) != len(tt.wantDiscounts) {
+				t.Errorf("discounts = %v, want %v", got, tt.wantDiscounts)
+			}
+			for id, want := range tt.wantDiscounts {
+				if got[id] != want {
+					t.Errorf("discount of %s = %.2f, want %.2f", id, got[id], want)
+				}
+			}
+			if quote.Subtotal.Amount != 500 || quote.Total.Amount != tt.wantTotal {
+				t.Errorf("subtotal %.2f, total %.2f, want 500.00 and %.2f", quote.Subtotal.Amount, quote.Total.Amount, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestRedeemDiscounts(t *testing.T) {
+	discounts := models.AppliedDiscounts{
+		{PromotionID: "launch", Code: "LAUNCH", Name: "Launch", Amount: models.Money{Amount: 50, Currency: "EUR"}},
+		{PromotionID: "summer", Name: "Summer", Amount: models.Money{Amount: 20, Currency: "EUR"}},
+	}
+
+	t.Run("records a redemption for each discount", func(t *testing.T) {
+		promotions := &promotionsOnFile{}
+		s := NewPromotionService(promotions, exchangerates.NewStaticExchangeRates())
+		if err := s.RedeemDiscounts("booking-1", "user-1", discounts); err != nil {
+			t.Fatalf("RedeemDiscounts: %v", err)
+		}
+		if len(promotions.redeemed) != 2 {
+			t.Fatalf("redeemed %+v, want both discounts", promotions.redeemed)
+		}
+		for i, redemption := range promotions.redeemed {
+			discount := discounts[i]
+			if redemption.RedemptionID != models.RedemptionID(discount.PromotionID, "booking-1") || redemption.PromotionID != discount.PromotionID ||
+				redemption.BookingID != "booking-1" || redemption.UserID != "user-1" || redemption.Discount != discount.Amount ||
+				redemption.Status != models.RedemptionActive {
+				t.Errorf("redemption %d = %+v, want an active redemption of %s", i, redemption, discount.PromotionID)
+			}
+		}
+	})
+
+	t.Run("bookings without discounts redeem nothing", func(t *testing.T) {
+		promotions := &promotionsOnFile{redeemErr: errors.New("unexpected redemption")}
+		s := NewPromotionService(promotions, exchangerates.NewStaticExchangeRates())
+		if err := s.RedeemDiscounts("booking-1", "user-1", nil); err != nil {
+			t.Errorf("RedeemDiscounts without discounts: %v", err)
+		}
+	})
+
+	// The repository enforces the caps when the redemptions are recorded.
+	t.Run("a used up promotion fails the redemption", func(t *testing.T) {
+		promotions := &promotionsOnFile{redeemErr: models.ErrPromotionUnavailable}
+		s := NewPromotionService(promotions, exchangerates.NewStaticExchangeRates())
+		if err := s.RedeemDiscounts("booking-1", "user-1", discounts); !errors.Is(err, models.ErrPromotionUnavailable) {
+			t.Errorf("RedeemDiscounts: got %v, want %v", err, models.ErrPromotionUnavailable)
+		}
+	})
+
+	t.Run("cancelled bookings give their uses back", func(t *testing.T) {
+		promotions := &promotionsOnFile{}
+		s := NewPromotionService(promotions, exchangerates.NewStaticExchangeRates())
+		if err := s.ReverseDiscounts("booking-1"); err != nil {
+			t.Fatalf("ReverseDiscounts: %v", err)
+		}
+		if !reflect.DeepEqual(promotions.reversed, []string{"booking-1"}) {
+			t.Errorf("reversed the redemptions of %v, want booking-1", promotions.reversed)
+		}
+	})
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxStepAttempts is how often a step or its compensation is tried in one run before the saga is
+// left for the next recovery run.
+const maxStepAttempts = 3
+
+// Statuses of the booking a package is recorded as.
+const (
+	packagePending   = "pending"
+	packageConfirmed = "confirmed"
+	packageCancelled = models.BookingCancelledStatus
+	packageFailed    = "failed"
+)
+
+// SagaOrchestrator books flight and hotel packages as a saga: reserve the flight, reserve the hotel,
+// take the payment, confirm. The state is stored before and after every step, so a saga interrupted
+// by a crash is picked up by ResumeSagas, and the steps already done are undone in reverse order
+// when a later one is rejected.
+type SagaOrchestrator struct {
+	sagas      ports.SagaDB
+	bookings   ports.BookingDB
+	flights    ports.FlightReservations
+	hotels     ports.HotelReservations
+	payments   ports.Payments
+	loyalty    ports.LoyaltyPoints
+	rates      ports.ExchangeRates
+	promotions ports.Discounts
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	active map[string]bool // Sagas this process is running, so recovery leaves them alone.
+}
+
+func NewSagaOrchestrator(sagas ports.SagaDB, bookings ports.BookingDB, flights ports.FlightReservations,
+	hotels ports.HotelReservations, payments ports.Payments, loyalty ports.LoyaltyPoints, rates ports.ExchangeRates,
+	promotions ports.Discounts) *SagaOrchestrator {
+	return &SagaOrchestrator{
+		sagas:      sagas,
+		bookings:   bookings,
+		flights:    flights,
+		hotels:     hotels,
+		payments:   payments,
+		loyalty:    loyalty,
+		rates:      rates,
+		promotions: promotions,
+		retryDelay: time.Second,
+		active:     make(map[string]bool),
+	}
+}
+
+// BookPackage records the package as a pending booking and runs its saga. The returned saga is
+// completed, rolled back (with an error wrapping models.ErrPackageBookingFailed), or still running
+// when another service could not be reached; recovery then finishes it. Packages booked by anyone
+// but another service are booked and paid for the requester.
+func (o *SagaOrchestrator) BookPackage(requester models.Requester, request models.PackageBookingRequest) (*models.Saga, error) {
+	if !requester.Service {
+		request.UserID = requester.UserID
+	}
+	request.Payment.Currency = strings.ToUpper(request.Payment.Currency)
+	if err := o.validatePackage(request); err != nil {
+		return nil, err
+	}
+	if err := o.promotions.CheckCodes(request.PromoCodes); err != nil {
+		return nil, err
+	}
+
+	checkIn := request.Hotel.CheckIn
+	booking := &models.Booking{
+		BookingID:     uuid.NewString(),
+		UserID:        request.UserID,
+		BookingStatus: packagePending,
+		ProductType:   models.ProductPackage,
+		TravelDate:    &checkIn,
+	}
+	if err := o.bookings.CreateBooking(booking, sagaChange("package booking started")); err != nil {
+		return nil, err
+	}
+
+	saga := &models.Saga{
+		SagaID:    uuid.NewString(),
+		BookingID: booking.BookingID,
+		UserID:    request.UserID,
+		Status:    models.SagaRunning,
+		Request:   request,
+	}
+	for _, step := range models.PackageBookingSteps {
+		saga.Steps = append(saga.Steps, models.SagaStepState{Step: step, Status: models.StepPending, UpdatedAt: time.Now().UTC()})
+	}
+	if err := o.sagas.CreateSaga(saga); err != nil {
+		return nil, err
+	}
+
+	o.claim(saga.SagaID)
+	defer o.release(saga.SagaID)
+	if err := o.run(saga); err != nil {
+		return saga, err
+	}
+	if saga.Status == models.SagaRolledBack || saga.Status == models.SagaFailed {
+		return saga, fmt.Errorf("%w: %s", models.ErrPackageBookingFailed, saga.Error)
+	}
+	return saga, nil
+}
+
+// GetPackageBooking returns how far a package booking got, for privileged requesters and its owner.
+func (o *SagaOrchestrator) GetPackageBooking(requester models.Requester, id string) (*models.Saga, error) {
+	saga, err := o.sagas.GetSagaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && (requester.UserID == "" || saga.UserID != requester.UserID) {
+		// Do not tell others which package bookings exist.
+		return nil, models.ErrSagaNotFound
+	}
+	return saga, nil
+}
+
+// ResumeSagas continues every saga that is still running or compensating, e.g. after a crash. Only
+// one replica may run it at a time: sagas are only claimed within this process.
+func (o *SagaOrchestrator) ResumeSagas() error {
+	sagas, err := o.sagas.GetUnfinishedSagas()
+	if err != nil {
+		return err
+	}
+	for i := range sagas {
+		saga := &sagas[i]
+		if !o.claim(saga.SagaID) {
+			continue
+		}
+		log.Printf("Resuming package booking %s (%s)\n", saga.SagaID, saga.Status)
+		err := o.run(saga)
+		o.release(saga.SagaID)
+		if err != nil {
+			log.Printf("Failed to resume package booking %s: %v\n", saga.SagaID, err)
+		}
+	}
+	return nil
+}
+
+func (o *SagaOrchestrator) validatePackage(request models.PackageBookingRequest) error {
+	switch {
+	case request.UserID == "":
+		return fmt.Errorf("%w: user ID is required", models.ErrInvalidBooking)
+	case request.Flight.ItineraryID == "":
+		return fmt.Errorf("%w: flight itinerary ID is required", models.ErrInvalidBooking)
+	case request.Flight.Adults < 1:
+		return fmt.Errorf("%w: at least one adult must travel", models.ErrInvalidBooking)
+	case request.Hotel.HotelID == "":
+		return fmt.Errorf("%w: hotel ID is required", models.ErrInvalidBooking)
+	case request.Hotel.CheckIn.IsZero() || !request.Hotel.CheckOut.After(request.Hotel.CheckIn):
+		return fmt.Errorf("%w: hotel check-out must be after check-in", models.ErrInvalidBooking)
+	case request.Hotel.Guests < 1:
+		return fmt.Errorf("%w: hotel guest count is required", models.ErrInvalidBooking)
+	case request.Payment.Method == "":
+		return fmt.Errorf("%w: payment method is required", models.ErrInvalidBooking)
+	case request.Payment.Points < 0:
+		return fmt.Errorf("%w: points to pay with cannot be negative", models.ErrInvalidBooking)
+	case request.Payment.Currency != "" && !o.rates.Supports(request.Payment.Currency):
+		return fmt.Errorf("%w: unsupported currency %s", models.ErrInvalidBooking, request.Payment.Currency)
+	}
+	return nil
+}
+
+// run moves a saga forward until it completes, or backward once a step was rejected. It only
+// returns an error when the saga state cannot be stored.
+func (o *SagaOrchestrator) run(saga *models.Saga) error {
+	if saga.Status == models.SagaRunning {
+		for _, step := range models.PackageBookingSteps {
+			state := saga.Step(step)
+			if state.Status == models.StepCompleted {
+				continue
+			}
+			err := o.forward(saga, state)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, models.ErrStepRejected) {
+				// The other service may or may not have done its part; the next recovery run repeats
+				// the step with the same idempotency key to find out.
+				log.Printf("Package booking %s paused at %s: %v\n", saga.SagaID, step, err)
+				return o.save(saga)
+			}
+
+			setStepStatus(state, models.StepFailed)
+			saga.Status = models.SagaCompensating
+			saga.Error = fmt.Sprintf("%s: %v", step, err)
+			if err := o.save(saga); err != nil {
+				return err
+			}
+			break
+		}
+		if saga.Status == models.SagaRunning {
+			saga.Status = models.SagaCompleted
+			return o.save(saga)
+		}
+	}
+
+	if saga.Status == models.SagaCompensating {
+		return o.compensate(saga)
+	}
+	return nil
+}
+
+// forward runs one step, recording that it started before calling the other service.
+func (o *SagaOrchestrator) forward(saga *models.Saga, state *models.SagaStepState) error {
+	var err error
+	for attempt := 1; attempt <= maxStepAttempts; attempt++ {
+		setStepStatus(state, models.StepStarted)
+		state.Attempts++
+		if err := o.save(saga); err != nil {
+			return err
+		}
+
+		err = o.execute(saga, state.Step)
+		if err == nil {
+			setStepStatus(state, models.StepCompleted)
+			state.Error = ""
+			return o.save(saga)
+		}
+		state.Error = err.Error()
+		if errors.Is(err, models.ErrStepRejected) {
+			return err
+		}
+		if attempt < maxStepAttempts {
+			time.Sleep(time.Duration(attempt) * o.retryDelay)
+		}
+	}
+	return err
+}
+
+func (o *SagaOrchestrator) execute(saga *models.Saga, step models.SagaStep) error {
+	key := saga.SagaID + "/" + string(step)
+	switch step {
+	case models.StepReserveFlight:
+		reservation, err := o.flights.ReserveFlight(key, saga.UserID, saga.Request.Flight)
+		if err != nil {
+			return err
+		}
+		saga.FlightBookingID = reservation.ID
+		saga.FlightPrice = &reservation.Price
+		saga.FlightDestination = reservation.Destination
+		_, err = o.bookings.UpdateBooking(saga.BookingID, &models.Booking{FlightID: reservation.ID}, models.AnyVersion,
+			sagaChange("flight reserved"))
+		return err
+
+	case models.StepReserveHotel:
+		reservation, err := o.hotels.ReserveHotel(key, saga.UserID, saga.Request.Hotel)
+		if err != nil {
+			return err
+		}
+		if reservation.Price.Currency == "" {
+			reservation.Price.Currency = paymentCurrency(saga)
+		}
+		saga.HotelBookingID = reservation.ID
+		saga.HotelPrice = &reservation.Price
+		return nil
+
+	case models.StepTakePayment:
+		quote, err := o.packagePrice(saga)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidPromotion) || errors.Is(err, models.ErrPromotionUnavailable) {
+				return fmt.Errorf("%w: %v", models.ErrStepRejected, err)
+			}
+			return err
+		}
+		// Redeeming again after a crash is a no-op, so the caps are only counted once per booking.
+		if err := o.promotions.RedeemDiscounts(saga.BookingID, saga.UserID, quote.Discounts); err != nil {
+			if errors.Is(err, models.ErrPromotionUnavailable) {
+				return fmt.Errorf("%w: %v", models.ErrStepRejected, err)
+			}
+			return err
+		}
+		saga.Discounts = quote.Discounts
+		_, err = o.bookings.UpdateBooking(saga.BookingID, &models.Booking{Discounts: quote.Discounts}, models.AnyVersion,
+			sagaChange("discounts applied"))
+		if err != nil {
+			return err
+		}
+		// Points pay first; redeeming again with the same key after a crash uses them once.
+		charge := quote.Total
+		if saga.Request.Payment.Points > 0 && charge.Amount > 0 {
+			paid, err := o.loyalty.RedeemPoints(key, saga.UserID, saga.BookingID, saga.Request.Payment.Points, charge)
+			if err != nil {
+				return err
+			}
+			saga.PointsPaid = paid
+			charge.Amount = math.Round((charge.Amount-paid.Amount)*100) / 100
+		}
+		saga.Charged = &charge
+		if charge.Amount <= 0 {
+			return nil
+		}
+		payment, err := o.payments.TakePayment(key, saga.UserID, saga.BookingID, saga.Request.Payment.Method, charge)
+		if err != nil {
+			return err
+		}
+		saga.PaymentID = payment.ID
+		return nil
+
+	case models.StepConfirm:
+		if err := o.hotels.ConfirmHotel(key, saga.HotelBookingID); err != nil {
+			return err
+		}
+		_, err := o.bookings.UpdateBookingStatus(saga.BookingID, packageConfirmed, models.AnyVersion,
+			sagaChange("flight, hotel and payment completed"))
+		return err
+	}
+	return fmt.Errorf("unknown saga step %s", step)
+}
+
+// compensate undoes the completed steps in reverse order. A compensation the other service rejects
+// is recorded and the saga ends up failed; one that cannot be reached is retried by recovery.
+func (o *SagaOrchestrator) compensate(saga *models.Saga) error {
+	for i := len(saga.Steps) - 1; i >= 0; i-- {
+		state := &saga.Steps[i]
+		if state.Status != models.StepCompleted {
+			continue
+		}
+
+		var err error
+		for attempt := 1; attempt <= maxStepAttempts; attempt++ {
+			if err = o.undo(saga, state.Step); err == nil || errors.Is(err, models.ErrStepRejected) {
+				break
+			}
+			if attempt < maxStepAttempts {
+				time.Sleep(time.Duration(attempt) * o.retryDelay)
+			}
+		}
+		switch {
+		case err == nil:
+			setStepStatus(state, models.StepCompensated)
+			state.Error = ""
+		case errors.Is(err, models.ErrStepRejected):
+			log.Printf("Package booking %s could not undo %s: %v\n", saga.SagaID, state.Step, err)
+			setStepStatus(state, models.StepCompensationFailed)
+			state.Error = err.Error()
+		default:
+			log.Printf("Package booking %s paused undoing %s: %v\n", saga.SagaID, state.Step, err)
+			state.Error = err.Error()
+			return o.save(saga)
+		}
+		if err := o.save(saga); err != nil {
+			return err
+		}
+	}
+
+	saga.Status = models.SagaRolledBack
+	status := packageCancelled
+	for _, state := range saga.Steps {
+		if state.Status == models.StepCompensationFailed {
+			saga.Status = models.SagaFailed
+			status = packageFailed
+		}
+	}
+	if err := o.promotions.ReverseDiscounts(saga.BookingID); err != nil {
+		log.Printf("Package booking %s paused giving back its promotions: %v\n", saga.SagaID, err)
+		return o.save(saga)
+	}
+	// Points may have been redeemed even when the payment step failed afterwards, so they are given
+	// back whenever the traveller paid with some.
+	if saga.Request.Payment.Points > 0 {
+		if err := o.loyalty.ReverseRedemptions(saga.SagaID+"/points/undo", saga.BookingID); err != nil && !errors.Is(err, models.ErrStepRejected) {
+			log.Printf("Package booking %s paused giving back its points: %v\n", saga.SagaID, err)
+			return o.save(saga)
+		}
+	}
+	if _, err := o.bookings.UpdateBookingStatus(saga.BookingID, status, models.AnyVersion, sagaChange(saga.Error)); err != nil {
+		return err
+	}
+	return o.save(saga)
+}
+
+func (o *SagaOrchestrator) undo(saga *models.Saga, step models.SagaStep) error {
+	key := saga.SagaID + "/" + string(step) + "/undo"
+	switch step {
+	case models.StepReserveFlight:
+		return o.flights.ReleaseFlight(key, saga.FlightBookingID)
+	case models.StepReserveHotel:
+		return o.hotels.CancelHotel(key, saga.HotelBookingID)
+	case models.StepTakePayment:
+		if saga.PaymentID == "" {
+			return nil // Paid with points only; compensate gives them back.
+		}
+		return o.payments.RefundPayment(key, saga.PaymentID, *saga.Charged)
+	}
+	// Confirming is the last step, so it never has to be undone.
+	return nil
+}
+
+// packagePrice adds up the flight and hotel prices in the currency the traveller pays in and takes
+// off the promotions that apply to the package.
+func (o *SagaOrchestrator) packagePrice(saga *models.Saga) (*models.PriceQuote, error) {
+	checkIn := saga.Request.Hotel.CheckIn
+	return o.promotions.QuotePrice(models.PricingRequest{
+		UserID:    saga.UserID,
+		BookingID: saga.BookingID,
+		Components: []models.PriceComponent{
+			{ProductType: models.ProductFlight, Price: *saga.FlightPrice},
+			{ProductType: models.ProductHotel, Price: *saga.HotelPrice},
+		},
+		Currency:    paymentCurrency(saga),
+		Destination: saga.FlightDestination,
+		TravelDate:  &checkIn,
+		Codes:       saga.Request.PromoCodes,
+	})
+}
+
+// paymentCurrency is the currency asked for in the request, or else the one the flight is priced in.
+func paymentCurrency(saga *models.Saga) string {
+	if saga.Request.Payment.Currency != "" {
+		return saga.Request.Payment.Currency
+	}
+	return saga.FlightPrice.Currency
+}
+
+func (o *SagaOrchestrator) save(saga *models.Saga) error {
+	saga.UpdatedAt = time.Now().UTC()
+	if err := o.sagas.UpdateSaga(saga); err != nil {
+		return fmt.Errorf("error saving package booking %s: %v", saga.SagaID, err)
+	}
+	return nil
+}
+
+func setStepStatus(state *models.SagaStepState, status models.StepStatus) {
+	state.Status = status
+	state.UpdatedAt = time.Now().UTC()
+}
+
+// claim marks a saga as run by this process; it returns false if it already is.
+func (o *SagaOrchestrator) claim(id string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.active[id] {
+		return false
+	}
+	o.active[id] = true
+	return true
+}
+
+func (o *SagaOrchestrator) release(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.active, id)
+}
+
+// sagaChange attributes a change of a package booking to the saga making it.
+func sagaChange(reason string) models.Change {
+	return models.SystemChange("package-booking-saga", reason)
+}
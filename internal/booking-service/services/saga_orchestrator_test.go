@@ -0,0 +1,408 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/exchangerates"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakePartners stands in for the flight, hotel, payment, loyalty and promotion services. It records
+// the calls that change something and fails the ones named in fail.
+type fakePartners struct {
+	fail  map[string]error
+	calls []string
+
+	hotelPrice   float64              // Price of the stays it reserves; 200 when zero.
+	flightChange models.ChangeCharges // What changing the flights costs.
+	flightPrice  models.Money         // Price of the flights once changed.
+}
+
+func (p *fakePartners) call(name string) error {
+	p.calls = append(p.calls, name)
+	return p.fail[name]
+}
+
+func (p *fakePartners) ReserveFlight(key string, userID string, request models.FlightReservationRequest) (*models.Reservation, error) {
+	if err := p.call("ReserveFlight"); err != nil {
+		return nil, err
+	}
+	return &models.Reservation{ID: "flight-1", Price: models.Money{Amount: 300, Currency: "EUR"}, Destination: "LIS"}, nil
+}
+
+func (p *fakePartners) ReleaseFlight(key string, flightBookingID string) error {
+	return p.call("ReleaseFlight")
+}
+
+func (p *fakePartners) ReserveHotel(key string, userID string, request models.HotelReservationRequest) (*models.Reservation, error) {
+	if err := p.call("ReserveHotel"); err != nil {
+		return nil, err
+	}
+	if p.hotelPrice != 0 {
+		return &models.Reservation{ID: key, Price: models.Money{Amount: p.hotelPrice}}, nil
+	}
+	return &models.Reservation{ID: "hotel-1", Price: models.Money{Amount: 200}}, nil
+}
+
+func (p *fakePartners) QuoteFlightChange(flightBookingID string, itineraryID string) (*models.ChangeCharges, error) {
+	if err := p.fail["QuoteFlightChange"]; err != nil {
+		return nil, err
+	}
+	charges := p.flightChange
+	return &charges, nil
+}
+
+func (p *fakePartners) ChangeFlight(key string, flightBookingID string, itineraryID string) (*models.Money, error) {
+	if err := p.call("ChangeFlight"); err != nil {
+		return nil, err
+	}
+	price := p.flightPrice
+	return &price, nil
+}
+
+func (p *fakePartners) ConfirmHotel(key string, hotelBookingID string) error {
+	return p.call("ConfirmHotel")
+}
+
+func (p *fakePartners) CancelHotel(key string, hotelBookingID string) error {
+	return p.call("CancelHotel")
+}
+
+func (p *fakePartners) TakePayment(key string, userID string, reference string, method string, amount models.Money) (*models.Reservation, error) {
+	if err := p.call("TakePayment"); err != nil {
+		return nil, err
+	}
+	return &models.Reservation{ID: "payment-1", Price: amount}, nil
+}
+
+func (p *fakePartners) RefundPayment(key string, paymentID string, amount models.Money) error {
+	return p.call("RefundPayment")
+}
+
+// RedeemPoints pays a cent per point, up to the price.
+func (p *fakePartners) RedeemPoints(key string, userID string, bookingID string, points int, price models.Money) (*models.Money, error) {
+	if err := p.call("RedeemPoints"); err != nil {
+		return nil, err
+	}
+	return &models.Money{Amount: math.Min(float64(points)/100, price.Amount), Currency: price.Currency}, nil
+}
+
+func (p *fakePartners) ReverseRedemptions(key string, bookingID string) error {
+	return p.call("ReverseRedemptions")
+}
+
+// QuotePrice adds up the components without discounts.
+func (p *fakePartners) QuotePrice(request models.PricingRequest) (*models.PriceQuote, error) {
+	total := models.Money{Currency: request.Currency}
+	for _, component := range request.Components {
+		total.Amount += component.Price.Amount
+	}
+	return &models.PriceQuote{Subtotal: total, Total: total}, nil
+}
+
+func (p *fakePartners) CheckCodes(codes []string) error { return nil }
+
+func (p *fakePartners) RedeemDiscounts(bookingID string, userID string, discounts models.AppliedDiscounts) error {
+	return p.call("RedeemDiscounts")
+}
+
+func (p *fakePartners) ReverseDiscounts(bookingID string) error {
+	return p.call("ReverseDiscounts")
+}
+
+// storedBookings keeps bookings in memory. It applies the fields an update sets and ignores versions,
+// which the orchestrator does not check.
+type storedBookings struct {
+	ports.BookingDB
+	bookings map[string]models.Booking
+}
+
+func (s *storedBookings) CreateBooking(booking *models.Booking, change models.Change) error {
+	booking.Version = 1
+	s.bookings[booking.BookingID] = *booking
+	return nil
+}
+
+func (s *storedBookings) GetBookingByID(id string) (*models.Booking, error) {
+	booking, ok := s.bookings[id]
+	if !ok {
+		return nil, models.ErrBookingNotFound
+	}
+	return &booking, nil
+}
+
+func (s *storedBookings) UpdateBooking(id string, booking *models.Booking, version int, change models.Change) (*models.Booking, error) {
+	updated, ok := s.bookings[id]
+	if !ok {
+		return nil, models.ErrBookingNotFound
+	}
+	if booking.FlightID != "" {
+		updated.FlightID = booking.FlightID
+	}
+	if booking.BookingStatus != "" {
+		updated.BookingStatus = booking.BookingStatus
+	}
+	if booking.TravelDate != nil {
+		updated.TravelDate = booking.TravelDate
+	}
+	if booking.Discounts != nil {
+		updated.Discounts = booking.Discounts
+	}
+	updated.Version++
+	s.bookings[id] = updated
+	return &updated, nil
+}
+
+func (s *storedBookings) UpdateBookingStatus(id string, status string, version int, change models.Change) (*models.Booking, error) {
+	return s.UpdateBooking(id, &models.Booking{BookingStatus: status}, version, change)
+}
+
+// storedSagas keeps copies of sagas in memory, so the orchestrator only sees what it saved.
+type storedSagas struct {
+	ports.SagaDB
+	sagas map[string]models.Saga
+}
+
+func (s *storedSagas) CreateSaga(saga *models.Saga) error {
+	s.sagas[saga.SagaID] = copySaga(*saga)
+	return nil
+}
+
+func (s *storedSagas) GetSagaByID(id string) (*models.Saga, error) {
+	saga, ok := s.sagas[id]
+	if !ok {
+		return nil, models.ErrSagaNotFound
+	}
+	saga = copySaga(saga)
+	return &saga, nil
+}
+
+func (s *storedSagas) GetSagaByBookingID(bookingID string) (*models.Saga, error) {
+	for _, saga := range s.sagas {
+		if saga.BookingID == bookingID {
+			saga = copySaga(saga)
+			return &saga, nil
+		}
+	}
+	return nil, models.ErrSagaNotFound
+}
+
+func (s *storedSagas) GetUnfinishedSagas() ([]models.Saga, error) {
+	var sagas []models.Saga
+	for _, saga := range s.sagas {
+		if !saga.Status.Finished() {
+			sagas = append(sagas, copySaga(saga))
+		}
+	}
+	return sagas, nil
+}
+
+func (s *storedSagas) UpdateSaga(saga *models.Saga) error {
+	s.sagas[saga.SagaID] = copySaga(*saga)
+	return nil
+}
+
+func copySaga(saga models.Saga) models.Saga {
+	saga.Steps = append(models.SagaSteps(nil), saga.Steps...)
+	return saga
+}
+
+func TestSagaCompensation(t *testing.T) {
+	rejected := fmt.Errorf("%w: no availability", models.ErrStepRejected)
+	unreachable := errors.New("connection refused")
+
+	tests := []struct {
+		name          string
+		points        int
+		fail          map[string]error
+		wantStatus    models.SagaStatus
+		wantBooking   string
+		wantErr       error
+		wantCalls     []string
+		wantStepState map[models.SagaStep]models.StepStatus
+	}{
+		{
+			name:        "every step succeeds",
+			wantStatus:  models.SagaCompleted,
+			wantBooking: packageConfirmed,
+			wantCalls:   []string{"ReserveFlight", "ReserveHotel", "RedeemDiscounts", "TakePayment", "ConfirmHotel"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompleted, models.StepReserveHotel: models.StepCompleted,
+				models.StepTakePayment: models.StepCompleted, models.StepConfirm: models.StepCompleted,
+			},
+		},
+		{
+			name:        "hotel rejected releases the flight",
+			fail:        map[string]error{"ReserveHotel": rejected},
+			wantStatus:  models.SagaRolledBack,
+			wantBooking: packageCancelled,
+			wantErr:     models.ErrPackageBookingFailed,
+			wantCalls:   []string{"ReserveFlight", "ReserveHotel", "ReleaseFlight", "ReverseDiscounts"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompensated, models.StepReserveHotel: models.StepFailed,
+				models.StepTakePayment: models.StepPending, models.StepConfirm: models.StepPending,
+			},
+		},
+		{
+			name:        "payment rejected gives back hotel, flight and points",
+			points:      500,
+			fail:        map[string]error{"TakePayment": rejected},
+			wantStatus:  models.SagaRolledBack,
+			wantBooking: packageCancelled,
+			wantErr:     models.ErrPackageBookingFailed,
+			wantCalls: []string{"ReserveFlight", "ReserveHotel", "RedeemDiscounts", "RedeemPoints", "TakePayment",
+				"CancelHotel", "ReleaseFlight", "ReverseDiscounts", "ReverseRedemptions"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompensated, models.StepReserveHotel: models.StepCompensated,
+				models.StepTakePayment: models.StepFailed, models.StepConfirm: models.StepPending,
+			},
+		},
+		{
+			name:        "confirmation rejected refunds the payment",
+			fail:        map[string]error{"ConfirmHotel": rejected},
+			wantStatus:  models.SagaRolledBack,
+			wantBooking: packageCancelled,
+			wantErr:     models.ErrPackageBookingFailed,
+			wantCalls: []string{"ReserveFlight", "ReserveHotel", "RedeemDiscounts", "TakePayment", "ConfirmHotel",
+				"RefundPayment", "CancelHotel", "ReleaseFlight", "ReverseDiscounts"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompensated, models.StepReserveHotel: models.StepCompensated,
+				models.StepTakePayment: models.StepCompensated, models.StepConfirm: models.StepFailed,
+			},
+		},
+		{
+			name:        "confirmation rejected after paying with points only refunds nothing",
+			points:      100000,
+			fail:        map[string]error{"ConfirmHotel": rejected},
+			wantStatus:  models.SagaRolledBack,
+			wantBooking: packageCancelled,
+			wantErr:     models.ErrPackageBookingFailed,
+			wantCalls: []string{"ReserveFlight", "ReserveHotel", "RedeemDiscounts", "RedeemPoints", "ConfirmHotel",
+				"CancelHotel", "ReleaseFlight", "ReverseDiscounts", "ReverseRedemptions"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompensated, models.StepReserveHotel: models.StepCompensated,
+				models.StepTakePayment: models.StepCompensated, models.StepConfirm: models.StepFailed,
+			},
+		},
+		{
+			name:        "rejected release fails the saga",
+			fail:        map[string]error{"ReserveHotel": rejected, "ReleaseFlight": rejected},
+			wantStatus:  models.SagaFailed,
+			wantBooking: packageFailed,
+			wantErr:     models.ErrPackageBookingFailed,
+			wantCalls:   []string{"ReserveFlight", "ReserveHotel", "ReleaseFlight", "ReverseDiscounts"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompensationFailed, models.StepReserveHotel: models.StepFailed,
+				models.StepTakePayment: models.StepPending, models.StepConfirm: models.StepPending,
+			},
+		},
+		{
+			name:        "unreachable hotel pauses the saga without undoing anything",
+			fail:        map[string]error{"ReserveHotel": unreachable},
+			wantStatus:  models.SagaRunning,
+			wantBooking: packagePending,
+			wantCalls:   []string{"ReserveFlight", "ReserveHotel", "ReserveHotel", "ReserveHotel"},
+			wantStepState: map[models.SagaStep]models.StepStatus{
+				models.StepReserveFlight: models.StepCompleted, models.StepReserveHotel: models.StepStarted,
+				models.StepTakePayment: models.StepPending, models.StepConfirm: models.StepPending,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sagas, bookings := &storedSagas{sagas: map[string]models.Saga{}}, &storedBookings{bookings: map[string]models.Booking{}}
+			partners := &fakePartners{fail: tt.fail}
+			o := NewSagaOrchestrator(sagas, bookings, partners, partners, partners, partners, exchangerates.NewStaticExchangeRates(), partners)
+			o.retryDelay = 0
+
+			checkIn := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+			saga, err := o.BookPackage(models.Requester{UserID: "user-1"}, models.PackageBookingRequest{
+				UserID:  "user-1",
+				Flight:  models.FlightReservationRequest{ItineraryID: "itinerary-1", Adults: 1},
+				Hotel:   models.HotelReservationRequest{HotelID: "hotel-1", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 1},
+				Payment: models.PaymentRequest{Method: "card-token", Points: tt.points},
+			})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("BookPackage: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BookPackage error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := sagas.GetSagaByID(saga.SagaID)
+			if err != nil {
+				t.Fatalf("GetSagaByID: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("saga status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			for step, want := range tt.wantStepState {
+				if got := stored.Step(step).Status; got != want {
+					t.Errorf("step %s = %s, want %s", step, got, want)
+				}
+			}
+			booking, err := bookings.GetBookingByID(saga.BookingID)
+			if err != nil {
+				t.Fatalf("GetBookingByID: %v", err)
+			}
+			if booking.BookingStatus != tt.wantBooking {
+				t.Errorf("booking status = %s, want %s", booking.BookingStatus, tt.wantBooking)
+			}
+			if !reflect.DeepEqual(partners.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", partners.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSagaResumesPausedCompensation(t *testing.T) {
+	sagas, bookings := &storedSagas{sagas: map[string]models.Saga{}}, &storedBookings{bookings: map[string]models.Booking{}}
+	partners := &fakePartners{fail: map[string]error{
+		"ReserveHotel":  fmt.Errorf("%w: sold out", models.ErrStepRejected),
+		"ReleaseFlight": errors.New("connection refused"),
+	}}
+	o := NewSagaOrchestrator(sagas, bookings, partners, partners, partners, partners, exchangerates.NewStaticExchangeRates(), partners)
+	o.retryDelay = 0
+
+	checkIn := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	saga, err := o.BookPackage(models.Requester{UserID: "user-1"}, models.PackageBookingRequest{
+		UserID:  "user-1",
+		Flight:  models.FlightReservationRequest{ItineraryID: "itinerary-1", Adults: 1},
+		Hotel:   models.HotelReservationRequest{HotelID: "hotel-1", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 1},
+		Payment: models.PaymentRequest{Method: "card-token"},
+	})
+	if err != nil {
+		t.Fatalf("BookPackage: %v", err)
+	}
+	if saga.Status != models.SagaCompensating {
+		t.Fatalf("saga status = %s, want %s", saga.Status, models.SagaCompensating)
+	}
+
+	delete(partners.fail, "ReleaseFlight")
+	if err := o.ResumeSagas(); err != nil {
+		t.Fatalf("ResumeSagas: %v", err)
+	}
+	stored, err := sagas.GetSagaByID(saga.SagaID)
+	if err != nil {
+		t.Fatalf("GetSagaByID: %v", err)
+	}
+	if stored.Status != models.SagaRolledBack {
+		t.Errorf("saga status = %s, want %s", stored.Status, models.SagaRolledBack)
+	}
+	if got := stored.Step(models.StepReserveFlight).Status; got != models.StepCompensated {
+		t.Errorf("flight step = %s, want %s", got, models.StepCompensated)
+	}
+	booking, err := bookings.GetBookingByID(saga.BookingID)
+	if err != nil {
+		t.Fatalf("GetBookingByID: %v", err)
+	}
+	if booking.BookingStatus != packageCancelled {
+		t.Errorf("booking status = %s, want %s", booking.BookingStatus, packageCancelled)
+	}
+}
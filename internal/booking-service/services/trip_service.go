@@ -0,0 +1,377 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// defaultTripCurrency is the currency trip totals are reported in unless the traveller picks another.
+const defaultTripCurrency = "EUR"
+
+// timelineEvents names the start and end of each kind of trip item on the timeline.
+var timelineEvents = map[models.TripItemType][2]models.TimelineEvent{
+	models.TripItemFlight:   {models.TimelineDeparture, models.TimelineArrival},
+	models.TripItemTransfer: {models.TimelineDeparture, models.TimelineArrival},
+	models.TripItemHotel:    {models.TimelineCheckIn, models.TimelineCheckOut},
+	models.TripItemActivity: {models.TimelineStart, models.TimelineEnd},
+	models.TripItemOther:    {models.TimelineStart, models.TimelineEnd},
+}
+
+type TripService struct {
+	trips    ports.TripDB
+	bookings ports.BookingDB
+	sagas    ports.SagaDB
+	rates    ports.ExchangeRates
+}
+
+func NewTripService(trips ports.TripDB, bookings ports.BookingDB, sagas ports.SagaDB, rates ports.ExchangeRates) *TripService {
+	return &TripService{trips: trips, bookings: bookings, sagas: sagas, rates: rates}
+}
+
+// CreateTrip starts a trip, optionally with its first items. Trips made by anyone but another
+// service belong to the requester.
+func (s *TripService) CreateTrip(requester models.Requester, request models.CreateTripRequest) (*models.Trip, error) {
+	if !requester.Service {
+		request.UserID = requester.UserID
+	}
+	if request.UserID == "" {
+		return nil, fmt.Errorf("%w: user ID is required", models.ErrInvalidTrip)
+	}
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", models.ErrInvalidTrip)
+	}
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = defaultTripCurrency
+	}
+	if !s.rates.Supports(currency) {
+		return nil, fmt.Errorf("%w: unsupported currency %s", models.ErrInvalidTrip, currency)
+	}
+
+	trip := &models.Trip{
+		TripID:   uuid.NewString(),
+		UserID:   request.UserID,
+		Name:     strings.TrimSpace(request.Name),
+		Currency: currency,
+		Items:    models.TripItems{},
+	}
+	for _, item := range request.Items {
+		if err := s.addItem(trip, item); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.trips.CreateTrip(trip); err != nil {
+		return nil, err
+	}
+	return s.withSummary(trip), nil
+}
+
+func (s *TripService) GetTrip(requester models.Requester, id string) (*models.Trip, error) {
+	trip, err := s.tripFor(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withSummary(trip), nil
+}
+
+// GetTripsByUserID lists the trips of a user, the requester's own unless they are privileged.
+func (s *TripService) GetTripsByUserID(requester models.Requester, userID string) ([]models.Trip, error) {
+	if userID == "" {
+		userID = requester.UserID
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user ID is required", models.ErrInvalidTrip)
+	}
+	if !requester.Privileged && userID != requester.UserID {
+		return nil, models.ErrForbidden
+	}
+	trips, err := s.trips.GetTripsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trips {
+		s.withSummary(&trips[i])
+	}
+	return trips, nil
+}
+
+// AddTripItem adds a flight, hotel stay or other item to a trip.
+func (s *TripService) AddTripItem(requester models.Requester, tripID string, item models.TripItem) (*models.Trip, error) {
+	trip, err := s.tripFor(requester, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addItem(trip, item); err != nil {
+		return nil, err
+	}
+	if err := s.trips.UpdateTrip(trip); err != nil {
+		return nil, err
+	}
+	return s.withSummary(trip), nil
+}
+
+func (s *TripService) RemoveTripItem(requester models.Requester, tripID string, itemID string) (*models.Trip, error) {
+	trip, err := s.tripFor(requester, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range trip.Items {
+		if item.ItemID == itemID {
+			trip.Items = append(trip.Items[:i], trip.Items[i+1:]...)
+			if err := s.trips.UpdateTrip(trip); err != nil {
+				return nil, err
+			}
+			return s.withSummary(trip), nil
+		}
+	}
+	return nil, models.ErrTripItemNotFound
+}
+
+// ShareTrip creates a link token that lets anyone view the trip. Sharing again keeps the same token.
+func (s *TripService) ShareTrip(requester models.Requester, tripID string) (*models.TripShare, error) {
+	trip, err := s.tripFor(requester, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip.ShareToken == nil {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		trip.ShareToken = &token
+		if err := s.trips.UpdateTrip(trip); err != nil {
+			return nil, err
+		}
+	}
+	return &models.TripShare{TripID: trip.TripID, ShareToken: *trip.ShareToken}, nil
+}
+
+// StopSharingTrip invalidates the share link of a trip.
+func (s *TripService) StopSharingTrip(requester models.Requester, tripID string) error {
+	trip, err := s.tripFor(requester, tripID)
+	if err != nil {
+		return err
+	}
+	if trip.ShareToken == nil {
+		return nil
+	}
+	trip.ShareToken = nil
+	return s.trips.UpdateTrip(trip)
+}
+
+// GetSharedTrip returns the trip behind a share link. Whoever holds the link sees the plan but not
+// who booked it, the booking references or what was paid.
+func (s *TripService) GetSharedTrip(token string) (*models.Trip, error) {
+	trip, err := s.trips.GetTripByShareToken(token)
+	if err != nil {
+		return nil, err
+	}
+	s.withSummary(trip)
+
+	trip.UserID = ""
+	trip.ShareToken = nil
+	trip.Costs = nil
+	trip.Total = nil
+	for i := range trip.Items {
+		trip.Items[i].BookingID = ""
+		trip.Items[i].Reference = ""
+		trip.Items[i].Cost = nil
+	}
+	return trip, nil
+}
+
+// tripFor reads a trip for privileged requesters and its owner.
+func (s *TripService) tripFor(requester models.Requester, id string) (*models.Trip, error) {
+	trip, err := s.trips.GetTripByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && (requester.UserID == "" || trip.UserID != requester.UserID) {
+		// Do not tell others which trips exist.
+		return nil, models.ErrTripNotFound
+	}
+	return trip, nil
+}
+
+// addItem validates an item and adds it to the trip, keeping the items in order of their start.
+// Items for a booking of this service must belong to the trip's user; the booking's flight is used
+// as title when none is given, and its cost is read from the booking rather than taken from the
+// request.
+func (s *TripService) addItem(trip *models.Trip, item models.TripItem) error {
+	if err := s.validateItem(item); err != nil {
+		return err
+	}
+
+	if item.BookingID != "" {
+		booking, err := s.bookings.GetBookingByID(item.BookingID)
+		if err != nil {
+			if errors.Is(err, models.ErrBookingNotFound) {
+				return fmt.Errorf("%w: booking %s not found", models.ErrInvalidTrip, item.BookingID)
+			}
+			return err
+		}
+		if booking.UserID != trip.UserID {
+			return fmt.Errorf("%w: booking %s belongs to another user", models.ErrInvalidTrip, item.BookingID)
+		}
+		if item.Title == "" {
+			item.Title = "Flight " + booking.FlightID
+		}
+		item.Cost = nil
+	}
+	if item.Title == "" {
+		return fmt.Errorf("%w: item title is required", models.ErrInvalidTrip)
+	}
+
+	item.ItemID = uuid.NewString()
+	if item.Cost != nil {
+		item.Cost.Currency = strings.ToUpper(item.Cost.Currency)
+	}
+	trip.Items = append(trip.Items, item)
+	sort.SliceStable(trip.Items, func(i, j int) bool { return trip.Items[i].StartTime.Before(trip.Items[j].StartTime) })
+	return nil
+}
+
+func (s *TripService) validateItem(item models.TripItem) error {
+	if _, ok := timelineEvents[item.Type]; !ok {
+		return fmt.Errorf("%w: unsupported item type %q", models.ErrInvalidTrip, item.Type)
+	}
+	if item.StartTime.IsZero() {
+		return fmt.Errorf("%w: item start time is required", models.ErrInvalidTrip)
+	}
+	if item.EndTime != nil && item.EndTime.Before(item.StartTime) {
+		return fmt.Errorf("%w: item ends before it starts", models.ErrInvalidTrip)
+	}
+
+	switch item.Type {
+	case models.TripItemFlight, models.TripItemTransfer:
+		if item.Origin == "" || item.Destination == "" {
+			return fmt.Errorf("%w: %s items need an origin and a destination", models.ErrInvalidTrip, item.Type)
+		}
+	case models.TripItemHotel:
+		if item.Location == "" || item.EndTime == nil {
+			return fmt.Errorf("%w: hotel stays need a location and a check-out time", models.ErrInvalidTrip)
+		}
+	}
+
+	if item.Cost != nil {
+		if item.Cost.Amount < 0 {
+			return fmt.Errorf("%w: item cost cannot be negative", models.ErrInvalidTrip)
+		}
+		if !s.rates.Supports(item.Cost.Currency) {
+			return fmt.Errorf("%w: unsupported currency %s", models.ErrInvalidTrip, item.Cost.Currency)
+		}
+	}
+	return nil
+}
+
+// withSummary fills in the timeline and costs of a trip from its items.
+func (s *TripService) withSummary(trip *models.Trip) *models.Trip {
+	trip.Timeline = buildTimeline(trip.Items)
+
+	perCurrency := make(map[string]float64)
+	for i := range trip.Items {
+		item := &trip.Items[i]
+		if item.BookingID != "" {
+			item.Cost = s.bookedCost(*item)
+		}
+		if item.Cost != nil {
+			perCurrency[item.Cost.Currency] += item.Cost.Amount
+		}
+	}
+	trip.Costs = make([]models.Money, 0, len(perCurrency))
+	total := 0.0
+	for currency, amount := range perCurrency {
+		trip.Costs = append(trip.Costs, models.Money{Amount: roundAmount(amount), Currency: currency})
+
+		converted, err := s.rates.Convert(amount, currency, trip.Currency)
+		if err != nil {
+			log.Printf("Failed to convert %s costs of trip %s: %v\n", currency, trip.TripID, err)
+			continue
+		}
+		total += converted
+	}
+	sort.Slice(trip.Costs, func(i, j int) bool { return trip.Costs[i].Currency < trip.Costs[j].Currency })
+	trip.Total = &models.Money{Amount: roundAmount(total), Currency: trip.Currency}
+	return trip
+}
+
+// bookedCost is what was paid for the part of a booking an item stands for: the flight or hotel
+// price of a package, or the whole charge for other items. Bookings made without a package record
+// no price, so their items have no cost.
+func (s *TripService) bookedCost(item models.TripItem) *models.Money {
+	saga, err := s.sagas.GetSagaByBookingID(item.BookingID)
+	if err != nil {
+		if !errors.Is(err, models.ErrSagaNotFound) {
+			log.Printf("Failed to read the price of booking %s: %v\n", item.BookingID, err)
+		}
+		return nil
+	}
+	cost := saga.Charged
+	switch item.Type {
+	case models.TripItemFlight:
+		cost = saga.FlightPrice
+	case models.TripItemHotel:
+		cost = saga.HotelPrice
+	}
+	if cost == nil {
+		return nil
+	}
+	paid := *cost
+	return &paid
+}
+
+// buildTimeline lists the start and end of every item in chronological order, e.g. a departure,
+// the arrival, then the hotel check-in.
+func buildTimeline(items []models.TripItem) []models.TimelineEntry {
+	timeline := []models.TimelineEntry{}
+	for _, item := range items {
+		events := timelineEvents[item.Type]
+		start := models.TimelineEntry{
+			Time:     item.StartTime,
+			Event:    events[0],
+			ItemID:   item.ItemID,
+			Type:     item.Type,
+			Title:    item.Title,
+			Location: item.Location,
+		}
+		if item.Origin != "" {
+			start.Location = item.Origin
+		}
+		timeline = append(timeline, start)
+
+		if item.EndTime != nil {
+			end := start
+			end.Time = *item.EndTime
+			end.Event = events[1]
+			if item.Destination != "" {
+				end.Location = item.Destination
+			}
+			timeline = append(timeline, end)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].Time.Before(timeline[j].Time) })
+	return timeline
+}
+
+func newShareToken() (string, error) {
+	token := make([]byte, 18)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("error generating share token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/booking-service/domain/models"
+	"microservices-travel-backend/internal/booking-service/domain/ports"
+	"microservices-travel-backend/pkg/exchangerates"
+	"testing"
+	"time"
+)
+
+// tripsByID keeps trips in memory.
+type tripsByID struct {
+	ports.TripDB
+	trips map[string]models.Trip
+}
+
+func (t *tripsByID) CreateTrip(trip *models.Trip) error {
+	t.trips[trip.TripID] = *trip
+	return nil
+}
+
+func (t *tripsByID) GetTripByID(id string) (*models.Trip, error) {
+	trip, ok := t.trips[id]
+	if !ok {
+		return nil, models.ErrTripNotFound
+	}
+	trip.Items = append(models.TripItems{}, trip.Items...)
+	return &trip, nil
+}
+
+func (t *tripsByID) UpdateTrip(trip *models.Trip) error {
+	t.trips[trip.TripID] = *trip
+	return nil
+}
+
+// bookingOwners answers which user made each booking.
+type bookingOwners struct {
+	ports.BookingDB
+	owners map[string]string
+}
+
+func (b bookingOwners) GetBookingByID(id string) (*models.Booking, error) {
+	owner, ok := b.owners[id]
+	if !ok {
+		return nil, models.ErrBookingNotFound
+	}
+	return &models.Booking{BookingID: id, UserID: owner, FlightID: "LH400"}, nil
+}
+
+// packagePrices answers the saga each package booking was made through.
+type packagePrices struct {
+	ports.SagaDB
+	sagas map[string]models.Saga
+}
+
+func (p packagePrices) GetSagaByBookingID(bookingID string) (*models.Saga, error) {
+	saga, ok := p.sagas[bookingID]
+	if !ok {
+		return nil, models.ErrSagaNotFound
+	}
+	return &saga, nil
+}
+
+func TestTripService(t *testing.T) {
+	traveller := models.Requester{UserID: "user-1"}
+	departure := time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+	checkOut := departure.AddDate(0, 0, 5)
+	newService := func() *TripService {
+		bookings := bookingOwners{owners: map[string]string{"package-1": "user-1", "flight-1": "user-1", "other-1": "user-2"}}
+		sagas := packagePrices{sagas: map[string]models.Saga{"package-1": {
+			BookingID:   "package-1",
+			FlightPrice: &models.Money{Amount: 400, Currency: "EUR"},
+			HotelPrice:  &models.Money{Amount: 650, Currency: "EUR"},
+			Charged:     &models.Money{Amount: 1000, Currency: "EUR"},
+		}}}
+		return NewTripService(&tripsByID{trips: map[string]models.Trip{}}, bookings, sagas, exchangerates.NewStaticExchangeRates())
+	}
+	flight := func(bookingID string, cost *models.Money) models.TripItem {
+		return models.TripItem{Type: models.TripItemFlight, BookingID: bookingID, Origin: "FRA", Destination: "JFK",
+			StartTime: departure, Cost: cost}
+	}
+
+	t.Run("trips belong to the user of the token", func(t *testing.T) {
+		service := newService()
+		trip, err := service.CreateTrip(traveller, models.CreateTripRequest{UserID: "user-2", Name: "New York"})
+		if err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+		if trip.UserID != "user-1" {
+			t.Errorf("trip belongs to %q, want user-1", trip.UserID)
+		}
+		if _, err := service.CreateTrip(traveller, models.CreateTripRequest{Name: "Attached",
+			Items: []models.TripItem{flight("other-1", nil)}}); !errors.Is(err, models.ErrInvalidTrip) {
+			t.Errorf("CreateTrip with the booking of another user: got %v, want %v", err, models.ErrInvalidTrip)
+		}
+	})
+
+	t.Run("others do not see or change the trip", func(t *testing.T) {
+		service := newService()
+		trip, err := service.CreateTrip(traveller, models.CreateTripRequest{Name: "New York"})
+		if err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+		stranger := models.Requester{UserID: "user-2"}
+		if _, err := service.GetTrip(stranger, trip.TripID); !errors.Is(err, models.ErrTripNotFound) {
+			t.Errorf("GetTrip of another user: got %v, want %v", err, models.ErrTripNotFound)
+		}
+		if _, err := service.ShareTrip(stranger, trip.TripID); !errors.Is(err, models.ErrTripNotFound) {
+			t.Errorf("ShareTrip of another user: got %v, want %v", err, models.ErrTripNotFound)
+		}
+		if _, err := service.GetTripsByUserID(stranger, "user-1"); !errors.Is(err, models.ErrForbidden) {
+			t.Errorf("GetTripsByUserID of another user: got %v, want %v", err, models.ErrForbidden)
+		}
+		if _, err := service.GetTrip(models.Requester{Privileged: true}, trip.TripID); err != nil {
+			t.Errorf("GetTrip by an agent: %v", err)
+		}
+	})
+
+	t.Run("booked items cost what was paid for them", func(t *testing.T) {
+		service := newService()
+		trip, err := service.CreateTrip(traveller, models.CreateTripRequest{Name: "New York", Items: []models.TripItem{
+			flight("package-1", &models.Money{Amount: 1, Currency: "EUR"}),
+			{Type: models.TripItemHotel, BookingID: "package-1", Location: "New York", StartTime: departure, EndTime: &checkOut},
+			flight("flight-1", &models.Money{Amount: 1, Currency: "EUR"}),
+			{Type: models.TripItemActivity, Title: "Museum", StartTime: departure, Cost: &models.Money{Amount: 30, Currency: "EUR"}},
+		}})
+		if err != nil {
+			t.Fatalf("CreateTrip: %v", err)
+		}
+
+		// The flight and hotel of the package at their prices, the museum at what the traveller
+		// entered, and nothing for a flight booking that records no price.
+		if trip.Total == nil || trip.Total.Amount != 1080 {
+			t.Errorf("trip total = %+v, want 1080 EUR", trip.Total)
+		}
+		for _, item := range trip.Items {
+			if item.BookingID == "flight-1" && item.Cost != nil {
+				t.Errorf("flight booking without a price costs %+v", item.Cost)
+			}
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serviceName identifies the flight booking service in the tokens it sends to other services and
+// as the source of its loyalty events.
+const serviceName = "flight-booking-service"
+
+// LoyaltyClient reports flown segments to the loyalty ledger of the user service.
+type LoyaltyClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewLoyaltyClient(baseURL string) *LoyaltyClient {
+	return &LoyaltyClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type bookingEvent struct {
+	ID         string    `json:"id"`
+	Source     string    `json:"source"`
+	Type       string    `json:"type"`
+	UserID     string    `json:"user_id"`
+	BookingID  string    `json:"booking_id"`
+	Reference  string    `json:"reference"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// RecordFlownSegment posts a flight_segment.flown event. The event is named after the coupon, so
+// reporting it again earns nothing more.
+func (c *LoyaltyClient) RecordFlownSegment(segment models.FlownSegment) error {
+	coupon := fmt.Sprintf("%s/%d", segment.TicketNumber, segment.CouponNumber)
+	data, err := json.Marshal(bookingEvent{
+		ID:         coupon,
+		Source:     serviceName,
+		Type:       "flight_segment.flown",
+		UserID:     segment.UserID,
+		BookingID:  segment.BookingID,
+		Reference:  "ticket " + coupon,
+		Amount:     segment.Amount,
+		Currency:   segment.Currency,
+		OccurredAt: segment.FlownAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding loyalty event: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/loyalty/events", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	token, err := middleware.GenerateJWT(serviceName)
+	if err != nil {
+		return fmt.Errorf("error generating service token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error reporting flown segment %s: %v", coupon, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("loyalty event for %s responded %d: %s", coupon, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PaymentClient looks up the payments of bookings in the payment service.
+type PaymentClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewPaymentClient(baseURL string) *PaymentClient {
+	return &PaymentClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (c *PaymentClient) GetPayment(id string) (*models.Payment, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/payments/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	token, err := middleware.GenerateJWT(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("error generating service token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching payment %s: %v", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: payment %s does not exist", models.ErrPaymentNotConfirmed, id)
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("payment %s responded %d: %s", id, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var payment models.Payment
+	if err := json.NewDecoder(resp.Body).Decode(&payment); err != nil {
+		return nil, fmt.Errorf("error decoding payment %s: %v", id, err)
+	}
+	return &payment, nil
+}
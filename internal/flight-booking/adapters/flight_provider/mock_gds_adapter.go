@@ -0,0 +1,296 @@
+package flight_provider
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// mockDeparture describes a daily departure that the mock GDS publishes on every route.
+type mockDeparture struct {
+	carrier      string
+	flightNumber int
+	hour         int
+	minute       int
+	aircraft     string
+}
+
+var mockSchedule = []mockDeparture{
+	{carrier: "LH", flightNumber: 1000, hour: 7, minute: 15, aircraft: "320"},
+	{carrier: "BA", flightNumber: 300, hour: 11, minute: 40, aircraft: "321"},
+	{carrier: "AF", flightNumber: 1500, hour: 16, minute: 5, aircraft: "319"},
+	{carrier: "KL", flightNumber: 1200, hour: 20, minute: 30, aircraft: "73H"},
+}
+
+// Hubs where each mock carrier connects passengers that it does not fly non-stop.
+var carrierHubs = map[string]string{
+	"LH": "FRA",
+	"BA": "LHR",
+	"AF": "CDG",
+	"KL": "AMS",
+}
+
+// Fare multipliers applied on top of the adult economy fare.
+var cabinMultipliers = map[models.CabinClass]float64{
+	models.CabinEconomy:        1.0,
+	models.CabinPremiumEconomy: 1.6,
+	models.CabinBusiness:       3.2,
+	models.CabinFirst:          5.5,
+}
+
+var passengerTypeMultipliers = map[models.PassengerType]float64{
+	models.PassengerAdult:  1.0,
+	models.PassengerChild:  0.75,
+	models.PassengerInfant: 0.1,
+}
+
+// mockFare describes a fare family the mock carriers file on every route.
+type mockFare struct {
+	family              models.FareFamily
+	name                string
+	multiplier          float64 // Applied on top of the cabin fare
+	refundable          bool
+	cancellationFee     float64
+	changeable          bool
+	changeFee           float64
+	advancePurchaseDays int
+	minimumStayDays     int
+	checkedPieces       int
+	cabinPieces         int
+}
+
+var economyFares = []mockFare{
+	{family: models.FareBasic, name: "Light", multiplier: 0.85, advancePurchaseDays: 14, minimumStayDays: 2, cabinPieces: 1},
+	{family: models.FareStandard, name: "Classic", multiplier: 1.0, changeable: true, changeFee: 75, advancePurchaseDays: 3, checkedPieces: 1, cabinPieces: 1},
+	{family: models.FareFlex, name: "Flex", multiplier: 1.4, refundable: true, changeable: true, checkedPieces: 2, cabinPieces: 1},
+}
+
+// Premium cabins are not sold as basic fares.
+var premiumFares = []mockFare{
+	{family: models.FareStandard, name: "Saver", multiplier: 1.0, refundable: true, cancellationFee: 150, changeable: true, changeFee: 100, checkedPieces: 2, cabinPieces: 1},
+	{family: models.FareFlex, name: "Flex", multiplier: 1.25, refundable: true, changeable: true, checkedPieces: 2, cabinPieces: 2},
+}
+
+var cabinNames = map[models.CabinClass]string{
+	models.CabinEconomy:        "Economy",
+	models.CabinPremiumEconomy: "Premium Economy",
+	models.CabinBusiness:       "Business",
+	models.CabinFirst:          "First",
+}
+
+type MockGDSAdapter struct {
+	apiKey string
+	name   string
+}
+
+// NewMockGDSAdapter creates a new MockGDSAdapter that answers searches with generated schedules.
+func NewMockGDSAdapter(apiKey string, name string) *MockGDSAdapter {
+	return &MockGDSAdapter{apiKey: apiKey, name: name}
+}
+
+// SearchFlights returns priced itineraries for the requested legs as raw data
+func (m *MockGDSAdapter) SearchFlights(params models.FlightSearchParams) ([]map[string]interface{}, error) {
+	// Mock API response - in production, you'd call the GDS availability and pricing API here
+	time.Sleep(300 * time.Millisecond) // Simulating network delay
+
+	currency := params.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	var rawResponse []map[string]interface{}
+	for i, departure := range mockSchedule {
+		var legs []map[string]interface{}
+		var adultFare float64
+		var offerID string
+
+		for legIndex, searchLeg := range params.Legs {
+			// Later legs use a different bank of departures so itineraries mix carriers.
+			legDeparture := departure
+			if legIndex > 0 {
+				legDeparture = mockSchedule[(i+2*legIndex)%len(mockSchedule)]
+			}
+
+			legs = append(legs, map[string]interface{}{
+				"segments": m.buildLegSegments(legDeparture, searchLeg, legIndex),
+			})
+			adultFare += m.baseFare(searchLeg.Origin, searchLeg.Destination, legDeparture.carrier, searchLeg.DepartureDate)
+			offerID += fmt.Sprintf("-%s%d-%s", legDeparture.carrier, legDeparture.flightNumber,
+				searchLeg.DepartureDate.Format("20060102"))
+		}
+		adultFare *= cabinMultipliers[params.Cabin]
+		// The price depends on the cabin, passengers and currency searched for, so they are part of
+		// the offer as much as the flights are.
+		offerID = fmt.Sprintf("%s%s-%s-%dA%dC%dI-%s", m.name, offerID, params.Cabin,
+			params.Passengers.Adults, params.Passengers.Children, params.Passengers.Infants, currency)
+
+		// Each fare family is sold as its own offer on the same flights.
+		for _, fare := range faresFor(params.Cabin) {
+			fareOfferID := offerID + "-" + string(fare.family)
+			rawResponse = append(rawResponse, map[string]interface{}{
+				"offer_id":           fareOfferID,
+				"validating_airline": departure.carrier,
+				"cabin":              string(params.Cabin),
+				"seats_available":    9 - i*2,
+				"legs":               legs,
+				"price":              m.buildPrice(adultFare*fare.multiplier, params.Passengers, currency),
+				"fare":               buildFare(fare, params.Cabin),
+				"provider_metadata": map[string]interface{}{
+					"provider_name": m.name,
+					"provider_id":   fareOfferID,
+					"last_updated":  time.Now().UTC().Format(time.RFC3339),
+				},
+			})
+		}
+	}
+
+	return rawResponse, nil
+}
+
+// buildLegSegments flies a leg non-stop, or through the carrier's hub on its connecting departures.
+func (m *MockGDSAdapter) buildLegSegments(departure mockDeparture, leg models.SearchLeg, legIndex int) []map[string]interface{} {
+	departureTime := time.Date(leg.DepartureDate.Year(), leg.DepartureDate.Month(), leg.DepartureDate.Day(),
+		departure.hour, departure.minute, 0, 0, time.UTC)
+	flightNumber := departure.flightNumber + legIndex*10
+
+	hub := carrierHubs[departure.carrier]
+	connects := departure.flightNumber%200 == 0 && hub != leg.Origin && hub != leg.Destination
+	if !connects {
+		segment, _ := m.buildSegment(departure, flightNumber, leg.Origin, leg.Destination, departureTime)
+		return []map[string]interface{}{segment}
+	}
+
+	first, hubArrival := m.buildSegment(departure, flightNumber, leg.Origin, hub, departureTime)
+	// KLM's bank is timed tightly and sometimes undercuts the hub's minimum connection time.
+	layover := 75 + int(routeHash(leg.Origin, hub)%45)
+	if departure.carrier == "KL" {
+		layover = 40
+	}
+	onwardDeparture := hubArrival.Add(time.Duration(layover) * time.Minute)
+	second, _ := m.buildSegment(departure, flightNumber+1, hub, leg.Destination, onwardDeparture)
+
+	return []map[string]interface{}{first, second}
+}
+
+func (m *MockGDSAdapter) buildSegment(departure mockDeparture, flightNumber int, origin, destination string, departureTime time.Time) (map[string]interface{}, time.Time) {
+	duration := 60 + int(routeHash(origin, destination)%420)
+	arrivalTime := departureTime.Add(time.Duration(duration) * time.Minute)
+
+	return map[string]interface{}{
+		"marketing_carrier": departure.carrier,
+		"operating_carrier": departure.carrier,
+		"flight_number":     fmt.Sprintf("%d", flightNumber),
+		"origin":            origin,
+		"destination":       destination,
+		"departure_time":    departureTime.Format(time.RFC3339),
+		"arrival_time":      arrivalTime.Format(time.RFC3339),
+		"aircraft":          departure.aircraft,
+		"duration_minutes":  duration,
+	}, arrivalTime
+}
+
+// baseFare derives a stable adult economy fare for a route, carrier and date.
+func (m *MockGDSAdapter) baseFare(origin, destination, carrier string, date time.Time) float64 {
+	fare := 80 + float64(routeHash(origin, destination)%400)
+	// Carriers and days vary slightly so results differ across providers and dates
+	fare += float64(routeHash(m.name, carrier+date.Format("20060102")) % 60)
+	return fare
+}
+
+func (m *MockGDSAdapter) buildPrice(adultFare float64, passengers models.PassengerMix, currency string) map[string]interface{} {
+	var perTraveler []map[string]interface{}
+	var base, taxes float64
+
+	for _, passengerType := range []models.PassengerType{models.PassengerAdult, models.PassengerChild, models.PassengerInfant} {
+		count := passengers.Counts()[passengerType]
+		if count == 0 {
+			continue
+		}
+		paxBase := roundPrice(adultFare * passengerTypeMultipliers[passengerType])
+		paxTaxes := roundPrice(paxBase*0.12 + 25)
+		if passengerType == models.PassengerInfant {
+			paxTaxes = roundPrice(paxBase * 0.12)
+		}
+
+		perTraveler = append(perTraveler, map[string]interface{}{
+			"passenger_type": string(passengerType),
+			"count":          count,
+			"base":           paxBase,
+			"taxes":          paxTaxes,
+			"total":          roundPrice(paxBase + paxTaxes),
+		})
+		base += paxBase * float64(count)
+		taxes += paxTaxes * float64(count)
+	}
+
+	return map[string]interface{}{
+		"total":        roundPrice(base + taxes),
+		"base":         roundPrice(base),
+		"taxes":        roundPrice(taxes),
+		"currency":     currency,
+		"per_traveler": perTraveler,
+	}
+}
+
+func faresFor(cabin models.CabinClass) []mockFare {
+	if cabin == models.CabinEconomy {
+		return economyFares
+	}
+	return premiumFares
+}
+
+// buildFare describes a fare family with its rules and the baggage each passenger type gets.
+func buildFare(fare mockFare, cabin models.CabinClass) map[string]interface{} {
+	checkedWeight := 23
+	if cabin == models.CabinBusiness || cabin == models.CabinFirst {
+		checkedWeight = 32
+	}
+
+	var baggage []map[string]interface{}
+	for _, passengerType := range []models.PassengerType{models.PassengerAdult, models.PassengerChild, models.PassengerInfant} {
+		allowance := map[string]interface{}{
+			"passenger_type":    string(passengerType),
+			"checked_pieces":    fare.checkedPieces,
+			"checked_weight_kg": checkedWeight,
+			"cabin_pieces":      fare.cabinPieces,
+			"cabin_weight_kg":   8,
+		}
+		// Infants have no seat, so no cabin bag, and get a single lighter bag when the fare includes checked bags.
+		if passengerType == models.PassengerInfant {
+			allowance["checked_pieces"] = min(fare.checkedPieces, 1)
+			allowance["checked_weight_kg"] = 10
+			allowance["cabin_pieces"] = 0
+			allowance["cabin_weight_kg"] = 0
+		}
+		if allowance["checked_pieces"] == 0 {
+			allowance["checked_weight_kg"] = 0
+		}
+		baggage = append(baggage, allowance)
+	}
+
+	return map[string]interface{}{
+		"family": string(fare.family),
+		"name":   cabinNames[cabin] + " " + fare.name,
+		"rules": map[string]interface{}{
+			"refundable":            fare.refundable,
+			"cancellation_fee":      fare.cancellationFee,
+			"changeable":            fare.changeable,
+			"change_fee":            fare.changeFee,
+			"advance_purchase_days": fare.advancePurchaseDays,
+			"minimum_stay_days":     fare.minimumStayDays,
+		},
+		"baggage": baggage,
+	}
+}
+
+func routeHash(a, b string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(a + "-" + b))
+	return h.Sum32()
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,93 @@
+package flight_status
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"os"
+	"time"
+)
+
+// FileStatusFeed reads flight status events from a file with one JSON event per line, such as an
+// export of an airline schedule-change feed, and hands every new line to the status handler.
+type FileStatusFeed struct {
+	path     string
+	handler  ports.FlightStatusHandler
+	interval time.Duration
+	offset   int64 // Bytes of the file already processed
+}
+
+// NewFileStatusFeed creates a feed that checks the file for new events at the given interval.
+func NewFileStatusFeed(path string, handler ports.FlightStatusHandler, interval time.Duration) *FileStatusFeed {
+	return &FileStatusFeed{path: path, handler: handler, interval: interval}
+}
+
+// Start polls the file until stop is closed.
+func (f *FileStatusFeed) Start(stop <-chan struct{}) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.poll(); err != nil {
+			log.Printf("Failed to read flight status feed %s: %v\n", f.path, err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll processes the complete lines appended since the last poll. A line still being written is left for the next poll.
+func (f *FileStatusFeed) poll() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < f.offset {
+		f.offset = 0 // The file was truncated or rotated
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		f.offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var event models.FlightStatusEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			log.Printf("Skipping malformed flight status event: %v\n", err)
+			continue
+		}
+		if _, err := f.handler.HandleFlightStatus(event); err != nil {
+			log.Printf("Failed to handle status of flight %s: %v\n", event.FlightID, err)
+		}
+	}
+}
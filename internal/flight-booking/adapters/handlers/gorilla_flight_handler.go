@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"microservices-travel-backend/internal/flight-booking/domain/models"
 	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -19,79 +25,517 @@ func NewFlightHandler(service ports.FlightService) *FlightHandler {
 }
 
 func (h *FlightHandler) RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/flights", h.CreateFlight).Methods(http.MethodPost)
-	r.HandleFunc("/flights/{id}", h.GetFlightByID).Methods(http.MethodGet)
-	r.HandleFunc("/flights/{id}", h.UpdateFlight).Methods(http.MethodPut)
-	r.HandleFunc("/flights/{id}", h.DeleteFlight).Methods(http.MethodDelete)
+	r.HandleFunc("/flights", h.SearchFlights).Methods(http.MethodGet)
+	r.HandleFunc("/flights/calendar", h.GetFareCalendar).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings", h.BookItinerary).Methods(http.MethodPost)
+	r.Handle("/flights/bookings", middleware.JWTMiddleware(http.HandlerFunc(h.GetBookingsByUserID))).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}", h.GetBookingByID).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}", h.CancelBooking).Methods(http.MethodDelete)
+	r.HandleFunc("/flights/bookings/{id}/cancellation", h.QuoteCancellation).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}/itinerary", h.QuoteItineraryChange).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}/itinerary", h.ChangeItinerary).Methods(http.MethodPut)
+	r.HandleFunc("/flights/bookings/{id}/seatmaps", h.GetBookingSeatMaps).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}/seats", h.AssignSeats).Methods(http.MethodPut)
+	r.HandleFunc("/flights/bookings/{id}/passengers", h.CreatePNR).Methods(http.MethodPost)
+	r.Handle("/flights/bookings/{id}/passengers", middleware.JWTMiddleware(http.HandlerFunc(h.GetBookingPNR))).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}/tickets", h.IssueTickets).Methods(http.MethodPost)
+	r.HandleFunc("/flights/bookings/{id}/tickets", h.GetBookingTickets).Methods(http.MethodGet)
+	r.HandleFunc("/flights/tickets/{number}", h.GetTicket).Methods(http.MethodGet)
+	r.Handle("/flights/tickets/{number}/coupons/{coupon}", middleware.JWTMiddleware(http.HandlerFunc(h.UpdateCouponStatus))).Methods(http.MethodPut)
+	r.HandleFunc("/flights/bookings/{id}/schedule-changes", h.GetScheduleChanges).Methods(http.MethodGet)
+	r.HandleFunc("/flights/bookings/{id}/schedule-changes/{changeId}/accept", h.AcceptScheduleChange).Methods(http.MethodPost)
+	r.Handle("/flights/status-events", middleware.JWTMiddleware(http.HandlerFunc(h.HandleFlightStatus))).Methods(http.MethodPost)
+	r.HandleFunc("/flights/seatmaps/{flightId}", h.GetSeatMap).Methods(http.MethodGet)
+	r.HandleFunc("/flights/pnr/{locator}", h.RetrievePNR).Methods(http.MethodGet)
 	r.HandleFunc("/test", h.TestRoute).Methods(http.MethodGet)
 }
 
-func (h *FlightHandler) CreateFlight(w http.ResponseWriter, r *http.Request) {
-	var flight models.Flight
+// SearchFlights returns priced itineraries, e.g.
+// GET /flights?origin=MAD&destination=CDG&departure_date=2025-06-01&return_date=2025-06-08&adults=2&cabin=economy
+// GET /flights?leg=MAD,CDG,2025-06-01&leg=CDG,FCO,2025-06-05&leg=FCO,MAD,2025-06-09
+func (h *FlightHandler) SearchFlights(w http.ResponseWriter, r *http.Request) {
+	params, err := parseSearchParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid search parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	itineraries, err := h.service.SearchFlights(params)
+	if err != nil {
+		if errors.Is(err, models.ErrNoFlightOffers) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(itineraries)
+}
+
+// GetFareCalendar returns the lowest fare per day on a route, e.g.
+// GET /flights/calendar?origin=MAD&destination=CDG&date=2025-06-10&window=3&adults=2
+// GET /flights/calendar?origin=MAD&destination=CDG&month=2025-06&stay_days=7
+func (h *FlightHandler) GetFareCalendar(w http.ResponseWriter, r *http.Request) {
+	params, err := parseCalendarParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid calendar parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	days, err := h.service.GetFareCalendar(params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(days)
+}
+
+func (h *FlightHandler) BookItinerary(w http.ResponseWriter, r *http.Request) {
+	var request models.ItineraryBookingRequest
 
 	// Parse JSON body
-	err := json.NewDecoder(r.Body).Decode(&flight)
+	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	createdFlight, err := h.service.CreateFlight(&flight)
+	booking, err := h.service.BookItinerary(request)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		if errors.Is(err, models.ErrItineraryNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusGone)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdFlight)
+	json.NewEncoder(w).Encode(booking)
 }
 
-func (h *FlightHandler) GetFlightByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+func (h *FlightHandler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
 
-	flight, err := h.service.GetFlightByID(id)
+	booking, err := h.service.GetBookingByID(id)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(flight)
+	json.NewEncoder(w).Encode(booking)
 }
 
-func (h *FlightHandler) UpdateFlight(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+// GetBookingsByUserID lists the bookings of a traveller, e.g. GET /flights/bookings?user_id=42.
+// Travellers only list their own; other services and admins list anyone's.
+func (h *FlightHandler) GetBookingsByUserID(w http.ResponseWriter, r *http.Request) {
+	caller, ok := middleware.RequireCaller(w, r)
+	if !ok {
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		userID = caller.UserID
+	}
+	if userID == "" {
+		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+	if !caller.IsService() && !caller.HasRole(middleware.RoleAdmin) && userID != caller.UserID {
+		http.Error(w, "Travellers can only list their own bookings", http.StatusForbidden)
+		return
+	}
+
+	bookings, err := h.service.GetBookingsByUserID(userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bookings)
+}
+
+// CancelBooking cancels a booking and returns the fee and refund applied under its fare rules.
+func (h *FlightHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	quote, err := h.service.CancelBooking(id)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
+
+func (h *FlightHandler) QuoteCancellation(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	quote, err := h.service.QuoteCancellation(id)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
+
+func (h *FlightHandler) ChangeItinerary(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request models.ItineraryChangeRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	change, err := h.service.ChangeItinerary(id, request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), changeErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(change)
+}
+
+// QuoteItineraryChange prices moving a booking onto an itinerary from a search without changing it,
+// e.g. GET /flights/bookings/{id}/itinerary?itinerary_id=LH1000-20250601-economy
+func (h *FlightHandler) QuoteItineraryChange(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	request := models.ItineraryChangeRequest{ItineraryID: r.URL.Query().Get("itinerary_id")}
+
+	quote, err := h.service.QuoteItineraryChange(id, request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), changeErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
+
+// changeErrorStatus maps the errors of changing an itinerary to HTTP status codes.
+func changeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBookingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrItineraryNotFound):
+		return http.StatusGone
+	case errors.Is(err, models.ErrChangeNotPermitted):
+		return http.StatusForbidden
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// GetSeatMap returns seat availability for a flight, e.g. GET /flights/seatmaps/LH1000-20250601?aircraft=320&cabin=economy
+func (h *FlightHandler) GetSeatMap(w http.ResponseWriter, r *http.Request) {
+	flightID := mux.Vars(r)["flightId"]
+	query := r.URL.Query()
+
+	seatMap, err := h.service.GetSeatMap(flightID, query.Get("aircraft"), models.CabinClass(query.Get("cabin")))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seatMap)
+}
+
+func (h *FlightHandler) GetBookingSeatMaps(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	seatMaps, err := h.service.GetBookingSeatMaps(id)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seatMaps)
+}
+
+func (h *FlightHandler) AssignSeats(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request models.SeatSelectionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	booking, err := h.service.AssignSeats(id, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrBookingNotFound):
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		case errors.Is(err, models.ErrSeatUnavailable):
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+func (h *FlightHandler) CreatePNR(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request models.PNRRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	pnr, err := h.service.CreatePNR(id, request)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, models.ErrPNRExists) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(pnr)
+}
+
+// GetBookingPNR returns the passengers of a booking to the traveller who made it, other services
+// and admins, with their document numbers masked.
+func (h *FlightHandler) GetBookingPNR(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !h.requireBookingOwner(w, r, id) {
+		return
+	}
+
+	pnr, err := h.service.GetBookingPNR(id)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrPNRNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pnr)
+}
+
+// RetrievePNR finds a passenger name record by locator and surname, e.g. GET /flights/pnr/X7KQ2M?last_name=garcia.
+// Document numbers are masked.
+func (h *FlightHandler) RetrievePNR(w http.ResponseWriter, r *http.Request) {
+	locator := mux.Vars(r)["locator"]
 
-	var flight models.Flight
-	err := json.NewDecoder(r.Body).Decode(&flight)
+	pnr, err := h.service.RetrievePNR(locator, r.URL.Query().Get("last_name"))
+	if err != nil {
+		if errors.Is(err, models.ErrPNRNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pnr)
+}
+
+// IssueTickets issues the e-tickets of a paid booking.
+func (h *FlightHandler) IssueTickets(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request models.TicketingRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
 	}
 
-	updatedFlight, err := h.service.UpdateFlight(id, &flight)
+	tickets, err := h.service.IssueTickets(id, request)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, models.ErrPaymentNotConfirmed) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusPaymentRequired)
+			return
+		}
+		if errors.Is(err, models.ErrAlreadyTicketed) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tickets)
+}
+
+func (h *FlightHandler) GetBookingTickets(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	tickets, err := h.service.GetBookingTickets(id)
 	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updatedFlight)
+	json.NewEncoder(w).Encode(tickets)
 }
 
-func (h *FlightHandler) DeleteFlight(w http.ResponseWriter, r *http.Request) {
+func (h *FlightHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
+	number := mux.Vars(r)["number"]
+
+	ticket, err := h.service.GetTicket(number)
+	if err != nil {
+		if errors.Is(err, models.ErrTicketNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// UpdateCouponStatus changes the status of a coupon, e.g. PUT /flights/tickets/2201234567890/coupons/1 {"status":"used"}
+func (h *FlightHandler) UpdateCouponStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireServiceOrAdmin(w, r, "Only services and admins can change the status of coupons") {
+		return
+	}
+
 	vars := mux.Vars(r)
-	id := vars["id"]
+	couponNumber, err := strconv.Atoi(vars["coupon"])
+	if err != nil {
+		http.Error(w, "Coupon number must be a number", http.StatusBadRequest)
+		return
+	}
+
+	var update models.CouponStatusUpdate
+	err = json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ticket, err := h.service.UpdateCouponStatus(vars["number"], couponNumber, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTicketNotFound):
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		case errors.Is(err, models.ErrCouponStatusConflict):
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// HandleFlightStatus accepts a flight status update from an airline feed and returns the booking
+// changes it caused. Only the services relaying the feeds may send updates.
+func (h *FlightHandler) HandleFlightStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireService(w, r, "Only services can report the status of flights") {
+		return
+	}
 
-	err := h.service.DeleteFlight(id)
+	var event models.FlightStatusEvent
+	err := json.NewDecoder(r.Body).Decode(&event)
 	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.HandleFlightStatus(event)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (h *FlightHandler) GetScheduleChanges(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	changes, err := h.service.GetScheduleChanges(id)
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (h *FlightHandler) AcceptScheduleChange(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	change, err := h.service.AcceptScheduleChange(vars["id"], vars["changeId"])
+	if err != nil {
+		if errors.Is(err, models.ErrBookingNotFound) || errors.Is(err, models.ErrScheduleChangeNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(change)
 }
 
 // TestRoute is a simple health check or testing route
@@ -105,3 +549,164 @@ func (h *FlightHandler) TestRoute(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// parseSearchParams builds flight search criteria from the request query string.
+func parseSearchParams(query url.Values) (models.FlightSearchParams, error) {
+	params := models.FlightSearchParams{
+		Origin:      query.Get("origin"),
+		Destination: query.Get("destination"),
+		Cabin:       models.CabinClass(query.Get("cabin")),
+		Currency:    query.Get("currency"),
+	}
+
+	// Multi-city searches list each leg as origin,destination,date
+	for i, value := range query["leg"] {
+		parts := strings.Split(value, ",")
+		if len(parts) != 3 {
+			return params, fmt.Errorf("leg %d must be formatted as ORIGIN,DESTINATION,YYYY-MM-DD", i+1)
+		}
+		departureDate, err := time.Parse("2006-01-02", parts[2])
+		if err != nil {
+			return params, fmt.Errorf("leg %d date must be formatted as YYYY-MM-DD", i+1)
+		}
+		params.Legs = append(params.Legs, models.SearchLeg{
+			Origin:        parts[0],
+			Destination:   parts[1],
+			DepartureDate: departureDate,
+		})
+	}
+
+	if len(params.Legs) == 0 {
+		departureDate, err := time.Parse("2006-01-02", query.Get("departure_date"))
+		if err != nil {
+			return params, fmt.Errorf("departure_date must be formatted as YYYY-MM-DD")
+		}
+		params.DepartureDate = departureDate
+
+		if value := query.Get("return_date"); value != "" {
+			returnDate, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return params, fmt.Errorf("return_date must be formatted as YYYY-MM-DD")
+			}
+			params.ReturnDate = &returnDate
+		}
+	}
+
+	if err := parsePassengers(query, &params.Passengers); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// parseCalendarParams builds fare calendar criteria from the request query string.
+func parseCalendarParams(query url.Values) (models.FareCalendarParams, error) {
+	params := models.FareCalendarParams{
+		Origin:      query.Get("origin"),
+		Destination: query.Get("destination"),
+		Cabin:       models.CabinClass(query.Get("cabin")),
+		Currency:    query.Get("currency"),
+	}
+
+	if value := query.Get("month"); value != "" {
+		month, err := time.Parse("2006-01", value)
+		if err != nil {
+			return params, fmt.Errorf("month must be formatted as YYYY-MM")
+		}
+		params.Month = month
+	} else {
+		date, err := time.Parse("2006-01-02", query.Get("date"))
+		if err != nil {
+			return params, fmt.Errorf("date must be formatted as YYYY-MM-DD, or month as YYYY-MM")
+		}
+		params.Date = date
+
+		if value := query.Get("window"); value != "" {
+			window, err := strconv.Atoi(value)
+			if err != nil {
+				return params, fmt.Errorf("window must be a number of days")
+			}
+			params.WindowDays = window
+		}
+	}
+
+	if value := query.Get("stay_days"); value != "" {
+		stayDays, err := strconv.Atoi(value)
+		if err != nil {
+			return params, fmt.Errorf("stay_days must be a number")
+		}
+		params.StayDays = &stayDays
+	}
+
+	if err := parsePassengers(query, &params.Passengers); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// parsePassengers reads the passenger counts, defaulting to a single adult.
+func parsePassengers(query url.Values, passengers *models.PassengerMix) error {
+	counts := map[string]*int{
+		"adults":   &passengers.Adults,
+		"children": &passengers.Children,
+		"infants":  &passengers.Infants,
+	}
+	for key, target := range counts {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", key)
+		}
+		*target = count
+	}
+	if query.Get("adults") == "" {
+		passengers.Adults = 1
+	}
+	return nil
+}
+
+// requireService responds 401 or 403 with the given message unless the request comes from another service.
+func requireService(w http.ResponseWriter, r *http.Request, message string) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if ok && !caller.IsService() {
+		http.Error(w, message, http.StatusForbidden)
+		return false
+	}
+	return ok
+}
+
+// requireBookingOwner responds 401 or 404 unless the request comes from another service, an admin or
+// the traveller who made the booking. Others are not told whether the booking exists.
+func (h *FlightHandler) requireBookingOwner(w http.ResponseWriter, r *http.Request, bookingID string) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if !ok {
+		return false
+	}
+	if caller.IsService() || caller.HasRole(middleware.RoleAdmin) {
+		return true
+	}
+	booking, err := h.service.GetBookingByID(bookingID)
+	if err != nil && !errors.Is(err, models.ErrBookingNotFound) {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return false
+	}
+	if err != nil || caller.UserID == "" || booking.UserID != caller.UserID {
+		http.Error(w, fmt.Sprintf("Error: %v", models.ErrBookingNotFound), http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// requireServiceOrAdmin responds 401 or 403 with the given message unless the request comes from
+// another service or an admin.
+func requireServiceOrAdmin(w http.ResponseWriter, r *http.Request, message string) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if ok && !caller.IsService() && !caller.HasRole(middleware.RoleAdmin) {
+		http.Error(w, message, http.StatusForbidden)
+		return false
+	}
+	return ok
+}
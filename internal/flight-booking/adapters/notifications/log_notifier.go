@@ -0,0 +1,35 @@
+package notifications
+
+import (
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+)
+
+// LogNotifier writes traveller notifications to the service log until a delivery channel is wired in.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new LogNotifier.
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// NotifyScheduleChange tells the traveller which flight changed and whether they need to act.
+func (n *LogNotifier) NotifyScheduleChange(booking *models.FlightBooking, pnr *models.PNR, change models.ScheduleChange) error {
+	recipient := "user " + booking.UserID
+	if pnr != nil && pnr.ContactEmail != "" {
+		recipient = pnr.ContactEmail
+	}
+
+	switch change.Type {
+	case models.ScheduleChangeCancellation:
+		log.Printf("Notify %s: flight %s on booking %s was cancelled; rebook or cancel for a full refund\n",
+			recipient, change.FlightID, booking.ID)
+	case models.ScheduleChangeSignificant:
+		log.Printf("Notify %s: flight %s on booking %s now departs %s; accept the new time, rebook or cancel for a full refund\n",
+			recipient, change.FlightID, booking.ID, change.NewDeparture.Format("02 Jan 2006 15:04 MST"))
+	default:
+		log.Printf("Notify %s: flight %s on booking %s now departs %s\n",
+			recipient, change.FlightID, booking.ID, change.NewDeparture.Format("02 Jan 2006 15:04 MST"))
+	}
+	return nil
+}
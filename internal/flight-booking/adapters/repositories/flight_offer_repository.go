@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *PostgresBookingRepository) SaveOffers(offers []models.FlightOffer) error {
+	if len(offers) == 0 {
+		return nil
+	}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"itinerary", "passengers", "expires_at"}),
+	}).Create(&offers).Error
+	if err != nil {
+		return fmt.Errorf("error saving flight offers: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetOffer(id string, now time.Time) (*models.FlightOffer, error) {
+	var offer models.FlightOffer
+	if err := r.DB.First(&offer, "id = ? AND expires_at > ?", id, now).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrItineraryNotFound
+		}
+		return nil, fmt.Errorf("error fetching flight offer: %v", err)
+	}
+	return &offer, nil
+}
+
+func (r *PostgresBookingRepository) DeleteOffer(id string) error {
+	if err := r.DB.Delete(&models.FlightOffer{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("error deleting flight offer: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) DeleteExpiredOffers(now time.Time) error {
+	if err := r.DB.Delete(&models.FlightOffer{}, "expires_at <= ?", now).Error; err != nil {
+		return fmt.Errorf("error deleting expired flight offers: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+
+	"gorm.io/gorm"
+)
+
+// CreatePNR stores the record with its passengers and links it to the booking in a single transaction.
+// It fails with models.ErrPNRExists when the booking already has a record, e.g. one stored by a
+// concurrent request, and with models.ErrDuplicateLocator when the locator is taken.
+func (r *PostgresBookingRepository) CreatePNR(pnr *models.PNR) error {
+	err := r.createPNR(pnr)
+	if errors.Is(err, models.ErrDuplicateLocator) {
+		// Both the locator and the booking ID are unique; the error does not tell which was violated.
+		if _, findErr := r.GetPNRByBookingID(pnr.BookingID); findErr == nil {
+			return models.ErrPNRExists
+		}
+	}
+	return err
+}
+
+func (r *PostgresBookingRepository) createPNR(pnr *models.PNR) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pnr).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return models.ErrDuplicateLocator
+			}
+			return fmt.Errorf("error creating passenger name record: %v", err)
+		}
+
+		result := tx.Model(&models.FlightBooking{}).Where("id = ?", pnr.BookingID).
+			Update("record_locator", pnr.RecordLocator)
+		if result.Error != nil {
+			return fmt.Errorf("error linking passenger name record to booking: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrBookingNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresBookingRepository) GetPNRByLocator(recordLocator string) (*models.PNR, error) {
+	var pnr models.PNR
+	err := r.DB.Preload("Passengers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("index")
+	}).First(&pnr, "record_locator = ?", recordLocator).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPNRNotFound
+		}
+		return nil, fmt.Errorf("error fetching passenger name record: %v", err)
+	}
+	return &pnr, nil
+}
+
+func (r *PostgresBookingRepository) GetPNRByBookingID(bookingID string) (*models.PNR, error) {
+	var pnr models.PNR
+	err := r.DB.Preload("Passengers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("index")
+	}).First(&pnr, "booking_id = ?", bookingID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPNRNotFound
+		}
+		return nil, fmt.Errorf("error fetching passenger name record: %v", err)
+	}
+	return &pnr, nil
+}
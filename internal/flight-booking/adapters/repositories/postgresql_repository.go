@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"os"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type PostgresBookingRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostgresRepository() (*PostgresBookingRepository, error) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	databaseUsername := os.Getenv("DATABASE_USERNAME")
+	databasePassword := os.Getenv("DATABASE_PASSWORD")
+	databasePort := os.Getenv("DATABASE_PORT")
+	databaseName := os.Getenv("DATABASE_NAME")
+	sslMode := os.Getenv("DATABASE_SSLMODE")
+
+	// If DATABASE_NAME is not provided, connect without it (to the default 'postgres' database)
+	var dsn string
+	if databaseName == "" {
+		dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/?sslmode=%s",
+			databaseUsername, databasePassword, databaseURL, databasePort, sslMode)
+	} else {
+		dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
+			databaseUsername, databasePassword, databaseURL, databasePort, databaseName, sslMode)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	log.Println("Successfully connected to the database")
+
+	return &PostgresBookingRepository{DB: db}, nil
+}
+
+func (r *PostgresBookingRepository) CreateBooking(booking *models.FlightBooking) (*models.FlightBooking, error) {
+	if err := r.DB.Create(booking).Error; err != nil {
+		return nil, fmt.Errorf("error creating flight booking: %v", err)
+	}
+	return booking, nil
+}
+
+func (r *PostgresBookingRepository) GetBookingByID(id string) (*models.FlightBooking, error) {
+	var booking models.FlightBooking
+	if err := r.DB.First(&booking, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrBookingNotFound
+		}
+		return nil, fmt.Errorf("error fetching flight booking: %v", err)
+	}
+	return &booking, nil
+}
+
+func (r *PostgresBookingRepository) GetBookingsByUserID(userID string) ([]models.FlightBooking, error) {
+	var bookings []models.FlightBooking
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("error fetching flight bookings for user: %v", err)
+	}
+	return bookings, nil
+}
+
+// GetBookingsByFlightID returns the active bookings with a segment on the given flight.
+func (r *PostgresBookingRepository) GetBookingsByFlightID(flightID string) ([]models.FlightBooking, error) {
+	var bookings []models.FlightBooking
+	err := r.DB.Where(`status <> ? AND EXISTS (
+		SELECT 1 FROM jsonb_array_elements(itinerary->'legs') AS leg, jsonb_array_elements(leg->'segments') AS seg
+		WHERE (seg->>'marketing_carrier') || (seg->>'flight_number') || '-' || COALESCE(seg->>'scheduled_date',
+			to_char((seg->>'departure_time')::timestamptz AT TIME ZONE 'UTC', 'YYYYMMDD')) = ?)`,
+		models.FlightBookingCancelled, flightID).Find(&bookings).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching flight bookings for flight: %v", err)
+	}
+	return bookings, nil
+}
+
+func (r *PostgresBookingRepository) UpdateBookingStatus(id string, status models.FlightBookingStatus) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return fmt.Errorf("error updating flight booking status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) UpdateSeatAssignments(id string, seats models.SeatAssignments, totalPrice float64) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"seats": seats, "total_price": totalPrice})
+	if result.Error != nil {
+		return fmt.Errorf("error updating seat assignments: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
+
+// UpdateCancellation cancels the booking and records the fee and refund applied.
+func (r *PostgresBookingRepository) UpdateCancellation(id string, cancellation models.FeeQuote) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.FlightBookingCancelled,
+			"cancellation": &cancellation,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error cancelling flight booking: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
+
+// UpdateItinerary moves the booking onto new flights. Seats belong to the old flights, so they are cleared.
+func (r *PostgresBookingRepository) UpdateItinerary(id string, itinerary models.Itinerary, fees float64, totalPrice float64) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"itinerary":   itinerary,
+			"trip_type":   itinerary.TripType,
+			"seats":       models.SeatAssignments{},
+			"fees":        fees,
+			"total_price": totalPrice,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error changing flight booking itinerary: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
+
+// UpdateReceipt links the e-ticket receipt of a ticketed booking.
+func (r *PostgresBookingRepository) UpdateReceipt(id string, receiptURL string) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).Update("receipt_url", receiptURL)
+	if result.Error != nil {
+		return fmt.Errorf("error updating booking ticketing: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
+
+// UpdateSchedule stores the itinerary of a booking after the airline changed its flight times.
+func (r *PostgresBookingRepository) UpdateSchedule(id string, itinerary models.Itinerary) error {
+	result := r.DB.Model(&models.FlightBooking{}).Where("id = ?", id).Update("itinerary", itinerary)
+	if result.Error != nil {
+		return fmt.Errorf("error updating flight booking schedule: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBookingNotFound
+	}
+	return nil
+}
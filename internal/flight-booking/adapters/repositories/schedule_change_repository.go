@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+)
+
+func (r *PostgresBookingRepository) CreateScheduleChange(change *models.ScheduleChange) error {
+	if err := r.DB.Create(change).Error; err != nil {
+		return fmt.Errorf("error creating schedule change: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresBookingRepository) GetScheduleChangesByBookingID(bookingID string) ([]models.ScheduleChange, error) {
+	var changes []models.ScheduleChange
+	if err := r.DB.Where("booking_id = ?", bookingID).Order("created_at").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("error fetching schedule changes for booking: %v", err)
+	}
+	return changes, nil
+}
+
+// UpdateScheduleChangeStatus moves a schedule change from one status to another, failing if it is no longer in the expected status.
+func (r *PostgresBookingRepository) UpdateScheduleChangeStatus(id string, from models.ScheduleChangeStatus, to models.ScheduleChangeStatus) error {
+	result := r.DB.Model(&models.ScheduleChange{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return fmt.Errorf("error updating schedule change status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrScheduleChangeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+
+	"gorm.io/gorm"
+)
+
+// NextTicketSerial draws the next ticket serial number from the database sequence.
+func (r *PostgresBookingRepository) NextTicketSerial() (int64, error) {
+	var serial int64
+	if err := r.DB.Raw("SELECT nextval('ticket_serial_seq')").Scan(&serial).Error; err != nil {
+		return 0, fmt.Errorf("error drawing ticket serial: %v", err)
+	}
+	return serial, nil
+}
+
+// CreateTickets marks a booking in the given status as ticketed and stores its tickets and their
+// coupons, in a single transaction. The status is part of the condition, so of two concurrent
+// issuances only one stores tickets.
+func (r *PostgresBookingRepository) CreateTickets(bookingID string, from models.FlightBookingStatus, tickets []models.Ticket) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FlightBooking{}).Where("id = ? AND status = ?", bookingID, from).
+			Update("status", models.FlightBookingConfirmed)
+		if result.Error != nil {
+			return fmt.Errorf("error claiming booking for ticketing: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrAlreadyTicketed
+		}
+		if err := tx.Create(&tickets).Error; err != nil {
+			return fmt.Errorf("error creating tickets: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *PostgresBookingRepository) GetTicket(number string) (*models.Ticket, error) {
+	var ticket models.Ticket
+	err := r.DB.Preload("Coupons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("number")
+	}).First(&ticket, "number = ?", number).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("error fetching ticket: %v", err)
+	}
+	return &ticket, nil
+}
+
+func (r *PostgresBookingRepository) GetTicketsByBookingID(bookingID string) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	err := r.DB.Preload("Coupons", func(db *gorm.DB) *gorm.DB {
+		return db.Order("number")
+	}).Where("booking_id = ?", bookingID).Order("issued_at, passenger_index, number").Find(&tickets).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching tickets for booking: %v", err)
+	}
+	return tickets, nil
+}
+
+// UpdateCouponStatus moves a coupon from one status to another. The current status is part of the
+// condition, so two concurrent updates cannot both succeed.
+func (r *PostgresBookingRepository) UpdateCouponStatus(ticketNumber string, couponNumber int, from models.CouponStatus, to models.CouponStatus) error {
+	result := r.DB.Model(&models.Coupon{}).
+		Where("ticket_number = ? AND number = ? AND status = ?", ticketNumber, couponNumber, from).
+		Update("status", to)
+	if result.Error != nil {
+		return fmt.Errorf("error updating coupon status: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrCouponStatusConflict
+	}
+	return nil
+}
+
+// UpdateBookingCoupons moves every coupon of a booking that is in one status to another.
+func (r *PostgresBookingRepository) UpdateBookingCoupons(bookingID string, from models.CouponStatus, to models.CouponStatus) error {
+	tickets := r.DB.Model(&models.Ticket{}).Select("number").Where("booking_id = ?", bookingID)
+	result := r.DB.Model(&models.Coupon{}).
+		Where("ticket_number IN (?) AND status = ?", tickets, from).
+		Update("status", to)
+	if result.Error != nil {
+		return fmt.Errorf("error updating booking coupons: %v", result.Error)
+	}
+	return nil
+}
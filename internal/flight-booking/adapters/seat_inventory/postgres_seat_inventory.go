@@ -0,0 +1,127 @@
+package seat_inventory
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// seatHold is a row of flight_seat_holds. The (flight_id, seat_number) primary key
+// guarantees a seat can only be held by one booking, even across service replicas.
+type seatHold struct {
+	FlightID   string `gorm:"primaryKey"`
+	SeatNumber string `gorm:"primaryKey"`
+	HoldRef    string
+	Status     models.SeatStatus
+	HeldUntil  *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (seatHold) TableName() string {
+	return "flight_seat_holds"
+}
+
+type PostgresSeatInventory struct {
+	DB *gorm.DB
+}
+
+// NewPostgresSeatInventory creates a seat inventory backed by the flight-booking database.
+func NewPostgresSeatInventory(db *gorm.DB) *PostgresSeatInventory {
+	return &PostgresSeatInventory{DB: db}
+}
+
+// GetSeatMap returns the layout of the flight with current holds applied, limited to the given cabin if set.
+func (s *PostgresSeatInventory) GetSeatMap(flightID string, aircraft string, cabin models.CabinClass) (*models.SeatMap, error) {
+	var holds []seatHold
+	if err := s.DB.Where("flight_id = ?", flightID).Find(&holds).Error; err != nil {
+		return nil, fmt.Errorf("error fetching seat holds: %v", err)
+	}
+
+	seatMap := buildSeatMap(flightID, aircraft)
+	now := time.Now()
+	for _, hold := range holds {
+		if hold.Status == models.SeatHeld && hold.HeldUntil != nil && hold.HeldUntil.Before(now) {
+			continue
+		}
+		if seat, _ := seatMap.Seat(hold.SeatNumber); seat != nil {
+			seat.Status = hold.Status
+		}
+	}
+
+	if cabin != "" {
+		var rows []models.SeatRow
+		for _, row := range seatMap.Rows {
+			if row.Cabin == cabin {
+				rows = append(rows, row)
+			}
+		}
+		seatMap.Rows = rows
+	}
+
+	return seatMap, nil
+}
+
+// HoldSeats replaces the seats holdRef has on the flight with the requested ones until the given time.
+// Either every requested seat is held or the previous seats are kept untouched.
+func (s *PostgresSeatInventory) HoldSeats(flightID string, seatNumbers []string, holdRef string, until time.Time) error {
+	for _, number := range seatNumbers {
+		if soldElsewhere(flightID, number) {
+			return fmt.Errorf("%w: %s on %s", models.ErrSeatUnavailable, number, flightID)
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		// Drop this booking's current seats and any expired holds on the requested ones.
+		if err := tx.Where("flight_id = ? AND (hold_ref = ? OR (seat_number IN ? AND status = ? AND held_until < ?))",
+			flightID, holdRef, seatNumbers, models.SeatHeld, time.Now()).Delete(&seatHold{}).Error; err != nil {
+			return fmt.Errorf("error clearing seat holds: %v", err)
+		}
+
+		holds := make([]seatHold, 0, len(seatNumbers))
+		for _, number := range seatNumbers {
+			holds = append(holds, seatHold{
+				FlightID:   flightID,
+				SeatNumber: number,
+				HoldRef:    holdRef,
+				Status:     models.SeatHeld,
+				HeldUntil:  &until,
+			})
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&holds)
+		if result.Error != nil {
+			return fmt.Errorf("error holding seats: %v", result.Error)
+		}
+		// Any conflicting row means another booking got one of the seats first.
+		if result.RowsAffected != int64(len(holds)) {
+			return fmt.Errorf("%w: one of %v on %s", models.ErrSeatUnavailable, seatNumbers, flightID)
+		}
+		return nil
+	})
+}
+
+// ConfirmSeats turns the holds of holdRef into permanent seat assignments.
+func (s *PostgresSeatInventory) ConfirmSeats(flightID string, holdRef string) error {
+	result := s.DB.Model(&seatHold{}).
+		Where("flight_id = ? AND hold_ref = ?", flightID, holdRef).
+		Updates(map[string]interface{}{"status": models.SeatOccupied, "held_until": nil})
+	if result.Error != nil {
+		return fmt.Errorf("error confirming seats: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no seats held for this booking")
+	}
+	return nil
+}
+
+// ReleaseSeats frees every seat held or occupied by holdRef on the flight.
+func (s *PostgresSeatInventory) ReleaseSeats(flightID string, holdRef string) error {
+	if err := s.DB.Where("flight_id = ? AND hold_ref = ?", flightID, holdRef).Delete(&seatHold{}).Error; err != nil {
+		return fmt.Errorf("error releasing seats: %v", err)
+	}
+	return nil
+}
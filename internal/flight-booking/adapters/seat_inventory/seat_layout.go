@@ -0,0 +1,166 @@
+package seat_inventory
+
+import (
+	"fmt"
+	"hash/fnv"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// aircraftLayout describes the cabin configuration of an aircraft type.
+type aircraftLayout struct {
+	columns       []string    // Seat letters with "" marking an aisle
+	cabins        []cabinRows // Cabins from the front; the rows after them are economy
+	totalRows     int
+	exitRows      []int
+	firstRowExtra bool // First economy row is a bulkhead with extra legroom
+}
+
+// cabinRows is a cabin taking up the given number of rows.
+type cabinRows struct {
+	cabin models.CabinClass
+	rows  int
+}
+
+var narrowBody = aircraftLayout{
+	columns: []string{"A", "B", "C", "", "D", "E", "F"},
+	cabins: []cabinRows{
+		{models.CabinFirst, 1},
+		{models.CabinBusiness, 3},
+		{models.CabinPremiumEconomy, 3},
+	},
+	totalRows:     30,
+	exitRows:      []int{12, 13},
+	firstRowExtra: true,
+}
+
+var wideBody = aircraftLayout{
+	columns: []string{"A", "B", "C", "", "D", "E", "F", "G", "", "H", "J", "K"},
+	cabins: []cabinRows{
+		{models.CabinFirst, 2},
+		{models.CabinBusiness, 6},
+		{models.CabinPremiumEconomy, 4},
+	},
+	totalRows:     45,
+	exitRows:      []int{9, 27},
+	firstRowExtra: true,
+}
+
+var aircraftLayouts = map[string]aircraftLayout{
+	"319": narrowBody,
+	"320": narrowBody,
+	"321": narrowBody,
+	"73H": narrowBody,
+	"77W": wideBody,
+	"789": wideBody,
+}
+
+// Seat selection fees by seat type, filed in seatCurrency. Bookings pay them converted to their own currency.
+const (
+	feeExtraLegroom = 35.0
+	feeWindowAisle  = 12.0
+	feeMiddle       = 8.0
+	seatCurrency    = "USD"
+)
+
+// buildSeatMap lays out every seat of the aircraft as available, then marks seats
+// sold through other channels as occupied.
+func buildSeatMap(flightID, aircraft string) *models.SeatMap {
+	layout, ok := aircraftLayouts[aircraft]
+	if !ok {
+		layout = narrowBody
+	}
+
+	seatMap := &models.SeatMap{
+		FlightID:      flightID,
+		Aircraft:      aircraft,
+		Columns:       layout.columns,
+		LastRefreshed: time.Now().UTC(),
+	}
+
+	economyFrom := 1
+	for _, cabin := range layout.cabins {
+		economyFrom += cabin.rows
+	}
+	for rowNumber := 1; rowNumber <= layout.totalRows; rowNumber++ {
+		row := models.SeatRow{
+			Number: rowNumber,
+			Cabin:  layout.cabinOf(rowNumber),
+		}
+		for _, exitRow := range layout.exitRows {
+			if rowNumber == exitRow {
+				row.ExitRow = true
+			}
+		}
+		row.ExtraLegroom = row.ExitRow || (layout.firstRowExtra && rowNumber == economyFrom)
+
+		for i, column := range layout.columns {
+			if column == "" {
+				continue
+			}
+			// Business and first cabins have no middle seats: only the outermost seats and the ones on an aisle count.
+			if (row.Cabin == models.CabinBusiness || row.Cabin == models.CabinFirst) && seatPosition(layout.columns, i) == models.SeatPreferenceMiddle {
+				continue
+			}
+
+			seat := models.Seat{
+				Number:   fmt.Sprintf("%d%s", rowNumber, column),
+				Column:   column,
+				Position: seatPosition(layout.columns, i),
+				Status:   models.SeatAvailable,
+				Currency: seatCurrency,
+			}
+			seat.Price = seatFee(row, seat.Position)
+			if soldElsewhere(flightID, seat.Number) {
+				seat.Status = models.SeatOccupied
+			}
+			row.Seats = append(row.Seats, seat)
+		}
+		seatMap.Rows = append(seatMap.Rows, row)
+	}
+
+	return seatMap
+}
+
+// cabinOf returns the cabin a row belongs to.
+func (l aircraftLayout) cabinOf(rowNumber int) models.CabinClass {
+	last := 0
+	for _, cabin := range l.cabins {
+		last += cabin.rows
+		if rowNumber <= last {
+			return cabin.cabin
+		}
+	}
+	return models.CabinEconomy
+}
+
+// seatPosition classifies the seat at index i of the column layout.
+func seatPosition(columns []string, i int) models.SeatPreference {
+	if i == 0 || i == len(columns)-1 {
+		return models.SeatPreferenceWindow
+	}
+	if columns[i-1] == "" || columns[i+1] == "" {
+		return models.SeatPreferenceAisle
+	}
+	return models.SeatPreferenceMiddle
+}
+
+func seatFee(row models.SeatRow, position models.SeatPreference) float64 {
+	switch {
+	case row.Cabin != models.CabinEconomy:
+		return 0
+	case row.ExtraLegroom:
+		return feeExtraLegroom
+	case position == models.SeatPreferenceMiddle:
+		return feeMiddle
+	default:
+		return feeWindowAisle
+	}
+}
+
+// soldElsewhere deterministically marks roughly a quarter of the seats as sold by the airline.
+func soldElsewhere(flightID, seatNumber string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(flightID + "/" + seatNumber))
+	return h.Sum32()%4 == 0
+}
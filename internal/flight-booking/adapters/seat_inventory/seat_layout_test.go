@@ -0,0 +1,58 @@
+package seat_inventory
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"testing"
+)
+
+// TestBuildSeatMapCabins checks that every cabin a flight can be searched and booked in has seats
+// on every aircraft, and that cabins above economy have no middle seats.
+func TestBuildSeatMapCabins(t *testing.T) {
+	cabins := []models.CabinClass{models.CabinEconomy, models.CabinPremiumEconomy, models.CabinBusiness, models.CabinFirst}
+	for _, aircraft := range []string{"320", "789", "unknown"} {
+		seatMap := buildSeatMap("LH1000-20250601", aircraft)
+		for _, cabin := range cabins {
+			t.Run(aircraft+"/"+string(cabin), func(t *testing.T) {
+				seats, middle := 0, 0
+				for _, row := range seatMap.Rows {
+					if row.Cabin != cabin {
+						continue
+					}
+					for _, seat := range row.Seats {
+						seats++
+						if seat.Position == models.SeatPreferenceMiddle {
+							middle++
+						}
+					}
+				}
+				if seats == 0 {
+					t.Fatalf("no seats in %s", cabin)
+				}
+				if (cabin == models.CabinBusiness || cabin == models.CabinFirst) && middle > 0 {
+					t.Errorf("%d middle seats in %s", middle, cabin)
+				}
+			})
+		}
+	}
+}
+
+// TestBuildSeatMapCabinOrder checks that cabins follow each other from the front without gaps.
+func TestBuildSeatMapCabinOrder(t *testing.T) {
+	rank := map[models.CabinClass]int{
+		models.CabinFirst:          0,
+		models.CabinBusiness:       1,
+		models.CabinPremiumEconomy: 2,
+		models.CabinEconomy:        3,
+	}
+	for _, aircraft := range []string{"320", "789"} {
+		seatMap := buildSeatMap("LH1000-20250601", aircraft)
+		for i, row := range seatMap.Rows {
+			if row.Number != i+1 {
+				t.Fatalf("%s: row %d numbered %d", aircraft, i+1, row.Number)
+			}
+			if i > 0 && rank[row.Cabin] < rank[seatMap.Rows[i-1].Cabin] {
+				t.Errorf("%s: row %d in %s follows %s", aircraft, row.Number, row.Cabin, seatMap.Rows[i-1].Cabin)
+			}
+		}
+	}
+}
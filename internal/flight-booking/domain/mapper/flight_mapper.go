@@ -0,0 +1,166 @@
+package mapper
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// FlightMapper is responsible for mapping external provider data to the local itinerary format.
+type FlightMapper struct{}
+
+// NewFlightMapper creates a new instance of FlightMapper.
+func NewFlightMapper() *FlightMapper {
+	return &FlightMapper{}
+}
+
+// MapToItinerary maps a provider-specific offer structure to the local itinerary structure.
+func (m *FlightMapper) MapToItinerary(externalOffer map[string]interface{}) models.Itinerary {
+	// Utility functions for safely getting values from a map
+	getString := func(m map[string]interface{}, key string) string {
+		if val, ok := m[key].(string); ok {
+			return val
+		}
+		return ""
+	}
+	getFloat64 := func(m map[string]interface{}, key string) float64 {
+		if val, ok := m[key].(float64); ok {
+			return val
+		}
+		return 0.0
+	}
+	getInt := func(m map[string]interface{}, key string) int {
+		if val, ok := m[key].(int); ok {
+			return val
+		}
+		return 0
+	}
+	getTime := func(m map[string]interface{}, key string) time.Time {
+		if val, ok := m[key].(string); ok {
+			if parsed, err := time.Parse(time.RFC3339, val); err == nil {
+				return parsed
+			}
+		}
+		return time.Time{}
+	}
+	getBool := func(m map[string]interface{}, key string) bool {
+		if val, ok := m[key].(bool); ok {
+			return val
+		}
+		return false
+	}
+	getMap := func(m map[string]interface{}, key string) map[string]interface{} {
+		if val, ok := m[key].(map[string]interface{}); ok {
+			return val
+		}
+		return map[string]interface{}{}
+	}
+
+	// Handle legs and their segments mapping
+	var legs []models.FlightLeg
+	if externalLegs, ok := externalOffer["legs"].([]map[string]interface{}); ok {
+		for _, extLeg := range externalLegs {
+			var segments []models.FlightSegment
+			if externalSegments, ok := extLeg["segments"].([]map[string]interface{}); ok {
+				for _, extSegment := range externalSegments {
+					segments = append(segments, models.FlightSegment{
+						MarketingCarrier: getString(extSegment, "marketing_carrier"),
+						OperatingCarrier: getString(extSegment, "operating_carrier"),
+						FlightNumber:     getString(extSegment, "flight_number"),
+						Origin:           getString(extSegment, "origin"),
+						Destination:      getString(extSegment, "destination"),
+						DepartureTime:    getTime(extSegment, "departure_time"),
+						ArrivalTime:      getTime(extSegment, "arrival_time"),
+						Aircraft:         getString(extSegment, "aircraft"),
+						DurationMinutes:  getInt(extSegment, "duration_minutes"),
+					})
+				}
+			}
+			if len(segments) == 0 {
+				continue
+			}
+
+			first, last := segments[0], segments[len(segments)-1]
+			legs = append(legs, models.FlightLeg{
+				Origin:          first.Origin,
+				Destination:     last.Destination,
+				DepartureTime:   first.DepartureTime,
+				ArrivalTime:     last.ArrivalTime,
+				DurationMinutes: int(last.ArrivalTime.Sub(first.DepartureTime).Minutes()),
+				Segments:        segments,
+			})
+		}
+	}
+
+	// Handle price mapping
+	externalPrice := getMap(externalOffer, "price")
+	var perTraveler []models.PassengerTypePrice
+	if externalPerTraveler, ok := externalPrice["per_traveler"].([]map[string]interface{}); ok {
+		for _, extPax := range externalPerTraveler {
+			perTraveler = append(perTraveler, models.PassengerTypePrice{
+				PassengerType: models.PassengerType(getString(extPax, "passenger_type")),
+				Count:         getInt(extPax, "count"),
+				Base:          getFloat64(extPax, "base"),
+				Taxes:         getFloat64(extPax, "taxes"),
+				Total:         getFloat64(extPax, "total"),
+			})
+		}
+	}
+
+	price := models.OfferPrice{
+		Total:       getFloat64(externalPrice, "total"),
+		Base:        getFloat64(externalPrice, "base"),
+		Taxes:       getFloat64(externalPrice, "taxes"),
+		Currency:    getString(externalPrice, "currency"),
+		PerTraveler: perTraveler,
+	}
+
+	// Handle fare family, rules and baggage mapping
+	externalFare := getMap(externalOffer, "fare")
+	externalRules := getMap(externalFare, "rules")
+	var baggage []models.BaggageAllowance
+	if externalBaggage, ok := externalFare["baggage"].([]map[string]interface{}); ok {
+		for _, extBag := range externalBaggage {
+			baggage = append(baggage, models.BaggageAllowance{
+				PassengerType:   models.PassengerType(getString(extBag, "passenger_type")),
+				CheckedPieces:   getInt(extBag, "checked_pieces"),
+				CheckedWeightKg: getInt(extBag, "checked_weight_kg"),
+				CabinPieces:     getInt(extBag, "cabin_pieces"),
+				CabinWeightKg:   getInt(extBag, "cabin_weight_kg"),
+			})
+		}
+	}
+
+	fare := models.Fare{
+		Family: models.FareFamily(getString(externalFare, "family")),
+		Name:   getString(externalFare, "name"),
+		Rules: models.FareRules{
+			Refundable:          getBool(externalRules, "refundable"),
+			CancellationFee:     getFloat64(externalRules, "cancellation_fee"),
+			Changeable:          getBool(externalRules, "changeable"),
+			ChangeFee:           getFloat64(externalRules, "change_fee"),
+			AdvancePurchaseDays: getInt(externalRules, "advance_purchase_days"),
+			MinimumStayDays:     getInt(externalRules, "minimum_stay_days"),
+			Currency:            price.Currency,
+		},
+		Baggage: baggage,
+	}
+
+	// Map metadata and return the local itinerary model
+	externalMetadata := getMap(externalOffer, "provider_metadata")
+	providerMetadata := models.ProviderMetadata{
+		ProviderName: getString(externalMetadata, "provider_name"),
+		ProviderID:   getString(externalMetadata, "provider_id"),
+		LastUpdated:  getString(externalMetadata, "last_updated"),
+	}
+
+	return models.Itinerary{
+		ID:                getString(externalOffer, "offer_id"),
+		Legs:              legs,
+		Cabin:             models.CabinClass(getString(externalOffer, "cabin")),
+		Price:             price,
+		Fare:              fare,
+		SeatsAvailable:    getInt(externalOffer, "seats_available"),
+		ValidatingAirline: getString(externalOffer, "validating_airline"),
+		ProviderMetadata:  providerMetadata,
+	}
+}
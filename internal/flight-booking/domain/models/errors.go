@@ -0,0 +1,34 @@
+package models
+
+import "errors"
+
+var (
+	// ErrNoFlightOffers is returned when none of the providers has an offer for a search.
+	ErrNoFlightOffers = errors.New("no flight offers found from any provider")
+	// ErrItineraryNotFound is returned when a booked itinerary is unknown or its offer has expired.
+	ErrItineraryNotFound = errors.New("itinerary not found or offer expired")
+	// ErrBookingNotFound is returned when a flight booking does not exist.
+	ErrBookingNotFound = errors.New("flight booking not found")
+	// ErrSeatUnavailable is returned when a requested seat is taken or held by someone else.
+	ErrSeatUnavailable = errors.New("seat is not available")
+	// ErrPNRNotFound is returned when no passenger name record matches the locator and surname.
+	ErrPNRNotFound = errors.New("passenger name record not found")
+	// ErrDuplicateLocator is returned when a generated record locator is already in use.
+	ErrDuplicateLocator = errors.New("record locator already in use")
+	// ErrPNRExists is returned when passengers are recorded for a booking that already has a record.
+	ErrPNRExists = errors.New("booking already has a passenger name record")
+	// ErrChangeNotPermitted is returned when the fare rules of a booking do not allow changing flights.
+	ErrChangeNotPermitted = errors.New("fare does not permit changes")
+	// ErrFareRuleViolation is returned when an itinerary does not meet the conditions of its fare.
+	ErrFareRuleViolation = errors.New("itinerary does not meet its fare rules")
+	// ErrTicketNotFound is returned when a ticket number is unknown.
+	ErrTicketNotFound = errors.New("ticket not found")
+	// ErrCouponStatusConflict is returned when a coupon is not in a status that allows the requested change.
+	ErrCouponStatusConflict = errors.New("coupon status does not allow this change")
+	// ErrPaymentNotConfirmed is returned when a booking is ticketed against a payment that did not take its price.
+	ErrPaymentNotConfirmed = errors.New("payment is not confirmed for this booking")
+	// ErrAlreadyTicketed is returned when tickets are issued for a booking that already has them.
+	ErrAlreadyTicketed = errors.New("booking is already ticketed")
+	// ErrScheduleChangeNotFound is returned when a schedule change does not exist on the booking.
+	ErrScheduleChangeNotFound = errors.New("schedule change not found")
+)
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// FareFamily Enum
+type FareFamily string
+
+const (
+	FareBasic    FareFamily = "basic"
+	FareStandard FareFamily = "standard"
+	FareFlex     FareFamily = "flex"
+)
+
+// FeeAction Enum
+type FeeAction string
+
+const (
+	FeeActionCancel FeeAction = "cancel"
+	FeeActionChange FeeAction = "change"
+)
+
+// Fare describes the fare family an itinerary is sold in and the conditions that come with it.
+type Fare struct {
+	Family  FareFamily         `json:"family"`  // Fare family (basic, standard, flex).
+	Name    string             `json:"name"`    // Commercial name shown to travellers (e.g., Economy Light).
+	Rules   FareRules          `json:"rules"`   // Conditions for refunds, changes and stay.
+	Baggage []BaggageAllowance `json:"baggage"` // Baggage included per passenger type.
+}
+
+// FareRules represents the conditions filed with a fare. Fees are per ticketed passenger.
+type FareRules struct {
+	Refundable          bool    `json:"refundable"`            // Whether the base fare is refunded on cancellation.
+	CancellationFee     float64 `json:"cancellation_fee"`      // Fee withheld from the refund of a refundable fare.
+	Changeable          bool    `json:"changeable"`            // Whether flights can be changed after booking.
+	ChangeFee           float64 `json:"change_fee"`            // Fee charged for each change, on top of any fare difference.
+	AdvancePurchaseDays int     `json:"advance_purchase_days"` // Days before departure the fare must be booked.
+	MinimumStayDays     int     `json:"minimum_stay_days"`     // Days between the outbound arrival and the last departure.
+	Currency            string  `json:"currency"`              // Currency of the fees.
+}
+
+// BaggageAllowance represents the bags included in the fare for one passenger type.
+type BaggageAllowance struct {
+	PassengerType   PassengerType `json:"passenger_type"`    // Passenger type code (ADT, CHD, INF).
+	CheckedPieces   int           `json:"checked_pieces"`    // Number of checked bags.
+	CheckedWeightKg int           `json:"checked_weight_kg"` // Maximum weight of each checked bag.
+	CabinPieces     int           `json:"cabin_pieces"`      // Number of cabin bags besides a personal item.
+	CabinWeightKg   int           `json:"cabin_weight_kg"`   // Maximum weight of each cabin bag.
+}
+
+// FeeQuote represents what a traveller pays or gets back when cancelling or changing a booking.
+type FeeQuote struct {
+	Action         FeeAction `json:"action"`          // Cancel or change.
+	Fee            float64   `json:"fee"`             // Penalty under the fare rules, including any forfeited fare.
+	FareDifference float64   `json:"fare_difference"` // Extra fare due for a more expensive itinerary.
+	AmountDue      float64   `json:"amount_due"`      // Amount the traveller has to pay.
+	Refund         float64   `json:"refund"`          // Amount returned to the traveller.
+	Currency       string    `json:"currency"`        // Currency of the amounts.
+}
+
+// ItineraryChangeRequest is the payload used to move a booking onto another itinerary from a search.
+type ItineraryChangeRequest struct {
+	ItineraryID string `json:"itinerary_id"` // ID of the itinerary offer to change to.
+}
+
+// ItineraryChange is the result of changing a booking: the updated booking and what the change cost.
+type ItineraryChange struct {
+	Booking FlightBooking `json:"booking"` // Booking on its new itinerary.
+	Charges FeeQuote      `json:"charges"` // Fees, fare difference and refund of the change.
+}
+
+// Value stores the fee quote as JSON.
+func (q FeeQuote) Value() (driver.Value, error) {
+	return json.Marshal(q)
+}
+
+// Scan reads a fee quote stored as JSON.
+func (q *FeeQuote) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported fee quote column type")
+	}
+	return json.Unmarshal(data, q)
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// FareCalendarParams holds the criteria for a flexible-date fare search on a route.
+// Either Month is set, or Date with the number of days around it to include.
+type FareCalendarParams struct {
+	Origin      string       `json:"origin"`              // IATA code of the departure airport or city.
+	Destination string       `json:"destination"`         // IATA code of the arrival airport or city.
+	Date        time.Time    `json:"date"`                // Preferred departure date at the centre of the window.
+	WindowDays  int          `json:"window_days"`         // Days before and after Date to include.
+	Month       time.Time    `json:"month"`               // Any day of the month to cover (zero when searching a window).
+	StayDays    *int         `json:"stay_days,omitempty"` // Nights before returning, for round trips (nil for one-way).
+	Passengers  PassengerMix `json:"passengers"`          // Number of travellers per passenger type.
+	Cabin       CabinClass   `json:"cabin"`               // Requested cabin class.
+	Currency    string       `json:"currency"`            // Preferred currency for prices.
+}
+
+// FareCalendarDay represents the cheapest fare found for one departure date.
+type FareCalendarDay struct {
+	Date        time.Time  `json:"date"`                  // Departure date.
+	ReturnDate  *time.Time `json:"return_date,omitempty"` // Return date, for round trips.
+	Available   bool       `json:"available"`             // Whether any provider had a bookable fare that day.
+	LowestFare  float64    `json:"lowest_fare,omitempty"` // Total price of the cheapest itinerary for all passengers.
+	Currency    string     `json:"currency,omitempty"`    // Currency of the fare.
+	FareFamily  FareFamily `json:"fare_family,omitempty"` // Fare family of the cheapest itinerary.
+	Carrier     string     `json:"carrier,omitempty"`     // Validating airline of the cheapest itinerary.
+	RetrievedAt time.Time  `json:"retrieved_at"`          // When the fare was looked up; fares may come from cache.
+}
@@ -1,4 +0,0 @@
-package models
-
-type Flight struct {
-}
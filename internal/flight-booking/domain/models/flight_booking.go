@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// FlightBookingStatus Enum
+type FlightBookingStatus string
+
+const (
+	FlightBookingPending   FlightBookingStatus = "pending"
+	FlightBookingConfirmed FlightBookingStatus = "confirmed"
+	FlightBookingCancelled FlightBookingStatus = "cancelled"
+)
+
+// FlightBooking represents a booking of a whole itinerary for a group of passengers.
+type FlightBooking struct {
+	ID            string              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        string              `json:"user_id"`
+	RecordLocator *string             `gorm:"size:6" json:"record_locator"`
+	Status        FlightBookingStatus `json:"status"`
+	TripType      TripType            `json:"trip_type"`
+	Itinerary     Itinerary           `gorm:"type:jsonb" json:"itinerary"`
+	Adults        int                 `json:"adults"`
+	Children      int                 `json:"children"`
+	Infants       int                 `json:"infants"`
+	Seats         SeatAssignments     `gorm:"type:jsonb" json:"seats"`
+	Fees          float64             `json:"fees"`
+	TotalPrice    float64             `json:"total_price"`
+	Cancellation  *FeeQuote           `gorm:"type:jsonb" json:"cancellation,omitempty"`
+	ReceiptURL    string              `json:"receipt_url,omitempty"`
+	Currency      string              `json:"currency"`
+	CreatedAt     time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ItineraryBookingRequest is the payload used to book an itinerary returned by a search.
+type ItineraryBookingRequest struct {
+	ItineraryID    string         `json:"itinerary_id"`              // ID of the itinerary offer to book.
+	UserID         string         `json:"user_id"`                   // User making the booking.
+	Passengers     PassengerMix   `json:"passengers"`                // Passengers the itinerary was priced for.
+	SeatPreference SeatPreference `json:"seat_preference,omitempty"` // Preference used to auto-assign seats to every passenger.
+}
+
+// SeatedPassengers returns how many passengers need a seat (infants travel on a lap).
+func (b *FlightBooking) SeatedPassengers() int {
+	return b.Adults + b.Children
+}
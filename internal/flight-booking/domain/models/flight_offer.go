@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FlightOffer is an itinerary quoted by a search. It can be booked at its quoted price, for the
+// passengers it was priced for, until it expires.
+type FlightOffer struct {
+	ID         string       `gorm:"primaryKey" json:"id"`              // ID of the itinerary.
+	Itinerary  Itinerary    `gorm:"type:jsonb" json:"itinerary"`       // Flights, fare and price as quoted.
+	Passengers PassengerMix `gorm:"serializer:json" json:"passengers"` // Passengers the price is for.
+	ExpiresAt  time.Time    `gorm:"index" json:"expires_at"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// FlightStatus Enum
+type FlightStatus string
+
+const (
+	FlightScheduled   FlightStatus = "scheduled"   // Operating to schedule
+	FlightDelayed     FlightStatus = "delayed"     // Departing later on the day of travel
+	FlightRescheduled FlightStatus = "rescheduled" // Schedule change published ahead of travel
+	FlightCancelled   FlightStatus = "cancelled"   // Not operating
+)
+
+// ScheduleChangeType Enum
+type ScheduleChangeType string
+
+const (
+	ScheduleChangeMinor        ScheduleChangeType = "minor"
+	ScheduleChangeSignificant  ScheduleChangeType = "significant"
+	ScheduleChangeCancellation ScheduleChangeType = "cancellation"
+)
+
+// ScheduleChangeStatus Enum
+type ScheduleChangeStatus string
+
+const (
+	ScheduleChangePending  ScheduleChangeStatus = "pending"  // Waiting for the traveller to accept or rebook
+	ScheduleChangeAccepted ScheduleChangeStatus = "accepted" // Accepted by the traveller, or automatically when minor
+	ScheduleChangeRebooked ScheduleChangeStatus = "rebooked" // Traveller moved to other flights
+)
+
+// FlightStatusEvent represents a status or schedule update published by an airline for one flight.
+type FlightStatusEvent struct {
+	FlightID      string       `json:"flight_id"`                // Flight identifier (carrier, number and date, e.g., LH1000-20250601).
+	Status        FlightStatus `json:"status"`                   // New status of the flight.
+	DepartureTime *time.Time   `json:"departure_time,omitempty"` // New scheduled or estimated departure time.
+	ArrivalTime   *time.Time   `json:"arrival_time,omitempty"`   // New scheduled or estimated arrival time.
+	Reason        string       `json:"reason,omitempty"`         // Reason given by the airline (e.g., weather, crew, schedule optimisation).
+	PublishedAt   time.Time    `json:"published_at"`             // When the airline published the update.
+}
+
+// ScheduleChange records how a flight status update affected one segment of a booking.
+type ScheduleChange struct {
+	ID                string               `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	BookingID         string               `gorm:"type:uuid;index" json:"booking_id"` // Affected flight booking.
+	FlightID          string               `json:"flight_id"`                         // Flight that changed.
+	SegmentIndex      int                  `json:"segment_index"`                     // Position of the segment in the itinerary.
+	Type              ScheduleChangeType   `json:"type"`                              // Minor, significant or cancellation.
+	Status            ScheduleChangeStatus `json:"status"`                            // Whether the traveller still has to act.
+	PreviousDeparture time.Time            `json:"previous_departure"`                // Departure time before the change.
+	PreviousArrival   time.Time            `json:"previous_arrival"`                  // Arrival time before the change.
+	NewDeparture      *time.Time           `json:"new_departure,omitempty"`           // Departure time after the change (nil when cancelled).
+	NewArrival        *time.Time           `json:"new_arrival,omitempty"`             // Arrival time after the change (nil when cancelled).
+	Reason            string               `json:"reason,omitempty"`                  // Reason given by the airline.
+	CreatedAt         time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// TripType Enum
+type TripType string
+
+const (
+	TripOneWay    TripType = "one_way"
+	TripRoundTrip TripType = "round_trip"
+	TripMultiCity TripType = "multi_city"
+)
+
+// Itinerary represents a priced, bookable combination of flight legs returned by a provider.
+type Itinerary struct {
+	ID                string           `json:"id"`                 // Unique identifier of the itinerary offer.
+	TripType          TripType         `json:"trip_type"`          // One-way, round trip or multi-city.
+	Legs              []FlightLeg      `json:"legs"`               // Journeys between each searched origin and destination, in travel order.
+	Cabin             CabinClass       `json:"cabin"`              // Cabin class the itinerary is priced in.
+	Price             OfferPrice       `json:"price"`              // Combined fare for all legs and passengers.
+	Fare              Fare             `json:"fare"`               // Fare family, rules and baggage allowance.
+	SeatsAvailable    int              `json:"seats_available"`    // Number of seats still sellable at this price.
+	ValidatingAirline string           `json:"validating_airline"` // Airline that issues the ticket.
+	ProviderMetadata  ProviderMetadata `json:"provider_metadata"`  // Metadata related to the external provider.
+}
+
+// FlightLeg represents the journey from one searched origin to its destination, possibly with connections.
+type FlightLeg struct {
+	Origin          string          `json:"origin"`           // Departure airport of the first segment.
+	Destination     string          `json:"destination"`      // Arrival airport of the last segment.
+	DepartureTime   time.Time       `json:"departure_time"`   // Departure time of the first segment.
+	ArrivalTime     time.Time       `json:"arrival_time"`     // Arrival time of the last segment.
+	DurationMinutes int             `json:"duration_minutes"` // Elapsed time from first departure to last arrival.
+	Segments        []FlightSegment `json:"segments"`         // Non-stop flights that make up the leg.
+	Connections     []Connection    `json:"connections"`      // Stopovers between consecutive segments.
+}
+
+// FlightSegment represents a single non-stop flight within a leg.
+type FlightSegment struct {
+	MarketingCarrier string    `json:"marketing_carrier"`        // Airline selling the flight (IATA code).
+	OperatingCarrier string    `json:"operating_carrier"`        // Airline operating the flight (IATA code).
+	FlightNumber     string    `json:"flight_number"`            // Flight number without the carrier code.
+	Origin           string    `json:"origin"`                   // Departure airport (IATA code).
+	Destination      string    `json:"destination"`              // Arrival airport (IATA code).
+	DepartureTime    time.Time `json:"departure_time"`           // Scheduled departure time.
+	ArrivalTime      time.Time `json:"arrival_time"`             // Scheduled arrival time.
+	Aircraft         string    `json:"aircraft"`                 // Aircraft type code (e.g., 320, 77W).
+	DurationMinutes  int       `json:"duration_minutes"`         // Block time of the segment in minutes.
+	Cancelled        bool      `json:"cancelled,omitempty"`      // Whether the airline cancelled the flight after booking.
+	ScheduledDate    string    `json:"scheduled_date,omitempty"` // UTC departure date (YYYYMMDD) before the airline retimed the flight.
+}
+
+// Connection represents the stopover between two consecutive segments of a leg.
+type Connection struct {
+	Airport        string `json:"airport"`         // Airport where the passenger changes flights.
+	Minutes        int    `json:"minutes"`         // Time between arrival and the onward departure.
+	MinimumMinutes int    `json:"minimum_minutes"` // Minimum connection time required at the airport.
+}
+
+// OfferPrice represents the total price of an offer and its breakdown per passenger type.
+type OfferPrice struct {
+	Total       float64              `json:"total"`        // Total price for all passengers.
+	Base        float64              `json:"base"`         // Total base fare for all passengers.
+	Taxes       float64              `json:"taxes"`        // Total taxes and fees for all passengers.
+	Currency    string               `json:"currency"`     // Currency of the prices.
+	PerTraveler []PassengerTypePrice `json:"per_traveler"` // Price breakdown per passenger type.
+}
+
+// PassengerTypePrice represents the price for each passenger of a given type.
+type PassengerTypePrice struct {
+	PassengerType PassengerType `json:"passenger_type"` // Passenger type code (ADT, CHD, INF).
+	Count         int           `json:"count"`          // Number of passengers of this type.
+	Base          float64       `json:"base"`           // Base fare per passenger.
+	Taxes         float64       `json:"taxes"`          // Taxes and fees per passenger.
+	Total         float64       `json:"total"`          // Total price per passenger.
+}
+
+// ProviderMetadata represents the metadata about the external flight provider.
+type ProviderMetadata struct {
+	ProviderName string `json:"provider_name"` // Name of the flight provider (e.g., Amadeus, Sabre).
+	ProviderID   string `json:"provider_id"`   // Unique ID for the offer in the provider's system.
+	LastUpdated  string `json:"last_updated"`  // Timestamp of the last update from the provider.
+}
+
+// Segments returns every segment of the itinerary in travel order.
+func (i Itinerary) Segments() []FlightSegment {
+	var segments []FlightSegment
+	for _, leg := range i.Legs {
+		segments = append(segments, leg.Segments...)
+	}
+	return segments
+}
+
+// Value stores the itinerary as JSON so bookings keep the exact flights that were sold.
+func (i Itinerary) Value() (driver.Value, error) {
+	return json.Marshal(i)
+}
+
+// Scan reads an itinerary stored as JSON.
+func (i *Itinerary) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported itinerary column type")
+	}
+	return json.Unmarshal(data, i)
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// FlownSegment tells the loyalty programme that a passenger flew a segment, so the user who
+// booked it earns points for their share of the fare.
+type FlownSegment struct {
+	TicketNumber string    // Ticket the flown coupon is on.
+	CouponNumber int       // Coupon of the segment on the ticket.
+	UserID       string    // User who made the booking.
+	BookingID    string    // Flight booking the ticket was issued for.
+	Amount       float64   // Share of the passenger's total for the segment.
+	Currency     string    // Currency of the amount.
+	FlownAt      time.Time // When the coupon was recorded as used.
+}
@@ -0,0 +1,14 @@
+package models
+
+// PaymentCaptured is the status of a payment whose money was taken.
+const PaymentCaptured = "captured"
+
+// Payment is what the payment service reports about the payment of a booking.
+type Payment struct {
+	ID             string  `json:"id"`
+	Reference      string  `json:"reference"` // Booking the payment is for.
+	Amount         float64 `json:"amount"`
+	RefundedAmount float64 `json:"refunded_amount"`
+	Currency       string  `json:"currency"`
+	Status         string  `json:"status"`
+}
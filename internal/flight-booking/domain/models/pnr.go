@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// TravelDocumentType Enum
+type TravelDocumentType string
+
+const (
+	DocumentPassport TravelDocumentType = "passport"
+	DocumentIDCard   TravelDocumentType = "id_card"
+)
+
+// PNR (passenger name record) holds who is travelling on a flight booking and how to reach them.
+type PNR struct {
+	RecordLocator string      `gorm:"primaryKey;size:6" json:"record_locator"` // Six-character booking reference given to the traveller.
+	BookingID     string      `gorm:"type:uuid;uniqueIndex" json:"booking_id"` // Flight booking the record belongs to.
+	Passengers    []Passenger `gorm:"foreignKey:RecordLocator;references:RecordLocator" json:"passengers"`
+	ContactEmail  string      `json:"contact_email"` // Email used for booking notifications.
+	ContactPhone  string      `json:"contact_phone"` // Phone used for disruption notifications.
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Passenger represents a traveller on a PNR, including the advance passenger information (APIS) airlines require.
+type Passenger struct {
+	ID                uint           `gorm:"primaryKey" json:"-"`
+	RecordLocator     string         `gorm:"size:6;index" json:"-"`
+	Index             int            `json:"index"`                          // Position on the booking: adults first, then children, then infants.
+	Type              PassengerType  `json:"type"`                           // Passenger type code (ADT, CHD, INF).
+	Title             string         `json:"title,omitempty"`                // Title (e.g., MR, MRS, MS, MSTR, MISS).
+	FirstName         string         `json:"first_name"`                     // Given names as printed on the travel document.
+	LastName          string         `json:"last_name"`                      // Surname as printed on the travel document.
+	DateOfBirth       time.Time      `gorm:"type:date" json:"date_of_birth"` // Date of birth.
+	Gender            string         `json:"gender"`                         // M, F or X as printed on the travel document.
+	Nationality       string         `json:"nationality"`                    // ISO 3166-1 alpha-2 country code.
+	Document          TravelDocument `gorm:"embedded;embeddedPrefix:document_" json:"document"`
+	Email             string         `json:"email,omitempty"`              // Passenger contact email.
+	Phone             string         `json:"phone,omitempty"`              // Passenger contact phone in E.164 format.
+	AccompanyingIndex *int           `json:"accompanying_index,omitempty"` // Index of the adult an infant travels with.
+}
+
+// TravelDocument represents the passport or identity card a passenger travels with.
+type TravelDocument struct {
+	Type           TravelDocumentType `json:"type"`                         // Passport or national identity card.
+	Number         string             `json:"number"`                       // Document number.
+	IssuingCountry string             `json:"issuing_country"`              // ISO 3166-1 alpha-2 code of the issuing country.
+	ExpiryDate     time.Time          `gorm:"type:date" json:"expiry_date"` // Date the document expires.
+}
+
+// PNRRequest is the payload used to add passenger details to a flight booking.
+type PNRRequest struct {
+	Passengers   []Passenger `json:"passengers"`    // Every traveller on the booking.
+	ContactEmail string      `json:"contact_email"` // Email used for booking notifications.
+	ContactPhone string      `json:"contact_phone"` // Phone used for disruption notifications.
+}
+
+// FullName returns the passenger name in airline format (SURNAME/GIVEN NAMES TITLE).
+func (p Passenger) FullName() string {
+	name := p.LastName + "/" + p.FirstName
+	if p.Title != "" {
+		name += " " + p.Title
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// CabinClass Enum
+type CabinClass string
+
+const (
+	CabinEconomy        CabinClass = "economy"
+	CabinPremiumEconomy CabinClass = "premium_economy"
+	CabinBusiness       CabinClass = "business"
+	CabinFirst          CabinClass = "first"
+)
+
+// PassengerType Enum (IATA passenger type codes)
+type PassengerType string
+
+const (
+	PassengerAdult  PassengerType = "ADT"
+	PassengerChild  PassengerType = "CHD"
+	PassengerInfant PassengerType = "INF"
+)
+
+// PassengerMix represents the number of travellers of each passenger type.
+type PassengerMix struct {
+	Adults   int `json:"adults"`   // Passengers aged 12 and over (ADT).
+	Children int `json:"children"` // Passengers aged 2 to 11 (CHD).
+	Infants  int `json:"infants"`  // Passengers under 2 travelling on an adult's lap (INF).
+}
+
+// Total returns the number of travellers in the mix.
+func (p PassengerMix) Total() int {
+	return p.Adults + p.Children + p.Infants
+}
+
+// Counts returns the mix keyed by passenger type, skipping empty types.
+func (p PassengerMix) Counts() map[PassengerType]int {
+	counts := make(map[PassengerType]int)
+	if p.Adults > 0 {
+		counts[PassengerAdult] = p.Adults
+	}
+	if p.Children > 0 {
+		counts[PassengerChild] = p.Children
+	}
+	if p.Infants > 0 {
+		counts[PassengerInfant] = p.Infants
+	}
+	return counts
+}
+
+// SearchLeg represents one origin-destination pair of a search and the date it is flown.
+type SearchLeg struct {
+	Origin        string    `json:"origin"`         // IATA code of the departure airport or city.
+	Destination   string    `json:"destination"`    // IATA code of the arrival airport or city.
+	DepartureDate time.Time `json:"departure_date"` // Travel date of the leg.
+}
+
+// FlightSearchParams holds the criteria for a flight availability search.
+// One-way and round-trip searches set Origin, Destination and the dates; multi-city
+// searches list every leg in Legs instead.
+type FlightSearchParams struct {
+	Origin        string       `json:"origin"`                // IATA code of the departure airport or city.
+	Destination   string       `json:"destination"`           // IATA code of the arrival airport or city.
+	DepartureDate time.Time    `json:"departure_date"`        // Outbound travel date.
+	ReturnDate    *time.Time   `json:"return_date,omitempty"` // Inbound travel date (nil for one-way).
+	Legs          []SearchLeg  `json:"legs,omitempty"`        // Every leg of the trip, in travel order.
+	Passengers    PassengerMix `json:"passengers"`            // Number of travellers per passenger type.
+	Cabin         CabinClass   `json:"cabin"`                 // Requested cabin class.
+	Currency      string       `json:"currency"`              // Preferred currency for prices.
+}
+
+// TripType infers the kind of trip being searched from its legs.
+func (p FlightSearchParams) TripType() TripType {
+	switch {
+	case len(p.Legs) == 2 && p.Legs[0].Origin == p.Legs[1].Destination && p.Legs[0].Destination == p.Legs[1].Origin:
+		return TripRoundTrip
+	case len(p.Legs) > 1:
+		return TripMultiCity
+	default:
+		return TripOneWay
+	}
+}
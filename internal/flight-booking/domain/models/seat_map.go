@@ -0,0 +1,134 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SeatStatus Enum
+type SeatStatus string
+
+const (
+	SeatAvailable SeatStatus = "available"
+	SeatHeld      SeatStatus = "held"
+	SeatOccupied  SeatStatus = "occupied"
+	SeatBlocked   SeatStatus = "blocked"
+)
+
+// SeatPreference Enum (values match FlightBookingRequest.seatPreference in the API spec)
+type SeatPreference string
+
+const (
+	SeatPreferenceWindow SeatPreference = "Window"
+	SeatPreferenceAisle  SeatPreference = "Aisle"
+	SeatPreferenceMiddle SeatPreference = "Middle"
+)
+
+// SeatMap represents the seat layout and availability of a single flight.
+type SeatMap struct {
+	FlightID      string    `json:"flight_id"`      // Flight identifier (carrier, number and date, e.g., LH1000-20250601).
+	Aircraft      string    `json:"aircraft"`       // Aircraft type code the layout belongs to.
+	Columns       []string  `json:"columns"`        // Seat letters from left to right; an empty entry marks an aisle.
+	Rows          []SeatRow `json:"rows"`           // Seat rows from front to back.
+	LastRefreshed time.Time `json:"last_refreshed"` // When availability was last read from the inventory.
+}
+
+// SeatRow represents a row of seats in a cabin.
+type SeatRow struct {
+	Number       int        `json:"number"`        // Row number as shown on board.
+	Cabin        CabinClass `json:"cabin"`         // Cabin the row belongs to.
+	ExitRow      bool       `json:"exit_row"`      // Whether the row is an emergency exit row.
+	ExtraLegroom bool       `json:"extra_legroom"` // Whether the row has extra legroom.
+	Seats        []Seat     `json:"seats"`         // Seats in the row from left to right.
+}
+
+// Seat represents a single seat and its sellable state.
+type Seat struct {
+	Number   string         `json:"number"`   // Seat number (e.g., 12A).
+	Column   string         `json:"column"`   // Seat letter.
+	Position SeatPreference `json:"position"` // Window, aisle or middle seat.
+	Status   SeatStatus     `json:"status"`   // Current availability of the seat.
+	Price    float64        `json:"price"`    // Seat selection fee (0 when free).
+	Currency string         `json:"currency"` // Currency of the seat fee.
+}
+
+// SeatAssignment represents a seat allocated to a passenger on one segment of a booking.
+type SeatAssignment struct {
+	FlightID       string  `json:"flight_id"`       // Flight the seat is on.
+	SegmentIndex   int     `json:"segment_index"`   // Position of the segment in the itinerary.
+	PassengerIndex int     `json:"passenger_index"` // Position of the passenger in the booking (adults first, then children).
+	SeatNumber     string  `json:"seat_number"`     // Assigned seat number.
+	Price          float64 `json:"price"`           // Fee charged for the seat, in the currency of the booking.
+	AutoAssigned   bool    `json:"auto_assigned"`   // Whether the seat was picked from preferences rather than by the passenger.
+}
+
+// SeatAssignments is the list of seats held by a booking, stored as JSON.
+type SeatAssignments []SeatAssignment
+
+// Value stores the seat assignments as JSON.
+func (s SeatAssignments) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan reads seat assignments stored as JSON.
+func (s *SeatAssignments) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported seat assignments column type")
+	}
+	return json.Unmarshal(data, s)
+}
+
+// SeatSelection is a seat explicitly chosen for a passenger on a segment.
+type SeatSelection struct {
+	SegmentIndex   int    `json:"segment_index"`   // Position of the segment in the itinerary.
+	PassengerIndex int    `json:"passenger_index"` // Position of the passenger in the booking.
+	SeatNumber     string `json:"seat_number"`     // Requested seat number.
+}
+
+// SeatSelectionRequest is the payload used to choose seats for a booking.
+// Passengers without an explicit selection get a seat matching their preference.
+type SeatSelectionRequest struct {
+	Selections  []SeatSelection        `json:"selections"`  // Seats picked by the passengers.
+	Preferences map[int]SeatPreference `json:"preferences"` // Seat preference per passenger index.
+}
+
+// FlightID identifies the flight a segment is operated on.
+func (s FlightSegment) FlightID() string {
+	return fmt.Sprintf("%s%s-%s", s.MarketingCarrier, s.FlightNumber, s.ScheduledDepartureDate())
+}
+
+// ScheduledDepartureDate returns the UTC date the flight was scheduled to depart on, which a delay
+// past midnight does not change.
+func (s FlightSegment) ScheduledDepartureDate() string {
+	if s.ScheduledDate != "" {
+		return s.ScheduledDate
+	}
+	return s.DepartureTime.UTC().Format("20060102")
+}
+
+// Seat finds a seat in the map by its number.
+func (m *SeatMap) Seat(number string) (*Seat, *SeatRow) {
+	for i := range m.Rows {
+		for j := range m.Rows[i].Seats {
+			if m.Rows[i].Seats[j].Number == number {
+				return &m.Rows[i].Seats[j], &m.Rows[i]
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// CouponStatus Enum
+type CouponStatus string
+
+const (
+	CouponOpen     CouponStatus = "open"
+	CouponUsed     CouponStatus = "used"
+	CouponVoid     CouponStatus = "void"
+	CouponRefunded CouponStatus = "refunded"
+)
+
+// Ticket represents an electronic ticket issued to one passenger. A ticket holds up to four
+// coupons; longer itineraries continue on conjunction tickets.
+type Ticket struct {
+	Number            string        `gorm:"primaryKey;size:13" json:"number"`          // Airline accounting code followed by a 10-digit serial.
+	BookingID         string        `gorm:"type:uuid;index" json:"booking_id"`         // Flight booking the ticket was issued for.
+	RecordLocator     string        `gorm:"size:6" json:"record_locator"`              // Passenger name record of the booking.
+	PassengerIndex    int           `json:"passenger_index"`                           // Position of the passenger on the PNR.
+	PassengerName     string        `json:"passenger_name"`                            // Name in airline format (SURNAME/GIVEN NAMES TITLE).
+	PassengerType     PassengerType `json:"passenger_type"`                            // Passenger type code (ADT, CHD, INF).
+	ConjunctionWith   string        `gorm:"size:13" json:"conjunction_with,omitempty"` // First ticket of the set, for conjunction tickets.
+	ValidatingAirline string        `json:"validating_airline"`                        // Airline the ticket is issued on.
+	FareFamily        FareFamily    `json:"fare_family"`                               // Fare family the ticket was sold in.
+	Total             float64       `json:"total"`                                     // Fare and taxes of the passenger (0 on conjunction tickets).
+	Currency          string        `json:"currency"`                                  // Currency of the total.
+	PaymentReference  string        `json:"payment_reference"`                         // Reference of the confirmed payment.
+	IssuedAt          time.Time     `json:"issued_at"`                                 // When the ticket was issued.
+	Coupons           []Coupon      `gorm:"foreignKey:TicketNumber;references:Number" json:"coupons"`
+}
+
+// Coupon represents the right to fly one segment of the itinerary.
+type Coupon struct {
+	TicketNumber  string       `gorm:"primaryKey;size:13" json:"-"`
+	Number        int          `gorm:"primaryKey" json:"number"` // Coupon number on the ticket (1-4).
+	SegmentIndex  int          `json:"segment_index"`            // Position of the segment in the itinerary.
+	FlightID      string       `json:"flight_id"`                // Flight the coupon is valid on.
+	Origin        string       `json:"origin"`                   // Departure airport (IATA code).
+	Destination   string       `json:"destination"`              // Arrival airport (IATA code).
+	DepartureTime time.Time    `json:"departure_time"`           // Scheduled departure time.
+	Status        CouponStatus `json:"status"`                   // Open, used, void or refunded.
+	UpdatedAt     time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TicketingRequest is the payload used to issue the tickets of a paid booking.
+type TicketingRequest struct {
+	PaymentReference string `json:"payment_reference"` // Reference of the confirmed payment.
+}
+
+// CouponStatusUpdate is the payload used to change the status of a coupon, e.g. when the flight is boarded.
+type CouponStatusUpdate struct {
+	Status CouponStatus `json:"status"` // New status of the coupon.
+}
@@ -3,8 +3,14 @@ package ports
 import "microservices-travel-backend/internal/flight-booking/domain/models"
 
 type FlightDB interface {
-	CreateFlight(hotel *models.Flight) (*models.Flight, error)
-	GetFlightByID(id string) (*models.Flight, error)
-	UpdateFlight(id string, hotel *models.Flight) (*models.Flight, error)
-	DeleteFlight(id string) error
+	CreateBooking(booking *models.FlightBooking) (*models.FlightBooking, error)
+	GetBookingByID(id string) (*models.FlightBooking, error)
+	GetBookingsByUserID(userID string) ([]models.FlightBooking, error)
+	GetBookingsByFlightID(flightID string) ([]models.FlightBooking, error)
+	UpdateBookingStatus(id string, status models.FlightBookingStatus) error
+	UpdateSeatAssignments(id string, seats models.SeatAssignments, totalPrice float64) error
+	UpdateCancellation(id string, cancellation models.FeeQuote) error
+	UpdateItinerary(id string, itinerary models.Itinerary, fees float64, totalPrice float64) error
+	UpdateReceipt(id string, receiptURL string) error
+	UpdateSchedule(id string, itinerary models.Itinerary) error
 }
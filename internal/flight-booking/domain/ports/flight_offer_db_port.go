@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// FlightOfferDB keeps the offers of recent searches where every instance of the service can book them.
+type FlightOfferDB interface {
+	// SaveOffers stores offers, replacing those with the same ID.
+	SaveOffers(offers []models.FlightOffer) error
+	// GetOffer returns an offer unless it expired by now; models.ErrItineraryNotFound otherwise.
+	GetOffer(id string, now time.Time) (*models.FlightOffer, error)
+	DeleteOffer(id string) error
+	DeleteExpiredOffers(now time.Time) error
+}
@@ -0,0 +1,7 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+type FlightProvider interface {
+	SearchFlights(params models.FlightSearchParams) ([]map[string]interface{}, error)
+}
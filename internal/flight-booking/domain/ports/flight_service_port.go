@@ -3,8 +3,26 @@ package ports
 import "microservices-travel-backend/internal/flight-booking/domain/models"
 
 type FlightService interface {
-	CreateFlight(hotel *models.Flight) (*models.Flight, error)
-	GetFlightByID(id string) (*models.Flight, error)
-	UpdateFlight(id string, hotel *models.Flight) (*models.Flight, error)
-	DeleteFlight(id string) error
+	SearchFlights(params models.FlightSearchParams) ([]models.Itinerary, error)
+	GetFareCalendar(params models.FareCalendarParams) ([]models.FareCalendarDay, error)
+	BookItinerary(request models.ItineraryBookingRequest) (*models.FlightBooking, error)
+	GetBookingByID(id string) (*models.FlightBooking, error)
+	GetBookingsByUserID(userID string) ([]models.FlightBooking, error)
+	CancelBooking(id string) (*models.FeeQuote, error)
+	QuoteCancellation(id string) (*models.FeeQuote, error)
+	QuoteItineraryChange(id string, request models.ItineraryChangeRequest) (*models.FeeQuote, error)
+	ChangeItinerary(id string, request models.ItineraryChangeRequest) (*models.ItineraryChange, error)
+	GetSeatMap(flightID string, aircraft string, cabin models.CabinClass) (*models.SeatMap, error)
+	GetBookingSeatMaps(bookingID string) ([]models.SeatMap, error)
+	AssignSeats(bookingID string, request models.SeatSelectionRequest) (*models.FlightBooking, error)
+	CreatePNR(bookingID string, request models.PNRRequest) (*models.PNR, error)
+	GetBookingPNR(bookingID string) (*models.PNR, error)
+	RetrievePNR(recordLocator string, lastName string) (*models.PNR, error)
+	IssueTickets(bookingID string, request models.TicketingRequest) ([]models.Ticket, error)
+	GetBookingTickets(bookingID string) ([]models.Ticket, error)
+	GetTicket(number string) (*models.Ticket, error)
+	HandleFlightStatus(event models.FlightStatusEvent) ([]models.ScheduleChange, error)
+	GetScheduleChanges(bookingID string) ([]models.ScheduleChange, error)
+	AcceptScheduleChange(bookingID string, changeID string) (*models.ScheduleChange, error)
+	UpdateCouponStatus(ticketNumber string, couponNumber int, update models.CouponStatusUpdate) (*models.Ticket, error)
 }
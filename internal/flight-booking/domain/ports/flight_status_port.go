@@ -0,0 +1,19 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+// FlightStatusHandler is the inbound port flight status feeds deliver airline updates to.
+type FlightStatusHandler interface {
+	HandleFlightStatus(event models.FlightStatusEvent) ([]models.ScheduleChange, error)
+}
+
+type ScheduleChangeDB interface {
+	CreateScheduleChange(change *models.ScheduleChange) error
+	GetScheduleChangesByBookingID(bookingID string) ([]models.ScheduleChange, error)
+	UpdateScheduleChangeStatus(id string, from models.ScheduleChangeStatus, to models.ScheduleChangeStatus) error
+}
+
+// TravelerNotifier tells travellers about changes to their bookings.
+type TravelerNotifier interface {
+	NotifyScheduleChange(booking *models.FlightBooking, pnr *models.PNR, change models.ScheduleChange) error
+}
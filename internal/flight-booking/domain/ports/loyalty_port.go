@@ -0,0 +1,8 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+// LoyaltyProgram earns users points for the segments they flew.
+type LoyaltyProgram interface {
+	RecordFlownSegment(segment models.FlownSegment) error
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+// Payments looks up the payments of bookings in the payment service.
+type Payments interface {
+	// GetPayment returns a payment, or models.ErrPaymentNotConfirmed when it does not exist.
+	GetPayment(id string) (*models.Payment, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+type PNRDB interface {
+	CreatePNR(pnr *models.PNR) error
+	GetPNRByLocator(recordLocator string) (*models.PNR, error)
+	GetPNRByBookingID(bookingID string) (*models.PNR, error)
+}
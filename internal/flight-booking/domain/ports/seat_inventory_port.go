@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// SeatInventory keeps the seat maps of the flights we sell. Holding seats replaces the
+// seats a reference already has on the flight and is all-or-nothing.
+type SeatInventory interface {
+	GetSeatMap(flightID string, aircraft string, cabin models.CabinClass) (*models.SeatMap, error)
+	HoldSeats(flightID string, seatNumbers []string, holdRef string, until time.Time) error
+	ConfirmSeats(flightID string, holdRef string) error
+	ReleaseSeats(flightID string, holdRef string) error
+}
@@ -0,0 +1,14 @@
+package ports
+
+import "microservices-travel-backend/internal/flight-booking/domain/models"
+
+type TicketDB interface {
+	NextTicketSerial() (int64, error)
+	// CreateTickets marks a booking that is in status from as ticketed and stores its tickets, all
+	// at once; models.ErrAlreadyTicketed when the booking is no longer in that status.
+	CreateTickets(bookingID string, from models.FlightBookingStatus, tickets []models.Ticket) error
+	GetTicket(number string) (*models.Ticket, error)
+	GetTicketsByBookingID(bookingID string) ([]models.Ticket, error)
+	UpdateCouponStatus(ticketNumber string, couponNumber int, from models.CouponStatus, to models.CouponStatus) error
+	UpdateBookingCoupons(bookingID string, from models.CouponStatus, to models.CouponStatus) error
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"sync"
+	"time"
+)
+
+const (
+	// calendarWorkers bounds how many provider searches a fare calendar runs at once.
+	calendarWorkers = 4
+	// fareCacheTTL is how long the lowest fare of a day is reused before providers are asked again.
+	fareCacheTTL = 15 * time.Minute
+
+	maxCalendarWindowDays = 15
+	maxCalendarStayDays   = 30
+)
+
+type cachedFare struct {
+	day       models.FareCalendarDay
+	expiresAt time.Time
+}
+
+// calendarJob is one provider search for one day of the calendar.
+type calendarJob struct {
+	day      int
+	provider int
+	params   models.FlightSearchParams
+}
+
+type calendarResult struct {
+	day      int
+	cheapest *models.Itinerary
+}
+
+// GetFareCalendar returns the lowest fare for every day of a window or month on a route. Days not
+// in the cache are searched on every provider by a bounded pool of workers.
+func (h *FlightService) GetFareCalendar(params models.FareCalendarParams) ([]models.FareCalendarDay, error) {
+	dates, err := calendarDates(params)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]models.FareCalendarDay, len(dates))
+	searches := make(map[int]models.FlightSearchParams)
+	for i, date := range dates {
+		search, err := normalizeSearchParams(calendarSearchParams(params, date))
+		if err != nil {
+			return nil, err
+		}
+
+		if cached, ok := h.lookupFare(fareCacheKey(search)); ok {
+			days[i] = cached
+			continue
+		}
+		days[i] = models.FareCalendarDay{Date: date}
+		if params.StayDays != nil {
+			returnDate := search.Legs[1].DepartureDate
+			days[i].ReturnDate = &returnDate
+		}
+		searches[i] = search
+	}
+
+	if len(searches) > 0 {
+		h.searchCalendarDays(days, searches)
+	}
+	return days, nil
+}
+
+// searchCalendarDays fans the uncached days out to every provider and keeps the cheapest itinerary of each day.
+func (h *FlightService) searchCalendarDays(days []models.FareCalendarDay, searches map[int]models.FlightSearchParams) {
+	jobs := make(chan calendarJob)
+	results := make(chan calendarResult)
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < calendarWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result := calendarResult{day: job.day}
+				for _, itinerary := range h.searchProvider(h.providers[job.provider], job.params, now) {
+					if result.cheapest == nil || itinerary.Price.Total < result.cheapest.Price.Total {
+						cheapest := itinerary
+						result.cheapest = &cheapest
+					}
+				}
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+		for day, search := range searches {
+			for provider := range h.providers {
+				jobs <- calendarJob{day: day, provider: provider, params: search}
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if result.cheapest == nil {
+			continue
+		}
+		day := &days[result.day]
+		if day.Available && day.LowestFare <= result.cheapest.Price.Total {
+			continue
+		}
+		day.Available = true
+		day.LowestFare = result.cheapest.Price.Total
+		day.Currency = result.cheapest.Price.Currency
+		day.FareFamily = result.cheapest.Fare.Family
+		day.Carrier = result.cheapest.ValidatingAirline
+	}
+
+	fares := make(map[string]models.FareCalendarDay, len(searches))
+	for i, search := range searches {
+		days[i].RetrievedAt = now
+		fares[fareCacheKey(search)] = days[i]
+	}
+	h.rememberFares(fares)
+	log.Printf("Fare calendar searched %d days on %d providers\n", len(searches), len(h.providers))
+}
+
+// calendarDates lists the departure dates a calendar covers, leaving out days already in the past.
+func calendarDates(params models.FareCalendarParams) ([]time.Time, error) {
+	var first, last time.Time
+	switch {
+	case !params.Month.IsZero():
+		first = time.Date(params.Month.Year(), params.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+		last = first.AddDate(0, 1, -1)
+	case !params.Date.IsZero():
+		if params.WindowDays < 0 || params.WindowDays > maxCalendarWindowDays {
+			return nil, fmt.Errorf("window must be between 0 and %d days", maxCalendarWindowDays)
+		}
+		date := time.Date(params.Date.Year(), params.Date.Month(), params.Date.Day(), 0, 0, 0, 0, time.UTC)
+		first = date.AddDate(0, 0, -params.WindowDays)
+		last = date.AddDate(0, 0, params.WindowDays)
+	default:
+		return nil, errors.New("a date or a month is required")
+	}
+	if params.StayDays != nil && (*params.StayDays < 0 || *params.StayDays > maxCalendarStayDays) {
+		return nil, fmt.Errorf("stay must be between 0 and %d days", maxCalendarStayDays)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if first.Before(today) {
+		first = today
+	}
+
+	var dates []time.Time
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	if len(dates) == 0 {
+		return nil, errors.New("the requested dates are in the past")
+	}
+	return dates, nil
+}
+
+func calendarSearchParams(params models.FareCalendarParams, date time.Time) models.FlightSearchParams {
+	search := models.FlightSearchParams{
+		Origin:        params.Origin,
+		Destination:   params.Destination,
+		DepartureDate: date,
+		Passengers:    params.Passengers,
+		Cabin:         params.Cabin,
+		Currency:      params.Currency,
+	}
+	if params.StayDays != nil {
+		returnDate := date.AddDate(0, 0, *params.StayDays)
+		search.ReturnDate = &returnDate
+	}
+	return search
+}
+
+// fareCacheKey identifies a day's fare by everything that changes its price.
+func fareCacheKey(search models.FlightSearchParams) string {
+	key := fmt.Sprintf("%s-%s|%s|%s|%d/%d/%d|%s", search.Legs[0].Origin, search.Legs[0].Destination,
+		search.Legs[0].DepartureDate.Format("2006-01-02"), search.Cabin,
+		search.Passengers.Adults, search.Passengers.Children, search.Passengers.Infants, search.Currency)
+	if len(search.Legs) > 1 {
+		key += "|" + search.Legs[1].DepartureDate.Format("2006-01-02")
+	}
+	return key
+}
+
+func (h *FlightService) lookupFare(key string) (models.FareCalendarDay, bool) {
+	h.faresMu.RLock()
+	defer h.faresMu.RUnlock()
+
+	fare, ok := h.fares[key]
+	if !ok || time.Now().After(fare.expiresAt) {
+		return models.FareCalendarDay{}, false
+	}
+	return fare.day, true
+}
+
+func (h *FlightService) rememberFares(fares map[string]models.FareCalendarDay) {
+	h.faresMu.Lock()
+	defer h.faresMu.Unlock()
+
+	now := time.Now()
+	for k, fare := range h.fares {
+		if now.After(fare.expiresAt) {
+			delete(h.fares, k)
+		}
+	}
+	for key, day := range fares {
+		h.fares[key] = cachedFare{day: day, expiresAt: now.Add(fareCacheTTL)}
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/mapper"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"sync"
+	"testing"
+	"time"
+)
+
+// searchLoad counts the searches running on all providers and the most that ran at once.
+type searchLoad struct {
+	mu      sync.Mutex
+	running int
+	busiest int
+}
+
+func (l *searchLoad) start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running++
+	if l.running > l.busiest {
+		l.busiest = l.running
+	}
+}
+
+func (l *searchLoad) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+}
+
+// pricedProvider offers one flight on each day it has a price for and counts its searches.
+type pricedProvider struct {
+	carrier string
+	prices  map[string]float64 // Price by departure date (YYYY-MM-DD).
+	load    *searchLoad
+
+	mu       sync.Mutex
+	searches int
+}
+
+func (p *pricedProvider) SearchFlights(params models.FlightSearchParams) ([]map[string]interface{}, error) {
+	p.mu.Lock()
+	p.searches++
+	p.mu.Unlock()
+	p.load.start()
+	defer p.load.finish()
+	time.Sleep(5 * time.Millisecond)
+
+	leg := params.Legs[0]
+	price, ok := p.prices[leg.DepartureDate.Format("2006-01-02")]
+	if !ok {
+		return nil, nil
+	}
+	departure := leg.DepartureDate.Add(9 * time.Hour)
+	return []map[string]interface{}{{
+		"offer_id":           p.carrier + "-" + leg.DepartureDate.Format("20060102"),
+		"cabin":              string(models.CabinEconomy),
+		"validating_airline": p.carrier,
+		"legs": []map[string]interface{}{{"segments": []map[string]interface{}{{
+			"marketing_carrier": p.carrier, "origin": leg.Origin, "destination": leg.Destination,
+			"departure_time": departure.Format(time.RFC3339), "arrival_time": departure.Add(2 * time.Hour).Format(time.RFC3339),
+		}}}},
+		"price": map[string]interface{}{"total": price, "currency": "EUR"},
+		"fare":  map[string]interface{}{"family": string(models.FareStandard)},
+	}}, nil
+}
+
+func TestGetFareCalendar(t *testing.T) {
+	date := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 1, 0)
+	day := func(offset int) string { return date.AddDate(0, 0, offset).Format("2006-01-02") }
+	params := models.FareCalendarParams{Origin: "MAD", Destination: "CDG", Date: date, WindowDays: 3,
+		Passengers: models.PassengerMix{Adults: 1}}
+
+	load := &searchLoad{}
+	lufthansa := &pricedProvider{carrier: "LH", prices: map[string]float64{day(-3): 120, day(-1): 90, day(0): 150}, load: load}
+	iberia := &pricedProvider{carrier: "IB", prices: map[string]float64{day(-1): 110, day(0): 130, day(2): 80}, load: load}
+	service := &FlightService{providers: []ports.FlightProvider{lufthansa, iberia}, flightMapper: mapper.NewFlightMapper(),
+		fares: map[string]cachedFare{}}
+
+	days, err := service.GetFareCalendar(params)
+	if err != nil {
+		t.Fatalf("GetFareCalendar: %v", err)
+	}
+
+	// The cheapest offer of each day, from whichever provider had it.
+	want := map[string]string{day(-3): "LH", day(-1): "LH", day(0): "IB", day(2): "IB"}
+	fares := map[string]float64{day(-3): 120, day(-1): 90, day(0): 130, day(2): 80}
+	if len(days) != 7 {
+		t.Fatalf("calendar has %d days, want 7", len(days))
+	}
+	for _, got := range days {
+		date := got.Date.Format("2006-01-02")
+		carrier, available := want[date]
+		if got.Available != available || got.Carrier != carrier || got.LowestFare != fares[date] {
+			t.Errorf("%s: available %v at %.2f from %q, want %v at %.2f from %q", date, got.Available, got.LowestFare,
+				got.Carrier, available, fares[date], carrier)
+		}
+	}
+
+	if load.busiest > calendarWorkers {
+		t.Errorf("ran %d searches at once, want at most %d", load.busiest, calendarWorkers)
+	}
+	if lufthansa.searches != 7 || iberia.searches != 7 {
+		t.Errorf("searched %d and %d days, want every day on each provider", lufthansa.searches, iberia.searches)
+	}
+
+	t.Run("cached days are not searched again", func(t *testing.T) {
+		again, err := service.GetFareCalendar(params)
+		if err != nil {
+			t.Fatalf("GetFareCalendar: %v", err)
+		}
+		if lufthansa.searches != 7 || iberia.searches != 7 || again[1] != days[1] {
+			t.Errorf("searched %d and %d days, want the calendar from the cache", lufthansa.searches, iberia.searches)
+		}
+	})
+
+	t.Run("expired days are searched again", func(t *testing.T) {
+		for key, fare := range service.fares {
+			fare.expiresAt = time.Now().Add(-time.Second)
+			service.fares[key] = fare
+		}
+		if _, err := service.GetFareCalendar(params); err != nil {
+			t.Fatalf("GetFareCalendar: %v", err)
+		}
+		if lufthansa.searches != 14 || iberia.searches != 14 {
+			t.Errorf("searched %d and %d days, want every day again", lufthansa.searches, iberia.searches)
+		}
+	})
+
+	t.Run("invalid windows are rejected", func(t *testing.T) {
+		for _, invalid := range []models.FareCalendarParams{
+			{Origin: "MAD", Destination: "CDG", Passengers: models.PassengerMix{Adults: 1}},
+			{Origin: "MAD", Destination: "CDG", Date: date, WindowDays: maxCalendarWindowDays + 1, Passengers: models.PassengerMix{Adults: 1}},
+			{Origin: "MAD", Destination: "CDG", Date: date.AddDate(-1, 0, 0), Passengers: models.PassengerMix{Adults: 1}},
+		} {
+			if _, err := service.GetFareCalendar(invalid); err == nil {
+				t.Errorf("GetFareCalendar(%+v) succeeded", invalid)
+			}
+		}
+	})
+}
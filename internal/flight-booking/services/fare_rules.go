@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// checkFareRules verifies that an itinerary booked at the given time meets the advance purchase
+// and minimum stay conditions of its fare.
+func checkFareRules(itinerary models.Itinerary, bookedAt time.Time) error {
+	if len(itinerary.Legs) == 0 {
+		return fmt.Errorf("%w: itinerary has no flights", models.ErrFareRuleViolation)
+	}
+	rules := itinerary.Fare.Rules
+	first := itinerary.Legs[0]
+
+	if rules.AdvancePurchaseDays > 0 {
+		deadline := first.DepartureTime.AddDate(0, 0, -rules.AdvancePurchaseDays)
+		if bookedAt.After(deadline) {
+			return fmt.Errorf("%w: %s fares must be booked %d days before departure",
+				models.ErrFareRuleViolation, itinerary.Fare.Family, rules.AdvancePurchaseDays)
+		}
+	}
+
+	// The stay is measured from the outbound arrival to the last departure, so it only applies to journeys with a return.
+	if rules.MinimumStayDays > 0 && len(itinerary.Legs) > 1 {
+		last := itinerary.Legs[len(itinerary.Legs)-1]
+		if last.DepartureTime.Before(first.ArrivalTime.AddDate(0, 0, rules.MinimumStayDays)) {
+			return fmt.Errorf("%w: %s fares require a stay of at least %d days",
+				models.ErrFareRuleViolation, itinerary.Fare.Family, rules.MinimumStayDays)
+		}
+	}
+	return nil
+}
+
+// cancellationQuote works out the refund due when a booking is cancelled. Refundable fares return
+// everything but the cancellation fee; other fares only return the taxes, which are never earned
+// by the airline for flights not flown. Seat fees are not refunded.
+func cancellationQuote(booking *models.FlightBooking) models.FeeQuote {
+	price := booking.Itinerary.Price
+	rules := booking.Itinerary.Fare.Rules
+	quote := models.FeeQuote{Action: models.FeeActionCancel, Currency: booking.Currency}
+
+	if rules.Refundable {
+		quote.Fee = math.Min(rules.CancellationFee*float64(ticketedPassengers(booking)), price.Base)
+	} else {
+		quote.Fee = price.Base
+	}
+	quote.Refund = roundAmount(price.Total - quote.Fee)
+	quote.Fee = roundAmount(quote.Fee)
+	return quote
+}
+
+// changeQuote works out what moving a booking onto a new itinerary costs: the change fee plus any
+// fare difference. When the new itinerary is cheaper the difference is refunded on refundable
+// fares and forfeited otherwise.
+func changeQuote(booking *models.FlightBooking, itinerary models.Itinerary, now time.Time) (models.FeeQuote, error) {
+	rules := booking.Itinerary.Fare.Rules
+	if !rules.Changeable {
+		return models.FeeQuote{}, fmt.Errorf("%w: %s fares cannot be changed", models.ErrChangeNotPermitted, booking.Itinerary.Fare.Family)
+	}
+	segments := booking.Itinerary.Segments()
+	if len(segments) > 0 && !now.Before(segments[0].DepartureTime) {
+		return models.FeeQuote{}, fmt.Errorf("%w: the journey has already started", models.ErrChangeNotPermitted)
+	}
+	if itinerary.Price.Currency != booking.Currency {
+		return models.FeeQuote{}, fmt.Errorf("new itinerary is priced in %s but the booking was paid in %s",
+			itinerary.Price.Currency, booking.Currency)
+	}
+
+	quote := models.FeeQuote{
+		Action:   models.FeeActionChange,
+		Fee:      rules.ChangeFee * float64(ticketedPassengers(booking)),
+		Currency: booking.Currency,
+	}
+	difference := itinerary.Price.Total - booking.Itinerary.Price.Total
+	switch {
+	case difference > 0:
+		quote.FareDifference = difference
+	case rules.Refundable:
+		quote.Refund = -difference
+	default:
+		quote.Fee -= difference
+	}
+	quote.AmountDue = roundAmount(rules.ChangeFee*float64(ticketedPassengers(booking)) + quote.FareDifference)
+	quote.Fee = roundAmount(quote.Fee)
+	quote.FareDifference = roundAmount(quote.FareDifference)
+	quote.Refund = roundAmount(quote.Refund)
+	return quote, nil
+}
+
+// ticketedPassengers returns how many passengers fare rule fees are charged for; infants travel free of them.
+func ticketedPassengers(booking *models.FlightBooking) int {
+	return booking.Adults + booking.Children
+}
+
+func roundAmount(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"testing"
+	"time"
+)
+
+// journey is an itinerary with a flight departing at each of the given times, two hours long.
+func journey(rules models.FareRules, total float64, departures ...time.Time) models.Itinerary {
+	itinerary := models.Itinerary{
+		Price: models.OfferPrice{Total: total, Base: total - 100, Currency: "EUR"},
+		Fare:  models.Fare{Family: models.FareStandard, Rules: rules},
+	}
+	for _, departure := range departures {
+		itinerary.Legs = append(itinerary.Legs, models.FlightLeg{DepartureTime: departure, ArrivalTime: departure.Add(2 * time.Hour),
+			Segments: []models.FlightSegment{{DepartureTime: departure, ArrivalTime: departure.Add(2 * time.Hour)}}})
+	}
+	return itinerary
+}
+
+func TestCheckFareRules(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	advance := models.FareRules{AdvancePurchaseDays: 14}
+	stay := models.FareRules{MinimumStayDays: 3}
+
+	tests := []struct {
+		name      string
+		itinerary models.Itinerary
+		wantErr   error
+	}{
+		{name: "booked early enough", itinerary: journey(advance, 500, now.AddDate(0, 0, 20))},
+		{name: "booked too late", itinerary: journey(advance, 500, now.AddDate(0, 0, 10)), wantErr: models.ErrFareRuleViolation},
+		{name: "long enough stay", itinerary: journey(stay, 500, now.AddDate(0, 0, 20), now.AddDate(0, 0, 25))},
+		{name: "too short a stay", itinerary: journey(stay, 500, now.AddDate(0, 0, 20), now.AddDate(0, 0, 22)), wantErr: models.ErrFareRuleViolation},
+		{name: "one-way journeys have no stay", itinerary: journey(stay, 500, now.AddDate(0, 0, 20))},
+		{name: "no flights", itinerary: journey(advance, 500), wantErr: models.ErrFareRuleViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkFareRules(tt.itinerary, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkFareRules: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCancellationQuote(t *testing.T) {
+	departure := time.Now().AddDate(0, 1, 0)
+	tests := []struct {
+		name       string
+		rules      models.FareRules
+		wantFee    float64
+		wantRefund float64
+	}{
+		{name: "refundable fares withhold the fee of each ticketed passenger", rules: models.FareRules{Refundable: true, CancellationFee: 30},
+			wantFee: 60, wantRefund: 440},
+		{name: "the fee never exceeds the base fare", rules: models.FareRules{Refundable: true, CancellationFee: 300},
+			wantFee: 400, wantRefund: 100},
+		{name: "other fares return the taxes", rules: models.FareRules{}, wantFee: 400, wantRefund: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := &models.FlightBooking{Adults: 1, Children: 1, Infants: 1, Currency: "EUR",
+				Itinerary: journey(tt.rules, 500, departure)}
+			quote := cancellationQuote(booking)
+			if quote.Fee != tt.wantFee || quote.Refund != tt.wantRefund {
+				t.Errorf("fee %.2f, refund %.2f, want %.2f and %.2f", quote.Fee, quote.Refund, tt.wantFee, tt.wantRefund)
+			}
+		})
+	}
+}
+
+func TestChangeQuote(t *testing.T) {
+	now := time.Now()
+	departure := now.AddDate(0, 1, 0)
+	changeable := models.FareRules{Changeable: true, ChangeFee: 50}
+	refundable := models.FareRules{Changeable: true, ChangeFee: 50, Refundable: true}
+
+	tests := []struct {
+		name      string
+		rules     models.FareRules
+		departure time.Time
+		newTotal  float64
+		currency  string
+		want      models.FeeQuote
+		wantErr   error
+	}{
+		{name: "a dearer itinerary costs the fee and the difference", rules: changeable, newTotal: 560,
+			want: models.FeeQuote{Fee: 100, FareDifference: 60, AmountDue: 160}},
+		{name: "refundable fares return a cheaper difference", rules: refundable, newTotal: 450,
+			want: models.FeeQuote{Fee: 100, AmountDue: 100, Refund: 50}},
+		{name: "other fares forfeit a cheaper difference", rules: changeable, newTotal: 450,
+			want: models.FeeQuote{Fee: 150, AmountDue: 100}},
+		{name: "fares that cannot be changed", rules: models.FareRules{}, newTotal: 500, wantErr: models.ErrChangeNotPermitted},
+		{name: "journeys already started", rules: changeable, departure: now.Add(-time.Hour), newTotal: 500,
+			wantErr: models.ErrChangeNotPermitted},
+		{name: "itineraries in another currency", rules: changeable, newTotal: 500, currency: "USD",
+			wantErr: errors.New("new itinerary is priced in USD but the booking was paid in EUR")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.departure.IsZero() {
+				tt.departure = departure
+			}
+			booking := &models.FlightBooking{Adults: 1, Children: 1, Infants: 1, Currency: "EUR",
+				Itinerary: journey(tt.rules, 500, tt.departure)}
+			itinerary := journey(tt.rules, tt.newTotal, departure.AddDate(0, 0, 1))
+			if tt.currency != "" {
+				itinerary.Price.Currency = tt.currency
+			}
+
+			quote, err := changeQuote(booking, itinerary, now)
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Fatalf("changeQuote: got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("changeQuote: %v", err)
+			}
+			tt.want.Action, tt.want.Currency = models.FeeActionChange, "EUR"
+			if quote != tt.want {
+				t.Errorf("quote = %+v, want %+v", quote, tt.want)
+			}
+		})
+	}
+}
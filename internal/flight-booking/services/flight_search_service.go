@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxPassengersPerSearch = 9
+	maxLegsPerSearch       = 6
+)
+
+// SearchFlights queries every provider for the requested legs, normalizes their itineraries and merges them into a single list sorted by price.
+func (h *FlightService) SearchFlights(params models.FlightSearchParams) ([]models.Itinerary, error) {
+	params, err := normalizeSearchParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var allItineraries []models.Itinerary
+	itineraryIndex := make(map[string]int) // Same flights sold by several providers, keyed by flights flown
+
+	// Iterate through the list of external providers.
+	for _, provider := range h.providers {
+		log.Printf("Searching flights from provider: %T\n", provider)
+
+		for _, itinerary := range h.searchProvider(provider, params, now) {
+			// Keep only the cheapest itinerary for identical flights and fare family.
+			key := itineraryKey(itinerary)
+			if i, exists := itineraryIndex[key]; exists {
+				if itinerary.Price.Total < allItineraries[i].Price.Total {
+					allItineraries[i] = itinerary
+				}
+				continue
+			}
+			itineraryIndex[key] = len(allItineraries)
+			allItineraries = append(allItineraries, itinerary)
+		}
+	}
+
+	if len(allItineraries) == 0 {
+		return nil, models.ErrNoFlightOffers
+	}
+
+	sort.SliceStable(allItineraries, func(i, j int) bool {
+		return allItineraries[i].Price.Total < allItineraries[j].Price.Total
+	})
+
+	if err := h.rememberOffers(allItineraries, params.Passengers); err != nil {
+		return nil, err
+	}
+	return allItineraries, nil
+}
+
+// searchProvider returns the itineraries of one provider that can be booked at the given time,
+// mapped to the local format. Provider failures are logged and yield no itineraries.
+func (h *FlightService) searchProvider(provider ports.FlightProvider, params models.FlightSearchParams, now time.Time) []models.Itinerary {
+	providerOffers, err := provider.SearchFlights(params)
+	if err != nil {
+		log.Printf("Provider %T failed to search flights: %v\n", provider, err)
+		return nil
+	}
+
+	// Map the provider-specific offers to the local itinerary format.
+	var itineraries []models.Itinerary
+	for _, externalOffer := range providerOffers {
+		itinerary := h.flightMapper.MapToItinerary(externalOffer)
+		if len(itinerary.Legs) != len(params.Legs) || itinerary.Price.Total <= 0 {
+			log.Printf("Skipping incomplete itinerary %q from provider %T\n", itinerary.ID, provider)
+			continue
+		}
+		itinerary.TripType = params.TripType()
+		buildConnections(&itinerary)
+
+		if err := validateItinerary(itinerary); err != nil {
+			log.Printf("Skipping itinerary %q from provider %T: %v\n", itinerary.ID, provider, err)
+			continue
+		}
+		if err := checkFareRules(itinerary, now); err != nil {
+			log.Printf("Skipping itinerary %q from provider %T: %v\n", itinerary.ID, provider, err)
+			continue
+		}
+		itineraries = append(itineraries, itinerary)
+	}
+	return itineraries
+}
+
+// normalizeSearchParams validates the search criteria, expands one-way and round-trip searches into legs and fills in defaults.
+func normalizeSearchParams(params models.FlightSearchParams) (models.FlightSearchParams, error) {
+	params.Currency = strings.ToUpper(strings.TrimSpace(params.Currency))
+
+	if len(params.Legs) == 0 {
+		params.Legs = []models.SearchLeg{{
+			Origin:        params.Origin,
+			Destination:   params.Destination,
+			DepartureDate: params.DepartureDate,
+		}}
+		if params.ReturnDate != nil {
+			params.Legs = append(params.Legs, models.SearchLeg{
+				Origin:        params.Destination,
+				Destination:   params.Origin,
+				DepartureDate: *params.ReturnDate,
+			})
+		}
+	}
+	if len(params.Legs) > maxLegsPerSearch {
+		return params, fmt.Errorf("a search cannot exceed %d legs", maxLegsPerSearch)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := range params.Legs {
+		leg := &params.Legs[i]
+		leg.Origin = strings.ToUpper(strings.TrimSpace(leg.Origin))
+		leg.Destination = strings.ToUpper(strings.TrimSpace(leg.Destination))
+
+		if len(leg.Origin) != 3 || len(leg.Destination) != 3 {
+			return params, fmt.Errorf("leg %d: origin and destination must be 3-letter IATA codes", i+1)
+		}
+		if leg.Origin == leg.Destination {
+			return params, fmt.Errorf("leg %d: origin and destination must differ", i+1)
+		}
+		if leg.DepartureDate.IsZero() {
+			return params, fmt.Errorf("leg %d: departure date is required", i+1)
+		}
+		if leg.DepartureDate.Before(today) {
+			return params, fmt.Errorf("leg %d: departure date cannot be in the past", i+1)
+		}
+		if i > 0 && leg.DepartureDate.Before(params.Legs[i-1].DepartureDate) {
+			return params, fmt.Errorf("leg %d: departure date cannot be before the previous leg", i+1)
+		}
+	}
+	params.Origin = params.Legs[0].Origin
+	params.Destination = params.Legs[0].Destination
+	params.DepartureDate = params.Legs[0].DepartureDate
+
+	if params.Passengers.Adults < 1 {
+		return params, errors.New("at least one adult passenger is required")
+	}
+	if params.Passengers.Children < 0 || params.Passengers.Infants < 0 {
+		return params, errors.New("passenger counts cannot be negative")
+	}
+	if params.Passengers.Infants > params.Passengers.Adults {
+		return params, errors.New("each infant must travel with an adult")
+	}
+	if params.Passengers.Total() > maxPassengersPerSearch {
+		return params, fmt.Errorf("a search cannot exceed %d passengers", maxPassengersPerSearch)
+	}
+
+	switch params.Cabin {
+	case "":
+		params.Cabin = models.CabinEconomy
+	case models.CabinEconomy, models.CabinPremiumEconomy, models.CabinBusiness, models.CabinFirst:
+	default:
+		return params, fmt.Errorf("unsupported cabin class: %s", params.Cabin)
+	}
+
+	return params, nil
+}
+
+// itineraryKey identifies an itinerary by the flights and fare family it contains, regardless of provider.
+func itineraryKey(itinerary models.Itinerary) string {
+	var parts []string
+	for _, segment := range itinerary.Segments() {
+		parts = append(parts, fmt.Sprintf("%s%s@%s", segment.MarketingCarrier, segment.FlightNumber,
+			segment.DepartureTime.UTC().Format(time.RFC3339)))
+	}
+	parts = append(parts, string(itinerary.Cabin), string(itinerary.Fare.Family))
+	return strings.Join(parts, "|")
+}
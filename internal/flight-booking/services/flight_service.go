@@ -1,46 +1,305 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/mapper"
 	"microservices-travel-backend/internal/flight-booking/domain/models"
 	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"sync"
+	"time"
 )
 
+// offerTTL is how long an itinerary returned by a search can still be booked at its quoted price.
+const offerTTL = 30 * time.Minute
+
 type FlightService struct {
-	db ports.FlightDB
+	db              ports.FlightDB         // Local database interface
+	offers          ports.FlightOfferDB    // Itineraries quoted by recent searches
+	providers       []ports.FlightProvider // External providers interface
+	flightMapper    *mapper.FlightMapper   // Dependency injected mapper
+	seats           ports.SeatInventory    // Seat maps and seat holds
+	pnrs            ports.PNRDB            // Passenger name records
+	tickets         ports.TicketDB         // Issued e-tickets and their coupons
+	documents       ports.DocumentStorage  // Storage for e-ticket receipts
+	scheduleChanges ports.ScheduleChangeDB // Airline schedule changes recorded on bookings
+	notifier        ports.TravelerNotifier // Tells travellers about changes to their bookings
+	loyalty         ports.LoyaltyProgram   // Earns users points for flown segments; nil when not configured
+	rates           ports.ExchangeRates    // Prices seat fees in the currency of the booking
+	payments        ports.Payments         // Confirms bookings were paid before they are ticketed
+
+	faresMu sync.RWMutex
+	fares   map[string]cachedFare // Lowest fare per route and date, for the fare calendar
+}
+
+// NewFlightService initializes and returns a new FlightService instance.
+func NewFlightService(db ports.FlightDB, offers ports.FlightOfferDB, providers []ports.FlightProvider, flightMapper *mapper.FlightMapper, seats ports.SeatInventory,
+	pnrs ports.PNRDB, tickets ports.TicketDB, documents ports.DocumentStorage,
+	scheduleChanges ports.ScheduleChangeDB, notifier ports.TravelerNotifier, loyalty ports.LoyaltyProgram,
+	rates ports.ExchangeRates, payments ports.Payments) *FlightService {
+	return &FlightService{
+		db:              db,
+		offers:          offers,
+		providers:       providers,
+		flightMapper:    flightMapper,
+		seats:           seats,
+		pnrs:            pnrs,
+		tickets:         tickets,
+		documents:       documents,
+		scheduleChanges: scheduleChanges,
+		notifier:        notifier,
+		loyalty:         loyalty,
+		rates:           rates,
+		payments:        payments,
+		fares:           make(map[string]cachedFare),
+	}
 }
 
-func NewFlightService(db ports.FlightDB) *FlightService {
-	return &FlightService{db: db}
+// BookItinerary books every leg of a previously quoted itinerary as a single booking.
+func (h *FlightService) BookItinerary(request models.ItineraryBookingRequest) (*models.FlightBooking, error) {
+	if request.UserID == "" {
+		return nil, errors.New("user ID is required")
+	}
+
+	offer, err := h.offers.GetOffer(request.ItineraryID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if request.Passengers != offer.Passengers {
+		return nil, errors.New("passengers do not match the ones the itinerary was priced for")
+	}
+	if offer.Itinerary.SeatsAvailable < request.Passengers.Adults+request.Passengers.Children {
+		return nil, errors.New("not enough seats left on this itinerary")
+	}
+
+	// Revalidate in case the schedule was quoted close to departure.
+	if err := validateItinerary(offer.Itinerary); err != nil {
+		return nil, fmt.Errorf("itinerary can no longer be booked: %v", err)
+	}
+	if err := checkFareRules(offer.Itinerary, time.Now()); err != nil {
+		return nil, err
+	}
+
+	booking := &models.FlightBooking{
+		UserID:     request.UserID,
+		Status:     models.FlightBookingPending,
+		TripType:   offer.Itinerary.TripType,
+		Itinerary:  offer.Itinerary,
+		Adults:     request.Passengers.Adults,
+		Children:   request.Passengers.Children,
+		Infants:    request.Passengers.Infants,
+		TotalPrice: offer.Itinerary.Price.Total,
+		Currency:   offer.Itinerary.Price.Currency,
+	}
+
+	createdBooking, err := h.db.CreateBooking(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	h.forgetOffer(request.ItineraryID)
+
+	// Passengers who did not pick seats still get one matching their preference.
+	if request.SeatPreference != "" {
+		preferences := make(map[int]models.SeatPreference)
+		for i := 0; i < createdBooking.SeatedPassengers(); i++ {
+			preferences[i] = request.SeatPreference
+		}
+		seatedBooking, err := h.AssignSeats(createdBooking.ID, models.SeatSelectionRequest{Preferences: preferences})
+		if err != nil {
+			log.Printf("Failed to auto-assign seats for booking %s: %v\n", createdBooking.ID, err)
+			return createdBooking, nil
+		}
+		return seatedBooking, nil
+	}
+
+	return createdBooking, nil
+}
+
+func (h *FlightService) GetBookingByID(id string) (*models.FlightBooking, error) {
+	booking, err := h.db.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func (h *FlightService) GetBookingsByUserID(userID string) ([]models.FlightBooking, error) {
+	bookings, err := h.db.GetBookingsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// CancelBooking cancels every leg of a booking and records the refund due under its fare rules.
+func (h *FlightService) CancelBooking(id string) (*models.FeeQuote, error) {
+	booking, err := h.db.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status == models.FlightBookingCancelled {
+		return nil, errors.New("booking is already cancelled")
+	}
+
+	quote := h.cancellationQuote(booking)
+	if err := h.db.UpdateCancellation(id, quote); err != nil {
+		return nil, err
+	}
+
+	h.releaseBookingSeats(booking)
+	h.settleCoupons(booking)
+	return &quote, nil
 }
 
-func (h *FlightService) CreateFlight(flight *models.Flight) (*models.Flight, error) {
-	createdFlight, err := h.db.CreateFlight(flight)
+// QuoteCancellation returns the fee and refund that cancelling a booking now would result in.
+func (h *FlightService) QuoteCancellation(id string) (*models.FeeQuote, error) {
+	booking, err := h.db.GetBookingByID(id)
 	if err != nil {
 		return nil, err
 	}
-	return createdFlight, nil
+	if booking.Status == models.FlightBookingCancelled {
+		return nil, errors.New("booking is already cancelled")
+	}
+
+	quote := h.cancellationQuote(booking)
+	return &quote, nil
 }
 
-func (h *FlightService) GetFlightByID(id string) (*models.Flight, error) {
-	flight, err := h.db.GetFlightByID(id)
+// cancellationQuote applies the fare rules, unless the airline significantly changed or cancelled a
+// flight the traveller has not accepted, in which case everything paid for the flights is refunded.
+func (h *FlightService) cancellationQuote(booking *models.FlightBooking) models.FeeQuote {
+	if len(h.pendingScheduleChanges(booking.ID)) > 0 {
+		return models.FeeQuote{
+			Action:   models.FeeActionCancel,
+			Refund:   booking.Itinerary.Price.Total,
+			Currency: booking.Currency,
+		}
+	}
+	return cancellationQuote(booking)
+}
+
+// QuoteItineraryChange returns the fees and fare difference that moving a booking onto an itinerary
+// from a recent search would result in, without changing the booking.
+func (h *FlightService) QuoteItineraryChange(id string, request models.ItineraryChangeRequest) (*models.FeeQuote, error) {
+	booking, err := h.db.GetBookingByID(id)
 	if err != nil {
 		return nil, err
 	}
-	return flight, nil
+	_, quote, _, err := h.prepareChange(booking, request)
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
 }
 
-func (h *FlightService) UpdateFlight(id string, flight *models.Flight) (*models.Flight, error) {
-	updatedFlight, err := h.db.UpdateFlight(id, flight)
+// ChangeItinerary moves a booking onto an itinerary from a recent search, charging the change fee
+// and fare difference of the original fare. Travellers rebooking away from a schedule change they
+// have not accepted keep their original fare and pay nothing. Seats do not carry over to the new flights,
+// so the seat fees paid for them are refunded with the change.
+func (h *FlightService) ChangeItinerary(id string, request models.ItineraryChangeRequest) (*models.ItineraryChange, error) {
+	booking, err := h.db.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	itinerary, quote, pending, err := h.prepareChange(booking, request)
 	if err != nil {
 		return nil, err
 	}
-	return updatedFlight, nil
+
+	fees := roundAmount(booking.Fees + quote.Fee)
+	totalPrice := roundAmount(itinerary.Price.Total + fees)
+	if err := h.db.UpdateItinerary(id, itinerary, fees, totalPrice); err != nil {
+		return nil, err
+	}
+
+	h.forgetOffer(request.ItineraryID)
+	h.releaseBookingSeats(booking)
+	for _, change := range pending {
+		if err := h.scheduleChanges.UpdateScheduleChangeStatus(change.ID, models.ScheduleChangePending, models.ScheduleChangeRebooked); err != nil {
+			log.Printf("Failed to mark schedule change %s as rebooked: %v\n", change.ID, err)
+		}
+	}
+
+	booking.Itinerary = itinerary
+	booking.TripType = itinerary.TripType
+	booking.Seats = models.SeatAssignments{}
+	booking.Fees = fees
+	booking.TotalPrice = totalPrice
+
+	// Tickets name the flights they are valid on, so a ticketed booking is reissued.
+	if booking.Status == models.FlightBookingConfirmed {
+		if err := h.reissueTickets(booking); err != nil {
+			log.Printf("Failed to reissue tickets for booking %s: %v\n", booking.ID, err)
+		}
+	}
+	return &models.ItineraryChange{Booking: *booking, Charges: quote}, nil
 }
 
-func (h *FlightService) DeleteFlight(id string) error {
-	err := h.db.DeleteFlight(id)
+// prepareChange checks that a booking can move onto an offered itinerary and prices the change. It
+// returns the itinerary as it will be booked and the schedule changes the move settles.
+func (h *FlightService) prepareChange(booking *models.FlightBooking, request models.ItineraryChangeRequest) (models.Itinerary, models.FeeQuote, []models.ScheduleChange, error) {
+	if booking.Status == models.FlightBookingCancelled {
+		return models.Itinerary{}, models.FeeQuote{}, nil, errors.New("cannot change a cancelled booking")
+	}
+
+	offer, err := h.offers.GetOffer(request.ItineraryID, time.Now())
+	if err != nil {
+		return models.Itinerary{}, models.FeeQuote{}, nil, err
+	}
+	passengers := models.PassengerMix{Adults: booking.Adults, Children: booking.Children, Infants: booking.Infants}
+	if offer.Passengers != passengers {
+		return models.Itinerary{}, models.FeeQuote{}, nil, errors.New("passengers do not match the ones the itinerary was priced for")
+	}
+	if offer.Itinerary.Cabin != booking.Itinerary.Cabin {
+		return models.Itinerary{}, models.FeeQuote{}, nil, errors.New("changes must stay in the booked cabin")
+	}
+	if err := validateItinerary(offer.Itinerary); err != nil {
+		return models.Itinerary{}, models.FeeQuote{}, nil, fmt.Errorf("itinerary can no longer be booked: %v", err)
+	}
+
+	now := time.Now()
+	itinerary := offer.Itinerary
+	pending := h.pendingScheduleChanges(booking.ID)
+	if len(pending) > 0 {
+		itinerary.Price = booking.Itinerary.Price
+		itinerary.Fare = booking.Itinerary.Fare
+		quote := models.FeeQuote{Action: models.FeeActionChange, Refund: seatFees(booking), Currency: booking.Currency}
+		return itinerary, quote, pending, nil
+	}
+	if err := checkFareRules(itinerary, now); err != nil {
+		return models.Itinerary{}, models.FeeQuote{}, nil, err
+	}
+	quote, err := changeQuote(booking, itinerary, now)
 	if err != nil {
-		return err
+		return models.Itinerary{}, models.FeeQuote{}, nil, err
+	}
+	quote.Refund = roundAmount(quote.Refund + seatFees(booking))
+	return itinerary, quote, pending, nil
+}
+
+// rememberOffers keeps the itineraries of a search bookable for offerTTL, dropping expired ones.
+func (h *FlightService) rememberOffers(itineraries []models.Itinerary, passengers models.PassengerMix) error {
+	now := time.Now()
+	if err := h.offers.DeleteExpiredOffers(now); err != nil {
+		log.Printf("Failed to delete expired offers: %v\n", err)
+	}
+	offers := make([]models.FlightOffer, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		offers = append(offers, models.FlightOffer{
+			ID:         itinerary.ID,
+			Itinerary:  itinerary,
+			Passengers: passengers,
+			ExpiresAt:  now.Add(offerTTL),
+		})
+	}
+	return h.offers.SaveOffers(offers)
+}
+
+// forgetOffer drops an offer once it was booked. The booking already happened, so a failure is only logged.
+func (h *FlightService) forgetOffer(id string) {
+	if err := h.offers.DeleteOffer(id); err != nil {
+		log.Printf("Failed to delete offer %s: %v\n", id, err)
 	}
-	return nil
 }
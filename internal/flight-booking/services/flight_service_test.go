@@ -0,0 +1,85 @@
+package services
+
+import (
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"testing"
+	"time"
+)
+
+// rebookedBooking holds a single flight booking and records the price it is moved onto another itinerary at.
+type rebookedBooking struct {
+	oneBooking
+	totalPrice float64
+}
+
+func (r *rebookedBooking) UpdateItinerary(id string, itinerary models.Itinerary, fees float64, totalPrice float64) error {
+	r.totalPrice = totalPrice
+	return nil
+}
+
+// oneOffer holds a single itinerary offer.
+type oneOffer struct {
+	ports.FlightOfferDB
+	offer models.FlightOffer
+}
+
+func (o oneOffer) GetOffer(id string, now time.Time) (*models.FlightOffer, error) {
+	if id != o.offer.ID {
+		return nil, models.ErrItineraryNotFound
+	}
+	offer := o.offer
+	return &offer, nil
+}
+
+func (o oneOffer) DeleteOffer(id string) error {
+	return nil
+}
+
+// noScheduleChanges records no schedule changes on any booking.
+type noScheduleChanges struct {
+	ports.ScheduleChangeDB
+}
+
+func (noScheduleChanges) GetScheduleChangesByBookingID(bookingID string) ([]models.ScheduleChange, error) {
+	return nil, nil
+}
+
+func TestChangeItinerary(t *testing.T) {
+	departure := time.Now().AddDate(0, 1, 0).UTC().Truncate(time.Hour)
+	itinerary := func(id string, departure time.Time, total float64) models.Itinerary {
+		return models.Itinerary{
+			ID:    id,
+			Cabin: models.CabinEconomy,
+			Legs: []models.FlightLeg{{Origin: "FRA", Destination: "JFK", DepartureTime: departure, ArrivalTime: departure.Add(9 * time.Hour),
+				Segments: []models.FlightSegment{{Origin: "FRA", Destination: "JFK", DepartureTime: departure, ArrivalTime: departure.Add(9 * time.Hour)}}}},
+			Price: models.OfferPrice{Total: total, Currency: "EUR"},
+			Fare:  models.Fare{Family: models.FareStandard, Rules: models.FareRules{Changeable: true, ChangeFee: 50, Refundable: true}},
+		}
+	}
+	booking := models.FlightBooking{ID: "booking-1", Status: models.FlightBookingPending, Adults: 1, Currency: "EUR",
+		Itinerary: itinerary("LH400-booked", departure, 400), TotalPrice: 430,
+		Seats: models.SeatAssignments{{FlightID: "LH400-20260701", SeatNumber: "12A", Price: 30}}}
+	offer := models.FlightOffer{ID: "LH402-offer", Itinerary: itinerary("LH402-offer", departure.AddDate(0, 0, 1), 380),
+		Passengers: models.PassengerMix{Adults: 1}}
+
+	db := &rebookedBooking{oneBooking: oneBooking{booking: booking}}
+	seats := &heldSeats{held: map[string][]string{"LH400-20260701": {"12A"}}}
+	service := &FlightService{db: db, offers: oneOffer{offer: offer}, seats: seats, scheduleChanges: noScheduleChanges{}}
+
+	change, err := service.ChangeItinerary("booking-1", models.ItineraryChangeRequest{ItineraryID: "LH402-offer"})
+	if err != nil {
+		t.Fatalf("ChangeItinerary: %v", err)
+	}
+
+	// The seat does not carry over, so its fee is refunded with the cheaper fare.
+	if change.Charges.Refund != 50 || change.Charges.AmountDue != 50 {
+		t.Errorf("charges = %+v, want a refund of 50 and 50 due", change.Charges)
+	}
+	if db.totalPrice != 430 || change.Booking.TotalPrice != 430 {
+		t.Errorf("total price = %v (stored %v), want 430", change.Booking.TotalPrice, db.totalPrice)
+	}
+	if len(change.Booking.Seats) != 0 || len(seats.held) != 0 {
+		t.Errorf("seats after the change = %v (held %v), want none", change.Booking.Seats, seats.held)
+	}
+}
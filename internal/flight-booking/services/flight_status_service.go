@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// significantChangeThreshold is the time shift from which a schedule change lets the traveller
+// rebook or cancel free of charge, in line with common airline schedule-change policies.
+const significantChangeThreshold = 90 * time.Minute
+
+// HandleFlightStatus applies an airline status update to every booking on the flight, records how
+// each booking was affected and notifies the travellers.
+func (h *FlightService) HandleFlightStatus(event models.FlightStatusEvent) ([]models.ScheduleChange, error) {
+	if event.FlightID == "" {
+		return nil, errors.New("flight ID is required")
+	}
+	switch event.Status {
+	case models.FlightScheduled, models.FlightDelayed, models.FlightRescheduled:
+		if event.DepartureTime == nil && event.ArrivalTime == nil {
+			return nil, nil // Nothing changed for the traveller
+		}
+	case models.FlightCancelled:
+	default:
+		return nil, fmt.Errorf("unsupported flight status: %s", event.Status)
+	}
+
+	bookings, err := h.db.GetBookingsByFlightID(event.FlightID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.ScheduleChange
+	for i := range bookings {
+		bookingChanges, err := h.applyFlightStatus(&bookings[i], event)
+		if err != nil {
+			log.Printf("Failed to apply status of %s to booking %s: %v\n", event.FlightID, bookings[i].ID, err)
+			continue
+		}
+		changes = append(changes, bookingChanges...)
+	}
+	log.Printf("Flight %s %s: %d booked segments affected\n", event.FlightID, event.Status, len(changes))
+	return changes, nil
+}
+
+// GetScheduleChanges returns the schedule changes recorded on a booking.
+func (h *FlightService) GetScheduleChanges(bookingID string) ([]models.ScheduleChange, error) {
+	if _, err := h.db.GetBookingByID(bookingID); err != nil {
+		return nil, err
+	}
+	return h.scheduleChanges.GetScheduleChangesByBookingID(bookingID)
+}
+
+// AcceptScheduleChange records that the traveller keeps the new flight times. Cancelled flights
+// cannot be accepted; the traveller rebooks or cancels instead.
+func (h *FlightService) AcceptScheduleChange(bookingID string, changeID string) (*models.ScheduleChange, error) {
+	changes, err := h.GetScheduleChanges(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.ID != changeID {
+			continue
+		}
+		if change.Type == models.ScheduleChangeCancellation {
+			return nil, errors.New("a cancelled flight cannot be accepted; rebook or cancel the booking instead")
+		}
+		if change.Status != models.ScheduleChangePending {
+			return nil, fmt.Errorf("schedule change is already %s", change.Status)
+		}
+		if err := h.scheduleChanges.UpdateScheduleChangeStatus(change.ID, models.ScheduleChangePending, models.ScheduleChangeAccepted); err != nil {
+			return nil, err
+		}
+		change.Status = models.ScheduleChangeAccepted
+		return &change, nil
+	}
+	return nil, models.ErrScheduleChangeNotFound
+}
+
+// applyFlightStatus updates the segments of one booking that are on the flight and records a change for each.
+func (h *FlightService) applyFlightStatus(booking *models.FlightBooking, event models.FlightStatusEvent) ([]models.ScheduleChange, error) {
+	itinerary := booking.Itinerary
+	var changes []models.ScheduleChange
+
+	segmentIndex := 0
+	for i := range itinerary.Legs {
+		leg := &itinerary.Legs[i]
+		for j := range leg.Segments {
+			segment := &leg.Segments[j]
+			if segment.FlightID() == event.FlightID {
+				if change, ok := applySegmentStatus(segment, event); ok {
+					change.BookingID = booking.ID
+					change.SegmentIndex = segmentIndex
+					changes = append(changes, change)
+				}
+			}
+			segmentIndex++
+		}
+		leg.DepartureTime = leg.Segments[0].DepartureTime
+		leg.ArrivalTime = leg.Segments[len(leg.Segments)-1].ArrivalTime
+		leg.DurationMinutes = int(leg.ArrivalTime.Sub(leg.DepartureTime).Minutes())
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// A retimed flight that breaks a connection is significant however small the shift.
+	buildConnections(&itinerary)
+	connectionsBroken := checkConnections(itinerary) != nil
+	for i := range changes {
+		if changes[i].Type == models.ScheduleChangeMinor && connectionsBroken {
+			changes[i].Type = models.ScheduleChangeSignificant
+		}
+		changes[i].Status = models.ScheduleChangePending
+		if changes[i].Type == models.ScheduleChangeMinor {
+			changes[i].Status = models.ScheduleChangeAccepted
+		}
+	}
+
+	if err := h.db.UpdateSchedule(booking.ID, itinerary); err != nil {
+		return nil, err
+	}
+	booking.Itinerary = itinerary
+
+	pnr, err := h.pnrs.GetPNRByBookingID(booking.ID)
+	if err != nil {
+		pnr = nil // Passenger details may not have been added yet; the booking owner is still notified
+	}
+	for i := range changes {
+		if err := h.scheduleChanges.CreateScheduleChange(&changes[i]); err != nil {
+			return nil, err
+		}
+		if err := h.notifier.NotifyScheduleChange(booking, pnr, changes[i]); err != nil {
+			log.Printf("Failed to notify booking %s of schedule change: %v\n", booking.ID, err)
+		}
+	}
+	return changes, nil
+}
+
+// applySegmentStatus retimes or cancels a segment and classifies the change. It reports false when
+// the update does not change the segment, e.g. when the same event is delivered twice.
+func applySegmentStatus(segment *models.FlightSegment, event models.FlightStatusEvent) (models.ScheduleChange, bool) {
+	change := models.ScheduleChange{
+		FlightID:          event.FlightID,
+		PreviousDeparture: segment.DepartureTime,
+		PreviousArrival:   segment.ArrivalTime,
+		Reason:            event.Reason,
+	}
+
+	if event.Status == models.FlightCancelled {
+		if segment.Cancelled {
+			return change, false
+		}
+		segment.Cancelled = true
+		change.Type = models.ScheduleChangeCancellation
+		return change, true
+	}
+
+	// When only one time is published, the other moves by the same amount.
+	departure, arrival := segment.DepartureTime, segment.ArrivalTime
+	switch {
+	case event.DepartureTime != nil && event.ArrivalTime != nil:
+		departure, arrival = *event.DepartureTime, *event.ArrivalTime
+	case event.DepartureTime != nil:
+		arrival = arrival.Add(event.DepartureTime.Sub(departure))
+		departure = *event.DepartureTime
+	case event.ArrivalTime != nil:
+		departure = departure.Add(event.ArrivalTime.Sub(arrival))
+		arrival = *event.ArrivalTime
+	}
+	if departure.Equal(segment.DepartureTime) && arrival.Equal(segment.ArrivalTime) && !segment.Cancelled {
+		return change, false
+	}
+
+	shift := absDuration(departure.Sub(segment.DepartureTime))
+	if arrivalShift := absDuration(arrival.Sub(segment.ArrivalTime)); arrivalShift > shift {
+		shift = arrivalShift
+	}
+
+	// The flight keeps the ID of its scheduled date, under which its seats and status are kept.
+	segment.ScheduledDate = segment.ScheduledDepartureDate()
+	segment.DepartureTime = departure
+	segment.ArrivalTime = arrival
+	segment.DurationMinutes = int(arrival.Sub(departure).Minutes())
+	segment.Cancelled = false
+	change.NewDeparture = &departure
+	change.NewArrival = &arrival
+
+	change.Type = models.ScheduleChangeMinor
+	if shift >= significantChangeThreshold {
+		change.Type = models.ScheduleChangeSignificant
+	}
+	return change, true
+}
+
+// pendingScheduleChanges returns the changes on a booking the traveller has not accepted or rebooked yet.
+func (h *FlightService) pendingScheduleChanges(bookingID string) []models.ScheduleChange {
+	changes, err := h.scheduleChanges.GetScheduleChangesByBookingID(bookingID)
+	if err != nil {
+		log.Printf("Failed to load schedule changes of booking %s: %v\n", bookingID, err)
+		return nil
+	}
+	var pending []models.ScheduleChange
+	for _, change := range changes {
+		if change.Status == models.ScheduleChangePending {
+			pending = append(pending, change)
+		}
+	}
+	return pending
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
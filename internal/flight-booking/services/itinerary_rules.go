@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"time"
+)
+
+// defaultMinimumConnection applies at airports without a published minimum connection time.
+const defaultMinimumConnection = 60
+
+// Published minimum connection times (in minutes) for the hubs our providers connect through.
+var minimumConnectionTimes = map[string]int{
+	"AMS": 50,
+	"CDG": 60,
+	"FRA": 45,
+	"LHR": 75,
+	"MAD": 45,
+	"JFK": 90,
+}
+
+// maxConnectionMinutes is the longest stopover still treated as a connection rather than a stay.
+const maxConnectionMinutes = 24 * 60
+
+func minimumConnectionTime(airport string) int {
+	if minutes, ok := minimumConnectionTimes[airport]; ok {
+		return minutes
+	}
+	return defaultMinimumConnection
+}
+
+// buildConnections fills in the connection times between consecutive segments of every leg.
+func buildConnections(itinerary *models.Itinerary) {
+	for i := range itinerary.Legs {
+		leg := &itinerary.Legs[i]
+		leg.Connections = nil
+		for j := 1; j < len(leg.Segments); j++ {
+			inbound, outbound := leg.Segments[j-1], leg.Segments[j]
+			leg.Connections = append(leg.Connections, models.Connection{
+				Airport:        outbound.Origin,
+				Minutes:        int(outbound.DepartureTime.Sub(inbound.ArrivalTime).Minutes()),
+				MinimumMinutes: minimumConnectionTime(outbound.Origin),
+			})
+		}
+	}
+}
+
+// validateItinerary checks that the legs chain together and that every connection is legal.
+func validateItinerary(itinerary models.Itinerary) error {
+	if len(itinerary.Legs) == 0 {
+		return fmt.Errorf("itinerary %s has no legs", itinerary.ID)
+	}
+	if itinerary.Legs[0].DepartureTime.Before(time.Now()) {
+		return fmt.Errorf("itinerary %s has already departed", itinerary.ID)
+	}
+
+	return checkConnections(itinerary)
+}
+
+// checkConnections verifies that every leg chains together, every connection is legal and the legs follow each other.
+func checkConnections(itinerary models.Itinerary) error {
+	for i, leg := range itinerary.Legs {
+		for j := 1; j < len(leg.Segments); j++ {
+			if leg.Segments[j-1].Destination != leg.Segments[j].Origin {
+				return fmt.Errorf("leg %d arrives at %s but continues from %s",
+					i+1, leg.Segments[j-1].Destination, leg.Segments[j].Origin)
+			}
+		}
+		for _, connection := range leg.Connections {
+			if connection.Minutes < connection.MinimumMinutes {
+				return fmt.Errorf("connection at %s is %d minutes, below the %d minute minimum",
+					connection.Airport, connection.Minutes, connection.MinimumMinutes)
+			}
+			if connection.Minutes > maxConnectionMinutes {
+				return fmt.Errorf("connection at %s exceeds 24 hours", connection.Airport)
+			}
+		}
+		if i > 0 && !leg.DepartureTime.After(itinerary.Legs[i-1].ArrivalTime) {
+			return fmt.Errorf("leg %d departs before leg %d arrives", i+1, i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// locatorAlphabet leaves out 0, 1, I and O so locators can be read out over the phone.
+	locatorAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	locatorLength   = 6
+	locatorAttempts = 5
+
+	minNameLength     = 2
+	maxNameLength     = 30
+	maxFullNameLength = 57 // Longest name an airline reservation system can print on a boarding pass
+	adultMinimumAge   = 12
+	childMinimumAge   = 2
+
+	// visibleDocumentCharacters is how much of a document number is shown when a record is read.
+	visibleDocumentCharacters = 3
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z '\-]*$`)
+
+// CreatePNR records the passengers of a booking and gives it a record locator.
+func (h *FlightService) CreatePNR(bookingID string, request models.PNRRequest) (*models.PNR, error) {
+	booking, err := h.db.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status == models.FlightBookingCancelled {
+		return nil, errors.New("cannot add passengers to a cancelled booking")
+	}
+	if booking.RecordLocator != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrPNRExists, *booking.RecordLocator)
+	}
+
+	passengers, err := validatePassengers(booking, request.Passengers)
+	if err != nil {
+		return nil, err
+	}
+	if request.ContactEmail == "" && request.ContactPhone == "" {
+		return nil, errors.New("a contact email or phone is required")
+	}
+
+	pnr := &models.PNR{
+		BookingID:    booking.ID,
+		Passengers:   passengers,
+		ContactEmail: request.ContactEmail,
+		ContactPhone: request.ContactPhone,
+	}
+	for attempt := 0; attempt < locatorAttempts; attempt++ {
+		pnr.RecordLocator, err = generateRecordLocator()
+		if err != nil {
+			return nil, err
+		}
+		for i := range pnr.Passengers {
+			pnr.Passengers[i].RecordLocator = pnr.RecordLocator
+		}
+
+		err = h.pnrs.CreatePNR(pnr)
+		if !errors.Is(err, models.ErrDuplicateLocator) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pnr, nil
+}
+
+// GetBookingPNR returns the passenger name record of a booking with its document numbers masked.
+func (h *FlightService) GetBookingPNR(bookingID string) (*models.PNR, error) {
+	if _, err := h.db.GetBookingByID(bookingID); err != nil {
+		return nil, err
+	}
+	pnr, err := h.pnrs.GetPNRByBookingID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	return maskDocuments(pnr), nil
+}
+
+// RetrievePNR looks a record up the way travellers do at check-in: by locator and the surname of
+// any passenger on it. A wrong surname is reported the same as an unknown locator, and document
+// numbers are masked since the locator and a surname are all it takes.
+func (h *FlightService) RetrievePNR(recordLocator string, lastName string) (*models.PNR, error) {
+	if recordLocator == "" || lastName == "" {
+		return nil, models.ErrPNRNotFound
+	}
+
+	pnr, err := h.pnrs.GetPNRByLocator(strings.ToUpper(recordLocator))
+	if err != nil {
+		return nil, err
+	}
+	for _, passenger := range pnr.Passengers {
+		if strings.EqualFold(passenger.LastName, strings.TrimSpace(lastName)) {
+			return maskDocuments(pnr), nil
+		}
+	}
+	return nil, models.ErrPNRNotFound
+}
+
+// maskDocuments hides all but the last characters of the passengers' document numbers, enough for a
+// traveller to tell which document was given.
+func maskDocuments(pnr *models.PNR) *models.PNR {
+	for i := range pnr.Passengers {
+		document := &pnr.Passengers[i].Document
+		hidden := len(document.Number) - visibleDocumentCharacters
+		if hidden < 1 {
+			// Too short to show any of it.
+			hidden = len(document.Number)
+		}
+		document.Number = strings.Repeat("*", hidden) + document.Number[hidden:]
+	}
+	return pnr
+}
+
+// validatePassengers checks the passenger details against the booking and returns them ordered
+// adults first, then children, then infants, the same order seat assignments use.
+func validatePassengers(booking *models.FlightBooking, passengers []models.Passenger) ([]models.Passenger, error) {
+	expected := map[models.PassengerType]int{
+		models.PassengerAdult:  booking.Adults,
+		models.PassengerChild:  booking.Children,
+		models.PassengerInfant: booking.Infants,
+	}
+	given := make(map[models.PassengerType]int)
+	for _, passenger := range passengers {
+		given[passenger.Type]++
+	}
+	for passengerType, count := range expected {
+		if given[passengerType] != count {
+			return nil, fmt.Errorf("booking has %d %s passengers but %d were given", count, passengerType, given[passengerType])
+		}
+	}
+	if len(passengers) != booking.Adults+booking.Children+booking.Infants {
+		return nil, errors.New("every passenger must be an adult (ADT), child (CHD) or infant (INF)")
+	}
+
+	rank := map[models.PassengerType]int{models.PassengerAdult: 0, models.PassengerChild: 1, models.PassengerInfant: 2}
+	ordered := make([]models.Passenger, len(passengers))
+	copy(ordered, passengers)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank[ordered[i].Type] < rank[ordered[j].Type]
+	})
+
+	segments := booking.Itinerary.Segments()
+	if len(segments) == 0 {
+		return nil, errors.New("booking has no flights")
+	}
+	lastDeparture := segments[len(segments)-1].DepartureTime
+	lastArrival := segments[len(segments)-1].ArrivalTime
+
+	accompanied := make(map[int]bool)
+	for i := range ordered {
+		passenger := &ordered[i]
+		passenger.ID = 0
+		passenger.Index = i
+		passenger.LastName = strings.ToUpper(strings.TrimSpace(passenger.LastName))
+		passenger.FirstName = strings.ToUpper(strings.TrimSpace(passenger.FirstName))
+		passenger.Title = strings.ToUpper(strings.TrimSpace(passenger.Title))
+		passenger.Nationality = strings.ToUpper(passenger.Nationality)
+		passenger.Document.IssuingCountry = strings.ToUpper(passenger.Document.IssuingCountry)
+
+		if err := validateName(passenger); err != nil {
+			return nil, fmt.Errorf("passenger %d: %v", i, err)
+		}
+		if err := validateAge(passenger, lastDeparture); err != nil {
+			return nil, fmt.Errorf("passenger %d: %v", i, err)
+		}
+		if err := validateDocument(passenger, lastArrival); err != nil {
+			return nil, fmt.Errorf("passenger %d: %v", i, err)
+		}
+
+		if passenger.Type != models.PassengerInfant {
+			passenger.AccompanyingIndex = nil
+			continue
+		}
+		// Each infant travels on the lap of a different adult.
+		if passenger.AccompanyingIndex == nil {
+			return nil, fmt.Errorf("passenger %d: infant must name the adult it travels with", i)
+		}
+		adult := *passenger.AccompanyingIndex
+		if adult < 0 || adult >= booking.Adults {
+			return nil, fmt.Errorf("passenger %d: accompanying passenger %d is not an adult", i, adult)
+		}
+		if accompanied[adult] {
+			return nil, fmt.Errorf("passenger %d: adult %d already travels with an infant", i, adult)
+		}
+		accompanied[adult] = true
+	}
+	return ordered, nil
+}
+
+func validateName(passenger *models.Passenger) error {
+	for _, name := range []string{passenger.FirstName, passenger.LastName} {
+		if len(name) < minNameLength || len(name) > maxNameLength {
+			return fmt.Errorf("names must be between %d and %d characters", minNameLength, maxNameLength)
+		}
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("name %q may only contain letters, spaces, hyphens and apostrophes", name)
+		}
+	}
+	if len(passenger.FullName()) > maxFullNameLength {
+		return fmt.Errorf("full name must not exceed %d characters", maxFullNameLength)
+	}
+	return nil
+}
+
+// validateAge checks the passenger type against the age on the day of the last flight, so an
+// infant turning two or a child turning twelve during the trip is booked in the older type.
+func validateAge(passenger *models.Passenger, travelDate time.Time) error {
+	if passenger.DateOfBirth.IsZero() {
+		return errors.New("date of birth is required")
+	}
+	if passenger.DateOfBirth.After(time.Now()) {
+		return errors.New("date of birth is in the future")
+	}
+
+	age := ageOn(passenger.DateOfBirth, travelDate)
+	switch passenger.Type {
+	case models.PassengerAdult:
+		if age < adultMinimumAge {
+			return fmt.Errorf("adults must be at least %d years old when travelling", adultMinimumAge)
+		}
+	case models.PassengerChild:
+		if age < childMinimumAge || age >= adultMinimumAge {
+			return fmt.Errorf("children must be between %d and %d years old when travelling", childMinimumAge, adultMinimumAge-1)
+		}
+	case models.PassengerInfant:
+		if age >= childMinimumAge {
+			return fmt.Errorf("infants must be under %d years old when travelling", childMinimumAge)
+		}
+	default:
+		return fmt.Errorf("unknown passenger type %q", passenger.Type)
+	}
+	return nil
+}
+
+func validateDocument(passenger *models.Passenger, lastArrival time.Time) error {
+	switch passenger.Gender {
+	case "M", "F", "X":
+	default:
+		return errors.New("gender must be M, F or X")
+	}
+	if len(passenger.Nationality) != 2 {
+		return errors.New("nationality must be a two-letter country code")
+	}
+
+	document := passenger.Document
+	if document.Type != models.DocumentPassport && document.Type != models.DocumentIDCard {
+		return errors.New("travel document must be a passport or an identity card")
+	}
+	if strings.TrimSpace(document.Number) == "" {
+		return errors.New("travel document number is required")
+	}
+	if len(document.IssuingCountry) != 2 {
+		return errors.New("travel document issuing country must be a two-letter country code")
+	}
+	if !document.ExpiryDate.After(lastArrival) {
+		return errors.New("travel document expires before the end of the trip")
+	}
+	return nil
+}
+
+// ageOn returns the age in whole years on the given date.
+func ageOn(dateOfBirth time.Time, date time.Time) int {
+	age := date.Year() - dateOfBirth.Year()
+	if date.Month() < dateOfBirth.Month() || (date.Month() == dateOfBirth.Month() && date.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
+
+func generateRecordLocator() (string, error) {
+	locator := make([]byte, locatorLength)
+	max := big.NewInt(int64(len(locatorAlphabet)))
+	for i := range locator {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating record locator: %v", err)
+		}
+		locator[i] = locatorAlphabet[n.Int64()]
+	}
+	return string(locator), nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"testing"
+	"time"
+)
+
+// oneBooking holds a single flight booking.
+type oneBooking struct {
+	ports.FlightDB
+	booking models.FlightBooking
+}
+
+func (o *oneBooking) GetBookingByID(id string) (*models.FlightBooking, error) {
+	if id != o.booking.ID {
+		return nil, models.ErrBookingNotFound
+	}
+	booking := o.booking
+	return &booking, nil
+}
+
+// scriptedPNRs answers CreatePNR with the given errors in turn and keeps the last record stored.
+type scriptedPNRs struct {
+	ports.PNRDB
+	errs   []error
+	calls  int
+	stored *models.PNR
+}
+
+func (s *scriptedPNRs) CreatePNR(pnr *models.PNR) error {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	stored := *pnr
+	stored.Passengers = append([]models.Passenger(nil), pnr.Passengers...)
+	s.stored = &stored
+	return nil
+}
+
+func (s *scriptedPNRs) GetPNRByLocator(recordLocator string) (*models.PNR, error) {
+	if s.stored == nil || s.stored.RecordLocator != recordLocator {
+		return nil, models.ErrPNRNotFound
+	}
+	pnr := *s.stored
+	pnr.Passengers = append([]models.Passenger(nil), s.stored.Passengers...)
+	return &pnr, nil
+}
+
+func TestCreatePNR(t *testing.T) {
+	departure := time.Now().AddDate(0, 1, 0).UTC()
+	booking := models.FlightBooking{ID: "booking-1", UserID: "user-1", Adults: 1, Itinerary: models.Itinerary{Legs: []models.FlightLeg{{
+		Segments: []models.FlightSegment{{Origin: "FRA", Destination: "JFK", DepartureTime: departure, ArrivalTime: departure.Add(9 * time.Hour)}},
+	}}}}
+	request := models.PNRRequest{ContactEmail: "ana@example.com", Passengers: []models.Passenger{{
+		Type: models.PassengerAdult, FirstName: "Ana", LastName: "Garcia", Gender: "F", Nationality: "es",
+		DateOfBirth: time.Date(1990, 4, 2, 0, 0, 0, 0, time.UTC),
+		Document: models.TravelDocument{Type: models.DocumentPassport, Number: "PAB123456", IssuingCountry: "es",
+			ExpiryDate: departure.AddDate(5, 0, 0)},
+	}}}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "stores the record", wantCalls: 1},
+		{name: "draws another locator when one is taken", errs: []error{models.ErrDuplicateLocator, nil}, wantCalls: 2},
+		{name: "a concurrent request recorded the passengers first", errs: []error{models.ErrPNRExists},
+			wantErr: models.ErrPNRExists, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pnrs := &scriptedPNRs{errs: tt.errs}
+			service := &FlightService{db: &oneBooking{booking: booking}, pnrs: pnrs}
+
+			_, err := service.CreatePNR("booking-1", request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreatePNR: got %v, want %v", err, tt.wantErr)
+			}
+			if pnrs.calls != tt.wantCalls {
+				t.Errorf("CreatePNR stored %d times, want %d", pnrs.calls, tt.wantCalls)
+			}
+		})
+	}
+
+	t.Run("bookings with a record are rejected", func(t *testing.T) {
+		locator := "X7KQ2M"
+		recorded := booking
+		recorded.RecordLocator = &locator
+		service := &FlightService{db: &oneBooking{booking: recorded}, pnrs: &scriptedPNRs{}}
+		if _, err := service.CreatePNR("booking-1", request); !errors.Is(err, models.ErrPNRExists) {
+			t.Errorf("CreatePNR: got %v, want %v", err, models.ErrPNRExists)
+		}
+	})
+
+	t.Run("retrieved records mask document numbers", func(t *testing.T) {
+		pnrs := &scriptedPNRs{}
+		service := &FlightService{db: &oneBooking{booking: booking}, pnrs: pnrs}
+		created, err := service.CreatePNR("booking-1", request)
+		if err != nil {
+			t.Fatalf("CreatePNR: %v", err)
+		}
+
+		pnr, err := service.RetrievePNR(created.RecordLocator, "garcia")
+		if err != nil {
+			t.Fatalf("RetrievePNR: %v", err)
+		}
+		if number := pnr.Passengers[0].Document.Number; number != "******456" {
+			t.Errorf("document number = %q, want ******456", number)
+		}
+		if number := pnrs.stored.Passengers[0].Document.Number; number != "PAB123456" {
+			t.Errorf("stored document number = %q, want it unmasked", number)
+		}
+		if _, err := service.RetrievePNR(created.RecordLocator, "smith"); !errors.Is(err, models.ErrPNRNotFound) {
+			t.Errorf("RetrievePNR with another surname: got %v, want %v", err, models.ErrPNRNotFound)
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// seatHoldDuration is how long seats stay held while the booking is being updated.
+const seatHoldDuration = 15 * time.Minute
+
+// GetSeatMap returns the seat availability of a flight.
+func (h *FlightService) GetSeatMap(flightID string, aircraft string, cabin models.CabinClass) (*models.SeatMap, error) {
+	if flightID == "" {
+		return nil, errors.New("flight ID is required")
+	}
+	return h.seats.GetSeatMap(flightID, aircraft, cabin)
+}
+
+// GetBookingSeatMaps returns the seat map of every segment of a booking, in the cabin it was booked
+// in and with the seats priced in the currency of the booking.
+func (h *FlightService) GetBookingSeatMaps(bookingID string) ([]models.SeatMap, error) {
+	booking, err := h.db.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var seatMaps []models.SeatMap
+	for _, segment := range booking.Itinerary.Segments() {
+		seatMap, err := h.bookingSeatMap(booking, segment)
+		if err != nil {
+			return nil, err
+		}
+		seatMaps = append(seatMaps, *seatMap)
+	}
+	return seatMaps, nil
+}
+
+// AssignSeats gives every seated passenger a seat on every segment, using their selection when
+// they made one and their preference otherwise. Seats are held for all segments before any is confirmed.
+func (h *FlightService) AssignSeats(bookingID string, request models.SeatSelectionRequest) (*models.FlightBooking, error) {
+	booking, err := h.db.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status == models.FlightBookingCancelled {
+		return nil, errors.New("cannot select seats on a cancelled booking")
+	}
+
+	segments := booking.Itinerary.Segments()
+	passengers := booking.SeatedPassengers()
+
+	selections := make(map[int]map[int]string) // segment index -> passenger index -> seat number
+	for _, selection := range request.Selections {
+		if selection.SegmentIndex < 0 || selection.SegmentIndex >= len(segments) {
+			return nil, fmt.Errorf("segment %d does not exist on this booking", selection.SegmentIndex)
+		}
+		if selection.PassengerIndex < 0 || selection.PassengerIndex >= passengers {
+			return nil, fmt.Errorf("passenger %d does not exist on this booking", selection.PassengerIndex)
+		}
+		if selections[selection.SegmentIndex] == nil {
+			selections[selection.SegmentIndex] = make(map[int]string)
+		}
+		if _, exists := selections[selection.SegmentIndex][selection.PassengerIndex]; exists {
+			return nil, fmt.Errorf("passenger %d has more than one seat on segment %d", selection.PassengerIndex, selection.SegmentIndex)
+		}
+		selections[selection.SegmentIndex][selection.PassengerIndex] = strings.ToUpper(selection.SeatNumber)
+	}
+
+	var assignments models.SeatAssignments
+	for segmentIndex, segment := range segments {
+		seatMap, err := h.bookingSeatMap(booking, segment)
+		if err != nil {
+			return nil, err
+		}
+		segmentAssignments, err := allocateSeats(seatMap, booking, segmentIndex, selections[segmentIndex], request.Preferences)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, segmentAssignments...)
+	}
+
+	if err := h.holdAndConfirmSeats(booking, assignments); err != nil {
+		return nil, err
+	}
+
+	totalPrice := booking.Itinerary.Price.Total + booking.Fees
+	for _, assignment := range assignments {
+		totalPrice += assignment.Price
+	}
+	if err := h.db.UpdateSeatAssignments(booking.ID, assignments, totalPrice); err != nil {
+		return nil, err
+	}
+
+	booking.Seats = assignments
+	booking.TotalPrice = totalPrice
+	return booking, nil
+}
+
+// bookingSeatMap returns the seat map of a segment of a booking, in its cabin and with the seats
+// priced in its currency so seat fees add up with the rest of its price.
+func (h *FlightService) bookingSeatMap(booking *models.FlightBooking, segment models.FlightSegment) (*models.SeatMap, error) {
+	seatMap, err := h.seats.GetSeatMap(segment.FlightID(), segment.Aircraft, booking.Itinerary.Cabin)
+	if err != nil {
+		return nil, err
+	}
+	for i := range seatMap.Rows {
+		for j := range seatMap.Rows[i].Seats {
+			seat := &seatMap.Rows[i].Seats[j]
+			if seat.Price == 0 || strings.EqualFold(seat.Currency, booking.Currency) {
+				seat.Currency = booking.Currency
+				continue
+			}
+			price, err := h.rates.Convert(seat.Price, seat.Currency, booking.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("cannot price seats in %s: %v", booking.Currency, err)
+			}
+			seat.Price, seat.Currency = roundAmount(price), booking.Currency
+		}
+	}
+	return seatMap, nil
+}
+
+// holdAndConfirmSeats holds the new seats flight by flight, then confirms them. If holding or
+// confirming a flight fails, the flights already changed get their previous seats back, and new
+// holds are released, before the error is returned.
+func (h *FlightService) holdAndConfirmSeats(booking *models.FlightBooking, assignments models.SeatAssignments) error {
+	byFlight := seatsByFlight(assignments)
+	previous := seatsByFlight(booking.Seats)
+
+	var flightIDs []string
+	for flightID := range byFlight {
+		flightIDs = append(flightIDs, flightID)
+	}
+	sort.Strings(flightIDs) // Stable ordering avoids two bookings holding flights in opposite order
+
+	until := time.Now().Add(seatHoldDuration)
+	for i, flightID := range flightIDs {
+		if err := h.seats.HoldSeats(flightID, byFlight[flightID], booking.ID, until); err != nil {
+			h.restoreSeats(booking.ID, flightIDs[:i], previous)
+			return err
+		}
+	}
+	for _, flightID := range flightIDs {
+		if err := h.seats.ConfirmSeats(flightID, booking.ID); err != nil {
+			h.restoreSeats(booking.ID, flightIDs, previous)
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *FlightService) restoreSeats(holdRef string, flightIDs []string, previous map[string][]string) {
+	for _, flightID := range flightIDs {
+		var err error
+		if len(previous[flightID]) == 0 {
+			err = h.seats.ReleaseSeats(flightID, holdRef)
+		} else if err = h.seats.HoldSeats(flightID, previous[flightID], holdRef, time.Now().Add(seatHoldDuration)); err == nil {
+			err = h.seats.ConfirmSeats(flightID, holdRef)
+		}
+		if err != nil {
+			log.Printf("Failed to restore seats of %s on %s: %v\n", holdRef, flightID, err)
+		}
+	}
+}
+
+// releaseBookingSeats frees every seat a booking holds.
+func (h *FlightService) releaseBookingSeats(booking *models.FlightBooking) {
+	for flightID := range seatsByFlight(booking.Seats) {
+		if err := h.seats.ReleaseSeats(flightID, booking.ID); err != nil {
+			log.Printf("Failed to release seats of %s on %s: %v\n", booking.ID, flightID, err)
+		}
+	}
+}
+
+// seatFees returns what the booking paid for its seats.
+func seatFees(booking *models.FlightBooking) float64 {
+	fees := 0.0
+	for _, assignment := range booking.Seats {
+		fees += assignment.Price
+	}
+	return roundAmount(fees)
+}
+
+func seatsByFlight(assignments models.SeatAssignments) map[string][]string {
+	byFlight := make(map[string][]string)
+	for _, assignment := range assignments {
+		byFlight[assignment.FlightID] = append(byFlight[assignment.FlightID], assignment.SeatNumber)
+	}
+	return byFlight
+}
+
+// allocateSeats checks the explicit selections for one segment and picks seats for the remaining passengers.
+func allocateSeats(seatMap *models.SeatMap, booking *models.FlightBooking, segmentIndex int,
+	selected map[int]string, preferences map[int]models.SeatPreference) (models.SeatAssignments, error) {
+
+	// Seats this booking already has on the flight can be kept or swapped between its passengers.
+	ownSeats := make(map[string]bool)
+	for _, assignment := range booking.Seats {
+		if assignment.FlightID == seatMap.FlightID {
+			ownSeats[assignment.SeatNumber] = true
+		}
+	}
+	isFree := func(seat *models.Seat) bool {
+		return seat.Status == models.SeatAvailable || ownSeats[seat.Number]
+	}
+
+	taken := make(map[string]bool)
+	var assignments models.SeatAssignments
+
+	for passengerIndex := 0; passengerIndex < booking.SeatedPassengers(); passengerIndex++ {
+		number, ok := selected[passengerIndex]
+		if !ok {
+			continue
+		}
+		seat, row := seatMap.Seat(number)
+		if seat == nil {
+			return nil, fmt.Errorf("seat %s does not exist in the booked cabin of %s", number, seatMap.FlightID)
+		}
+		if !isFree(seat) || taken[number] {
+			return nil, fmt.Errorf("%w: %s on %s", models.ErrSeatUnavailable, number, seatMap.FlightID)
+		}
+		if row.ExitRow && isChild(booking, passengerIndex) {
+			return nil, fmt.Errorf("seat %s is in an exit row and cannot be assigned to a child", number)
+		}
+		taken[number] = true
+		assignments = append(assignments, models.SeatAssignment{
+			FlightID:       seatMap.FlightID,
+			SegmentIndex:   segmentIndex,
+			PassengerIndex: passengerIndex,
+			SeatNumber:     number,
+			Price:          seat.Price,
+		})
+	}
+
+	for passengerIndex := 0; passengerIndex < booking.SeatedPassengers(); passengerIndex++ {
+		if _, ok := selected[passengerIndex]; ok {
+			continue
+		}
+		seat := pickSeat(seatMap, preferences[passengerIndex], isChild(booking, passengerIndex), nearRow(seatMap, assignments),
+			func(seat *models.Seat) bool { return isFree(seat) && !taken[seat.Number] })
+		if seat == nil {
+			return nil, fmt.Errorf("no seat left for passenger %d on %s", passengerIndex, seatMap.FlightID)
+		}
+		taken[seat.Number] = true
+		assignments = append(assignments, models.SeatAssignment{
+			FlightID:       seatMap.FlightID,
+			SegmentIndex:   segmentIndex,
+			PassengerIndex: passengerIndex,
+			SeatNumber:     seat.Number,
+			Price:          seat.Price,
+			AutoAssigned:   true,
+		})
+	}
+
+	return assignments, nil
+}
+
+// pickSeat chooses the best free seat for a passenger: matching the preference first, then the
+// cheapest, then the closest to the rest of the party.
+func pickSeat(seatMap *models.SeatMap, preference models.SeatPreference, child bool, near int,
+	free func(seat *models.Seat) bool) *models.Seat {
+
+	var best *models.Seat
+	var bestScore [3]int
+	for i := range seatMap.Rows {
+		row := &seatMap.Rows[i]
+		if row.ExitRow && child {
+			continue
+		}
+		for j := range row.Seats {
+			seat := &row.Seats[j]
+			if !free(seat) {
+				continue
+			}
+
+			mismatch := 0
+			if preference != "" && seat.Position != preference {
+				mismatch = 1
+			}
+			distance := row.Number - near
+			if distance < 0 {
+				distance = -distance
+			}
+			score := [3]int{mismatch, int(seat.Price * 100), distance}
+			if best == nil || lessScore(score, bestScore) {
+				best, bestScore = seat, score
+			}
+		}
+	}
+	return best
+}
+
+func lessScore(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// nearRow returns the row of the party's first seat so auto-assigned seats stay together.
+func nearRow(seatMap *models.SeatMap, assignments models.SeatAssignments) int {
+	if len(assignments) == 0 {
+		return 0
+	}
+	_, row := seatMap.Seat(assignments[0].SeatNumber)
+	if row == nil {
+		return 0
+	}
+	return row.Number
+}
+
+// isChild reports whether the passenger at index is a child; adults are listed first.
+func isChild(booking *models.FlightBooking, passengerIndex int) bool {
+	return passengerIndex >= booking.Adults
+}
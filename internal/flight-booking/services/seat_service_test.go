@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"microservices-travel-backend/internal/flight-booking/domain/ports"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// heldSeats records the seats each flight holds for one booking and fails to confirm the flights
+// in failConfirm.
+type heldSeats struct {
+	ports.SeatInventory
+	held        map[string][]string
+	failConfirm map[string]bool
+}
+
+func (s *heldSeats) HoldSeats(flightID string, seatNumbers []string, holdRef string, until time.Time) error {
+	s.held[flightID] = seatNumbers
+	return nil
+}
+
+func (s *heldSeats) ConfirmSeats(flightID string, holdRef string) error {
+	if s.failConfirm[flightID] {
+		return errors.New("seat inventory unavailable")
+	}
+	return nil
+}
+
+func (s *heldSeats) ReleaseSeats(flightID string, holdRef string) error {
+	delete(s.held, flightID)
+	return nil
+}
+
+func TestHoldAndConfirmSeats(t *testing.T) {
+	booking := &models.FlightBooking{ID: "booking-1", Seats: models.SeatAssignments{
+		{FlightID: "LH400-20260701", SeatNumber: "12A"},
+	}}
+	seats := &heldSeats{
+		held:        map[string][]string{"LH400-20260701": {"12A"}},
+		failConfirm: map[string]bool{"LH401-20260708": true},
+	}
+	service := &FlightService{seats: seats}
+
+	err := service.holdAndConfirmSeats(booking, models.SeatAssignments{
+		{FlightID: "LH400-20260701", SeatNumber: "14C"},
+		{FlightID: "LH401-20260708", SeatNumber: "3F"},
+	})
+	if err == nil {
+		t.Fatal("holdAndConfirmSeats succeeded although a flight could not be confirmed")
+	}
+
+	// The outbound keeps its previous seat and the return flight holds nothing.
+	want := map[string][]string{"LH400-20260701": {"12A"}}
+	if !reflect.DeepEqual(seats.held, want) {
+		t.Errorf("seats held after the failure = %v, want %v", seats.held, want)
+	}
+}
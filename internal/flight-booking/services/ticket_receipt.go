@@ -0,0 +1,63 @@
+package services
+
+import (
+	"html/template"
+	"io"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+)
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>E-ticket receipt {{.PNR.RecordLocator}}</title>
+<style>
+body { font-family: Arial, sans-serif; font-size: 14px; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 6px 8px; text-align: left; }
+th { background: #f2f2f2; }
+</style>
+</head>
+<body>
+<h1>Electronic ticket receipt</h1>
+<p>Booking reference: <strong>{{.PNR.RecordLocator}}</strong><br>
+Issued by: {{.Booking.Itinerary.ValidatingAirline}}<br>
+Fare: {{.Booking.Itinerary.Fare.Name}}</p>
+
+<h2>Flights</h2>
+<table>
+<tr><th>Flight</th><th>From</th><th>To</th><th>Departure</th><th>Arrival</th></tr>
+{{range .Segments}}<tr><td>{{.MarketingCarrier}}{{.FlightNumber}}</td><td>{{.Origin}}</td><td>{{.Destination}}</td><td>{{.DepartureTime.Format "02 Jan 2006 15:04"}}</td><td>{{.ArrivalTime.Format "02 Jan 2006 15:04"}}</td></tr>
+{{end}}</table>
+
+<h2>Tickets</h2>
+<table>
+<tr><th>Passenger</th><th>Ticket number</th><th>Coupons</th><th>Total</th></tr>
+{{range .Tickets}}<tr><td>{{.PassengerName}} ({{.PassengerType}})</td><td>{{.Number}}{{if .ConjunctionWith}} (conjunction){{end}}</td><td>{{range .Coupons}}{{.Number}}: {{.Origin}}-{{.Destination}} {{.Status}}<br>{{end}}</td><td>{{if .Total}}{{printf "%.2f" .Total}} {{.Currency}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Fare conditions</h2>
+<p>{{with .Booking.Itinerary.Fare.Rules}}{{if .Refundable}}Refundable{{else}}Non-refundable; unused taxes are refunded{{end}}.
+{{if .Changeable}}Changes allowed{{if .ChangeFee}} for {{printf "%.2f" .ChangeFee}} {{.Currency}} per passenger plus any fare difference{{end}}.{{else}}Changes not allowed.{{end}}{{end}}</p>
+<table>
+<tr><th>Passenger type</th><th>Checked baggage</th><th>Cabin baggage</th></tr>
+{{range .Booking.Itinerary.Fare.Baggage}}<tr><td>{{.PassengerType}}</td><td>{{.CheckedPieces}} x {{.CheckedWeightKg}} kg</td><td>{{.CabinPieces}} x {{.CabinWeightKg}} kg</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// renderReceipt writes the e-ticket receipt of a booking as an HTML document.
+func renderReceipt(w io.Writer, booking *models.FlightBooking, pnr *models.PNR, tickets []models.Ticket) error {
+	return receiptTemplate.Execute(w, struct {
+		Booking  *models.FlightBooking
+		PNR      *models.PNR
+		Segments []models.FlightSegment
+		Tickets  []models.Ticket
+	}{
+		Booking:  booking,
+		PNR:      pnr,
+		Segments: booking.Itinerary.Segments(),
+		Tickets:  tickets,
+	})
+}
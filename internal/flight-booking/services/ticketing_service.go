@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"microservices-travel-backend/internal/flight-booking/domain/models"
+	"strings"
+	"time"
+)
+
+const (
+	// couponsPerTicket is how many flight coupons fit on one ticket before a conjunction ticket is needed.
+	couponsPerTicket = 4
+	// voidWindow is how long after issuance a ticket can be voided instead of refunded.
+	voidWindow = 24 * time.Hour
+)
+
+// airlineAccountingCodes are the three-digit prefixes of the tickets each validating airline issues.
+var airlineAccountingCodes = map[string]string{
+	"AF": "057",
+	"BA": "125",
+	"KL": "074",
+	"LH": "220",
+}
+
+// allowedCouponTransitions lists the statuses an open coupon can move to; the others are final.
+var allowedCouponTransitions = map[models.CouponStatus]bool{
+	models.CouponUsed:     true,
+	models.CouponVoid:     true,
+	models.CouponRefunded: true,
+}
+
+// IssueTickets issues an e-ticket per passenger once the payment service confirms the booking was
+// paid, and stores the e-ticket receipt alongside the booking.
+func (h *FlightService) IssueTickets(bookingID string, request models.TicketingRequest) ([]models.Ticket, error) {
+	if request.PaymentReference == "" {
+		return nil, errors.New("a confirmed payment reference is required to issue tickets")
+	}
+
+	booking, err := h.db.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	switch booking.Status {
+	case models.FlightBookingCancelled:
+		return nil, errors.New("cannot issue tickets for a cancelled booking")
+	case models.FlightBookingConfirmed:
+		return nil, models.ErrAlreadyTicketed
+	}
+	if err := h.checkPayment(booking, request.PaymentReference); err != nil {
+		return nil, err
+	}
+
+	return h.issueTickets(booking, request.PaymentReference)
+}
+
+// checkPayment makes sure a payment was captured for a booking and covers its price.
+func (h *FlightService) checkPayment(booking *models.FlightBooking, paymentReference string) error {
+	payment, err := h.payments.GetPayment(paymentReference)
+	if err != nil {
+		return err
+	}
+	switch {
+	case payment.Reference != booking.ID:
+		return fmt.Errorf("%w: payment %s is for another booking", models.ErrPaymentNotConfirmed, payment.ID)
+	case payment.Status != models.PaymentCaptured:
+		return fmt.Errorf("%w: payment %s is %s", models.ErrPaymentNotConfirmed, payment.ID, payment.Status)
+	case !strings.EqualFold(payment.Currency, booking.Currency):
+		return fmt.Errorf("%w: payment %s is in %s, the booking in %s", models.ErrPaymentNotConfirmed, payment.ID, payment.Currency, booking.Currency)
+	case roundAmount(payment.Amount-payment.RefundedAmount) < roundAmount(booking.TotalPrice):
+		return fmt.Errorf("%w: payment %s does not cover the booking", models.ErrPaymentNotConfirmed, payment.ID)
+	}
+	return nil
+}
+
+// GetBookingTickets returns every ticket issued for a booking, including voided and reissued ones.
+func (h *FlightService) GetBookingTickets(bookingID string) ([]models.Ticket, error) {
+	if _, err := h.db.GetBookingByID(bookingID); err != nil {
+		return nil, err
+	}
+	return h.tickets.GetTicketsByBookingID(bookingID)
+}
+
+func (h *FlightService) GetTicket(number string) (*models.Ticket, error) {
+	return h.tickets.GetTicket(number)
+}
+
+// UpdateCouponStatus records what happened to a coupon, e.g. that it was flown. Only open coupons can change.
+func (h *FlightService) UpdateCouponStatus(ticketNumber string, couponNumber int, update models.CouponStatusUpdate) (*models.Ticket, error) {
+	if !allowedCouponTransitions[update.Status] {
+		return nil, fmt.Errorf("unsupported coupon status: %s", update.Status)
+	}
+
+	ticket, err := h.tickets.GetTicket(ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+	var coupon *models.Coupon
+	for i := range ticket.Coupons {
+		if ticket.Coupons[i].Number == couponNumber {
+			coupon = &ticket.Coupons[i]
+		}
+	}
+	if coupon == nil {
+		return nil, fmt.Errorf("%w: coupon %d", models.ErrTicketNotFound, couponNumber)
+	}
+
+	if err := h.tickets.UpdateCouponStatus(ticketNumber, couponNumber, models.CouponOpen, update.Status); err != nil {
+		return nil, err
+	}
+	coupon.Status = update.Status
+	if update.Status == models.CouponUsed {
+		h.recordFlownSegment(ticket, coupon)
+	}
+	return ticket, nil
+}
+
+// recordFlownSegment earns the user who booked the ticket points for their share of the
+// passenger's fare. The coupon stays used when the loyalty programme cannot be reached.
+func (h *FlightService) recordFlownSegment(ticket *models.Ticket, coupon *models.Coupon) {
+	if h.loyalty == nil {
+		return
+	}
+	booking, err := h.db.GetBookingByID(ticket.BookingID)
+	if err != nil {
+		log.Printf("Failed to earn points for coupon %d of ticket %s: %v\n", coupon.Number, ticket.Number, err)
+		return
+	}
+
+	// The passenger's total is on the first ticket of a conjunction set.
+	fare := ticket
+	if ticket.ConjunctionWith != "" {
+		if fare, err = h.tickets.GetTicket(ticket.ConjunctionWith); err != nil {
+			log.Printf("Failed to earn points for coupon %d of ticket %s: %v\n", coupon.Number, ticket.Number, err)
+			return
+		}
+	}
+	amount := fare.Total
+	if segments := len(booking.Itinerary.Segments()); segments > 0 {
+		amount = math.Round(fare.Total/float64(segments)*100) / 100
+	}
+
+	err = h.loyalty.RecordFlownSegment(models.FlownSegment{
+		TicketNumber: ticket.Number,
+		CouponNumber: coupon.Number,
+		UserID:       booking.UserID,
+		BookingID:    booking.ID,
+		Amount:       amount,
+		Currency:     fare.Currency,
+		FlownAt:      time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to earn points for coupon %d of ticket %s: %v\n", coupon.Number, ticket.Number, err)
+	}
+}
+
+// issueTickets numbers and stores the tickets of a booking, then generates its receipt.
+func (h *FlightService) issueTickets(booking *models.FlightBooking, paymentReference string) ([]models.Ticket, error) {
+	if booking.RecordLocator == nil {
+		return nil, errors.New("passenger details must be added before tickets can be issued")
+	}
+	pnr, err := h.pnrs.GetPNRByBookingID(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	accountingCode, ok := airlineAccountingCodes[booking.Itinerary.ValidatingAirline]
+	if !ok {
+		return nil, fmt.Errorf("no ticketing agreement with validating airline %s", booking.Itinerary.ValidatingAirline)
+	}
+
+	segments := booking.Itinerary.Segments()
+	issuedAt := time.Now()
+	var tickets []models.Ticket
+	for _, passenger := range pnr.Passengers {
+		var primary string
+		for first := 0; first < len(segments); first += couponsPerTicket {
+			serial, err := h.tickets.NextTicketSerial()
+			if err != nil {
+				return nil, err
+			}
+			ticket := models.Ticket{
+				Number:            fmt.Sprintf("%s%010d", accountingCode, serial%10000000000),
+				BookingID:         booking.ID,
+				RecordLocator:     pnr.RecordLocator,
+				PassengerIndex:    passenger.Index,
+				PassengerName:     passenger.FullName(),
+				PassengerType:     passenger.Type,
+				ConjunctionWith:   primary,
+				ValidatingAirline: booking.Itinerary.ValidatingAirline,
+				FareFamily:        booking.Itinerary.Fare.Family,
+				Currency:          booking.Currency,
+				PaymentReference:  paymentReference,
+				IssuedAt:          issuedAt,
+			}
+			if primary == "" {
+				primary = ticket.Number
+				ticket.Total = passengerFare(booking.Itinerary.Price, passenger.Type)
+			}
+
+			last := min(first+couponsPerTicket, len(segments))
+			for segmentIndex := first; segmentIndex < last; segmentIndex++ {
+				segment := segments[segmentIndex]
+				ticket.Coupons = append(ticket.Coupons, models.Coupon{
+					TicketNumber:  ticket.Number,
+					Number:        segmentIndex - first + 1,
+					SegmentIndex:  segmentIndex,
+					FlightID:      segment.FlightID(),
+					Origin:        segment.Origin,
+					Destination:   segment.Destination,
+					DepartureTime: segment.DepartureTime,
+					Status:        models.CouponOpen,
+				})
+			}
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	// The booking must still be in the status it was read in, so concurrent issuances store tickets once.
+	if err := h.tickets.CreateTickets(booking.ID, booking.Status, tickets); err != nil {
+		return nil, err
+	}
+
+	// The tickets are valid without the receipt, so a failure to store or link it does not undo the issuance.
+	receiptURL, err := h.storeReceipt(booking, pnr, tickets)
+	if err != nil {
+		log.Printf("Failed to store e-ticket receipt for booking %s: %v\n", booking.ID, err)
+		return tickets, nil
+	}
+	if err := h.db.UpdateReceipt(booking.ID, receiptURL); err != nil {
+		log.Printf("Failed to link e-ticket receipt for booking %s: %v\n", booking.ID, err)
+	}
+	return tickets, nil
+}
+
+// settleCoupons closes the open coupons of a cancelled booking: tickets issued within the void
+// window are voided, later ones are refunded.
+func (h *FlightService) settleCoupons(booking *models.FlightBooking) {
+	if booking.Status != models.FlightBookingConfirmed {
+		return
+	}
+	tickets, err := h.tickets.GetTicketsByBookingID(booking.ID)
+	if err != nil || len(tickets) == 0 {
+		log.Printf("Failed to load tickets of booking %s: %v\n", booking.ID, err)
+		return
+	}
+
+	status := models.CouponRefunded
+	if time.Since(tickets[len(tickets)-1].IssuedAt) < voidWindow {
+		status = models.CouponVoid
+	}
+	if err := h.tickets.UpdateBookingCoupons(booking.ID, models.CouponOpen, status); err != nil {
+		log.Printf("Failed to settle coupons of booking %s: %v\n", booking.ID, err)
+	}
+}
+
+// reissueTickets replaces the tickets of a changed booking: the open coupons of the old flights
+// are voided and new tickets are issued against the same payment.
+func (h *FlightService) reissueTickets(booking *models.FlightBooking) error {
+	tickets, err := h.tickets.GetTicketsByBookingID(booking.ID)
+	if err != nil {
+		return err
+	}
+	if len(tickets) == 0 {
+		return nil
+	}
+	if err := h.tickets.UpdateBookingCoupons(booking.ID, models.CouponOpen, models.CouponVoid); err != nil {
+		return err
+	}
+	_, err = h.issueTickets(booking, tickets[len(tickets)-1].PaymentReference)
+	return err
+}
+
+func (h *FlightService) storeReceipt(booking *models.FlightBooking, pnr *models.PNR, tickets []models.Ticket) (string, error) {
+	var receipt bytes.Buffer
+	if err := renderReceipt(&receipt, booking, pnr, tickets); err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("tickets/%s/%s-%d.html", pnr.RecordLocator, booking.ID, tickets[0].IssuedAt.Unix())
+	return h.documents.UploadDocument(&receipt, fileName, "text/html; charset=utf-8")
+}
+
+// passengerFare returns what one passenger of the given type paid for the itinerary.
+func passengerFare(price models.OfferPrice, passengerType models.PassengerType) float64 {
+	for _, perTraveler := range price.PerTraveler {
+		if perTraveler.PassengerType == passengerType {
+			return perTraveler.Total
+		}
+	}
+	return 0
+}
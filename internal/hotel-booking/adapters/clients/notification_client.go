@@ -0,0 +1,76 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// serviceName identifies the hotel booking service in the tokens it sends to other services.
+const serviceName = "hotel-booking"
+
+// NotificationClient emails guests through the notification endpoint of the user service.
+type NotificationClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewNotificationClient(baseURL string) *NotificationClient {
+	return &NotificationClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type notificationRequest struct {
+	ID       string            `json:"id"`
+	Source   string            `json:"source"`
+	UserID   string            `json:"user_id"`
+	Template string            `json:"template"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+func (c *NotificationClient) Notify(notification models.Notification) error {
+	payload, err := json.Marshal(notificationRequest{
+		ID:       notification.ID,
+		Source:   serviceName,
+		UserID:   notification.UserID,
+		Template: notification.Template,
+		Data:     notification.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/notifications", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	token, err := middleware.GenerateJWT(serviceName)
+	if err != nil {
+		return fmt.Errorf("error generating service token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", notification.ID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending notification %s: %v", notification.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return fmt.Errorf("%w: user service responded %d: %s", models.ErrNotificationRejected, resp.StatusCode, strings.TrimSpace(string(message)))
+		}
+		return fmt.Errorf("error sending notification %s: user service responded %d: %s", notification.ID, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"microservices-travel-backend/internal/hotel-booking/domain/ports"
+	"microservices-travel-backend/pkg/httputil"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type BookingHandler struct {
+	service ports.BookingService
+}
+
+func NewBookingHandler(service ports.BookingService) *BookingHandler {
+	return &BookingHandler{service: service}
+}
+
+// RegisterRoutes registers the hotel booking endpoints. Reads return the version of the booking as
+// its ETag, and writes must send it back in If-Match.
+func (h *BookingHandler) RegisterRoutes(router *mux.Router) {
+	bookingRouter := router.PathPrefix("/hotels/bookings").Subrouter()
+	bookingRouter.Use(middleware.JWTMiddleware)
+	bookingRouter.HandleFunc("/{id}", h.GetBookingByID).Methods(http.MethodGet)
+	bookingRouter.HandleFunc("/{id}", h.UpdateBookingStatus).Methods(http.MethodPatch)
+}
+
+func (h *BookingHandler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := h.service.GetBookingByID(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	httputil.SetETag(w, booking.Version)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// UpdateBookingStatus changes the status of the version of a booking named by If-Match, e.g.
+// PATCH /hotels/bookings/{id} {"status":"cancelled_by_guest"}. An outdated version gets 412 with
+// the current booking.
+func (h *BookingHandler) UpdateBookingStatus(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	version, ok := httputil.RequireVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var update struct {
+		Status models.BookingStatus `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.Status == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	booking, err := h.service.UpdateBookingStatus(requester, id, update.Status, version)
+	if errors.Is(err, models.ErrVersionConflict) {
+		h.writeConflict(w, requester, id)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	httputil.SetETag(w, booking.Version)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// requireRequester tells who made a request, answering 401 when its token names nobody: other
+// services, agents and admins are privileged; guests are their subject.
+func requireRequester(w http.ResponseWriter, r *http.Request) (models.Requester, bool) {
+	caller, ok := middleware.RequireCaller(w, r)
+	return models.Requester{UserID: caller.UserID, Privileged: caller.IsService() || caller.IsStaff()}, ok
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrBookingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeConflict answers a write made against an outdated version with 412 and the booking as it
+// is now.
+func (h *BookingHandler) writeConflict(w http.ResponseWriter, requester models.Requester, id string) {
+	current, err := h.service.GetBookingByID(requester, id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+	httputil.WriteConflict(w, current.Version, current)
+}
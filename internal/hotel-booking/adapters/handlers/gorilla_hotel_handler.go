@@ -32,7 +32,7 @@ func (h *HotelHandler) RegisterRoutes(router *mux.Router) {
 
 	// // Booking related routes
 	// hotelRouter.HandleFunc("/bookings", h.CreateBookingHandler).Methods(http.MethodPost)
-	// hotelRouter.HandleFunc("/bookings/{id}", h.UpdateBookingStatusHandler).Methods(http.MethodPatch)
+	// Reading and changing single bookings is served by BookingHandler.
 	// hotelRouter.HandleFunc("/bookings/{id}/cancel", h.CancelBookingHandler).Methods(http.MethodPost)
 	// hotelRouter.HandleFunc("/availability", h.GetHotelAvailabilityHandler).Methods(http.MethodGet)
 }
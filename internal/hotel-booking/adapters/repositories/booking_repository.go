@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *PostgresBookingRepository) GetBookingByID(id string) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.DB.First(&booking, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrBookingNotFound
+		}
+		return nil, fmt.Errorf("error fetching booking: %v", err)
+	}
+	return &booking, nil
+}
+
+func (r *PostgresBookingRepository) UpdateBookingStatus(id string, status models.BookingStatus, version int) (*models.Booking, error) {
+	if err := r.updateBooking(id, map[string]interface{}{"status": status}, version); err != nil {
+		return nil, err
+	}
+	return r.GetBookingByID(id)
+}
+
+// UpdateBooking stores the non-zero fields of a booking, if it is still at the given version.
+func (r *PostgresBookingRepository) UpdateBooking(booking *models.Booking, version int) (*models.Booking, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND version = ?", booking.ID, version).
+			Omit("id", "version", "created_at").
+			Updates(booking)
+		if result.Error != nil {
+			return fmt.Errorf("error updating booking: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return r.missedVersion(booking.ID)
+		}
+		// The row stays locked by the update above until the transaction commits.
+		bump := tx.Model(&models.Booking{}).Where("id = ?", booking.ID).UpdateColumn("version", gorm.Expr("version + 1"))
+		if bump.Error != nil {
+			return fmt.Errorf("error updating booking: %v", bump.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetBookingByID(booking.ID)
+}
+
+func (r *PostgresBookingRepository) GetUnpaidBookings(before time.Time, limit int) ([]models.Booking, error) {
+	return r.findBookings(limit, "updated_at",
+		"status = ? AND updated_at < ?", models.StatusAwaitingPayment, before)
+}
+
+func (r *PostgresBookingRepository) GetMissedBookings(before time.Time, limit int) ([]models.Booking, error) {
+	return r.findBookings(limit, "end_date",
+		"status IN ? AND checkin_date IS NULL AND end_date < ?", models.UpcomingStatuses, before)
+}
+
+func (r *PostgresBookingRepository) GetCheckedOutBookings(before time.Time, limit int) ([]models.Booking, error) {
+	return r.findBookings(limit, "COALESCE(checkout_date, end_date)",
+		"status = ? AND COALESCE(checkout_date, end_date) < ?", models.StatusCheckedOut, before)
+}
+
+func (r *PostgresBookingRepository) GetBookingsToRemind(from time.Time, until time.Time, limit int) ([]models.Booking, error) {
+	return r.findBookings(limit, "start_date",
+		"status IN ? AND reminder_sent_at IS NULL AND start_date >= ? AND start_date < ?", models.UpcomingStatuses, from, until)
+}
+
+// findBookings returns at most limit bookings matching the condition, ordered by the given column.
+func (r *PostgresBookingRepository) findBookings(limit int, order string, condition string, args ...interface{}) ([]models.Booking, error) {
+	var bookings []models.Booking
+	if err := r.DB.Where(condition, args...).Order(order).Limit(limit).Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("error fetching bookings: %v", err)
+	}
+	return bookings, nil
+}
+
+// updateBooking sets the given columns on a version of a booking and increments its version.
+func (r *PostgresBookingRepository) updateBooking(id string, changes map[string]interface{}, version int) error {
+	changes["version"] = gorm.Expr("version + 1")
+	result := r.DB.Model(&models.Booking{}).Where("id = ? AND version = ?", id, version).Updates(changes)
+	if result.Error != nil {
+		return fmt.Errorf("error updating booking: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.missedVersion(id)
+	}
+	return nil
+}
+
+// missedVersion explains why a conditional update matched no booking.
+func (r *PostgresBookingRepository) missedVersion(id string) error {
+	if _, err := r.GetBookingByID(id); err != nil {
+		return err
+	}
+	return models.ErrVersionConflict
+}
@@ -29,10 +29,17 @@ const (
 	StatusDisputed                BookingStatus = "disputed"
 )
 
+// AnyVersion applies a change to whichever version of a booking is current, for If-Match: *.
+const AnyVersion = 0
+
+// UpcomingStatuses are those of bookings whose guests are still expected to arrive.
+var UpcomingStatuses = []BookingStatus{StatusConfirmed, StatusPaymentReceived}
+
 // Booking Model
 type Booking struct {
 	ID                      string        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	HotelID                 string        `json:"hotel_id"`
+	UserID                  string        `json:"user_id"` // Guest who made the booking, as known to the user service.
 	RoomID                  *string       `json:"room_id"`
 	StartDate               time.Time     `json:"start_date"`
 	EndDate                 time.Time     `json:"end_date"`
@@ -51,4 +58,6 @@ type Booking struct {
 	ExternalSyncAttempts    int           `json:"external_sync_attempts"`
 	ExternalSyncLastAttempt *time.Time    `json:"external_sync_last_attempt"`
 	ExternalSyncError       *string       `json:"external_sync_error"`
+	ReminderSentAt          *time.Time    `json:"reminder_sent_at,omitempty"`        // When the guest was reminded of the stay.
+	Version                 int           `gorm:"not null;default:1" json:"version"` // Incremented by every update, see BookingDB.
 }
@@ -0,0 +1,15 @@
+package models
+
+import "errors"
+
+var (
+	// ErrBookingNotFound is returned when no booking has the requested ID.
+	ErrBookingNotFound = errors.New("booking not found")
+	// ErrVersionConflict is returned when a booking was updated since the version a change was based on.
+	ErrVersionConflict = errors.New("booking was changed by someone else")
+	// ErrForbidden is returned when a guest makes a change only hotel staff and services may make.
+	ErrForbidden = errors.New("not allowed to change this booking")
+	// ErrNotificationRejected is returned when the user service refuses a notification, e.g. for an
+	// unknown user; sending it again will not help.
+	ErrNotificationRejected = errors.New("notification rejected")
+)
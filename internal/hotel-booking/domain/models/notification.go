@@ -0,0 +1,13 @@
+package models
+
+// TemplateTripReminder is the email reminding a guest of an upcoming stay.
+const TemplateTripReminder = "trip_reminder"
+
+// Notification asks the user service to email a guest. Notifications are recognised by their ID, so
+// one sent again after a failure reaches the guest once.
+type Notification struct {
+	ID       string
+	UserID   string
+	Template string
+	Data     map[string]string // Values the template fills in.
+}
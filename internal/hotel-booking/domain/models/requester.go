@@ -0,0 +1,9 @@
+package models
+
+// Requester is who a request was made by, as told by its JWT.
+type Requester struct {
+	UserID string // Subject of an end-user token.
+	// Privileged requesters, such as other services, agents and admins, may see and change every
+	// guest's bookings.
+	Privileged bool
+}
@@ -0,0 +1,26 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"time"
+)
+
+// BookingDB stores hotel bookings. Updates only apply to the given version of a booking, failing
+// with models.ErrVersionConflict when it has moved on, and increment its version.
+type BookingDB interface {
+	GetBookingByID(id string) (*models.Booking, error)
+	UpdateBookingStatus(id string, status models.BookingStatus, version int) (*models.Booking, error)
+	UpdateBooking(booking *models.Booking, version int) (*models.Booking, error)
+
+	// The queries of the lifecycle jobs return at most limit bookings, oldest first.
+
+	// GetUnpaidBookings returns the bookings awaiting payment since before before.
+	GetUnpaidBookings(before time.Time, limit int) ([]models.Booking, error)
+	// GetMissedBookings returns the upcoming bookings that ended before before without a check-in.
+	GetMissedBookings(before time.Time, limit int) ([]models.Booking, error)
+	// GetCheckedOutBookings returns the checked out bookings whose guests left before before.
+	GetCheckedOutBookings(before time.Time, limit int) ([]models.Booking, error)
+	// GetBookingsToRemind returns the upcoming bookings starting between from and until whose guests
+	// were not reminded yet.
+	GetBookingsToRemind(from time.Time, until time.Time, limit int) ([]models.Booking, error)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "microservices-travel-backend/internal/hotel-booking/domain/models"
+
+// BookingService reads and changes hotel bookings on behalf of a requester. Guests only ever see
+// their own bookings; the bookings of others are reported as not found.
+type BookingService interface {
+	GetBookingByID(requester models.Requester, id string) (*models.Booking, error)
+	// UpdateBookingStatus changes the status of a version of a booking. Guests may only cancel.
+	UpdateBookingStatus(requester models.Requester, id string, status models.BookingStatus, version int) (*models.Booking, error)
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "microservices-travel-backend/internal/hotel-booking/domain/models"
+
+// Notifications emails guests through the user service. Errors wrapping
+// models.ErrNotificationRejected mean the notification will never be taken; any other error may be
+// retried.
+type Notifications interface {
+	Notify(notification models.Notification) error
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"microservices-travel-backend/internal/hotel-booking/domain/ports"
+	"time"
+)
+
+// LifecyclePolicy sets when bookings move on by themselves.
+type LifecyclePolicy struct {
+	PaymentWindow time.Duration // How long a booking awaits payment before it expires.
+	ReviewDelay   time.Duration // Wait after check-out before the guest is asked for a review.
+	ReminderLead  time.Duration // How long before the start of a stay the guest is reminded of it.
+	BatchSize     int           // Most bookings a job handles per run.
+}
+
+func DefaultLifecyclePolicy() LifecyclePolicy {
+	return LifecyclePolicy{
+		PaymentWindow: 24 * time.Hour,
+		ReviewDelay:   24 * time.Hour,
+		ReminderLead:  72 * time.Hour,
+		BatchSize:     100,
+	}
+}
+
+// BookingLifecycle holds the jobs moving bookings on with time and reminding guests of their stays.
+// A booking changed by someone else while a job runs is left for the next run, which only picks it
+// up if it is still due.
+type BookingLifecycle struct {
+	db            ports.BookingDB
+	notifications ports.Notifications
+	policy        LifecyclePolicy
+}
+
+func NewBookingLifecycle(db ports.BookingDB, notifications ports.Notifications, policy LifecyclePolicy) *BookingLifecycle {
+	return &BookingLifecycle{db: db, notifications: notifications, policy: policy}
+}
+
+// ExpireUnpaid expires the bookings that were not paid within the payment window.
+func (l *BookingLifecycle) ExpireUnpaid(now time.Time) error {
+	bookings, err := l.db.GetUnpaidBookings(now.Add(-l.policy.PaymentWindow), l.policy.BatchSize)
+	if err != nil {
+		return err
+	}
+	return l.transition(bookings, models.StatusExpired)
+}
+
+// MarkNoShows marks the upcoming bookings that ended without a check-in as no-shows.
+func (l *BookingLifecycle) MarkNoShows(now time.Time) error {
+	bookings, err := l.db.GetMissedBookings(now, l.policy.BatchSize)
+	if err != nil {
+		return err
+	}
+	return l.transition(bookings, models.StatusNoShow)
+}
+
+// RequestReviews moves checked out bookings on to be reviewed once the review delay has passed.
+func (l *BookingLifecycle) RequestReviews(now time.Time) error {
+	bookings, err := l.db.GetCheckedOutBookings(now.Add(-l.policy.ReviewDelay), l.policy.BatchSize)
+	if err != nil {
+		return err
+	}
+	return l.transition(bookings, models.StatusPendingReview)
+}
+
+// SendReminders emails the guests whose stays start within the reminder lead time. The reminder of
+// a booking keeps its ID, so the user service sends it once even when recording it here fails.
+func (l *BookingLifecycle) SendReminders(now time.Time) error {
+	// Stays start on a date, so those starting today are still ahead.
+	today := now.UTC().Truncate(24 * time.Hour)
+	bookings, err := l.db.GetBookingsToRemind(today, now.Add(l.policy.ReminderLead), l.policy.BatchSize)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, booking := range bookings {
+		if booking.UserID == "" {
+			log.Printf("Booking %s names no user to remind\n", booking.ID)
+		} else {
+			err := l.notifications.Notify(models.Notification{
+				ID:       models.TemplateTripReminder + ":" + booking.ID,
+				UserID:   booking.UserID,
+				Template: models.TemplateTripReminder,
+				Data: map[string]string{
+					"bookingID":  booking.ID,
+					"travelDate": booking.StartDate.Format("2006-01-02"),
+				},
+			})
+			if errors.Is(err, models.ErrNotificationRejected) {
+				log.Printf("Reminder of booking %s was refused: %v\n", booking.ID, err)
+			} else if err != nil {
+				errs = append(errs, fmt.Errorf("error reminding guest of booking %s: %v", booking.ID, err))
+				continue
+			}
+		}
+
+		sentAt := now
+		_, err := l.db.UpdateBooking(&models.Booking{ID: booking.ID, ReminderSentAt: &sentAt}, booking.Version)
+		if err != nil && !errors.Is(err, models.ErrVersionConflict) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transition moves the bookings to status, skipping those changed since they were loaded.
+func (l *BookingLifecycle) transition(bookings []models.Booking, status models.BookingStatus) error {
+	var errs []error
+	for _, booking := range bookings {
+		_, err := l.db.UpdateBookingStatus(booking.ID, status, booking.Version)
+		switch {
+		case err == nil:
+			log.Printf("Booking %s moved from %s to %s\n", booking.ID, booking.Status, status)
+		case errors.Is(err, models.ErrVersionConflict), errors.Is(err, models.ErrBookingNotFound):
+		default:
+			errs = append(errs, fmt.Errorf("error moving booking %s to %s: %v", booking.ID, status, err))
+		}
+	}
+	return errors.Join(errs...)
+}
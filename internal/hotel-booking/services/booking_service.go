@@ -0,0 +1,42 @@
+package services
+
+import (
+	"microservices-travel-backend/internal/hotel-booking/domain/models"
+	"microservices-travel-backend/internal/hotel-booking/domain/ports"
+)
+
+type BookingService struct {
+	db ports.BookingDB
+}
+
+func NewBookingService(db ports.BookingDB) *BookingService {
+	return &BookingService{db: db}
+}
+
+// GetBookingByID returns a booking to privileged requesters and the guest who made it.
+func (s *BookingService) GetBookingByID(requester models.Requester, id string) (*models.Booking, error) {
+	booking, err := s.db.GetBookingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && booking.UserID != requester.UserID {
+		return nil, models.ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+// UpdateBookingStatus changes the status of a version of a booking, or of the current one for
+// models.AnyVersion. Guests may cancel their bookings; other changes are left to staff and services.
+func (s *BookingService) UpdateBookingStatus(requester models.Requester, id string, status models.BookingStatus, version int) (*models.Booking, error) {
+	current, err := s.GetBookingByID(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged && status != models.StatusCancelledByGuest {
+		return nil, models.ErrForbidden
+	}
+	if version == models.AnyVersion {
+		version = current.Version
+	}
+	return s.db.UpdateBookingStatus(id, status, version)
+}
@@ -0,0 +1,134 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// serviceName identifies the payment service in the tokens it sends to other services.
+const serviceName = "payment-service"
+
+// transitionAttempts bounds how often a transition is retried when the booking keeps changing
+// between reading and updating it.
+const transitionAttempts = 3
+
+// BookingClient changes the status of bookings through the booking service.
+type BookingClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewBookingClient(baseURL string) *BookingClient {
+	return &BookingClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type booking struct {
+	BookingStatus string `json:"bookingStatus"`
+}
+
+type statusRequest struct {
+	BookingStatus string `json:"bookingStatus"`
+	Reason        string `json:"reason"`
+}
+
+// TransitionBooking reads the booking and updates its status conditionally on the version read,
+// so a booking that moved on in between, e.g. because it was confirmed, is not overwritten.
+func (c *BookingClient) TransitionBooking(bookingID string, from []string, status string, reason string) error {
+	path := "/bookings/" + url.PathEscape(bookingID)
+	for attempt := 1; ; attempt++ {
+		var current booking
+		resp, err := c.send(http.MethodGet, path, "", nil)
+		if err != nil {
+			return err
+		}
+		etag := resp.Header.Get("ETag")
+		err = decode(resp, &current)
+		if err != nil {
+			return err
+		}
+		if !oneOf(current.BookingStatus, from) {
+			return nil
+		}
+
+		resp, err = c.send(http.MethodPatch, "/bookings/status/"+url.PathEscape(bookingID), etag, statusRequest{BookingStatus: status, Reason: reason})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusPreconditionFailed && attempt < transitionAttempts {
+			resp.Body.Close()
+			continue
+		}
+		return decode(resp, nil)
+	}
+}
+
+func (c *BookingClient) send(method string, path string, ifMatch string, body interface{}) (*http.Response, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding request: %v", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	token, err := middleware.GenerateJWT(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("error generating service token: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s %s: %v", method, path, err)
+	}
+	return resp, nil
+}
+
+// decode reads a response into out when it is not nil, turning error responses into errors.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return models.ErrBookingNotFound
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s responded %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("error decoding response of %s %s: %v", resp.Request.Method, resp.Request.URL.Path, err)
+		}
+	}
+	return nil
+}
+
+func oneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package gateways
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test payment methods steering the outcome of mock authorizations and refunds; every other
+// payment method is authorized right away and refunded after the settle delay.
+const (
+	MockCardDeclined          = "pm_card_declined"
+	MockCardInsufficientFunds = "pm_card_insufficient_funds"
+	MockCard3DS               = "pm_card_3ds"              // Authorized once the 3-D Secure challenge is passed.
+	MockCardDelayed           = "pm_card_delayed"          // Authorized after the settle delay.
+	MockCardDelayedDeclined   = "pm_card_delayed_declined" // Declined after the settle delay.
+	MockCardRefundFails       = "pm_card_refund_fails"     // Authorized right away, but its refunds fail.
+)
+
+// webhookAttempts is how often the mock gateway delivers a webhook before giving up.
+const webhookAttempts = 3
+
+var errUnknownReference = errors.New("unknown gateway reference")
+
+type MockGatewayOptions struct {
+	// WebhookURL receives the outcome of challenged and delayed authorizations.
+	WebhookURL    string
+	WebhookSecret string
+	// ChallengeBaseURL is where the challenge pages of the mock gateway are served, see CompleteChallenge.
+	ChallengeBaseURL string
+	// Latency is how long every call to the gateway takes.
+	Latency time.Duration
+	// SettleDelay is how long delayed authorizations and refunds take to report their outcome.
+	SettleDelay time.Duration
+}
+
+// MockGateway is an in-memory payment gateway for development and tests. It simulates declines,
+// 3-D Secure challenges, delayed authorizations and failing refunds, chosen by the test payment
+// methods, and reports their outcome through signed webhooks like a real gateway.
+type MockGateway struct {
+	options  MockGatewayOptions
+	http     *http.Client
+	mu       sync.Mutex
+	payments map[string]*mockPayment
+	refunds  map[string]*mockRefund
+}
+
+// mockPayment is the state of a payment at the mock gateway.
+type mockPayment struct {
+	status        models.PaymentStatus
+	paymentMethod string
+	amount        float64
+	currency      string
+	refunded      float64     // Refunded or being refunded.
+	settle        *time.Timer // Reports a delayed authorization.
+}
+
+// mockRefund is the state of a refund at the mock gateway.
+type mockRefund struct {
+	reference     string
+	status        models.RefundStatus
+	failureReason string
+}
+
+func NewMockGateway(options MockGatewayOptions) *MockGateway {
+	return &MockGateway{
+		options:  options,
+		http:     &http.Client{Timeout: 10 * time.Second},
+		payments: map[string]*mockPayment{},
+		refunds:  map[string]*mockRefund{},
+	}
+}
+
+func (g *MockGateway) Authorize(payment *models.Payment) (*models.GatewayResult, error) {
+	time.Sleep(g.options.Latency)
+	reference := "mock_" + uuid.NewString()
+	result := &models.GatewayResult{Reference: reference, Status: models.StatusAuthorized}
+	switch payment.PaymentMethod {
+	case MockCardDeclined:
+		result.Status, result.DeclineReason = models.StatusDeclined, "card_declined"
+	case MockCardInsufficientFunds:
+		result.Status, result.DeclineReason = models.StatusDeclined, "insufficient_funds"
+	case MockCard3DS:
+		result.Status = models.StatusRequiresAction
+		result.ChallengeURL = strings.TrimSuffix(g.options.ChallengeBaseURL, "/") + "/mock-gateway/challenges/" + reference
+	case MockCardDelayed, MockCardDelayedDeclined:
+		result.Status = models.StatusProcessing
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stored := &mockPayment{status: result.Status, paymentMethod: payment.PaymentMethod, amount: payment.Amount, currency: payment.Currency}
+	g.payments[reference] = stored
+	if result.Status == models.StatusProcessing {
+		succeeds := payment.PaymentMethod == MockCardDelayed
+		stored.settle = time.AfterFunc(g.options.SettleDelay, func() {
+			g.completeAuthorization(reference, succeeds, "card_declined")
+		})
+	}
+	return result, nil
+}
+
+func (g *MockGateway) Capture(reference string, amount float64, currency string) (*models.GatewayResult, error) {
+	time.Sleep(g.options.Latency)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	payment, ok := g.payments[reference]
+	switch {
+	case !ok:
+		return nil, errUnknownReference
+	case payment.status != models.StatusAuthorized:
+		return nil, fmt.Errorf("cannot capture a payment that is %s", payment.status)
+	case currency != payment.currency || amount > payment.amount:
+		return nil, fmt.Errorf("cannot capture %.2f %s of an authorization of %.2f %s", amount, currency, payment.amount, payment.currency)
+	}
+	payment.status = models.StatusCaptured
+	return &models.GatewayResult{Reference: reference, Status: models.StatusCaptured}, nil
+}
+
+func (g *MockGateway) Void(reference string) (*models.GatewayResult, error) {
+	time.Sleep(g.options.Latency)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, errUnknownReference
+	}
+	switch payment.status {
+	case models.StatusAuthorized, models.StatusRequiresAction, models.StatusProcessing:
+	default:
+		return nil, fmt.Errorf("cannot void a payment that is %s", payment.status)
+	}
+	if payment.settle != nil {
+		payment.settle.Stop()
+	}
+	payment.status = models.StatusVoided
+	return &models.GatewayResult{Reference: reference, Status: models.StatusVoided}, nil
+}
+
+// Refund pays back part of a captured payment after the settle delay. Refunds of payments with
+// MockCardRefundFails fail instead, and give the money back to what is left to refund.
+func (g *MockGateway) Refund(reference string, refundID string, amount float64, currency string) (string, error) {
+	time.Sleep(g.options.Latency)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if refund, ok := g.refunds[refundID]; ok {
+		return refund.reference, nil
+	}
+	payment, ok := g.payments[reference]
+	switch {
+	case !ok:
+		return "", errUnknownReference
+	case payment.status != models.StatusCaptured:
+		return "", fmt.Errorf("cannot refund a payment that is %s", payment.status)
+	case currency != payment.currency || amount <= 0 || payment.refunded+amount > payment.amount+0.005:
+		return "", fmt.Errorf("cannot refund %.2f %s of a payment of %.2f %s with %.2f refunded", amount, currency, payment.amount, payment.currency, payment.refunded)
+	}
+	payment.refunded += amount
+	refund := &mockRefund{reference: "mock_re_" + uuid.NewString(), status: models.RefundPending}
+	g.refunds[refundID] = refund
+	succeeds := payment.paymentMethod != MockCardRefundFails
+	time.AfterFunc(g.options.SettleDelay, func() {
+		g.completeRefund(payment, refund, amount, succeeds)
+	})
+	return refund.reference, nil
+}
+
+func (g *MockGateway) RefundStatus(reference string) (models.RefundStatus, string, error) {
+	time.Sleep(g.options.Latency)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, refund := range g.refunds {
+		if refund.reference == reference {
+			return refund.status, refund.failureReason, nil
+		}
+	}
+	return "", "", errUnknownReference
+}
+
+func (g *MockGateway) ParseWebhook(payload []byte, signature string) (*models.GatewayEvent, error) {
+	if err := verifyWebhook(g.options.WebhookSecret, payload, signature, time.Now()); err != nil {
+		return nil, err
+	}
+	var event models.GatewayEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.Reference == "" {
+		return nil, fmt.Errorf("%w: malformed event", models.ErrInvalidSignature)
+	}
+	return &event, nil
+}
+
+// CompleteChallenge plays the customer answering the 3-D Secure challenge of an authorization,
+// which the gateway then reports through a webhook.
+func (g *MockGateway) CompleteChallenge(reference string, passed bool) error {
+	g.mu.Lock()
+	payment, ok := g.payments[reference]
+	open := ok && payment.status == models.StatusRequiresAction
+	g.mu.Unlock()
+	switch {
+	case !ok:
+		return errUnknownReference
+	case !open:
+		return fmt.Errorf("payment %s has no open challenge", reference)
+	}
+	g.completeAuthorization(reference, passed, "authentication_failed")
+	return nil
+}
+
+// completeAuthorization settles a challenged or delayed authorization, unless it was voided in the
+// meantime, and sends its webhook.
+func (g *MockGateway) completeAuthorization(reference string, succeeded bool, declineReason string) {
+	event := models.GatewayEvent{
+		ID:        "evt_" + uuid.NewString(),
+		Type:      models.GatewayAuthorizationSucceeded,
+		Reference: reference,
+		CreatedAt: time.Now().UTC(),
+	}
+	g.mu.Lock()
+	payment := g.payments[reference]
+	if payment.status != models.StatusRequiresAction && payment.status != models.StatusProcessing {
+		g.mu.Unlock()
+		return
+	}
+	payment.status = models.StatusAuthorized
+	if !succeeded {
+		payment.status = models.StatusDeclined
+		event.Type, event.DeclineReason = models.GatewayAuthorizationFailed, declineReason
+	}
+	g.mu.Unlock()
+
+	go g.sendWebhook(event)
+}
+
+// completeRefund settles a refund and sends its webhook.
+func (g *MockGateway) completeRefund(payment *mockPayment, refund *mockRefund, amount float64, succeeded bool) {
+	event := models.GatewayEvent{
+		ID:        "evt_" + uuid.NewString(),
+		Type:      models.GatewayRefundSucceeded,
+		Reference: refund.reference,
+		CreatedAt: time.Now().UTC(),
+	}
+	g.mu.Lock()
+	refund.status = models.RefundSucceeded
+	if !succeeded {
+		payment.refunded -= amount
+		refund.status, refund.failureReason = models.RefundFailed, "refund_declined_by_issuer"
+		event.Type, event.DeclineReason = models.GatewayRefundFailed, refund.failureReason
+	}
+	g.mu.Unlock()
+
+	g.sendWebhook(event)
+}
+
+// sendWebhook delivers an event to the webhook URL, retrying with a growing pause until it is accepted.
+func (g *MockGateway) sendWebhook(event models.GatewayEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Mock gateway failed to encode webhook %s: %v\n", event.ID, err)
+		return
+	}
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = g.deliver(payload)
+		if err == nil {
+			return
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	log.Printf("Mock gateway gave up delivering webhook %s: %v\n", event.ID, err)
+}
+
+func (g *MockGateway) deliver(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, g.options.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, signWebhook(g.options.WebhookSecret, payload, time.Now()))
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package gateways
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the signature of a webhook, "t=<unix time>,v1=<hex HMAC>", where
+// the HMAC-SHA256 with the shared secret covers "<unix time>.<body>".
+const WebhookSignatureHeader = "Webhook-Signature"
+
+// webhookTolerance is how old a signature may be, so that captured webhooks cannot be replayed later.
+const webhookTolerance = 5 * time.Minute
+
+// signWebhook signs a webhook body sent at the given time.
+func signWebhook(secret string, payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, webhookMAC(secret, timestamp, payload))
+}
+
+// verifyWebhook checks that a webhook body was signed with the secret within webhookTolerance of now.
+func verifyWebhook(secret string, payload []byte, signature string, now time.Time) error {
+	var timestamp string
+	var macs []string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			macs = append(macs, value)
+		}
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(macs) == 0 {
+		return fmt.Errorf("%w: malformed %s header", models.ErrInvalidSignature, WebhookSignatureHeader)
+	}
+	if age := now.Sub(time.Unix(sent, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return fmt.Errorf("%w: signed too long ago", models.ErrInvalidSignature)
+	}
+
+	expected := webhookMAC(secret, timestamp, payload)
+	for _, mac := range macs {
+		if hmac.Equal([]byte(mac), []byte(expected)) {
+			return nil
+		}
+	}
+	return models.ErrInvalidSignature
+}
+
+func webhookMAC(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package gateways
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":"evt-1","type":"authorization.succeeded","reference":"pay-1"}`)
+	signed := signWebhook("secret", payload, now)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		at        time.Duration // Since the webhook was signed.
+		wantErr   bool
+	}{
+		{name: "signed with the secret", payload: payload, signature: signed},
+		{name: "received a little later", payload: payload, signature: signed, at: 4 * time.Minute},
+		{name: "one of several signatures matches", payload: payload,
+			signature: "t=" + timestamp + ",v1=" + webhookMAC("old-secret", timestamp, payload) + ",v1=" + webhookMAC("secret", timestamp, payload)},
+		{name: "a changed body", payload: []byte(`{"id":"evt-1","type":"authorization.succeeded","reference":"pay-2"}`),
+			signature: signed, wantErr: true},
+		{name: "signed with another secret", payload: payload, signature: signWebhook("other", payload, now), wantErr: true},
+		{name: "replayed after the tolerance", payload: payload, signature: signed, at: webhookTolerance + time.Second, wantErr: true},
+		{name: "dated in the future", payload: payload, signature: signWebhook("secret", payload, now.Add(time.Hour)), wantErr: true},
+		{name: "no signature", payload: payload, wantErr: true},
+		{name: "no timestamp", payload: payload, signature: "v1=" + webhookMAC("secret", "", payload), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyWebhook("secret", tt.payload, tt.signature, now.Add(tt.at))
+			if tt.wantErr && !errors.Is(err, models.ErrInvalidSignature) {
+				t.Errorf("verifyWebhook: got %v, want %v", err, models.ErrInvalidSignature)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("verifyWebhook: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	gateway := NewMockGateway(MockGatewayOptions{WebhookSecret: "secret"})
+	payload := []byte(`{"id":"evt-1","type":"authorization.succeeded","reference":"pay-1"}`)
+
+	event, err := gateway.ParseWebhook(payload, signWebhook("secret", payload, time.Now()))
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.ID != "evt-1" || event.Reference != "pay-1" {
+		t.Errorf("event = %+v, want evt-1 about pay-1", event)
+	}
+
+	if _, err := gateway.ParseWebhook(payload, signWebhook("secret", payload, time.Now().Add(-time.Hour))); !errors.Is(err, models.ErrInvalidSignature) {
+		t.Errorf("ParseWebhook of a replayed webhook: got %v, want %v", err, models.ErrInvalidSignature)
+	}
+	unnamed := []byte(`{"type":"authorization.succeeded"}`)
+	if _, err := gateway.ParseWebhook(unnamed, signWebhook("secret", unnamed, time.Now())); !errors.Is(err, models.ErrInvalidSignature) {
+		t.Errorf("ParseWebhook of an event without ID: got %v, want %v", err, models.ErrInvalidSignature)
+	}
+}
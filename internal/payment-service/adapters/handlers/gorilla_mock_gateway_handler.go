@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"microservices-travel-backend/internal/payment-service/adapters/gateways"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MockGatewayHandler serves the pages of the mock gateway a customer would see, such as its
+// 3-D Secure challenge. It is only registered while payments go through the mock gateway.
+type MockGatewayHandler struct {
+	gateway *gateways.MockGateway
+}
+
+func NewMockGatewayHandler(gateway *gateways.MockGateway) *MockGatewayHandler {
+	return &MockGatewayHandler{gateway: gateway}
+}
+
+func (h *MockGatewayHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/mock-gateway/challenges/{reference}", h.CompleteChallenge).Methods(http.MethodPost)
+}
+
+// CompleteChallenge answers the 3-D Secure challenge of an authorization: it passes unless
+// result=fail is given. The outcome reaches the payment through the gateway's webhook.
+func (h *MockGatewayHandler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
+	reference := mux.Vars(r)["reference"]
+	passed := r.URL.Query().Get("result") != "fail"
+	if err := h.gateway.CompleteChallenge(reference, passed); err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(struct {
+		Reference string `json:"reference"`
+		Passed    bool   `json:"passed"`
+	}{reference, passed})
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/payment-service/adapters/gateways"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"microservices-travel-backend/internal/payment-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookSize bounds the body of a webhook that is read for verification.
+const maxWebhookSize = 1 << 20
+
+type PaymentHandler struct {
+	service ports.PaymentService
+}
+
+func NewPaymentHandler(service ports.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+func (h *PaymentHandler) RegisterRoutes(router *mux.Router) {
+	paymentRouter := router.PathPrefix("/payments").Subrouter()
+	paymentRouter.Use(middleware.JWTMiddleware)
+	paymentRouter.HandleFunc("", h.CreatePayment).Methods(http.MethodPost)
+	paymentRouter.HandleFunc("/{id}", h.GetPayment).Methods(http.MethodGet)
+	paymentRouter.HandleFunc("/{id}/events", h.GetPaymentEvents).Methods(http.MethodGet)
+	paymentRouter.HandleFunc("/{id}/authorize", h.AuthorizePayment).Methods(http.MethodPost)
+	paymentRouter.HandleFunc("/{id}/capture", h.CapturePayment).Methods(http.MethodPost)
+	paymentRouter.HandleFunc("/{id}/void", h.VoidPayment).Methods(http.MethodPost)
+
+	// The gateway signs its webhooks instead of sending a token.
+	router.HandleFunc("/webhooks/gateway", h.HandleWebhook).Methods(http.MethodPost)
+}
+
+// CreatePayment starts a payment; a declined one is answered with 402 and the payment, which can
+// be authorized again with another payment method.
+func (h *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request models.PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.service.CreatePayment(requester, request)
+	writePayment(w, payment, err, http.StatusCreated)
+}
+
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	payment, err := h.service.GetPayment(requester, mux.Vars(r)["id"])
+	writePayment(w, payment, err, http.StatusOK)
+}
+
+// GetPaymentEvents lists how a payment got to its status, oldest first.
+func (h *PaymentHandler) GetPaymentEvents(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	events, err := h.service.GetPaymentEvents(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(events)
+}
+
+// AuthorizePayment authorizes a pending or declined payment, optionally with another payment method.
+func (h *PaymentHandler) AuthorizePayment(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request struct {
+		PaymentMethod string `json:"payment_method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.service.AuthorizePayment(requester, mux.Vars(r)["id"], request.PaymentMethod)
+	writePayment(w, payment, err, http.StatusOK)
+}
+
+func (h *PaymentHandler) CapturePayment(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	payment, err := h.service.CapturePayment(requester, mux.Vars(r)["id"])
+	writePayment(w, payment, err, http.StatusOK)
+}
+
+func (h *PaymentHandler) VoidPayment(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	payment, err := h.service.VoidPayment(requester, mux.Vars(r)["id"])
+	writePayment(w, payment, err, http.StatusOK)
+}
+
+// HandleWebhook applies a signed webhook of the payment gateway. Errors make the gateway deliver
+// the webhook again, so webhooks that are merely outdated are acknowledged.
+func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, "Unable to read request body", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.HandleWebhook(payload, r.Header.Get(gateways.WebhookSignatureHeader)); err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePayment answers with a payment, or with the error of the request making it. Declined
+// payments are sent along with 402 so the client can see why.
+func writePayment(w http.ResponseWriter, payment *models.Payment, err error, status int) {
+	if errors.Is(err, models.ErrPaymentDeclined) && payment != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(payment)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// requireRequester tells who made a request from the claims of its JWT: other services and admins
+// are privileged; end users are their subject. Tokens naming nobody get 401.
+func requireRequester(w http.ResponseWriter, r *http.Request) (models.Requester, bool) {
+	caller, ok := middleware.RequireCaller(w, r)
+	admin := caller.HasRole(middleware.RoleAdmin)
+	return models.Requester{UserID: caller.UserID, Service: caller.Service, Privileged: caller.IsService() || admin, Admin: admin}, ok
+}
+
+// errorStatus maps the errors of the payment service to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrPaymentNotFound), errors.Is(err, models.ErrRefundNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidPayment), errors.Is(err, models.ErrInvalidRefund):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrPaymentDeclined):
+		return http.StatusPaymentRequired
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrInvalidTransition), errors.Is(err, models.ErrVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"microservices-travel-backend/internal/payment-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type RefundHandler struct {
+	service ports.RefundService
+}
+
+func NewRefundHandler(service ports.RefundService) *RefundHandler {
+	return &RefundHandler{service: service}
+}
+
+func (h *RefundHandler) RegisterRoutes(router *mux.Router) {
+	paymentRouter := router.PathPrefix("/payments/{id}/refunds").Subrouter()
+	paymentRouter.Use(middleware.JWTMiddleware)
+	paymentRouter.HandleFunc("", h.CreateRefund).Methods(http.MethodPost)
+	paymentRouter.HandleFunc("", h.GetRefunds).Methods(http.MethodGet)
+
+	refundRouter := router.PathPrefix("/refunds").Subrouter()
+	refundRouter.Use(middleware.JWTMiddleware)
+	refundRouter.HandleFunc("/{id}", h.GetRefund).Methods(http.MethodGet)
+	refundRouter.HandleFunc("/{id}/approve", h.ApproveRefund).Methods(http.MethodPost)
+	refundRouter.HandleFunc("/{id}/reject", h.RejectRefund).Methods(http.MethodPost)
+}
+
+// CreateRefund refunds a captured payment, in full unless the body names an amount. Refunds
+// waiting for approval are answered with 202.
+func (h *RefundHandler) CreateRefund(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.service.CreateRefund(requester, mux.Vars(r)["id"], request)
+	if err == nil && refund.Status == models.RefundPendingApproval {
+		writeRefund(w, refund, nil, http.StatusAccepted)
+		return
+	}
+	writeRefund(w, refund, err, http.StatusCreated)
+}
+
+// GetRefunds lists the refunds of a payment, oldest first.
+func (h *RefundHandler) GetRefunds(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	refunds, err := h.service.GetRefunds(requester, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(refunds)
+}
+
+func (h *RefundHandler) GetRefund(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	refund, err := h.service.GetRefund(requester, mux.Vars(r)["id"])
+	writeRefund(w, refund, err, http.StatusOK)
+}
+
+func (h *RefundHandler) ApproveRefund(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	refund, err := h.service.ApproveRefund(requester, mux.Vars(r)["id"])
+	writeRefund(w, refund, err, http.StatusOK)
+}
+
+// RejectRefund turns down a refund waiting for approval, optionally saying why.
+func (h *RefundHandler) RejectRefund(w http.ResponseWriter, r *http.Request) {
+	requester, ok := requireRequester(w, r)
+	if !ok {
+		return
+	}
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.service.RejectRefund(requester, mux.Vars(r)["id"], request.Reason)
+	writeRefund(w, refund, err, http.StatusOK)
+}
+
+func writeRefund(w http.ResponseWriter, refund *models.Refund, err error, status int) {
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(refund)
+}
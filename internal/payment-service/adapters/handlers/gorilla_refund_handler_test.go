@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"microservices-travel-backend/internal/payment-service/domain/ports"
+	"microservices-travel-backend/internal/payment-service/services"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// memoryPayments keeps payments and refunds in memory, without the version checks of the
+// PostgreSQL repository.
+type memoryPayments struct {
+	ports.PaymentDB
+	mu       sync.Mutex
+	payments map[string]models.Payment
+	refunds  map[string]models.Refund
+}
+
+func (m *memoryPayments) GetPaymentByID(id string) (*models.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	payment, ok := m.payments[id]
+	if !ok {
+		return nil, models.ErrPaymentNotFound
+	}
+	return &payment, nil
+}
+
+func (m *memoryPayments) CreateRefund(refund *models.Refund, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refunds[refund.ID] = *refund
+	m.payments[payment.ID] = *payment
+	return nil
+}
+
+func (m *memoryPayments) GetRefundByID(id string) (*models.Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	refund, ok := m.refunds[id]
+	if !ok {
+		return nil, models.ErrRefundNotFound
+	}
+	return &refund, nil
+}
+
+func (m *memoryPayments) UpdateRefund(refund *models.Refund, version int, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refunds[refund.ID] = *refund
+	if payment != nil {
+		m.payments[payment.ID] = *payment
+	}
+	return nil
+}
+
+func (m *memoryPayments) GetRefundByGatewayReference(string) (*models.Refund, error) {
+	return nil, models.ErrRefundNotFound
+}
+func (m *memoryPayments) GetRefundsByPayment(string) ([]models.Refund, error) { return nil, nil }
+func (m *memoryPayments) GetStaleRefunds(models.RefundStatus, time.Time) ([]models.Refund, error) {
+	return nil, nil
+}
+
+// refundingGateway accepts every refund.
+type refundingGateway struct {
+	ports.PaymentGateway
+}
+
+func (refundingGateway) Refund(reference string, refundID string, amount float64, currency string) (string, error) {
+	return "re_" + refundID, nil
+}
+
+func TestRefundApproval(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	token := func(userID string, role string) string {
+		signed, err := middleware.GenerateUserJWT(userID, role)
+		if err != nil {
+			t.Fatalf("GenerateUserJWT: %v", err)
+		}
+		return signed
+	}
+	send := func(router *mux.Router, path string, token string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		request.Header.Set("Authorization", "Bearer "+token)
+		response := httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+		return response
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"travellers cannot approve the refunds of their payments", token("user-1", ""), http.StatusForbidden},
+		{"agents do not see the payments of travellers", token("agent-1", middleware.RoleAgent), http.StatusNotFound},
+		{"admins cannot approve their own refunds", token("admin-1", middleware.RoleAdmin), http.StatusForbidden},
+		{"another admin approves the refund", token("admin-2", middleware.RoleAdmin), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &memoryPayments{
+				payments: map[string]models.Payment{"payment-1": {ID: "payment-1", UserID: "user-1", BookingID: "booking-1",
+					Amount: 900, Currency: "EUR", Status: models.StatusCaptured, GatewayReference: "mock_1"}},
+				refunds: map[string]models.Refund{},
+			}
+			router := mux.NewRouter()
+			NewRefundHandler(services.NewPaymentService(db, db, refundingGateway{}, nil, 500)).RegisterRoutes(router)
+
+			// Above the threshold, a refund an admin asks for waits for another admin.
+			created := send(router, "/payments/payment-1/refunds", token("admin-1", middleware.RoleAdmin))
+			var refund models.Refund
+			if err := json.NewDecoder(created.Body).Decode(&refund); err != nil || created.Code != http.StatusAccepted {
+				t.Fatalf("CreateRefund answered %d (%v), want %d", created.Code, err, http.StatusAccepted)
+			}
+
+			response := send(router, "/refunds/"+refund.ID+"/approve", tt.token)
+			if response.Code != tt.wantStatus {
+				t.Fatalf("ApproveRefund answered %d: %s, want %d", response.Code, response.Body, tt.wantStatus)
+			}
+			stored, _ := db.GetRefundByID(refund.ID)
+			wantRefund := models.RefundPendingApproval
+			if tt.wantStatus == http.StatusOK {
+				wantRefund = models.RefundPending
+				if stored.ReviewedBy != "user:admin-2" || stored.GatewayReference != "re_"+refund.ID {
+					t.Errorf("approved refund reviewed by %q with reference %q", stored.ReviewedBy, stored.GatewayReference)
+				}
+			}
+			if stored.Status != wantRefund {
+				t.Errorf("refund is %s, want %s", stored.Status, wantRefund)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type PostgresPaymentRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostgresPaymentRepository(dsn string) (*PostgresPaymentRepository, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.Payment{}, &models.PaymentEvent{}, &models.Refund{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate payment tables: %v", err)
+	}
+	return &PostgresPaymentRepository{DB: db}, nil
+}
+
+// CreatePayment stores a new payment at version 1 together with the event creating it.
+func (r *PostgresPaymentRepository) CreatePayment(payment *models.Payment, event models.PaymentEvent) error {
+	payment.Version = 1
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return tx.Create(&event).Error
+	})
+	if err != nil {
+		return fmt.Errorf("error creating payment: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresPaymentRepository) GetPaymentByID(id string) (*models.Payment, error) {
+	return r.getPayment("id = ?", id)
+}
+
+func (r *PostgresPaymentRepository) GetPaymentByGatewayReference(reference string) (*models.Payment, error) {
+	return r.getPayment("gateway_reference = ?", reference)
+}
+
+func (r *PostgresPaymentRepository) getPayment(condition string, value string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := r.DB.First(&payment, condition, value).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("error fetching payment: %v", err)
+	}
+	return &payment, nil
+}
+
+// UpdatePayment stores a version of a payment as the next version, together with the event
+// changing it.
+func (r *PostgresPaymentRepository) UpdatePayment(payment *models.Payment, version int, event models.PaymentEvent) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createEvent(tx, event); err != nil {
+			return err
+		}
+		return r.updatePayment(tx, payment, version)
+	})
+	if err != nil {
+		payment.Version = version
+		if errors.Is(err, models.ErrDuplicateEvent) || errors.Is(err, models.ErrVersionConflict) || errors.Is(err, models.ErrPaymentNotFound) {
+			return err
+		}
+		return fmt.Errorf("error updating payment: %v", err)
+	}
+	return nil
+}
+
+func createEvent(tx *gorm.DB, event models.PaymentEvent) error {
+	if err := tx.Create(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.ErrDuplicateEvent
+		}
+		return err
+	}
+	return nil
+}
+
+// updatePayment stores a payment as the next version if it is still at the given version.
+func (r *PostgresPaymentRepository) updatePayment(tx *gorm.DB, payment *models.Payment, version int) error {
+	payment.Version = version + 1
+	result := tx.Model(&models.Payment{}).Where("id = ? AND version = ?", payment.ID, version).
+		Select("*").Omit("id", "created_at").Updates(payment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetPaymentByID(payment.ID); err != nil {
+			return err
+		}
+		return models.ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *PostgresPaymentRepository) GetPaymentEvents(paymentID string) ([]models.PaymentEvent, error) {
+	events := []models.PaymentEvent{}
+	if err := r.DB.Where("payment_id = ?", paymentID).Order("created_at, id").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("error fetching payment events: %v", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateRefund stores a new refund at version 1, together with the payment counting it and the
+// event creating it.
+func (r *PostgresPaymentRepository) CreateRefund(refund *models.Refund, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error {
+	refund.Version = 1
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createEvent(tx, event); err != nil {
+			return err
+		}
+		if err := r.updatePayment(tx, payment, paymentVersion); err != nil {
+			return err
+		}
+		return tx.Create(refund).Error
+	})
+	if err != nil {
+		payment.Version = paymentVersion
+		if errors.Is(err, models.ErrDuplicateEvent) || errors.Is(err, models.ErrVersionConflict) || errors.Is(err, models.ErrPaymentNotFound) {
+			return err
+		}
+		return fmt.Errorf("error creating refund: %v", err)
+	}
+	return nil
+}
+
+func (r *PostgresPaymentRepository) GetRefundByID(id string) (*models.Refund, error) {
+	return r.getRefund("id = ?", id)
+}
+
+func (r *PostgresPaymentRepository) GetRefundByGatewayReference(reference string) (*models.Refund, error) {
+	return r.getRefund("gateway_reference = ?", reference)
+}
+
+func (r *PostgresPaymentRepository) getRefund(condition string, value string) (*models.Refund, error) {
+	var refund models.Refund
+	if err := r.DB.First(&refund, condition, value).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("error fetching refund: %v", err)
+	}
+	return &refund, nil
+}
+
+func (r *PostgresPaymentRepository) GetRefundsByPayment(paymentID string) ([]models.Refund, error) {
+	refunds := []models.Refund{}
+	if err := r.DB.Where("payment_id = ?", paymentID).Order("created_at, id").Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("error fetching refunds: %v", err)
+	}
+	return refunds, nil
+}
+
+func (r *PostgresPaymentRepository) GetStaleRefunds(status models.RefundStatus, before time.Time) ([]models.Refund, error) {
+	refunds := []models.Refund{}
+	if err := r.DB.Where("status = ? AND updated_at < ?", status, before).Order("updated_at").Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("error fetching refunds: %v", err)
+	}
+	return refunds, nil
+}
+
+// UpdateRefund stores a version of a refund as the next version, together with the event changing
+// it and, unless it is nil, the payment it changes.
+func (r *PostgresPaymentRepository) UpdateRefund(refund *models.Refund, version int, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createEvent(tx, event); err != nil {
+			return err
+		}
+		if payment != nil {
+			if err := r.updatePayment(tx, payment, paymentVersion); err != nil {
+				return err
+			}
+		}
+		refund.Version = version + 1
+		result := tx.Model(&models.Refund{}).Where("id = ? AND version = ?", refund.ID, version).
+			Select("*").Omit("id", "created_at").Updates(refund)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if _, err := r.GetRefundByID(refund.ID); err != nil {
+				return err
+			}
+			return models.ErrVersionConflict
+		}
+		return nil
+	})
+	if err != nil {
+		refund.Version = version
+		if payment != nil {
+			payment.Version = paymentVersion
+		}
+		if errors.Is(err, models.ErrDuplicateEvent) || errors.Is(err, models.ErrVersionConflict) ||
+			errors.Is(err, models.ErrPaymentNotFound) || errors.Is(err, models.ErrRefundNotFound) {
+			return err
+		}
+		return fmt.Errorf("error updating refund: %v", err)
+	}
+	return nil
+}
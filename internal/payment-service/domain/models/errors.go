@@ -0,0 +1,28 @@
+package models
+
+import "errors"
+
+var (
+	// ErrPaymentNotFound is returned when a payment does not exist.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidPayment is returned when a payment request is missing required details.
+	ErrInvalidPayment = errors.New("invalid payment")
+	// ErrPaymentDeclined is returned when the gateway declined the payment method.
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrInvalidTransition is returned when a payment or refund cannot change this way in its current status.
+	ErrInvalidTransition = errors.New("invalid status change")
+	// ErrVersionConflict is returned when a payment or refund was changed since the version an update was based on.
+	ErrVersionConflict = errors.New("payment was changed by someone else")
+	// ErrDuplicateEvent is returned when an event with the same ID was already recorded.
+	ErrDuplicateEvent = errors.New("event already recorded")
+	// ErrInvalidSignature is returned when a webhook is not signed by the payment gateway.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrForbidden is returned when a requester acts on a payment they may not settle.
+	ErrForbidden = errors.New("not allowed to change this payment")
+	// ErrRefundNotFound is returned when a refund does not exist.
+	ErrRefundNotFound = errors.New("refund not found")
+	// ErrInvalidRefund is returned when a refund is not for a captured payment or exceeds what is left to refund.
+	ErrInvalidRefund = errors.New("invalid refund")
+	// ErrBookingNotFound is returned by the booking service client when a payment's booking does not exist.
+	ErrBookingNotFound = errors.New("booking not found")
+)
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+type EventSource string
+
+const (
+	SourceAPI     EventSource = "api"     // A request to the payment service.
+	SourceGateway EventSource = "gateway" // A webhook of the payment gateway.
+)
+
+// PaymentEvent records a change of the status of a payment or one of its refunds. Events of the
+// gateway keep the ID the gateway gave them, so that a webhook delivered twice is only applied once.
+type PaymentEvent struct {
+	ID           string        `json:"id" gorm:"primaryKey"`
+	PaymentID    string        `json:"payment_id" gorm:"index"`
+	Source       EventSource   `json:"source"`
+	Status       PaymentStatus `json:"status"` // Status of the payment after the event.
+	RefundID     string        `json:"refund_id,omitempty"`
+	RefundStatus RefundStatus  `json:"refund_status,omitempty"` // Status of the refund after the event.
+	Detail       string        `json:"detail,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type GatewayEventType string
+
+// Events the payment gateway reports through webhooks.
+const (
+	GatewayAuthorizationSucceeded GatewayEventType = "authorization.succeeded"
+	GatewayAuthorizationFailed    GatewayEventType = "authorization.failed"
+	GatewayRefundSucceeded        GatewayEventType = "refund.succeeded"
+	GatewayRefundFailed           GatewayEventType = "refund.failed"
+)
+
+// GatewayEvent is a verified webhook of the payment gateway about one of its payments or refunds.
+type GatewayEvent struct {
+	ID            string           `json:"id"`
+	Type          GatewayEventType `json:"type"`
+	Reference     string           `json:"reference"`                // The gateway's reference of the payment or refund.
+	DeclineReason string           `json:"decline_reason,omitempty"` // Why an authorization or refund failed.
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// GatewayResult is how the gateway answered an authorization, capture or void.
+type GatewayResult struct {
+	Reference     string
+	Status        PaymentStatus
+	ChallengeURL  string
+	DeclineReason string
+}
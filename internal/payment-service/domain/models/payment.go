@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type PaymentStatus string
+
+// A payment starts pending and is authorized, possibly after a 3-D Secure challenge or a delay at
+// the gateway, before it is captured or voided. Declined payments may be authorized again with
+// another payment method; captured ones are refunded once refunds settled their whole amount.
+const (
+	StatusPending        PaymentStatus = "pending"         // Waiting for a payment method to be authorized.
+	StatusRequiresAction PaymentStatus = "requires_action" // The customer has to complete a 3-D Secure challenge.
+	StatusProcessing     PaymentStatus = "processing"      // The gateway reports the outcome later through a webhook.
+	StatusAuthorized     PaymentStatus = "authorized"
+	StatusCaptured       PaymentStatus = "captured"
+	StatusVoided         PaymentStatus = "voided"
+	StatusDeclined       PaymentStatus = "declined"
+	StatusRefunded       PaymentStatus = "refunded"
+)
+
+// CaptureMethod decides whether an authorized payment is captured right away or left for an
+// explicit capture, e.g. once a hotel confirms the stay.
+type CaptureMethod string
+
+const (
+	CaptureAutomatic CaptureMethod = "automatic"
+	CaptureManual    CaptureMethod = "manual"
+)
+
+// Payment is a payment intent: an amount a user pays for a booking, tracked from authorization to
+// capture at the payment gateway.
+type Payment struct {
+	ID               string        `json:"id" gorm:"primaryKey"`
+	UserID           string        `json:"user_id" gorm:"index"`
+	BookingID        string        `json:"reference" gorm:"index"` // Booking the payment is for.
+	Amount           float64       `json:"amount"`
+	Currency         string        `json:"currency"`
+	PaymentMethod    string        `json:"payment_method,omitempty"` // Tokenized card or wallet, as issued by the gateway.
+	CaptureMethod    CaptureMethod `json:"capture_method"`
+	Status           PaymentStatus `json:"status" gorm:"index"`
+	GatewayReference string        `json:"gateway_reference,omitempty" gorm:"index"`
+	ChallengeURL     string        `json:"challenge_url,omitempty"` // Where the customer completes a 3-D Secure challenge.
+	DeclineReason    string        `json:"decline_reason,omitempty"`
+	RefundedAmount   float64       `json:"refunded_amount"` // Refunds that settled.
+	PendingRefunds   float64       `json:"pending_refunds"` // Refunds waiting for approval or the gateway.
+	AuthorizedAt     *time.Time    `json:"authorized_at,omitempty"`
+	CapturedAt       *time.Time    `json:"captured_at,omitempty"`
+	VoidedAt         *time.Time    `json:"voided_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	Version          int           `json:"version" gorm:"not null;default:1"` // Incremented by every update, see PaymentDB.
+}
+
+// PaymentRequest asks for a payment of a booking. With a payment method it is authorized right
+// away, and captured too unless the capture method is manual.
+type PaymentRequest struct {
+	UserID        string        `json:"user_id"`
+	BookingID     string        `json:"reference"`
+	Amount        float64       `json:"amount"`
+	Currency      string        `json:"currency"`
+	PaymentMethod string        `json:"payment_method"`
+	CaptureMethod CaptureMethod `json:"capture_method"`
+}
+
+// Requester is who a request was made by, as told by its JWT.
+type Requester struct {
+	UserID  string // Subject of an end-user token.
+	Service string // Name of the service sending a service token.
+	// Privileged requesters, such as other services and admins, may see and settle every payment.
+	Privileged bool
+	// Admins approve refunds above the approval threshold.
+	Admin bool
+}
+
+// Name identifies the requester in the records of refunds.
+func (r Requester) Name() string {
+	if r.Service != "" {
+		return "service:" + r.Service
+	}
+	return "user:" + r.UserID
+}
+
+// CanSee reports whether the requester may see a payment.
+func (r Requester) CanSee(payment *Payment) bool {
+	return r.Privileged || (r.UserID != "" && r.UserID == payment.UserID)
+}
+
+// transitions lists the statuses a payment may move to from each status; voided and refunded
+// payments are final.
+var transitions = map[PaymentStatus][]PaymentStatus{
+	StatusPending:        {StatusRequiresAction, StatusProcessing, StatusAuthorized, StatusDeclined, StatusVoided},
+	StatusDeclined:       {StatusRequiresAction, StatusProcessing, StatusAuthorized, StatusDeclined, StatusVoided},
+	StatusRequiresAction: {StatusAuthorized, StatusDeclined, StatusVoided},
+	StatusProcessing:     {StatusAuthorized, StatusDeclined, StatusVoided},
+	StatusAuthorized:     {StatusCaptured, StatusVoided},
+	StatusCaptured:       {StatusRefunded},
+}
+
+// CanMoveTo reports whether the payment may change to status.
+func (p *Payment) CanMoveTo(status PaymentStatus) bool {
+	for _, next := range transitions[p.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Refundable is how much of a captured payment may still be refunded.
+func (p *Payment) Refundable() float64 {
+	if p.Status != StatusCaptured {
+		return 0
+	}
+	return RoundCents(p.Amount - p.RefundedAmount - p.PendingRefunds)
+}
+
+// RoundCents drops the floating point noise sums of amounts pick up.
+func RoundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type RefundStatus string
+
+// A refund above the approval threshold waits for an admin first. Submitted refunds are pending
+// at the gateway until it reports that they settled or failed.
+const (
+	RefundPendingApproval RefundStatus = "pending_approval"
+	RefundRejected        RefundStatus = "rejected" // An admin turned the refund down.
+	RefundPending         RefundStatus = "pending"
+	RefundSucceeded       RefundStatus = "succeeded"
+	RefundFailed          RefundStatus = "failed"
+)
+
+// Refund pays back all or part of a captured payment.
+type Refund struct {
+	ID               string       `json:"id" gorm:"primaryKey"`
+	PaymentID        string       `json:"payment_id" gorm:"index"`
+	BookingID        string       `json:"reference"`
+	Amount           float64      `json:"amount"`
+	Currency         string       `json:"currency"`
+	Reason           string       `json:"reason,omitempty"`
+	Status           RefundStatus `json:"status" gorm:"index"`
+	GatewayReference string       `json:"gateway_reference,omitempty" gorm:"index"`
+	FailureReason    string       `json:"failure_reason,omitempty"` // Why the gateway failed or the admin rejected the refund.
+	RequestedBy      string       `json:"requested_by"`
+	ReviewedBy       string       `json:"reviewed_by,omitempty"` // Admin who approved or rejected the refund.
+	SettledAt        *time.Time   `json:"settled_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" gorm:"index"`
+	Version          int          `json:"version" gorm:"not null;default:1"` // Incremented by every update, see RefundDB.
+}
+
+// RefundRequest asks to refund a payment; without an amount, everything still refundable is refunded.
+type RefundRequest struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Reason   string  `json:"reason"`
+}
+
+// Open reports whether the refund still counts against the refundable amount of its payment
+// without having settled.
+func (r *Refund) Open() bool {
+	return r.Status == RefundPendingApproval || r.Status == RefundPending
+}
@@ -0,0 +1,8 @@
+package ports
+
+// Bookings moves bookings along as their payments progress.
+type Bookings interface {
+	// TransitionBooking changes the status of a booking to status if it is in one of the from
+	// statuses, and leaves it alone otherwise. It returns models.ErrBookingNotFound for unknown bookings.
+	TransitionBooking(bookingID string, from []string, status string, reason string) error
+}
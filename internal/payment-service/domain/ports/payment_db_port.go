@@ -0,0 +1,16 @@
+package ports
+
+import "microservices-travel-backend/internal/payment-service/domain/models"
+
+// PaymentDB stores payments. Every change of a payment is stored together with the event causing
+// it and is conditional on the version of the payment it was based on: it fails with
+// models.ErrVersionConflict when the payment changed in between, and with models.ErrDuplicateEvent
+// when the event was already recorded.
+type PaymentDB interface {
+	CreatePayment(payment *models.Payment, event models.PaymentEvent) error
+	GetPaymentByID(id string) (*models.Payment, error)
+	GetPaymentByGatewayReference(reference string) (*models.Payment, error)
+	UpdatePayment(payment *models.Payment, version int, event models.PaymentEvent) error
+	// GetPaymentEvents lists the events of a payment, oldest first.
+	GetPaymentEvents(paymentID string) ([]models.PaymentEvent, error)
+}
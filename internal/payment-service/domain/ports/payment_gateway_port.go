@@ -0,0 +1,23 @@
+package ports
+
+import "microservices-travel-backend/internal/payment-service/domain/models"
+
+// PaymentGateway moves money through a payment provider. Authorizations may not complete right
+// away and refunds never do: the gateway reports their outcome through a webhook.
+type PaymentGateway interface {
+	// Authorize reserves the amount of a payment on its payment method.
+	Authorize(payment *models.Payment) (*models.GatewayResult, error)
+	// Capture takes the authorized amount of the payment with the given gateway reference.
+	Capture(reference string, amount float64, currency string) (*models.GatewayResult, error)
+	// Void releases an authorization, or abandons one that has not completed yet.
+	Void(reference string) (*models.GatewayResult, error)
+	// Refund pays back part of the captured payment with the given gateway reference and returns
+	// the reference of the refund. Refunds settle later: the gateway reports their outcome through
+	// a webhook. Refunding again with the same refund ID does not pay back twice.
+	Refund(reference string, refundID string, amount float64, currency string) (string, error)
+	// RefundStatus tells where a refund stands at the gateway, and why it failed if it did.
+	RefundStatus(reference string) (models.RefundStatus, string, error)
+	// ParseWebhook verifies the signature of a webhook and decodes its event; it returns
+	// models.ErrInvalidSignature for webhooks the gateway did not send.
+	ParseWebhook(payload []byte, signature string) (*models.GatewayEvent, error)
+}
@@ -0,0 +1,27 @@
+package ports
+
+import "microservices-travel-backend/internal/payment-service/domain/models"
+
+type PaymentService interface {
+	// CreatePayment starts a payment, authorizing it right away when the request has a payment method.
+	CreatePayment(requester models.Requester, request models.PaymentRequest) (*models.Payment, error)
+	GetPayment(requester models.Requester, id string) (*models.Payment, error)
+	GetPaymentEvents(requester models.Requester, id string) ([]models.PaymentEvent, error)
+	// AuthorizePayment authorizes a pending or declined payment, with a new payment method if given.
+	AuthorizePayment(requester models.Requester, id string, paymentMethod string) (*models.Payment, error)
+	CapturePayment(requester models.Requester, id string) (*models.Payment, error)
+	VoidPayment(requester models.Requester, id string) (*models.Payment, error)
+	// HandleWebhook applies a webhook of the payment gateway; webhooks delivered again are ignored.
+	HandleWebhook(payload []byte, signature string) error
+}
+
+type RefundService interface {
+	// CreateRefund refunds all or part of a captured payment. Refunds above the approval threshold
+	// wait for an admin unless another service asks for them.
+	CreateRefund(requester models.Requester, paymentID string, request models.RefundRequest) (*models.Refund, error)
+	GetRefund(requester models.Requester, id string) (*models.Refund, error)
+	GetRefunds(requester models.Requester, paymentID string) ([]models.Refund, error)
+	// ApproveRefund lets an admin other than the one asking for a refund send it to the gateway.
+	ApproveRefund(requester models.Requester, id string) (*models.Refund, error)
+	RejectRefund(requester models.Requester, id string, reason string) (*models.Refund, error)
+}
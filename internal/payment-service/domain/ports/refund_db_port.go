@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"time"
+)
+
+// RefundDB stores refunds. A change of a refund is stored together with the change of its payment
+// it makes, if any, and the event causing it; like PaymentDB, the change is conditional on the
+// versions it was based on and fails on events that were already recorded.
+type RefundDB interface {
+	CreateRefund(refund *models.Refund, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error
+	GetRefundByID(id string) (*models.Refund, error)
+	GetRefundByGatewayReference(reference string) (*models.Refund, error)
+	// GetRefundsByPayment lists the refunds of a payment, oldest first.
+	GetRefundsByPayment(paymentID string) ([]models.Refund, error)
+	// GetStaleRefunds lists the refunds in a status that did not change since before the given time.
+	GetStaleRefunds(status models.RefundStatus, before time.Time) ([]models.Refund, error)
+	// UpdateRefund stores a version of a refund, and of its payment unless payment is nil.
+	UpdateRefund(refund *models.Refund, version int, payment *models.Payment, paymentVersion int, event models.PaymentEvent) error
+}
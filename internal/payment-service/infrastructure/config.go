@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"microservices-travel-backend/pkg/middlewares"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Payment gateways payments can be taken through.
+const (
+	GatewayMock = "mock"
+)
+
+type Config struct {
+	Database struct {
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		User     string `mapstructure:"user"`
+		Password string `mapstructure:"password"`
+		DBName   string `mapstructure:"db_name"`
+		SSLMode  string `mapstructure:"ssl_mode"`
+	} `mapstructure:"database"`
+
+	Service struct {
+		Port int `mapstructure:"port"`
+		// PublicURL is where the payment gateway reaches this service, for its webhooks.
+		PublicURL string `mapstructure:"public_url"`
+	} `mapstructure:"service"`
+
+	// Services are the base URLs of the services payments are made for.
+	Services struct {
+		BookingURL string `mapstructure:"booking_url"`
+	} `mapstructure:"services"`
+
+	Gateway struct {
+		Driver string `mapstructure:"driver"`
+		// WebhookSecret is shared with the gateway, which signs its webhooks with it.
+		WebhookSecret string `mapstructure:"webhook_secret"`
+		Mock          struct {
+			// Latency is how long every call to the mock gateway takes.
+			Latency time.Duration `mapstructure:"latency"`
+			// SettleDelay is how long delayed authorizations and refunds take to report their outcome.
+			SettleDelay time.Duration `mapstructure:"settle_delay"`
+		} `mapstructure:"mock"`
+	} `mapstructure:"gateway"`
+
+	Refunds struct {
+		// ApprovalThreshold is the amount above which refunds asked for by users wait for an
+		// admin's approval; zero turns approvals off.
+		ApprovalThreshold float64 `mapstructure:"approval_threshold"`
+		// SyncInterval is how often refunds still pending are checked at the gateway, in case their
+		// webhook got lost.
+		SyncInterval time.Duration `mapstructure:"sync_interval"`
+	} `mapstructure:"refunds"`
+
+	Idempotency struct {
+		// KeyTTL is how long Idempotency-Key responses are kept for replay.
+		KeyTTL time.Duration `mapstructure:"key_ttl"`
+	} `mapstructure:"idempotency"`
+}
+
+// environment maps each setting to the variable it is read from; the database ones are shared
+// with the other services through config/shared.
+var environment = map[string]string{
+	"database.host":              "DATABASE_URL",
+	"database.port":              "DATABASE_PORT",
+	"database.user":              "DATABASE_USERNAME",
+	"database.password":          "DATABASE_PASSWORD",
+	"database.db_name":           "DATABASE_NAME",
+	"database.ssl_mode":          "DATABASE_SSLMODE",
+	"service.port":               "PAYMENT_SERVICE_PORT",
+	"service.public_url":         "PAYMENT_SERVICE_PUBLIC_URL",
+	"services.booking_url":       "BOOKING_SERVICE_URL",
+	"gateway.driver":             "PAYMENT_GATEWAY",
+	"gateway.webhook_secret":     "PAYMENT_WEBHOOK_SECRET",
+	"gateway.mock.latency":       "MOCK_GATEWAY_LATENCY",
+	"gateway.mock.settle_delay":  "MOCK_GATEWAY_SETTLE_DELAY",
+	"refunds.approval_threshold": "REFUND_APPROVAL_THRESHOLD",
+	"refunds.sync_interval":      "REFUND_SYNC_INTERVAL",
+	"idempotency.key_ttl":        "IDEMPOTENCY_KEY_TTL",
+}
+
+// LoadConfig reads the payment service configuration from the environment.
+func LoadConfig() (*Config, error) {
+	v := viper.New()
+	for key, variable := range environment {
+		if err := v.BindEnv(key, variable); err != nil {
+			return nil, fmt.Errorf("error binding %s: %v", variable, err)
+		}
+	}
+	v.SetDefault("database.port", 5432)
+	v.SetDefault("database.ssl_mode", "disable")
+	v.SetDefault("service.port", 6200)
+	v.SetDefault("service.public_url", "http://localhost:6200")
+	v.SetDefault("services.booking_url", "http://localhost:6000")
+	v.SetDefault("gateway.driver", GatewayMock)
+	v.SetDefault("gateway.mock.latency", 200*time.Millisecond)
+	v.SetDefault("gateway.mock.settle_delay", 5*time.Second)
+	v.SetDefault("refunds.approval_threshold", 500)
+	v.SetDefault("refunds.sync_interval", time.Minute)
+	v.SetDefault("idempotency.key_ttl", middleware.DefaultIdempotencyWindow)
+
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("unable to decode configuration: %v", err)
+	}
+
+	if config.Gateway.Driver != GatewayMock {
+		return nil, fmt.Errorf("unsupported payment gateway %q, expected %s", config.Gateway.Driver, GatewayMock)
+	}
+	if config.Gateway.WebhookSecret == "" {
+		return nil, fmt.Errorf("PAYMENT_WEBHOOK_SECRET is required to verify gateway webhooks")
+	}
+	if config.Refunds.ApprovalThreshold < 0 || config.Refunds.SyncInterval <= 0 {
+		return nil, fmt.Errorf("REFUND_APPROVAL_THRESHOLD must not be negative and REFUND_SYNC_INTERVAL must be positive")
+	}
+	config.Service.PublicURL = strings.TrimSuffix(config.Service.PublicURL, "/")
+	return &config, nil
+}
+
+// PostgresDSN builds the connection string of the payments database.
+func (c *Config) PostgresDSN() string {
+	db := c.Database
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		db.User, db.Password, db.Host, db.Port, db.DBName, db.SSLMode)
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"microservices-travel-backend/internal/payment-service/domain/ports"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of bookings that payments move bookings between.
+const (
+	bookingPending         = "pending"
+	bookingAwaitingPayment = "awaiting_payment"
+	bookingPaymentReceived = "payment_received"
+	bookingRefunded        = "refunded"
+)
+
+// bookingTransition moves a booking in one of the from statuses to the to status.
+type bookingTransition struct {
+	from []string
+	to   string
+}
+
+// bookingTransitions are the changes of the status of its booking a payment reaching a status
+// makes. Bookings in any other status, e.g. confirmed bookings of declined payments, are left alone.
+var bookingTransitions = map[models.PaymentStatus]bookingTransition{
+	models.StatusPending:        {[]string{bookingPending}, bookingAwaitingPayment},
+	models.StatusRequiresAction: {[]string{bookingPending}, bookingAwaitingPayment},
+	models.StatusProcessing:     {[]string{bookingPending}, bookingAwaitingPayment},
+	models.StatusDeclined:       {[]string{bookingPending}, bookingAwaitingPayment},
+	models.StatusCaptured:       {[]string{bookingPending, bookingAwaitingPayment}, bookingPaymentReceived},
+	models.StatusRefunded: {[]string{
+		bookingPaymentReceived, "confirmed", "cancelled", "cancelled_by_guest", "cancelled_by_hotel",
+		"under_cancellation_review", "disputed", "no_show",
+	}, bookingRefunded},
+}
+
+// PaymentService takes payments and refunds through the payment gateway and moves their bookings
+// along.
+type PaymentService struct {
+	db       ports.PaymentDB
+	refunds  ports.RefundDB
+	gateway  ports.PaymentGateway
+	bookings ports.Bookings
+	// approvalThreshold is the amount above which refunds asked for by users wait for an admin;
+	// zero lets every refund through.
+	approvalThreshold float64
+}
+
+func NewPaymentService(db ports.PaymentDB, refunds ports.RefundDB, gateway ports.PaymentGateway, bookings ports.Bookings, approvalThreshold float64) *PaymentService {
+	return &PaymentService{db: db, refunds: refunds, gateway: gateway, bookings: bookings, approvalThreshold: approvalThreshold}
+}
+
+// CreatePayment stores a pending payment for a booking and, when the request has a payment
+// method, authorizes it and captures it unless the capture is manual. End users pay for themselves.
+func (s *PaymentService) CreatePayment(requester models.Requester, request models.PaymentRequest) (*models.Payment, error) {
+	if !requester.Privileged {
+		if request.UserID != "" && request.UserID != requester.UserID {
+			return nil, fmt.Errorf("%w: payments can only be made for yourself", models.ErrForbidden)
+		}
+		request.UserID = requester.UserID
+	}
+	request.Currency = strings.ToUpper(request.Currency)
+	if request.CaptureMethod == "" {
+		request.CaptureMethod = models.CaptureAutomatic
+	}
+	switch {
+	case request.UserID == "":
+		return nil, fmt.Errorf("%w: user ID is required", models.ErrInvalidPayment)
+	case request.BookingID == "":
+		return nil, fmt.Errorf("%w: booking reference is required", models.ErrInvalidPayment)
+	case request.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", models.ErrInvalidPayment)
+	case len(request.Currency) != 3:
+		return nil, fmt.Errorf("%w: currency must be a three-letter code", models.ErrInvalidPayment)
+	case request.CaptureMethod != models.CaptureAutomatic && request.CaptureMethod != models.CaptureManual:
+		return nil, fmt.Errorf("%w: unknown capture method %q", models.ErrInvalidPayment, request.CaptureMethod)
+	}
+
+	payment := &models.Payment{
+		ID:            uuid.NewString(),
+		UserID:        request.UserID,
+		BookingID:     request.BookingID,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		PaymentMethod: request.PaymentMethod,
+		CaptureMethod: request.CaptureMethod,
+		Status:        models.StatusPending,
+	}
+	if err := s.db.CreatePayment(payment, newEvent(payment, models.SourceAPI, "payment created")); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if payment.PaymentMethod != "" {
+		payment, err = s.authorize(payment)
+	}
+	s.moveBooking(payment)
+	return payment, err
+}
+
+// GetPayment returns a payment to privileged requesters and the user paying it.
+func (s *PaymentService) GetPayment(requester models.Requester, id string) (*models.Payment, error) {
+	payment, err := s.db.GetPaymentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.CanSee(payment) {
+		return nil, models.ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// GetPaymentEvents lists how a payment got to its status, oldest first.
+func (s *PaymentService) GetPaymentEvents(requester models.Requester, id string) ([]models.PaymentEvent, error) {
+	if _, err := s.GetPayment(requester, id); err != nil {
+		return nil, err
+	}
+	return s.db.GetPaymentEvents(id)
+}
+
+// AuthorizePayment authorizes a pending or declined payment, switching to another payment method
+// when one is given.
+func (s *PaymentService) AuthorizePayment(requester models.Requester, id string, paymentMethod string) (*models.Payment, error) {
+	payment, err := s.GetPayment(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.StatusPending && payment.Status != models.StatusDeclined {
+		return nil, fmt.Errorf("%w: payment is %s", models.ErrInvalidTransition, payment.Status)
+	}
+	if paymentMethod != "" {
+		payment.PaymentMethod = paymentMethod
+	}
+	if payment.PaymentMethod == "" {
+		return nil, fmt.Errorf("%w: payment method is required", models.ErrInvalidPayment)
+	}
+
+	payment, err = s.authorize(payment)
+	s.moveBooking(payment)
+	return payment, err
+}
+
+// CapturePayment takes the money of an authorized payment. Only privileged requesters settle payments.
+func (s *PaymentService) CapturePayment(requester models.Requester, id string) (*models.Payment, error) {
+	payment, err := s.settleable(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.StatusAuthorized {
+		return nil, fmt.Errorf("%w: payment is %s", models.ErrInvalidTransition, payment.Status)
+	}
+
+	payment, err = s.capture(payment)
+	if err != nil {
+		return nil, err
+	}
+	s.moveBooking(payment)
+	return payment, nil
+}
+
+// VoidPayment abandons a payment that was not captured, releasing its authorization at the gateway.
+func (s *PaymentService) VoidPayment(requester models.Requester, id string) (*models.Payment, error) {
+	payment, err := s.settleable(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if !payment.CanMoveTo(models.StatusVoided) {
+		return nil, fmt.Errorf("%w: payment is %s", models.ErrInvalidTransition, payment.Status)
+	}
+
+	result := &models.GatewayResult{Status: models.StatusVoided}
+	if payment.GatewayReference != "" && payment.Status != models.StatusDeclined {
+		if result, err = s.gateway.Void(payment.GatewayReference); err != nil {
+			return nil, fmt.Errorf("error voiding payment: %v", err)
+		}
+	}
+	return s.record(payment, result, newEventID(), models.SourceAPI)
+}
+
+// HandleWebhook applies the outcome of an authorization the gateway completed later, after a
+// 3-D Secure challenge or a delay, or of a refund. Webhooks about payments and refunds that moved
+// on since are ignored.
+func (s *PaymentService) HandleWebhook(payload []byte, signature string) error {
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+	switch event.Type {
+	case models.GatewayRefundSucceeded, models.GatewayRefundFailed:
+		return s.handleRefundEvent(event)
+	}
+	payment, err := s.db.GetPaymentByGatewayReference(event.Reference)
+	if err != nil {
+		return err
+	}
+	if payment.Status != models.StatusRequiresAction && payment.Status != models.StatusProcessing {
+		return nil
+	}
+
+	result := &models.GatewayResult{Status: models.StatusAuthorized}
+	switch event.Type {
+	case models.GatewayAuthorizationSucceeded:
+	case models.GatewayAuthorizationFailed:
+		result = &models.GatewayResult{Status: models.StatusDeclined, DeclineReason: event.DeclineReason}
+	default:
+		return nil
+	}
+
+	payment, err = s.record(payment, result, event.ID, models.SourceGateway)
+	if errors.Is(err, models.ErrDuplicateEvent) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if payment.Status == models.StatusAuthorized && payment.CaptureMethod == models.CaptureAutomatic {
+		if payment, err = s.capture(payment); err != nil {
+			return err
+		}
+	}
+	s.moveBooking(payment)
+	return nil
+}
+
+// settleable returns a payment a privileged requester may capture or void.
+func (s *PaymentService) settleable(requester models.Requester, id string) (*models.Payment, error) {
+	payment, err := s.GetPayment(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged {
+		return nil, fmt.Errorf("%w: only the merchant captures and voids payments", models.ErrForbidden)
+	}
+	return payment, nil
+}
+
+// authorize asks the gateway to authorize a payment and records the answer, capturing the payment
+// right away when the capture is automatic. A declined payment is returned with ErrPaymentDeclined.
+func (s *PaymentService) authorize(payment *models.Payment) (*models.Payment, error) {
+	result, err := s.gateway.Authorize(payment)
+	if err != nil {
+		return payment, fmt.Errorf("error authorizing payment: %v", err)
+	}
+	authorized, err := s.record(payment, result, newEventID(), models.SourceAPI)
+	if err != nil {
+		return payment, err
+	}
+
+	switch {
+	case authorized.Status == models.StatusDeclined:
+		return authorized, fmt.Errorf("%w: %s", models.ErrPaymentDeclined, authorized.DeclineReason)
+	case authorized.Status == models.StatusAuthorized && authorized.CaptureMethod == models.CaptureAutomatic:
+		captured, err := s.capture(authorized)
+		if err != nil {
+			return authorized, err
+		}
+		return captured, nil
+	}
+	return authorized, nil
+}
+
+func (s *PaymentService) capture(payment *models.Payment) (*models.Payment, error) {
+	result, err := s.gateway.Capture(payment.GatewayReference, payment.Amount, payment.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("error capturing payment: %v", err)
+	}
+	return s.record(payment, result, newEventID(), models.SourceAPI)
+}
+
+// record stores the status the gateway answered with, along with the event reporting it.
+func (s *PaymentService) record(payment *models.Payment, result *models.GatewayResult, eventID string, source models.EventSource) (*models.Payment, error) {
+	if !payment.CanMoveTo(result.Status) {
+		return nil, fmt.Errorf("%w: payment is %s, gateway answered %s", models.ErrInvalidTransition, payment.Status, result.Status)
+	}
+
+	updated := *payment
+	now := time.Now().UTC()
+	updated.Status = result.Status
+	if result.Reference != "" {
+		updated.GatewayReference = result.Reference
+	}
+	updated.ChallengeURL = result.ChallengeURL
+	updated.DeclineReason = result.DeclineReason
+	switch result.Status {
+	case models.StatusAuthorized:
+		updated.AuthorizedAt = &now
+	case models.StatusCaptured:
+		updated.CapturedAt = &now
+	case models.StatusVoided:
+		updated.VoidedAt = &now
+	}
+
+	event := newEvent(&updated, source, result.DeclineReason)
+	event.ID = eventID
+	if err := s.db.UpdatePayment(&updated, payment.Version, event); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// moveBooking brings the booking of a payment in line with the payment. The payment stands even
+// when the booking cannot be moved, so failures are only logged.
+func (s *PaymentService) moveBooking(payment *models.Payment) {
+	transition, ok := bookingTransitions[payment.Status]
+	if !ok {
+		return
+	}
+	reason := fmt.Sprintf("payment %s %s", payment.ID, payment.Status)
+	if err := s.bookings.TransitionBooking(payment.BookingID, transition.from, transition.to, reason); err != nil {
+		log.Printf("Failed to move booking %s to %s after payment %s: %v\n", payment.BookingID, transition.to, payment.ID, err)
+	}
+}
+
+func newEvent(payment *models.Payment, source models.EventSource, detail string) models.PaymentEvent {
+	return models.PaymentEvent{
+		ID:        newEventID(),
+		PaymentID: payment.ID,
+		Source:    source,
+		Status:    payment.Status,
+		Detail:    detail,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func newEventID() string {
+	return "evt_" + uuid.NewString()
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/payment-service/domain/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateRefund refunds all or part of what is left to refund of a captured payment. Only
+// privileged requesters refund payments. Refunds above the approval threshold asked for by users
+// wait for another admin to approve them; refunds other services ask for, e.g. to compensate a
+// failed booking, go to the gateway right away.
+func (s *PaymentService) CreateRefund(requester models.Requester, paymentID string, request models.RefundRequest) (*models.Refund, error) {
+	payment, err := s.GetPayment(requester, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !requester.Privileged {
+		return nil, fmt.Errorf("%w: only the merchant refunds payments", models.ErrForbidden)
+	}
+	if payment.Status != models.StatusCaptured {
+		return nil, fmt.Errorf("%w: payment is %s", models.ErrInvalidTransition, payment.Status)
+	}
+	refundable := payment.Refundable()
+	amount := models.RoundCents(request.Amount)
+	if request.Amount == 0 {
+		amount = refundable
+	}
+	switch {
+	case request.Currency != "" && !strings.EqualFold(request.Currency, payment.Currency):
+		return nil, fmt.Errorf("%w: payment is in %s", models.ErrInvalidRefund, payment.Currency)
+	case amount <= 0 && refundable <= 0:
+		return nil, fmt.Errorf("%w: nothing is left to refund", models.ErrInvalidRefund)
+	case amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", models.ErrInvalidRefund)
+	case amount > refundable:
+		return nil, fmt.Errorf("%w: only %.2f %s is left to refund", models.ErrInvalidRefund, refundable, payment.Currency)
+	}
+
+	refund := &models.Refund{
+		ID:          uuid.NewString(),
+		PaymentID:   payment.ID,
+		BookingID:   payment.BookingID,
+		Amount:      amount,
+		Currency:    payment.Currency,
+		Reason:      request.Reason,
+		Status:      models.RefundPending,
+		RequestedBy: requester.Name(),
+	}
+	if requester.Service == "" && s.approvalThreshold > 0 && amount > s.approvalThreshold {
+		refund.Status = models.RefundPendingApproval
+	}
+	updated := *payment
+	updated.PendingRefunds = models.RoundCents(updated.PendingRefunds + amount)
+	event := newRefundEvent(&updated, refund, models.SourceAPI, "refund requested by "+refund.RequestedBy)
+	if err := s.refunds.CreateRefund(refund, &updated, payment.Version, event); err != nil {
+		return nil, err
+	}
+
+	if refund.Status == models.RefundPending {
+		return s.submitRefund(&updated, refund)
+	}
+	return refund, nil
+}
+
+// GetRefund returns a refund to the requesters who may see its payment.
+func (s *PaymentService) GetRefund(requester models.Requester, id string) (*models.Refund, error) {
+	refund, err := s.refunds.GetRefundByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetPayment(requester, refund.PaymentID); err != nil {
+		if errors.Is(err, models.ErrPaymentNotFound) {
+			return nil, models.ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return refund, nil
+}
+
+// GetRefunds lists the refunds of a payment, oldest first.
+func (s *PaymentService) GetRefunds(requester models.Requester, paymentID string) ([]models.Refund, error) {
+	if _, err := s.GetPayment(requester, paymentID); err != nil {
+		return nil, err
+	}
+	return s.refunds.GetRefundsByPayment(paymentID)
+}
+
+// ApproveRefund sends a refund waiting for approval to the gateway. Admins do not approve the
+// refunds they asked for themselves.
+func (s *PaymentService) ApproveRefund(requester models.Requester, id string) (*models.Refund, error) {
+	refund, err := s.reviewable(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := s.db.GetPaymentByID(refund.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := *refund
+	approved.Status = models.RefundPending
+	approved.ReviewedBy = requester.Name()
+	event := newRefundEvent(payment, &approved, models.SourceAPI, "refund approved by "+approved.ReviewedBy)
+	if err := s.refunds.UpdateRefund(&approved, refund.Version, nil, 0, event); err != nil {
+		return nil, err
+	}
+	return s.submitRefund(payment, &approved)
+}
+
+// RejectRefund turns down a refund waiting for approval, giving its amount back to what is left
+// to refund of its payment.
+func (s *PaymentService) RejectRefund(requester models.Requester, id string, reason string) (*models.Refund, error) {
+	refund, err := s.reviewable(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := s.db.GetPaymentByID(refund.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected := *refund
+	rejected.Status = models.RefundRejected
+	rejected.ReviewedBy = requester.Name()
+	rejected.FailureReason = reason
+	updated := *payment
+	updated.PendingRefunds = models.RoundCents(updated.PendingRefunds - refund.Amount)
+	event := newRefundEvent(&updated, &rejected, models.SourceAPI, "refund rejected by "+rejected.ReviewedBy)
+	if err := s.refunds.UpdateRefund(&rejected, refund.Version, &updated, payment.Version, event); err != nil {
+		return nil, err
+	}
+	return &rejected, nil
+}
+
+// SyncRefunds settles the refunds that have been pending for longer than maxAge with what the
+// gateway tells about them, in case their webhook got lost, and submits again those the gateway
+// never got.
+func (s *PaymentService) SyncRefunds(maxAge time.Duration) error {
+	refunds, err := s.refunds.GetStaleRefunds(models.RefundPending, time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		return err
+	}
+	for i := range refunds {
+		refund := &refunds[i]
+		if err := s.syncRefund(refund); err != nil {
+			log.Printf("Failed to sync refund %s: %v\n", refund.ID, err)
+		}
+	}
+	return nil
+}
+
+// SyncRefundsPeriodically runs SyncRefunds every interval until the process exits.
+func (s *PaymentService) SyncRefundsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.SyncRefunds(interval); err != nil {
+			log.Printf("Failed to sync refunds: %v\n", err)
+		}
+	}
+}
+
+func (s *PaymentService) syncRefund(refund *models.Refund) error {
+	if refund.GatewayReference == "" {
+		payment, err := s.db.GetPaymentByID(refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		_, err = s.submitRefund(payment, refund)
+		return err
+	}
+	status, failureReason, err := s.gateway.RefundStatus(refund.GatewayReference)
+	if err != nil {
+		return fmt.Errorf("error fetching refund status: %v", err)
+	}
+	if status != models.RefundSucceeded && status != models.RefundFailed {
+		return nil
+	}
+	return s.settleRefund(refund, status, failureReason, newEventID())
+}
+
+// reviewable returns a refund waiting for approval that the requester may approve or reject.
+func (s *PaymentService) reviewable(requester models.Requester, id string) (*models.Refund, error) {
+	refund, err := s.GetRefund(requester, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !requester.Admin:
+		return nil, fmt.Errorf("%w: only admins review refunds", models.ErrForbidden)
+	case refund.RequestedBy == requester.Name():
+		return nil, fmt.Errorf("%w: refunds are reviewed by someone else than who asked for them", models.ErrForbidden)
+	case refund.Status != models.RefundPendingApproval:
+		return nil, fmt.Errorf("%w: refund is %s", models.ErrInvalidTransition, refund.Status)
+	}
+	return refund, nil
+}
+
+// submitRefund sends a pending refund to the gateway and records the reference of the gateway.
+// When the gateway cannot be reached, the refund stays pending and SyncRefunds submits it again.
+func (s *PaymentService) submitRefund(payment *models.Payment, refund *models.Refund) (*models.Refund, error) {
+	reference, err := s.gateway.Refund(payment.GatewayReference, refund.ID, refund.Amount, refund.Currency)
+	if err != nil {
+		log.Printf("Failed to submit refund %s to the gateway: %v\n", refund.ID, err)
+		return refund, nil
+	}
+
+	submitted := *refund
+	submitted.GatewayReference = reference
+	event := newRefundEvent(payment, &submitted, models.SourceAPI, "refund submitted to gateway")
+	if err := s.refunds.UpdateRefund(&submitted, refund.Version, nil, 0, event); err != nil {
+		return nil, err
+	}
+	return &submitted, nil
+}
+
+// handleRefundEvent applies the outcome of a refund the gateway reported through a webhook.
+func (s *PaymentService) handleRefundEvent(event *models.GatewayEvent) error {
+	refund, err := s.refunds.GetRefundByGatewayReference(event.Reference)
+	if err != nil {
+		return err
+	}
+	status := models.RefundSucceeded
+	if event.Type == models.GatewayRefundFailed {
+		status = models.RefundFailed
+	}
+	err = s.settleRefund(refund, status, event.DeclineReason, event.ID)
+	if errors.Is(err, models.ErrDuplicateEvent) {
+		return nil
+	}
+	return err
+}
+
+// settleRefund records that a pending refund succeeded or failed. A refund that succeeded moves
+// its amount to the refunded amount of its payment, and once the whole payment was refunded,
+// the payment and its booking become refunded; a refund that failed gives its amount back to
+// what is left to refund.
+func (s *PaymentService) settleRefund(refund *models.Refund, status models.RefundStatus, failureReason string, eventID string) error {
+	if refund.Status != models.RefundPending {
+		return nil
+	}
+	payment, err := s.db.GetPaymentByID(refund.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	settled := *refund
+	now := time.Now().UTC()
+	settled.Status = status
+	settled.SettledAt = &now
+	updated := *payment
+	updated.PendingRefunds = models.RoundCents(updated.PendingRefunds - refund.Amount)
+	if status == models.RefundSucceeded {
+		updated.RefundedAmount = models.RoundCents(updated.RefundedAmount + refund.Amount)
+		if updated.RefundedAmount >= updated.Amount && updated.CanMoveTo(models.StatusRefunded) {
+			updated.Status = models.StatusRefunded
+		}
+	} else {
+		settled.FailureReason = failureReason
+	}
+
+	event := newRefundEvent(&updated, &settled, models.SourceGateway, failureReason)
+	event.ID = eventID
+	if err := s.refunds.UpdateRefund(&settled, refund.Version, &updated, payment.Version, event); err != nil {
+		return err
+	}
+	if updated.Status == models.StatusRefunded {
+		s.moveBooking(&updated)
+	}
+	return nil
+}
+
+func newRefundEvent(payment *models.Payment, refund *models.Refund, source models.EventSource, detail string) models.PaymentEvent {
+	event := newEvent(payment, source, detail)
+	event.RefundID = refund.ID
+	event.RefundStatus = refund.Status
+	return event
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type LoyaltyHandler struct {
+	service ports.LoyaltyService
+}
+
+func NewLoyaltyHandler(service ports.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+// RegisterRoutes registers the ledger endpoints. Points are earned, redeemed and given back only
+// on behalf of the services owning the bookings; users read their own statement.
+func (h *LoyaltyHandler) RegisterRoutes(router *mux.Router) {
+	loyaltyRouter := router.PathPrefix("/loyalty").Subrouter()
+	loyaltyRouter.Use(middleware.JWTMiddleware)
+	loyaltyRouter.HandleFunc("/events", h.RecordBookingEvent).Methods(http.MethodPost)
+	loyaltyRouter.HandleFunc("/redemptions", h.RedeemPoints).Methods(http.MethodPost)
+	loyaltyRouter.HandleFunc("/redemptions/reverse", h.ReverseRedemptions).Methods(http.MethodPost)
+
+	router.Handle("/users/{id}/loyalty/statement", middleware.JWTMiddleware(http.HandlerFunc(h.GetStatement))).Methods(http.MethodGet)
+}
+
+func (h *LoyaltyHandler) RecordBookingEvent(w http.ResponseWriter, r *http.Request) {
+	if !requireService(w, r) {
+		return
+	}
+	var event models.BookingEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.RecordBookingEvent(event)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *LoyaltyHandler) RedeemPoints(w http.ResponseWriter, r *http.Request) {
+	if !requireService(w, r) {
+		return
+	}
+	var redemption models.PointsRedemption
+	if err := json.NewDecoder(r.Body).Decode(&redemption); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.RedeemPoints(redemption)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *LoyaltyHandler) ReverseRedemptions(w http.ResponseWriter, r *http.Request) {
+	if !requireService(w, r) {
+		return
+	}
+	var reversal models.RedemptionReversal
+	if err := json.NewDecoder(r.Body).Decode(&reversal); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.ReverseRedemptions(reversal)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetStatement returns the balance, tier and entries of a user. The period is given with the
+// optional from and to query parameters in RFC 3339.
+func (h *LoyaltyHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !requireUserOrPrivileged(w, r, userID) {
+		return
+	}
+	var from, to time.Time
+	for name, value := range map[string]*time.Time{"from": &from, "to": &to} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s: expected RFC 3339", name), http.StatusBadRequest)
+			return
+		}
+		*value = parsed
+	}
+
+	statement, err := h.service.GetStatement(userID, from, to)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), loyaltyErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statement)
+}
+
+// requireService responds 401 or 403 unless the request comes from another service.
+func requireService(w http.ResponseWriter, r *http.Request) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if ok && !caller.IsService() {
+		http.Error(w, "Only services can change the points of users", http.StatusForbidden)
+		return false
+	}
+	return ok
+}
+
+// requireUserOrPrivileged responds 401 or 403 unless the request comes from the given user, an
+// agent, an admin or another service.
+func requireUserOrPrivileged(w http.ResponseWriter, r *http.Request, userID string) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if ok && !caller.IsService() && !caller.IsStaff() && caller.UserID != userID {
+		http.Error(w, "Users can only read their own records", http.StatusForbidden)
+		return false
+	}
+	return ok
+}
+
+// loyaltyErrorStatus maps the errors of the loyalty service to HTTP status codes.
+func loyaltyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidLoyaltyRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrInsufficientPoints):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrEntryNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"microservices-travel-backend/pkg/middlewares"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	service ports.NotificationService
+}
+
+func NewNotificationHandler(service ports.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// RegisterRoutes registers the notification endpoints. Other services ask for emails to users;
+// agents and admins look after the dead letters; users read their own delivery log.
+func (h *NotificationHandler) RegisterRoutes(router *mux.Router) {
+	notificationRouter := router.PathPrefix("/notifications").Subrouter()
+	notificationRouter.Use(middleware.JWTMiddleware)
+	notificationRouter.HandleFunc("", h.Notify).Methods(http.MethodPost)
+	notificationRouter.HandleFunc("/dead-letters", h.GetDeadLetters).Methods(http.MethodGet)
+	notificationRouter.HandleFunc("/{id}/retry", h.RetryDeadLetter).Methods(http.MethodPost)
+
+	router.Handle("/users/{id}/notifications", middleware.JWTMiddleware(http.HandlerFunc(h.GetDeliveryLog))).Methods(http.MethodGet)
+}
+
+// Notify queues an email to a user. It responds 202 since the email may still be waiting for a
+// retry; the returned notification tells whether it was sent.
+func (h *NotificationHandler) Notify(w http.ResponseWriter, r *http.Request) {
+	if !requireService(w, r) {
+		return
+	}
+	var request models.NotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	notification, err := h.service.Notify(request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), notificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(notification)
+}
+
+func (h *NotificationHandler) GetDeliveryLog(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if !requireUserOrPrivileged(w, r, userID) {
+		return
+	}
+
+	notifications, err := h.service.GetDeliveryLog(userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), notificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notifications)
+}
+
+func (h *NotificationHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+
+	notifications, err := h.service.GetDeadLetters()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), notificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// RetryDeadLetter sends a dead letter again with a fresh set of attempts.
+func (h *NotificationHandler) RetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !requirePrivileged(w, r) {
+		return
+	}
+
+	notification, err := h.service.RetryDeadLetter(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), notificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(notification)
+}
+
+// requirePrivileged responds 401 or 403 unless the request comes from an agent, an admin or
+// another service.
+func requirePrivileged(w http.ResponseWriter, r *http.Request) bool {
+	caller, ok := middleware.RequireCaller(w, r)
+	if ok && !caller.IsService() && !caller.IsStaff() {
+		http.Error(w, "Only agents and admins can manage notifications", http.StatusForbidden)
+		return false
+	}
+	return ok
+}
+
+// notificationErrorStatus maps the errors of the notification service to HTTP status codes.
+func notificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidNotification):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrNotificationNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
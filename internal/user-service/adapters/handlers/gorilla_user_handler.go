@@ -13,14 +13,17 @@ import (
 
 type UserHandler struct {
 	userService ports.UserServicePort
+	idempotent  mux.MiddlewareFunc
 }
 
-func NewUserHandler(service ports.UserServicePort) *UserHandler {
-	return &UserHandler{userService: service}
+// NewUserHandler creates a handler whose signup endpoint is wrapped in idempotent, so a retried
+// signup with the same Idempotency-Key does not try to create the user twice.
+func NewUserHandler(service ports.UserServicePort, idempotent mux.MiddlewareFunc) *UserHandler {
+	return &UserHandler{userService: service, idempotent: idempotent}
 }
 
 func (h *UserHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/users", h.CreateUser).Methods(http.MethodPost)
+	router.Handle("/users", h.idempotent(http.HandlerFunc(h.CreateUser))).Methods(http.MethodPost)
 	router.HandleFunc("/users/login", h.Login).Methods(http.MethodPost)
 	router.HandleFunc("/users/{id}", h.GetUserByID).Methods(http.MethodGet)
 	router.HandleFunc("/users", h.GetAllUsers).Methods(http.MethodGet)
@@ -0,0 +1,102 @@
+package notifiers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPOptions configure the mail server emails are handed to. Without a username no
+// authentication is attempted, which suits a local catch-all server such as Mailpit.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // Sender address, optionally with a name: "Travel <no-reply@example.com>".
+}
+
+// SMTPNotifier sends emails as multipart/alternative messages with a plain text and an HTML part.
+type SMTPNotifier struct {
+	options SMTPOptions
+	from    *mail.Address
+}
+
+func NewSMTPNotifier(options SMTPOptions) (*SMTPNotifier, error) {
+	from, err := mail.ParseAddress(options.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %v", options.From, err)
+	}
+	return &SMTPNotifier{options: options, from: from}, nil
+}
+
+func (n *SMTPNotifier) Send(message models.EmailMessage) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %v", message.To, err)
+	}
+	body, err := n.compose(to, message)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(n.options.Host, strconv.Itoa(n.options.Port))
+	var auth smtp.Auth
+	if n.options.Username != "" {
+		auth = smtp.PlainAuth("", n.options.Username, n.options.Password, n.options.Host)
+	}
+	if err := smtp.SendMail(address, auth, n.from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("error sending email to %s: %v", to.Address, err)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) compose(to *mail.Address, message models.EmailMessage) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buffer, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buffer, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", message.Text},
+		{"text/html", message.HTML},
+	} {
+		fmt.Fprintf(&buffer, "--%s\r\n", boundary)
+		fmt.Fprintf(&buffer, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		fmt.Fprintf(&buffer, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writer := quotedprintable.NewWriter(&buffer)
+		if _, err := writer.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		buffer.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buffer, "--%s--\r\n", boundary)
+	return buffer.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("error generating MIME boundary: %v", err)
+	}
+	return hex.EncodeToString(random), nil
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgreSQLLoyaltyRepository keeps the points ledger next to the users. Every write locks the
+// account of its user first, so that the balance and the remaining points of the credits change
+// one entry at a time.
+type PostgreSQLLoyaltyRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLLoyaltyRepository(db *gorm.DB) (*PostgreSQLLoyaltyRepository, error) {
+	if err := db.AutoMigrate(&models.LoyaltyAccount{}, &models.LedgerEntry{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate loyalty tables: %v", err)
+	}
+	return &PostgreSQLLoyaltyRepository{db: db}, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) AddCredit(entry *models.LedgerEntry) error {
+	return repo.record(entry, func(tx *gorm.DB) error { return nil })
+}
+
+func (repo *PostgreSQLLoyaltyRepository) RedeemPoints(entry *models.LedgerEntry) error {
+	return repo.record(entry, func(tx *gorm.DB) error {
+		var credits []models.LedgerEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", entry.UserID, entry.CreatedAt).
+			Order("expires_at, created_at, id").Find(&credits).Error
+		if err != nil {
+			return err
+		}
+
+		needed := -entry.Points
+		for _, credit := range credits {
+			if needed == 0 {
+				break
+			}
+			used := credit.Remaining
+			if used > needed {
+				used = needed
+			}
+			if err := tx.Model(&credit).Update("remaining", credit.Remaining-used).Error; err != nil {
+				return err
+			}
+			needed -= used
+			if credit.ExpiresAt != nil && (entry.ExpiresAt == nil || credit.ExpiresAt.After(*entry.ExpiresAt)) {
+				entry.ExpiresAt = credit.ExpiresAt
+			}
+		}
+		if needed > 0 {
+			return models.ErrInsufficientPoints
+		}
+		return nil
+	})
+}
+
+// ExpireCredits expires each credit in its own transaction, so that one failing leaves the others
+// expired and it is tried again on the next run.
+func (repo *PostgreSQLLoyaltyRepository) ExpireCredits(at time.Time) ([]models.LedgerEntry, error) {
+	var credits []models.LedgerEntry
+	if err := repo.db.Where("remaining > 0 AND expires_at <= ?", at).Order("expires_at, id").Find(&credits).Error; err != nil {
+		return nil, fmt.Errorf("error fetching expired credits: %v", err)
+	}
+
+	expired := []models.LedgerEntry{}
+	for _, credit := range credits {
+		entry := models.LedgerEntry{
+			ID:             models.ExpiredEntryID(credit.ID),
+			UserID:         credit.UserID,
+			Type:           models.EntryExpired,
+			EventID:        credit.EventID,
+			EventType:      credit.EventType,
+			Source:         credit.Source,
+			BookingID:      credit.BookingID,
+			Reference:      credit.Reference,
+			RelatedEntryID: credit.ID,
+			CreatedAt:      at,
+		}
+		err := repo.record(&entry, func(tx *gorm.DB) error {
+			// Read again under the lock of the account, in case points were redeemed meanwhile.
+			var locked models.LedgerEntry
+			if err := tx.First(&locked, "id = ?", credit.ID).Error; err != nil {
+				return err
+			}
+			entry.Points = -locked.Remaining
+			return tx.Model(&locked).Update("remaining", 0).Error
+		})
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to expire points of entry %s: %v\n", credit.ID, err)
+			continue
+		}
+		expired = append(expired, entry)
+	}
+	return expired, nil
+}
+
+// record stores an entry and changes the balance of its user by its points, after apply made its
+// other changes. The points of apply's entry may still be set by apply.
+func (repo *PostgreSQLLoyaltyRepository) record(entry *models.LedgerEntry, apply func(tx *gorm.DB) error) error {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		account := models.LoyaltyAccount{UserID: entry.UserID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "user_id = ?", entry.UserID).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.LedgerEntry{}).Where("id = ?", entry.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return models.ErrDuplicateEntry
+		}
+
+		if err := apply(tx); err != nil {
+			return err
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&account).Update("balance", gorm.Expr("balance + ?", entry.Points)).Error
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrDuplicateEntry) || errors.Is(err, models.ErrInsufficientPoints) {
+			return err
+		}
+		return fmt.Errorf("error recording ledger entry: %v", err)
+	}
+	return nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) GetAccount(userID string) (*models.LoyaltyAccount, error) {
+	var account models.LoyaltyAccount
+	if err := repo.db.First(&account, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.LoyaltyAccount{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("error fetching loyalty account: %v", err)
+	}
+	return &account, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) GetEntry(id string) (*models.LedgerEntry, error) {
+	var entry models.LedgerEntry
+	if err := repo.db.First(&entry, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrEntryNotFound
+		}
+		return nil, fmt.Errorf("error fetching ledger entry: %v", err)
+	}
+	return &entry, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) GetEntries(userID string, from time.Time, to time.Time) ([]models.LedgerEntry, error) {
+	entries := []models.LedgerEntry{}
+	err := repo.db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Order("created_at, id").Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ledger entries: %v", err)
+	}
+	return entries, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) GetEntriesByBooking(bookingID string) ([]models.LedgerEntry, error) {
+	entries := []models.LedgerEntry{}
+	if err := repo.db.Where("booking_id = ?", bookingID).Order("created_at, id").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("error fetching ledger entries: %v", err)
+	}
+	return entries, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) EarnedSince(userID string, since time.Time) (int, error) {
+	var earned int
+	err := repo.db.Model(&models.LedgerEntry{}).Select("COALESCE(SUM(points), 0)").
+		Where("user_id = ? AND type = ? AND created_at >= ?", userID, models.EntryEarned, since).Scan(&earned).Error
+	if err != nil {
+		return 0, fmt.Errorf("error adding up earned points: %v", err)
+	}
+	return earned, nil
+}
+
+func (repo *PostgreSQLLoyaltyRepository) ExpiringPoints(userID string, before time.Time) (int, error) {
+	var expiring int
+	err := repo.db.Model(&models.LedgerEntry{}).Select("COALESCE(SUM(remaining), 0)").
+		Where("user_id = ? AND remaining > 0 AND expires_at < ?", userID, before).Scan(&expiring).Error
+	if err != nil {
+		return 0, fmt.Errorf("error adding up expiring points: %v", err)
+	}
+	return expiring, nil
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgreSQLNotificationRepository keeps the notifications of users next to the users. Pending
+// notifications double as the retry queue and dead letters stay in the table with their status.
+type PostgreSQLNotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLNotificationRepository(db *gorm.DB) (*PostgreSQLNotificationRepository, error) {
+	if err := db.AutoMigrate(&models.Notification{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate notifications table: %v", err)
+	}
+	return &PostgreSQLNotificationRepository{db: db}, nil
+}
+
+func (repo *PostgreSQLNotificationRepository) CreateNotification(notification *models.Notification) error {
+	result := repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if result.Error != nil {
+		return fmt.Errorf("error creating notification: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrDuplicateNotification
+	}
+	return nil
+}
+
+func (repo *PostgreSQLNotificationRepository) GetNotification(id string) (*models.Notification, error) {
+	var notification models.Notification
+	if err := repo.db.First(&notification, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("error fetching notification: %v", err)
+	}
+	return &notification, nil
+}
+
+func (repo *PostgreSQLNotificationRepository) UpdateNotification(notification *models.Notification) error {
+	result := repo.db.Model(notification).Select("*").Omit("id", "created_at").Updates(notification)
+	if result.Error != nil {
+		return fmt.Errorf("error updating notification: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (repo *PostgreSQLNotificationRepository) ClaimNotification(id string, at time.Time, until time.Time) (bool, error) {
+	result := repo.db.Model(&models.Notification{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.NotificationPending, at).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, fmt.Errorf("error claiming notification: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *PostgreSQLNotificationRepository) GetDueNotifications(at time.Time, limit int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := repo.db.Where("status = ? AND next_attempt_at <= ?", models.NotificationPending, at).
+		Order("next_attempt_at, id").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching due notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+func (repo *PostgreSQLNotificationRepository) GetNotificationsByUser(userID string) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	if err := repo.db.Where("user_id = ?", userID).Order("created_at DESC, id").Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("error fetching notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+func (repo *PostgreSQLNotificationRepository) GetDeadLetters() ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	err := repo.db.Where("status = ?", models.NotificationDeadLetter).Order("created_at, id").Find(&notifications).Error
+	if err != nil {
+		return nil, fmt.Errorf("error fetching dead letters: %v", err)
+	}
+	return notifications, nil
+}
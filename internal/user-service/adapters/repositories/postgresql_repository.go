@@ -45,6 +45,11 @@ func NewPostgreSQLUserRepository() (*PostgreSQLUserRepository, error) {
 	return &PostgreSQLUserRepository{db: db}, nil
 }
 
+// DB returns the connection of the repository, for stores that keep their tables next to the users.
+func (repo *PostgreSQLUserRepository) DB() *gorm.DB {
+	return repo.db
+}
+
 // Create creates a new user in the database using GORM
 func (repo *PostgreSQLUserRepository) Create(user models.User) (*models.User, error) {
 	if user.ID == "" {
@@ -0,0 +1,101 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// files holds email/<locale>/<template>.txt and .html. The text template defines the subject in a
+// "subject" block.
+//
+//go:embed email
+var files embed.FS
+
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// EmailTemplates renders the embedded email templates. Values missing from the data fail the
+// rendering rather than leaving a gap in the email.
+type EmailTemplates struct {
+	templates map[string]map[string]localized // By template, then locale.
+}
+
+func NewEmailTemplates() (*EmailTemplates, error) {
+	t := &EmailTemplates{templates: make(map[string]map[string]localized)}
+	locales, err := fs.ReadDir(files, "email")
+	if err != nil {
+		return nil, err
+	}
+	for _, locale := range locales {
+		dir := path.Join("email", locale.Name())
+		entries, err := fs.ReadDir(files, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".txt")
+			if !ok {
+				continue
+			}
+			text, err := texttemplate.New(entry.Name()).Option("missingkey=error").ParseFS(files, path.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing template %s: %v", entry.Name(), err)
+			}
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("template %s/%s defines no subject", locale.Name(), entry.Name())
+			}
+			html, err := htmltemplate.New(name+".html").Option("missingkey=error").ParseFS(files, path.Join(dir, name+".html"))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing template %s.html: %v", name, err)
+			}
+			if t.templates[name] == nil {
+				t.templates[name] = make(map[string]localized)
+			}
+			t.templates[name][locale.Name()] = localized{text: text, html: html}
+		}
+	}
+	return t, nil
+}
+
+func (t *EmailTemplates) Has(template string) bool {
+	_, ok := t.templates[template][models.DefaultLocale]
+	return ok
+}
+
+func (t *EmailTemplates) Render(template string, locale string, data map[string]string) (*models.EmailMessage, error) {
+	translations, ok := t.templates[template]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown template %q", models.ErrInvalidNotification, template)
+	}
+	// "de-AT" falls back to "de", then to the default locale.
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	language, _, _ := strings.Cut(locale, "-")
+	chosen, ok := translations[locale]
+	if !ok {
+		chosen, ok = translations[language]
+	}
+	if !ok {
+		chosen = translations[models.DefaultLocale]
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := chosen.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("error rendering subject of %s: %v", template, err)
+	}
+	if err := chosen.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("error rendering %s: %v", template, err)
+	}
+	if err := chosen.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("error rendering %s: %v", template, err)
+	}
+	return &models.EmailMessage{Subject: strings.TrimSpace(subject.String()), Text: text.String(), HTML: html.String()}, nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "errors"
+
+var (
+	// ErrInvalidLoyaltyRequest is returned when a booking event, redemption or statement request is
+	// missing required details.
+	ErrInvalidLoyaltyRequest = errors.New("invalid loyalty request")
+	// ErrInsufficientPoints is returned when a user redeems more points than they have.
+	ErrInsufficientPoints = errors.New("not enough points")
+	// ErrDuplicateEntry is returned when the ledger already has an entry for an event.
+	ErrDuplicateEntry = errors.New("ledger entry already recorded")
+	// ErrEntryNotFound is returned when a ledger entry does not exist.
+	ErrEntryNotFound = errors.New("ledger entry not found")
+	// ErrInvalidNotification is returned when a notification names no user or an unknown template.
+	ErrInvalidNotification = errors.New("invalid notification")
+	// ErrNotificationNotFound is returned when a notification does not exist.
+	ErrNotificationNotFound = errors.New("notification not found")
+	// ErrDuplicateNotification is returned when a notification was already requested.
+	ErrDuplicateNotification = errors.New("notification already requested")
+)
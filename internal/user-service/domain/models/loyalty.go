@@ -0,0 +1,159 @@
+package models
+
+import "time"
+
+type LedgerEntryType string
+
+const (
+	EntryEarned   LedgerEntryType = "earned"
+	EntryRedeemed LedgerEntryType = "redeemed"
+	EntryRestored LedgerEntryType = "restored" // Redeemed points given back because the booking was cancelled.
+	EntryExpired  LedgerEntryType = "expired"
+)
+
+type BookingEventType string
+
+// Events of the other services that move points.
+const (
+	EventHotelStayCheckedOut BookingEventType = "hotel_stay.checked_out"
+	EventFlightSegmentFlown  BookingEventType = "flight_segment.flown"
+	EventPaidWithPoints      BookingEventType = "booking.paid_with_points"
+	EventBookingCancelled    BookingEventType = "booking.cancelled"
+)
+
+// BookingEvent is reported by the service owning a booking when the traveller completed part of
+// it, e.g. checked out of a hotel or flew a segment. Events are recognised by their source and ID,
+// so reporting one twice earns nothing more.
+type BookingEvent struct {
+	ID         string           `json:"id"`
+	Source     string           `json:"source"` // Service that reported the event.
+	Type       BookingEventType `json:"type"`
+	UserID     string           `json:"user_id"`
+	BookingID  string           `json:"booking_id"`
+	Reference  string           `json:"reference,omitempty"` // What was completed, e.g. a ticket and coupon number.
+	Amount     float64          `json:"amount"`              // What the traveller paid for it.
+	Currency   string           `json:"currency"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// PointsRedemption pays for a booking with points, as far as the points go. The event ID is that
+// of the payment, so a retried payment redeems the points once.
+type PointsRedemption struct {
+	EventID   string  `json:"event_id"`
+	Source    string  `json:"source"`
+	UserID    string  `json:"user_id"`
+	BookingID string  `json:"booking_id"`
+	Points    int     `json:"points"`   // Most points to use.
+	Amount    float64 `json:"amount"`   // Price of the booking; no more points are used than it takes.
+	Currency  string  `json:"currency"` // Currency of the price.
+}
+
+// RedemptionReversal gives back the points redeemed for a cancelled booking.
+type RedemptionReversal struct {
+	EventID   string `json:"event_id"` // Event of the cancellation.
+	Source    string `json:"source"`
+	BookingID string `json:"booking_id"`
+}
+
+// LedgerEntry is a change of the points of a user. Entries are never changed afterwards except
+// for the points of a credit that are still unused, and each one names the booking event it was
+// made for.
+type LedgerEntry struct {
+	ID        string           `json:"id" gorm:"primaryKey"` // Derived from the event, see the Entry ID functions.
+	UserID    string           `json:"user_id" gorm:"index"`
+	Type      LedgerEntryType  `json:"type"`
+	Points    int              `json:"points"` // Positive for earned and restored points, negative otherwise.
+	EventID   string           `json:"event_id" gorm:"index"`
+	EventType BookingEventType `json:"event_type"`
+	Source    string           `json:"source"`
+	BookingID string           `json:"booking_id" gorm:"index"`
+	Reference string           `json:"reference,omitempty"`
+	Amount    float64          `json:"amount,omitempty"` // Spent on earned points, or paid with redeemed points.
+	Currency  string           `json:"currency,omitempty"`
+	// Remaining are the points of a credit not yet redeemed or expired; they expire at ExpiresAt.
+	// A redemption keeps the latest expiry of the points it used, which they get back when it is
+	// restored.
+	Remaining      int        `json:"remaining,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	RelatedEntryID string     `json:"related_entry_id,omitempty"` // Credit an expiry is for, or the redemption a restore gives back.
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Credit reports whether the entry adds points that can be redeemed.
+func (e *LedgerEntry) Credit() bool {
+	return e.Type == EntryEarned || e.Type == EntryRestored
+}
+
+func EarnedEntryID(source string, eventID string) string {
+	return "earned:" + source + ":" + eventID
+}
+
+func RedeemedEntryID(source string, eventID string) string {
+	return "redeemed:" + source + ":" + eventID
+}
+
+func RestoredEntryID(redemptionID string) string {
+	return "restored:" + redemptionID
+}
+
+func ExpiredEntryID(creditID string) string {
+	return "expired:" + creditID
+}
+
+// LoyaltyAccount holds the points balance of a user; it changes only with entries of the ledger.
+type LoyaltyAccount struct {
+	UserID    string    `json:"user_id" gorm:"primaryKey"`
+	Balance   int       `json:"balance"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Tier string
+
+const (
+	TierBlue     Tier = "blue"
+	TierSilver   Tier = "silver"
+	TierGold     Tier = "gold"
+	TierPlatinum Tier = "platinum"
+)
+
+// TierLevel is reached with the points earned during the qualifying period and multiplies the
+// points earned from then on.
+type TierLevel struct {
+	Tier             Tier    `json:"tier"`
+	QualifyingPoints int     `json:"qualifying_points"`
+	EarnMultiplier   float64 `json:"earn_multiplier"`
+}
+
+// TierLevels are ordered from the lowest to the highest tier.
+var TierLevels = []TierLevel{
+	{Tier: TierBlue, QualifyingPoints: 0, EarnMultiplier: 1},
+	{Tier: TierSilver, QualifyingPoints: 5000, EarnMultiplier: 1.25},
+	{Tier: TierGold, QualifyingPoints: 15000, EarnMultiplier: 1.5},
+	{Tier: TierPlatinum, QualifyingPoints: 40000, EarnMultiplier: 2},
+}
+
+// TierFor returns the level reached with the given qualifying points.
+func TierFor(qualifyingPoints int) TierLevel {
+	level := TierLevels[0]
+	for _, candidate := range TierLevels {
+		if qualifyingPoints >= candidate.QualifyingPoints {
+			level = candidate
+		}
+	}
+	return level
+}
+
+// LoyaltyStatement shows the balance and status of a user and the entries of a period.
+type LoyaltyStatement struct {
+	UserID           string        `json:"user_id"`
+	Balance          int           `json:"balance"`
+	Tier             Tier          `json:"tier"`
+	QualifyingPoints int           `json:"qualifying_points"`             // Earned during the qualifying period up to now.
+	NextTier         Tier          `json:"next_tier,omitempty"`           // Empty at the highest tier.
+	PointsToNextTier int           `json:"points_to_next_tier,omitempty"` // Still to earn for the next tier.
+	ExpiringPoints   int           `json:"expiring_points"`               // Points expiring before ExpiringBefore.
+	ExpiringBefore   time.Time     `json:"expiring_before"`
+	From             time.Time     `json:"from"`
+	To               time.Time     `json:"to"`
+	Entries          []LedgerEntry `json:"entries"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+type NotificationStatus string
+
+const (
+	NotificationPending    NotificationStatus = "pending"     // Waiting for its next delivery attempt.
+	NotificationSent       NotificationStatus = "sent"        // Accepted by the mail server.
+	NotificationDeadLetter NotificationStatus = "dead_letter" // Gave up on; an admin can send it again.
+)
+
+// Templates of the emails sent to users. Each is rendered in the locale of its recipient, or in
+// DefaultLocale when there is no translation.
+const (
+	TemplatePasswordReset    = "password_reset"
+	TemplateBookingConfirmed = "booking_confirmed"
+	TemplateBookingCancelled = "booking_cancelled"
+	TemplateTripReminder     = "trip_reminder"
+)
+
+const DefaultLocale = "en"
+
+// NotificationRequest asks for an email to a user. Requests are recognised by their source and
+// ID, so a service repeating one after a timeout does not send the email twice.
+type NotificationRequest struct {
+	ID       string            `json:"id,omitempty"` // E.g. the ID of the event the email is about.
+	Source   string            `json:"source"`
+	UserID   string            `json:"user_id"`
+	Template string            `json:"template"`
+	Locale   string            `json:"locale,omitempty"` // Defaults to the locale of the user.
+	Data     map[string]string `json:"data,omitempty"`   // Values the template fills in.
+}
+
+// Notification is an email to a user and the record of delivering it. The delivery log of a user
+// lists their notifications.
+type Notification struct {
+	ID            string             `json:"id" gorm:"primaryKey"`
+	UserID        string             `json:"user_id" gorm:"index"`
+	Source        string             `json:"source"`
+	Template      string             `json:"template"`
+	Locale        string             `json:"locale"`
+	Recipient     string             `json:"recipient"`
+	Subject       string             `json:"subject,omitempty"` // As last rendered.
+	Data          map[string]string  `json:"-" gorm:"serializer:json"`
+	Status        NotificationStatus `json:"status" gorm:"index"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at,omitempty" gorm:"index"`
+	LastError     string             `json:"last_error,omitempty"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func NotificationID(source string, requestID string) string {
+	return "notification:" + source + ":" + requestID
+}
+
+// EmailMessage is a rendered email with a plain text and an HTML body.
+type EmailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
@@ -7,6 +7,8 @@ type User struct {
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
 	Password  string    `json:"password"`
+	Locale    string    `json:"locale,omitempty"` // Language of the emails sent to the user, e.g. "de".
+	Role      string    `json:"role,omitempty"`   // "agent" or "admin" for staff, empty for travellers.
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
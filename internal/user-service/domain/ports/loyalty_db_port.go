@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"time"
+)
+
+// LoyaltyDB stores the points ledger. Every write records one entry and changes the balance of its
+// user in the same transaction, failing with models.ErrDuplicateEntry when the entry ID is taken.
+type LoyaltyDB interface {
+	// AddCredit records earned or restored points.
+	AddCredit(entry *models.LedgerEntry) error
+	// RedeemPoints records a redemption, using the remaining points of the user's credits that
+	// expire soonest first. It fails with models.ErrInsufficientPoints when the credits that have
+	// not expired do not cover it.
+	RedeemPoints(entry *models.LedgerEntry) error
+	// ExpireCredits records the expiry of the remaining points of every credit that expired at the
+	// given time and returns the expiry entries.
+	ExpireCredits(at time.Time) ([]models.LedgerEntry, error)
+	// GetAccount returns the account of a user, with a zero balance before their first entry.
+	GetAccount(userID string) (*models.LoyaltyAccount, error)
+	GetEntry(id string) (*models.LedgerEntry, error)
+	// GetEntries lists the entries of a user made in [from, to), oldest first.
+	GetEntries(userID string, from time.Time, to time.Time) ([]models.LedgerEntry, error)
+	GetEntriesByBooking(bookingID string) ([]models.LedgerEntry, error)
+	// EarnedSince adds up the points a user earned since the given time.
+	EarnedSince(userID string, since time.Time) (int, error)
+	// ExpiringPoints adds up the remaining points of a user's credits expiring before the given time.
+	ExpiringPoints(userID string, before time.Time) (int, error)
+}
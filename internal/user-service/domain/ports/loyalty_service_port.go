@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"time"
+)
+
+type LoyaltyService interface {
+	RecordBookingEvent(event models.BookingEvent) (*models.LedgerEntry, error)
+	RedeemPoints(redemption models.PointsRedemption) (*models.LedgerEntry, error)
+	ReverseRedemptions(reversal models.RedemptionReversal) ([]models.LedgerEntry, error)
+	GetStatement(userID string, from time.Time, to time.Time) (*models.LoyaltyStatement, error)
+}
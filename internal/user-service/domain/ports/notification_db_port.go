@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"time"
+)
+
+// NotificationDB stores notifications until they are delivered and keeps them as delivery log.
+type NotificationDB interface {
+	// CreateNotification fails with models.ErrDuplicateNotification when the ID is taken.
+	CreateNotification(notification *models.Notification) error
+	GetNotification(id string) (*models.Notification, error)
+	UpdateNotification(notification *models.Notification) error
+	// ClaimNotification moves the next attempt of a pending notification that is due at the given
+	// time to until, so that no other replica delivers it meanwhile. It returns false when the
+	// notification is not due, e.g. because another replica claimed it first.
+	ClaimNotification(id string, at time.Time, until time.Time) (bool, error)
+	// GetDueNotifications lists up to limit pending notifications whose next attempt is due at the
+	// given time, oldest first.
+	GetDueNotifications(at time.Time, limit int) ([]models.Notification, error)
+	// GetNotificationsByUser lists the notifications of a user, newest first.
+	GetNotificationsByUser(userID string) ([]models.Notification, error)
+	// GetDeadLetters lists the notifications given up on, oldest first.
+	GetDeadLetters() ([]models.Notification, error)
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "microservices-travel-backend/internal/user-service/domain/models"
+
+type NotificationService interface {
+	Notify(request models.NotificationRequest) (*models.Notification, error)
+	GetDeliveryLog(userID string) ([]models.Notification, error)
+	GetDeadLetters() ([]models.Notification, error)
+	RetryDeadLetter(id string) (*models.Notification, error)
+}
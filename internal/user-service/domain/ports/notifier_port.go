@@ -0,0 +1,17 @@
+package ports
+
+import "microservices-travel-backend/internal/user-service/domain/models"
+
+// Notifier delivers rendered emails, e.g. through an SMTP server.
+type Notifier interface {
+	Send(message models.EmailMessage) error
+}
+
+// EmailTemplates renders the email templates in the locales they are translated to.
+type EmailTemplates interface {
+	// Has reports whether a template exists in the default locale.
+	Has(template string) bool
+	// Render fills in a template in the given locale, falling back to the language without region
+	// and then to the default locale.
+	Render(template string, locale string, data map[string]string) (*models.EmailMessage, error)
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"strings"
+	"time"
+)
+
+// LoyaltyPolicy sets how many points are earned, what they are worth and for how long.
+type LoyaltyPolicy struct {
+	Currency         string                              // Currency points are earned and valued in.
+	EarnRates        map[models.BookingEventType]float64 // Points per unit of Currency spent.
+	PointValue       float64                             // Worth of a point in Currency when redeemed.
+	Validity         time.Duration                       // How long earned points can be redeemed.
+	QualifyingPeriod time.Duration                       // Period whose earned points set the tier.
+	ExpiryNotice     time.Duration                       // Statements show the points expiring within it.
+}
+
+func DefaultLoyaltyPolicy() LoyaltyPolicy {
+	return LoyaltyPolicy{
+		Currency: "EUR",
+		EarnRates: map[models.BookingEventType]float64{
+			models.EventHotelStayCheckedOut: 10,
+			models.EventFlightSegmentFlown:  5,
+		},
+		PointValue:       0.01,
+		Validity:         18 * 30 * 24 * time.Hour,
+		QualifyingPeriod: 365 * 24 * time.Hour,
+		ExpiryNotice:     90 * 24 * time.Hour,
+	}
+}
+
+type LoyaltyService struct {
+	db     ports.LoyaltyDB
+	rates  ports.ExchangeRates
+	policy LoyaltyPolicy
+}
+
+func NewLoyaltyService(db ports.LoyaltyDB, rates ports.ExchangeRates, policy LoyaltyPolicy) *LoyaltyService {
+	return &LoyaltyService{db: db, rates: rates, policy: policy}
+}
+
+// RecordBookingEvent earns the points for a completed hotel stay or flight segment, multiplied by
+// the tier the user has reached. An event reported again returns the entry it already earned.
+func (s *LoyaltyService) RecordBookingEvent(event models.BookingEvent) (*models.LedgerEntry, error) {
+	rate, ok := s.policy.EarnRates[event.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: points are not earned on %q events", models.ErrInvalidLoyaltyRequest, event.Type)
+	}
+	if event.ID == "" || event.Source == "" || event.UserID == "" || event.BookingID == "" {
+		return nil, fmt.Errorf("%w: id, source, user_id and booking_id are required", models.ErrInvalidLoyaltyRequest)
+	}
+	if event.Amount < 0 || !s.rates.Supports(event.Currency) {
+		return nil, fmt.Errorf("%w: a non-negative amount in a supported currency is required", models.ErrInvalidLoyaltyRequest)
+	}
+
+	id := models.EarnedEntryID(event.Source, event.ID)
+	if existing, err := s.db.GetEntry(id); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, models.ErrEntryNotFound) {
+		return nil, err
+	}
+
+	spent, err := s.rates.Convert(event.Amount, event.Currency, s.policy.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidLoyaltyRequest, err)
+	}
+	now := time.Now().UTC()
+	qualifying, err := s.db.EarnedSince(event.UserID, now.Add(-s.policy.QualifyingPeriod))
+	if err != nil {
+		return nil, err
+	}
+	points := int(math.Floor(spent * rate * models.TierFor(qualifying).EarnMultiplier))
+
+	expiresAt := now.Add(s.policy.Validity)
+	entry := &models.LedgerEntry{
+		ID:        id,
+		UserID:    event.UserID,
+		Type:      models.EntryEarned,
+		Points:    points,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Source:    event.Source,
+		BookingID: event.BookingID,
+		Reference: event.Reference,
+		Amount:    event.Amount,
+		Currency:  strings.ToUpper(event.Currency),
+		Remaining: points,
+		ExpiresAt: &expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.db.AddCredit(entry); err != nil {
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			return s.db.GetEntry(id)
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RedeemPoints pays for a booking with up to the requested points, using no more than its price
+// takes. The entry's amount is what the points paid, in the currency of the price.
+func (s *LoyaltyService) RedeemPoints(redemption models.PointsRedemption) (*models.LedgerEntry, error) {
+	if redemption.EventID == "" || redemption.Source == "" || redemption.UserID == "" || redemption.BookingID == "" {
+		return nil, fmt.Errorf("%w: event_id, source, user_id and booking_id are required", models.ErrInvalidLoyaltyRequest)
+	}
+	if redemption.Points <= 0 || redemption.Amount <= 0 || !s.rates.Supports(redemption.Currency) {
+		return nil, fmt.Errorf("%w: positive points and a positive amount in a supported currency are required", models.ErrInvalidLoyaltyRequest)
+	}
+
+	id := models.RedeemedEntryID(redemption.Source, redemption.EventID)
+	if existing, err := s.db.GetEntry(id); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, models.ErrEntryNotFound) {
+		return nil, err
+	}
+
+	value, err := s.rates.Convert(s.policy.PointValue, s.policy.Currency, redemption.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidLoyaltyRequest, err)
+	}
+	points := redemption.Points
+	if needed := int(math.Ceil(redemption.Amount/value - 1e-9)); needed < points {
+		points = needed
+	}
+	paid := math.Min(math.Round(float64(points)*value*100)/100, redemption.Amount)
+
+	entry := &models.LedgerEntry{
+		ID:        id,
+		UserID:    redemption.UserID,
+		Type:      models.EntryRedeemed,
+		Points:    -points,
+		EventID:   redemption.EventID,
+		EventType: models.EventPaidWithPoints,
+		Source:    redemption.Source,
+		BookingID: redemption.BookingID,
+		Amount:    paid,
+		Currency:  strings.ToUpper(redemption.Currency),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.db.RedeemPoints(entry); err != nil {
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			return s.db.GetEntry(id)
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ReverseRedemptions gives back the points redeemed for a booking, with the expiry they had. It
+// returns the restored entries, including those of an earlier reversal.
+func (s *LoyaltyService) ReverseRedemptions(reversal models.RedemptionReversal) ([]models.LedgerEntry, error) {
+	if reversal.EventID == "" || reversal.Source == "" || reversal.BookingID == "" {
+		return nil, fmt.Errorf("%w: event_id, source and booking_id are required", models.ErrInvalidLoyaltyRequest)
+	}
+	entries, err := s.db.GetEntriesByBooking(reversal.BookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := []models.LedgerEntry{}
+	for _, redeemed := range entries {
+		if redeemed.Type != models.EntryRedeemed {
+			continue
+		}
+		entry := models.LedgerEntry{
+			ID:             models.RestoredEntryID(redeemed.ID),
+			UserID:         redeemed.UserID,
+			Type:           models.EntryRestored,
+			Points:         -redeemed.Points,
+			EventID:        reversal.EventID,
+			EventType:      models.EventBookingCancelled,
+			Source:         reversal.Source,
+			BookingID:      redeemed.BookingID,
+			Amount:         redeemed.Amount,
+			Currency:       redeemed.Currency,
+			Remaining:      -redeemed.Points,
+			ExpiresAt:      redeemed.ExpiresAt,
+			RelatedEntryID: redeemed.ID,
+			CreatedAt:      time.Now().UTC(),
+		}
+		if err := s.db.AddCredit(&entry); err != nil {
+			if !errors.Is(err, models.ErrDuplicateEntry) {
+				return nil, err
+			}
+			existing, err := s.db.GetEntry(entry.ID)
+			if err != nil {
+				return nil, err
+			}
+			entry = *existing
+		}
+		restored = append(restored, entry)
+	}
+	return restored, nil
+}
+
+// ExpirePoints records the expiry of every credit whose points ran out of validity.
+func (s *LoyaltyService) ExpirePoints() error {
+	expired, err := s.db.ExpireCredits(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		log.Printf("Expired the remaining points of %d ledger entries\n", len(expired))
+	}
+	return nil
+}
+
+// ExpirePointsPeriodically runs ExpirePoints every interval until the process exits.
+func (s *LoyaltyService) ExpirePointsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.ExpirePoints(); err != nil {
+			log.Printf("Failed to expire points: %v\n", err)
+		}
+	}
+}
+
+// GetStatement returns the balance and tier of a user with the entries made in [from, to).
+// Without from and to, it covers the qualifying period up to now.
+func (s *LoyaltyService) GetStatement(userID string, from time.Time, to time.Time) (*models.LoyaltyStatement, error) {
+	now := time.Now().UTC()
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-s.policy.QualifyingPeriod)
+	}
+	if userID == "" || !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", models.ErrInvalidLoyaltyRequest)
+	}
+
+	account, err := s.db.GetAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+	qualifying, err := s.db.EarnedSince(userID, now.Add(-s.policy.QualifyingPeriod))
+	if err != nil {
+		return nil, err
+	}
+	expiringBefore := now.Add(s.policy.ExpiryNotice)
+	expiring, err := s.db.ExpiringPoints(userID, expiringBefore)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.db.GetEntries(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	level := models.TierFor(qualifying)
+	statement := &models.LoyaltyStatement{
+		UserID:           userID,
+		Balance:          account.Balance,
+		Tier:             level.Tier,
+		QualifyingPoints: qualifying,
+		ExpiringPoints:   expiring,
+		ExpiringBefore:   expiringBefore,
+		From:             from,
+		To:               to,
+		Entries:          entries,
+	}
+	for _, next := range models.TierLevels {
+		if next.QualifyingPoints > qualifying {
+			statement.NextTier = next.Tier
+			statement.PointsToNextTier = next.QualifyingPoints - qualifying
+			break
+		}
+	}
+	return statement, nil
+}
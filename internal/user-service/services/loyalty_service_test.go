@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"microservices-travel-backend/pkg/exchangerates"
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// pointsLedger keeps ledger entries in memory. Redemptions only check the balance; which credits
+// they use up is left to the repositories.
+type pointsLedger struct {
+	ports.LoyaltyDB
+	entries []models.LedgerEntry
+}
+
+func (p *pointsLedger) GetEntry(id string) (*models.LedgerEntry, error) {
+	for _, entry := range p.entries {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, models.ErrEntryNotFound
+}
+
+func (p *pointsLedger) AddCredit(entry *models.LedgerEntry) error {
+	if _, err := p.GetEntry(entry.ID); err == nil {
+		return models.ErrDuplicateEntry
+	}
+	p.entries = append(p.entries, *entry)
+	return nil
+}
+
+func (p *pointsLedger) RedeemPoints(entry *models.LedgerEntry) error {
+	account, _ := p.GetAccount(entry.UserID)
+	if account.Balance < -entry.Points {
+		return models.ErrInsufficientPoints
+	}
+	return p.AddCredit(entry)
+}
+
+func (p *pointsLedger) GetAccount(userID string) (*models.LoyaltyAccount, error) {
+	account := &models.LoyaltyAccount{UserID: userID}
+	for _, entry := range p.entries {
+		if entry.UserID == userID {
+			account.Balance += entry.Points
+		}
+	}
+	return account, nil
+}
+
+func (p *pointsLedger) EarnedSince(userID string, since time.Time) (int, error) {
+	earned := 0
+	for _, entry := range p.entries {
+		if entry.UserID == userID && entry.Type == models.EntryEarned && !entry.CreatedAt.Before(since) {
+			earned += entry.Points
+		}
+	}
+	return earned, nil
+}
+
+func (p *pointsLedger) ExpiringPoints(userID string, before time.Time) (int, error) {
+	expiring := 0
+	for _, entry := range p.entries {
+		if entry.UserID == userID && entry.Remaining > 0 && entry.ExpiresAt != nil && entry.ExpiresAt.Before(before) {
+			expiring += entry.Remaining
+		}
+	}
+	return expiring, nil
+}
+
+func (p *pointsLedger) GetEntries(userID string, from time.Time, to time.Time) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	for _, entry := range p.entries {
+		if entry.UserID == userID && !entry.CreatedAt.Before(from) && entry.CreatedAt.Before(to) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (p *pointsLedger) GetEntriesByBooking(bookingID string) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	for _, entry := range p.entries {
+		if entry.BookingID == bookingID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// earned is a credit of points a user earned at some time, expiring at another, relative to now.
+func earned(id string, points int, remaining int, earnedAgo time.Duration, expiresIn time.Duration) models.LedgerEntry {
+	now := time.Now().UTC()
+	expiresAt := now.Add(expiresIn)
+	return models.LedgerEntry{
+		ID:        models.EarnedEntryID("test", id),
+		UserID:    "user-1",
+		Type:      models.EntryEarned,
+		Points:    points,
+		EventID:   id,
+		Source:    "test",
+		BookingID: "booking-" + id,
+		Remaining: remaining,
+		ExpiresAt: &expiresAt,
+		CreatedAt: now.Add(-earnedAgo),
+	}
+}
+
+// redeemed is a redemption of points made before the test, which used up part of the credits.
+func redeemed(id string, points int, ago time.Duration) models.LedgerEntry {
+	return models.LedgerEntry{
+		ID:        models.RedeemedEntryID("test", id),
+		UserID:    "user-1",
+		Type:      models.EntryRedeemed,
+		Points:    -points,
+		EventID:   id,
+		Source:    "test",
+		BookingID: "booking-" + id,
+		CreatedAt: time.Now().UTC().Add(-ago),
+	}
+}
+
+func TestLoyaltyTiers(t *testing.T) {
+	tests := []struct {
+		name       string
+		ledger     []models.LedgerEntry
+		wantPoints int // Earned on a hotel stay of 100 EUR at 10 points per euro.
+		wantTier   models.Tier
+		wantNext   models.Tier
+		wantToNext int
+	}{
+		{
+			name:       "new member earns the base rate",
+			wantPoints: 1000,
+			wantTier:   models.TierBlue,
+			wantNext:   models.TierSilver,
+			wantToNext: 4000,
+		},
+		{
+			name:       "just below silver still earns the base rate but reaches silver",
+			ledger:     []models.LedgerEntry{earned("e1", 4999, 0, 30*day, 300*day)},
+			wantPoints: 1000,
+			wantTier:   models.TierSilver,
+			wantNext:   models.TierGold,
+			wantToNext: 9001,
+		},
+		{
+			name:       "silver earns a quarter more",
+			ledger:     []models.LedgerEntry{earned("e1", 5000, 0, 30*day, 300*day)},
+			wantPoints: 1250,
+			wantTier:   models.TierSilver,
+			wantNext:   models.TierGold,
+			wantToNext: 8750,
+		},
+		{
+			name:       "gold earns half more",
+			ledger:     []models.LedgerEntry{earned("e1", 15000, 0, 30*day, 300*day)},
+			wantPoints: 1500,
+			wantTier:   models.TierGold,
+			wantNext:   models.TierPlatinum,
+			wantToNext: 23500,
+		},
+		{
+			name:       "platinum earns double and has no next tier",
+			ledger:     []models.LedgerEntry{earned("e1", 40000, 0, 30*day, 300*day)},
+			wantPoints: 2000,
+			wantTier:   models.TierPlatinum,
+		},
+		{
+			name:       "points earned before the qualifying period do not count",
+			ledger:     []models.LedgerEntry{earned("e1", 50000, 0, 400*day, 140*day)},
+			wantPoints: 1000,
+			wantTier:   models.TierBlue,
+			wantNext:   models.TierSilver,
+			wantToNext: 4000,
+		},
+		{
+			name:       "redeemed points still count towards the tier",
+			ledger:     []models.LedgerEntry{earned("e1", 5000, 0, 30*day, 300*day), redeemed("r1", 5000, 10*day)},
+			wantPoints: 1250,
+			wantTier:   models.TierSilver,
+			wantNext:   models.TierGold,
+			wantToNext: 8750,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoyaltyService(&pointsLedger{entries: tt.ledger}, exchangerates.NewStaticExchangeRates(), DefaultLoyaltyPolicy())
+			entry, err := s.RecordBookingEvent(models.BookingEvent{
+				ID: "stay-1", Source: "hotel-booking", Type: models.EventHotelStayCheckedOut,
+				UserID: "user-1", BookingID: "booking-1", Amount: 100, Currency: "EUR",
+			})
+			if err != nil {
+				t.Fatalf("RecordBookingEvent: %v", err)
+			}
+			if entry.Points != tt.wantPoints || entry.Remaining != tt.wantPoints {
+				t.Errorf("earned %d points (%d remaining), want %d", entry.Points, entry.Remaining, tt.wantPoints)
+			}
+
+			statement, err := s.GetStatement("user-1", time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("GetStatement: %v", err)
+			}
+			if statement.Tier != tt.wantTier || statement.NextTier != tt.wantNext || statement.PointsToNextTier != tt.wantToNext {
+				t.Errorf("tier %s, next %q in %d, want %s, next %q in %d", statement.Tier, statement.NextTier,
+					statement.PointsToNextTier, tt.wantTier, tt.wantNext, tt.wantToNext)
+			}
+		})
+	}
+}
+
+func TestLoyaltyStatementShowsExpiringPoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		ledger       []models.LedgerEntry
+		wantBalance  int
+		wantExpiring int
+	}{
+		{
+			name:        "points valid beyond the notice",
+			ledger:      []models.LedgerEntry{earned("e1", 1000, 1000, 30*day, 300*day)},
+			wantBalance: 1000,
+		},
+		{
+			name:         "points expiring within the notice",
+			ledger:       []models.LedgerEntry{earned("e1", 300, 300, 500*day, 30*day), earned("e2", 1000, 1000, 30*day, 300*day)},
+			wantBalance:  1300,
+			wantExpiring: 300,
+		},
+		{
+			name:         "only the unused rest of a credit expires",
+			ledger:       []models.LedgerEntry{earned("e1", 1000, 300, 500*day, 30*day), redeemed("r1", 700, 100*day)},
+			wantBalance:  300,
+			wantExpiring: 300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoyaltyService(&pointsLedger{entries: tt.ledger}, exchangerates.NewStaticExchangeRates(), DefaultLoyaltyPolicy())
+			statement, err := s.GetStatement("user-1", time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("GetStatement: %v", err)
+			}
+			if statement.Balance != tt.wantBalance || statement.ExpiringPoints != tt.wantExpiring {
+				t.Errorf("balance %d with %d expiring, want %d with %d", statement.Balance, statement.ExpiringPoints,
+					tt.wantBalance, tt.wantExpiring)
+			}
+		})
+	}
+}
+
+func TestRedeemPoints(t *testing.T) {
+	redemption := models.PointsRedemption{EventID: "payment-1", Source: "booking-service", UserID: "user-1", BookingID: "booking-2",
+		Points: 1000, Amount: 4, Currency: "EUR"}
+
+	t.Run("uses no more points than the price takes", func(t *testing.T) {
+		ledger := &pointsLedger{entries: []models.LedgerEntry{earned("e1", 1000, 1000, 30*day, 300*day)}}
+		s := NewLoyaltyService(ledger, exchangerates.NewStaticExchangeRates(), DefaultLoyaltyPolicy())
+		entry, err := s.RedeemPoints(redemption)
+		if err != nil {
+			t.Fatalf("RedeemPoints: %v", err)
+		}
+		if entry.Points != -400 || entry.Amount != 4 {
+			t.Errorf("redeemed %d points paying %.2f, want -400 paying 4.00", entry.Points, entry.Amount)
+		}
+
+		again, err := s.RedeemPoints(redemption)
+		if err != nil || again.ID != entry.ID || len(ledger.entries) != 2 {
+			t.Errorf("redemption reported again returned %+v, %v with %d entries, want the first one", again, err, len(ledger.entries))
+		}
+	})
+
+	t.Run("fails without enough points", func(t *testing.T) {
+		ledger := &pointsLedger{entries: []models.LedgerEntry{earned("e1", 300, 300, 30*day, 300*day)}}
+		s := NewLoyaltyService(ledger, exchangerates.NewStaticExchangeRates(), DefaultLoyaltyPolicy())
+		if _, err := s.RedeemPoints(redemption); !errors.Is(err, models.ErrInsufficientPoints) {
+			t.Errorf("RedeemPoints: got %v, want %v", err, models.ErrInsufficientPoints)
+		}
+	})
+}
+
+func TestReversedRedemptionKeepsItsExpiry(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(300 * day)
+	redemption := redeemed("payment-1", 500, day)
+	redemption.BookingID = "booking-2"
+	redemption.ExpiresAt = &expiresAt
+	ledger := &pointsLedger{entries: []models.LedgerEntry{earned("e1", 1000, 500, 30*day, 300*day), redemption}}
+	s := NewLoyaltyService(ledger, exchangerates.NewStaticExchangeRates(), DefaultLoyaltyPolicy())
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		restored, err := s.ReverseRedemptions(models.RedemptionReversal{EventID: "cancel-1", Source: "booking-service", BookingID: "booking-2"})
+		if err != nil {
+			t.Fatalf("ReverseRedemptions: %v", err)
+		}
+		if len(restored) != 1 || restored[0].Points != 500 || restored[0].Remaining != 500 || !restored[0].ExpiresAt.Equal(expiresAt) {
+			t.Fatalf("attempt %d restored %v, want 500 points expiring at %v", attempt, restored, expiresAt)
+		}
+	}
+	account, err := ledger.GetAccount("user-1")
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if account.Balance != 1000 {
+		t.Errorf("balance = %d, want 1000", account.Balance)
+	}
+}
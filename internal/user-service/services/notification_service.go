@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPolicy sets how often and how long delivering an email is retried.
+type NotificationPolicy struct {
+	MaxAttempts  int           // Attempts before a notification becomes a dead letter.
+	RetryBackoff time.Duration // Wait after the first failed attempt; it doubles with every further one.
+	ClaimTimeout time.Duration // How long an attempt may take before another replica tries again.
+	BatchSize    int           // Most notifications delivered per run.
+}
+
+func DefaultNotificationPolicy() NotificationPolicy {
+	return NotificationPolicy{
+		MaxAttempts:  5,
+		RetryBackoff: 30 * time.Second,
+		ClaimTimeout: 2 * time.Minute,
+		BatchSize:    100,
+	}
+}
+
+// NotificationService emails users. Every notification is stored before it is sent, so one the
+// mail server does not take is retried with a growing backoff until it becomes a dead letter, and
+// the stored notifications make up the delivery log of each user.
+type NotificationService struct {
+	db        ports.NotificationDB
+	users     ports.UserRepositoryPort
+	notifier  ports.Notifier
+	templates ports.EmailTemplates
+	policy    NotificationPolicy
+}
+
+func NewNotificationService(db ports.NotificationDB, users ports.UserRepositoryPort, notifier ports.Notifier,
+	templates ports.EmailTemplates, policy NotificationPolicy) *NotificationService {
+	return &NotificationService{db: db, users: users, notifier: notifier, templates: templates, policy: policy}
+}
+
+// Notify queues an email to a user in their locale and tries to send it right away. A request
+// repeated with the same source and ID returns the notification it already queued.
+func (s *NotificationService) Notify(request models.NotificationRequest) (*models.Notification, error) {
+	if request.Source == "" || request.UserID == "" || request.Template == "" {
+		return nil, fmt.Errorf("%w: source, user_id and template are required", models.ErrInvalidNotification)
+	}
+	if !s.templates.Has(request.Template) {
+		return nil, fmt.Errorf("%w: unknown template %q", models.ErrInvalidNotification, request.Template)
+	}
+
+	id := uuid.NewString()
+	if request.ID != "" {
+		id = models.NotificationID(request.Source, request.ID)
+		if existing, err := s.db.GetNotification(id); err == nil {
+			return existing, nil
+		} else if !errors.Is(err, models.ErrNotificationNotFound) {
+			return nil, err
+		}
+	}
+
+	user, err := s.users.GetByID(request.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user %s: %v", models.ErrInvalidNotification, request.UserID, err)
+	}
+	locale := request.Locale
+	if locale == "" {
+		locale = user.Locale
+	}
+	if locale == "" {
+		locale = models.DefaultLocale
+	}
+	data := map[string]string{"name": user.Name}
+	for key, value := range request.Data {
+		data[key] = value
+	}
+
+	now := time.Now().UTC()
+	notification := &models.Notification{
+		ID:            id,
+		UserID:        user.ID,
+		Source:        request.Source,
+		Template:      request.Template,
+		Locale:        locale,
+		Recipient:     user.Email,
+		Data:          data,
+		Status:        models.NotificationPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.db.CreateNotification(notification); err != nil {
+		if errors.Is(err, models.ErrDuplicateNotification) {
+			return s.db.GetNotification(id)
+		}
+		return nil, err
+	}
+	if err := s.deliver(notification); err != nil {
+		log.Printf("Failed to record delivery of notification %s: %v\n", notification.ID, err)
+	}
+	return notification, nil
+}
+
+// DeliverDue sends the notifications whose next attempt is due.
+func (s *NotificationService) DeliverDue() error {
+	due, err := s.db.GetDueNotifications(time.Now().UTC(), s.policy.BatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if err := s.deliver(&due[i]); err != nil {
+			log.Printf("Failed to record delivery of notification %s: %v\n", due[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// DeliverPeriodically runs DeliverDue every interval until the process exits.
+func (s *NotificationService) DeliverPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.DeliverDue(); err != nil {
+			log.Printf("Failed to load due notifications: %v\n", err)
+		}
+	}
+}
+
+func (s *NotificationService) GetDeliveryLog(userID string) ([]models.Notification, error) {
+	return s.db.GetNotificationsByUser(userID)
+}
+
+func (s *NotificationService) GetDeadLetters() ([]models.Notification, error) {
+	return s.db.GetDeadLetters()
+}
+
+// RetryDeadLetter queues a notification that was given up on again, with a fresh set of attempts,
+// e.g. once the mail server or the recipient's address is fixed.
+func (s *NotificationService) RetryDeadLetter(id string) (*models.Notification, error) {
+	notification, err := s.db.GetNotification(id)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Status != models.NotificationDeadLetter {
+		return nil, fmt.Errorf("%w: notification %s is %s, not a dead letter", models.ErrInvalidNotification, id, notification.Status)
+	}
+	if user, err := s.users.GetByID(notification.UserID); err == nil {
+		notification.Recipient = user.Email
+	}
+	now := time.Now().UTC()
+	notification.Status = models.NotificationPending
+	notification.Attempts = 0
+	notification.NextAttemptAt = &now
+	if err := s.db.UpdateNotification(notification); err != nil {
+		return nil, err
+	}
+	if err := s.deliver(notification); err != nil {
+		log.Printf("Failed to record delivery of notification %s: %v\n", notification.ID, err)
+	}
+	return notification, nil
+}
+
+// deliver makes one attempt at sending a due notification and records its outcome. A template
+// that cannot be rendered will not render on the next attempt either, so it is a dead letter at once.
+func (s *NotificationService) deliver(notification *models.Notification) error {
+	now := time.Now().UTC()
+	claimed, err := s.db.ClaimNotification(notification.ID, now, now.Add(s.policy.ClaimTimeout))
+	if err != nil || !claimed {
+		return err
+	}
+
+	notification.Attempts++
+	message, err := s.templates.Render(notification.Template, notification.Locale, notification.Data)
+	if err == nil {
+		notification.Subject = message.Subject
+		message.To = notification.Recipient
+		err = s.notifier.Send(*message)
+	} else {
+		notification.Attempts = s.policy.MaxAttempts
+	}
+
+	notification.UpdatedAt = time.Now().UTC()
+	switch {
+	case err == nil:
+		sentAt := notification.UpdatedAt
+		notification.Status = models.NotificationSent
+		notification.SentAt = &sentAt
+		notification.NextAttemptAt = nil
+		notification.LastError = ""
+	case notification.Attempts >= s.policy.MaxAttempts:
+		log.Printf("Notification %s to user %s is a dead letter after %d attempts: %v\n",
+			notification.ID, notification.UserID, notification.Attempts, err)
+		notification.Status = models.NotificationDeadLetter
+		notification.NextAttemptAt = nil
+		notification.LastError = err.Error()
+	default:
+		next := notification.UpdatedAt.Add(s.policy.RetryBackoff << (notification.Attempts - 1))
+		notification.NextAttemptAt = &next
+		notification.LastError = err.Error()
+	}
+	return s.db.UpdateNotification(notification)
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"microservices-travel-backend/internal/user-service/domain/models"
+	"microservices-travel-backend/internal/user-service/domain/ports"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// storedNotifications keeps notifications in memory and claims them the way the PostgreSQL
+// repository does.
+type storedNotifications struct {
+	ports.NotificationDB
+	notifications []models.Notification
+}
+
+func (s *storedNotifications) CreateNotification(notification *models.Notification) error {
+	if s.notification(notification.ID) != nil {
+		return models.ErrDuplicateNotification
+	}
+	s.notifications = append(s.notifications, *notification)
+	return nil
+}
+
+func (s *storedNotifications) GetNotification(id string) (*models.Notification, error) {
+	notification := s.notification(id)
+	if notification == nil {
+		return nil, models.ErrNotificationNotFound
+	}
+	copied := *notification
+	return &copied, nil
+}
+
+func (s *storedNotifications) UpdateNotification(notification *models.Notification) error {
+	stored := s.notification(notification.ID)
+	if stored == nil {
+		return models.ErrNotificationNotFound
+	}
+	*stored = *notification
+	return nil
+}
+
+func (s *storedNotifications) ClaimNotification(id string, at time.Time, until time.Time) (bool, error) {
+	notification := s.notification(id)
+	if notification == nil || notification.Status != models.NotificationPending ||
+		notification.NextAttemptAt == nil || notification.NextAttemptAt.After(at) {
+		return false, nil
+	}
+	notification.NextAttemptAt = &until
+	return true, nil
+}
+
+func (s *storedNotifications) GetDueNotifications(at time.Time, limit int) ([]models.Notification, error) {
+	var due []models.Notification
+	for _, notification := range s.notifications {
+		if len(due) < limit && notification.Status == models.NotificationPending &&
+			notification.NextAttemptAt != nil && !notification.NextAttemptAt.After(at) {
+			due = append(due, notification)
+		}
+	}
+	return due, nil
+}
+
+func (s *storedNotifications) GetDeadLetters() ([]models.Notification, error) {
+	var notifications []models.Notification
+	for _, notification := range s.notifications {
+		if notification.Status == models.NotificationDeadLetter {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+// makeDue moves the next attempt of every pending notification to now, as if their backoff passed.
+func (s *storedNotifications) makeDue() {
+	now := time.Now().UTC()
+	for i := range s.notifications {
+		if s.notifications[i].Status == models.NotificationPending {
+			s.notifications[i].NextAttemptAt = &now
+		}
+	}
+}
+
+func (s *storedNotifications) notification(id string) *models.Notification {
+	for i := range s.notifications {
+		if s.notifications[i].ID == id {
+			return &s.notifications[i]
+		}
+	}
+	return nil
+}
+
+// usersByID keeps users in memory by ID.
+type usersByID struct {
+	ports.UserRepositoryPort
+	users map[string]models.User
+}
+
+func (u *usersByID) GetByID(id string) (*models.User, error) {
+	user, ok := u.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return &user, nil
+}
+
+func (u *usersByID) Update(id string, user models.User) (*models.User, error) {
+	user.ID = id
+	u.users[id] = user
+	return &user, nil
+}
+
+// fakeMailer fails the first failures emails it is given and keeps the ones it sends.
+type fakeMailer struct {
+	failures int
+	attempts int
+	sent     []models.EmailMessage
+}
+
+func (f *fakeMailer) Send(message models.EmailMessage) error {
+	f.attempts++
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("451 mailbox temporarily unavailable")
+	}
+	f.sent = append(f.sent, message)
+	return nil
+}
+
+// fakeTemplates has every template and renders its name as the subject, unless it is broken.
+type fakeTemplates struct {
+	broken bool
+}
+
+func (f fakeTemplates) Has(template string) bool { return true }
+
+func (f fakeTemplates) Render(template string, locale string, data map[string]string) (*models.EmailMessage, error) {
+	if f.broken {
+		return nil, errors.New("template: missing value for name")
+	}
+	return &models.EmailMessage{Subject: template + " (" + locale + ")", Text: data["name"]}, nil
+}
+
+func newNotificationTest(mailer *fakeMailer, templates fakeTemplates) (*NotificationService, *storedNotifications, *usersByID) {
+	db := &storedNotifications{}
+	users := &usersByID{users: map[string]models.User{
+		"user-1": {ID: "user-1", Email: "ana@example.com", Name: "Ana", Locale: "pt"},
+	}}
+	policy := NotificationPolicy{MaxAttempts: 3, RetryBackoff: 30 * time.Second, ClaimTimeout: time.Minute, BatchSize: 10}
+	return NewNotificationService(db, users, mailer, templates, policy), db, users
+}
+
+func TestNotificationDeadLettering(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int  // Emails the mail server refuses before taking one.
+		broken       bool // The template cannot be rendered.
+		wantStatus   models.NotificationStatus
+		wantAttempts int
+		wantSends    int             // Emails handed to the mail server.
+		wantBackoffs []time.Duration // Waits after each failed attempt that is retried.
+	}{
+		{
+			name:         "sent on the first attempt",
+			wantStatus:   models.NotificationSent,
+			wantAttempts: 1,
+			wantSends:    1,
+		},
+		{
+			name:         "sent after retries with a doubling backoff",
+			failures:     2,
+			wantStatus:   models.NotificationSent,
+			wantAttempts: 3,
+			wantSends:    3,
+			wantBackoffs: []time.Duration{30 * time.Second, time.Minute},
+		},
+		{
+			name:         "a dead letter after the last attempt",
+			failures:     10,
+			wantStatus:   models.NotificationDeadLetter,
+			wantAttempts: 3,
+			wantSends:    3,
+			wantBackoffs: []time.Duration{30 * time.Second, time.Minute},
+		},
+		{
+			name:         "a template that does not render is a dead letter at once",
+			broken:       true,
+			wantStatus:   models.NotificationDeadLetter,
+			wantAttempts: 3,
+			wantSends:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &fakeMailer{failures: tt.failures}
+			s, db, _ := newNotificationTest(mailer, fakeTemplates{broken: tt.broken})
+
+			notification, err := s.Notify(models.NotificationRequest{ID: "booking-1", Source: "booking-service",
+				UserID: "user-1", Template: models.TemplateBookingConfirmed})
+			if err != nil {
+				t.Fatalf("Notify: %v", err)
+			}
+			backoffs := []time.Duration{}
+			for run := 0; run < 5; run++ {
+				stored, err := db.GetNotification(notification.ID)
+				if err != nil {
+					t.Fatalf("GetNotification: %v", err)
+				}
+				if stored.Status != models.NotificationPending {
+					break
+				}
+				backoffs = append(backoffs, stored.NextAttemptAt.Sub(stored.UpdatedAt))
+				// Nothing is sent before the backoff passed.
+				if err := s.DeliverDue(); err != nil {
+					t.Fatalf("DeliverDue: %v", err)
+				}
+				db.makeDue()
+				if err := s.DeliverDue(); err != nil {
+					t.Fatalf("DeliverDue: %v", err)
+				}
+			}
+
+			stored, err := db.GetNotification(notification.ID)
+			if err != nil {
+				t.Fatalf("GetNotification: %v", err)
+			}
+			if stored.Status != tt.wantStatus || stored.Attempts != tt.wantAttempts {
+				t.Errorf("%s after %d attempts, want %s after %d", stored.Status, stored.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if mailer.attempts != tt.wantSends {
+				t.Errorf("handed %d emails to the mail server, want %d", mailer.attempts, tt.wantSends)
+			}
+			if tt.wantBackoffs == nil {
+				tt.wantBackoffs = []time.Duration{}
+			}
+			if !reflect.DeepEqual(backoffs, tt.wantBackoffs) {
+				t.Errorf("backoffs = %v, want %v", backoffs, tt.wantBackoffs)
+			}
+			if stored.Status != models.NotificationPending && stored.NextAttemptAt != nil {
+				t.Errorf("%s notification is still scheduled for %v", stored.Status, stored.NextAttemptAt)
+			}
+
+			deadLetters, err := s.GetDeadLetters()
+			if err != nil {
+				t.Fatalf("GetDeadLetters: %v", err)
+			}
+			if dead := len(deadLetters) == 1 && deadLetters[0].ID == notification.ID; dead != (tt.wantStatus == models.NotificationDeadLetter) {
+				t.Errorf("dead letters = %v", deadLetters)
+			}
+			if tt.wantStatus == models.NotificationDeadLetter && stored.LastError == "" {
+				t.Error("dead letter does not say why it failed")
+			}
+		})
+	}
+}
+
+func TestRetryDeadLetter(t *testing.T) {
+	mailer := &fakeMailer{failures: 3}
+	s, db, users := newNotificationTest(mailer, fakeTemplates{})
+	notification, err := s.Notify(models.NotificationRequest{ID: "reset-1", Source: "user-service",
+		UserID: "user-1", Template: models.TemplatePasswordReset})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	if _, err := s.RetryDeadLetter(notification.ID); !errors.Is(err, models.ErrInvalidNotification) {
+		t.Fatalf("RetryDeadLetter of a pending notification: got %v, want %v", err, models.ErrInvalidNotification)
+	}
+	for run := 0; run < 2; run++ {
+		db.makeDue()
+		if err := s.DeliverDue(); err != nil {
+			t.Fatalf("DeliverDue: %v", err)
+		}
+	}
+	if stored, _ := db.GetNotification(notification.ID); stored.Status != models.NotificationDeadLetter {
+		t.Fatalf("status = %s, want %s", stored.Status, models.NotificationDeadLetter)
+	}
+
+	// The user fixes their address, and an admin sends the email again with a fresh set of attempts.
+	if _, err := users.Update("user-1", models.User{Email: "ana@example.org", Name: "Ana", Locale: "pt"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	retried, err := s.RetryDeadLetter(notification.ID)
+	if err != nil {
+		t.Fatalf("RetryDeadLetter: %v", err)
+	}
+	if retried.Status != models.NotificationSent || retried.Attempts != 1 {
+		t.Errorf("%s after %d attempts, want sent after 1", retried.Status, retried.Attempts)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "ana@example.org" || mailer.sent[0].Subject != "password_reset (pt)" {
+		t.Errorf("sent %+v, want the password reset in Portuguese to the new address", mailer.sent)
+	}
+	if deadLetters, _ := s.GetDeadLetters(); len(deadLetters) != 0 {
+		t.Errorf("dead letters = %v, want none", deadLetters)
+	}
+}
@@ -4,21 +4,19 @@ import (
 	"errors"
 	"microservices-travel-backend/internal/user-service/domain/models"
 	"microservices-travel-backend/internal/user-service/domain/ports"
-	"time"
+	"microservices-travel-backend/pkg/middlewares"
 	"fmt"
 
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	userRepo ports.UserRepositoryPort
+	userRepo      ports.UserRepositoryPort
+	notifications ports.NotificationService
 }
 
-var jwtKey = []byte("secret-key")
-
-func NewUserService(userRepo ports.UserRepositoryPort) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo ports.UserRepositoryPort, notifications ports.NotificationService) *UserService {
+	return &UserService{userRepo: userRepo, notifications: notifications}
 }
 
 func (s *UserService) CreateUser(user models.User) (*models.User, error) {
@@ -30,6 +28,8 @@ func (s *UserService) CreateUser(user models.User) (*models.User, error) {
 
 	// Replace the plain password with the hashed one
 	user.Password = string(hashedPassword)
+	// Nobody signs up as an agent or admin; roles are granted in the database.
+	user.Role = ""
 	createdUser, err := s.userRepo.Create(user)
 	if err != nil {
 		return nil, err
@@ -50,15 +50,9 @@ func (s *UserService) Login(creds models.Credentials) (string, error) {
 		return "", errors.New("invalid credentials")
 	}
 
-	claims := &jwt.RegisteredClaims{
-		Subject:   user.ID,
-		Issuer:    "royal-dusk",
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err := token.SignedString(jwtKey)
+	// Signed with the key the other services check tokens with, so they know who the user is and
+	// whether they are an agent or admin.
+	signedToken, err := middleware.GenerateUserJWT(user.ID, user.Role)
 	if err != nil {
 		return "", err
 	}
@@ -72,13 +66,14 @@ func (s *UserService) ForgotPassword(email string) error {
 		return errors.New("user not found")
 	}
 	resetToken := fmt.Sprintf("reset-token-for-%s", user.ID)
-	return sendResetPasswordEmail(user.Email, resetToken)
-}
-
-func sendResetPasswordEmail(email, resetToken string) error {
-	// Mock email function (implement SMTP if needed)
-	fmt.Printf("Sending password reset email to %s with token %s\n", email, resetToken)
-	return nil
+	// Queued even when the mail server is down; the notification service keeps retrying.
+	_, err = s.notifications.Notify(models.NotificationRequest{
+		Source:   "user-service",
+		UserID:   user.ID,
+		Template: models.TemplatePasswordReset,
+		Data:     map[string]string{"resetToken": resetToken},
+	})
+	return err
 }
 
 func (s *UserService) ResetPassword(token, newPassword string) error {
@@ -119,6 +114,8 @@ func (s *UserService) GetAllUsers() ([]models.User, error) {
 
 func (s *UserService) UpdateUser(id string, user models.User) (*models.User, error) {
 	// You can add validation here (e.g., ensuring the user exists)
+	// Users cannot change their own role; an empty role leaves the stored one alone.
+	user.Role = ""
 	updatedUser, err := s.userRepo.Update(id, user)
 	if err != nil {
 		return nil, err
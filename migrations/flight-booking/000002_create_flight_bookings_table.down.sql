@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_flight_bookings_user_id;
+
+DROP TABLE IF EXISTS flight_bookings;
@@ -0,0 +1,18 @@
+CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+
+CREATE TABLE flight_bookings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),  -- Unique booking ID
+    user_id VARCHAR(255) NOT NULL,                  -- User who made the booking
+    status VARCHAR(50) NOT NULL,                    -- Booking status (pending, confirmed, cancelled)
+    trip_type VARCHAR(20) NOT NULL,                 -- one_way, round_trip or multi_city
+    itinerary JSONB NOT NULL,                       -- Legs, segments and fare exactly as sold
+    adults INT NOT NULL DEFAULT 1,                  -- Number of adult passengers (ADT)
+    children INT NOT NULL DEFAULT 0,                -- Number of child passengers (CHD)
+    infants INT NOT NULL DEFAULT 0,                 -- Number of infant passengers (INF)
+    total_price DECIMAL(10, 2) NOT NULL,            -- Combined fare for all legs and passengers
+    currency VARCHAR(3) NOT NULL,                   -- Currency code (e.g., USD, EUR, GBP)
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, -- Timestamp when the booking was created
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP  -- Timestamp when the booking was last updated
+);
+
+CREATE INDEX idx_flight_bookings_user_id ON flight_bookings (user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS flight_seat_holds;
+
+ALTER TABLE flight_bookings DROP COLUMN IF EXISTS seats;
@@ -0,0 +1,13 @@
+ALTER TABLE flight_bookings
+    ADD COLUMN seats JSONB NOT NULL DEFAULT '[]'::jsonb; -- Seats assigned to each passenger per segment
+
+CREATE TABLE flight_seat_holds (
+    flight_id VARCHAR(32) NOT NULL,         -- Flight identifier (carrier, number and date)
+    seat_number VARCHAR(4) NOT NULL,        -- Seat number (e.g., 12A)
+    hold_ref VARCHAR(255) NOT NULL,         -- Booking holding the seat
+    status VARCHAR(20) NOT NULL,            -- held or occupied
+    held_until TIMESTAMP,                   -- Expiry of a temporary hold
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (flight_id, seat_number)    -- A seat can only be held by one booking at a time
+);
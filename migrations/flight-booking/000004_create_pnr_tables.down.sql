@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS passengers;
+
+DROP TABLE IF EXISTS pnrs;
+
+ALTER TABLE flight_bookings DROP COLUMN IF EXISTS record_locator;
@@ -0,0 +1,35 @@
+ALTER TABLE flight_bookings
+    ADD COLUMN record_locator VARCHAR(6) UNIQUE; -- Passenger name record of the booking
+
+CREATE TABLE pnrs (
+    record_locator VARCHAR(6) PRIMARY KEY,       -- Six-character booking reference
+    booking_id UUID NOT NULL UNIQUE REFERENCES flight_bookings(id) ON DELETE CASCADE,
+    contact_email VARCHAR(255),                  -- Email used for booking notifications
+    contact_phone VARCHAR(20),                   -- Phone used for disruption notifications
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE passengers (
+    id SERIAL PRIMARY KEY,
+    record_locator VARCHAR(6) NOT NULL REFERENCES pnrs(record_locator) ON DELETE CASCADE,
+    index INT NOT NULL,                          -- Position on the booking (adults, children, infants)
+    type VARCHAR(3) NOT NULL,                    -- ADT, CHD or INF
+    title VARCHAR(10),
+    first_name VARCHAR(60) NOT NULL,
+    last_name VARCHAR(60) NOT NULL,
+    date_of_birth DATE NOT NULL,
+    gender VARCHAR(1) NOT NULL,
+    nationality VARCHAR(2) NOT NULL,             -- ISO 3166-1 alpha-2 country code
+    document_type VARCHAR(20) NOT NULL,          -- passport or id_card
+    document_number VARCHAR(30) NOT NULL,
+    document_issuing_country VARCHAR(2) NOT NULL,
+    document_expiry_date DATE NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(20),
+    accompanying_index INT,                      -- Adult an infant travels with
+
+    UNIQUE (record_locator, index)
+);
+
+CREATE INDEX idx_passengers_last_name ON passengers (record_locator, UPPER(last_name));
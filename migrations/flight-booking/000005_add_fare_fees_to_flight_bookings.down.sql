@@ -0,0 +1,3 @@
+ALTER TABLE flight_bookings
+    DROP COLUMN IF EXISTS cancellation,
+    DROP COLUMN IF EXISTS fees;
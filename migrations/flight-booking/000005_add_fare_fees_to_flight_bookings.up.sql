@@ -0,0 +1,3 @@
+ALTER TABLE flight_bookings
+    ADD COLUMN fees DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Change fees and fare forfeited on changes
+    ADD COLUMN cancellation JSONB;                     -- Fee and refund applied under the fare rules when cancelled
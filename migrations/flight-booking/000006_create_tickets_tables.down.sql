@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS coupons;
+
+DROP TABLE IF EXISTS tickets;
+
+DROP SEQUENCE IF EXISTS ticket_serial_seq;
+
+ALTER TABLE flight_bookings DROP COLUMN IF EXISTS receipt_url;
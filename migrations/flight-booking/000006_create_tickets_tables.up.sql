@@ -0,0 +1,38 @@
+ALTER TABLE flight_bookings
+    ADD COLUMN receipt_url TEXT; -- E-ticket receipt stored once the booking is ticketed
+
+CREATE SEQUENCE ticket_serial_seq START 1000000000; -- Ten-digit serial appended to the airline accounting code
+
+CREATE TABLE tickets (
+    number VARCHAR(13) PRIMARY KEY,                -- Airline accounting code followed by the serial
+    booking_id UUID NOT NULL REFERENCES flight_bookings(id) ON DELETE CASCADE,
+    record_locator VARCHAR(6) NOT NULL,
+    passenger_index INT NOT NULL,                  -- Position of the passenger on the PNR
+    passenger_name VARCHAR(120) NOT NULL,
+    passenger_type VARCHAR(3) NOT NULL,            -- ADT, CHD or INF
+    conjunction_with VARCHAR(13),                  -- First ticket of the set for conjunction tickets
+    validating_airline VARCHAR(3) NOT NULL,
+    fare_family VARCHAR(20),
+    total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    payment_reference VARCHAR(255) NOT NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_tickets_booking_id ON tickets (booking_id);
+
+CREATE TABLE coupons (
+    ticket_number VARCHAR(13) NOT NULL REFERENCES tickets(number) ON DELETE CASCADE,
+    number INT NOT NULL,                           -- Coupon number on the ticket (1-4)
+    segment_index INT NOT NULL,                    -- Position of the segment in the itinerary
+    flight_id VARCHAR(30) NOT NULL,
+    origin VARCHAR(3) NOT NULL,
+    destination VARCHAR(3) NOT NULL,
+    departure_time TIMESTAMP NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'open',    -- open, used, void or refunded
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (ticket_number, number)
+);
+
+CREATE INDEX idx_coupons_flight_id ON coupons (flight_id);
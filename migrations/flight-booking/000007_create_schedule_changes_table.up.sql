@@ -0,0 +1,17 @@
+CREATE TABLE schedule_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES flight_bookings(id) ON DELETE CASCADE,
+    flight_id VARCHAR(30) NOT NULL,                -- Flight that changed (e.g., LH1000-20250601)
+    segment_index INT NOT NULL,                    -- Position of the segment in the itinerary
+    type VARCHAR(20) NOT NULL,                     -- minor, significant or cancellation
+    status VARCHAR(20) NOT NULL,                   -- pending, accepted or rebooked
+    previous_departure TIMESTAMP NOT NULL,
+    previous_arrival TIMESTAMP NOT NULL,
+    new_departure TIMESTAMP,                       -- NULL when the flight was cancelled
+    new_arrival TIMESTAMP,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_schedule_changes_booking_id ON schedule_changes (booking_id);
@@ -0,0 +1,9 @@
+CREATE TABLE flight_offers (
+    id VARCHAR(200) PRIMARY KEY,                   -- Itinerary ID, e.g. MockGDS-LH1000-20250601-economy-2A0C0I-EUR-light
+    itinerary JSONB NOT NULL,                      -- Flights, fare and price as quoted
+    passengers JSONB NOT NULL,                     -- Passenger mix the price is for
+    expires_at TIMESTAMP NOT NULL,                 -- Offers can no longer be booked after this
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_flight_offers_expires_at ON flight_offers (expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE bookings
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1; -- Incremented by every update, checked against If-Match
@@ -0,0 +1,2 @@
+ALTER TABLE bookings DROP COLUMN IF EXISTS reminder_sent_at;
+ALTER TABLE bookings ALTER COLUMN user_id TYPE INT USING user_id::integer;
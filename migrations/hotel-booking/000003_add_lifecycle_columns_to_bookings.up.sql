@@ -0,0 +1,3 @@
+ALTER TABLE bookings
+    ALTER COLUMN user_id TYPE VARCHAR(255) USING user_id::text, -- IDs of the user service are not numbers
+    ADD COLUMN reminder_sent_at TIMESTAMP;                       -- When the guest was reminded of the stay
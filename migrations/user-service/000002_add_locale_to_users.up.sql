@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT ''; -- Language of the emails sent to the user; empty for the default
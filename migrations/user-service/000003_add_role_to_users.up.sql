@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT ''; -- 'agent' or 'admin' for staff, signed into their tokens; empty for travellers
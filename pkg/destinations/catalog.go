@@ -0,0 +1,194 @@
+package destinations
+
+import (
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/airports.csv
+var dataset embed.FS
+
+var ErrDestinationNotFound = errors.New("destination not found")
+
+type DestinationType string
+
+const (
+	DestinationCity    DestinationType = "city"
+	DestinationAirport DestinationType = "airport"
+)
+
+// Destination is a city or an airport travellers can search for. Cities carry the IATA metropolitan
+// area code (e.g. LON) and list their airports; airports carry their own IATA and ICAO codes.
+type Destination struct {
+	Type        DestinationType `json:"type"`
+	Code        string          `json:"code"`
+	ICAO        string          `json:"icao,omitempty"`
+	Name        string          `json:"name"`
+	City        string          `json:"city"`
+	CityCode    string          `json:"city_code"`
+	CountryCode string          `json:"country_code"`
+	Country     string          `json:"country"`
+	Latitude    float64         `json:"latitude"`
+	Longitude   float64         `json:"longitude"`
+	TimeZone    string          `json:"time_zone"`
+	Airports    []string        `json:"airports,omitempty"`
+}
+
+// term is one searchable word of a destination, kept sorted so prefixes can be found by binary search.
+type term struct {
+	text        string
+	destination int
+}
+
+// Catalog holds the destinations in memory and answers code lookups and autocomplete queries.
+type Catalog struct {
+	destinations []Destination
+	byCode       map[string]int
+	terms        []term
+	// destinationTerms holds the words of each destination's names for typo-tolerant matching; codes never match with typos.
+	destinationTerms [][]string
+}
+
+// NewCatalog loads the destination dataset bundled with the binary.
+func NewCatalog() (*Catalog, error) {
+	file, err := dataset.Open("data/airports.csv")
+	if err != nil {
+		return nil, fmt.Errorf("error opening destination dataset: %v", err)
+	}
+	defer file.Close()
+	return LoadCatalog(file)
+}
+
+// LoadCatalog reads airports in the bundled CSV layout and derives a city entry for every city they serve.
+func LoadCatalog(r io.Reader) (*Catalog, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading destination dataset: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("destination dataset is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"iata", "icao", "name", "city", "city_code", "country_code", "country", "latitude", "longitude", "timezone"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("destination dataset has no %s column", name)
+		}
+	}
+
+	catalog := &Catalog{byCode: make(map[string]int)}
+	for line, record := range records[1:] {
+		latitude, err := strconv.ParseFloat(record[columns["latitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latitude on line %d: %v", line+2, err)
+		}
+		longitude, err := strconv.ParseFloat(record[columns["longitude"]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid longitude on line %d: %v", line+2, err)
+		}
+		airport := Destination{
+			Type:        DestinationAirport,
+			Code:        strings.ToUpper(record[columns["iata"]]),
+			ICAO:        strings.ToUpper(record[columns["icao"]]),
+			Name:        record[columns["name"]],
+			City:        record[columns["city"]],
+			CityCode:    strings.ToUpper(record[columns["city_code"]]),
+			CountryCode: strings.ToUpper(record[columns["country_code"]]),
+			Country:     record[columns["country"]],
+			Latitude:    latitude,
+			Longitude:   longitude,
+			TimeZone:    record[columns["timezone"]],
+		}
+		if len(airport.Code) != 3 {
+			return nil, fmt.Errorf("invalid IATA code %q on line %d", airport.Code, line+2)
+		}
+		if _, ok := catalog.byCode[airport.Code]; ok {
+			return nil, fmt.Errorf("duplicate IATA code %s on line %d", airport.Code, line+2)
+		}
+		catalog.add(airport)
+	}
+
+	// Metropolitan areas get their own entry (e.g. LON for Heathrow, Gatwick and the others). A city
+	// whose code is that of its only airport (e.g. MAD) is found through the airport.
+	airports := len(catalog.destinations)
+	cities := make(map[string]int)
+	for i := 0; i < airports; i++ {
+		airport := catalog.destinations[i]
+		if _, ok := cities[airport.CityCode]; !ok {
+			if _, ok := catalog.byCode[airport.CityCode]; ok {
+				continue
+			}
+			cities[airport.CityCode] = len(catalog.destinations)
+			catalog.add(Destination{
+				Type:        DestinationCity,
+				Code:        airport.CityCode,
+				Name:        airport.City,
+				City:        airport.City,
+				CityCode:    airport.CityCode,
+				CountryCode: airport.CountryCode,
+				Country:     airport.Country,
+				Latitude:    airport.Latitude,
+				Longitude:   airport.Longitude,
+				TimeZone:    airport.TimeZone,
+			})
+		}
+		city := &catalog.destinations[cities[airport.CityCode]]
+		city.Airports = append(city.Airports, airport.Code)
+	}
+
+	for i, destination := range catalog.destinations {
+		catalog.destinationTerms = append(catalog.destinationTerms, searchTerms(destination.Name, destination.City, destination.Country))
+		for _, text := range searchTerms(destination.Code, destination.ICAO, destination.Name, destination.City, destination.Country) {
+			catalog.terms = append(catalog.terms, term{text: text, destination: i})
+		}
+	}
+	sort.Slice(catalog.terms, func(i, j int) bool {
+		return catalog.terms[i].text < catalog.terms[j].text
+	})
+	return catalog, nil
+}
+
+// add indexes a destination by its IATA code and, for airports, its ICAO code.
+func (c *Catalog) add(destination Destination) {
+	c.byCode[destination.Code] = len(c.destinations)
+	if destination.ICAO != "" {
+		c.byCode[destination.ICAO] = len(c.destinations)
+	}
+	c.destinations = append(c.destinations, destination)
+}
+
+// Lookup returns the airport or city with the given IATA code, or the airport with the given ICAO code.
+func (c *Catalog) Lookup(code string) (*Destination, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if i, ok := c.byCode[code]; ok {
+		destination := c.destinations[i]
+		return &destination, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDestinationNotFound, code)
+}
+
+// searchTerms lists the distinct normalized words of a destination's codes and names.
+func searchTerms(texts ...string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, text := range texts {
+		for _, word := range strings.Fields(normalize(text)) {
+			if !seen[word] {
+				seen[word] = true
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
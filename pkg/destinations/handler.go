@@ -0,0 +1,75 @@
+package destinations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler serves the destination catalog to the hotel and flight search front ends.
+type Handler struct {
+	catalog *Catalog
+}
+
+func NewHandler(catalog *Catalog) *Handler {
+	return &Handler{catalog: catalog}
+}
+
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/destinations", h.SearchDestinations).Methods(http.MethodGet)
+	r.HandleFunc("/destinations/{code}", h.GetDestination).Methods(http.MethodGet)
+}
+
+// SearchDestinations autocompletes cities and airports, e.g.
+// GET /destinations?q=lond
+// GET /destinations?q=barcelna&type=airport&limit=5
+func (h *Handler) SearchDestinations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := SearchParams{
+		Query: query.Get("q"),
+		Type:  DestinationType(query.Get("type")),
+	}
+	if params.Query == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+	switch params.Type {
+	case "", DestinationCity, DestinationAirport:
+	default:
+		http.Error(w, fmt.Sprintf("Invalid destination type: %s", params.Type), http.StatusBadRequest)
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxSearchLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", MaxSearchLimit), http.StatusBadRequest)
+			return
+		}
+		params.Limit = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.catalog.Search(params))
+}
+
+// GetDestination returns the city or airport with an IATA or ICAO code.
+func (h *Handler) GetDestination(w http.ResponseWriter, r *http.Request) {
+	destination, err := h.catalog.Lookup(mux.Vars(r)["code"])
+	if err != nil {
+		if errors.Is(err, ErrDestinationNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(destination)
+}
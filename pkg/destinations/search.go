@@ -0,0 +1,228 @@
+package destinations
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 50
+
+	// minFuzzyLength is the shortest query word matched with typos; shorter words only match as prefixes.
+	minFuzzyLength = 4
+)
+
+// Scores of the ways a destination can match, highest first.
+const (
+	scoreCode       = 100
+	scoreCityPrefix = 80
+	scoreNamePrefix = 60
+	scoreWordPrefix = 40
+	scoreFuzzy      = 20
+)
+
+// foldedRunes spells accented letters the way travellers type them without the accent.
+var foldedRunes = map[rune]string{
+	'á': "a", 'à': "a", 'â': "a", 'ä': "a", 'ã': "a", 'å': "a", 'ă': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i", 'ı': "i",
+	'ñ': "n",
+	'ó': "o", 'ò': "o", 'ô': "o", 'ö': "o", 'õ': "o", 'ø': "o", 'ő': "o",
+	'ú': "u", 'ù': "u", 'û': "u", 'ü': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ğ': "g", 'ł': "l", 'ř': "r", 'š': "s", 'ş': "s", 'ș': "s", 'ț': "t", 'ž': "z",
+	'ß': "ss",
+}
+
+type SearchParams struct {
+	Query string
+	Type  DestinationType
+	Limit int
+}
+
+type match struct {
+	destination int
+	score       int
+}
+
+// Search autocompletes a partial city, airport, country or code. Every word of the query must start
+// a word of the destination; when that finds too few destinations, words of four letters or more
+// also match with a typo or two. Cities come before their airports when they score the same.
+func (c *Catalog) Search(params SearchParams) []Destination {
+	words := strings.Fields(normalize(params.Query))
+	if len(words) == 0 {
+		return []Destination{}
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	scores := make(map[int]int)
+	for i := range c.prefixCandidates(words) {
+		scores[i] = c.prefixScore(i, words)
+	}
+	if len(scores) < limit {
+		c.fuzzyMatches(words, scores)
+	}
+
+	matches := make([]match, 0, len(scores))
+	for i, score := range scores {
+		if params.Type != "" && c.destinations[i].Type != params.Type {
+			continue
+		}
+		matches = append(matches, match{destination: i, score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := c.destinations[matches[i].destination], c.destinations[matches[j].destination]
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if a.Type != b.Type {
+			return a.Type == DestinationCity
+		}
+		return a.Name < b.Name
+	})
+
+	results := make([]Destination, 0, min(limit, len(matches)))
+	for _, m := range matches {
+		if len(results) == limit {
+			break
+		}
+		results = append(results, c.destinations[m.destination])
+	}
+	return results
+}
+
+// prefixCandidates marks the destinations that have a word starting with every word of the query.
+func (c *Catalog) prefixCandidates(words []string) map[int]bool {
+	var candidates map[int]bool
+	for _, word := range words {
+		found := make(map[int]bool)
+		first := sort.Search(len(c.terms), func(i int) bool { return c.terms[i].text >= word })
+		for i := first; i < len(c.terms) && strings.HasPrefix(c.terms[i].text, word); i++ {
+			if candidates == nil || candidates[c.terms[i].destination] {
+				found[c.terms[i].destination] = true
+			}
+		}
+		candidates = found
+	}
+	return candidates
+}
+
+// prefixScore ranks a prefix match: a typed code beats the start of the city name, which beats the
+// start of the airport name, which beats any other word.
+func (c *Catalog) prefixScore(i int, words []string) int {
+	destination := c.destinations[i]
+	query := strings.Join(words, " ")
+	switch {
+	case len(words) == 1 && (strings.EqualFold(query, destination.Code) || strings.EqualFold(query, destination.ICAO)):
+		return scoreCode
+	case strings.HasPrefix(normalize(destination.City), query):
+		return scoreCityPrefix
+	case strings.HasPrefix(normalize(destination.Name), query):
+		return scoreNamePrefix
+	default:
+		return scoreWordPrefix
+	}
+}
+
+// fuzzyMatches adds the destinations whose words are within a small edit distance of every query word.
+func (c *Catalog) fuzzyMatches(words []string, scores map[int]int) {
+	for i, terms := range c.destinationTerms {
+		if _, ok := scores[i]; ok {
+			continue
+		}
+		total := 0
+		for _, word := range words {
+			distance, ok := closestTerm(word, terms)
+			if !ok {
+				total = -1
+				break
+			}
+			total += distance
+		}
+		if total > 0 {
+			scores[i] = scoreFuzzy - total
+		}
+	}
+}
+
+// closestTerm returns the smallest edit distance between a query word and the start of any term,
+// allowing one typo up to seven letters and two beyond.
+func closestTerm(word string, terms []string) (int, bool) {
+	letters := []rune(word)
+	if len(letters) < minFuzzyLength {
+		for _, term := range terms {
+			if strings.HasPrefix(term, word) {
+				return 0, true
+			}
+		}
+		return 0, false
+	}
+	allowed := 1
+	if len(letters) > 7 {
+		allowed = 2
+	}
+
+	best := allowed + 1
+	for _, term := range terms {
+		termLetters := []rune(term)
+		// Compare against the start of the term so partially typed words still match.
+		if len(termLetters) > len(letters)+allowed {
+			termLetters = termLetters[:len(letters)+allowed]
+		}
+		if distance := prefixDistance(letters, termLetters); distance < best {
+			best = distance
+		}
+	}
+	return best, best <= allowed
+}
+
+// prefixDistance is the Levenshtein distance between a and the closest prefix of b.
+func prefixDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	best := previous[0]
+	for _, distance := range previous {
+		best = min(best, distance)
+	}
+	return best
+}
+
+// normalize lowercases text, drops accents and turns punctuation into spaces, e.g. "Côte d'Azur" becomes "cote d azur".
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if folded, ok := foldedRunes[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune(' ')
+	}
+	return b.String()
+}
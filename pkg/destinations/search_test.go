@@ -0,0 +1,75 @@
+package destinations
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	catalog, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		params    SearchParams
+		wantFirst string
+		wantAll   DestinationType
+	}{
+		{name: "the city comes before its airports", params: SearchParams{Query: "lon"}, wantFirst: "LON"},
+		{name: "a typed code wins", params: SearchParams{Query: "MAD"}, wantFirst: "MAD"},
+		{name: "accents need not be typed", params: SearchParams{Query: "mala"}, wantFirst: "AGP"},
+		{name: "a typo still matches", params: SearchParams{Query: "lomdon"}, wantFirst: "LON"},
+		{name: "several words", params: SearchParams{Query: "london gat"}, wantFirst: "LGW"},
+		{name: "airports only", params: SearchParams{Query: "london", Type: DestinationAirport}, wantAll: DestinationAirport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := catalog.Search(tt.params)
+			if len(results) == 0 {
+				t.Fatalf("Search(%q) found nothing", tt.params.Query)
+			}
+			if tt.wantFirst != "" && results[0].Code != tt.wantFirst {
+				t.Errorf("Search(%q) starts with %s, want %s", tt.params.Query, results[0].Code, tt.wantFirst)
+			}
+			for _, result := range results {
+				if tt.wantAll != "" && result.Type != tt.wantAll {
+					t.Errorf("Search(%q) returned the %s %s", tt.params.Query, result.Type, result.Code)
+				}
+			}
+		})
+	}
+
+	if results := catalog.Search(SearchParams{Query: "lon", Limit: 2}); len(results) != 2 {
+		t.Errorf("Search with limit 2 returned %d destinations", len(results))
+	}
+	if results := catalog.Search(SearchParams{Query: "  "}); len(results) != 0 {
+		t.Errorf("Search of a blank query returned %d destinations", len(results))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	catalog, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog: %v", err)
+	}
+
+	for code, want := range map[string]string{"mad": "MAD", "LEMD": "MAD", "LON": "LON"} {
+		destination, err := catalog.Lookup(code)
+		if err != nil {
+			t.Errorf("Lookup(%q): %v", code, err)
+			continue
+		}
+		if destination.Code != want {
+			t.Errorf("Lookup(%q) = %s, want %s", code, destination.Code, want)
+		}
+	}
+	if city, _ := catalog.Lookup("LON"); city == nil || len(city.Airports) != 5 {
+		t.Errorf("London lists airports %v, want its five airports", city)
+	}
+	if _, err := catalog.Lookup("XXX"); !errors.Is(err, ErrDestinationNotFound) {
+		t.Errorf("Lookup of an unknown code: got %v, want %v", err, ErrDestinationNotFound)
+	}
+}
@@ -0,0 +1,65 @@
+// Package exchangerates converts between currencies for the services that show, value or price
+// amounts in another currency than they were filed in.
+package exchangerates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// referenceRates are units of each currency per euro. They are indicative reference rates for trip
+// totals, the value of loyalty points and seat fees filed in another currency than the booking;
+// what a traveller pays for a booking is always its price in its own currency.
+var referenceRates = map[string]float64{
+	"EUR": 1,
+	"USD": 1.08,
+	"GBP": 0.85,
+	"CHF": 0.95,
+	"JPY": 162.5,
+	"CAD": 1.47,
+	"AUD": 1.64,
+	"NZD": 1.79,
+	"SEK": 11.4,
+	"NOK": 11.6,
+	"DKK": 7.46,
+	"PLN": 4.3,
+	"CZK": 25.2,
+	"HUF": 395,
+	"TRY": 35.5,
+	"AED": 3.97,
+	"QAR": 3.93,
+	"SGD": 1.45,
+	"HKD": 8.44,
+	"CNY": 7.8,
+	"INR": 90.3,
+	"THB": 38.2,
+	"MXN": 19.9,
+	"BRL": 5.9,
+	"ZAR": 20.1,
+}
+
+// StaticExchangeRates converts between currencies with a fixed table of euro reference rates.
+type StaticExchangeRates struct {
+	rates map[string]float64
+}
+
+func NewStaticExchangeRates() *StaticExchangeRates {
+	return &StaticExchangeRates{rates: referenceRates}
+}
+
+func (s *StaticExchangeRates) Supports(currency string) bool {
+	_, ok := s.rates[strings.ToUpper(currency)]
+	return ok
+}
+
+func (s *StaticExchangeRates) Convert(amount float64, from string, to string) (float64, error) {
+	fromRate, ok := s.rates[strings.ToUpper(from)]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", from)
+	}
+	toRate, ok := s.rates[strings.ToUpper(to)]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for %s", to)
+	}
+	return amount / fromRate * toRate, nil
+}
@@ -0,0 +1,75 @@
+// Package httputil holds the HTTP helpers the handlers of several services share.
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AnyVersion is the version If-Match: * stands for; it equals the AnyVersion of the services, which
+// apply such a change to whichever version is current.
+const AnyVersion = 0
+
+var (
+	errMissingIfMatch = errors.New("If-Match header with the ETag of the booking is required")
+	errInvalidIfMatch = errors.New("If-Match header must be an ETag returned for the booking or *")
+)
+
+// ETag is the entity tag of a version of a resource.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// SetETag tells the client which version of a resource it was sent.
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// IfMatchVersion reads the version of the resource a write applies to from the If-Match header.
+// "*" matches any version; weak ETags are accepted since versions identify a resource exactly.
+func IfMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errMissingIfMatch
+	}
+	if value == "*" {
+		return AnyVersion, nil
+	}
+	quoted := strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(quoted)
+	if err != nil || !strings.HasPrefix(quoted, `"`) {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// RequireVersion reads the If-Match version of a write, answering 428 or 400 when it is missing
+// or malformed.
+func RequireVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := IfMatchVersion(r)
+	switch {
+	case errors.Is(err, errMissingIfMatch):
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusPreconditionRequired)
+		return 0, false
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
+// WriteConflict answers a write made against an outdated version with 412 and the resource as it
+// is now, so the client can merge its change and retry with the new ETag.
+func WriteConflict(w http.ResponseWriter, version int, current any) {
+	SetETag(w, version)
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(current)
+}
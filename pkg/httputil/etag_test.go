@@ -0,0 +1,44 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireVersion(t *testing.T) {
+	tests := []struct {
+		ifMatch     string
+		wantVersion int
+		wantStatus  int // Zero when the version is accepted.
+	}{
+		{ifMatch: `"3"`, wantVersion: 3},
+		{ifMatch: `W/"3"`, wantVersion: 3},
+		{ifMatch: `*`, wantVersion: AnyVersion},
+		{ifMatch: ``, wantStatus: http.StatusPreconditionRequired},
+		{ifMatch: `3`, wantStatus: http.StatusBadRequest},
+		{ifMatch: `"0"`, wantStatus: http.StatusBadRequest},
+		{ifMatch: `"three"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ifMatch, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/bookings/1", nil)
+			if tt.ifMatch != "" {
+				request.Header.Set("If-Match", tt.ifMatch)
+			}
+			response := httptest.NewRecorder()
+			version, ok := RequireVersion(response, request)
+			if ok != (tt.wantStatus == 0) || version != tt.wantVersion {
+				t.Errorf("RequireVersion = %d, %v, want %d", version, ok, tt.wantVersion)
+			}
+			if tt.wantStatus != 0 && response.Code != tt.wantStatus {
+				t.Errorf("answered %d, want %d", response.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	if etag := ETag(7); etag != `"7"` {
+		t.Errorf("ETag(7) = %s", etag)
+	}
+}
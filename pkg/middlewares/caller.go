@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Roles a user token may carry in its role claim. The user service signs users in with the role of
+// their record; end users have none.
+const (
+	RoleAdmin = "admin"
+	RoleAgent = "agent"
+)
+
+// Caller is who a request was made by, as told by the claims of the token JWTMiddleware accepted.
+type Caller struct {
+	Service string // Name of the service sending a service token.
+	UserID  string // Subject of a user token.
+	Role    string // Role of the user; empty for end users.
+}
+
+// CallerFromContext reads who made a request. It reports false when the request was not
+// authenticated or its token names neither a service nor a user.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return Caller{}, false
+	}
+	return callerFromClaims(claims)
+}
+
+func callerFromClaims(claims jwt.MapClaims) (Caller, bool) {
+	if service, _ := claims["service"].(string); service != "" {
+		return Caller{Service: service}, true
+	}
+	subject, _ := claims["sub"].(string)
+	role, _ := claims["role"].(string)
+	return Caller{UserID: subject, Role: role}, subject != ""
+}
+
+// RequireCaller reads who made a request, answering 401 when its token names nobody.
+func RequireCaller(w http.ResponseWriter, r *http.Request) (Caller, bool) {
+	caller, ok := CallerFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Token does not name a user or service", http.StatusUnauthorized)
+	}
+	return caller, ok
+}
+
+// IsService reports whether the caller is another service.
+func (c Caller) IsService() bool {
+	return c.Service != ""
+}
+
+// HasRole reports whether the caller is a user with one of roles.
+func (c Caller) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if c.UserID != "" && c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the caller is an agent or an admin.
+func (c Caller) IsStaff() bool {
+	return c.HasRole(RoleAdmin, RoleAgent)
+}
+
+// Subject identifies the caller the same way across all of its tokens.
+func (c Caller) Subject() string {
+	if c.IsService() {
+		return "service:" + c.Service
+	}
+	return "user:" + c.UserID
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestCallerFromContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    jwt.MapClaims
+		want      Caller
+		wantOK    bool
+		wantStaff bool
+	}{
+		{name: "service token", claims: jwt.MapClaims{"service": "booking-service"},
+			want: Caller{Service: "booking-service"}, wantOK: true},
+		{name: "end user", claims: jwt.MapClaims{"sub": "user-1"},
+			want: Caller{UserID: "user-1"}, wantOK: true},
+		{name: "agent", claims: jwt.MapClaims{"sub": "user-2", "role": RoleAgent},
+			want: Caller{UserID: "user-2", Role: RoleAgent}, wantOK: true, wantStaff: true},
+		{name: "a role without a subject names nobody", claims: jwt.MapClaims{"role": RoleAdmin},
+			want: Caller{Role: RoleAdmin}},
+		{name: "unknown roles are not staff", claims: jwt.MapClaims{"sub": "user-3", "role": "owner"},
+			want: Caller{UserID: "user-3", Role: "owner"}, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), claimsKey{}, tt.claims)
+			caller, ok := CallerFromContext(ctx)
+			if caller != tt.want || ok != tt.wantOK {
+				t.Errorf("CallerFromContext = %+v, %v, want %+v, %v", caller, ok, tt.want, tt.wantOK)
+			}
+			if caller.IsStaff() != tt.wantStaff {
+				t.Errorf("IsStaff = %v, want %v", caller.IsStaff(), tt.wantStaff)
+			}
+		})
+	}
+
+	if _, ok := CallerFromContext(context.Background()); ok {
+		t.Error("unauthenticated request has a caller")
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key a client picks for a request it may retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response that was replayed from an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyWindow is how long keys are remembered unless configured otherwise.
+	DefaultIdempotencyWindow = 24 * time.Hour
+	// IdempotencyLease is how long a key is held for a request still running. A retry after that
+	// runs the request again, e.g. when the replica handling the first one crashed.
+	IdempotencyLease = 5 * time.Minute
+)
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests that carry an Idempotency-Key
+// header safe to retry. Keys belong to the user or service the bearer token names, so a retry with
+// a refreshed token matches and two callers never share a key. The first request with a key runs
+// and its response is stored for window; repeating it replays that response, reusing the key for a
+// different request is rejected with 422, and a repeat that arrives while the first is still
+// running gets 409. Failed requests (5xx) and requests turned away for their token (401 and 403)
+// are not stored so they can be retried. Requests without the header are passed through.
+func IdempotencyMiddleware(store IdempotencyStore, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Unable to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now().UTC()
+			record := &IdempotencyRecord{
+				Key:         requestSubject(r) + "/" + key,
+				Fingerprint: fingerprint(r, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(IdempotencyLease),
+			}
+			existing, err := store.Reserve(record)
+			if err != nil {
+				log.Printf("Failed to reserve idempotency key: %v\n", err)
+				http.Error(w, "Unable to check Idempotency-Key", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				replay(w, existing, record.Fingerprint)
+				return
+			}
+
+			release := func() {
+				if err := store.Release(record.Key); err != nil {
+					log.Printf("Failed to release idempotency key: %v\n", err)
+				}
+			}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					release()
+					panic(recovered)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if !storable(recorder.status) {
+				release()
+				return
+			}
+			record.Completed = true
+			record.ExpiresAt = time.Now().UTC().Add(window)
+			record.StatusCode = recorder.status
+			record.Header = recorder.Header().Clone()
+			record.Body = recorder.body.Bytes()
+			if err := store.Complete(record); err != nil {
+				log.Printf("Failed to store idempotent response: %v\n", err)
+			}
+		})
+	}
+}
+
+// IdempotencyWindowFromEnv reads how long idempotency keys are kept from IDEMPOTENCY_KEY_TTL,
+// e.g. "24h", falling back to DefaultIdempotencyWindow.
+func IdempotencyWindowFromEnv() time.Duration {
+	value := os.Getenv("IDEMPOTENCY_KEY_TTL")
+	if value == "" {
+		return DefaultIdempotencyWindow
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		log.Printf("Invalid IDEMPOTENCY_KEY_TTL %q, using %s\n", value, DefaultIdempotencyWindow)
+		return DefaultIdempotencyWindow
+	}
+	return window
+}
+
+func replay(w http.ResponseWriter, record *IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+	case !record.Completed:
+		http.Error(w, "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+	default:
+		for name, values := range record.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+	}
+}
+
+// requestSubject names who sends a request, as told by its bearer token. Requests without a valid
+// token share the anonymous subject; the routes that need one turn them away.
+func requestSubject(r *http.Request) string {
+	claims, err := ValidateJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err != nil {
+		return "anonymous"
+	}
+	caller, ok := callerFromClaims(claims)
+	if !ok {
+		return "anonymous"
+	}
+	return caller.Subject()
+}
+
+// storable reports whether a response is replayed to retries. Server errors and responses to a
+// missing, expired or insufficient token are not: the retry may succeed.
+func storable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusUnauthorized && status != http.StatusForbidden
+}
+
+// fingerprint identifies a request by its method, target and body, so a key reused for another
+// payload does not match.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.RequestURI()} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
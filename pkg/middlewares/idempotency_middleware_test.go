@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	// Tokens of the same user differ by when they expire, e.g. after a refresh.
+	tokenUntil := func(userID string, expiresIn time.Duration) string {
+		claims := jwt.MapClaims{"sub": userID, "exp": time.Now().Add(expiresIn).Unix()}
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+	token := func(userID string) string { return tokenUntil(userID, time.Hour) }
+	// The handler creates a booking for each request it runs, answering 401 without a token.
+	newServer := func(store IdempotencyStore) (http.Handler, *int) {
+		runs := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "Missing Authorization Header", http.StatusUnauthorized)
+				return
+			}
+			if r.Header.Get("X-Panic") != "" {
+				panic("handler failed")
+			}
+			runs++
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"bookingID":"1"}`))
+		})
+		return IdempotencyMiddleware(store, time.Hour)(handler), &runs
+	}
+	send := func(server http.Handler, token string, body string, header ...string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/bookings/", strings.NewReader(body))
+		request.Header.Set(IdempotencyKeyHeader, "key-1")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("a retry with a refreshed token is replayed", func(t *testing.T) {
+		server, runs := newServer(NewMemoryIdempotencyStore())
+		first := send(server, token("user-1"), `{"flightID":"LH400"}`)
+		retry := send(server, tokenUntil("user-1", 2*time.Hour), `{"flightID":"LH400"}`)
+		if retry.Code != first.Code || retry.Header().Get(IdempotentReplayedHeader) != "true" || *runs != 1 {
+			t.Errorf("retry answered %d (replayed %q) after %d runs, want a replay of %d",
+				retry.Code, retry.Header().Get(IdempotentReplayedHeader), *runs, first.Code)
+		}
+		if payload := send(server, token("user-1"), `{"flightID":"BA117"}`); payload.Code != http.StatusUnprocessableEntity {
+			t.Errorf("key reused for another payload answered %d, want %d", payload.Code, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("callers do not share keys", func(t *testing.T) {
+		server, runs := newServer(NewMemoryIdempotencyStore())
+		send(server, token("user-1"), `{"flightID":"LH400"}`)
+		other := send(server, token("user-2"), `{"flightID":"BA117"}`)
+		if other.Code != http.StatusCreated || *runs != 2 {
+			t.Errorf("another caller's request answered %d after %d runs, want it run", other.Code, *runs)
+		}
+	})
+
+	t.Run("requests turned away for their token are not stored", func(t *testing.T) {
+		server, runs := newServer(NewMemoryIdempotencyStore())
+		if rejected := send(server, "", `{"flightID":"LH400"}`); rejected.Code != http.StatusUnauthorized {
+			t.Fatalf("request without a token answered %d", rejected.Code)
+		}
+		if retry := send(server, token("user-1"), `{"flightID":"LH400"}`); retry.Code != http.StatusCreated || *runs != 1 {
+			t.Errorf("retry with a token answered %d after %d runs, want it run", retry.Code, *runs)
+		}
+	})
+
+	t.Run("a panicking request releases its key", func(t *testing.T) {
+		server, runs := newServer(NewMemoryIdempotencyStore())
+		func() {
+			defer func() { recover() }()
+			send(server, token("user-1"), `{"flightID":"LH400"}`, "X-Panic", "true")
+		}()
+		if retry := send(server, token("user-1"), `{"flightID":"LH400"}`); retry.Code != http.StatusCreated || *runs != 1 {
+			t.Errorf("retry after a panic answered %d after %d runs, want it run", retry.Code, *runs)
+		}
+	})
+
+	t.Run("an abandoned reservation lapses after its lease", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore()
+		server, runs := newServer(store)
+		body := `{"flightID":"LH400"}`
+		request := httptest.NewRequest(http.MethodPost, "/bookings/", strings.NewReader(body))
+		reserved := time.Now().UTC().Add(-IdempotencyLease - time.Second)
+		store.Reserve(&IdempotencyRecord{Key: "user:user-1/key-1", Fingerprint: fingerprint(request, []byte(body)),
+			CreatedAt: reserved, ExpiresAt: reserved.Add(IdempotencyLease)})
+
+		if retry := send(server, token("user-1"), body); retry.Code != http.StatusCreated || *runs != 1 {
+			t.Errorf("retry of an abandoned request answered %d after %d runs, want it run", retry.Code, *runs)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRecord is what is remembered about a request sent with an Idempotency-Key.
+type IdempotencyRecord struct {
+	Key         string      `gorm:"column:idempotency_key;primaryKey;size:320" dynamodbav:"idempotency_key"`
+	Fingerprint string      `gorm:"column:fingerprint" dynamodbav:"fingerprint"`
+	Completed   bool        `gorm:"column:completed" dynamodbav:"completed"` // False while the first request is still running.
+	StatusCode  int         `gorm:"column:status_code" dynamodbav:"status_code"`
+	Header      http.Header `gorm:"column:header;serializer:json" dynamodbav:"header"`
+	Body        []byte      `gorm:"column:body" dynamodbav:"body"`
+	CreatedAt   time.Time   `gorm:"column:created_at" dynamodbav:"created_at"`
+	ExpiresAt   time.Time   `gorm:"column:expires_at;index" dynamodbav:"expires_at"` // End of the lease while running, then of the replay window.
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// IdempotencyStore keeps idempotency records until they expire.
+type IdempotencyStore interface {
+	// Reserve stores the record unless its key is held by an unexpired record, which is returned instead.
+	Reserve(record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response of a reserved key.
+	Complete(record *IdempotencyRecord) error
+	// Release forgets a reserved key, e.g. when its request failed.
+	Release(key string) error
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory, for tests and single-replica services.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*IdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, stored := range s.records {
+		if stored.ExpiresAt.Before(now) {
+			delete(s.records, key)
+		}
+	}
+	if stored, ok := s.records[record.Key]; ok {
+		existing := *stored
+		return &existing, nil
+	}
+	stored := *record
+	s.records[record.Key] = &stored
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *record
+	s.records[record.Key] = &stored
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// GormIdempotencyStore keeps idempotency records in the idempotency_keys table, so every replica
+// of a service sees them. Services sharing a database keep their keys apart through scope.
+type GormIdempotencyStore struct {
+	db    *gorm.DB
+	scope string
+}
+
+func NewGormIdempotencyStore(db *gorm.DB, scope string) (*GormIdempotencyStore, error) {
+	if err := db.AutoMigrate(&IdempotencyRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate idempotency keys table: %v", err)
+	}
+	return &GormIdempotencyStore{db: db, scope: scope}, nil
+}
+
+func (s *GormIdempotencyStore) Reserve(record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	stored := *record
+	stored.Key = s.scopedKey(record.Key)
+
+	var existing *IdempotencyRecord
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Where("idempotency_key = ? AND expires_at < ?", stored.Key, time.Now().UTC())
+		if err := expired.Delete(&IdempotencyRecord{}).Error; err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&stored)
+		if result.Error != nil || result.RowsAffected == 1 {
+			return result.Error
+		}
+		existing = &IdempotencyRecord{}
+		return tx.First(existing, "idempotency_key = ?", stored.Key).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reserving idempotency key: %v", err)
+	}
+	return existing, nil
+}
+
+func (s *GormIdempotencyStore) Complete(record *IdempotencyRecord) error {
+	stored := *record
+	stored.Key = s.scopedKey(record.Key)
+	if err := s.db.Save(&stored).Error; err != nil {
+		return fmt.Errorf("error storing idempotent response: %v", err)
+	}
+	return nil
+}
+
+func (s *GormIdempotencyStore) Release(key string) error {
+	if err := s.db.Delete(&IdempotencyRecord{}, "idempotency_key = ?", s.scopedKey(key)).Error; err != nil {
+		return fmt.Errorf("error releasing idempotency key: %v", err)
+	}
+	return nil
+}
+
+func (s *GormIdempotencyStore) scopedKey(key string) string {
+	return s.scope + "/" + key
+}
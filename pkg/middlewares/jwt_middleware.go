@@ -1,11 +1,15 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
+type claimsKey struct{}
+
 // JWTMiddleware checks for a valid JWT token in the Authorization header
 func JWTMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -22,11 +26,12 @@ func JWTMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Extract service name from claims and log it (for debugging)
-		if service, ok := claims["service"].(string); ok {
-			fmt.Printf("Authenticated request from service: %s\n", service)
-		}
-
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
 	})
 }
+
+// ClaimsFromContext returns the claims of the token JWTMiddleware accepted for a request
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(jwt.MapClaims)
+	return claims, ok
+}
@@ -2,13 +2,32 @@ package middleware
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// Secret Key (Should be stored in environment variables)
-var jwtSecret = []byte("your-secret-key")
+// jwtSecretVariable names the environment variable with the key every user and service token is
+// signed and validated with. config/shared provides it in development; deployments set it from
+// their secrets.
+const jwtSecretVariable = "JWT_SECRET"
+
+// jwtSecret returns the signing key; without one no token is issued or accepted.
+func jwtSecret() ([]byte, error) {
+	secret := os.Getenv(jwtSecretVariable)
+	if secret == "" {
+		return nil, fmt.Errorf("%s is not set", jwtSecretVariable)
+	}
+	return []byte(secret), nil
+}
+
+// CheckJWTSecret fails when the signing key is not set, so that a service refuses to start instead
+// of rejecting every token it is sent.
+func CheckJWTSecret() error {
+	_, err := jwtSecret()
+	return err
+}
 
 // GenerateJWT generates a new JWT token
 func GenerateJWT(serviceName string) (string, error) {
@@ -17,8 +36,38 @@ func GenerateJWT(serviceName string) (string, error) {
 		"exp":     time.Now().Add(time.Hour * 1).Unix(), // Token expires in 1 hour
 	}
 
+	secret, err := jwtSecret()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// userClaims are the claims of a user token; only agents and admins have a role.
+type userClaims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateUserJWT generates the token a user signs in with; its subject is the user's ID and its
+// role claim the role of the user, see RoleAdmin and RoleAgent
+func GenerateUserJWT(userID string, role string) (string, error) {
+	claims := &userClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Issuer:    "royal-dusk",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		},
+	}
+
+	secret, err := jwtSecret()
+	if err != nil {
+		return "", err
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(secret)
 }
 
 // ValidateJWT validates a JWT token and extracts claims
@@ -27,7 +76,7 @@ func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return jwtSecret, nil
+		return jwtSecret()
 	})
 
 	if err != nil {
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaderLock elects the replica that runs the jobs of a scheduler. The lock is a lease: the holder
+// renews it while running, and another replica takes it over once it expires.
+type LeaderLock interface {
+	// Acquire takes or renews the named lock for holder until until, reporting whether holder has it.
+	// Locks held by someone else are taken over when they expired before now.
+	Acquire(name string, holder string, now time.Time, until time.Time) (bool, error)
+}
+
+// MemoryLeaderLock keeps leases in memory, for tests and single-replica services.
+type MemoryLeaderLock struct {
+	mu     sync.Mutex
+	leases map[string]SchedulerLock
+}
+
+func NewMemoryLeaderLock() *MemoryLeaderLock {
+	return &MemoryLeaderLock{leases: make(map[string]SchedulerLock)}
+}
+
+func (l *MemoryLeaderLock) Acquire(name string, holder string, now time.Time, until time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lease, ok := l.leases[name]; ok && lease.Holder != holder && !lease.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	l.leases[name] = SchedulerLock{Name: name, Holder: holder, ExpiresAt: until}
+	return true, nil
+}
+
+// SchedulerLock is a lease on a leader lock.
+type SchedulerLock struct {
+	Name      string    `gorm:"column:name;primaryKey"`
+	Holder    string    `gorm:"column:holder"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+}
+
+func (SchedulerLock) TableName() string {
+	return "scheduler_locks"
+}
+
+// GormLeaderLock keeps leases in the scheduler_locks table, shared by the replicas of a service.
+type GormLeaderLock struct {
+	db *gorm.DB
+}
+
+func NewGormLeaderLock(db *gorm.DB) (*GormLeaderLock, error) {
+	if err := db.AutoMigrate(&SchedulerLock{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate scheduler locks table: %v", err)
+	}
+	return &GormLeaderLock{db: db}, nil
+}
+
+func (l *GormLeaderLock) Acquire(name string, holder string, now time.Time, until time.Time) (bool, error) {
+	lease := SchedulerLock{Name: name, Holder: holder, ExpiresAt: until.UTC()}
+	created := l.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease)
+	if created.Error != nil {
+		return false, fmt.Errorf("error acquiring lock %s: %v", name, created.Error)
+	}
+	if created.RowsAffected == 1 {
+		return true, nil
+	}
+	// The condition and the update are one statement, so two replicas cannot both take over.
+	renewed := l.db.Model(&SchedulerLock{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now.UTC()).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": until.UTC()})
+	if renewed.Error != nil {
+		return false, fmt.Errorf("error acquiring lock %s: %v", name, renewed.Error)
+	}
+	return renewed.RowsAffected == 1, nil
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job is work a scheduler runs on a schedule. Jobs should be safe to run again after a failure or a
+// change of leader, e.g. by only acting on records still in the state the job moves them out of.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(now time.Time) error
+}
+
+// Scheduler runs jobs on the one replica holding its leader lock. Every replica keeps track of
+// when the jobs are due, so whichever holds the lock at that time runs them.
+type Scheduler struct {
+	name   string // Name of the leader lock.
+	lock   LeaderLock
+	holder string // Identifies this replica in the lock.
+	tick   time.Duration
+	jobs   []*scheduledJob
+}
+
+type scheduledJob struct {
+	Job
+	next time.Time
+}
+
+// New creates a scheduler checking for due jobs every tick. A leader that stops renewing its lease
+// for three ticks, e.g. because it crashed, is replaced.
+func New(name string, lock LeaderLock, tick time.Duration) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{name: name, lock: lock, holder: host + "/" + uuid.NewString(), tick: tick}
+}
+
+// Add schedules a job, first running it at its next scheduled time.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, &scheduledJob{Job: job, next: job.Schedule.Next(time.Now().UTC())})
+}
+
+// RunDue renews the leader lock and, if this replica holds it, runs the jobs due at now. Due jobs
+// are scheduled again either way; jobs missed while no replica was the leader are not caught up on.
+func (s *Scheduler) RunDue(now time.Time) {
+	leader := s.acquire(now)
+	for _, job := range s.jobs {
+		if job.next.IsZero() || now.Before(job.next) {
+			continue
+		}
+		job.next = job.Schedule.Next(now)
+		if !leader {
+			continue
+		}
+		if err := job.Run(now); err != nil {
+			log.Printf("Job %s failed: %v\n", job.Name, err)
+		}
+		// Renewed after every job so that a slow one does not cost the lease.
+		leader = s.acquire(time.Now().UTC())
+	}
+}
+
+// acquire takes or renews the lease for three ticks.
+func (s *Scheduler) acquire(now time.Time) bool {
+	leader, err := s.lock.Acquire(s.name, s.holder, now, now.Add(3*s.tick))
+	if err != nil {
+		log.Printf("Failed to acquire scheduler lock %s: %v\n", s.name, err)
+		return false
+	}
+	return leader
+}
+
+// Run calls RunDue every tick until the process exits.
+func (s *Scheduler) Run() {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.RunDue(now.UTC())
+	}
+}
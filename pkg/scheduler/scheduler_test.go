@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLeaderLock(t *testing.T) {
+	lock := NewMemoryLeaderLock()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name   string
+		holder string
+		at     time.Duration // Since now.
+		want   bool
+	}{
+		{name: "a free lock is taken", holder: "replica-1", want: true},
+		{name: "a held lock is refused", holder: "replica-2", at: time.Minute, want: false},
+		{name: "the holder renews it", holder: "replica-1", at: 2 * time.Minute, want: true},
+		{name: "the renewed lease still holds", holder: "replica-2", at: 4 * time.Minute, want: false},
+		{name: "an expired lease is taken over", holder: "replica-2", at: 6 * time.Minute, want: true},
+		{name: "the old holder lost it", holder: "replica-1", at: 7 * time.Minute, want: false},
+	}
+	for _, step := range steps {
+		at := now.Add(step.at)
+		got, err := lock.Acquire("jobs", step.holder, at, at.Add(3*time.Minute))
+		if err != nil {
+			t.Fatalf("%s: Acquire: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: Acquire = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	lock := NewMemoryLeaderLock()
+	start := time.Now().UTC()
+	runs := map[string]int{}
+	newReplica := func(name string) *Scheduler {
+		s := New("jobs", lock, time.Hour)
+		s.Add(Job{Name: "sweep", Schedule: Every(time.Minute), Run: func(time.Time) error {
+			runs[name]++
+			return nil
+		}})
+		return s
+	}
+	first, second := newReplica("first"), newReplica("second")
+
+	first.RunDue(start.Add(30 * time.Second))
+	second.RunDue(start.Add(30 * time.Second))
+	if len(runs) != 0 {
+		t.Fatalf("jobs ran before they were due: %v", runs)
+	}
+
+	// The jobs were added just after start, so they are due a moment after each minute.
+	for minute := 1; minute <= 2; minute++ {
+		at := start.Add(time.Duration(minute)*time.Minute + time.Second)
+		first.RunDue(at)
+		second.RunDue(at)
+	}
+	if runs["first"] != 2 || runs["second"] != 0 {
+		t.Errorf("runs = %v, want 2 on the leader only", runs)
+	}
+
+	// The leader stops renewing its lease, and the other replica takes over once it expired.
+	second.RunDue(start.Add(4 * time.Hour))
+	if runs["second"] != 1 {
+		t.Errorf("runs = %v, want the job run once by the new leader", runs)
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{spec: "*/15 * * * *", after: time.Date(2026, 6, 1, 10, 7, 0, 0, time.UTC), want: time.Date(2026, 6, 1, 10, 15, 0, 0, time.UTC)},
+		{spec: "0 8 * * 1-5", after: time.Date(2026, 6, 5, 9, 0, 0, 0, time.UTC), want: time.Date(2026, 6, 8, 8, 0, 0, 0, time.UTC)},
+		{spec: "30 2 1 * *", after: time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC), want: time.Date(2027, 1, 1, 2, 30, 0, 0, time.UTC)},
+		// Days matching either day field run when both are restricted.
+		{spec: "0 0 1,15 * 5", after: time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", after: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron: %v", err)
+			}
+			if next := schedule.Next(tt.after); !next.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, next, tt.want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * JAN *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", spec)
+		}
+	}
+}
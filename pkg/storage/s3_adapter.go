@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"mime/multipart"
 	"os"
@@ -50,3 +51,18 @@ func (s *S3Client) UploadFile(file multipart.File, fileName string) (string, err
 	}
 	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.BucketName, os.Getenv("AWS_REGION"), fileName), nil
 }
+
+// UploadDocument uploads a generated document to S3 with its content type
+func (s *S3Client) UploadDocument(body io.Reader, fileName string, contentType string) (string, error) {
+	_, err := s.Uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(s.BucketName),
+		Key:         aws.String(fileName),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		log.Printf("failed to upload document: %v", err)
+		return "", err
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.BucketName, os.Getenv("AWS_REGION"), fileName), nil
+}